@@ -30,7 +30,7 @@ func main() {
 	cmd := &cli.Command{
 		Name:  appNameGitBack,
 		Usage: "Navigate back through git checkout/switch operations",
-		Flags: shared.CommonFlags(),
+		Flags: shared.BackFlags(),
 		Action: func(ctx context.Context, c *cli.Command) error {
 			a := app.NewAppGitBack(version, versionSource)
 
@@ -42,8 +42,20 @@ func main() {
 				Verbose:     c.Bool("verbose"),
 				DryRun:      c.Bool("dry-run"),
 				HookCommand: c.String("hook"),
+				HookShell:   c.String("hook-shell"),
+				HookFailed:  c.Bool("hook-failed"),
+				PreHook:     c.String("pre-hook"),
 				ShowLog:     c.Bool("log"),
 				Args:        c.Args().Slice(),
+				BackTo:      c.String("to"),
+				Count:       c.Int("count"),
+				ListBack:    c.Bool("list"),
+				Forward:     c.Bool("forward"),
+				Complete:    c.String("complete"),
+				Accessible:  c.Bool("accessible"),
+				Repo:        c.String("repo"),
+				DebugFile:   c.String("debug-file"),
+				JSON:        c.Bool("json"),
 			})
 		},
 	}
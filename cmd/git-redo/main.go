@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/amberpixels/git-undo/cmd/shared"
+	"github.com/amberpixels/git-undo/internal/app"
+	"github.com/urfave/cli/v3"
+)
+
+// version is set by the build ldflags
+// The default value is "dev+dirty" but it should never be used. In success path, it's always overwritten.
+var version = "dev+dirty"
+var versionSource = "hardcoded"
+
+const (
+	appNameGitRedo = "git-redo"
+)
+
+func main() {
+	// Create a context that can be cancelled with Ctrl+C
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	version, versionSource = app.HandleAppVersion(version, versionSource)
+
+	cmd := &cli.Command{
+		Name:  appNameGitRedo,
+		Usage: "Redo the git commands undone by git undo",
+		Flags: shared.RedoFlags(),
+		Action: func(ctx context.Context, c *cli.Command) error {
+			a := app.NewAppGitRedo(version, versionSource)
+
+			if c.Bool("version") {
+				return a.HandleVersion(ctx, c.Bool("verbose"))
+			}
+
+			return a.Run(ctx, app.RunOptions{
+				Verbose:     c.Bool("verbose"),
+				DryRun:      c.Bool("dry-run"),
+				HookCommand: c.String("hook"),
+				HookShell:   c.String("hook-shell"),
+				HookFailed:  c.Bool("hook-failed"),
+				PreHook:     c.String("pre-hook"),
+				ShowLog:     c.Bool("log"),
+				Args:        c.Args().Slice(),
+				Count:       c.Int("count"),
+				Complete:    c.String("complete"),
+				Accessible:  c.Bool("accessible"),
+				Repo:        c.String("repo"),
+				DebugFile:   c.String("debug-file"),
+				JSON:        c.Bool("json"),
+
+				PreserveMetadata: c.Bool("preserve-metadata"),
+				RedoAll:          c.Bool("all"),
+				Session:          c.Bool("session"),
+			})
+		},
+	}
+
+	if err := cmd.Run(ctx, os.Args); err != nil {
+		app.HandleError(appNameGitRedo, err)
+	}
+}
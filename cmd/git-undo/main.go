@@ -32,20 +32,72 @@ func main() {
 		Usage:                     "Universal \"Ctrl + Z\" for Git commands",
 		DisableSliceFlagSeparator: true,
 		HideHelp:                  true,
-		Flags:                     shared.CommonFlags(),
+		Flags:                     shared.UndoFlags(),
 		Action: func(ctx context.Context, c *cli.Command) error {
 			application := app.NewAppGitUndo(version, versionSource)
 			if c.Bool("version") {
 				return application.HandleVersion(ctx, c.Bool("verbose"))
 			}
 
+			commitMode, err := shared.CommitMode(c)
+			if err != nil {
+				return err
+			}
+
 			// Use the new structured approach with parsed options
 			opts := app.RunOptions{
 				Verbose:     c.Bool("verbose"),
 				DryRun:      c.Bool("dry-run"),
 				HookCommand: c.String("hook"),
+				HookShell:   c.String("hook-shell"),
+				HookFailed:  c.Bool("hook-failed"),
+				PreHook:     c.String("pre-hook"),
 				ShowLog:     c.Bool("log"),
 				Args:        c.Args().Slice(),
+				EntryID:     c.String("id"),
+				At:          c.String("at"),
+				Interactive: c.Bool("interactive"),
+				Since:       c.String("since"),
+				Count:       c.Int("count"),
+				Complete:    c.String("complete"),
+
+				PreserveMetadata: c.Bool("preserve-metadata"),
+				Session:          c.Bool("session"),
+				MessageOnly:      c.Bool("message-only"),
+				CommitMode:       commitMode,
+				Force:            c.Bool("force"),
+				LimitPaths:       c.StringSlice("limit-paths"),
+				DiffFrom:         c.String("from"),
+				DiffTo:           c.String("to"),
+				Simulate:         c.Bool("simulate"),
+				ListSnapshots:    c.Bool("list-snapshots"),
+				Accessible:       c.Bool("accessible"),
+				Fix:              c.Bool("fix"),
+				Repo:             c.String("repo"),
+				DebugFile:        c.String("debug-file"),
+				Worktree:         c.String("worktree"),
+				Shell:            c.String("shell"),
+				JSON:             c.Bool("json"),
+				Porcelain:        c.Bool("porcelain"),
+				Pretty:           c.Bool("pretty"),
+				History: app.HistoryQuery{
+					Branch:   c.String("branch"),
+					Worktree: c.String("worktree"),
+					Author:   c.String("author"),
+					Since:    c.String("since"),
+					Until:    c.String("until"),
+					GroupBy:  c.String("group-by"),
+				},
+				Log: app.LogQuery{
+					Ref:      c.String("ref"),
+					AllRefs:  c.Bool("all-refs"),
+					Global:   c.Bool("global"),
+					Type:     c.String("type"),
+					Undone:   c.Bool("undone"),
+					Since:    c.String("since"),
+					Count:    c.Int("count"),
+					Worktree: c.String("worktree"),
+				},
 			}
 
 			return application.Run(ctx, opts)
@@ -1,10 +1,12 @@
 package shared
 
 import (
+	"errors"
+
 	"github.com/urfave/cli/v3"
 )
 
-// CommonFlags returns the standard set of CLI flags used by both git-undo and git-back commands.
+// CommonFlags returns the standard set of CLI flags used by git-undo, git-back and git-redo commands.
 func CommonFlags() []cli.Flag {
 	return []cli.Flag{
 		&cli.BoolFlag{
@@ -18,8 +20,9 @@ func CommonFlags() []cli.Flag {
 			Usage:   "Enable verbose output",
 		},
 		&cli.BoolFlag{
-			Name:  "dry-run",
-			Usage: "Show what would be executed without running commands",
+			Name:    "dry-run",
+			Aliases: []string{"preview"},
+			Usage:   "Show the undo plan (commands, what they do and warnings) without running it",
 		},
 		&cli.BoolFlag{
 			Name:  "version",
@@ -29,9 +32,241 @@ func CommonFlags() []cli.Flag {
 			Name:  "hook",
 			Usage: "Hook command for shell integration (internal use)",
 		},
+		&cli.StringFlag{
+			Name:   "pre-hook",
+			Usage:  "Capture the state a hooked command is about to change, right before it runs (internal use)",
+			Hidden: true,
+		},
+		&cli.StringFlag{
+			Name:   "hook-shell",
+			Usage:  "Shell the hooked command was typed in, to split it by its quoting rules (internal use)",
+			Hidden: true,
+		},
+		&cli.BoolFlag{
+			Name:   "hook-failed",
+			Usage:  "The hooked command failed (internal use)",
+			Hidden: true,
+		},
+		&cli.StringFlag{
+			Name:    "repo",
+			Aliases: []string{"C"},
+			Usage:   "Run as if started in `PATH` instead of the current directory (like git -C)",
+		},
 		&cli.BoolFlag{
 			Name:  "log",
 			Usage: "Display the git-undo command log",
 		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "With self info, log and stats: print the report as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "accessible",
+			Usage: "Screen reader friendly output: no colors or emoji, states spelled out in words",
+		},
+		&cli.StringFlag{
+			Name:  "debug-file",
+			Usage: "Write detailed traces to `FILE` (see also the git-undo.debugLog config)",
+		},
+		&cli.StringFlag{
+			Name:   "complete",
+			Usage:  "Shell completion query (internal use)",
+			Hidden: true,
+		},
+	}
+}
+
+// UndoFlags returns the CLI flags of the git-undo command.
+func UndoFlags() []cli.Flag {
+	return append(CommonFlags(),
+		&cli.StringFlag{
+			Name:  "id",
+			Usage: "Undo the log entry with the given ID instead of the latest one",
+		},
+		&cli.StringFlag{
+			Name:  "at",
+			Usage: "Undo the log entry at the `INDEX` in the log (1 is the latest) or with the given identifier",
+		},
+		&cli.BoolFlag{
+			Name:    "interactive",
+			Aliases: []string{"i"},
+			Usage:   "Pick the entries to undo from the latest ones",
+		},
+		&cli.IntFlag{
+			Name:    "count",
+			Aliases: []string{"n"},
+			Usage:   "Undo the latest `N` commands at once (with log: show at most N entries)",
+		},
+		&cli.BoolFlag{
+			Name:  "preserve-metadata",
+			Usage: "On redo, restore the undone commit exactly (author, dates, signature) instead of re-committing",
+		},
+		&cli.BoolFlag{
+			Name:  "session",
+			Usage: "Only consider the commands run from the current shell session (not from other terminals)",
+		},
+		&cli.BoolFlag{
+			Name:  "message-only",
+			Usage: "When undoing git commit --amend: restore only the previous message, keeping the amended changes",
+		},
+		&cli.BoolFlag{
+			Name:  "soft",
+			Usage: "When undoing a commit: keep its changes staged (the default)",
+		},
+		&cli.BoolFlag{
+			Name:  "unstage",
+			Usage: "When undoing a commit: leave its changes in the working tree, unstaged",
+		},
+		&cli.BoolFlag{
+			Name:  "hard",
+			Usage: "When undoing a commit: discard it along with its changes",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "Rewrite commits that are already pushed instead of reverting them (or refusing to)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "limit-paths",
+			Usage: "Undo a path-wide operation (e.g. git add .) only for paths matching the `GLOB` (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:  "shell",
+			Usage: "With self install-hooks: the `SHELL` to install the hook of (bash, zsh, fish or powershell)",
+		},
+		&cli.BoolFlag{
+			Name:  "list-snapshots",
+			Usage: "List the snapshots of files taken before destructive commands (e.g. git clean)",
+		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "Run the undo in a temporary copy of the repository and show the resulting state",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "With diff: the log entry `ID` whose state the diff starts from",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "With diff: the log entry `ID` whose state the diff ends at (the current state by default)",
+		},
+		&cli.BoolFlag{
+			Name:  "fix",
+			Usage: "With doctor: repair the found problems (files owned by root need it run via sudo)",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "With history: show the entries logged on the `REF`",
+		},
+		&cli.StringFlag{
+			Name: "worktree",
+			Usage: "Show the log of (or undo in) the worktree at `PATH`; \"all\" shows all worktrees' logs " +
+				"and undoes the latest command of any worktree",
+		},
+		&cli.StringFlag{
+			Name:  "author",
+			Usage: "With history: show the entries of users whose name or email contains `TEXT`",
+		},
+		&cli.StringFlag{
+			Name: "since",
+			Usage: "Undo every command logged since the `DATE` (e.g. 2025-01-31 15:04) or a duration ago (10m); " +
+				"with history, log and stats: show the entries logged since then",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "With history: show the entries logged before the `DATE` or a duration ago",
+		},
+		&cli.StringFlag{
+			Name:  "group-by",
+			Usage: "With history: group the entries by branch, worktree, author or day",
+		},
+		&cli.StringFlag{
+			Name:  "ref",
+			Usage: "With log: show the entries logged on the `REF` (the current one by default)",
+		},
+		&cli.BoolFlag{
+			Name:  "all-refs",
+			Usage: "With log: show the entries of all refs",
+		},
+		&cli.BoolFlag{
+			Name:  "global",
+			Usage: "With log: show the commands of every repository, from the global journal (git-undo.globalJournal)",
+		},
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "With log: show mutation, navigation or failed entries only",
+		},
+		&cli.BoolFlag{
+			Name:  "undone",
+			Usage: "With log: show undone entries only",
+		},
+		&cli.BoolFlag{
+			Name:  "pretty",
+			Usage: "With log: print the entries in colors, with relative times and what undoing each would do",
+		},
+		&cli.BoolFlag{
+			Name:  "porcelain",
+			Usage: "With log: print the entries as tab-separated lines for scripts",
+		},
+	)
+}
+
+// BackFlags returns the CLI flags of the git-back command.
+func BackFlags() []cli.Flag {
+	return append(CommonFlags(),
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Go back to the given ref from the navigation history",
+		},
+		&cli.IntFlag{
+			Name:    "count",
+			Aliases: []string{"n"},
+			Usage:   "Go back `N` steps of the navigation history at once",
+		},
+		&cli.BoolFlag{
+			Name:  "list",
+			Usage: "List the navigation history with the steps to go back to each ref",
+		},
+		&cli.BoolFlag{
+			Name:  "forward",
+			Usage: "Go forward again to where git back went back from (with -n: N steps)",
+		},
+	)
+}
+
+// RedoFlags returns the CLI flags of the git-redo command.
+func RedoFlags() []cli.Flag {
+	return append(CommonFlags(),
+		&cli.IntFlag{
+			Name:    "count",
+			Aliases: []string{"n"},
+			Usage:   "Redo the latest `N` undone commands at once",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "Redo every command undone since the last regular one, in their original order",
+		},
+		&cli.BoolFlag{
+			Name:  "preserve-metadata",
+			Usage: "Restore the undone commit exactly (author, dates, signature) instead of re-committing",
+		},
+		&cli.BoolFlag{
+			Name:  "session",
+			Usage: "Only consider the commands run from the current shell session (not from other terminals)",
+		},
+	)
+}
+
+// CommitMode returns the commit undo mode chosen by the --soft, --unstage or --hard flag (empty if none).
+func CommitMode(c *cli.Command) (string, error) {
+	var mode string
+	for _, flag := range []string{"soft", "unstage", "hard"} {
+		if !c.Bool(flag) {
+			continue
+		}
+		if mode != "" {
+			return "", errors.New("only one of --soft, --unstage and --hard can be given")
+		}
+		mode = flag
 	}
+	return mode, nil
 }
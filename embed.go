@@ -19,3 +19,67 @@ func GetUpdateScript() string {
 func GetUninstallScript() string {
 	return uninstallScript
 }
+
+//go:embed scripts/git-undo-hook.bash
+var bashHook string
+
+//go:embed scripts/git-undo-hook.zsh
+var zshHook string
+
+//go:embed scripts/git-undo-git-hook.sh
+var gitHook string
+
+//go:embed scripts/git-undo-hook.fish
+var fishHook string
+
+//go:embed scripts/git-undo-hook.ps1
+var powerShellHook string
+
+// GetBashHook returns the embedded bash hook script content.
+func GetBashHook() string {
+	return bashHook
+}
+
+// GetZshHook returns the embedded zsh hook script content.
+func GetZshHook() string {
+	return zshHook
+}
+
+//go:embed scripts/git-undo-completion.bash
+var bashCompletion string
+
+//go:embed scripts/git-undo-completion.zsh
+var zshCompletion string
+
+//go:embed scripts/git-undo-completion.fish
+var fishCompletion string
+
+// GetBashCompletion returns the embedded bash completion script content.
+func GetBashCompletion() string {
+	return bashCompletion
+}
+
+// GetZshCompletion returns the embedded zsh completion script content.
+func GetZshCompletion() string {
+	return zshCompletion
+}
+
+// GetFishCompletion returns the embedded fish completion script content.
+func GetFishCompletion() string {
+	return fishCompletion
+}
+
+// GetFishHook returns the embedded fish hook script content.
+func GetFishHook() string {
+	return fishHook
+}
+
+// GetPowerShellHook returns the embedded PowerShell hook script content.
+func GetPowerShellHook() string {
+	return powerShellHook
+}
+
+// GetGitHook returns the embedded git hooks dispatcher script content.
+func GetGitHook() string {
+	return gitHook
+}
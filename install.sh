@@ -3,9 +3,9 @@
 # DO NOT EDIT - modify scripts/src/*.src.sh instead and run 'make buildscripts'
 
 # ── Embedded hook files ── that's a base64 of scripts/git-undo-hook.bash ────
-EMBEDDED_BASH_HOOK='IyBWYXJpYWJsZSB0byBzdG9yZSB0aGUgZ2l0IGNvbW1hbmQgdGVtcG9yYXJpbHkKR0lUX0NPTU1BTkRfVE9fTE9HPSIiCgojIEZ1bmN0aW9uIHRvIHN0b3JlIHRoZSBnaXQgY29tbWFuZCB0ZW1wb3JhcmlseQpzdG9yZV9naXRfY29tbWFuZCgpIHsKICBsb2NhbCByYXdfY21kPSIkMSIKICBsb2NhbCBoZWFkPSR7cmF3X2NtZCUlICp9CiAgbG9jYWwgcmVzdD0ke3Jhd19jbWQjIiRoZWFkIn0KCiAgIyBDaGVjayBpZiB0aGUgY29tbWFuZCBpcyBhbiBhbGlhcyBhbmQgZXhwYW5kIGl0CiAgaWYgYWxpYXMgIiRoZWFkIiAmPi9kZXYvbnVsbDsgdGhlbgogICAgbG9jYWwgZGVmCiAgICBkZWY9JChhbGlhcyAiJGhlYWQiKQogICAgIyBFeHRyYWN0IHRoZSBleHBhbnNpb24gZnJvbSBhbGlhcyBvdXRwdXQgKGZvcm1hdDogYWxpYXMgbmFtZT0nZXhwYW5zaW9uJykKICAgIGxvY2FsIGV4cGFuc2lvbj0ke2RlZiMqXCd9CiAgICBleHBhbnNpb249JHtleHBhbnNpb24lXCd9CiAgICByYXdfY21kPSIke2V4cGFuc2lvbn0ke3Jlc3R9IgogIGZpCgogICMgT25seSBzdG9yZSBpZiBpdCdzIGEgZ2l0IGNvbW1hbmQKICBbWyAiJHJhd19jbWQiID09IGdpdFwgKiBdXSB8fCByZXR1cm4KICBHSVRfQ09NTUFORF9UT19MT0c9IiRyYXdfY21kIgp9CgojIEZ1bmN0aW9uIHRvIGxvZyB0aGUgY29tbWFuZCBvbmx5IGlmIGl0IHdhcyBzdWNjZXNzZnVsCmxvZ19zdWNjZXNzZnVsX2dpdF9jb21tYW5kKCkgewogICMgQ2hlY2sgaWYgd2UgaGF2ZSBhIGdpdCBjb21tYW5kIHRvIGxvZyBhbmQgaWYgdGhlIHByZXZpb3VzIGNvbW1hbmQgd2FzIHN1Y2Nlc3NmdWwKICBpZiBbWyAtbiAiJEdJVF9DT01NQU5EX1RPX0xPRyIgJiYgJD8gLWVxIDAgXV07IHRoZW4KICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iJEdJVF9DT01NQU5EX1RPX0xPRyIKICBmaQogICMgQ2xlYXIgdGhlIHN0b3JlZCBjb21tYW5kCiAgR0lUX0NPTU1BTkRfVE9fTE9HPSIiCn0KCiMgdHJhcCBkb2VzIHRoZSBhY3R1YWwgaG9va2luZzogbWFraW5nIGFuIGV4dHJhIGdpdC11bmRvIGNhbGwgZm9yIGV2ZXJ5IGdpdCBjb21tYW5kLgp0cmFwICdzdG9yZV9naXRfY29tbWFuZCAiJEJBU0hfQ09NTUFORCInIERFQlVHCgojIFNldCB1cCBQUk9NUFRfQ09NTUFORCB0byBsb2cgc3VjY2Vzc2Z1bCBjb21tYW5kcyBhZnRlciBleGVjdXRpb24KaWYgW1sgLXogIiRQUk9NUFRfQ09NTUFORCIgXV07IHRoZW4KICBQUk9NUFRfQ09NTUFORD0ibG9nX3N1Y2Nlc3NmdWxfZ2l0X2NvbW1hbmQiCmVsc2UKICBQUk9NUFRfQ09NTUFORD0iJFBST01QVF9DT01NQU5EOyBsb2dfc3VjY2Vzc2Z1bF9naXRfY29tbWFuZCIKZmk='
-EMBEDDED_BASH_TEST_HOOK='IyBWYXJpYWJsZSB0byBzdG9yZSB0aGUgZ2l0IGNvbW1hbmQgdGVtcG9yYXJpbHkKR0lUX0NPTU1BTkRfVE9fTE9HPSIiCgojIEZ1bmN0aW9uIHRvIHN0b3JlIHRoZSBnaXQgY29tbWFuZCB0ZW1wb3JhcmlseQpzdG9yZV9naXRfY29tbWFuZCgpIHsKICBsb2NhbCByYXdfY21kPSIkMSIKICBsb2NhbCBoZWFkPSR7cmF3X2NtZCUlICp9CiAgbG9jYWwgcmVzdD0ke3Jhd19jbWQjIiRoZWFkIn0KCiAgIyBDaGVjayBpZiB0aGUgY29tbWFuZCBpcyBhbiBhbGlhcyBhbmQgZXhwYW5kIGl0CiAgaWYgYWxpYXMgIiRoZWFkIiAmPi9kZXYvbnVsbDsgdGhlbgogICAgbG9jYWwgZGVmCiAgICBkZWY9JChhbGlhcyAiJGhlYWQiKQogICAgIyBFeHRyYWN0IHRoZSBleHBhbnNpb24gZnJvbSBhbGlhcyBvdXRwdXQgKGZvcm1hdDogYWxpYXMgbmFtZT0nZXhwYW5zaW9uJykKICAgIGxvY2FsIGV4cGFuc2lvbj0ke2RlZiMqXCd9CiAgICBleHBhbnNpb249JHtleHBhbnNpb24lXCd9CiAgICByYXdfY21kPSIke2V4cGFuc2lvbn0ke3Jlc3R9IgogIGZpCgogICMgT25seSBzdG9yZSBpZiBpdCdzIGEgZ2l0IGNvbW1hbmQKICBbWyAiJHJhd19jbWQiID09IGdpdFwgKiBdXSB8fCByZXR1cm4KICBHSVRfQ09NTUFORF9UT19MT0c9IiRyYXdfY21kIgp9CgojIEZ1bmN0aW9uIHRvIGxvZyB0aGUgY29tbWFuZCBvbmx5IGlmIGl0IHdhcyBzdWNjZXNzZnVsCmxvZ19zdWNjZXNzZnVsX2dpdF9jb21tYW5kKCkgewogICMgQ2hlY2sgaWYgd2UgaGF2ZSBhIGdpdCBjb21tYW5kIHRvIGxvZyBhbmQgaWYgdGhlIHByZXZpb3VzIGNvbW1hbmQgd2FzIHN1Y2Nlc3NmdWwKICBpZiBbWyAtbiAiJEdJVF9DT01NQU5EX1RPX0xPRyIgJiYgJD8gLWVxIDAgXV07IHRoZW4KICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iJEdJVF9DT01NQU5EX1RPX0xPRyIKICBmaQogICMgQ2xlYXIgdGhlIHN0b3JlZCBjb21tYW5kCiAgR0lUX0NPTU1BTkRfVE9fTE9HPSIiCn0KCgojIFRlc3QgbW9kZTogcHJvdmlkZSBhIG1hbnVhbCB3YXkgdG8gY2FwdHVyZSBjb21tYW5kcwojIFRoaXMgaXMgb25seSB1c2VkIGZvciBpbnRlZ3JhdGlvbi10ZXN0LmJhdHMuIApnaXQoKSB7CiAgICBjb21tYW5kIGdpdCAiJEAiCiAgICBsb2NhbCBleGl0X2NvZGU9JD8KICAgIGlmIFtbICRleGl0X2NvZGUgLWVxIDAgXV07IHRoZW4KICAgICAgICBHSVRfVU5ET19JTlRFUk5BTF9IT09LPTEgY29tbWFuZCBnaXQtdW5kbyAtLWhvb2s9ImdpdCAkKiIKICAgIGZpCiAgICByZXR1cm4gJGV4aXRfY29kZQp9CgoKIyBTZXQgdXAgUFJPTVBUX0NPTU1BTkQgdG8gbG9nIHN1Y2Nlc3NmdWwgY29tbWFuZHMgYWZ0ZXIgZXhlY3V0aW9uCmlmIFtbIC16ICIkUFJPTVBUX0NPTU1BTkQiIF1dOyB0aGVuCiAgUFJPTVBUX0NPTU1BTkQ9ImxvZ19zdWNjZXNzZnVsX2dpdF9jb21tYW5kIgplbHNlCiAgUFJPTVBUX0NPTU1BTkQ9IiRQUk9NUFRfQ09NTUFORDsgbG9nX3N1Y2Nlc3NmdWxfZ2l0X2NvbW1hbmQiCmZpCg=='
-EMBEDDED_ZSH_HOOK='IyEvdXNyL2Jpbi9lbnYgenNoCiMgc2hlbGxjaGVjayBkaXNhYmxlPWFsbAojIEZ1bmN0aW9uIHRvIHN0b3JlIHRoZSBnaXQgY29tbWFuZCB0ZW1wb3JhcmlseQpzdG9yZV9naXRfY29tbWFuZCgpIHsKICBsb2NhbCByYXdfY21kPSIkMSIKICBsb2NhbCBoZWFkPSR7cmF3X2NtZCUlICp9CiAgbG9jYWwgcmVzdD0ke3Jhd19jbWQjIiRoZWFkIn0KICBpZiBhbGlhcyAiJGhlYWQiICY+L2Rldi9udWxsOyB0aGVuCiAgICBsb2NhbCBkZWYKICAgIGRlZj0kKGFsaWFzICIkaGVhZCIpCiAgICBsb2NhbCBleHBhbnNpb249JHtkZWYjKlwnfQogICAgZXhwYW5zaW9uPSR7ZXhwYW5zaW9uJVwnfQogICAgcmF3X2NtZD0iJHtleHBhbnNpb259JHtyZXN0fSIKICBmaQogIFtbICIkcmF3X2NtZCIgPT0gZ2l0XCAqIF1dIHx8IHJldHVybgogIEdJVF9DT01NQU5EX1RPX0xPRz0iJHJhd19jbWQiCn0KCiMgRnVuY3Rpb24gdG8gbG9nIHRoZSBjb21tYW5kIG9ubHkgaWYgaXQgd2FzIHN1Y2Nlc3NmdWwKbG9nX3N1Y2Nlc3NmdWxfZ2l0X2NvbW1hbmQoKSB7CiAgIyBDaGVjayBpZiB3ZSBoYXZlIGEgZ2l0IGNvbW1hbmQgdG8gbG9nIGFuZCBpZiB0aGUgcHJldmlvdXMgY29tbWFuZCB3YXMgc3VjY2Vzc2Z1bAogIGlmIFtbIC1uICIkR0lUX0NPTU1BTkRfVE9fTE9HIiAmJiAkPyAtZXEgMCBdXTsgdGhlbgogICAgR0lUX1VORE9fSU5URVJOQUxfSE9PSz0xIGNvbW1hbmQgZ2l0LXVuZG8gLS1ob29rPSIkR0lUX0NPTU1BTkRfVE9fTE9HIgogIGZpCiAgIyBDbGVhciB0aGUgc3RvcmVkIGNvbW1hbmQKICBHSVRfQ09NTUFORF9UT19MT0c9IiIKfQoKYXV0b2xvYWQgLVUgYWRkLXpzaC1ob29rCmFkZC16c2gtaG9vayBwcmVleGVjIHN0b3JlX2dpdF9jb21tYW5kCmFkZC16c2gtaG9vayBwcmVjbWQgbG9nX3N1Y2Nlc3NmdWxfZ2l0X2NvbW1hbmQK'
+EMBEDDED_BASH_HOOK='IyBJRCBvZiB0aGlzIHNoZWxsIHNlc3Npb24sIHJlY29yZGVkIHdpdGggdGhlIGxvZ2dlZCBjb21tYW5kczogYGdpdCB1bmRvIC0tc2Vzc2lvbmAgb25seSBjb25zaWRlcnMgaXRzIG93bi4KIyBJdCdzIGtlcHQgd2hlbiB0aGUgaG9vayBpcyBzb3VyY2VkIGFnYWluLCByZW5ld2VkIGluIG5lc3RlZCBzaGVsbHMgKHRoZWlyIFBJRCBkaWZmZXJzKS4KW1sgIiR7R0lUX1VORE9fU0VTU0lPTiUlLSp9IiA9PSAiJCQiIF1dIHx8IGV4cG9ydCBHSVRfVU5ET19TRVNTSU9OPSIkJC0kKGRhdGUgKyVzKSIKCiMgVmFyaWFibGUgdG8gc3RvcmUgdGhlIGdpdCBjb21tYW5kIHRlbXBvcmFyaWx5CkdJVF9DT01NQU5EX1RPX0xPRz0iIgoKIyBGdW5jdGlvbiB0byBzdG9yZSB0aGUgZ2l0IGNvbW1hbmQgdGVtcG9yYXJpbHkKc3RvcmVfZ2l0X2NvbW1hbmQoKSB7CiAgbG9jYWwgcmF3X2NtZD0iJDEiCiAgbG9jYWwgaGVhZD0ke3Jhd19jbWQlJSAqfQogIGxvY2FsIHJlc3Q9JHtyYXdfY21kIyIkaGVhZCJ9CgogICMgQ2hlY2sgaWYgdGhlIGNvbW1hbmQgaXMgYW4gYWxpYXMgYW5kIGV4cGFuZCBpdAogIGlmIGFsaWFzICIkaGVhZCIgJj4vZGV2L251bGw7IHRoZW4KICAgIGxvY2FsIGRlZgogICAgZGVmPSQoYWxpYXMgIiRoZWFkIikKICAgICMgRXh0cmFjdCB0aGUgZXhwYW5zaW9uIGZyb20gYWxpYXMgb3V0cHV0IChmb3JtYXQ6IGFsaWFzIG5hbWU9J2V4cGFuc2lvbicpCiAgICBsb2NhbCBleHBhbnNpb249JHtkZWYjKlwnfQogICAgZXhwYW5zaW9uPSR7ZXhwYW5zaW9uJVwnfQogICAgcmF3X2NtZD0iJHtleHBhbnNpb259JHtyZXN0fSIKICBmaQoKICAjIE9ubHkgc3RvcmUgaWYgaXQncyBhIGdpdCBjb21tYW5kCiAgW1sgIiRyYXdfY21kIiA9PSBnaXRcICogXV0gfHwgcmV0dXJuCiAgR0lUX0NPTU1BTkRfVE9fTE9HPSIkcmF3X2NtZCIKCiAgIyBTb21lIGNvbW1hbmRzIG5lZWQgdGhlIHN0YXRlIHRoZXkgY2hhbmdlIHJlY29yZGVkIGJlZm9yZWhhbmQgKGUuZy4gYSBjb25maWcgdmFsdWUgb3IgdGhlIGZpbGVzIGdpdCBjbGVhbiByZW1vdmVzKQogIGNhc2UgIiRyYXdfY21kIiBpbgogIGdpdFwgYWRkXCAqIHwgZ2l0XCBicmFuY2hcICogfCBnaXRcIGNvbmZpZ1wgKiB8IGdpdFwgcmVtb3RlXCAqIHwgZ2l0XCBzdGFzaFwgKiB8IGdpdFwgdGFnXCAqIHwgZ2l0XCBjbGVhblwgKiB8IGdpdFwgcmVzZXRcICogfCBnaXRcIGNoZWNrb3V0XCAqKQogICAgR0lUX1VORE9fSU5URVJOQUxfSE9PSz0xIGNvbW1hbmQgZ2l0LXVuZG8gLS1wcmUtaG9vaz0iJHJhd19jbWQiIC0taG9vay1zaGVsbD1iYXNoCiAgICA7OwogIGVzYWMKfQoKIyBGdW5jdGlvbiB0byBsb2cgdGhlIGNvbW1hbmQgb25jZSBpdCByYW46IGZhaWxlZCBjb21tYW5kcyBhcmUgb25seSBsb2dnZWQgKGFzIHN1Y2gpIGlmIGdpdC11bmRvLmxvZ2ZhaWxlZCBpcyBzZXQKbG9nX2dpdF9jb21tYW5kKCkgewogIGxvY2FsIGV4aXRfY29kZT0kPwogIGlmIFtbIC1uICIkR0lUX0NPTU1BTkRfVE9fTE9HIiAmJiAkZXhpdF9jb2RlIC1lcSAwIF1dOyB0aGVuCiAgICBHSVRfVU5ET19JTlRFUk5BTF9IT09LPTEgY29tbWFuZCBnaXQtdW5kbyAtLWhvb2s9IiRHSVRfQ09NTUFORF9UT19MT0ciIC0taG9vay1zaGVsbD1iYXNoCiAgZWxpZiBbWyAtbiAiJEdJVF9DT01NQU5EX1RPX0xPRyIgXV07IHRoZW4KICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iJEdJVF9DT01NQU5EX1RPX0xPRyIgLS1ob29rLXNoZWxsPWJhc2ggLS1ob29rLWZhaWxlZAogIGZpCiAgIyBDbGVhciB0aGUgc3RvcmVkIGNvbW1hbmQKICBHSVRfQ09NTUFORF9UT19MT0c9IiIKfQoKIyB0cmFwIGRvZXMgdGhlIGFjdHVhbCBob29raW5nOiBtYWtpbmcgYW4gZXh0cmEgZ2l0LXVuZG8gY2FsbCBmb3IgZXZlcnkgZ2l0IGNvbW1hbmQuCnRyYXAgJ3N0b3JlX2dpdF9jb21tYW5kICIkQkFTSF9DT01NQU5EIicgREVCVUcKCiMgU2V0IHVwIFBST01QVF9DT01NQU5EIHRvIGxvZyBzdWNjZXNzZnVsIGNvbW1hbmRzIGFmdGVyIGV4ZWN1dGlvbgppZiBbWyAteiAiJFBST01QVF9DT01NQU5EIiBdXTsgdGhlbgogIFBST01QVF9DT01NQU5EPSJsb2dfZ2l0X2NvbW1hbmQiCmVsc2UKICBQUk9NUFRfQ09NTUFORD0iJFBST01QVF9DT01NQU5EOyBsb2dfZ2l0X2NvbW1hbmQiCmZpCg=='
+EMBEDDED_BASH_TEST_HOOK='IyBJRCBvZiB0aGlzIHNoZWxsIHNlc3Npb24sIHJlY29yZGVkIHdpdGggdGhlIGxvZ2dlZCBjb21tYW5kczogYGdpdCB1bmRvIC0tc2Vzc2lvbmAgb25seSBjb25zaWRlcnMgaXRzIG93bi4KIyBJdCdzIGtlcHQgd2hlbiB0aGUgaG9vayBpcyBzb3VyY2VkIGFnYWluLCByZW5ld2VkIGluIG5lc3RlZCBzaGVsbHMgKHRoZWlyIFBJRCBkaWZmZXJzKS4KW1sgIiR7R0lUX1VORE9fU0VTU0lPTiUlLSp9IiA9PSAiJCQiIF1dIHx8IGV4cG9ydCBHSVRfVU5ET19TRVNTSU9OPSIkJC0kKGRhdGUgKyVzKSIKCiMgVmFyaWFibGUgdG8gc3RvcmUgdGhlIGdpdCBjb21tYW5kIHRlbXBvcmFyaWx5CkdJVF9DT01NQU5EX1RPX0xPRz0iIgoKIyBGdW5jdGlvbiB0byBzdG9yZSB0aGUgZ2l0IGNvbW1hbmQgdGVtcG9yYXJpbHkKc3RvcmVfZ2l0X2NvbW1hbmQoKSB7CiAgbG9jYWwgcmF3X2NtZD0iJDEiCiAgbG9jYWwgaGVhZD0ke3Jhd19jbWQlJSAqfQogIGxvY2FsIHJlc3Q9JHtyYXdfY21kIyIkaGVhZCJ9CgogICMgQ2hlY2sgaWYgdGhlIGNvbW1hbmQgaXMgYW4gYWxpYXMgYW5kIGV4cGFuZCBpdAogIGlmIGFsaWFzICIkaGVhZCIgJj4vZGV2L251bGw7IHRoZW4KICAgIGxvY2FsIGRlZgogICAgZGVmPSQoYWxpYXMgIiRoZWFkIikKICAgICMgRXh0cmFjdCB0aGUgZXhwYW5zaW9uIGZyb20gYWxpYXMgb3V0cHV0IChmb3JtYXQ6IGFsaWFzIG5hbWU9J2V4cGFuc2lvbicpCiAgICBsb2NhbCBleHBhbnNpb249JHtkZWYjKlwnfQogICAgZXhwYW5zaW9uPSR7ZXhwYW5zaW9uJVwnfQogICAgcmF3X2NtZD0iJHtleHBhbnNpb259JHtyZXN0fSIKICBmaQoKICAjIE9ubHkgc3RvcmUgaWYgaXQncyBhIGdpdCBjb21tYW5kCiAgW1sgIiRyYXdfY21kIiA9PSBnaXRcICogXV0gfHwgcmV0dXJuCiAgR0lUX0NPTU1BTkRfVE9fTE9HPSIkcmF3X2NtZCIKCiAgIyBTb21lIGNvbW1hbmRzIG5lZWQgdGhlIHN0YXRlIHRoZXkgY2hhbmdlIHJlY29yZGVkIGJlZm9yZWhhbmQgKGUuZy4gYSBjb25maWcgdmFsdWUgb3IgdGhlIGZpbGVzIGdpdCBjbGVhbiByZW1vdmVzKQogIGNhc2UgIiRyYXdfY21kIiBpbgogIGdpdFwgYWRkXCAqIHwgZ2l0XCBicmFuY2hcICogfCBnaXRcIGNvbmZpZ1wgKiB8IGdpdFwgcmVtb3RlXCAqIHwgZ2l0XCBzdGFzaFwgKiB8IGdpdFwgdGFnXCAqIHwgZ2l0XCBjbGVhblwgKiB8IGdpdFwgcmVzZXRcICogfCBnaXRcIGNoZWNrb3V0XCAqKQogICAgR0lUX1VORE9fSU5URVJOQUxfSE9PSz0xIGNvbW1hbmQgZ2l0LXVuZG8gLS1wcmUtaG9vaz0iJHJhd19jbWQiIC0taG9vay1zaGVsbD1iYXNoCiAgICA7OwogIGVzYWMKfQoKIyBGdW5jdGlvbiB0byBsb2cgdGhlIGNvbW1hbmQgb25jZSBpdCByYW46IGZhaWxlZCBjb21tYW5kcyBhcmUgb25seSBsb2dnZWQgKGFzIHN1Y2gpIGlmIGdpdC11bmRvLmxvZ2ZhaWxlZCBpcyBzZXQKbG9nX2dpdF9jb21tYW5kKCkgewogIGxvY2FsIGV4aXRfY29kZT0kPwogIGlmIFtbIC1uICIkR0lUX0NPTU1BTkRfVE9fTE9HIiAmJiAkZXhpdF9jb2RlIC1lcSAwIF1dOyB0aGVuCiAgICBHSVRfVU5ET19JTlRFUk5BTF9IT09LPTEgY29tbWFuZCBnaXQtdW5kbyAtLWhvb2s9IiRHSVRfQ09NTUFORF9UT19MT0ciIC0taG9vay1zaGVsbD1iYXNoCiAgZWxpZiBbWyAtbiAiJEdJVF9DT01NQU5EX1RPX0xPRyIgXV07IHRoZW4KICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iJEdJVF9DT01NQU5EX1RPX0xPRyIgLS1ob29rLXNoZWxsPWJhc2ggLS1ob29rLWZhaWxlZAogIGZpCiAgIyBDbGVhciB0aGUgc3RvcmVkIGNvbW1hbmQKICBHSVRfQ09NTUFORF9UT19MT0c9IiIKfQoKCiMgVGVzdCBtb2RlOiBwcm92aWRlIGEgbWFudWFsIHdheSB0byBjYXB0dXJlIGNvbW1hbmRzCiMgVGhpcyBpcyBvbmx5IHVzZWQgZm9yIGluc3RhbGxzIGluIHRlc3QgbW9kZSAoR0lUX1VORE9fVEVTVF9NT0RFKS4KZ2l0KCkgewogICAgY2FzZSAiJDEiIGluCiAgICBjb25maWcgfCByZW1vdGUgfCBzdGFzaCB8IGNsZWFuIHwgcmVzZXQgfCBjaGVja291dCkKICAgICAgICBHSVRfVU5ET19JTlRFUk5BTF9IT09LPTEgY29tbWFuZCBnaXQtdW5kbyAtLXByZS1ob29rPSJnaXQgJCoiIC0taG9vay1zaGVsbD1iYXNoCiAgICAgICAgOzsKICAgIGVzYWMKICAgIGNvbW1hbmQgZ2l0ICIkQCIKICAgIGxvY2FsIGV4aXRfY29kZT0kPwogICAgaWYgW1sgJGV4aXRfY29kZSAtZXEgMCBdXTsgdGhlbgogICAgICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iZ2l0ICQqIiAtLWhvb2stc2hlbGw9YmFzaAogICAgZWxzZQogICAgICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iZ2l0ICQqIiAtLWhvb2stc2hlbGw9YmFzaCAtLWhvb2stZmFpbGVkCiAgICBmaQogICAgcmV0dXJuICRleGl0X2NvZGUKfQoKCiMgU2V0IHVwIFBST01QVF9DT01NQU5EIHRvIGxvZyBzdWNjZXNzZnVsIGNvbW1hbmRzIGFmdGVyIGV4ZWN1dGlvbgppZiBbWyAteiAiJFBST01QVF9DT01NQU5EIiBdXTsgdGhlbgogIFBST01QVF9DT01NQU5EPSJsb2dfZ2l0X2NvbW1hbmQiCmVsc2UKICBQUk9NUFRfQ09NTUFORD0iJFBST01QVF9DT01NQU5EOyBsb2dfZ2l0X2NvbW1hbmQiCmZpCg=='
+EMBEDDED_ZSH_HOOK='IyEvdXNyL2Jpbi9lbnYgenNoCiMgc2hlbGxjaGVjayBkaXNhYmxlPWFsbAojIElEIG9mIHRoaXMgc2hlbGwgc2Vzc2lvbiwgcmVjb3JkZWQgd2l0aCB0aGUgbG9nZ2VkIGNvbW1hbmRzOiBgZ2l0IHVuZG8gLS1zZXNzaW9uYCBvbmx5IGNvbnNpZGVycyBpdHMgb3duLgojIEl0J3Mga2VwdCB3aGVuIHRoZSBob29rIGlzIHNvdXJjZWQgYWdhaW4sIHJlbmV3ZWQgaW4gbmVzdGVkIHNoZWxscyAodGhlaXIgUElEIGRpZmZlcnMpLgpbWyAiJHtHSVRfVU5ET19TRVNTSU9OJSUtKn0iID09ICIkJCIgXV0gfHwgZXhwb3J0IEdJVF9VTkRPX1NFU1NJT049IiQkLSQoZGF0ZSArJXMpIgoKIyBGdW5jdGlvbiB0byBzdG9yZSB0aGUgZ2l0IGNvbW1hbmQgdGVtcG9yYXJpbHkKc3RvcmVfZ2l0X2NvbW1hbmQoKSB7CiAgbG9jYWwgcmF3X2NtZD0iJDEiCiAgbG9jYWwgaGVhZD0ke3Jhd19jbWQlJSAqfQogIGxvY2FsIHJlc3Q9JHtyYXdfY21kIyIkaGVhZCJ9CiAgaWYgYWxpYXMgIiRoZWFkIiAmPi9kZXYvbnVsbDsgdGhlbgogICAgbG9jYWwgZGVmCiAgICBkZWY9JChhbGlhcyAiJGhlYWQiKQogICAgbG9jYWwgZXhwYW5zaW9uPSR7ZGVmIypcJ30KICAgIGV4cGFuc2lvbj0ke2V4cGFuc2lvbiVcJ30KICAgIHJhd19jbWQ9IiR7ZXhwYW5zaW9ufSR7cmVzdH0iCiAgZmkKICBbWyAiJHJhd19jbWQiID09IGdpdFwgKiBdXSB8fCByZXR1cm4KICBHSVRfQ09NTUFORF9UT19MT0c9IiRyYXdfY21kIgoKICAjIFNvbWUgY29tbWFuZHMgbmVlZCB0aGUgc3RhdGUgdGhleSBjaGFuZ2UgcmVjb3JkZWQgYmVmb3JlaGFuZCAoZS5nLiBhIGNvbmZpZyB2YWx1ZSBvciB0aGUgZmlsZXMgZ2l0IGNsZWFuIHJlbW92ZXMpCiAgY2FzZSAiJHJhd19jbWQiIGluCiAgZ2l0XCBhZGRcICogfCBnaXRcIGJyYW5jaFwgKiB8IGdpdFwgY29uZmlnXCAqIHwgZ2l0XCByZW1vdGVcICogfCBnaXRcIHN0YXNoXCAqIHwgZ2l0XCB0YWdcICogfCBnaXRcIGNsZWFuXCAqIHwgZ2l0XCByZXNldFwgKiB8IGdpdFwgY2hlY2tvdXRcICopCiAgICBHSVRfVU5ET19JTlRFUk5BTF9IT09LPTEgY29tbWFuZCBnaXQtdW5kbyAtLXByZS1ob29rPSIkcmF3X2NtZCIgLS1ob29rLXNoZWxsPXpzaAogICAgOzsKICBlc2FjCn0KCiMgRnVuY3Rpb24gdG8gbG9nIHRoZSBjb21tYW5kIG9uY2UgaXQgcmFuOiBmYWlsZWQgY29tbWFuZHMgYXJlIG9ubHkgbG9nZ2VkIChhcyBzdWNoKSBpZiBnaXQtdW5kby5sb2dmYWlsZWQgaXMgc2V0CmxvZ19naXRfY29tbWFuZCgpIHsKICBsb2NhbCBleGl0X2NvZGU9JD8KICBpZiBbWyAtbiAiJEdJVF9DT01NQU5EX1RPX0xPRyIgJiYgJGV4aXRfY29kZSAtZXEgMCBdXTsgdGhlbgogICAgR0lUX1VORE9fSU5URVJOQUxfSE9PSz0xIGNvbW1hbmQgZ2l0LXVuZG8gLS1ob29rPSIkR0lUX0NPTU1BTkRfVE9fTE9HIiAtLWhvb2stc2hlbGw9enNoCiAgZWxpZiBbWyAtbiAiJEdJVF9DT01NQU5EX1RPX0xPRyIgXV07IHRoZW4KICAgIEdJVF9VTkRPX0lOVEVSTkFMX0hPT0s9MSBjb21tYW5kIGdpdC11bmRvIC0taG9vaz0iJEdJVF9DT01NQU5EX1RPX0xPRyIgLS1ob29rLXNoZWxsPXpzaCAtLWhvb2stZmFpbGVkCiAgZmkKICAjIENsZWFyIHRoZSBzdG9yZWQgY29tbWFuZAogIEdJVF9DT01NQU5EX1RPX0xPRz0iIgp9CgphdXRvbG9hZCAtVSBhZGQtenNoLWhvb2sKYWRkLXpzaC1ob29rIHByZWV4ZWMgc3RvcmVfZ2l0X2NvbW1hbmQKYWRkLXpzaC1ob29rIHByZWNtZCBsb2dfZ2l0X2NvbW1hbmQK'
 # ── End of embedded hook files ──────────────────────────────────────────────
 
 set -e
@@ -99,10 +99,12 @@ echo "SCRIPT DIR $SCRIPT_DIR"
 # Git-undo specific configuration
 UNDO_BIN_NAME="git-undo"
 BACK_BIN_NAME="git-back"
+REDO_BIN_NAME="git-redo"
 BIN_DIR=$(go env GOBIN 2>/dev/null || true)
 [[ -z "$BIN_DIR" ]] && BIN_DIR="$(go env GOPATH)/bin"
 export UNDO_BIN_PATH="$BIN_DIR/$UNDO_BIN_NAME"
 export BACK_BIN_PATH="$BIN_DIR/$BACK_BIN_NAME"
+export REDO_BIN_PATH="$BIN_DIR/$REDO_BIN_NAME"
 
 # Legacy variable for backward compatibility
 export BIN_PATH="$UNDO_BIN_PATH"
@@ -112,13 +114,58 @@ export BASH_HOOK="$CFG_DIR/git-undo-hook.bash"
 export ZSH_HOOK="$CFG_DIR/git-undo-hook.zsh"
 GIT_HOOKS_DIR="$CFG_DIR/hooks"
 DISPATCHER_FILE="$GIT_HOOKS_DIR/git-hooks.sh"
-DISPATCHER_SRC="$SCRIPT_DIR/scripts/git-undo-git-hook.sh"
 
 REPO_OWNER="amberpixels"
 REPO_NAME="git-undo"
-export GITHUB_REPO_URL="github.com/$REPO_OWNER/$REPO_NAME"
-GITHUB_API_URL="https://api.github.com/repos/$REPO_OWNER/$REPO_NAME"
-export INSTALL_URL="https://raw.githubusercontent.com/$REPO_OWNER/$REPO_NAME/main/install.sh"
+
+# _setting prints the environment variable $1 if set, otherwise git config git-undo.$2, otherwise $3.
+# Trailing slashes are dropped, so both "https://host/x" and "https://host/x/" work.
+_setting() {
+    local value="${!1:-}"
+    if [[ -z "$value" ]]; then
+        value=$(git config --get "git-undo.$2" 2>/dev/null || true)
+    fi
+    value="${value:-$3}"
+    printf '%s' "${value%/}"
+}
+
+# Release endpoints can point to a fork or a GitHub Enterprise mirror instead of github.com:
+#   GIT_UNDO_MODULE / git-undo.module             Go module installed by `go install`
+#   GIT_UNDO_RELEASES_API / git-undo.releasesapi  repository API URL (https://<host>/api/v3/repos/<owner>/<repo>)
+#   GIT_UNDO_DOWNLOAD_URL / git-undo.downloadurl  base URL of raw files (https://<host>/raw/<owner>/<repo>/main)
+#   GIT_UNDO_GITHUB_TOKEN                         token sent to both endpoints (for private mirrors)
+export GITHUB_REPO_URL
+GITHUB_REPO_URL="$(_setting GIT_UNDO_MODULE module "github.com/$REPO_OWNER/$REPO_NAME")"
+export GITHUB_API_URL
+GITHUB_API_URL="$(_setting GIT_UNDO_RELEASES_API releasesapi "https://api.github.com/repos/$REPO_OWNER/$REPO_NAME")"
+export DOWNLOAD_URL
+DOWNLOAD_URL="$(_setting GIT_UNDO_DOWNLOAD_URL downloadurl "https://raw.githubusercontent.com/$REPO_OWNER/$REPO_NAME/main")"
+export INSTALL_URL="$DOWNLOAD_URL/install.sh"
+unset -f _setting
+
+# http_get downloads the URL to the file $2 (or stdout), authenticating with GIT_UNDO_GITHUB_TOKEN if set.
+http_get() {
+    local url="$1"
+    local output="${2:--}"
+    local token="${GIT_UNDO_GITHUB_TOKEN:-}"
+
+    if command -v curl >/dev/null 2>&1; then
+        if [[ -n "$token" ]]; then
+            curl -fsSL -H "Authorization: token $token" -o "$output" "$url"
+        else
+            curl -fsSL -o "$output" "$url"
+        fi
+    elif command -v wget >/dev/null 2>&1; then
+        if [[ -n "$token" ]]; then
+            wget -qO "$output" --header="Authorization: token $token" "$url"
+        else
+            wget -qO "$output" "$url"
+        fi
+    else
+        echo "error: curl or wget required" >&2
+        return 1
+    fi
+}
 
 detect_shell() {
     # Method 1: Check $SHELL environment variable (most reliable for login shell)
@@ -150,14 +197,7 @@ detect_shell() {
 
 get_latest_version() {
     local latest_release
-    if command -v curl >/dev/null 2>&1; then
-        latest_release=$(curl -s "$GITHUB_API_URL/releases/latest" | grep '"tag_name":' | sed -E 's/.*"([^"]+)".*/\1/')
-    elif command -v wget >/dev/null 2>&1; then
-        latest_release=$(wget -qO- "$GITHUB_API_URL/releases/latest" | grep '"tag_name":' | sed -E 's/.*"([^"]+)".*/\1/')
-    else
-        echo "error: curl or wget required for version check" >&2
-        return 1
-    fi
+    latest_release=$(http_get "$GITHUB_API_URL/releases/latest" | grep '"tag_name":' | sed -E 's/.*"([^"]+)".*/\1/')
 
     if [[ -z "$latest_release" || "$latest_release" == "null" ]]; then
         echo "error: failed to fetch latest version" >&2
@@ -209,135 +249,6 @@ version_compare() {
     fi
 }
 
-install_dispatcher_into() {
-    local target="$1"
-
-    # Validate target directory
-    if [[ -z "$target" ]]; then
-        log_error "Target directory not specified"
-        return 1
-    fi
-
-    log "Installing git hooks into: $target"
-
-    # Create target directory if it doesn't exist
-    if ! mkdir -p "$target" 2>/dev/null; then
-        log_error "Failed to create hooks directory: $target"
-        return 1
-    fi
-
-    # 1) Install the dispatcher script only if target is our managed hooks directory
-    if [[ "$target" == "$GIT_HOOKS_DIR" ]]; then
-        log "Installing dispatcher script to: $DISPATCHER_FILE"
-
-        # Debug: Check if source file exists
-        if [[ ! -f "$DISPATCHER_SRC" ]]; then
-            log_error "Source dispatcher script not found: $DISPATCHER_SRC"
-            log_error "DISPATCHER_SRC variable: '$DISPATCHER_SRC'"
-            log_error "Contents of script directory:"
-            ls -la "$(dirname "$DISPATCHER_SRC")" 2>/dev/null || log_error "Cannot list script directory"
-            return 1
-        fi
-
-        log "Source file exists: $DISPATCHER_SRC"
-        log "Source file permissions: $(ls -l "$DISPATCHER_SRC")"
-
-        # Ensure the hooks directory exists
-        if ! mkdir -p "$GIT_HOOKS_DIR" 2>/dev/null; then
-            log_error "Failed to create git hooks directory: $GIT_HOOKS_DIR"
-            return 1
-        fi
-
-        log "Target directory exists: $GIT_HOOKS_DIR"
-        log "Target directory permissions: $(ls -ld "$GIT_HOOKS_DIR")"
-
-        # Try using cp instead of install command for better compatibility
-        if cp "$DISPATCHER_SRC" "$DISPATCHER_FILE" 2>/dev/null; then
-            chmod 755 "$DISPATCHER_FILE" 2>/dev/null || {
-                log_error "Failed to set permissions on dispatcher script"
-                return 1
-            }
-            log "Dispatcher copied and permissions set successfully"
-        else
-            log_error "Failed to copy dispatcher script from $DISPATCHER_SRC to $DISPATCHER_FILE"
-            log_error "Let's try to understand why:"
-
-            # More detailed debugging
-            log_error "Source file readable? $(test -r "$DISPATCHER_SRC" && echo "YES" || echo "NO")"
-            log_error "Target directory writable? $(test -w "$GIT_HOOKS_DIR" && echo "YES" || echo "NO")"
-            log_error "Disk space available? $(df -h "$GIT_HOOKS_DIR" | tail -1)"
-
-            return 1
-        fi
-    fi
-
-    # 2) Wire up post-commit & post-merge hooks
-    for hook in post-commit post-merge; do
-        local hook_file="$target/$hook"
-        log "Processing hook: $hook_file"
-
-        if [[ -f "$hook_file" && ! -L "$hook_file" ]]; then
-            # Existing regular file - append our hook call if not already present
-            log "Found existing hook file, checking if git-undo is already integrated"
-
-            if ! grep -q 'git-undo --hook' "$hook_file" 2>/dev/null; then
-                log "Adding git-undo integration to existing hook"
-                {
-                    echo ""
-                    echo "# git-undo integration"
-                    echo "GIT_UNDO_INTERNAL_HOOK=1 git-undo --hook=\"$hook\""
-                } >> "$hook_file"
-
-                # Ensure it's executable
-                chmod +x "$hook_file" 2>/dev/null || {
-                    log_error "Failed to make hook executable: $hook_file"
-                    return 1
-                }
-                log "Successfully integrated with existing $hook hook"
-            else
-                log "git-undo already integrated in $hook hook"
-            fi
-
-        elif [[ -L "$hook_file" ]]; then
-            # It's a symlink - check if it points to our dispatcher
-            local link_target
-            link_target=$(readlink "$hook_file" 2>/dev/null || echo "")
-
-            if [[ "$link_target" != "$DISPATCHER_FILE" ]]; then
-                log_warning "Hook $hook_file is a symlink to $link_target, not our dispatcher"
-                log "This hook may not work with git-undo"
-            else
-                log "Hook $hook_file already points to our dispatcher"
-            fi
-
-        else
-            # No hook exists yet - create one
-            log "Creating new $hook hook"
-
-            # Try to create a symlink first (preferred method)
-            if ln -sf "$DISPATCHER_FILE" "$hook_file" 2>/dev/null; then
-                log "Created symlink: $hook_file -> $DISPATCHER_FILE"
-            else
-                # Fallback for filesystems that don't support symlinks
-                log "Symlink failed, creating standalone hook script"
-                cat > "$hook_file" << EOF
-# git-undo hook - auto-generated
-set -e
-GIT_UNDO_INTERNAL_HOOK=1 git-undo --hook="$hook"
-EOF
-                chmod +x "$hook_file" 2>/dev/null || {
-                    log_error "Failed to make hook executable: $hook_file"
-                    return 1
-                }
-                log "Created standalone hook: $hook_file"
-            fi
-        fi
-    done
-
-    log "Hook installation completed for: $target"
-    return 0
-}
-
 # ── End of inlined content ──────────────────────────────────────────────────
 
 # Verbose logging function
@@ -555,7 +466,7 @@ main() {
                      # Get the version that was just installed
                      INSTALLED_VERSION=$(git-undo --version 2>/dev/null  || echo "unknown")
                      echo -e "${GRAY}git-undo:${NC} Binaries installed with version: ${BLUE}$INSTALLED_VERSION${NC}"
-                     log "Installed: git-undo and git-back"
+                     log "Installed: git-undo, git-back and git-redo"
                  else
                      verbose_log "make binary-install failed"
                      echo -e "${GRAY}git-undo:${NC} ${RED}Failed to build from source using Makefile${NC}"
@@ -568,7 +479,7 @@ main() {
                      # Get the version that was just installed
                      INSTALLED_VERSION=$(git-undo --version 2>/dev/null  || echo "unknown")
                      echo -e "${GRAY}git-undo:${NC} Binaries installed with version: ${BLUE}$INSTALLED_VERSION${NC}"
-                     log "Installed: git-undo and git-back"
+                     log "Installed: git-undo, git-back and git-redo"
                  else
                      verbose_log "make binary-install failed"
                      echo -e "${GRAY}git-undo:${NC} ${RED}Failed to build from source using Makefile${NC}"
@@ -624,6 +535,14 @@ main() {
                  fi
              fi
              
+             verbose_log "Installing git-redo from $GITHUB_REPO_URL/cmd/$REDO_BIN_NAME@latest"
+             # Install git-redo (optional: `git undo undo` works without it)
+             if ! go install "$GITHUB_REPO_URL/cmd/$REDO_BIN_NAME@latest" 2>/dev/null; then
+                 verbose_log "git-redo installation failed - continuing without it"
+             else
+                 verbose_log "git-redo installation succeeded"
+             fi
+
              # Success message based on what was installed
              UNDO_BIN_PATH=$(command -v git-undo || echo "$BIN_DIR/$UNDO_BIN_NAME")
              INSTALLED_VERSION=$(git-undo --version 2>/dev/null  || echo "unknown")
@@ -645,37 +564,22 @@ main() {
         verbose_log "Skipping binary installation due to Go issues"
     fi
 
-    # 2) Git hooks integration
+    # 2) Git hooks integration: the binary installs them (see `git-undo self install-hooks`),
+    # chaining them into the hooks of a global core.hooksPath or setting it to $GIT_HOOKS_DIR.
     echo -en "${GRAY}git-undo:${NC} 2. Git integration..."
     verbose_log "Starting git hooks integration"
 
-    current_hooks_path=$(git config --global --get core.hooksPath || echo "")
-    target_hooks_path="$GIT_HOOKS_DIR"
-    
-    verbose_log "Current global hooks path: '${current_hooks_path}'"
-    verbose_log "Target hooks path: '${target_hooks_path}'"
-
-    if [[ -z "$current_hooks_path" ]]; then
-        verbose_log "No global hooks path set, configuring our hooks path"
-        if git config --global core.hooksPath "$target_hooks_path"; then
-            verbose_log "Successfully set global core.hooksPath to $target_hooks_path"
-        else
-            verbose_log "Failed to set global core.hooksPath"
-        fi
-        
-        verbose_log "Installing dispatcher into $target_hooks_path"
-        install_dispatcher_into "$target_hooks_path"
-        echo -e " ${GREEN}OK${NC} (set core.hooksPath)"
-    elif [[ "$current_hooks_path" == "$target_hooks_path" ]]; then
-        verbose_log "Hooks path already configured correctly"
-        verbose_log "Installing dispatcher into $target_hooks_path"
-        install_dispatcher_into "$target_hooks_path"
-        echo -e " ${YELLOW}SKIP${NC} (already configured)"
+    local hooks_output
+    if ! command -v git-undo >/dev/null 2>&1; then
+        verbose_log "git-undo binary not found, can't install git hooks"
+        echo -e " ${YELLOW}SKIP${NC} (git-undo binary not found)"
+    # Outside of any repository, so that only the global hooks get installed
+    elif hooks_output=$(cd / && git-undo self install-hooks --shell git 2>&1); then
+        verbose_log "$hooks_output"
+        echo -e " ${GREEN}OK${NC}"
     else
-        verbose_log "Different hooks path already configured, piggybacking on it"
-        verbose_log "Installing dispatcher into existing path: $current_hooks_path"
-        install_dispatcher_into "$current_hooks_path"
-        echo -e " ${YELLOW}SHARED${NC} (pig-backed on $current_hooks_path)"
+        echo -e " ${RED}FAILED${NC}"
+        log_error "$hooks_output"
     fi
 
     # 3) Shell integration
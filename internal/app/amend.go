@@ -0,0 +1,27 @@
+package app
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// annotateAmend records the commit replaced by `git commit --amend` into its entry (it's HEAD@{1} right after
+// the amend only), so the undo brings back that very commit.
+func (a *App) annotateAmend(lgr *logging.Logger, g GitHelper, gitCmd *githelpers.GitCommand) {
+	if gitCmd.Name != "commit" || !slices.Contains(gitCmd.Args, "--amend") {
+		return
+	}
+	sha, err := g.GitOutput("rev-parse", "--verify", "-q", "HEAD@{1}")
+	if err != nil || strings.TrimSpace(sha) == "" {
+		return
+	}
+
+	entries, err := lgr.GetEntries(1, nil)
+	if err != nil || len(entries) == 0 || entries[0].IsNavigation {
+		return
+	}
+	_ = lgr.SetEntryMeta(entries[0].GetIdentifier(), logging.MetaAmended, strings.TrimSpace(sha))
+}
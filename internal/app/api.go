@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// ErrNothingToUndo is returned by PlanUndo when the log has no command left to undo.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// History returns the latest entries of the log (all of them if limit is not positive), newest first.
+// Like every read-only invocation, it never writes to the git dir.
+func (a *App) History(ctx context.Context, limit int) ([]*logging.Entry, error) {
+	g, gitDir, err := a.openRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.newReadOnlyLogger(gitDir, g).GetEntries(limit, nil)
+}
+
+// PlanUndo returns the entry `git undo` would undo with the options (the latest regular entry,
+// or the one of opts.EntryID) along with the commands it would run, without running anything.
+func (a *App) PlanUndo(ctx context.Context, opts RunOptions) (*logging.Entry, []*undoer.UndoCommand, error) {
+	g, gitDir, err := a.openRepo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.planUndo(g, gitDir, opts)
+}
+
+// planUndo is PlanUndo in the opened repository.
+func (a *App) planUndo(g GitHelper, gitDir string, opts RunOptions) (*logging.Entry, []*undoer.UndoCommand, error) {
+	lgr := a.newReadOnlyLogger(gitDir, g)
+	var entry *logging.Entry
+	var err error
+	if opts.EntryID != "" {
+		if entry, err = lgr.GetEntryByID(opts.EntryID); err == nil && entry == nil {
+			err = fmt.Errorf("no entry with id %s found in the log", opts.EntryID)
+		}
+	} else if entry, err = lgr.GetLastRegularEntry(); err == nil && entry == nil {
+		err = ErrNothingToUndo
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry.Undoed {
+		return nil, nil, fmt.Errorf("entry %s is already undone: %s", entry.ID(), entry.Command)
+	}
+
+	u, err := a.prepareUndoer(g, opts, entry, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	undoCmds, err := undoCommands(u, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, undoCmds, nil
+}
+
+// openRepo opens the repository of the app's directory and loads its git-undo config.
+func (a *App) openRepo(ctx context.Context) (GitHelper, string, error) {
+	g := githelpers.NewGitHelper(ctx, a.dir)
+	if err := g.CheckWorkTree(); err != nil {
+		return nil, "", noWorkTreeError(a.getAppName(), err)
+	}
+	gitDir, err := g.GetRepoGitDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("not a git repository: %s", a.dir)
+	}
+
+	cfg, err := config.Load(g)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load git-undo config: %w", err)
+	}
+	a.cfg = cfg
+	a.passphrase = nil
+	return g, gitDir, nil
+}
@@ -1,15 +1,25 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"runtime/debug"
 
 	gitundoembeds "github.com/amberpixels/git-undo"
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
 	"github.com/amberpixels/git-undo/internal/git-undo/logging"
 	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
 	"github.com/amberpixels/git-undo/internal/githelpers"
@@ -19,8 +29,10 @@ import (
 type GitHelper interface {
 	GetCurrentGitRef() (string, error)
 	GetRepoGitDir() (string, error)
+	GetAlias(name string) (string, bool)
 
 	GitRun(subCmd string, args ...string) error
+	GitRunWithInput(input string, subCmd string, args ...string) error
 	GitOutput(subCmd string, args ...string) (string, error)
 }
 
@@ -40,6 +52,36 @@ type App struct {
 
 	// isBackMode indicates if this is git-back (true) or git-undo (false)
 	isBackMode bool
+
+	// isRedoMode indicates if this is git-redo: a shortcut of `git undo undo`.
+	isRedoMode bool
+
+	// theme is the output theme (it's set once git-undo config is loaded).
+	theme *outputTheme
+
+	// cfg is git-undo config (it's set once loaded in Run).
+	cfg *config.Config
+
+	// stdin is where confirmations are read from (os.Stdin if nil).
+	// It's suggested to be filled and used in tests only.
+	stdin io.Reader
+
+	// messages is where messages (info, warnings, errors) are written to (os.Stderr if nil, see SetMessages).
+	messages io.Writer
+	// confirmed tells the undo commands were confirmed by the caller already (see AssumeConfirmed).
+	confirmed bool
+	// debugLog is the handler of the debug log every record goes to (nil when disabled, see openDebugLog).
+	debugLog slog.Handler
+	// passphrase is the passphrase of the encrypted log, once its command ran (see logPassphrase).
+	passphrase *string
+}
+
+// getTheme returns the output theme (the default one until the config is loaded).
+func (a *App) getTheme() outputTheme {
+	if a.theme != nil {
+		return *a.theme
+	}
+	return defaultOutputTheme()
 }
 
 // getIsInternalCall checks if the hook is being called internally (either via test or zsh script).
@@ -69,6 +111,13 @@ func NewAppGitBack(version, versionSource string) *App {
 	return app
 }
 
+// NewAppGitRedo creates a new App instance for git-redo.
+func NewAppGitRedo(version, versionSource string) *App {
+	app := NewAppGitUndo(version, versionSource)
+	app.isRedoMode = true
+	return app
+}
+
 // HandleVersion handles the --version flag by delegating to SelfController.
 func (a *App) HandleVersion(ctx context.Context, verbose bool) error {
 	selfCtrl := NewSelfController(ctx, a.version, a.versionSource, verbose, a.getAppName())
@@ -82,21 +131,144 @@ type RunOptions struct {
 	HookCommand string
 	ShowLog     bool
 	Args        []string
+
+	// HookShell is the shell the hooked command was typed in: its quoting splits the command (POSIX by default).
+	HookShell string
+	// HookFailed tells the hooked command failed: it's logged as failed if git-undo.logfailed is set.
+	HookFailed bool
+	// Shell (git-undo only) is the shell `self install-hooks` installs the hook of ($SHELL by default).
+	Shell string
+	// PreHook is a hooked command about to run: the state it changes is captured for its undo (see cmdPreHook).
+	PreHook string
+
+	// EntryID (git-undo only) selects a specific log entry to undo instead of the latest one.
+	EntryID string
+	// At (git-undo only) selects the log entry to undo by its position in the log (1 is the latest entry)
+	// or by its identifier (see logging.Entry.GetIdentifier).
+	At string
+	// Interactive (git-undo only) lets the user pick the entries to undo from the latest ones.
+	Interactive bool
+	// Session (undo and redo) only considers the commands run from the current shell session
+	// (see logging.EnvSession), leaving alone the ones run from other terminals.
+	Session bool
+	// Since (git-undo only) undoes every command logged on the current ref since a date or a duration ago
+	// (e.g. 2025-01-31 15:04 or 10m), once the user confirmed the plan.
+	Since string
+	// Count is how many of the latest entries to undo (or redo) at once (1 if not set),
+	// or with git-back how many steps of the navigation history to go back.
+	Count int
+	// BackTo (git-back only) selects a ref from the navigation history to go back to.
+	BackTo string
+	// ListBack (git-back only) lists the navigation history with the step count going back to each ref.
+	ListBack bool
+	// Forward (git-back only) goes forward again through the navigation history walked back by git back.
+	Forward bool
+	// Complete is a shell completion query (see Complete* constants).
+	Complete string
+
+	// PreserveMetadata (redo only) restores the undone commit exactly (author, dates, signature)
+	// instead of re-running the commit command, when the commit is still kept.
+	PreserveMetadata bool
+	// MessageOnly (git-undo only) makes the undo of `git commit --amend` restore only the previous message,
+	// keeping the changes the amend added committed.
+	MessageOnly bool
+	// CommitMode (git-undo only) is what the undo of a commit does with its changes:
+	// keeps them staged (soft, the default), leaves them unstaged (unstage) or discards them (hard).
+	CommitMode string
+	// Force (git-undo only) makes the undo rewrite commits that are already pushed,
+	// instead of reverting them (or refusing to).
+	Force bool
+	// RedoAll (redo only) redoes every command undone since the last regular one, in their original order.
+	RedoAll bool
+
+	// LimitPaths (git-undo only) limits the undo of a path-wide operation (e.g. `git add .`)
+	// to the affected paths matching any of these globs.
+	LimitPaths []string
+
+	// DiffFrom and DiffTo (`git undo diff` only) are the IDs of the entries whose states are compared.
+	// Without DiffTo, the state after DiffFrom is compared to the current one.
+	DiffFrom string
+	DiffTo   string
+
+	// Simulate (git-undo only) runs the undo in a temporary copy of the repository
+	// and reports the resulting state, leaving the repository itself untouched.
+	Simulate bool
+
+	// ListSnapshots (git-undo only) lists the snapshots taken before destructive commands.
+	ListSnapshots bool
+
+	// Accessible forces the screen reader friendly output (see ThemeAccessible).
+	Accessible bool
+
+	// Fix (`git undo doctor` only) repairs the found problems.
+	Fix bool
+
+	// History (`git undo history` only) selects and groups the shown entries.
+	History HistoryQuery
+
+	// Log (`git undo log` only) selects the shown entries.
+	Log LogQuery
+
+	// JSON prints reports (e.g. `self info`, `git undo log`) as JSON.
+	JSON bool
+	// Porcelain (`git undo log` only) prints the entries as stable tab-separated lines for scripts.
+	Porcelain bool
+	// Pretty (`git undo log` only) prints the entries in colors, with relative times and their would-be undo.
+	Pretty bool
+
+	// DebugFile is where detailed traces are written to (see also config.KeyDebugLog), whatever Verbose is.
+	DebugFile string
+
+	// Repo runs the app in the repository at the given path instead of the current one (like `git -C`).
+	Repo string
+	// Worktree undoes in the worktree at the given path instead of the current one, or with HistoryAllWorktrees
+	// in the worktree of the repository whose undoable command is the latest.
+	Worktree string
 }
 
 // Run executes the app with parsed options.
-func (a *App) Run(ctx context.Context, opts RunOptions) error {
+func (a *App) Run(ctx context.Context, opts RunOptions) (runErr error) {
 	a.logDebugf(opts.Verbose, "called in verbose mode")
 
 	defer func() {
 		if recovered := recover(); recovered != nil {
 			a.logDebugf(opts.Verbose, "git-undo panic recovery: %v", recovered)
+			runErr = a.reportCrash(ctx, recovered, debug.Stack())
 		}
 	}()
 
+	if opts.Repo != "" {
+		if err := validateRepoDir(opts.Repo); err != nil {
+			return err
+		}
+		a.dir = opts.Repo
+	}
+
+	// Completion queries must be fast and side effect free, so they bypass everything else
+	if opts.Complete != "" {
+		return a.cmdComplete(ctx, opts.Complete)
+	}
+	// Completion scripts are set up once per shell, in or out of a repository
+	if len(opts.Args) > 0 && opts.Args[0] == CommandCompletion {
+		return a.cmdCompletion(opts.Args[1:])
+	}
+
+	// The global journal is read in or out of a repository
+	if len(opts.Args) > 0 && opts.Args[0] == CommandLog && opts.Log.Global {
+		return a.cmdGlobalLog(ctx, opts)
+	}
+
 	selfCtrl := NewSelfController(ctx, a.version, a.versionSource, opts.Verbose, a.getAppName()).
 		AddScript(CommandUpdate, gitundoembeds.GetUpdateScript()).
-		AddScript(CommandUninstall, gitundoembeds.GetUninstallScript())
+		AddScript(CommandUninstall, gitundoembeds.GetUninstallScript()).
+		AddHook(HookBash, gitundoembeds.GetBashHook()).
+		AddHook(HookZsh, gitundoembeds.GetZshHook()).
+		AddHook(HookFish, gitundoembeds.GetFishHook()).
+		AddHook(HookPowerShell, gitundoembeds.GetPowerShellHook()).
+		AddHook(HookGit, gitundoembeds.GetGitHook()).
+		WithJSON(opts.JSON).
+		WithShell(opts.Shell).
+		InDir(a.dir)
 
 	if err := selfCtrl.HandleSelfCommand(opts.Args); err == nil {
 		return nil
@@ -105,37 +277,233 @@ func (a *App) Run(ctx context.Context, opts RunOptions) error {
 	}
 
 	g := githelpers.NewGitHelper(ctx, a.dir)
+	if hooked := cmp.Or(opts.HookCommand, opts.PreHook); hooked != "" {
+		g = a.hookedGitHelper(ctx, g, hooked, opts.HookShell)
+	}
+
+	// Without a work tree (e.g. in bare repositories on servers) there's nothing to log or undo:
+	// nothing should be even created in the git dir
+	if err := g.CheckWorkTree(); err != nil {
+		if opts.HookCommand != "" || opts.PreHook != "" {
+			a.logDebugf(opts.Verbose, "hook: skipping as there's no work tree: %v", err)
+			return nil
+		}
+		return noWorkTreeError(a.getAppName(), err)
+	}
 
 	gitDir, err := g.GetRepoGitDir()
+	if err != nil && opts.Repo != "" {
+		return fmt.Errorf("not a git repository: %s", opts.Repo)
+	}
 	if err != nil {
 		// Silently return for non-git repos when not using self commands
 		a.logDebugf(opts.Verbose, "not in a git repository, ignoring command%v: %s", opts.Args, err)
 		return nil
 	}
 
-	lgr := logging.NewLogger(gitDir, g)
-	if lgr == nil {
-		return errors.New("failed to create git-undo logger")
+	cfg, err := config.Load(g)
+	if err != nil {
+		return fmt.Errorf("failed to load git-undo config: %w", err)
+	}
+	a.cfg = cfg
+	a.passphrase = nil
+	name := themeName(cfg)
+	if opts.Accessible {
+		name = ThemeAccessible
+	}
+	theme, ok := newOutputTheme(name)
+	theme.colors = theme.colors && cfg.Color
+	a.theme = &theme
+	if !ok {
+		a.logWarnf("unknown theme %q (supported: %s, %s, %s, %s)",
+			name, ThemeEmoji, ThemeMinimal, ThemePlain, ThemeAccessible)
+	}
+
+	// Repositories excluded via config are never logged: nothing should be even created in their .git
+	if opts.HookCommand != "" || opts.PreHook != "" {
+		if included, reason := a.isRepoIncluded(g, gitDir, cfg); !included {
+			a.logDebugf(opts.Verbose, "hook: skipping as repository is %s", reason)
+			return nil
+		}
+	}
+
+	// The debug log is written in plain text: nothing of an encrypted log goes there
+	if opts.DebugFile != "" && cfg.Encrypt {
+		a.logWarnf("the debug log is disabled: the log is encrypted (see git-undo.encrypt)")
+	}
+	// Nor do read-only invocations write to the git dir, unless told where to write it
+	if (opts.DebugFile != "" || (cfg.DebugLog && !isReadOnlyInvocation(opts))) && !cfg.Encrypt {
+		closeDebugLog, err := a.openDebugLog(gitDir, opts.DebugFile)
+		if err != nil {
+			a.logWarnf("%v", err)
+		} else {
+			defer closeDebugLog()
+			defer func() {
+				if runErr != nil {
+					slog.New(a.debugLog).Error("failed", "err", runErr)
+				}
+			}()
+			a.logger(opts.Verbose).Debug("run", "version", a.version, "args", opts.Args, "hook", opts.HookCommand,
+				"preHook", opts.PreHook, "internal", a.getIsInternalCall(), "gitDir", gitDir)
+		}
 	}
 
 	// Handle --hook flag
 	if opts.HookCommand != "" {
-		return a.cmdHook(lgr, opts.Verbose, opts.HookCommand)
+		if opts.HookFailed {
+			return a.cmdHookFailed(gitDir, g, opts.Verbose, opts.HookCommand, opts.HookShell)
+		}
+		return a.cmdHook(gitDir, g, opts.Verbose, opts.HookCommand, opts.HookShell)
+	}
+	if opts.PreHook != "" {
+		return a.cmdPreHook(gitDir, g, opts.Verbose, opts.PreHook, opts.HookShell)
 	}
 
-	// Handle --log flag
+	// Read-only invocations never write to the git dir: no log dir creation and no migration
 	if opts.ShowLog {
-		return a.cmdLog(lgr)
+		return a.cmdLog(a.newReadOnlyLogger(gitDir, g))
+	}
+	if opts.ListSnapshots {
+		return a.cmdListSnapshots(gitDir)
+	}
+	if len(opts.Args) > 0 && opts.Args[0] == CommandStatus {
+		return a.cmdStatus(g, gitDir, cfg)
+	}
+	if len(opts.Args) > 0 && opts.Args[0] == CommandDoctor {
+		return a.cmdDoctor(gitDir, opts.Fix)
+	}
+
+	if len(opts.Args) > 0 && opts.Args[0] == CommandLog {
+		return a.cmdLogQuery(ctx, g, gitDir, opts)
+	}
+	if len(opts.Args) > 0 && opts.Args[0] == CommandHistory {
+		return a.cmdHistory(ctx, g, gitDir, opts.History)
+	}
+	if len(opts.Args) > 0 && opts.Args[0] == CommandStats {
+		return a.cmdStats(a.newReadOnlyLogger(gitDir, g), opts.Since, opts.JSON)
+	}
+	if len(opts.Args) > 0 && opts.Args[0] == CommandDiff {
+		return a.cmdDiff(a.newReadOnlyLogger(gitDir, g), g, opts.DiffFrom, opts.DiffTo)
+	}
+
+	// Handle `git undo notes ...`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandNotes {
+		return a.cmdNotes(g, opts.Args[1:])
+	}
+
+	// Handle `git undo gc`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandGC {
+		return a.cmdGC(gitDir, g, opts.Verbose)
+	}
+
+	// Handle `git undo trash ...`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandTrash {
+		return a.cmdTrash(g, opts.Args[1:])
+	}
+
+	// Handle `git undo checkpoint ...`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandCheckpoint {
+		return a.cmdCheckpoint(gitDir, a.newReadOnlyLogger(gitDir, g), g, opts.Args[1:])
+	}
+
+	// Handle `git undo config ...`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandConfig {
+		return a.cmdConfig(g, opts.Args[1:])
+	}
+
+	// Handle `git undo web [<port>]`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandWeb {
+		return a.cmdWeb(ctx, g, gitDir, opts.Args[1:])
+	}
+
+	// Handle `git undo serve [<socket>]`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandServe {
+		return a.cmdServe(ctx, g, gitDir, opts.Args[1:])
+	}
+
+	// Undoing in another worktree is undoing there: the log and the git commands are that worktree's
+	if opts.Worktree != "" {
+		worktree, err := a.undoWorktree(ctx, g, gitDir, opts.Worktree)
+		if err != nil {
+			return err
+		}
+		defer func(dir string) { a.dir = dir }(a.dir)
+		opts.Repo, opts.Worktree = worktree, ""
+		return a.Run(ctx, opts)
+	}
+
+	if err := checkLogOwnership(gitDir); err != nil {
+		return err
+	}
+	lgr, err := a.newLogger(gitDir, g)
+	if err != nil {
+		return err
+	}
+	lgr.SetDebugLogger(a.logger(opts.Verbose))
+
+	// Handle `git undo pin|unpin <id>`
+	if len(opts.Args) > 0 && (opts.Args[0] == CommandPin || opts.Args[0] == CommandUnpin) {
+		return a.cmdPin(lgr, opts.Args[1:], opts.Args[0] == CommandPin)
+	}
+
+	// Handle `git undo restore <name>`
+	if len(opts.Args) > 0 && opts.Args[0] == CommandRestore {
+		return a.cmdRestore(ctx, gitDir, lgr, g, opts)
 	}
 
 	return a.run(ctx, lgr, g, opts)
 }
 
+// isReadOnlyInvocation tells whether the invocation only reads the log (see Run): it never writes to the git dir.
+func isReadOnlyInvocation(opts RunOptions) bool {
+	if opts.HookCommand != "" || opts.PreHook != "" {
+		return false
+	}
+	if opts.ShowLog || opts.ListSnapshots {
+		return true
+	}
+	if len(opts.Args) == 0 {
+		return false
+	}
+	switch opts.Args[0] {
+	case CommandStatus, CommandLog, CommandHistory, CommandStats, CommandDiff:
+		return true
+	case CommandDoctor:
+		return !opts.Fix
+	default:
+		return false
+	}
+}
+
+// validateRepoDir checks that the --repo path is an existing directory (git does the same for -C).
+func validateRepoDir(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot change to %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cannot change to %s: not a directory", path)
+	}
+	return nil
+}
+
 // run contains the core undo/back functionality.
 func (a *App) run(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	if opts.Session {
+		session := os.Getenv(logging.EnvSession)
+		if session == "" {
+			return fmt.Errorf("no shell session: --session needs the shell hook (see %s self install-hooks)",
+				appNameGitUndo)
+		}
+		lgr.SetSession(session)
+	}
+
 	if a.isBackMode {
 		return a.runBack(ctx, lgr, g, opts)
 	}
+	if a.isRedoMode {
+		return a.runRedo(ctx, lgr, g, opts)
+	}
 
 	// Determine the operation type based on args and app mode
 	// `git undo undo` -> redo
@@ -147,48 +515,264 @@ func (a *App) run(ctx context.Context, lgr *logging.Logger, g GitHelper, opts Ru
 	return a.runUndo(ctx, lgr, g, opts)
 }
 
-// runRedo handles "git undo undo" operations (redo functionality).
+// runRedo handles "git undo undo" (and git-redo) operations: with a count, the latest undone entries
+// are redone one by one, as if the redo was run count times.
 func (a *App) runRedo(_ context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
 	a.logDebugf(opts.Verbose, "runRedo called")
 
-	// Get the last undoed entry (from current reference)
-	lastEntry, err := lgr.GetLastUndoedEntry()
+	count := max(opts.Count, 1)
+	if opts.RedoAll {
+		count = 0
+	}
+	entries, err := redoableEntries(lgr, g, count)
 	if err != nil {
 		a.logErrorf("something wrong with the log: %v", err)
 		return nil
 	}
-	if lastEntry == nil {
+	if len(entries) == 0 {
 		// nothing to redo
 		a.logInfof("nothing to redo")
 		return nil
 	}
+	if len(entries) < opts.Count {
+		a.logInfof("Only %d commands can be redone", len(entries))
+	}
+	if opts.Count <= 1 && !opts.RedoAll {
+		// The commands of an undo group are redone together, in their original order
+		if group, err := lgr.GetLastUndoedGroup(); err == nil && len(group) > 1 {
+			a.logInfof("Redoing a group of %d commands", len(group))
+			entries = group
+		}
+	}
+
+	if opts.DryRun {
+		for _, entry := range entries {
+			a.logInfof("Would run: %s", a.getTheme().highlight(entry.Command))
+		}
+		return nil
+	}
 
-	a.logDebugf(opts.Verbose, "runRedo: found undoed entry: %s", lastEntry.Command)
+	for done, entry := range entries {
+		if err := a.redoEntry(lgr, g, opts, entry); err != nil {
+			if done == 0 {
+				return err
+			}
+			return fmt.Errorf("redid %d of %d commands, then redoing `%s` failed: %w",
+				done, len(entries), entry.Command, err)
+		}
+	}
+	return nil
+}
+
+// redoableEntries returns up to count (all if count <= 0) undone entries of the current ref in the order
+// to redo them in (the one repeated `git undo undo` redoes first, first): the latest undone entries
+// in the log were undone newest first, so they're redone oldest first.
+func redoableEntries(lgr *logging.Logger, g GitHelper, count int) ([]*logging.Entry, error) {
+	ref, _ := g.GetCurrentGitRef()
+	candidates, err := lgr.GetEntries(0, func(e *logging.Entry) bool {
+		return !e.IsNavigation && (ref == "" || e.Ref.String() == ref)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*logging.Entry
+	for _, entry := range candidates {
+		if !entry.Undoed && len(entries) > 0 {
+			break
+		}
+		if entry.Undoed {
+			entries = append(entries, entry)
+		}
+	}
+	slices.Reverse(entries)
+	if count > 0 && len(entries) > count {
+		entries = entries[:count]
+	}
+	return entries, nil
+}
+
+// redoEntry re-runs the command of the undone entry and unmarks it in the log.
+func (a *App) redoEntry(lgr *logging.Logger, g GitHelper, opts RunOptions, entry *logging.Entry) error {
+	a.logDebugf(opts.Verbose, "runRedo: found undoed entry: %s", entry.Command)
+
+	gitCmd, err := githelpers.ParseGitCommand(entry.Command)
+	if err != nil {
+		return fmt.Errorf("invalid last undo-ed cmd[%s]: %w", entry.Command, err)
+	}
+	// The command may have been logged with a newer git (e.g. the repository is used from several machines)
+	if capability, ok := gitCmd.SupportedBy(githelpers.InstalledGitVersion()); !ok {
+		return fmt.Errorf("cannot redo `%s`: `git %s` is not supported by the installed git %s",
+			entry.Command, capability, githelpers.InstalledGitVersion())
+	}
+	// Re-run with the redacted text, the command would do something else
+	if slices.Contains(entry.RedactedParts(), logging.RedactedCommand) {
+		return fmt.Errorf("cannot redo `%s`: the command was redacted (see git-undo.redact)", entry.Command)
+	}
+
+	// Re-run, the command wouldn't bring back a commit removed from under later ones or reverted
+	if record := entry.UndoRecord(); record != nil && record.Strategy != "" {
+		return fmt.Errorf("cannot redo `%s`: %s", entry.Command, describeKeptLaterCommits(g, record))
+	}
 
 	// Unmark the entry in the log
-	if err := lgr.ToggleEntry(lastEntry.GetIdentifier()); err != nil {
+	if err := lgr.ToggleEntry(entry.GetIdentifier()); err != nil {
 		return fmt.Errorf("failed to unmark command: %w", err)
 	}
 
-	// Execute the original command
-	gitCmd, err := githelpers.ParseGitCommand(lastEntry.Command)
+	if gitCmd.Name == "commit" {
+		if err := a.redoCommitEntry(lgr, g, opts, entry, gitCmd); err != nil {
+			// The commit isn't back: the entry stays undone, and its commit kept to restore it later
+			_ = lgr.ToggleEntry(entry.GetIdentifier())
+			return err
+		}
+		a.recordRedo(lgr, entry)
+		dropKeptCommit(g, entry)
+		return nil
+	}
+	a.recordRedo(lgr, entry)
+
+	// Execute the original command verbatim: its words as the shell split them when it was typed
+	args, err := githelpers.SplitGitCommand(entry.Command)
 	if err != nil {
-		return fmt.Errorf("invalid last undo-ed cmd[%s]: %w", lastEntry.Command, err)
+		return fmt.Errorf("invalid last undo-ed cmd[%s]: %w", entry.Command, err)
+	}
+	if err := g.GitRun(args[0], args[1:]...); err != nil {
+		return fmt.Errorf("failed to redo command[%s]: %w", entry.Command, err)
+	}
+	a.restoreTracking(g, entry)
+	// The redone stash is a new commit
+	a.annotateStash(lgr, g, gitCmd, entry)
+
+	a.logDebugf(opts.Verbose, "Successfully redid: %s", entry.Command)
+	return nil
+}
+
+// redoCommitEntry brings back the commit of the undone entry: the kept commit itself when possible,
+// or a new one re-running the commit command.
+func (a *App) redoCommitEntry(
+	lgr *logging.Logger,
+	g GitHelper,
+	opts RunOptions,
+	entry *logging.Entry,
+	gitCmd *githelpers.GitCommand,
+) error {
+	// After undoing a commit with --unstage or --hard nothing is staged: re-running the commit
+	// would commit nothing, so the kept commit is restored instead
+	if opts.PreserveMetadata || g.GitRun("diff", "--cached", "--quiet") == nil {
+		restored, err := a.restoreKeptCommit(g, opts, entry)
+		if err != nil {
+			return err
+		}
+		if restored {
+			a.describeFixupRedo(g, entry)
+			return nil
+		}
+	}
+	if err := a.redoCommit(g, opts, entry, gitCmd); err != nil {
+		return err
+	}
+	// The re-executed commit is a new one: a later undo of the entry must target it, not the undone one
+	if head, err := g.GitOutput("rev-parse", "HEAD"); err == nil && entry.Metadata[logging.MetaHead] != "" {
+		_ = lgr.SetEntryMeta(entry.GetIdentifier(), logging.MetaHead, strings.TrimSpace(head))
+	}
+	a.describeFixupRedo(g, entry)
+	return nil
+}
+
+// restoreKeptCommit restores the exact undone commit (author, dates, signature) from its keep-alive ref.
+// Returns false if it's not possible, so the commit command should be re-executed instead.
+func (a *App) restoreKeptCommit(g GitHelper, opts RunOptions, entry *logging.Entry) (bool, error) {
+	sha, ok := getKeptCommit(g, entry)
+	if !ok {
+		if opts.PreserveMetadata {
+			a.logWarnf("undone commit is not kept anymore: re-running the commit command instead")
+		}
+		return false, nil
+	}
+
+	resetMode := "--hard"
+	switch {
+	case canRestoreKeptCommit(g, sha):
+	case canReapplyKeptCommit(g, sha):
+		// The changes of the commit were discarded by the undo: they're brought back with the commit
+		resetMode = "--keep"
+	case canRecommitKeptCommit(g, sha):
+		// The changes of the commit were left unstaged by the undo: the working tree is kept as it is
+		resetMode = "--mixed"
+	default:
+		if opts.PreserveMetadata {
+			a.logWarnf("repository has changed since the undo: re-running the commit command instead")
+		}
+		return false, nil
+	}
+
+	if err := g.GitRun("reset", resetMode, sha); err != nil {
+		return false, fmt.Errorf("failed to restore commit %s: %w", sha, err)
+	}
+
+	a.logDebugf(opts.Verbose, "Successfully redid: %s (restored commit %s)", entry.Command, sha)
+	return true, nil
+}
+
+// redoCommit re-executes the commit command respecting the signing configuration:
+// the commit is signed when the original one was, or made explicitly unsigned (with a warning)
+// when the signing program isn't available (e.g. no GPG agent in the hook context).
+func (a *App) redoCommit(g GitHelper, opts RunOptions, entry *logging.Entry, gitCmd *githelpers.GitCommand) error {
+	// Fixup targets are re-created against the recorded commit: relative targets (e.g. HEAD~2) have moved
+	baseArgs := gitCmd.Args
+	if kind, target, ok := entry.FixupTarget(); ok {
+		baseArgs = withFixupTarget(baseArgs, kind, target)
 	}
-	if !gitCmd.Supported {
-		return fmt.Errorf("invalid last undo-ed cmd[%s]: not supported", lastEntry.Command)
+
+	signing := detectCommitSigning(g)
+	args, signed, warning := withCommitSigning(baseArgs, signing, signing.available())
+	if warning != "" {
+		a.logWarnf("%s", warning)
 	}
 
-	if err := g.GitRun(gitCmd.Name, gitCmd.Args...); err != nil {
-		return fmt.Errorf("failed to redo command[%s]: %w", lastEntry.Command, err)
+	if err := g.GitRun(gitCmd.Name, args...); err != nil {
+		if !signed {
+			return fmt.Errorf("failed to redo command[%s]: %w", entry.Command, err)
+		}
+
+		// Signing may fail even if the program exists (e.g. agent is locked): retry unsigned
+		a.logWarnf("failed to create a signed commit (%v): retrying without signing", err)
+		args, _, _ = withCommitSigning(baseArgs, signing, false)
+		if err := g.GitRun(gitCmd.Name, args...); err != nil {
+			return fmt.Errorf("failed to redo command[%s]: %w", entry.Command, err)
+		}
 	}
 
-	a.logDebugf(opts.Verbose, "Successfully redid: %s", lastEntry.Command)
+	a.logDebugf(opts.Verbose, "Successfully redid: %s", entry.Command)
+
+	// The redo makes a new commit: how it's signed is reported, a bad or missing signature that was meant
+	// to be there (see withCommitSigning) as a warning
+	status, _ := g.GitOutput("log", "-1", "--format=%G?")
+	described := describeSignatureStatus(status)
+	if strings.TrimSpace(status) == "B" || described == "unsigned" && (signed || warning != "") {
+		a.logWarnf("the redone commit is %s", described)
+	} else {
+		a.logInfof("The redone commit is %s", described)
+	}
 	return nil
 }
 
 // runBack handles git-back operations (navigation undo).
 func (a *App) runBack(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	if opts.BackTo != "" {
+		return a.runBackTo(ctx, lgr, g, opts)
+	}
+	if opts.ListBack {
+		return a.cmdBackList(lgr)
+	}
+	if opts.Forward {
+		return a.runForward(ctx, lgr, g, opts)
+	}
+	if opts.Count > 1 {
+		return a.runBackCount(ctx, lgr, g, opts)
+	}
+
 	// For git-back, look for the last checkout/switch command (including undoed ones for toggle behavior)
 	// We pass "any" to look across all refs, not just the current one
 	lastEntry, err := lgr.GetLastEntry(logging.RefAny)
@@ -218,6 +802,22 @@ func (a *App) runBack(ctx context.Context, lgr *logging.Logger, g GitHelper, opt
 
 // runUndo handles git-undo operations (mutation undo).
 func (a *App) runUndo(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	if opts.EntryID != "" {
+		return a.runUndoEntryID(ctx, lgr, g, opts)
+	}
+	if opts.At != "" {
+		return a.runUndoAt(ctx, lgr, g, opts)
+	}
+	if opts.Interactive {
+		return a.runUndoInteractive(ctx, lgr, g, opts)
+	}
+	if opts.Since != "" {
+		return a.runUndoSince(ctx, lgr, g, opts)
+	}
+	if opts.Count > 1 {
+		return a.runUndoCount(ctx, lgr, g, opts)
+	}
+
 	// First, check if the chronologically last command was a checkout/switch command
 	absoluteLastEntry, err := lgr.GetLastEntry()
 	if err != nil {
@@ -225,7 +825,7 @@ func (a *App) runUndo(ctx context.Context, lgr *logging.Logger, g GitHelper, opt
 	}
 
 	if absoluteLastEntry != nil && a.isCheckoutOrSwitchCommand(absoluteLastEntry.Command) {
-		a.logInfof("Last operation can't be undone. Use %sgit back%s instead.", yellowColor, resetColor)
+		a.logInfof("Last operation can't be undone. Use %s instead.", a.getTheme().highlight("git back"))
 		return nil
 	}
 
@@ -242,7 +842,7 @@ func (a *App) runUndo(ctx context.Context, lgr *logging.Logger, g GitHelper, opt
 			return fmt.Errorf("failed to get last checkout/switch command: %w", err)
 		}
 		if lastNavEntry != nil {
-			a.logInfof("Last operation can't be undone. Use %sgit back%s instead.", yellowColor, resetColor)
+			a.logInfof("Last operation can't be undone. Use %s instead.", a.getTheme().highlight("git back"))
 			return nil
 		}
 		a.logInfof("nothing to undo")
@@ -251,13 +851,318 @@ func (a *App) runUndo(ctx context.Context, lgr *logging.Logger, g GitHelper, opt
 
 	// Check if the last regular command was checkout or switch - suggest git back instead
 	if a.isCheckoutOrSwitchCommand(lastEntry.Command) {
-		a.logInfof("Last operation can't be undone. Use %sgit back%s instead.", yellowColor, resetColor)
+		a.logInfof("Last operation can't be undone. Use %s instead.", a.getTheme().highlight("git back"))
 		return nil
 	}
 
+	// The commands of an undo group are undone together (see logging.MetaGroup)
+	group, err := lgr.GetLastRegularGroup()
+	if err != nil {
+		return fmt.Errorf("failed to get the undo group of the last git command: %w", err)
+	}
+	if len(group) > 1 {
+		a.logInfof("Undoing a group of %d commands", len(group))
+		return a.undoEntries(ctx, lgr, g, opts, group)
+	}
+
 	return a.executeUndoOperation(ctx, lgr, g, opts, lastEntry, false)
 }
 
+// runUndoCount handles `git undo -n <count>`: the latest count entries of the current ref are undone,
+// newest first, as if `git undo` was run count times.
+func (a *App) runUndoCount(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	if absoluteLastEntry, err := lgr.GetLastEntry(); err == nil && absoluteLastEntry != nil &&
+		a.isCheckoutOrSwitchCommand(absoluteLastEntry.Command) {
+		a.logInfof("Last operation can't be undone. Use %s instead.", a.getTheme().highlight("git back"))
+		return nil
+	}
+
+	// The entries are picked upfront: with --dry-run nothing gets marked as undone in between
+	ref, _ := g.GetCurrentGitRef()
+	entries, err := lgr.GetEntries(opts.Count, func(e *logging.Entry) bool {
+		return !e.Undoed && !e.IsNavigation && (ref == "" || e.Ref.String() == ref)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get last git commands: %w", err)
+	}
+	if len(entries) == 0 {
+		a.logInfof("nothing to undo")
+		return nil
+	}
+	if len(entries) < opts.Count {
+		a.logInfof("Only %d commands can be undone", len(entries))
+	}
+
+	return a.undoEntries(ctx, lgr, g, opts, entries)
+}
+
+// undoEntries undoes the entries one by one (newest first), stopping at the first failure:
+// every entry is marked as undone right after its own undo, so the log always tells what was undone.
+func (a *App) undoEntries(
+	ctx context.Context,
+	lgr *logging.Logger,
+	g GitHelper,
+	opts RunOptions,
+	entries []*logging.Entry,
+) error {
+	for done, entry := range entries {
+		if err := a.executeUndoOperation(ctx, lgr, g, opts, entry, false); err != nil {
+			if done == 0 {
+				return err
+			}
+			return fmt.Errorf("undid %d of %d commands, then undoing `%s` failed (it and the rest stay done): %w",
+				done, len(entries), entry.Command, err)
+		}
+	}
+	return nil
+}
+
+// runUndoEntryID handles `git undo --id <ID>`: undoing a specific (not necessarily the latest) entry.
+func (a *App) runUndoEntryID(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	entry, err := lgr.GetEntryByID(opts.EntryID)
+	if err != nil {
+		return fmt.Errorf("failed to find entry %s: %w", opts.EntryID, err)
+	}
+	if entry == nil {
+		return fmt.Errorf("no entry with id %s found in the log", opts.EntryID)
+	}
+
+	return a.undoPickedEntry(ctx, lgr, g, opts, entry, opts.EntryID)
+}
+
+// runUndoAt handles `git undo --at <index|identifier>`: undoing the entry at the position in the log
+// (1 is the latest entry) or the entry with the identifier (a whole log line is accepted too).
+func (a *App) runUndoAt(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	var entry *logging.Entry
+	var err error
+	if index, convErr := strconv.Atoi(strings.TrimSpace(opts.At)); convErr == nil {
+		entry, err = lgr.GetEntryByIndex(index)
+	} else {
+		identifier := opts.At
+		if parsed, parseErr := logging.ParseLogLine(opts.At); parseErr == nil {
+			identifier = parsed.GetIdentifier()
+		}
+		entry, err = lgr.GetEntryByIdentifier(identifier)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find entry %s: %w", opts.At, err)
+	}
+	if entry == nil {
+		return fmt.Errorf("no entry at %s found in the log", opts.At)
+	}
+
+	return a.undoPickedEntry(ctx, lgr, g, opts, entry, opts.At)
+}
+
+// undoPickedEntry undoes the entry picked by the user (instead of the latest one). The commands run after it
+// on the same ref and still done may depend on its changes: they're listed, so the user knows what to check.
+func (a *App) undoPickedEntry(
+	ctx context.Context,
+	lgr *logging.Logger,
+	g GitHelper,
+	opts RunOptions,
+	entry *logging.Entry,
+	selector string,
+) error {
+	if entry.Undoed {
+		return fmt.Errorf("entry %s is already undone: %s", selector, entry.Command)
+	}
+	if entry.IsNavigation {
+		return fmt.Errorf("entry %s is a navigation command, use %s instead",
+			selector, a.getTheme().highlight("git back"))
+	}
+
+	// The log is newest first: the later entries are the ones before the picked one
+	reached := false
+	later, err := lgr.GetEntries(0, func(e *logging.Entry) bool {
+		reached = reached || e.GetIdentifier() == entry.GetIdentifier()
+		return !reached && !e.Undoed && !e.IsNavigation && e.Ref == entry.Ref
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+	if len(later) > 0 {
+		a.logWarnf("%d later command(s) on %s are still done and may conflict with undoing `%s`:",
+			len(later), entry.Ref, entry.Command)
+		for _, e := range later[:min(len(later), maxListedLaterEntries)] {
+			a.logWarnf("  %s %s", e.ID(), e.Command)
+		}
+		if len(later) > maxListedLaterEntries {
+			a.logWarnf("  ... and %d more", len(later)-maxListedLaterEntries)
+		}
+	}
+
+	return a.executeUndoOperation(ctx, lgr, g, opts, entry, false)
+}
+
+// maxListedLaterEntries is how many of the later commands are listed when an older entry is undone.
+const maxListedLaterEntries = 3
+
+// runBackTo handles `git back --to <ref>`: going back to a ref from the navigation history.
+func (a *App) runBackTo(_ context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	refs, err := a.navigationHistoryRefs(lgr, g)
+	if err != nil {
+		return fmt.Errorf("failed to read navigation history: %w", err)
+	}
+	if !slices.Contains(refs, opts.BackTo) {
+		return fmt.Errorf("%s is not found in the navigation history", opts.BackTo)
+	}
+
+	backCmd := undoer.NewUndoCommand(g,
+		"git checkout "+opts.BackTo,
+		"Switch back to "+opts.BackTo,
+	)
+	if opts.DryRun {
+		return a.showDryRunOutput(nil, []*undoer.UndoCommand{backCmd})
+	}
+
+	if err := backCmd.Exec(); err != nil {
+		return fmt.Errorf("failed to go back to %s: %w", opts.BackTo, err)
+	}
+
+	a.logDebugf(opts.Verbose, "Successfully went back to %s", opts.BackTo)
+	return nil
+}
+
+// navigationStack returns the navigation history as a stack, newest first: the checkout and switch entries
+// not undone yet, each on the ref it went to. Its first entry is where the latest navigation went (usually
+// the current ref), and going back N steps is going to the ref of its entry N.
+func navigationStack(lgr *logging.Logger) ([]*logging.Entry, error) {
+	entries, err := lgr.GetNavigationEntries(0, false)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(entries, func(e *logging.Entry) bool { return e.Ref == logging.RefUnknown }), nil
+}
+
+// runBackCount handles `git back -n <count>`: going back count steps of the navigation history.
+// The entries walked back through are marked as undoed, so the next steps continue from there.
+func (a *App) runBackCount(_ context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	stack, err := navigationStack(lgr)
+	if err != nil {
+		return fmt.Errorf("failed to read navigation history: %w", err)
+	}
+	if len(stack) <= opts.Count {
+		return fmt.Errorf("cannot go back %d steps: the navigation history has %d (see git back --list)",
+			opts.Count, max(len(stack)-1, 0))
+	}
+
+	target := stack[opts.Count].Ref.String()
+	backCmd := undoer.NewUndoCommand(g,
+		"git checkout "+target,
+		fmt.Sprintf("Go back %d steps to %s", opts.Count, target),
+	)
+	if opts.DryRun {
+		return a.showDryRunOutput(nil, []*undoer.UndoCommand{backCmd})
+	}
+
+	if err := backCmd.Exec(); err != nil {
+		return fmt.Errorf("failed to go back to %s: %w", target, err)
+	}
+	for _, entry := range stack[:opts.Count] {
+		if err := lgr.ToggleEntry(entry.GetIdentifier()); err != nil {
+			a.logWarnf("Failed to mark command as undoed: %v", err)
+		}
+	}
+
+	a.logDebugf(opts.Verbose, "Successfully went back %d steps to %s", opts.Count, target)
+	return nil
+}
+
+// runForward handles `git back --forward [-n <count>]`: going forward again through the navigation
+// walked back, like a browser does. The entries walked back through are the undoed ones on top of the
+// navigation history: a navigation after going back isn't undoed, so it drops them (there's no way forward
+// from there anymore). The entries walked forward through are marked as not undoed again.
+func (a *App) runForward(_ context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	entries, err := lgr.GetNavigationEntries(0, true)
+	if err != nil {
+		return fmt.Errorf("failed to read navigation history: %w", err)
+	}
+	var forward []*logging.Entry
+	for _, entry := range entries {
+		if !entry.Undoed {
+			break
+		}
+		forward = append(forward, entry)
+	}
+	if len(forward) == 0 {
+		a.logInfof("nothing to go forward to")
+		return nil
+	}
+
+	steps := max(opts.Count, 1)
+	if len(forward) < steps {
+		return fmt.Errorf("cannot go forward %d steps: only %d were gone back", steps, len(forward))
+	}
+	// The forward entries are newest first: the next step forward is the oldest one
+	walked := forward[len(forward)-steps:]
+	if walked[0].Ref == logging.RefUnknown {
+		return fmt.Errorf("cannot go forward: the ref %s went to is unknown", walked[0].Command)
+	}
+
+	target := walked[0].Ref.String()
+	forwardCmd := undoer.NewUndoCommand(g,
+		"git checkout "+target,
+		fmt.Sprintf("Go forward %d steps to %s", steps, target),
+	)
+	if opts.DryRun {
+		return a.showDryRunOutput(nil, []*undoer.UndoCommand{forwardCmd})
+	}
+
+	if err := forwardCmd.Exec(); err != nil {
+		return fmt.Errorf("failed to go forward to %s: %w", target, err)
+	}
+	for _, entry := range walked {
+		if err := lgr.ToggleEntry(entry.GetIdentifier()); err != nil {
+			a.logWarnf("Failed to mark command as not undoed: %v", err)
+		}
+	}
+
+	a.logDebugf(opts.Verbose, "Successfully went forward %d steps to %s", steps, target)
+	return nil
+}
+
+// cmdBackList handles `git back --list`: the navigation history, newest first, with the step count
+// `git back -n` goes back to each ref with (0 is where the latest navigation went).
+func (a *App) cmdBackList(lgr *logging.Logger) error {
+	stack, err := navigationStack(lgr)
+	if err != nil {
+		return fmt.Errorf("failed to read navigation history: %w", err)
+	}
+	if len(stack) == 0 {
+		a.logInfof("no checkout/switch commands found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "STEPS\tREF\tTIME\tCOMMAND")
+	for i, entry := range stack {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i, entry.Ref, entry.Timestamp.Format(time.DateTime),
+			entry.TypedCommand())
+	}
+	return w.Flush()
+}
+
+// navigationHistoryRefs returns refs that were left via navigation commands (newest first, unique),
+// excluding the current one. These are the refs `git back --to` can go back to.
+func (a *App) navigationHistoryRefs(lgr *logging.Logger, g GitHelper) ([]string, error) {
+	entries, err := lgr.GetEntries(0, func(e *logging.Entry) bool { return e.IsNavigation })
+	if err != nil {
+		return nil, err
+	}
+
+	currentRef, _ := g.GetCurrentGitRef()
+	var refs []string
+	for _, entry := range entries {
+		ref := entry.Ref.String()
+		if entry.Ref == logging.RefUnknown || ref == currentRef || slices.Contains(refs, ref) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
 // executeUndoOperation performs the actual undo operation for a given entry.
 func (a *App) executeUndoOperation(
 	ctx context.Context,
@@ -267,33 +1172,98 @@ func (a *App) executeUndoOperation(
 	lastEntry *logging.Entry,
 	isBackMode bool,
 ) error {
-	a.logDebugf(opts.Verbose, "Last git command[%s]: %s", lastEntry.Ref, yellowColor+lastEntry.Command+resetColor)
+	a.logDebugf(opts.Verbose, "Last git command[%s]: %s", lastEntry.Ref, a.getTheme().highlight(lastEntry.Command))
 
-	// Get the appropriate undoer
-	var u undoer.Undoer
-	if isBackMode {
-		u = undoer.NewBack(lastEntry.Command, g)
-	} else {
-		u = undoer.New(lastEntry.Command, g)
+	u, err := a.prepareUndoer(g, opts, lastEntry, isBackMode)
+	if err != nil {
+		return err
 	}
 
 	// Get the undo commands
-	undoCmds, err := u.GetUndoCommands()
+	undoCmds, err := undoCommands(u, lastEntry)
 	if err != nil {
-		return err
+		var unsupported *undoer.UnsupportedError
+		if errors.As(err, &unsupported) {
+			a.showSuggestion(unsupported.Suggestion)
+		}
+		return a.explainConsumedFixup(lgr, lastEntry, err)
+	}
+
+	partial := false
+	if len(opts.LimitPaths) > 0 {
+		if undoCmds, partial, err = a.limitUndoPaths(undoCmds, opts.LimitPaths); err != nil {
+			return err
+		}
 	}
 
+	if opts.Simulate && !isBackMode {
+		return a.simulateUndo(ctx, g, lastEntry, undoCmds)
+	}
 	if opts.DryRun {
-		return a.showDryRunOutput(opts, undoCmds)
+		return a.showDryRunOutput(lastEntry, undoCmds)
+	}
+
+	// From now on, the undo fails instead of clobbering refs moved by someone else (e.g. while confirming)
+	if err := undoer.LockRefs(undoCmds); err != nil {
+		return fmt.Errorf("failed to lock refs: %w", err)
+	}
+
+	plan := newUndoPlan(g, isBackMode, lastEntry, undoCmds)
+	if err := a.runPreUndoHook(ctx, plan); err != nil {
+		return err
+	}
+
+	if !isBackMode {
+		if err := a.confirmUndo(lastEntry, undoCmds); err != nil {
+			return err
+		}
+	}
+
+	// Keep the undone commit reachable, so redo can restore it exactly
+	if !isBackMode && a.isCommitCommand(lastEntry.Command) {
+		undone := "HEAD"
+		if commitUndoer, ok := u.(*undoer.CommitUndoer); ok {
+			undone = commitUndoer.UndoneCommit()
+		}
+		if err := keepAlive(g, lastEntry, undone); err != nil {
+			a.logWarnf("Failed to keep undone commit: %v", err)
+		}
 	}
 
+	headBeforeUndo := getHead(g)
+
 	// Execute the undo commands
-	if err := a.executeUndoCommands(ctx, opts, lastEntry, undoCmds); err != nil {
+	err = a.executeUndoCommands(ctx, g, opts, lastEntry, undoCmds)
+	a.runPostUndoHook(ctx, plan, err == nil)
+	if err != nil {
+		if errors.Is(err, ErrUndoRolledBack) {
+			// Nothing was undone: there is no undone commit to keep
+			dropKeptCommit(g, lastEntry)
+		}
+		a.recordUndo(lgr, lastEntry, undoCmds, err)
 		return err
 	}
 
-	// Mark the entry as undoed in the log
-	if err := lgr.ToggleEntry(lastEntry.GetIdentifier()); err != nil {
+	// The undo removed the repository, its git-undo log included: there is nothing left to update
+	if slices.ContainsFunc(undoCmds, func(undoCmd *undoer.UndoCommand) bool { return undoCmd.RemovesRepository }) {
+		a.logUndoSummary(opts, lastEntry, undoCmds)
+		return nil
+	}
+
+	if !isBackMode {
+		a.addUndoNote(g, lastEntry, headBeforeUndo)
+		a.writeAudit(lgr, g, lastEntry, undoCmds)
+		a.recordTracking(lgr, lastEntry, undoCmds)
+		if err := expireTrash(g, a.cfg.TrashRetention); err != nil {
+			a.logWarnf("failed to expire trash: %s", err)
+		}
+	}
+	a.recordUndo(lgr, lastEntry, undoCmds, nil)
+
+	// Mark the entry as undoed in the log (unless only some of its paths were undone)
+	if partial {
+		a.logInfof("Only part of the paths were undone: the command stays undoable")
+	} else if err := lgr.ToggleEntry(lastEntry.GetIdentifier()); err != nil {
 		a.logWarnf("Failed to mark command as undoed: %v", err)
 	}
 
@@ -302,33 +1272,149 @@ func (a *App) executeUndoOperation(
 	return nil
 }
 
-// showDryRunOutput displays what would be executed in dry-run mode.
-func (a *App) showDryRunOutput(opts RunOptions, undoCmds []*undoer.UndoCommand) error {
+// prepareUndoer returns the undoer of the entry set up with the options, once the undo is allowed by the policy.
+func (a *App) prepareUndoer(
+	g GitHelper,
+	opts RunOptions,
+	entry *logging.Entry,
+	isBackMode bool,
+) (undoer.Undoer, error) {
+	u := newUndoer(g, entry, isBackMode)
+	if opts.MessageOnly && !isBackMode {
+		commitUndoer, ok := u.(*undoer.CommitUndoer)
+		if !ok {
+			return nil, undoer.ErrNotAmend
+		}
+		if err := commitUndoer.RestoreMessageOnly(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.CommitMode != "" && !isBackMode {
+		commitUndoer, ok := u.(*undoer.CommitUndoer)
+		if !ok {
+			return nil, undoer.ErrNotCommit
+		}
+		if err := commitUndoer.SetMode(undoer.CommitMode(opts.CommitMode)); err != nil {
+			return nil, err
+		}
+	}
+
+	if forcer, ok := u.(interface{ ForcePushed() }); ok && opts.Force && !isBackMode {
+		forcer.ForcePushed()
+	}
+
+	if !isBackMode {
+		if err := a.checkUndoPolicy(g, entry); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// limitUndoPaths limits the undo commands to the affected paths matching the given globs.
+// It returns the limited commands and whether some of the affected paths were left out.
+func (a *App) limitUndoPaths(
+	undoCmds []*undoer.UndoCommand,
+	patterns []string,
+) ([]*undoer.UndoCommand, bool, error) {
+	var limited []*undoer.UndoCommand
+	partial := false
 	for _, undoCmd := range undoCmds {
-		a.logDebugf(opts.Verbose, "Would run: %s\n", undoCmd.Command)
-		if len(undoCmd.Warnings) > 0 {
-			for _, warning := range undoCmd.Warnings {
-				a.logWarnf("%s", warning)
-			}
+		total := len(undoCmd.Paths)
+		left, err := undoCmd.LimitPaths(patterns)
+		if err != nil {
+			return nil, false, err
+		}
+		if left < total {
+			partial = true
+		}
+		if left > 0 {
+			limited = append(limited, undoCmd)
+		}
+	}
+
+	if len(limited) == 0 {
+		return nil, false, fmt.Errorf("no affected paths match %s", strings.Join(patterns, ", "))
+	}
+	return limited, partial, nil
+}
+
+// showSuggestion displays the guidance for a command that can't be undone automatically.
+func (a *App) showSuggestion(suggestion undoer.Suggestion) {
+	if suggestion.Changed != "" {
+		a.logInfof("What it likely changed: %s", suggestion.Changed)
+	}
+	a.logInfof("Why it can't be undone automatically: %s", suggestion.Reason)
+	if len(suggestion.Recovery) > 0 {
+		a.logInfof("To recover manually:")
+		for _, recovery := range suggestion.Recovery {
+			a.logInfof("  %s", a.getTheme().highlight(recovery))
+		}
+	}
+}
+
+// showDryRunOutput displays the undo plan in dry-run mode: the entry being undone (nil when going back
+// to a ref), then each command with its description and warnings.
+func (a *App) showDryRunOutput(entry *logging.Entry, undoCmds []*undoer.UndoCommand) error {
+	theme := a.getTheme()
+	if entry != nil {
+		a.logInfof("Would undo %s (on %s, ID %s) by running:", theme.highlight(entry.TypedCommand()), entry.Ref,
+			entry.ID())
+	} else {
+		a.logInfof("Would run:")
+	}
+
+	for i, undoCmd := range undoCmds {
+		a.logInfof("  %d. %s", i+1, undoCmd.Description)
+		a.logInfof("     %s", theme.highlight(undoCmd.Command))
+		for _, warning := range undoCmd.Warnings {
+			a.logWarnf("     %s", warning)
 		}
 	}
 	return nil
 }
 
-// executeUndoCommands executes the list of undo commands.
+// ErrUndoRolledBack is returned when an undo command failed after others were executed:
+// they're rolled back, so the repository is left as it was before the undo.
+var ErrUndoRolledBack = errors.New("the undo commands already executed were rolled back")
+
+// executeUndoCommands executes the list of undo commands as a transaction: when one of them fails
+// (or the context is canceled) after others were executed, the repository is rolled back
+// to the state recorded before the first one (see undoTransaction).
 func (a *App) executeUndoCommands(
 	ctx context.Context,
+	g GitHelper,
 	opts RunOptions,
 	lastEntry *logging.Entry,
 	undoCmds []*undoer.UndoCommand,
 ) error {
-	for i, undoCmd := range undoCmds {
-		// TODO: at some point we can check ctx here for timeout/cancel/etc
-		_ = ctx
+	var tx *undoTransaction
+	removesRepository := slices.ContainsFunc(undoCmds, func(undoCmd *undoer.UndoCommand) bool {
+		return undoCmd.RemovesRepository
+	})
+	if len(undoCmds) > 1 && !removesRepository {
+		var err error
+		if tx, err = beginUndoTransaction(g); err != nil {
+			a.logDebugf(opts.Verbose, "The undo can't be rolled back if it fails: %v", err)
+		}
+	}
 
-		if err := undoCmd.Exec(); err != nil {
-			return fmt.Errorf("failed to execute undo command %d/%d %s via %s: %w",
+	for i, undoCmd := range undoCmds {
+		err := ctx.Err()
+		if err == nil {
+			err = undoCmd.Exec()
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to execute undo command %d/%d %s via %s: %w",
 				i+1, len(undoCmds), lastEntry.Command, undoCmd.Command, err)
+			if i == 0 || tx == nil {
+				return err
+			}
+			if rollbackErr := tx.rollback(); rollbackErr != nil {
+				return fmt.Errorf("%w (rolling back the %d commands already executed failed too: %w)",
+					err, i, rollbackErr)
+			}
+			return fmt.Errorf("%w: %w", ErrUndoRolledBack, err)
 		}
 		a.logDebugf(opts.Verbose, "Successfully executed undo command %d/%d: %s via %s",
 			i+1, len(undoCmds), lastEntry.Command, undoCmd.Command)
@@ -343,6 +1429,13 @@ func (a *App) executeUndoCommands(
 
 // logUndoSummary logs a summary message after successful undo operation.
 func (a *App) logUndoSummary(opts RunOptions, lastEntry *logging.Entry, undoCmds []*undoer.UndoCommand) {
+	// Path-wide operations get an aggregated summary of what was undone
+	for _, undoCmd := range undoCmds {
+		if len(undoCmd.Paths) > 1 || undoCmd.Report {
+			a.logInfof("%s", undoCmd.Description)
+		}
+	}
+
 	if len(undoCmds) == 1 {
 		a.logDebugf(opts.Verbose, "Successfully undid: %s via %s", lastEntry.Command, undoCmds[0].Command)
 	} else {
@@ -350,19 +1443,49 @@ func (a *App) logUndoSummary(opts RunOptions, lastEntry *logging.Entry, undoCmds
 	}
 }
 
-// ANSI escape code for gray color.
+// Subcommands of git-undo (besides self-management ones).
 const (
-	yellowColor = "\033[33m"
-	orangeColor = "\033[38;5;208m"
-	grayColor   = "\033[90m"
-	redColor    = "\033[31m"
-	resetColor  = "\033[0m"
+	// CommandStatus shows the state of git-undo in the current repository.
+	CommandStatus = "status"
+	// CommandNotes manages mirroring undo events as git notes.
+	CommandNotes = "notes"
+	// CommandDiff shows the changes between the states after two log entries.
+	CommandDiff = "diff"
+	// CommandPin protects a log entry from truncation/pruning.
+	CommandPin = "pin"
+	// CommandUnpin removes the protection of a pinned log entry.
+	CommandUnpin = "unpin"
+	// CommandGC removes old undone entries, expired snapshots and stale files, and compacts the log.
+	CommandGC = "gc"
+	// CommandTrash lists and restores branches soft deleted by undo operations.
+	CommandTrash = "trash"
+	// CommandCheckpoint saves, lists and deletes named states of the repository.
+	CommandCheckpoint = "checkpoint"
+	// CommandRestore brings the repository back to a checkpoint.
+	CommandRestore = "restore"
+	// CommandDoctor checks (and with --fix repairs) the git-undo setup of the repository.
+	CommandDoctor = "doctor"
+	// CommandWeb serves a local web dashboard of the undo history.
+	CommandWeb = "web"
+	// CommandServe serves the undo history and the undo over a JSON protocol (stdio or a unix socket).
+	CommandServe = "serve"
+	// CommandHistory shows the log entries filtered by branch, worktree, author and time.
+	CommandHistory = "history"
+	// CommandStats sums up the log: the most undone commands, undos and redos per day, the most active refs.
+	CommandStats = "stats"
+	// CommandLog shows the log entries in columns, filtered by ref, type, state and time.
+	CommandLog = "log"
+	// CommandConfig shows and changes git-undo settings.
+	CommandConfig = "config"
+	// CommandCompletion prints the shell completion script of git undo and git back.
+	CommandCompletion = "completion"
 )
 
 // Application names.
 const (
 	appNameGitUndo = "git-undo"
 	appNameGitBack = "git-back"
+	appNameGitRedo = "git-redo"
 )
 
 // getAppName returns the appropriate app name based on mode.
@@ -370,6 +1493,9 @@ func (a *App) getAppName() string {
 	if a.isBackMode {
 		return appNameGitBack
 	}
+	if a.isRedoMode {
+		return appNameGitRedo
+	}
 	return appNameGitUndo
 }
 
@@ -383,68 +1509,281 @@ func (a *App) isCheckoutOrSwitchCommand(command string) bool {
 	return gitCmd.IsCheckoutOrSwitch()
 }
 
-// logDebugf writes debug messages to stderr when verbose mode is enabled.
-func (a *App) logDebugf(verbose bool, format string, args ...any) {
-	if !verbose {
-		return
+// isCommitCommand checks if a command is a git commit command.
+func (a *App) isCommitCommand(command string) bool {
+	gitCmd, err := githelpers.ParseGitCommand(command)
+	if err != nil {
+		return false
 	}
 
-	_, _ = fmt.Fprintf(os.Stderr, yellowColor+a.getAppName()+" ⚙️: "+grayColor+format+resetColor+"\n", args...)
+	return gitCmd.Name == "commit"
+}
+
+// logDebugf writes debug messages to stderr when verbose mode is enabled (and to the debug log).
+func (a *App) logDebugf(verbose bool, format string, args ...any) {
+	a.logger(verbose).Debug(fmt.Sprintf(format, args...))
 }
 
 // logErrorf writes error messages to stderr.
 func (a *App) logErrorf(format string, args ...any) {
-	_, _ = fmt.Fprintf(os.Stderr, redColor+a.getAppName()+" ❌️: "+grayColor+format+resetColor+"\n", args...)
+	a.logger(false).Error(fmt.Sprintf(format, args...))
 }
 
 // logWarnf writes warning (soft error) messages to stderr.
 func (a *App) logWarnf(format string, args ...any) {
-	_, _ = fmt.Fprintf(os.Stderr, orangeColor+a.getAppName()+" ⚠️: "+grayColor+format+resetColor+"\n", args...)
+	a.logger(false).Warn(fmt.Sprintf(format, args...))
+}
+
+// InDir makes the app work in the repository of the given directory (the current one by default).
+func (a *App) InDir(dir string) *App {
+	a.dir = dir
+	return a
+}
+
+// SetMessages makes the app write its messages (info, warnings, errors) to w instead of stderr:
+// e.g. io.Discard when the app is embedded.
+func (a *App) SetMessages(w io.Writer) {
+	a.messages = w
+}
+
+// AssumeConfirmed makes the undo run without asking for confirmation: the caller already confirmed
+// the commands (e.g. it showed the plan returned by PlanUndo).
+func (a *App) AssumeConfirmed() {
+	a.confirmed = true
+}
+
+// getMessages returns the writer messages are written to.
+func (a *App) getMessages() io.Writer {
+	if a.messages != nil {
+		return a.messages
+	}
+	return os.Stderr
 }
 
 // logInfof writes info messages to stderr.
 func (a *App) logInfof(format string, args ...any) {
-	_, _ = fmt.Fprintf(os.Stderr, yellowColor+a.getAppName()+" ℹ️: "+grayColor+format+resetColor+"\n", args...)
+	a.logger(false).Info(fmt.Sprintf(format, args...))
 }
 
-func (a *App) cmdHook(lgr *logging.Logger, verbose bool, hooked string) error {
+// cmdHook logs the hooked git command. The logger is only created once the command is known to be logged,
+// so the hook (called after every git command) doesn't touch the git dir for read-only commands.
+// Commands typed in shells with non-POSIX quoting are logged re-quoted, as the log is always parsed POSIX-wise.
+func (a *App) cmdHook(gitDir string, g GitHelper, verbose bool, hooked, shell string) error {
 	a.logDebugf(verbose, "hook: start")
 
+	typed := strings.TrimSpace(hooked)
+	hooked, gitCmd, err := a.hookedCommand(gitDir, g, verbose, hooked, shell)
+	if err != nil || gitCmd == nil {
+		return err
+	}
+
+	lgr, err := a.newLogger(gitDir, g)
+	if err != nil {
+		return err
+	}
+	lgr.SetDebugLogger(a.logger(verbose))
+	if a.cfg != nil {
+		lgr.SetMaxPerMinute(a.cfg.MaxPerMinute)
+		lgr.SetMaxEntries(a.cfg.MaxLogEntries)
+		lgr.SetGroupWindow(a.cfg.GroupWindow)
+	}
+	meta := captureState(g)
+	if author := currentAuthor(g); author != "" {
+		meta[logging.MetaAuthor] = author
+	}
+	if gitCmd.Dir != "" {
+		if dir, err := g.GitOutput("rev-parse", "--show-prefix"); err == nil && dir != "" {
+			meta[logging.MetaDir] = strings.TrimSuffix(dir, "/")
+		}
+	}
+	if gitCmd.Alias != "" {
+		meta[logging.MetaTyped] = typed
+	}
+	maps.Copy(meta, lgr.TakePreState(hooked))
+	if err := lgr.LogCommandWithMeta(hooked, meta); err != nil {
+		var ownershipErr *logging.OwnershipError
+		if errors.As(err, &ownershipErr) {
+			a.logWarnf("%q is not logged: %v", hooked, ownershipErr)
+			return nil
+		}
+		return fmt.Errorf("failed to log command: %w", err)
+	}
+	a.annotateFixup(lgr, g, gitCmd)
+	a.annotateAmend(lgr, g, gitCmd)
+	a.annotateStash(lgr, g, gitCmd, nil)
+	if entry := lgr.LastLogged(); entry != nil && a.cfg != nil && a.cfg.GlobalJournal {
+		a.recordGlobalJournal(g, gitDir, verbose, entry)
+	}
+	a.autoGC(gitDir, g, lgr, verbose)
+
+	a.logDebugf(verbose, "hook: prepended %q", hooked)
+	return nil
+}
+
+// cmdHookFailed logs the hooked git command that failed, when enabled via config (see logging.Entry.Failed).
+func (a *App) cmdHookFailed(gitDir string, g GitHelper, verbose bool, hooked, shell string) error {
+	if a.cfg == nil || !a.cfg.LogFailed {
+		a.logDebugf(verbose, "hook: skipping as a failed command")
+		return nil
+	}
+
+	typed := strings.TrimSpace(hooked)
+	hooked, gitCmd, err := a.hookedCommand(gitDir, g, verbose, hooked, shell)
+	if err != nil || gitCmd == nil {
+		return err
+	}
+
+	lgr, err := a.newLogger(gitDir, g)
+	if err != nil {
+		return err
+	}
+	lgr.SetDebugLogger(a.logger(verbose))
+	// The state captured before the command is of no use: the failed command is never undone
+	lgr.TakePreState(hooked)
+	meta := map[string]string{}
+	if author := currentAuthor(g); author != "" {
+		meta[logging.MetaAuthor] = author
+	}
+	if gitCmd.Alias != "" {
+		meta[logging.MetaTyped] = typed
+	}
+	if err := lgr.LogFailedCommand(hooked, meta); err != nil {
+		return fmt.Errorf("failed to log command: %w", err)
+	}
+
+	a.logDebugf(verbose, "hook: logged failed %q", hooked)
+	return nil
+}
+
+// noWorkTreeError explains that the app is disabled in the repository as it has no work tree
+// (see githelpers.H.CheckWorkTree).
+func noWorkTreeError(appName string, err error) error {
+	return fmt.Errorf("%s is disabled %w: there's no work tree to undo commands in", appName, err)
+}
+
+// hookedGitHelper returns the git helper of the repository the hooked command ran in:
+// its -C, --git-dir and --work-tree options locate it instead of the working dir.
+func (a *App) hookedGitHelper(ctx context.Context, g *githelpers.H, hooked, shell string) *githelpers.H {
+	gitCmd, err := githelpers.ParseGitCommandWith(strings.TrimSpace(hooked), githelpers.QuotingFor(shell))
+	if err != nil {
+		return g
+	}
+	if gitCmd.Dir != "" {
+		dir := gitCmd.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(a.dir, dir)
+		}
+		g = githelpers.NewGitHelper(ctx, dir)
+	}
+	if gitCmd.GitDir == "" && gitCmd.WorkTree == "" {
+		return g
+	}
+	return g.WithLocation(gitCmd.GitDir, gitCmd.WorkTree)
+}
+
+// hookedCommand parses the hooked git command, returning it normalized (see cmdHook) if it is to be logged
+// (a nil command otherwise). Aliases are logged expanded: they may be redefined (or gone) by the time
+// the command is undone.
+func (a *App) hookedCommand(
+	gitDir string,
+	g GitHelper,
+	verbose bool,
+	hooked, shell string,
+) (string, *githelpers.GitCommand, error) {
 	if !a.getIsInternalCall() {
-		return errors.New("hook must be called from inside shell script (bash/zsh hook)")
+		return "", nil, errors.New("hook must be called from inside shell script (bash/zsh hook)")
 	}
 
 	hooked = strings.TrimSpace(hooked)
 
-	gitCmd, err := githelpers.ParseGitCommand(hooked)
+	quoting := githelpers.QuotingFor(shell)
+	gitCmd, err := githelpers.ParseGitCommandWith(hooked, quoting)
+	if err == nil {
+		gitCmd = gitCmd.ExpandAlias(g.GetAlias)
+	}
+	if _, posix := quoting.(githelpers.PosixQuoting); err == nil && (!posix || gitCmd.Alias != "") {
+		words := slices.Concat([]string{"git"}, gitCmd.GlobalOptions, []string{gitCmd.Name}, gitCmd.Args)
+		hooked = githelpers.QuotePosix(words)
+	}
 	if err != nil || !gitCmd.Supported {
 		// This should not happen in a success path
 		// because the zsh script should only send non-failed (so valid) git command
 		// but just in case let's re-validate again here
 		a.logDebugf(verbose, "hook: skipping as invalid git command %q", hooked)
-		return nil //nolint:nilerr // We're fine with this
+		return "", nil, nil
 	}
 	if !logging.ShouldBeLogged(gitCmd) {
 		a.logDebugf(verbose, "hook: skipping as a read-only command: %q", hooked)
-		return nil
+		return "", nil, nil
 	}
-
-	if err := lgr.LogCommand(hooked); err != nil {
-		return fmt.Errorf("failed to log command: %w", err)
+	if a.cfg != nil && a.cfg.IsCommandIgnored(gitCmd.Name, gitCmd.Args) {
+		a.logDebugf(verbose, "hook: skipping as an ignored command: %q", hooked)
+		return "", nil, nil
+	}
+	if reason, background := logging.BackgroundInvocation(); background {
+		a.logDebugf(verbose, "hook: skipping as a background git process (%s): %q", reason, hooked)
+		return "", nil, nil
 	}
 
-	a.logDebugf(verbose, "hook: prepended %q", hooked)
-	return nil
+	// The git command itself succeeded: a log owned by another user (e.g. after `sudo git`) only gets a warning
+	if err := checkLogOwnership(gitDir); err != nil {
+		a.logWarnf("%q is not logged: %v", hooked, err)
+		return "", nil, nil
+	}
+	return hooked, gitCmd, nil
 }
 
 // cmdLog displays the git-undo command log.
+// The accessible theme gets one linear line per entry instead of the raw log format.
 func (a *App) cmdLog(lgr *logging.Logger) error {
-	return lgr.Dump(os.Stdout)
+	if !a.getTheme().accessible {
+		return lgr.Dump(os.Stdout)
+	}
+
+	return lgr.ProcessLogFile(func(line string) bool {
+		if entry, err := logging.ParseLogLine(line); err == nil {
+			_, _ = fmt.Fprintln(os.Stdout, describeEntry(entry))
+		}
+		return true
+	})
+}
+
+// describeEntry renders the entry for screen readers: its state as a word first,
+// then the command, where and when it ran, and its ID (e.g. "UNDONE: git add a.txt; on main; at ...; ID abc1234").
+func describeEntry(entry *logging.Entry) string {
+	state := "DONE"
+	switch {
+	case entry.Failed:
+		state = "FAILED"
+	case entry.Summary:
+		state = "SUMMARY"
+	case entry.Undoed:
+		state = "UNDONE"
+	case entry.IsNavigation:
+		state = "NAVIGATION"
+	}
+
+	parts := []string{
+		state + ": " + entry.TypedCommand(),
+		"on " + entry.Ref.String(),
+		"at " + entry.Timestamp.Format(time.DateTime),
+		"ID " + entry.ID(),
+	}
+	if entry.IsPinned() {
+		parts = append(parts, "PINNED")
+	}
+	return strings.Join(parts, "; ")
 }
 
 // HandleError prints error messages and exits with status code 1.
 func HandleError(appName string, err error) {
-	_, _ = fmt.Fprintln(os.Stderr, redColor+appName+" ❌: "+grayColor+err.Error()+resetColor)
+	theme := defaultOutputTheme()
+	if cfg, cfgErr := config.Load(githelpers.NewGitHelper(context.Background())); cfgErr == nil {
+		theme, _ = newOutputTheme(themeName(cfg))
+		theme.colors = theme.colors && cfg.Color
+	}
+
+	theme.printf(os.Stderr, appName, levelError, "%s", err.Error())
 	os.Exit(1)
 }
 
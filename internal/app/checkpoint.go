@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/checkpoint"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// Subcommands of `git undo checkpoint`.
+const (
+	CheckpointSave   = "save"
+	CheckpointList   = "list"
+	CheckpointDelete = "delete"
+)
+
+// cmdCheckpoint handles `git undo checkpoint [list]`, `git undo checkpoint save <name>`
+// and `git undo checkpoint delete <name>`: named states of the repository `git undo restore` goes back to.
+func (a *App) cmdCheckpoint(gitDir string, lgr *logging.Logger, g GitHelper, args []string) error {
+	store := checkpoint.NewStore(gitDir)
+	if len(args) == 0 || args[0] == CheckpointList {
+		return a.listCheckpoints(store)
+	}
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: git undo checkpoint %s <name>", args[0])
+	}
+	switch args[0] {
+	case CheckpointSave:
+		return a.saveCheckpoint(store, lgr, g, args[1])
+	case CheckpointDelete:
+		if err := store.Delete(args[1]); err != nil {
+			return err
+		}
+		a.logInfof("Deleted checkpoint %s", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown checkpoint command: %s", args[0])
+	}
+}
+
+// saveCheckpoint records the current HEAD, index and log position under the name.
+func (a *App) saveCheckpoint(store *checkpoint.Store, lgr *logging.Logger, g GitHelper, name string) error {
+	ref, err := g.GetCurrentGitRef()
+	if err != nil {
+		return fmt.Errorf("failed to get the current ref: %w", err)
+	}
+	state := captureState(g)
+	cp := &checkpoint.Checkpoint{
+		Name:  name,
+		Time:  time.Now(),
+		Ref:   ref,
+		Head:  state[logging.MetaHead],
+		Index: state[logging.MetaIndex],
+	}
+	last, err := lgr.GetLastRegularEntry(logging.Ref(ref))
+	if err != nil {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+	if last != nil {
+		cp.Entry = last.GetIdentifier()
+	}
+
+	if err := store.Save(cp); err != nil {
+		if errors.Is(err, checkpoint.ErrExists) {
+			return fmt.Errorf("%w (delete it first: git undo checkpoint %s %s)", err, CheckpointDelete, name)
+		}
+		return err
+	}
+	a.logInfof("Saved checkpoint %s on %s at %s", name, ref, shortHash(cp.Head))
+	return nil
+}
+
+// listCheckpoints prints the checkpoints, the most recent ones first.
+func (a *App) listCheckpoints(store *checkpoint.Store) error {
+	checkpoints, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		a.logInfof("No checkpoints: save one with git undo checkpoint %s <name>", CheckpointSave)
+		return nil
+	}
+
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		cp := checkpoints[i]
+		_, _ = fmt.Fprintf(os.Stdout, "%s\t%s at %s (saved %s)\n",
+			cp.Name, cp.Ref, shortHash(cp.Head), cp.Time.Format(time.DateTime))
+	}
+	return nil
+}
+
+// cmdRestore handles `git undo restore <name>`: the commands logged on the branch since the checkpoint
+// are undone, newest first, as repeated `git undo` would. If HEAD or the index still differ from the checkpoint
+// (e.g. the log was truncated, or an undo can't bring the exact state back), they are reset to it;
+// the files of the working tree are kept.
+func (a *App) cmdRestore(ctx context.Context, gitDir string, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	if len(opts.Args) < 2 {
+		return errors.New("usage: git undo restore <name>")
+	}
+	cp, err := checkpoint.NewStore(gitDir).Get(opts.Args[1])
+	if err != nil {
+		return err
+	}
+	if ref, _ := g.GetCurrentGitRef(); ref != cp.Ref {
+		return fmt.Errorf("checkpoint %s was saved on %s: switch to it first", cp.Name, cp.Ref)
+	}
+
+	later, found, err := entriesSince(lgr, cp)
+	if err != nil {
+		return err
+	}
+	if !found {
+		a.logWarnf("the log position of checkpoint %s is gone from the log: HEAD and the index are reset instead",
+			cp.Name)
+		later = nil
+	}
+
+	if opts.DryRun {
+		for _, entry := range later {
+			a.logInfof("Would undo: %s", a.getTheme().highlight(entry.Command))
+		}
+		a.logInfof("Would reset HEAD and the index to %s if they still differ", shortHash(cp.Head))
+		return nil
+	}
+
+	if len(later) > 0 {
+		a.logInfof("Undoing %d commands run since checkpoint %s", len(later), cp.Name)
+		if err := a.undoEntries(ctx, lgr, g, opts, later); err != nil {
+			return err
+		}
+	}
+	if err := a.resetToCheckpoint(g, cp); err != nil {
+		return err
+	}
+	a.logInfof("Restored checkpoint %s", cp.Name)
+	return nil
+}
+
+// entriesSince returns the entries done on the branch of the checkpoint since it was saved, newest first.
+// found is false when the log position of the checkpoint is no longer in the log.
+func entriesSince(lgr *logging.Logger, cp *checkpoint.Checkpoint) ([]*logging.Entry, bool, error) {
+	// The log is newest first: the later entries are the ones before the checkpoint one
+	reached := cp.Entry == ""
+	later, err := lgr.GetEntries(0, func(e *logging.Entry) bool {
+		reached = reached || e.GetIdentifier() == cp.Entry
+		return !reached && !e.Undoed && !e.IsNavigation && e.Ref.String() == cp.Ref
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the log: %w", err)
+	}
+	if cp.Entry == "" {
+		// Nothing was logged on the branch before the checkpoint: every entry is a later one
+		return later, true, nil
+	}
+	return later, reached, nil
+}
+
+// resetToCheckpoint resets HEAD and the index to the ones of the checkpoint, unless they match already.
+// The working tree is left alone: what differs from the checkpoint shows up as unstaged changes.
+func (a *App) resetToCheckpoint(g GitHelper, cp *checkpoint.Checkpoint) error {
+	if cp.Head == "" {
+		return nil
+	}
+	state := captureState(g)
+	if state[logging.MetaHead] == cp.Head && (cp.Index == "" || state[logging.MetaIndex] == cp.Index) {
+		return nil
+	}
+
+	if state[logging.MetaHead] != cp.Head && a.cfg != nil && a.cfg.IsBranchProtected(cp.Ref) {
+		return fmt.Errorf("branch %s is protected: restoring checkpoint %s would rewrite its history", cp.Ref, cp.Name)
+	}
+	if err := g.GitRun("reset", "--soft", cp.Head); err != nil {
+		return fmt.Errorf("failed to reset HEAD to %s: %w", shortHash(cp.Head), err)
+	}
+	if cp.Index != "" {
+		if err := g.GitRun("read-tree", cp.Index); err != nil {
+			return fmt.Errorf("failed to reset the index: %w", err)
+		}
+	}
+	a.logInfof("Reset HEAD and the index to %s (the working tree files were kept)", shortHash(cp.Head))
+	return nil
+}
+
+// shortHash returns the abbreviated commit hash (as git shows it by default).
+func shortHash(hash string) string {
+	if hash == "" {
+		return "(no commits)"
+	}
+	return hash[:min(len(hash), 7)]
+}
+
+// checkpointNames returns the names of the checkpoints, for completion.
+func checkpointNames(gitDir string) []string {
+	checkpoints, _ := checkpoint.NewStore(gitDir).List()
+	names := make([]string, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		names = append(names, cp.Name)
+	}
+	return names
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// Shell completion query kinds (values of the hidden --complete flag).
+const (
+	// CompleteEntryIDs lists recent undoable entry IDs (for `git undo --id <TAB>`).
+	CompleteEntryIDs = "ids"
+	// CompleteNavigationRefs lists refs from the navigation history (for `git back --to <TAB>`).
+	CompleteNavigationRefs = "refs"
+	// CompleteEntryIdentifiers lists recent undoable entry identifiers (for `git undo --at <TAB>`).
+	CompleteEntryIdentifiers = "identifiers"
+	// CompleteCheckpoints lists the names of the checkpoints (for `git undo restore <TAB>`).
+	CompleteCheckpoints = "checkpoints"
+)
+
+// completionEntriesLimit limits how many entries are offered as completion candidates.
+const completionEntriesLimit = 20
+
+// cmdComplete answers shell completion queries. Output is one candidate per line
+// in the `<value>\t<description>` format (description is optional).
+// It must stay fast and never write anything: it's called on every <TAB> press.
+func (a *App) cmdComplete(ctx context.Context, kind string) error {
+	g := githelpers.NewGitHelper(ctx, a.dir)
+
+	gitDir, err := g.GetRepoGitDir()
+	if err != nil {
+		// No candidates outside a git repository
+		return nil //nolint:nilerr // We're fine with this
+	}
+	lgr := a.newReadOnlyLogger(gitDir, g)
+
+	switch kind {
+	case CompleteEntryIDs, CompleteEntryIdentifiers:
+		entries, err := lgr.GetEntries(completionEntriesLimit, func(e *logging.Entry) bool {
+			return !e.Undoed && !e.IsNavigation
+		})
+		if err != nil {
+			return nil //nolint:nilerr // completion must never fail loudly
+		}
+		for _, entry := range entries {
+			value := entry.ID()
+			if kind == CompleteEntryIdentifiers {
+				value = entry.GetIdentifier()
+			}
+			_, _ = fmt.Fprintf(os.Stdout, "%s\t%s (%s)\n", value, entry.TypedCommand(), entry.Ref)
+		}
+	case CompleteNavigationRefs:
+		refs, err := a.navigationHistoryRefs(lgr, g)
+		if err != nil {
+			return nil //nolint:nilerr // completion must never fail loudly
+		}
+		for _, ref := range refs {
+			_, _ = fmt.Fprintln(os.Stdout, ref)
+		}
+	case CompleteCheckpoints:
+		for _, name := range checkpointNames(gitDir) {
+			_, _ = fmt.Fprintln(os.Stdout, name)
+		}
+	default:
+		return fmt.Errorf("unknown completion kind: %s", kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gitundoembeds "github.com/amberpixels/git-undo"
+)
+
+// Shells `git undo completion` prints completion scripts for.
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+// completionScripts are the completion scripts by shell: each covers both `git undo` and `git back`.
+var completionScripts = map[string]func() string{
+	ShellBash: gitundoembeds.GetBashCompletion,
+	ShellZsh:  gitundoembeds.GetZshCompletion,
+	ShellFish: gitundoembeds.GetFishCompletion,
+}
+
+// cmdCompletion handles `git undo completion [bash|zsh|fish]`: prints the completion script of the shell
+// (the one of $SHELL when not given), e.g. to be loaded with `source <(git undo completion bash)`.
+func (a *App) cmdCompletion(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: git undo completion [bash|zsh|fish]")
+	}
+
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if len(args) == 1 {
+		shell = args[0]
+	}
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (supported: %s, %s, %s)", shell, ShellBash, ShellZsh, ShellFish)
+	}
+
+	_, _ = fmt.Fprint(os.Stdout, script())
+	return nil
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+)
+
+// Subcommands of `git undo config`.
+const (
+	ConfigList  = "list"
+	ConfigGet   = "get"
+	ConfigSet   = "set"
+	ConfigUnset = "unset"
+)
+
+// cmdConfig handles `git undo config [list]`, `git undo config get <key>`, `git undo config set <key> <value>`
+// and `git undo config unset <key>`: settings are validated before being written to the repository's git config,
+// and listed with where their effective values come from.
+func (a *App) cmdConfig(g GitHelper, args []string) error {
+	if len(args) == 0 || args[0] == ConfigList {
+		return a.listConfig(g)
+	}
+
+	switch args[0] {
+	case ConfigGet:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: git undo config %s <key>", ConfigGet)
+		}
+		return a.getConfig(g, args[1])
+	case ConfigSet:
+		if len(args) != 3 {
+			return fmt.Errorf("usage: git undo config %s <key> <value>", ConfigSet)
+		}
+		return a.setConfig(g, args[1], args[2])
+	case ConfigUnset:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: git undo config %s <key>", ConfigUnset)
+		}
+		return a.unsetConfig(g, args[1])
+	default:
+		return fmt.Errorf("unknown config command: %s", args[0])
+	}
+}
+
+// listConfig prints the effective settings with their origins.
+func (a *App) listConfig(g GitHelper) error {
+	settings, err := config.Settings(g)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, setting := range settings {
+		_, _ = fmt.Fprintf(w, "%s.%s\t%s\t%s\n", config.Section, setting.Key, setting.Value, setting.Origin)
+	}
+	return w.Flush()
+}
+
+// getConfig prints the effective value of the key (every value of multi-valued keys, one per line).
+func (a *App) getConfig(g GitHelper, key string) error {
+	info, ok := config.LookupKey(key)
+	if !ok {
+		return unknownSettingError(key)
+	}
+	settings, err := config.Settings(g)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, setting := range settings {
+		if setting.Key == info.Name {
+			_, _ = fmt.Fprintln(os.Stdout, setting.Value)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s.%s is not set", config.Section, info.Name)
+	}
+	return nil
+}
+
+// setConfig validates the value and writes it to the repository's git config
+// (multi-valued keys get one more value).
+func (a *App) setConfig(g GitHelper, key, value string) error {
+	info, ok := config.LookupKey(key)
+	if !ok {
+		return unknownSettingError(key)
+	}
+	if err := config.Validate(info.Name, value); err != nil {
+		return err
+	}
+
+	name := config.Section + "." + info.Name
+	args := []string{name, value}
+	if info.Multi {
+		args = append([]string{"--add"}, args...)
+	}
+	if err := g.GitRun("config", args...); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+
+	if info.Multi {
+		a.logInfof("Added %s to %s", value, name)
+	} else {
+		a.logInfof("Set %s to %s", name, value)
+	}
+	a.warnConfigOverridden(g, info)
+	return nil
+}
+
+// unsetConfig removes every value of the key from the repository's git config.
+func (a *App) unsetConfig(g GitHelper, key string) error {
+	info, ok := config.LookupKey(key)
+	if !ok {
+		return unknownSettingError(key)
+	}
+
+	name := config.Section + "." + info.Name
+	// Unsetting a missing key fails: that's fine, it's unset anyway
+	_ = g.GitRun("config", "--unset-all", name)
+	a.logInfof("Unset %s", name)
+	a.warnConfigOverridden(g, info)
+	return nil
+}
+
+// warnConfigOverridden warns when git config doesn't decide the value of the (single-valued) key anymore:
+// the config file of the repository and the environment win over it.
+func (a *App) warnConfigOverridden(g GitHelper, info config.KeyInfo) {
+	gitDir, err := g.GitOutput("rev-parse", "--absolute-git-dir")
+	if info.Multi || err != nil {
+		return
+	}
+	settings, err := config.Settings(g)
+	if err != nil {
+		return
+	}
+	for _, setting := range settings {
+		if setting.Key == info.Name &&
+			(setting.Origin == "file:"+config.FilePath(gitDir) || strings.HasPrefix(setting.Origin, "env:")) {
+			a.logWarnf("%s.%s is still %s as set by %s", config.Section, info.Name, setting.Value, setting.Origin)
+		}
+	}
+}
+
+// unknownSettingError reports a key that isn't a supported setting.
+func unknownSettingError(key string) error {
+	return fmt.Errorf("unknown setting: %s (see git undo config %s)", key, ConfigList)
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// crashReportLogEntries is how many of the latest log entries go into a crash report.
+const crashReportLogEntries = 20
+
+// issuesURL is where users are asked to report crashes.
+const issuesURL = "https://github.com/amberpixels/git-undo/issues"
+
+// Redaction rules of crash reports, on top of the redact config: commands may carry credentials (in remote URLs)
+// or private text (messages, given as `-m msg`, `-mmsg`, `--message msg` or `--message=msg`).
+var (
+	urlCredentialsRe = regexp.MustCompile(`(\w+://)[^/\s@]+@`)
+	messageArgRe     = regexp.MustCompile(`(\s(?:-m\s*|--message(?:=|\s+)))(.*?)(\s+-\S|$)`)
+	authHeaderRe     = regexp.MustCompile(`(?i)(authorization:?\s*(?:basic|bearer|token)?\s*)\S+`)
+)
+
+// redact removes credentials and messages from a command line.
+// Logged messages aren't quoted, so a message spans up to the next option (or the end of the line).
+func redact(text string) string {
+	text = urlCredentialsRe.ReplaceAllString(text, "$1<redacted>@")
+	text = messageArgRe.ReplaceAllString(text, "$1<redacted>$3")
+	return authHeaderRe.ReplaceAllString(text, "$1<redacted>")
+}
+
+// reportCrash saves a crash report of the recovered panic and returns the error telling the user where it is.
+func (a *App) reportCrash(ctx context.Context, recovered any, stack []byte) error {
+	path, err := a.writeCrashReport(ctx, recovered, stack)
+	if err != nil {
+		return fmt.Errorf("crashed: %v (failed to save a crash report: %w)", recovered, err)
+	}
+	return fmt.Errorf("crashed: %v (a crash report was saved to %s: please attach it to an issue at %s)",
+		recovered, path, issuesURL)
+}
+
+// writeCrashReport writes the crash report into the git-undo dir of the repository as crash-<timestamp>.log
+// (or into the temp dir outside of repositories) and returns its path.
+// It has the panic with its stack, the version, the invocation and the latest log entries, all redacted
+// (with the redact config as well).
+func (a *App) writeCrashReport(ctx context.Context, recovered any, stack []byte) (string, error) {
+	g := githelpers.NewGitHelper(ctx, a.dir)
+	// The config is loaded again: the crash may have happened before it was
+	var redactor logging.Redactor
+	if cfg, err := config.Load(g); err == nil {
+		redactor = logging.NewRedactor(cfg.Redact)
+	}
+	redactAll := func(text string) string { return redact(redactor.Redact(text)) }
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "%s crash report\n\n", a.getAppName())
+	fmt.Fprintf(&report, "Time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&report, "Version: %s (%s)\n", a.version, a.versionSource)
+	fmt.Fprintf(&report, "Go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&report, "Git: %s\n", githelpers.InstalledGitVersion())
+	fmt.Fprintf(&report, "Invocation: %s\n\n", redactAll(strings.Join(os.Args, " ")))
+	fmt.Fprintf(&report, "Panic: %s\n\n%s\n", redactAll(fmt.Sprint(recovered)), stack)
+
+	dir := os.TempDir()
+	if gitDir, err := g.GetRepoGitDir(); err == nil {
+		dir = filepath.Join(gitDir, "git-undo")
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+
+		fmt.Fprintf(&report, "\nLatest log entries (redacted):\n")
+		count := 0
+		err := logging.NewReadOnlyLogger(gitDir, g).ProcessLogFile(func(line string) bool {
+			report.WriteString(redactAll(line) + "\n")
+			count++
+			return count < crashReportLogEntries
+		})
+		if err != nil {
+			fmt.Fprintf(&report, "failed to read the log: %s\n", err)
+		}
+	}
+
+	path := filepath.Join(dir, "crash-"+time.Now().Format("20060102-150405")+".log")
+	if err := os.WriteFile(path, []byte(report.String()), 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// debugLogFileName is the debug log in the git-undo dir of the repository (see config.KeyDebugLog).
+const debugLogFileName = "debug.log"
+
+// debugLogMaxSize is the size the debug log is rotated at: the previous one is kept as debug.log.1.
+const debugLogMaxSize = 1 << 20
+
+// logger returns the structured logger of the app: records are written to the user (debug ones only
+// when verbose) and, when enabled, every one of them to the debug log (see openDebugLog).
+func (a *App) logger(verbose bool) *slog.Logger {
+	var handler slog.Handler = &consoleHandler{app: a, verbose: verbose}
+	if a.debugLog != nil {
+		handler = teeHandler{handler, a.debugLog}
+	}
+	return slog.New(handler)
+}
+
+// openDebugLog starts writing the records of every level to the debug log: the given file,
+// or the one in the git-undo dir when empty. It returns the function closing it.
+// The records are redacted with the redact config (see logging.Logger.SetRedactions).
+func (a *App) openDebugLog(gitDir, path string) (func(), error) {
+	if path == "" {
+		path = filepath.Join(gitDir, "git-undo", debugLogFileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > debugLogMaxSize {
+		_ = os.Rename(path, path+".1")
+	}
+
+	// Each record is a single append, so concurrent hooks can share the file
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the debug log: %w", err)
+	}
+	var handler slog.Handler = slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})
+	if a.cfg != nil && len(a.cfg.Redact) > 0 {
+		// Records carry the hooked commands: they're redacted as the log is
+		handler = redactHandler{Handler: handler, redactor: logging.NewRedactor(a.cfg.Redact)}
+	}
+	a.debugLog = handler.WithAttrs([]slog.Attr{slog.String("app", a.getAppName()), slog.Int("pid", os.Getpid())})
+	return func() {
+		a.debugLog = nil
+		_ = file.Close()
+	}, nil
+}
+
+// consoleHandler writes records to the messages of the app, formatted by its theme.
+// Attributes follow the message as key=value pairs.
+type consoleHandler struct {
+	app     *App
+	verbose bool
+	attrs   []slog.Attr
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.verbose || level >= slog.LevelInfo
+}
+
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var msg strings.Builder
+	msg.WriteString(record.Message)
+	appendAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&msg, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		appendAttr(attr)
+	}
+	record.Attrs(appendAttr)
+
+	h.app.getTheme().printf(h.app.getMessages(), h.app.getAppName(), outputLevelOf(record.Level), "%s", msg.String())
+	return nil
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(slices.Clip(h.attrs), attrs...)
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// outputLevelOf returns the output level of the record level.
+func outputLevelOf(level slog.Level) outputLevel {
+	switch {
+	case level >= slog.LevelError:
+		return levelError
+	case level >= slog.LevelWarn:
+		return levelWarn
+	case level >= slog.LevelInfo:
+		return levelInfo
+	default:
+		return levelDebug
+	}
+}
+
+// teeHandler hands records to every handler enabled for them.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slices.ContainsFunc(t, func(h slog.Handler) bool { return h.Enabled(ctx, level) })
+}
+
+func (t teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(teeHandler, 0, len(t))
+	for _, h := range t {
+		handlers = append(handlers, h.WithAttrs(attrs))
+	}
+	return handlers
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(teeHandler, 0, len(t))
+	for _, h := range t {
+		handlers = append(handlers, h.WithGroup(name))
+	}
+	return handlers
+}
+
+// redactHandler redacts the message and the attributes of the records before handing them over.
+type redactHandler struct {
+	slog.Handler
+	redactor logging.Redactor
+}
+
+func (h redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, h.redactAttr(attr))
+	}
+	return redactHandler{Handler: h.Handler.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{Handler: h.Handler.WithGroup(name), redactor: h.redactor}
+}
+
+// redactAttr returns the attribute with its value redacted (as text, group members one by one).
+func (h redactHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString, slog.KindAny:
+		return slog.String(attr.Key, h.redactor.Redact(value.String()))
+	case slog.KindGroup:
+		members := make([]any, 0, len(value.Group()))
+		for _, member := range value.Group() {
+			members = append(members, h.redactAttr(member))
+		}
+		return slog.Group(attr.Key, members...)
+	default:
+		return attr
+	}
+}
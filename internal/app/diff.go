@@ -0,0 +1,92 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// repoState is the state of the repository at some point of the log.
+type repoState struct {
+	// label describes the point for the user.
+	label string
+	// head is the HEAD commit (empty if there was none).
+	head string
+	// index is the tree of the index (empty if it couldn't be written, e.g. during conflicts).
+	index string
+}
+
+// captureState returns the metadata describing the current state of the repository (see logging.MetaHead).
+func captureState(g GitHelper) map[string]string {
+	meta := make(map[string]string)
+	if head := getHead(g); head != "" {
+		meta[logging.MetaHead] = head
+	}
+	// write-tree only stores the tree object of the index: the index itself is untouched
+	if tree, err := g.GitOutput("write-tree"); err == nil && tree != "" {
+		meta[logging.MetaIndex] = tree
+	}
+	return meta
+}
+
+// cmdDiff shows the changes between the states after two log entries (or after one and the current state),
+// so the user can see what undoing back to an entry would take away.
+func (a *App) cmdDiff(lgr *logging.Logger, g GitHelper, fromID, toID string) error {
+	if fromID == "" {
+		return errors.New("usage: git undo diff --from <id> [--to <id>]")
+	}
+
+	from, err := entryState(lgr, fromID)
+	if err != nil {
+		return err
+	}
+	to := repoState{label: "the current state"}
+	if toID != "" {
+		if to, err = entryState(lgr, toID); err != nil {
+			return err
+		}
+	} else {
+		current := captureState(g)
+		to.head, to.index = current[logging.MetaHead], current[logging.MetaIndex]
+	}
+
+	var args []string
+	if from.index != "" && to.index != "" {
+		args = []string{from.index, to.index}
+	} else {
+		a.logWarnf("Index state isn't recorded for both points: showing the changes between their commits only")
+		args = []string{from.head, to.head}
+	}
+
+	diff, err := g.GitOutput("diff", args...)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s and %s: %w", from.label, to.label, err)
+	}
+
+	a.logInfof("Changes from %s to %s:", from.label, to.label)
+	if diff == "" {
+		a.logInfof("No changes")
+		return nil
+	}
+	_, _ = fmt.Fprintln(os.Stdout, diff)
+	return nil
+}
+
+// entryState returns the state recorded right after the log entry with the given ID.
+func entryState(lgr *logging.Logger, id string) (repoState, error) {
+	entry, err := lgr.GetEntryByID(id)
+	if err != nil {
+		return repoState{}, fmt.Errorf("failed to find entry %s: %w", id, err)
+	}
+	if entry == nil {
+		return repoState{}, fmt.Errorf("no log entry with id %s", id)
+	}
+
+	head, index := entry.State()
+	if head == "" {
+		return repoState{}, fmt.Errorf("the state after entry %s (%s) isn't recorded", entry.ID(), entry.Command)
+	}
+	return repoState{label: fmt.Sprintf("%s (%s)", entry.ID(), entry.Command), head: head, index: index}, nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// ErrForeignLogFiles is returned when the log has files the current user can't modify:
+// they belong to another user, typically root after `sudo git ...`.
+var ErrForeignLogFiles = errors.New("git-undo log is owned by another user")
+
+// doctorFixHint tells how to give foreign log files back.
+const doctorFixHint = "run `sudo git undo doctor --fix` to give them back"
+
+// cmdDoctor handles `git undo doctor [--fix]`: it checks that the log belongs to the owner of the repository
+// and, with --fix, gives the files created by other users (e.g. via sudo) back to them.
+func (a *App) cmdDoctor(gitDir string, fix bool) error {
+	_, _ = fmt.Fprintf(os.Stdout, "Log directory: %s\n", filepath.Join(gitDir, "git-undo"))
+
+	foreign, err := logging.ForeignFiles(gitDir)
+	if err != nil {
+		return err
+	}
+	if len(foreign) == 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "Ownership: OK\n")
+		return nil
+	}
+
+	if fix {
+		if err := logging.FixOwnership(gitDir, foreign); err != nil {
+			return fmt.Errorf("%w (changing the owner needs root: run it via sudo)", err)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Ownership: fixed %d files\n", len(foreign))
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Ownership: %d files owned by another user than the repository:\n", len(foreign))
+	for _, file := range foreign {
+		_, _ = fmt.Fprintf(os.Stdout, "  %s (uid %d)\n", file.Path, file.UID)
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "Hint: %s\n", doctorFixHint)
+	return nil
+}
+
+// checkLogOwnership fails if the current user can't modify the log because some of its files belong
+// to another user. It's checked before anything is done, so an undo never runs without being recorded.
+// Root can modify everything (and gives the files it writes back, see logging), so it's never stopped.
+func checkLogOwnership(gitDir string) error {
+	if os.Geteuid() <= 0 {
+		return nil
+	}
+
+	foreign, err := logging.ForeignFiles(gitDir)
+	if err != nil {
+		return nil //nolint:nilerr // writing the log will report the actual problem
+	}
+	for _, file := range foreign {
+		if file.UID != os.Geteuid() {
+			return fmt.Errorf("%w: %s belongs to uid %d: %s", ErrForeignLogFiles, file.Path, file.UID, doctorFixHint)
+		}
+	}
+	return nil
+}
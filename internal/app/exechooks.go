@@ -0,0 +1,122 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+)
+
+// ErrVetoed is returned when the pre-undo hook refuses the undo (exits non-zero).
+var ErrVetoed = errors.New("undo was vetoed by the pre-undo hook")
+
+// Names of the undo hooks (also given to the hooks as GIT_UNDO_HOOK env variable).
+const (
+	hookPreUndo  = "pre-undo"
+	hookPostUndo = "post-undo"
+)
+
+// Operations an undo plan describes.
+const (
+	planOperationUndo = "undo"
+	planOperationBack = "back"
+)
+
+// undoPlan is what undo hooks receive as JSON on stdin.
+type undoPlan struct {
+	// Operation is "undo" (git undo) or "back" (git back).
+	Operation string `json:"operation"`
+	// Repository is the top-level directory of the repository.
+	Repository string        `json:"repository"`
+	Entry      planEntry     `json:"entry"`
+	Commands   []planCommand `json:"commands"`
+	// Succeeded tells the post-undo hook whether the undo commands succeeded (it's not set for pre-undo).
+	Succeeded *bool `json:"succeeded,omitempty"`
+}
+
+// planEntry is the log entry being undone.
+type planEntry struct {
+	ID        string `json:"id"`
+	Command   string `json:"command"`
+	Ref       string `json:"ref"`
+	Timestamp string `json:"timestamp"`
+}
+
+// planCommand is a command the undo runs.
+type planCommand struct {
+	Command     string   `json:"command"`
+	Description string   `json:"description"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// newUndoPlan describes the undo of the entry via the commands.
+func newUndoPlan(g GitHelper, isBackMode bool, entry *logging.Entry, undoCmds []*undoer.UndoCommand) *undoPlan {
+	plan := &undoPlan{
+		Operation: planOperationUndo,
+		Entry: planEntry{
+			ID:        entry.ID(),
+			Command:   entry.Command,
+			Ref:       entry.Ref.String(),
+			Timestamp: localRFC3339(entry.Timestamp),
+		},
+		Commands: make([]planCommand, 0, len(undoCmds)),
+	}
+	if isBackMode {
+		plan.Operation = planOperationBack
+	}
+	plan.Repository, _ = g.GitOutput("rev-parse", "--show-toplevel")
+	for _, undoCmd := range undoCmds {
+		plan.Commands = append(plan.Commands, planCommand{
+			Command:     undoCmd.Command,
+			Description: undoCmd.Description,
+			Warnings:    undoCmd.Warnings,
+		})
+	}
+	return plan
+}
+
+// runPreUndoHook runs the configured pre-undo hook: a non-zero exit vetoes the undo.
+func (a *App) runPreUndoHook(ctx context.Context, plan *undoPlan) error {
+	if a.cfg == nil || a.cfg.PreUndoHook == "" {
+		return nil
+	}
+	if err := runUndoHook(ctx, hookPreUndo, a.cfg.PreUndoHook, plan); err != nil {
+		return fmt.Errorf("%w (%s): %w", ErrVetoed, a.cfg.PreUndoHook, err)
+	}
+	return nil
+}
+
+// runPostUndoHook runs the configured post-undo hook. It can't change anything anymore,
+// so its failures are only reported.
+func (a *App) runPostUndoHook(ctx context.Context, plan *undoPlan, succeeded bool) {
+	if a.cfg == nil || a.cfg.PostUndoHook == "" {
+		return
+	}
+	plan.Succeeded = &succeeded
+	if err := runUndoHook(ctx, hookPostUndo, a.cfg.PostUndoHook, plan); err != nil {
+		a.logWarnf("post-undo hook (%s) failed: %v", a.cfg.PostUndoHook, err)
+	}
+}
+
+// runUndoHook runs the hook via the shell in the top-level directory of the repository,
+// passing the plan as JSON on stdin. The output of the hook goes to stderr.
+func runUndoHook(ctx context.Context, name, hook string, plan *undoPlan) error {
+	input, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to encode the undo plan: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Dir = plan.Repository
+	cmd.Env = append(os.Environ(), "GIT_UNDO_HOOK="+name)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -1,5 +1,15 @@
 package app
 
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
 func SetupInternalCall(app *App) {
 	app.isInternalCall = true
 }
@@ -7,3 +17,73 @@ func SetupInternalCall(app *App) {
 func SetupAppDir(app *App, dir string) {
 	app.dir = dir
 }
+
+func WithCommitSigning(args []string, signingEnabled, signerAvailable bool) ([]string, bool, string) {
+	cs := commitSigning{enabled: signingEnabled, format: signingFormatOpenPGP, program: "gpg"}
+	return withCommitSigning(args, cs, signerAvailable)
+}
+
+var DescribeSignatureStatus = describeSignatureStatus
+
+// FormatWarning formats a warning message the way the given theme prints it.
+func FormatWarning(themeName, appName, msg string) (string, bool) {
+	theme, ok := newOutputTheme(themeName)
+	var sb strings.Builder
+	theme.printf(&sb, appName, levelWarn, "%s", msg)
+	return sb.String(), ok
+}
+
+// FormatDefaultWarning formats a warning message the way the theme selected via env variables prints it.
+func FormatDefaultWarning(appName, msg string) string {
+	var sb strings.Builder
+	defaultOutputTheme().printf(&sb, appName, levelWarn, "%s", msg)
+	return sb.String()
+}
+
+func SetupStdin(app *App, stdin io.Reader) {
+	app.stdin = stdin
+}
+
+var Redact = redact
+
+// WriteCrashReport writes a crash report of the given panic value (see App.Run).
+func WriteCrashReport(app *App, ctx context.Context, recovered any) (string, error) {
+	return app.writeCrashReport(ctx, recovered, []byte("goroutine 1 [running]:\nmain.main()"))
+}
+
+// ReportCrash saves a crash report of the given panic value and returns the error App.Run returns after it.
+func ReportCrash(app *App, ctx context.Context, recovered any) error {
+	return app.reportCrash(ctx, recovered, []byte("goroutine 1 [running]:\nmain.main()"))
+}
+
+// WebTestHost is the host the dashboard handler of NewWebHandler expects.
+const WebTestHost = "127.0.0.1:4242"
+
+// NewWebHandler returns the handler of `git undo web` for the repository of the app and its session token.
+func NewWebHandler(ctx context.Context, app *App) (http.Handler, string, error) {
+	g := githelpers.NewGitHelper(ctx, app.dir)
+	gitDir, err := g.GetRepoGitDir()
+	if err != nil {
+		return nil, "", err
+	}
+	if app.cfg, err = config.Load(g); err != nil {
+		return nil, "", err
+	}
+	ws := &webServer{app: app, g: g, gitDir: gitDir, token: "test-token", host: WebTestHost}
+	return ws.routes(), ws.token, nil
+}
+
+var RelativeTime = relativeTime
+
+// Serve answers the `git undo serve` requests read from in for the repository of the app, writing to out.
+func Serve(ctx context.Context, app *App, in io.Reader, out io.Writer) error {
+	g := githelpers.NewGitHelper(ctx, app.dir)
+	gitDir, err := g.GetRepoGitDir()
+	if err != nil {
+		return err
+	}
+	if app.cfg, err = config.Load(g); err != nil {
+		return err
+	}
+	return (&serveServer{app: app, g: g, gitDir: gitDir}).serve(ctx, in, out)
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// annotateFixup records the target commit of a just logged `git commit --fixup/--squash` entry,
+// so history display and redo can refer to it even after the target expression (e.g. HEAD~2) moved.
+func (a *App) annotateFixup(lgr *logging.Logger, g GitHelper, gitCmd *githelpers.GitCommand) {
+	kind, target, ok := gitCmd.Fixup()
+	if !ok {
+		return
+	}
+
+	// The target was resolved by git before the fixup commit was created: HEAD is one commit further now
+	if rest, isRelative := strings.CutPrefix(target, "HEAD"); isRelative {
+		target = "HEAD~1" + rest
+	}
+	sha, err := g.GitOutput("rev-parse", "--short", "--verify", "-q", target+"^{commit}")
+	if err != nil || strings.TrimSpace(sha) == "" {
+		return
+	}
+
+	entries, err := lgr.GetEntries(1, nil)
+	if err != nil || len(entries) == 0 || entries[0].IsNavigation {
+		return
+	}
+	if entryCmd, err := githelpers.ParseGitCommand(entries[0].Command); err != nil || entryCmd.Name != "commit" {
+		return
+	}
+	_ = lgr.SetEntryMeta(entries[0].GetIdentifier(), kind, strings.TrimSpace(sha))
+}
+
+// explainConsumedFixup points at the (autosquash) rebase entry that consumed the fixup commit of the entry.
+func (a *App) explainConsumedFixup(lgr *logging.Logger, entry *logging.Entry, err error) error {
+	if !errors.Is(err, undoer.ErrFixupConsumed) {
+		return err
+	}
+
+	// Entries are newest first: the rebase closest to the fixup entry is the last one seen before it
+	var rebase *logging.Entry
+	_ = lgr.ProcessLogFile(func(line string) bool {
+		e, parseErr := logging.ParseLogLine(line)
+		if parseErr != nil {
+			return true
+		}
+		if e.GetIdentifier() == entry.GetIdentifier() {
+			return false
+		}
+		if gitCmd, parseErr := githelpers.ParseGitCommand(e.Command); parseErr == nil && gitCmd.Name == "rebase" {
+			rebase = e
+		}
+		return true
+	})
+	if rebase == nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: it was squashed by `%s` (entry %s), undo that one instead",
+		err, rebase.Command, rebase.ID())
+}
+
+// describeFixupRedo notes the target of a redone fixup/squash commit
+// and warns when the target is not in the history of the current branch anymore.
+func (a *App) describeFixupRedo(g GitHelper, entry *logging.Entry) {
+	kind, target, ok := entry.FixupTarget()
+	if !ok {
+		return
+	}
+
+	a.logInfof("Redid %s commit for %s", kind, target)
+	if err := g.GitRun("merge-base", "--is-ancestor", target, "HEAD"); err != nil {
+		a.logWarnf("%s target %s is not in the current branch history: `git rebase --autosquash` won't apply it",
+			kind, target)
+	}
+}
+
+// withFixupTarget replaces the target of --fixup/--squash commit arguments with the given commit.
+func withFixupTarget(args []string, kind, target string) []string {
+	flag := "--" + kind + "=" + target
+	switch kind {
+	case githelpers.FixupKindAmend, githelpers.FixupKindReword:
+		flag = "--" + githelpers.FixupKindFixup + "=" + kind + ":" + target
+	}
+
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--"+githelpers.FixupKindFixup || arg == "--"+githelpers.FixupKindSquash:
+			result = append(result, flag)
+			i++ // skip the original target
+		case strings.HasPrefix(arg, "--"+githelpers.FixupKindFixup+"="),
+			strings.HasPrefix(arg, "--"+githelpers.FixupKindSquash+"="):
+			result = append(result, flag)
+		default:
+			result = append(result, arg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/snapshot"
+)
+
+// gcStampFileName is touched in the git-undo dir by every gc: the hook runs it automatically
+// (see config.KeyAutoGC) at most once per autoGCInterval.
+const gcStampFileName = "gc"
+
+// autoGCInterval is how long the hook waits after a gc before running another one, however large the log is.
+const autoGCInterval = 24 * time.Hour
+
+// gcReport is what a gc removed.
+type gcReport struct {
+	entries   int
+	snapshots int
+	files     int
+	// reclaimed is the number of bytes freed.
+	reclaimed int64
+}
+
+// cmdGC handles `git undo gc`: it removes the undone entries past their retention, the expired snapshots
+// and the stale files of the git-undo dir, compacts the log and reports the space reclaimed.
+func (a *App) cmdGC(gitDir string, g GitHelper, verbose bool) error {
+	lgr, err := a.newLogger(gitDir, g)
+	if err != nil {
+		return err
+	}
+	lgr.SetDebugLogger(a.logger(verbose))
+
+	report, err := a.collectGarbage(gitDir, g, lgr)
+	if err != nil {
+		return err
+	}
+	a.logInfof("Removed %d undone entries, %d snapshots and %d stale files: %s reclaimed",
+		report.entries, report.snapshots, report.files, formatSize(report.reclaimed))
+	return nil
+}
+
+// autoGC runs a gc from the hook once the log is larger than configured (see config.KeyAutoGC),
+// unless one ran within autoGCInterval. Failing never fails the hook.
+func (a *App) autoGC(gitDir string, g GitHelper, lgr *logging.Logger, verbose bool) {
+	if a.cfg == nil || a.cfg.AutoGC <= 0 {
+		return
+	}
+	info, err := os.Stat(lgr.GetLogPath())
+	if err != nil || info.Size() <= a.cfg.AutoGC {
+		return
+	}
+	if stamp, err := os.Stat(filepath.Join(gitDir, "git-undo", gcStampFileName)); err == nil &&
+		time.Since(stamp.ModTime()) < autoGCInterval {
+		return
+	}
+
+	report, err := a.collectGarbage(gitDir, g, lgr)
+	if err != nil {
+		a.logDebugf(verbose, "hook: gc failed: %v", err)
+		return
+	}
+	a.logDebugf(verbose, "hook: gc removed %d entries, %d snapshots and %d files (%s)",
+		report.entries, report.snapshots, report.files, formatSize(report.reclaimed))
+}
+
+// collectGarbage removes what the repository's git-undo dir doesn't need anymore (see cmdGC),
+// along with the commits kept alive for the removed entries.
+func (a *App) collectGarbage(gitDir string, g GitHelper, lgr *logging.Logger) (*gcReport, error) {
+	logRetention, snapshotRetention := config.DefaultLogRetention, config.DefaultSnapshotRetention
+	if a.cfg != nil {
+		logRetention, snapshotRetention = a.cfg.LogRetention, a.cfg.SnapshotRetention
+	}
+	report := &gcReport{}
+
+	removed, reclaimed, err := lgr.Compact(logRetention)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact the log: %w", err)
+	}
+	for _, entry := range removed {
+		dropKeptCommit(g, entry)
+	}
+	report.entries = len(removed)
+	report.reclaimed += reclaimed
+
+	store := snapshot.NewStore(gitDir)
+	sizeBefore, err := store.Size()
+	if err != nil {
+		return nil, err
+	}
+	if report.snapshots, err = store.Prune(snapshotRetention); err != nil {
+		return nil, err
+	}
+	sizeAfter, err := store.Size()
+	if err != nil {
+		return nil, err
+	}
+	report.reclaimed += sizeBefore - sizeAfter
+
+	if report.files, reclaimed, err = lgr.RemoveStaleFiles(); err != nil {
+		return nil, fmt.Errorf("failed to remove stale files: %w", err)
+	}
+	report.reclaimed += reclaimed
+
+	stamp := filepath.Join(gitDir, "git-undo", gcStampFileName)
+	if err := os.WriteFile(stamp, nil, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", stamp, err)
+	}
+	return report, nil
+}
+
+// formatSize formats the number of bytes for humans (e.g. 12.5 KiB).
+func formatSize(bytes int64) string {
+	switch {
+	case bytes < 1<<10:
+		return fmt.Sprintf("%d B", bytes)
+	case bytes < 1<<20:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	}
+}
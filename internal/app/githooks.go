@@ -0,0 +1,181 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/hooks"
+)
+
+// installGitHooks writes the dispatcher script to the config dir and the hook shims calling it
+// into every hooks dir git uses (see gitHooksDirs).
+func (sc *SelfController) installGitHooks() error {
+	dispatcher, err := sc.writeDispatcher()
+	if err != nil {
+		return err
+	}
+	dirs, err := sc.gitHooksDirs(true)
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		changed, err := hooks.Install(dir, dispatcher)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			_, _ = fmt.Fprintf(os.Stdout, "Installed the git hooks (%s): %s\n", strings.Join(changed, ", "), dir)
+		} else {
+			_, _ = fmt.Fprintf(os.Stdout, "The git hooks are up to date: %s\n", dir)
+		}
+	}
+	return nil
+}
+
+// cmdUninstallHooks removes the git hook shims (keeping the rest of the hooks they were chained to)
+// and the dispatcher script. core.hooksPath is unset if it's the dir git-undo set it to.
+func (sc *SelfController) cmdUninstallHooks() error {
+	dispatcher := filepath.Join(configDir(), hookInstallPaths[HookGit])
+	dirs, err := sc.gitHooksDirs(false)
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		changed, err := hooks.Uninstall(dir, dispatcher)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			_, _ = fmt.Fprintf(os.Stdout, "Removed the git hooks (%s): %s\n", strings.Join(changed, ", "), dir)
+		}
+	}
+
+	if sc.globalHooksPath() == managedHooksDir() {
+		if err := sc.git("config", "--global", "--unset", "core.hooksPath"); err != nil {
+			return fmt.Errorf("failed to unset core.hooksPath: %w", err)
+		}
+	}
+	if err := os.Remove(dispatcher); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove the dispatcher: %w", err)
+	}
+	return nil
+}
+
+// cmdUpdateHooks refreshes the dispatcher script and the git hook shims where they are installed
+// (the update runs it with the new binary). Hooks dirs with no shims are left alone.
+func (sc *SelfController) cmdUpdateHooks() error {
+	dispatcher := filepath.Join(configDir(), hookInstallPaths[HookGit])
+	dirs, err := sc.gitHooksDirs(false)
+	if err != nil {
+		return err
+	}
+
+	updated := false
+	for _, dir := range dirs {
+		if len(hooks.Installed(dir, dispatcher)) == 0 {
+			continue
+		}
+		if !updated {
+			if _, err := sc.writeDispatcher(); err != nil {
+				return err
+			}
+			updated = true
+		}
+		if _, err := hooks.Install(dir, dispatcher); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Updated the git hooks: %s\n", dir)
+	}
+	if !updated {
+		_, _ = fmt.Fprintf(os.Stdout, "No git hooks installed: run %s self install-hooks\n", appNameGitUndo)
+	}
+	return nil
+}
+
+// writeDispatcher writes the dispatcher script shipped with the binary to the config dir, returning its path.
+func (sc *SelfController) writeDispatcher() (string, error) {
+	script, ok := sc.hooks[HookGit]
+	if !ok {
+		return "", errors.New("git hook dispatcher not available")
+	}
+	path := filepath.Join(configDir(), hookInstallPaths[HookGit])
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	//nolint:gosec // hooks run the dispatcher: it must be executable
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write the dispatcher: %w", err)
+	}
+	return path, nil
+}
+
+// gitHooksDirs returns the hooks dirs git runs hooks from: the global core.hooksPath, chained to if it's
+// someone else's, and the hooks dir of the current repository when it's another one (e.g. a local core.hooksPath).
+// With no global core.hooksPath, it's the dir managed by git-undo: setGlobal points core.hooksPath to it
+// (as the install script does).
+func (sc *SelfController) gitHooksDirs(setGlobal bool) ([]string, error) {
+	global := sc.globalHooksPath()
+	if global == "" {
+		global = managedHooksDir()
+		if setGlobal {
+			if err := sc.git("config", "--global", "core.hooksPath", global); err != nil {
+				return nil, fmt.Errorf("failed to set core.hooksPath: %w", err)
+			}
+		}
+	}
+	dirs := []string{global}
+
+	if repoDir := sc.repoHooksDir(); repoDir != "" && filepath.Clean(repoDir) != filepath.Clean(global) {
+		dirs = append(dirs, repoDir)
+	}
+	return dirs, nil
+}
+
+// globalHooksPath returns the global core.hooksPath (with ~ expanded), empty if unset.
+func (sc *SelfController) globalHooksPath() string {
+	cmd := exec.CommandContext(sc.ctx, "git", "config", "--global", "--type=path", "--get", "core.hooksPath")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// repoHooksDir returns the absolute hooks dir of the current repository, empty outside of one.
+func (sc *SelfController) repoHooksDir() string {
+	cmd := exec.CommandContext(sc.ctx, "git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = sc.dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	dir := strings.TrimSpace(string(out))
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	// Relative paths are relative to where git ran
+	base, err := filepath.Abs(sc.dir)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, dir)
+}
+
+// git runs a git command (in the current directory for reports).
+func (sc *SelfController) git(args ...string) error {
+	cmd := exec.CommandContext(sc.ctx, "git", args...)
+	cmd.Dir = sc.dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// managedHooksDir is the hooks dir core.hooksPath is set to when nothing else uses it.
+func managedHooksDir() string {
+	return filepath.Join(configDir(), filepath.Dir(hookInstallPaths[HookGit]))
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/journal"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// globalStateGone is the state of journal records whose entry is gone from the log of their repository
+// (the repository is gone, or the entry was truncated).
+const globalStateGone = "gone"
+
+// globalEncryptedCommand is recorded to the journal instead of the commands of encrypted logs.
+const globalEncryptedCommand = "(encrypted)"
+
+// globalJSONEntry is a journal record as `git undo log --global --json` prints it.
+type globalJSONEntry struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Repo      string `json:"repo"`
+	Ref       string `json:"ref"`
+	Command   string `json:"command"`
+	// State is the current state of the entry in its repository (e.g. "undone", see describeEntryState).
+	State string `json:"state"`
+}
+
+// recordGlobalJournal records the logged entry to the global journal (see config.KeyGlobalJournal).
+// The journal is only a view: failing to write it never fails the hook. It's never encrypted:
+// the commands of encrypted logs are left out of it.
+func (a *App) recordGlobalJournal(g GitHelper, gitDir string, verbose bool, entry *logging.Entry) {
+	command := entry.Command
+	if a.cfg.Encrypt {
+		command = globalEncryptedCommand
+	}
+	path, err := journal.DefaultPath()
+	if err == nil {
+		err = journal.Append(path, journal.Record{
+			Time:    entry.Timestamp,
+			Repo:    a.getRepoPath(g, gitDir),
+			ID:      entry.ID(),
+			Ref:     entry.Ref.String(),
+			Command: command,
+		})
+	}
+	if err != nil {
+		a.logDebugf(verbose, "hook: failed to record %q to the global journal: %v", entry.Command, err)
+	}
+}
+
+// cmdGlobalLog handles `git undo log --global`: the commands recorded to the global journal, newest first,
+// with the repository each one ran in and its current state there. Only --since and --count filter them.
+func (a *App) cmdGlobalLog(ctx context.Context, opts RunOptions) error {
+	query := opts.Log
+	if query.Ref != "" || query.AllRefs || query.Type != "" || query.Undone || query.Worktree != "" {
+		return errors.New("--global can only be filtered with --since and --count")
+	}
+	if opts.Pretty {
+		return errors.New("--global can't be used with --pretty")
+	}
+	if opts.JSON && opts.Porcelain {
+		return errors.New("only one of --json and --porcelain can be used")
+	}
+	since, err := parseHistoryTime(query.Since, entryClockNow())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	// Out of a repository there's no config to pick the theme from
+	if opts.Accessible {
+		theme, _ := newOutputTheme(ThemeAccessible)
+		a.theme = &theme
+	}
+
+	path, err := journal.DefaultPath()
+	if err != nil {
+		return err
+	}
+	records, err := journal.Read(path, since, query.Count)
+	if err != nil {
+		return err
+	}
+
+	states := a.globalStates(ctx, records)
+	switch {
+	case opts.JSON:
+		entries := make([]globalJSONEntry, 0, len(records))
+		for i, record := range records {
+			entries = append(entries, globalJSONEntry{
+				ID:        record.ID,
+				Timestamp: localRFC3339(record.Time),
+				Repo:      record.Repo,
+				Ref:       record.Ref,
+				Command:   record.Command,
+				State:     states[i],
+			})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case opts.Porcelain:
+		for i, record := range records {
+			_, _ = fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t%s\t%s\n", record.ID, localRFC3339(record.Time),
+				record.Repo, record.Ref, strings.ReplaceAll(states[i], ", ", ","), record.Command)
+		}
+		return nil
+	}
+
+	if len(records) == 0 {
+		a.logInfof("No commands in the global journal (enable it with: git config --global git-undo.globalJournal true)")
+		return nil
+	}
+	if a.getTheme().accessible {
+		for i, record := range records {
+			_, _ = fmt.Fprintf(os.Stdout, "%s: %s, on %s in %s at %s, %s\n", record.ID, record.Command,
+				record.Ref, record.Repo, record.Time.Format(time.DateTime), states[i])
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tTIME\tREPO\tREF\tSTATE\tCOMMAND")
+	for i, record := range records {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", record.ID, record.Time.Format(time.DateTime),
+			record.Repo, record.Ref, states[i], record.Command)
+	}
+	return w.Flush()
+}
+
+// globalStates returns the current state of the entry of each record in the log of its repository.
+// Every log is read once.
+func (a *App) globalStates(ctx context.Context, records []journal.Record) []string {
+	repoStates := make(map[string]map[string]string)
+	states := make([]string, 0, len(records))
+	for _, record := range records {
+		entryStates, ok := repoStates[record.Repo]
+		if !ok {
+			entryStates = make(map[string]string)
+			g := githelpers.NewGitHelper(ctx, record.Repo)
+			if gitDir, err := g.GetRepoGitDir(); err == nil {
+				_ = a.newReadOnlyLogger(gitDir, g).ProcessLogFile(func(line string) bool {
+					if entry, err := logging.ParseLogLine(line); err == nil {
+						entryStates[entry.ID()] = describeEntryState(entry)
+					}
+					return true
+				})
+			}
+			repoStates[record.Repo] = entryStates
+		}
+
+		state, ok := entryStates[record.ID]
+		if !ok {
+			state = globalStateGone
+		}
+		states = append(states, state)
+	}
+	return states
+}
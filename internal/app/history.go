@@ -0,0 +1,333 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// HistoryQuery selects and groups the entries `git undo history` shows.
+type HistoryQuery struct {
+	// Branch keeps the entries logged on the ref.
+	Branch string
+	// Worktree is the path of the worktree whose log is shown (the current one by default),
+	// or HistoryAllWorktrees for the logs of all worktrees of the repository.
+	Worktree string
+	// Author keeps the entries of users whose identity (see logging.MetaAuthor) contains it (case-insensitive).
+	Author string
+	// Since and Until keep the entries logged in the range: a date (e.g. 2025-01-31 or 2025-01-31 15:04),
+	// or a duration ago (e.g. 90m, 2h, 3d, 2w).
+	Since string
+	Until string
+	// GroupBy is one of HistoryGroup* constants: entries are printed under a header per group.
+	GroupBy string
+}
+
+// HistoryAllWorktrees is the HistoryQuery.Worktree value selecting all worktrees.
+const HistoryAllWorktrees = "all"
+
+// Groupings of `git undo history --group-by`.
+const (
+	HistoryGroupBranch   = "branch"
+	HistoryGroupWorktree = "worktree"
+	HistoryGroupAuthor   = "author"
+	HistoryGroupDay      = "day"
+)
+
+// historyDateFormats are the accepted absolute dates of --since/--until.
+var historyDateFormats = []string{time.DateOnly, "2006-01-02 15:04", time.DateTime, time.RFC3339}
+
+// historyEntry is an entry of the history and the worktree it was logged in.
+type historyEntry struct {
+	*logging.Entry
+	worktree string
+}
+
+// cmdHistory handles `git undo history`: the log entries matching the query, newest first.
+func (a *App) cmdHistory(ctx context.Context, g GitHelper, gitDir string, query HistoryQuery) error {
+	match, err := query.matcher(entryClockNow())
+	if err != nil {
+		return err
+	}
+	groupKey, err := query.groupKey()
+	if err != nil {
+		return err
+	}
+
+	logs, err := a.historyLogs(ctx, g, gitDir, query.Worktree)
+	if err != nil {
+		return err
+	}
+	var entries []historyEntry
+	for worktree, logGitDir := range logs {
+		lgrEntries, err := a.newReadOnlyLogger(logGitDir, g).GetEntries(0, match)
+		if err != nil {
+			return fmt.Errorf("failed to read the log of %s: %w", worktree, err)
+		}
+		for _, entry := range lgrEntries {
+			entries = append(entries, historyEntry{Entry: entry, worktree: worktree})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b historyEntry) int { return b.Timestamp.Compare(a.Timestamp) })
+
+	if len(entries) == 0 {
+		a.logInfof("No log entries match")
+		return nil
+	}
+
+	// Groups are ordered by their newest entry
+	var groups []string
+	grouped := make(map[string][]historyEntry)
+	for _, entry := range entries {
+		key := groupKey(entry)
+		if _, ok := grouped[key]; !ok {
+			groups = append(groups, key)
+		}
+		grouped[key] = append(grouped[key], entry)
+	}
+
+	for i, group := range groups {
+		indent := ""
+		if query.GroupBy != "" {
+			if i > 0 {
+				_, _ = fmt.Fprintln(os.Stdout)
+			}
+			_, _ = fmt.Fprintf(os.Stdout, "%s %s (%d)\n", query.GroupBy, a.getTheme().highlight(group),
+				len(grouped[group]))
+			indent = "  "
+		}
+		for _, entry := range grouped[group] {
+			_, _ = fmt.Fprintln(os.Stdout, indent+a.describeHistoryEntry(entry, query))
+		}
+	}
+	return nil
+}
+
+// describeHistoryEntry renders the entry as a line of the history, leaving out what its group tells already.
+func (a *App) describeHistoryEntry(entry historyEntry, query HistoryQuery) string {
+	author := entry.Metadata[logging.MetaAuthor]
+	if a.getTheme().accessible {
+		line := describeEntry(entry.Entry)
+		if author != "" {
+			line += "; by " + author
+		}
+		return line
+	}
+
+	parts := []string{entry.ID(), entry.Timestamp.Format(time.DateTime)}
+	if query.GroupBy != HistoryGroupBranch {
+		parts = append(parts, "["+entry.Ref.String()+"]")
+	}
+	parts = append(parts, entry.TypedCommand())
+	if author != "" && query.GroupBy != HistoryGroupAuthor {
+		parts = append(parts, "by "+author)
+	}
+	switch {
+	case entry.Undoed:
+		parts = append(parts, "(undone)")
+	case entry.IsNavigation:
+		parts = append(parts, "(navigation)")
+	}
+	if entry.IsPinned() {
+		parts = append(parts, "(pinned)")
+	}
+	return strings.Join(parts, "  ")
+}
+
+// matcher returns the filter of log entries matching the query.
+func (q HistoryQuery) matcher(now time.Time) (func(*logging.Entry) bool, error) {
+	since, err := parseHistoryTime(q.Since, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseHistoryTime(q.Until, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --until: %w", err)
+	}
+	author := strings.ToLower(q.Author)
+
+	return func(entry *logging.Entry) bool {
+		switch {
+		case q.Branch != "" && entry.Ref.String() != q.Branch:
+			return false
+		case author != "" && !strings.Contains(strings.ToLower(entry.Metadata[logging.MetaAuthor]), author):
+			return false
+		case !since.IsZero() && entry.Timestamp.Before(since):
+			return false
+		case !until.IsZero() && entry.Timestamp.After(until):
+			return false
+		}
+		return true
+	}, nil
+}
+
+// groupKey returns the function naming the group of an entry.
+func (q HistoryQuery) groupKey() (func(historyEntry) string, error) {
+	switch q.GroupBy {
+	case "":
+		return func(historyEntry) string { return "" }, nil
+	case HistoryGroupBranch:
+		return func(e historyEntry) string { return e.Ref.String() }, nil
+	case HistoryGroupWorktree:
+		return func(e historyEntry) string { return e.worktree }, nil
+	case HistoryGroupAuthor:
+		return func(e historyEntry) string {
+			if author := e.Metadata[logging.MetaAuthor]; author != "" {
+				return author
+			}
+			return "unknown"
+		}, nil
+	case HistoryGroupDay:
+		return func(e historyEntry) string { return e.Timestamp.Format(time.DateOnly) }, nil
+	default:
+		return nil, fmt.Errorf("invalid --group-by %q (supported: %s, %s, %s, %s)", q.GroupBy,
+			HistoryGroupBranch, HistoryGroupWorktree, HistoryGroupAuthor, HistoryGroupDay)
+	}
+}
+
+// entryClockNow returns the current time as log entries keep it: the local wall clock time (parsed as UTC),
+// so it can be compared to their timestamps.
+func entryClockNow() time.Time {
+	return entryClock(time.Now())
+}
+
+// entryClock returns the time as log entries keep it (see entryClockNow).
+func entryClock(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// localRFC3339 formats the time of a log entry (see entryClockNow) as RFC 3339,
+// with the local offset instead of a Z suffix.
+func localRFC3339(t time.Time) string {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local).
+		Format(time.RFC3339)
+}
+
+// parseHistoryTime parses a --since/--until value: a date (in the location of now) or a duration ago.
+// An empty value is the zero time (no limit).
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, format := range historyDateFormats {
+		if t, err := time.ParseInLocation(format, value, now.Location()); err == nil {
+			if format == time.RFC3339 {
+				return entryClock(t), nil // it has its own offset, e.g. the one of a timestamp of git undo log
+			}
+			return t, nil
+		}
+	}
+
+	// Days and weeks aren't known to time.ParseDuration
+	ago, err := time.ParseDuration(value)
+	if unit := value[len(value)-1]; err != nil && (unit == 'd' || unit == 'w') {
+		var n int
+		if n, err = strconv.Atoi(value[:len(value)-1]); err == nil {
+			ago = time.Duration(n) * 24 * time.Hour
+			if unit == 'w' {
+				ago *= 7
+			}
+		}
+	}
+	if err != nil || ago < 0 {
+		return time.Time{}, fmt.Errorf("%q is neither a date (e.g. 2025-01-31 15:04) nor a duration (e.g. 3d)", value)
+	}
+	return now.Add(-ago), nil
+}
+
+// historyLogs returns the git dirs keeping the logs to show by the worktree they belong to.
+func (a *App) historyLogs(ctx context.Context, g GitHelper, gitDir, worktree string) (map[string]string, error) {
+	switch worktree {
+	case "":
+		return map[string]string{a.getRepoPath(g, gitDir): gitDir}, nil
+	case HistoryAllWorktrees:
+		return allWorktreeLogs(ctx, g)
+	}
+
+	wtGitDir, err := githelpers.NewGitHelper(ctx, worktree).GetRepoGitDir()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a worktree of the repository: %w", worktree, err)
+	}
+	return map[string]string{worktree: wtGitDir}, nil
+}
+
+// undoWorktree returns the path of the worktree to undo in for the --worktree value: the given worktree,
+// or with HistoryAllWorktrees the one whose last undoable command (on its current ref) is the latest.
+func (a *App) undoWorktree(ctx context.Context, g GitHelper, gitDir, worktree string) (string, error) {
+	logs, err := a.historyLogs(ctx, g, gitDir, worktree)
+	if err != nil {
+		return "", err
+	}
+	if worktree != HistoryAllWorktrees {
+		return worktree, nil
+	}
+
+	var latest *logging.Entry
+	var latestWorktree string
+	for path, wtGitDir := range logs {
+		entry, err := a.newReadOnlyLogger(wtGitDir, githelpers.NewGitHelper(ctx, path)).GetLastRegularEntry()
+		if err != nil || entry == nil {
+			continue
+		}
+		if latest == nil || entry.Timestamp.After(latest.Timestamp) {
+			latest, latestWorktree = entry, path
+		}
+	}
+	if latest == nil {
+		return "", errors.New("nothing to undo in any worktree")
+	}
+	a.logInfof("Undoing in worktree %s", latestWorktree)
+	return latestWorktree, nil
+}
+
+// allWorktreeLogs returns the git dirs of all worktrees of the repository (each has a log of its own).
+func allWorktreeLogs(ctx context.Context, g GitHelper) (map[string]string, error) {
+	out, err := g.GitOutput("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	logs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		path, ok := strings.CutPrefix(strings.TrimSpace(line), "worktree ")
+		if !ok {
+			continue
+		}
+		wtGitDir, err := githelpers.NewGitHelper(ctx, path).GetRepoGitDir()
+		if err != nil {
+			// e.g. a prunable worktree whose directory is gone
+			continue
+		}
+		logs[path] = wtGitDir
+	}
+	if len(logs) == 0 {
+		return nil, errors.New("no worktrees found")
+	}
+	return logs, nil
+}
+
+// currentAuthor returns the identity of the user running git (see logging.MetaAuthor): the configured
+// name and email, as git itself records them in commits.
+func currentAuthor(g GitHelper) string {
+	name, _ := g.GitOutput("config", "user.name")
+	email, _ := g.GitOutput("config", "user.email")
+	name, email = strings.TrimSpace(name), strings.TrimSpace(email)
+	switch {
+	case name != "" && email != "":
+		return name + " <" + email + ">"
+	case email != "":
+		return "<" + email + ">"
+	default:
+		return name
+	}
+}
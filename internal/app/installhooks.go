@@ -0,0 +1,131 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// WithShell sets the shell `install-hooks` installs the hook of ($SHELL if empty).
+func (sc *SelfController) WithShell(shell string) *SelfController {
+	sc.shell = shell
+	return sc
+}
+
+// cmdInstallHooks installs the hook of the shell: the hook script goes to the config dir
+// and is sourced from the startup file of the shell (unless it already is).
+// The git hooks are installed too (see installGitHooks), or only them with --shell git.
+func (sc *SelfController) cmdInstallHooks() error {
+	shell := hookShellName(sc.shell)
+	if shell == HookGit {
+		return sc.installGitHooks()
+	}
+	if shell == "" {
+		shell = hookShellName(filepath.Base(os.Getenv("SHELL")))
+	}
+	script, ok := sc.hooks[shell]
+	if !ok || shell == HookGit {
+		return fmt.Errorf("unsupported shell %q (supported: %s, %s, %s, %s, %s)",
+			shell, HookBash, HookZsh, HookFish, HookPowerShell, HookGit)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	hookPath := filepath.Join(configDir(), hookInstallPaths[shell])
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(hookPath), err)
+	}
+	//nolint:gosec // the hook is sourced by the shell: it's as readable as the startup files
+	if err := os.WriteFile(hookPath, []byte(script), 0o644); err != nil {
+		return fmt.Errorf("failed to write the %s hook: %w", shell, err)
+	}
+
+	startupFile := filepath.Join(home, shellStartupFile(shell))
+	added, err := appendLineOnce(startupFile, hookSourceLine(shell))
+	if err != nil {
+		return fmt.Errorf("failed to source the hook from %s: %w", startupFile, err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Installed the %s hook: %s\n", shell, hookPath)
+	if added {
+		_, _ = fmt.Fprintf(os.Stdout, "Sourced it from %s: restart the shell to start logging\n", startupFile)
+	}
+	return sc.installGitHooks()
+}
+
+// hookShellName returns the hook name (see Hook* constants) of the shell (e.g. as named by $SHELL).
+func hookShellName(shell string) string {
+	shell = strings.ToLower(strings.TrimSuffix(shell, ".exe"))
+	if shell == "pwsh" {
+		return HookPowerShell
+	}
+	return shell
+}
+
+// shellStartupFile returns the file the shell runs when it starts (relative to the home directory),
+// the same ones the install script uses for bash and zsh.
+func shellStartupFile(shell string) string {
+	switch shell {
+	case HookBash:
+		// Terminals on macOS start login shells, which don't read .bashrc
+		if runtime.GOOS == "darwin" {
+			return ".bash_profile"
+		}
+		return ".bashrc"
+	case HookZsh:
+		return ".zshrc"
+	case HookFish:
+		return filepath.Join(".config", "fish", "config.fish")
+	default:
+		if runtime.GOOS == "windows" {
+			return filepath.Join("Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+		}
+		return filepath.Join(".config", "powershell", "Microsoft.PowerShell_profile.ps1")
+	}
+}
+
+// hookSourceLine returns the line of the startup file that sources the hook of the shell
+// (the uninstall script removes the lines referencing git-undo/git-undo-hook.*).
+func hookSourceLine(shell string) string {
+	if shell == HookPowerShell {
+		return `. "$HOME/.config/git-undo/` + hookInstallPaths[shell] + `"`
+	}
+	return "source ~/.config/git-undo/" + hookInstallPaths[shell]
+}
+
+// appendLineOnce appends the line to the file (created if needed) unless the file has it already.
+func appendLineOnce(path, line string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == line {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		line = "\n" + line
+	}
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// interactiveEntriesLimit is how many of the latest undoable entries `git undo -i` offers.
+const interactiveEntriesLimit = 10
+
+// ErrInvalidSelection is returned when the interactive selection can't be parsed.
+var ErrInvalidSelection = errors.New("invalid selection")
+
+// runUndoInteractive handles `git undo --interactive`: it lists the latest undoable entries of the current ref
+// and undoes the picked ones, newest first (so each undo sees the state its command left behind).
+func (a *App) runUndoInteractive(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	// Like plain `git undo`, only the entries of the current ref are offered
+	ref, _ := g.GetCurrentGitRef()
+	entries, err := lgr.GetEntries(interactiveEntriesLimit, func(e *logging.Entry) bool {
+		return !e.Undoed && !e.IsNavigation && (ref == "" || e.Ref.String() == ref)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+	if len(entries) == 0 {
+		a.logInfof("nothing to undo")
+		return nil
+	}
+
+	for i, entry := range entries {
+		_, _ = fmt.Fprintf(os.Stderr, "%3d) %s  %s  %s\n", i+1, entry.ID(), entry.Timestamp.Format(time.DateTime),
+			entry.TypedCommand())
+	}
+	_, _ = fmt.Fprint(os.Stderr, "Undo which entries? (e.g. 1, 1 3 or 1-3; empty to cancel) ")
+
+	answer, err := readLine(a.getStdin())
+	if err != nil {
+		return fmt.Errorf("failed to read the selection: %w", err)
+	}
+	picked, err := parseSelection(answer, len(entries))
+	if err != nil {
+		return err
+	}
+	if len(picked) == 0 {
+		a.logInfof("nothing selected")
+		return nil
+	}
+
+	// Like `git undo --at`, each entry is undone as picked: warned about the later commands still done,
+	// and targeted by its own recorded state (e.g. an older commit isn't undone as HEAD)
+	for done, index := range picked {
+		entry := entries[index]
+		if err := a.undoPickedEntry(ctx, lgr, g, opts, entry, strconv.Itoa(index+1)); err != nil {
+			if done == 0 {
+				return err
+			}
+			return fmt.Errorf("undid %d of %d commands, then undoing `%s` failed (it and the rest stay done): %w",
+				done, len(picked), entry.Command, err)
+		}
+	}
+	return nil
+}
+
+// parseSelection parses 1-based entry numbers and ranges (e.g. "1 3-4" or "1,3") into sorted 0-based indexes.
+func parseSelection(answer string, count int) ([]int, error) {
+	var picked []int
+	pick := func(number int) error {
+		if number < 1 || number > count {
+			return fmt.Errorf("%w: %d is not in 1-%d", ErrInvalidSelection, number, count)
+		}
+		if !slices.Contains(picked, number-1) {
+			picked = append(picked, number-1)
+		}
+		return nil
+	}
+
+	for _, token := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		from, to, isRange := strings.Cut(token, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSelection, token)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil || last < first {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidSelection, token)
+			}
+		}
+		for number := first; number <= last; number++ {
+			if err := pick(number); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Newest entries go first
+	slices.Sort(picked)
+	return picked, nil
+}
+
+// readLine reads a line byte by byte: nothing past it is consumed, so later prompts
+// (e.g. the confirmation of strict mode) can read their answers from the same input.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, buf[0])
+		}
+		if errors.Is(err, io.EOF) {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
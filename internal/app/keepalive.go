@@ -0,0 +1,89 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// keepAliveRefPrefix is where git-undo keeps undone commits reachable,
+// so they are not garbage collected and can be restored exactly on redo.
+const keepAliveRefPrefix = "refs/git-undo/keep/"
+
+// keepAliveRef returns the keep-alive ref name for the given entry.
+func keepAliveRef(entry *logging.Entry) string {
+	return keepAliveRefPrefix + entry.ID()
+}
+
+// keepAlive stores the commit being undone (usually HEAD) under the entry's keep-alive ref.
+// It's called right before undoing a commit.
+func keepAlive(g GitHelper, entry *logging.Entry, commit string) error {
+	return g.GitRun("update-ref", keepAliveRef(entry), commit)
+}
+
+// getKeptCommit returns the commit kept alive for the given entry (if any).
+func getKeptCommit(g GitHelper, entry *logging.Entry) (string, bool) {
+	sha, err := g.GitOutput("rev-parse", "--verify", "-q", keepAliveRef(entry)+"^{commit}")
+	if err != nil || strings.TrimSpace(sha) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(sha), true
+}
+
+// dropKeptCommit removes the entry's keep-alive ref (if any).
+func dropKeptCommit(g GitHelper, entry *logging.Entry) {
+	if _, ok := getKeptCommit(g, entry); ok {
+		_ = g.GitRun("update-ref", "-d", keepAliveRef(entry))
+	}
+}
+
+// canRestoreKeptCommit checks that restoring the kept commit via `git reset --hard` is lossless:
+// the kept commit must be a child of the current HEAD and the working tree (with the index)
+// must be identical to it, which is exactly the state right after `git undo` of a commit.
+func canRestoreKeptCommit(g GitHelper, sha string) bool {
+	return isChildOfHead(g, sha) && g.GitRun("diff", "--quiet", sha) == nil
+}
+
+// canReapplyKeptCommit checks that restoring the kept commit with its changes is lossless:
+// the kept commit must be a child of the current HEAD and the paths it changes must have no local changes
+// (untracked files included), which is exactly the state right after `git undo --hard` of a commit.
+func canReapplyKeptCommit(g GitHelper, sha string) bool {
+	if !isChildOfHead(g, sha) {
+		return false
+	}
+	status, err := g.GitOutput("status", append([]string{"--porcelain", "--untracked-files=all", "--"},
+		keptCommitPaths(g, sha)...)...)
+	return err == nil && strings.TrimSpace(status) == ""
+}
+
+// canRecommitKeptCommit checks that restoring the kept commit while keeping the working tree as it is
+// is lossless: the kept commit must be a child of the current HEAD and nothing must be staged,
+// which is exactly the state right after `git undo --unstage` of a commit.
+func canRecommitKeptCommit(g GitHelper, sha string) bool {
+	return isChildOfHead(g, sha) && g.GitRun("diff", "--cached", "--quiet") == nil
+}
+
+// keptCommitPaths returns the paths the kept commit changes, as top-level pathspecs.
+func keptCommitPaths(g GitHelper, sha string) []string {
+	output, err := g.GitOutput("diff-tree", "-r", "--name-only", "--no-commit-id", sha+"^", sha)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, path := range strings.Split(strings.TrimSpace(output), "\n") {
+		if path != "" {
+			paths = append(paths, ":/"+path)
+		}
+	}
+	return paths
+}
+
+// isChildOfHead checks that the commit's (first) parent is the current HEAD.
+func isChildOfHead(g GitHelper, sha string) bool {
+	parent, err := g.GitOutput("rev-parse", "--verify", "-q", sha+"^")
+	if err != nil {
+		return false
+	}
+	head, err := g.GitOutput("rev-parse", "--verify", "-q", "HEAD")
+	return err == nil && strings.TrimSpace(parent) == strings.TrimSpace(head)
+}
@@ -0,0 +1,372 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// LogQuery selects the entries `git undo log` shows.
+type LogQuery struct {
+	// Ref keeps the entries logged on the ref (the current one by default).
+	Ref string
+	// AllRefs keeps the entries of all refs.
+	AllRefs bool
+	// Type keeps the mutations, the navigations or the failed commands only (one of LogType* constants).
+	Type string
+	// Undone keeps the undone entries only.
+	Undone bool
+	// Since keeps the entries logged after a date or a duration ago (e.g. 2025-01-31 or 3d).
+	Since string
+	// Count is the most entries shown (all of them when not positive).
+	Count int
+	// Worktree is the path of the worktree whose log is shown (the current one by default),
+	// or HistoryAllWorktrees for the logs of all worktrees of the repository.
+	Worktree string
+	// Global shows the commands of every repository recorded to the global journal instead
+	// (see config.KeyGlobalJournal).
+	Global bool
+}
+
+// Entry types of `git undo log --type`.
+const (
+	LogTypeMutation   = "mutation"
+	LogTypeNavigation = "navigation"
+	// LogTypeFailed keeps the failed commands (logged only with git-undo.logfailed set).
+	LogTypeFailed = "failed"
+)
+
+// logJSONEntry is an entry as `git undo log --json` prints it.
+type logJSONEntry struct {
+	ID string `json:"id"`
+	// Identifier is the stable identifier of the entry in the log (see logging.Entry.GetIdentifier).
+	Identifier string `json:"identifier"`
+	Timestamp  string `json:"timestamp"`
+	Ref        string `json:"ref"`
+	Command    string `json:"command"`
+	// Typed is the command as typed, when it was logged otherwise (see logging.MetaTyped).
+	Typed      string `json:"typed,omitempty"`
+	Undone     bool   `json:"undone"`
+	Navigation bool   `json:"navigation"`
+	Failed     bool   `json:"failed"`
+	// Suppressed counts the commands a summary entry stands for (see logging.Entry.Summary).
+	Suppressed int  `json:"suppressed,omitempty"`
+	Pinned     bool `json:"pinned"`
+	// Worktree is the worktree the entry was logged in (with --worktree only).
+	Worktree string `json:"worktree,omitempty"`
+	// Undo tells how the entry was undone (if it was, or an undo of it failed).
+	Undo *logJSONUndo `json:"undo,omitempty"`
+}
+
+// logJSONUndo is the record of the undo of an entry as `git undo log --json` prints it (see logging.UndoRecord).
+type logJSONUndo struct {
+	Commands []string `json:"commands"`
+	At       string   `json:"at"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// cmdLogQuery handles `git undo log`: the log entries matching opts.Log, newest first, in columns.
+// With opts.JSON they're printed as a JSON array, with opts.Porcelain as stable tab-separated lines for scripts,
+// and with opts.Pretty in colors, with relative times and the would-be undo of each entry.
+// Entries of other worktrees (see LogQuery.Worktree) come with the worktree they were logged in.
+func (a *App) cmdLogQuery(ctx context.Context, g GitHelper, gitDir string, opts RunOptions) error {
+	query := opts.Log
+	formats := 0
+	for _, set := range []bool{opts.JSON, opts.Porcelain, opts.Pretty} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		return errors.New("only one of --json, --porcelain and --pretty can be used")
+	}
+	if query.Type != "" && !slices.Contains([]string{LogTypeMutation, LogTypeNavigation, LogTypeFailed}, query.Type) {
+		return fmt.Errorf("invalid --type %q (supported: %s, %s, %s)",
+			query.Type, LogTypeMutation, LogTypeNavigation, LogTypeFailed)
+	}
+	since, err := parseHistoryTime(query.Since, entryClockNow())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	logs, err := a.historyLogs(ctx, g, gitDir, query.Worktree)
+	if err != nil {
+		return err
+	}
+	var entries []historyEntry
+	gits := make(map[string]GitHelper)
+	nextUndos := make(map[string][]string)
+	for _, worktree := range slices.Sorted(maps.Keys(logs)) {
+		logGitDir := logs[worktree]
+		wtGit := g
+		if query.Worktree != "" {
+			wtGit = githelpers.NewGitHelper(ctx, worktree)
+		}
+		lgr := a.newReadOnlyLogger(logGitDir, wtGit)
+		wtEntries, err := queryLog(lgr, wtGit, query, since)
+		if err != nil {
+			return fmt.Errorf("failed to read the log of %s: %w", worktree, err)
+		}
+		for _, entry := range wtEntries {
+			entries = append(entries, historyEntry{Entry: entry, worktree: worktree})
+		}
+		gits[worktree] = wtGit
+		if opts.Pretty {
+			nextUndos[worktree] = nextUndoIDs(lgr)
+		}
+	}
+	// Logs of several worktrees are merged, newest first
+	slices.SortStableFunc(entries, func(x, y historyEntry) int { return y.Timestamp.Compare(x.Timestamp) })
+	if query.Count > 0 && len(entries) > query.Count {
+		entries = entries[:query.Count]
+	}
+	withWorktree := query.Worktree != ""
+
+	switch {
+	case opts.JSON:
+		return printLogJSON(entries, withWorktree)
+	case opts.Porcelain:
+		for _, entry := range entries {
+			line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", entry.ID(), localRFC3339(entry.Timestamp),
+				entry.Ref, strings.ReplaceAll(describeEntryState(entry.Entry), ", ", ","), entry.Command)
+			if withWorktree {
+				line += "\t" + entry.worktree
+			}
+			_, _ = fmt.Fprintln(os.Stdout, line)
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		a.logInfof("No log entries match")
+		return nil
+	}
+	if opts.Pretty && !a.getTheme().accessible {
+		a.printPrettyLog(gits, nextUndos, entries, withWorktree)
+		return nil
+	}
+	if a.getTheme().accessible {
+		for _, entry := range entries {
+			line := describeEntry(entry.Entry)
+			if undo := describeUndoRecord(entry.Entry); undo != "" {
+				line += "; " + undo
+			}
+			if withWorktree {
+				line += "; in " + entry.worktree
+			}
+			_, _ = fmt.Fprintln(os.Stdout, line)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "ID\tTIME\tREF\tSTATE\tCOMMAND"
+	if withWorktree {
+		header = "ID\tTIME\tWORKTREE\tREF\tSTATE\tCOMMAND"
+	}
+	_, _ = fmt.Fprintln(w, header)
+	for _, entry := range entries {
+		columns := []string{entry.ID(), entry.Timestamp.Format(time.DateTime), entry.Ref.String(),
+			describeEntryState(entry.Entry), entry.TypedCommand()}
+		if withWorktree {
+			columns = slices.Insert(columns, 2, entry.worktree)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(columns, "\t"))
+
+		// How the entry was undone goes on a line of its own, under its command
+		if undo := describeUndoRecord(entry.Entry); undo != "" {
+			_, _ = fmt.Fprintln(w, strings.Repeat("\t", len(columns)-1)+undo)
+		}
+	}
+	return w.Flush()
+}
+
+// queryLog returns the entries of the log matching the query, newest first: the ref filter defaults
+// to the current ref of the worktree (as g sees it).
+func queryLog(lgr *logging.Logger, g GitHelper, query LogQuery, since time.Time) ([]*logging.Entry, error) {
+	ref := logging.RefAny
+	if !query.AllRefs {
+		ref = logging.Ref(query.Ref)
+		if query.Ref == "" {
+			current, err := g.GetCurrentGitRef()
+			if err != nil {
+				return nil, fmt.Errorf("failed to determine the current ref (use --all-refs): %w", err)
+			}
+			ref = logging.Ref(current)
+		}
+	}
+
+	var entries []*logging.Entry
+	err := lgr.ProcessLogFile(func(line string) bool {
+		entry, err := logging.ParseLogLine(line)
+		if err != nil {
+			return true
+		}
+		switch {
+		// The log is newest first: the remaining entries are older
+		case !since.IsZero() && entry.Timestamp.Before(since):
+			return false
+		case ref != logging.RefAny && entry.Ref != ref:
+			return true
+		case query.Type == LogTypeMutation && (entry.IsNavigation || entry.Inert()),
+			query.Type == LogTypeNavigation && !entry.IsNavigation,
+			query.Type == LogTypeFailed && !entry.Failed:
+			return true
+		case query.Undone && !entry.Undoed:
+			return true
+		}
+		entries = append(entries, entry)
+		return query.Count <= 0 || len(entries) < query.Count
+	})
+	return entries, err
+}
+
+// nextUndoIDs returns the IDs of the entries the next `git undo` acts on (the last regular group, see run).
+func nextUndoIDs(lgr *logging.Logger) []string {
+	group, err := lgr.GetLastRegularGroup()
+	if err != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(group))
+	for _, entry := range group {
+		ids = append(ids, entry.ID())
+	}
+	return ids
+}
+
+// printPrettyLog prints the entries in colors, each with an icon of its state, when it was logged
+// relative to now, and what undoing it would do now (its undoer preview). Only the entries the next undo
+// acts on (nextUndos, by worktree) are previewed: the undo of the others depends on the state they leave.
+func (a *App) printPrettyLog(
+	gits map[string]GitHelper,
+	nextUndos map[string][]string,
+	entries []historyEntry,
+	withWorktree bool,
+) {
+	theme := a.getTheme()
+	now := entryClockNow()
+	for _, entry := range entries {
+		where := fmt.Sprintf("(%s, %s)", entry.Ref, relativeTime(entry.Timestamp, now))
+		if withWorktree {
+			where = fmt.Sprintf("(%s, %s, %s)", entry.worktree, entry.Ref, relativeTime(entry.Timestamp, now))
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s %s  %s  %s\n", theme.stateIcon(entry.Entry), theme.highlight(entry.ID()),
+			entry.TypedCommand(), theme.colorize(grayColor, where))
+
+		if undo := describeUndoRecord(entry.Entry); undo != "" {
+			_, _ = fmt.Fprintf(os.Stdout, "    %s\n", theme.colorize(grayColor, undo))
+		}
+		// Failed commands changed nothing to undo, summaries stand for commands not logged
+		if entry.Undoed || entry.Inert() {
+			continue
+		}
+		if !slices.Contains(nextUndos[entry.worktree], entry.ID()) {
+			notNext := "not the next undo"
+			if !entry.IsNavigation {
+				notNext += fmt.Sprintf(": preview it with git undo --at %s --dry-run", entry.ID())
+			}
+			_, _ = fmt.Fprintf(os.Stdout, "    %s\n", theme.colorize(grayColor, notNext))
+			continue
+		}
+		u := newUndoer(gits[entry.worktree], entry.Entry, entry.IsNavigation)
+		undoCmds, err := undoCommands(u, entry.Entry)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "    %s\n", theme.colorize(grayColor, "can't be undone: "+err.Error()))
+			continue
+		}
+		for _, undoCmd := range undoCmds {
+			_, _ = fmt.Fprintf(os.Stdout, "    %s %s\n", theme.colorize(orangeColor, "undo:"), undoCmd.Description)
+		}
+	}
+}
+
+// relativeTime renders the time relative to now (e.g. "3 minutes ago"): older than a month, as a date.
+func relativeTime(t, now time.Time) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit + " ago"
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return plural(int(age/time.Minute), "minute")
+	case age < 24*time.Hour:
+		return plural(int(age/time.Hour), "hour")
+	case age < 30*24*time.Hour:
+		return plural(int(age/(24*time.Hour)), "day")
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
+// printLogJSON prints the entries as a JSON array (empty when there are none).
+func printLogJSON(entries []historyEntry, withWorktree bool) error {
+	jsonEntries := make([]logJSONEntry, 0, len(entries))
+	for _, entry := range entries {
+		worktree := ""
+		if withWorktree {
+			worktree = entry.worktree
+		}
+		jsonEntries = append(jsonEntries, logJSONEntry{
+			ID:         entry.ID(),
+			Identifier: entry.GetIdentifier(),
+			Timestamp:  localRFC3339(entry.Timestamp),
+			Ref:        entry.Ref.String(),
+			Command:    entry.Command,
+			Typed:      entry.Metadata[logging.MetaTyped],
+			Undone:     entry.Undoed,
+			Navigation: entry.IsNavigation,
+			Failed:     entry.Failed,
+			Suppressed: entry.Suppressed(),
+			Pinned:     entry.IsPinned(),
+			Worktree:   worktree,
+			Undo:       jsonUndoRecord(entry.Entry),
+		})
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonEntries)
+}
+
+// jsonUndoRecord returns the undo record of the entry for `git undo log --json` (nil if it has none).
+func jsonUndoRecord(entry *logging.Entry) *logJSONUndo {
+	record := entry.UndoRecord()
+	if record == nil {
+		return nil
+	}
+	return &logJSONUndo{Commands: record.Commands, At: localRFC3339(record.Time), Error: record.Error}
+}
+
+// describeEntryState describes the state of the entry in a word or two (e.g. "undone, pinned").
+func describeEntryState(entry *logging.Entry) string {
+	states := []string{"done"}
+	switch {
+	case entry.Failed:
+		states[0] = "failed"
+	case entry.Summary:
+		states[0] = "summary"
+	case entry.Undoed:
+		states[0] = "undone"
+	case entry.IsNavigation:
+		states[0] = "navigation"
+	}
+	if entry.IsPinned() {
+		states = append(states, "pinned")
+	}
+	return strings.Join(states, ", ")
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// EnvPassphrase holds the passphrase of the encrypted log (see config.KeyEncrypt).
+const EnvPassphrase = "GIT_UNDO_PASSPHRASE"
+
+// newLogger returns the logger of the log of the repository set up with the config: it redacts what it logs
+// (see config.KeyRedact), and encrypts it when enabled (see config.KeyEncrypt).
+func (a *App) newLogger(gitDir string, g GitHelper) (*logging.Logger, error) {
+	lgr := logging.NewLogger(gitDir, g)
+	if lgr == nil {
+		return nil, errors.New("failed to create git-undo logger")
+	}
+	if a.cfg != nil {
+		lgr.SetRedactions(a.cfg.Redact)
+	}
+	if err := a.unlockLog(lgr); err != nil {
+		return nil, err
+	}
+	return lgr, nil
+}
+
+// newReadOnlyLogger returns a read-only logger (see logging.NewReadOnlyLogger) decrypting the encrypted log
+// when the passphrase is known. Otherwise its encrypted entries are left out, with a warning.
+func (a *App) newReadOnlyLogger(gitDir string, g GitHelper) *logging.Logger {
+	lgr := logging.NewReadOnlyLogger(gitDir, g)
+	if err := a.unlockLog(lgr); err != nil {
+		a.logWarnf("%v: its encrypted entries are left out", err)
+	}
+	return lgr
+}
+
+// unlockLog gives the logger the passphrase of the log: it's required to encrypt the log,
+// and only needed to read it once it's encrypted.
+func (a *App) unlockLog(lgr *logging.Logger) error {
+	encrypt := a.cfg != nil && a.cfg.Encrypt
+	if !encrypt && !lgr.IsEncrypted() {
+		return nil
+	}
+
+	passphrase, err := a.logPassphrase()
+	if err != nil {
+		return err
+	}
+	if passphrase == "" {
+		return fmt.Errorf("no passphrase for the encrypted log (set %s or git config %s.%s)",
+			EnvPassphrase, config.Section, config.KeyPassphraseCommand)
+	}
+	return lgr.SetPassphrase(passphrase, encrypt)
+}
+
+// logPassphrase returns the passphrase of the encrypted log: GIT_UNDO_PASSPHRASE, or what the passphrase command
+// prints (see config.KeyPassphraseCommand). It's empty when neither is set. The command runs once per run.
+func (a *App) logPassphrase() (string, error) {
+	if passphrase := os.Getenv(EnvPassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+	if a.cfg == nil || a.cfg.PassphraseCommand == "" {
+		return "", nil
+	}
+	if a.passphrase != nil {
+		return *a.passphrase, nil
+	}
+
+	cmd := exec.Command("sh", "-c", a.cfg.PassphraseCommand)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("passphrase command (%s) failed: %w", a.cfg.PassphraseCommand, err)
+	}
+	passphrase := strings.TrimRight(string(out), "\r\n")
+	a.passphrase = &passphrase
+	return passphrase, nil
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// notesRef is where undo events are mirrored as git notes, so teammates can see
+// that a rewritten history was a deliberate git-undo action.
+const notesRef = "refs/notes/git-undo"
+
+// notesRemoteRef is where remote notes are fetched to before being merged into notesRef.
+const notesRemoteRef = "refs/notes/git-undo-remote"
+
+// Subcommands of `git undo notes`.
+const (
+	NotesEnable  = "enable"
+	NotesDisable = "disable"
+	NotesSync    = "sync"
+	NotesPrune   = "prune"
+)
+
+// defaultNotesRemote is the remote notes are synced with unless another one is given.
+const defaultNotesRemote = "origin"
+
+// cmdNotes handles `git undo notes <enable|disable|sync [remote]|prune>`.
+func (a *App) cmdNotes(g GitHelper, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: git undo notes <%s|%s|%s [remote]|%s>",
+			NotesEnable, NotesDisable, NotesSync, NotesPrune)
+	}
+
+	switch args[0] {
+	case NotesEnable:
+		if err := g.GitRun("config", config.Section+"."+config.KeyNotes, "true"); err != nil {
+			return fmt.Errorf("failed to enable notes: %w", err)
+		}
+		a.logInfof("Undo events will be recorded as git notes in %s", notesRef)
+	case NotesDisable:
+		// Unsetting a missing key fails: that's fine, it's disabled anyway
+		_ = g.GitRun("config", "--unset", config.Section+"."+config.KeyNotes)
+		a.logInfof("Undo events won't be recorded as git notes anymore (existing notes are kept)")
+	case NotesSync:
+		remote := defaultNotesRemote
+		if len(args) > 1 {
+			remote = args[1]
+		}
+		return a.syncNotes(g, remote)
+	case NotesPrune:
+		if err := g.GitRun("notes", "--ref="+notesRef, "prune"); err != nil {
+			return fmt.Errorf("failed to prune notes: %w", err)
+		}
+		a.logInfof("Pruned notes of commits that no longer exist")
+	default:
+		return fmt.Errorf("unknown notes command: %s", args[0])
+	}
+
+	return nil
+}
+
+// syncNotes merges the remote's notes into the local ones and pushes the result back.
+func (a *App) syncNotes(g GitHelper, remote string) error {
+	// The remote may have no notes yet: then there's just nothing to merge
+	if err := g.GitRun("fetch", remote, "+"+notesRef+":"+notesRemoteRef); err == nil {
+		defer func() { _ = g.GitRun("update-ref", "-d", notesRemoteRef) }()
+
+		err := g.GitRun("notes", "--ref="+notesRef, "merge", "--strategy=cat_sort_uniq", notesRemoteRef)
+		if err != nil {
+			return fmt.Errorf("failed to merge notes from %s: %w", remote, err)
+		}
+	}
+
+	if _, err := g.GitOutput("rev-parse", "--verify", "-q", notesRef); err != nil {
+		return errors.New("no undo notes recorded yet")
+	}
+	if err := g.GitRun("push", remote, notesRef); err != nil {
+		return fmt.Errorf("failed to push notes to %s: %w", remote, err)
+	}
+
+	a.logInfof("Synced %s with %s", notesRef, remote)
+	return nil
+}
+
+// addUndoNote records the undo of the entry as a git note on the commit that was HEAD before the undo.
+// Failing to add a note never fails the undo itself.
+func (a *App) addUndoNote(g GitHelper, entry *logging.Entry, undoneHead string) {
+	if a.cfg == nil || !a.cfg.Notes || undoneHead == "" {
+		return
+	}
+
+	user, _ := g.GitOutput("config", "--get", "user.name")
+	message := fmt.Sprintf("git-undo: undid `%s` on %s at %s",
+		entry.Command, entry.Ref, time.Now().Format(time.RFC3339))
+	if user = strings.TrimSpace(user); user != "" {
+		message += " by " + user
+	}
+
+	if err := g.GitRun("notes", "--ref="+notesRef, "append", "-m", message, undoneHead); err != nil {
+		a.logWarnf("Failed to record the undo as a git note: %v", err)
+	}
+}
+
+// getHead returns the current HEAD commit (empty if there's none).
+func getHead(g GitHelper) string {
+	head, err := g.GitOutput("rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(head)
+}
+
+// printNotesStatus prints whether undo events are mirrored as git notes.
+func printNotesStatus(cfg *config.Config) {
+	if cfg.Notes {
+		_, _ = fmt.Fprintf(os.Stdout, "Git notes: enabled (%s)\n", notesRef)
+	} else {
+		_, _ = fmt.Fprintf(os.Stdout, "Git notes: disabled\n")
+	}
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// Output themes (`git config git-undo.theme <theme>` or GIT_UNDO_THEME env variable).
+const (
+	// ThemeEmoji is the default theme: colored output with emoji icons.
+	ThemeEmoji = "emoji"
+	// ThemeMinimal is colored output with plain ASCII labels instead of emoji.
+	ThemeMinimal = "minimal"
+	// ThemePlain has neither colors nor emoji: suitable for CI and log collectors.
+	ThemePlain = "plain"
+	// ThemeAccessible is screen reader friendly: no colors and no emoji, states spelled out
+	// in upper-case words (WARNING, UNDONE) and the log rendered as one sentence-like line per entry.
+	ThemeAccessible = "accessible"
+)
+
+// envTheme overrides the configured output theme.
+const envTheme = "GIT_UNDO_THEME"
+
+// envAccessible selects the accessible theme when set to a non-empty value other than 0
+// (the convention several CLI toolkits follow for screen reader users).
+const envAccessible = "ACCESSIBLE"
+
+// envNoColor disables colors in any theme (see https://no-color.org).
+const envNoColor = "NO_COLOR"
+
+// ANSI escape codes of colors.
+const (
+	yellowColor = "\033[33m"
+	orangeColor = "\033[38;5;208m"
+	grayColor   = "\033[90m"
+	redColor    = "\033[31m"
+	resetColor  = "\033[0m"
+)
+
+// outputLevel is the level of an output message.
+type outputLevel int
+
+const (
+	levelDebug outputLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// levelColors are the colors of message prefixes per level.
+var levelColors = map[outputLevel]string{
+	levelDebug: yellowColor,
+	levelInfo:  yellowColor,
+	levelWarn:  orangeColor,
+	levelError: redColor,
+}
+
+// outputTheme defines how messages are formatted.
+type outputTheme struct {
+	// colors enables ANSI colors.
+	colors bool
+	// labels are the per-level markers printed after the app name.
+	labels map[outputLevel]string
+	// accessible renders structured output (e.g. the log) linearly with states spelled out.
+	accessible bool
+	// emoji marks states (e.g. of log entries) with emoji rather than ASCII signs.
+	emoji bool
+}
+
+var (
+	emojiLabels = map[outputLevel]string{
+		levelDebug: "⚙️",
+		levelInfo:  "ℹ️",
+		levelWarn:  "⚠️",
+		levelError: "❌️",
+	}
+	asciiLabels = map[outputLevel]string{
+		levelDebug: "debug",
+		levelInfo:  "info",
+		levelWarn:  "warning",
+		levelError: "error",
+	}
+	accessibleLabels = map[outputLevel]string{
+		levelDebug: "DEBUG",
+		levelInfo:  "INFO",
+		levelWarn:  "WARNING",
+		levelError: "ERROR",
+	}
+)
+
+// newOutputTheme returns the theme by its name. Unknown names fall back to the default theme (ok is false then).
+func newOutputTheme(name string) (outputTheme, bool) {
+	noColor := os.Getenv(envNoColor) != ""
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ThemeEmoji, "":
+		return outputTheme{colors: !noColor, labels: emojiLabels, emoji: true}, true
+	case ThemeMinimal:
+		return outputTheme{colors: !noColor, labels: asciiLabels}, true
+	case ThemePlain:
+		return outputTheme{colors: false, labels: asciiLabels}, true
+	case ThemeAccessible:
+		return outputTheme{colors: false, labels: accessibleLabels, accessible: true}, true
+	default:
+		return outputTheme{colors: !noColor, labels: emojiLabels, emoji: true}, false
+	}
+}
+
+// defaultOutputTheme returns the theme used before the config is loaded (ACCESSIBLE, GIT_UNDO_THEME or emoji).
+func defaultOutputTheme() outputTheme {
+	theme, _ := newOutputTheme(themeName(&config.Config{}))
+	return theme
+}
+
+// themeName returns the name of the theme to use. Accessibility wins over everything else
+// (ACCESSIBLE env variable or `git-undo.accessible` config), then GIT_UNDO_THEME env variable over the config.
+func themeName(cfg *config.Config) string {
+	if value := os.Getenv(envAccessible); (value != "" && value != "0") || cfg.Accessible {
+		return ThemeAccessible
+	}
+	if name := os.Getenv(envTheme); name != "" {
+		return name
+	}
+	return cfg.Theme
+}
+
+// colorize wraps the text into the color (if colors are enabled).
+func (t outputTheme) colorize(color, text string) string {
+	if !t.colors {
+		return text
+	}
+	return color + text + resetColor
+}
+
+// highlight emphasizes a part of a message (e.g. a command to run).
+func (t outputTheme) highlight(text string) string {
+	return t.colorize(yellowColor, text)
+}
+
+// stateIcon marks the state of the log entry: done, undone, navigation, failed or summary (with a pin when pinned).
+func (t outputTheme) stateIcon(entry *logging.Entry) string {
+	var icon string
+	switch {
+	case t.emoji && entry.Failed:
+		icon = "❌"
+	case t.emoji && entry.Summary:
+		icon = "🔇"
+	case t.emoji && entry.Undoed:
+		icon = "↩️"
+	case t.emoji && entry.IsNavigation:
+		icon = "🧭"
+	case t.emoji:
+		icon = "✅"
+	case entry.Failed:
+		icon = t.colorize(redColor, "x")
+	case entry.Summary:
+		icon = t.colorize(grayColor, "~")
+	case entry.Undoed:
+		icon = t.colorize(grayColor, "-")
+	case entry.IsNavigation:
+		icon = t.colorize(yellowColor, ">")
+	default:
+		icon = t.colorize(orangeColor, "+")
+	}
+	if entry.IsPinned() {
+		if t.emoji {
+			return icon + "📌"
+		}
+		return icon + "*"
+	}
+	return icon
+}
+
+// printf writes the formatted message of the given level to w.
+func (t outputTheme) printf(w io.Writer, appName string, level outputLevel, format string, args ...any) {
+	prefix := t.colorize(levelColors[level], appName+" "+t.labels[level]+":")
+	_, _ = fmt.Fprintln(w, prefix+" "+t.colorize(grayColor, fmt.Sprintf(format, args...)))
+}
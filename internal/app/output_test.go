@@ -0,0 +1,67 @@
+package app_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputThemes(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	out, ok := app.FormatWarning(app.ThemeEmoji, "git-undo", "careful")
+	assert.True(t, ok)
+	assert.Contains(t, out, "⚠️")
+	assert.Contains(t, out, "\033[")
+
+	out, ok = app.FormatWarning(app.ThemeMinimal, "git-undo", "careful")
+	assert.True(t, ok)
+	assert.Contains(t, out, "git-undo warning:")
+	assert.Contains(t, out, "\033[")
+
+	out, ok = app.FormatWarning(app.ThemePlain, "git-undo", "careful")
+	assert.True(t, ok)
+	assert.Equal(t, "git-undo warning: careful\n", out)
+
+	out, ok = app.FormatWarning(app.ThemeAccessible, "git-undo", "careful")
+	assert.True(t, ok)
+	assert.Equal(t, "git-undo WARNING: careful\n", out)
+
+	// Unknown themes fall back to the default one
+	out, ok = app.FormatWarning("fancy", "git-undo", "careful")
+	assert.False(t, ok)
+	assert.Contains(t, out, "⚠️")
+
+	// NO_COLOR disables colors in any theme
+	t.Setenv("NO_COLOR", "1")
+	out, _ = app.FormatWarning(app.ThemeEmoji, "git-undo", "careful")
+	assert.Equal(t, "git-undo ⚠️: careful\n", out)
+}
+
+func TestAccessibleEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("GIT_UNDO_THEME", app.ThemeEmoji)
+
+	t.Setenv("ACCESSIBLE", "1")
+	assert.Equal(t, "git-undo WARNING: careful\n", app.FormatDefaultWarning("git-undo", "careful"))
+
+	t.Setenv("ACCESSIBLE", "0")
+	assert.Contains(t, app.FormatDefaultWarning("git-undo", "careful"), "⚠️")
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := map[time.Duration]string{
+		10 * time.Second:    "just now",
+		time.Minute:         "1 minute ago",
+		3 * time.Minute:     "3 minutes ago",
+		2 * time.Hour:       "2 hours ago",
+		49 * time.Hour:      "2 days ago",
+		40 * 24 * time.Hour: "2025-02-19",
+	}
+	for ago, expected := range tests {
+		assert.Equal(t, expected, app.RelativeTime(now.Add(-ago), now), ago.String())
+	}
+}
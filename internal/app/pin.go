@@ -0,0 +1,47 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// cmdPin handles `git undo pin <id>` and `git undo unpin <id>`.
+// Pinned entries are never removed from the log by truncation or pruning.
+func (a *App) cmdPin(lgr *logging.Logger, args []string, pin bool) error {
+	cmdName, value := CommandUnpin, ""
+	if pin {
+		cmdName, value = CommandPin, "1"
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: git undo %s <id>", cmdName)
+	}
+
+	entry, err := lgr.GetEntryByID(args[0])
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("no log entry with id %s", args[0])
+	}
+
+	if entry.IsPinned() == pin {
+		a.logInfof("Entry %s is already %s: %s", entry.ID(), pinState(pin), entry.Command)
+		return nil
+	}
+
+	if err := lgr.SetEntryMeta(entry.GetIdentifier(), logging.MetaPinned, value); err != nil {
+		return fmt.Errorf("failed to %s entry %s: %w", cmdName, entry.ID(), err)
+	}
+
+	a.logInfof("Entry %s is %s: %s", entry.ID(), pinState(pin), entry.Command)
+	return nil
+}
+
+// pinState returns the human-readable pin state.
+func pinState(pinned bool) string {
+	if pinned {
+		return "pinned"
+	}
+	return "unpinned"
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/snapshot"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// preStateCaptures capture the state commands are about to change (which can't be known once they ran),
+// by git subcommand. Shell hooks only call the pre-hook for these subcommands and destructive ones
+// (whose files are snapshotted, see takeSnapshot).
+var preStateCaptures = map[string]func(g GitHelper, gitCmd *githelpers.GitCommand) map[string]string{
+	"add":    captureIndex,
+	"branch": captureBranchTips,
+	"config": captureConfig,
+	"remote": captureRemote,
+	"stash":  captureStash,
+	"tag":    captureTagObjects,
+}
+
+// cmdPreHook captures the state the hooked git command is about to change: it's recorded into
+// the entry of the command once it's logged by cmdHook.
+func (a *App) cmdPreHook(gitDir string, g GitHelper, verbose bool, hooked, shell string) error {
+	a.logDebugf(verbose, "pre-hook: start")
+
+	hooked, gitCmd, err := a.hookedCommand(gitDir, g, verbose, hooked, shell)
+	if err != nil || gitCmd == nil {
+		return err
+	}
+	meta := map[string]string{}
+	if capture, ok := preStateCaptures[gitCmd.Name]; ok {
+		maps.Copy(meta, capture(g, gitCmd))
+	}
+	if snapshot.IsDestructive(gitCmd.Name, gitCmd.Args) {
+		if id := a.takeSnapshot(gitDir, g, verbose, hooked, gitCmd); id != "" {
+			meta[logging.MetaSnapshot] = id
+		}
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+
+	lgr, err := a.newLogger(gitDir, g)
+	if err != nil {
+		return err
+	}
+	if err := lgr.SavePreState(hooked, meta); err != nil {
+		return err
+	}
+
+	a.logDebugf(verbose, "pre-hook: captured the state changed by %q", hooked)
+	return nil
+}
+
+// captureIndex records the tree of the index the command is about to stage changes to.
+// It can't be written while there are unmerged paths: the undo of the command is just less precise then.
+func captureIndex(g GitHelper, _ *githelpers.GitCommand) map[string]string {
+	tree, err := g.GitOutput("write-tree")
+	if err != nil || tree == "" {
+		return nil
+	}
+	return map[string]string{logging.MetaIndexBefore: tree}
+}
+
+// captureBranchTips records the tips of the branches the command deletes.
+func captureBranchTips(g GitHelper, gitCmd *githelpers.GitCommand) map[string]string {
+	branches, ok := undoer.BranchDeletionTargets(gitCmd.Args)
+	if !ok {
+		return nil
+	}
+
+	tips := make(map[string]string)
+	for _, branch := range branches {
+		if tip, err := g.GitOutput("rev-parse", "--verify", "-q", "refs/heads/"+branch); err == nil {
+			tips[branch] = tip
+		}
+	}
+	if len(tips) == 0 {
+		return nil
+	}
+	return logging.BranchTipsMeta(tips)
+}
+
+// captureTagObjects records the objects of the tags the command deletes or may move.
+func captureTagObjects(g GitHelper, gitCmd *githelpers.GitCommand) map[string]string {
+	tags, ok := undoer.TagChangeTargets(gitCmd.Args)
+	if !ok {
+		return nil
+	}
+
+	objects := make(map[string]string)
+	for _, tag := range tags {
+		if object, err := g.GitOutput("rev-parse", "--verify", "-q", "refs/tags/"+tag); err == nil {
+			objects[tag] = object
+		}
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+	return logging.TagObjectsMeta(objects)
+}
+
+// captureConfig records the values of the config key the command changes (in the scope it changes).
+func captureConfig(g GitHelper, gitCmd *githelpers.GitCommand) map[string]string {
+	scope, key, ok := undoer.ConfigTarget(gitCmd.Args)
+	if !ok {
+		return nil
+	}
+
+	// git config exits with 1 when the key isn't set
+	output, err := g.GitOutput("config", append(scope, "--get-all", key)...)
+	if err != nil {
+		return logging.ConfigBeforeMeta(key, nil, false)
+	}
+	return logging.ConfigBeforeMeta(key, strings.Split(strings.TrimSuffix(output, "\n"), "\n"), true)
+}
+
+// captureRemote records the config of the remote the command changes or removes.
+func captureRemote(g GitHelper, gitCmd *githelpers.GitCommand) map[string]string {
+	name, ok := undoer.RemoteTarget(gitCmd.Args)
+	if !ok {
+		return nil
+	}
+
+	output, err := g.GitOutput("config", "--local", "-z", "--get-regexp", undoer.RemoteConfigPattern(name))
+	if err != nil {
+		return nil
+	}
+	return map[string]string{logging.MetaRemoteConfig: output}
+}
+
+// captureStash records the commit of the stash the command pops, applies or drops.
+func captureStash(g GitHelper, gitCmd *githelpers.GitCommand) map[string]string {
+	rev, ok := undoer.StashTarget(gitCmd.Args)
+	if !ok {
+		return nil
+	}
+
+	stash, err := g.GitOutput("rev-parse", "--verify", "-q", rev)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{logging.MetaStash: strings.TrimSpace(stash)}
+}
+
+// targetPreState makes the undoer of the entry restore the state recorded before its command.
+func targetPreState(g GitHelper, u undoer.Undoer, entry *logging.Entry) {
+	if snapshotUndoer, ok := u.(interface{ TargetSnapshot(*snapshot.Snapshot) }); ok {
+		if snap := entrySnapshot(g, entry); snap != nil {
+			snapshotUndoer.TargetSnapshot(snap)
+		}
+	}
+
+	switch u := u.(type) {
+	case *undoer.AddUndoer:
+		if before := entry.Metadata[logging.MetaIndexBefore]; before != "" {
+			u.RecordedIndex(before, entry.Metadata[logging.MetaIndex])
+		}
+	case *undoer.BranchUndoer:
+		if entry.Metadata[logging.MetaBranchTips] != "" {
+			u.RecordedTips(entry.BranchTips())
+		}
+	case *undoer.ConfigUndoer:
+		if entry.Metadata[logging.MetaConfigKey] != "" {
+			u.RecordedValues(entry.ConfigBefore())
+		}
+	case *undoer.CommitUndoer:
+		if amended := entry.Metadata[logging.MetaAmended]; amended != "" {
+			u.TargetAmended(amended)
+		}
+		if head, _ := entry.State(); head != "" {
+			u.TargetCommit(head)
+		}
+	case *undoer.TagUndoer:
+		if entry.Metadata[logging.MetaTagObjects] != "" {
+			u.RecordedObjects(entry.TagObjects())
+		}
+	case *undoer.RemoteUndoer:
+		if config, ok := entry.Metadata[logging.MetaRemoteConfig]; ok {
+			u.RecordedConfig(config)
+		}
+	}
+}
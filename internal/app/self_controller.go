@@ -16,6 +16,13 @@ const (
 	CommandUninstall = "uninstall"
 	CommandVersion   = "version"
 	CommandHelp      = "help"
+	CommandInfo      = "info"
+	// CommandInstallHooks installs the shell hook (of the --shell one or $SHELL) and the git hooks.
+	CommandInstallHooks = "install-hooks"
+	// CommandUninstallHooks removes the git hooks.
+	CommandUninstallHooks = "uninstall-hooks"
+	// CommandUpdateHooks refreshes the git hooks where they are installed.
+	CommandUpdateHooks = "update-hooks"
 )
 
 // ErrNotSelfCommand is returned when the command is not a self command.
@@ -27,6 +34,10 @@ var allowedSelfCommands = []string{
 	CommandUninstall,
 	CommandVersion,
 	CommandHelp,
+	CommandInfo,
+	CommandInstallHooks,
+	CommandUninstallHooks,
+	CommandUpdateHooks,
 }
 
 // SelfController handles self-management commands that don't require a git repository.
@@ -39,6 +50,14 @@ type SelfController struct {
 
 	// scripts is a map of self-management commands to their scripts.
 	scripts map[string]string
+	// hooks is a map of hook names (see Hook* constants) to the hook scripts shipped with the binary.
+	hooks map[string]string
+	// json prints reports as JSON.
+	json bool
+	// dir is where git commands of reports run (the current directory if empty).
+	dir string
+	// shell is the shell `install-hooks` installs the hook of ($SHELL if empty).
+	shell string
 }
 
 // NewSelfController creates a new SelfController instance.
@@ -55,6 +74,7 @@ func NewSelfController(
 		appName:       appName,
 		ctx:           ctx,
 		scripts:       map[string]string{},
+		hooks:         map[string]string{},
 	}
 }
 
@@ -77,19 +97,19 @@ func (sc *SelfController) HandleSelfCommand(args []string) error {
 
 	switch selfCommand {
 	case CommandUpdate:
-		if sc.appName == appNameGitBack {
+		if sc.appName != appNameGitUndo {
 			return fmt.Errorf(
 				"%s does not support update command. Use %s self update instead",
-				appNameGitBack,
+				sc.appName,
 				appNameGitUndo,
 			)
 		}
 		return sc.cmdSelfUpdate()
 	case CommandUninstall:
-		if sc.appName == appNameGitBack {
+		if sc.appName != appNameGitUndo {
 			return fmt.Errorf(
 				"%s does not support uninstall command. Use %s self uninstall instead",
-				appNameGitBack,
+				sc.appName,
 				appNameGitUndo,
 			)
 		}
@@ -98,6 +118,26 @@ func (sc *SelfController) HandleSelfCommand(args []string) error {
 		return sc.cmdVersion()
 	case CommandHelp:
 		return sc.cmdHelp()
+	case CommandInfo:
+		return sc.cmdInfo()
+	case CommandInstallHooks, CommandUninstallHooks, CommandUpdateHooks:
+		if sc.appName != appNameGitUndo {
+			return fmt.Errorf(
+				"%s does not support %s command. Use %s self %s instead",
+				sc.appName,
+				selfCommand,
+				appNameGitUndo,
+				selfCommand,
+			)
+		}
+		switch selfCommand {
+		case CommandUninstallHooks:
+			return sc.cmdUninstallHooks()
+		case CommandUpdateHooks:
+			return sc.cmdUpdateHooks()
+		default:
+			return sc.cmdInstallHooks()
+		}
 	}
 
 	return ErrNotSelfCommand
@@ -145,13 +185,29 @@ func (sc *SelfController) cmdVersion() error {
 func (sc *SelfController) cmdHelp() error {
 	if sc.appName == appNameGitBack {
 		fmt.Fprintf(os.Stdout, "%s %s\n", appNameGitBack, sc.version)
-		fmt.Fprintf(os.Stdout, "Usage: %s\n", appNameGitBack)
+		fmt.Fprintf(os.Stdout, "Usage: %s [-n <count>] [--forward] [--list]\n", appNameGitBack)
 		fmt.Fprintf(os.Stdout, "\n")
 		fmt.Fprintf(os.Stdout, "Git-back undoes the last git checkout or git switch command,\n")
 		fmt.Fprintf(os.Stdout, "returning you to the previous branch or commit.\n")
+		fmt.Fprintf(os.Stdout, "With -n it goes back several steps of the navigation history (see --list),\n")
+		fmt.Fprintf(os.Stdout, "and with --forward it retraces them like a browser does.\n")
 		fmt.Fprintf(os.Stdout, "\n")
 		fmt.Fprintf(os.Stdout, "Commands:\n")
 		fmt.Fprintf(os.Stdout, "  version   Display %s version\n", appNameGitBack)
+		fmt.Fprintf(os.Stdout, "  info      Display build, install and config details (--json for JSON)\n")
+		fmt.Fprintf(os.Stdout, "  help      Display this help\n")
+		return nil
+	}
+
+	if sc.appName == appNameGitRedo {
+		fmt.Fprintf(os.Stdout, "%s %s\n", appNameGitRedo, sc.version)
+		fmt.Fprintf(os.Stdout, "Usage: %s [-n <count>]\n", appNameGitRedo)
+		fmt.Fprintf(os.Stdout, "\n")
+		fmt.Fprintf(os.Stdout, "Git-redo re-runs the last command undone by git undo (same as git undo undo).\n")
+		fmt.Fprintf(os.Stdout, "\n")
+		fmt.Fprintf(os.Stdout, "Commands:\n")
+		fmt.Fprintf(os.Stdout, "  version   Display %s version\n", appNameGitRedo)
+		fmt.Fprintf(os.Stdout, "  info      Display build, install and config details (--json for JSON)\n")
 		fmt.Fprintf(os.Stdout, "  help      Display this help\n")
 		return nil
 	}
@@ -161,9 +217,19 @@ func (sc *SelfController) cmdHelp() error {
 	fmt.Fprintf(os.Stdout, "Usage: %s [command]\n", appNameGitUndo)
 	fmt.Fprintf(os.Stdout, "\n")
 	fmt.Fprintf(os.Stdout, "Commands:\n")
+	fmt.Fprintf(os.Stdout, "  status    Show %s status of the current repository\n", appNameGitUndo)
+	fmt.Fprintf(os.Stdout, "  notes     Mirror undo events as git notes (enable|disable|sync|prune)\n")
+	fmt.Fprintf(os.Stdout, "  pin       Protect a log entry from truncation (pin|unpin <id>)\n")
+	fmt.Fprintf(os.Stdout, "  checkpoint  Save, list and delete named checkpoints (save|list|delete <name>)\n")
+	fmt.Fprintf(os.Stdout, "  restore   Go back to a checkpoint (restore <name>)\n")
 	fmt.Fprintf(os.Stdout, "  update    Update %s to the latest version\n", appNameGitUndo)
 	fmt.Fprintf(os.Stdout, "  uninstall Uninstall %s\n", appNameGitUndo)
 	fmt.Fprintf(os.Stdout, "  version   Display %s version\n", appNameGitUndo)
+	fmt.Fprintf(os.Stdout, "  info      Display build, install and config details (--json for JSON)\n")
+	fmt.Fprintf(os.Stdout,
+		"  install-hooks  Install the shell hook (--shell bash|zsh|fish|powershell) and the git hooks (--shell git)\n")
+	fmt.Fprintf(os.Stdout, "  uninstall-hooks  Remove the git hooks\n")
+	fmt.Fprintf(os.Stdout, "  update-hooks  Refresh the installed git hooks\n")
 	fmt.Fprintf(os.Stdout, "  help      Display this help\n")
 	return nil
 }
@@ -176,7 +242,20 @@ func (sc *SelfController) cmdSelfUpdate() error {
 		return errors.New("update script not available")
 	}
 
-	return sc.runEmbeddedScript(updateScript, "update")
+	if err := sc.runEmbeddedScript(updateScript, "update"); err != nil {
+		return err
+	}
+
+	// The git hooks come from the binary: the new one refreshes them
+	//nolint:gosec // runs the binary just installed
+	cmd := exec.CommandContext(sc.ctx, appNameGitUndo, Self, CommandUpdateHooks)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		defaultOutputTheme().printf(os.Stderr, sc.appName, levelWarn,
+			"failed to update the git hooks (run %s self %s): %v", appNameGitUndo, CommandUpdateHooks, err)
+	}
+	return nil
 }
 
 // cmdSelfUninstall runs the embedded self-uninstall script.
@@ -238,5 +317,5 @@ func (sc *SelfController) logDebugf(format string, args ...any) {
 		return
 	}
 
-	fmt.Fprintf(os.Stderr, yellowColor+sc.appName+" ⚙️: "+grayColor+format+resetColor+"\n", args...)
+	defaultOutputTheme().printf(os.Stderr, sc.appName, levelDebug, format, args...)
 }
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// Install methods `git undo self info` tells apart.
+const (
+	installMethodBrew   = "brew"
+	installMethodGo     = "go install"
+	installMethodScript = "install script"
+	installMethodManual = "manual"
+)
+
+// Hook scripts as installed by the install script (see scripts/src/common.sh) or by `self install-hooks`.
+const (
+	HookBash       = "bash"
+	HookZsh        = "zsh"
+	HookFish       = "fish"
+	HookPowerShell = "powershell"
+	HookGit        = "git"
+)
+
+// hookInstallPaths are where the hooks are installed (relative to the config dir).
+var hookInstallPaths = map[string]string{
+	HookBash:       "git-undo-hook.bash",
+	HookZsh:        "git-undo-hook.zsh",
+	HookFish:       "git-undo-hook.fish",
+	HookPowerShell: "git-undo-hook.ps1",
+	HookGit:        filepath.Join("hooks", "git-hooks.sh"),
+}
+
+// States of installed hook scripts compared to the ones shipped with the binary.
+const (
+	hookStateCurrent      = "current"
+	hookStateDiffers      = "differs"
+	hookStateNotInstalled = "not installed"
+)
+
+// selfInfo is the report of `git undo self info`: what bug reports and packaging checks need.
+type selfInfo struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	VersionSource string `json:"versionSource"`
+	Commit        string `json:"commit,omitempty"`
+	// Modified tells that the binary was built from a checkout with uncommitted changes.
+	Modified      bool             `json:"modified,omitempty"`
+	BuildDate     string           `json:"buildDate,omitempty"`
+	GoVersion     string           `json:"goVersion"`
+	Platform      string           `json:"platform"`
+	Executable    string           `json:"executable"`
+	InstallMethod string           `json:"installMethod"`
+	ConfigDir     string           `json:"configDir"`
+	Hooks         []selfHookInfo   `json:"hooks"`
+	ConfigFiles   []selfConfigFile `json:"configFiles"`
+}
+
+// selfHookInfo is an installed hook script.
+type selfHookInfo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// State tells whether the installed script is the one shipped with this binary (see hookState* constants).
+	State string `json:"state"`
+	// Checksum is the short SHA-256 of the installed script (to compare installations).
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// selfConfigFile is a git config file holding git-undo settings.
+type selfConfigFile struct {
+	Path string   `json:"path"`
+	Keys []string `json:"keys"`
+}
+
+// AddHook registers the hook script shipped with the binary, so installed hooks are compared against it.
+func (sc *SelfController) AddHook(name, script string) *SelfController {
+	sc.hooks[name] = script
+	return sc
+}
+
+// WithJSON makes reports (e.g. `self info`) printed as JSON.
+func (sc *SelfController) WithJSON(enabled bool) *SelfController {
+	sc.json = enabled
+	return sc
+}
+
+// InDir makes git commands of reports run in the directory (e.g. to include the repository config).
+func (sc *SelfController) InDir(dir string) *SelfController {
+	sc.dir = dir
+	return sc
+}
+
+// cmdInfo prints the build, installation and configuration report.
+func (sc *SelfController) cmdInfo() error {
+	info := sc.collectInfo()
+	if sc.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	orUnknown := func(value string) string {
+		if value == "" {
+			return "unknown"
+		}
+		return value
+	}
+	commit := orUnknown(info.Commit)
+	if info.Modified {
+		commit += " (modified)"
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "%s %s (obtained via %s)\n", info.Name, info.Version, info.VersionSource)
+	_, _ = fmt.Fprintf(os.Stdout, "Commit: %s\n", commit)
+	_, _ = fmt.Fprintf(os.Stdout, "Build date: %s\n", orUnknown(info.BuildDate))
+	_, _ = fmt.Fprintf(os.Stdout, "Go version: %s %s\n", info.GoVersion, info.Platform)
+	_, _ = fmt.Fprintf(os.Stdout, "Executable: %s\n", orUnknown(info.Executable))
+	_, _ = fmt.Fprintf(os.Stdout, "Install method: %s\n", info.InstallMethod)
+	_, _ = fmt.Fprintf(os.Stdout, "Config dir: %s\n", info.ConfigDir)
+	_, _ = fmt.Fprintf(os.Stdout, "Hooks:\n")
+	for _, hook := range info.Hooks {
+		_, _ = fmt.Fprintf(os.Stdout, "  %-10s %s (%s)\n", hook.Name, hook.Path, hook.State)
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "Config files:\n")
+	if len(info.ConfigFiles) == 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "  none (defaults are used)\n")
+	}
+	for _, file := range info.ConfigFiles {
+		_, _ = fmt.Fprintf(os.Stdout, "  %s: %s\n", file.Path, strings.Join(file.Keys, ", "))
+	}
+	return nil
+}
+
+// collectInfo gathers the report of `self info`.
+func (sc *SelfController) collectInfo() selfInfo {
+	info := selfInfo{
+		Name:          sc.appName,
+		Version:       sc.version,
+		VersionSource: sc.versionSource,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		ConfigDir:     configDir(),
+		Hooks:         []selfHookInfo{},
+		ConfigFiles:   []selfConfigFile{},
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.BuildDate = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if executable, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+			executable = resolved
+		}
+		info.Executable = executable
+	}
+	info.InstallMethod = detectInstallMethod(info.Executable, info.ConfigDir)
+
+	names := make([]string, 0, len(hookInstallPaths))
+	for name := range hookInstallPaths {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		info.Hooks = append(info.Hooks, sc.hookInfo(name, filepath.Join(info.ConfigDir, hookInstallPaths[name])))
+	}
+
+	info.ConfigFiles = configFiles(sc.ctx, sc.dir)
+	return info
+}
+
+// hookInfo describes the hook installed at the path.
+func (sc *SelfController) hookInfo(name, path string) selfHookInfo {
+	hook := selfHookInfo{Name: name, Path: path, State: hookStateNotInstalled}
+	installed, err := os.ReadFile(path)
+	if err != nil {
+		return hook
+	}
+
+	sum := sha256.Sum256(installed)
+	hook.Checksum = hex.EncodeToString(sum[:])[:12]
+	hook.State = hookStateDiffers
+	if shipped, ok := sc.hooks[name]; ok && bytes.Equal(installed, []byte(shipped)) {
+		hook.State = hookStateCurrent
+	}
+	return hook
+}
+
+// configDir returns the directory the install script puts hooks into.
+func configDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join("~", ".config", appNameGitUndo)
+	}
+	return filepath.Join(home, ".config", appNameGitUndo)
+}
+
+// detectInstallMethod guesses how the executable was installed from where it lives.
+func detectInstallMethod(executable, cfgDir string) string {
+	for _, marker := range []string{"/Cellar/", "/homebrew/", "/linuxbrew/"} {
+		if strings.Contains(executable, marker) {
+			return installMethodBrew
+		}
+	}
+
+	// The install script uses `go install` too, and leaves its hooks behind
+	dir := filepath.Dir(executable)
+	if executable != "" && slices.Contains(goBinDirs(), dir) {
+		if _, err := os.Stat(cfgDir); err == nil {
+			return installMethodScript
+		}
+		return installMethodGo
+	}
+	return installMethodManual
+}
+
+// goBinDirs returns the directories `go install` puts binaries into.
+func goBinDirs() []string {
+	var dirs []string
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		dirs = append(dirs, filepath.Clean(gobin))
+	}
+	for _, gopath := range filepath.SplitList(os.Getenv("GOPATH")) {
+		if gopath != "" {
+			dirs = append(dirs, filepath.Join(gopath, "bin"))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "go", "bin"))
+	}
+	return dirs
+}
+
+// configFiles returns the git config files setting git-undo options (see config.Section), in git's order.
+func configFiles(ctx context.Context, dir string) []selfConfigFile {
+	files := []selfConfigFile{}
+	cmd := exec.CommandContext(ctx, "git", "config", "--show-origin", "--name-only", "--get-regexp", `^git-undo\.`)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return files
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		origin, key, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		path := strings.TrimPrefix(origin, "file:")
+		index := slices.IndexFunc(files, func(f selfConfigFile) bool { return f.Path == path })
+		if index < 0 {
+			files = append(files, selfConfigFile{Path: path})
+			index = len(files) - 1
+		}
+		if !slices.Contains(files[index].Keys, key) {
+			files[index].Keys = append(files[index].Keys, key)
+		}
+	}
+	return files
+}
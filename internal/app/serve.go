@@ -0,0 +1,235 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// serveMaxRequestSize is the maximum size of a request line.
+const serveMaxRequestSize = 1 << 20
+
+// Methods of the `git undo serve` protocol.
+const (
+	serveMethodHistory = "history"
+	serveMethodPreview = "preview"
+	serveMethodUndo    = "undo"
+	serveMethodRedo    = "redo"
+)
+
+// serveServer serves `git undo serve`: the history and the undo of the repository to a long-lived client
+// (e.g. an editor integration), one JSON request per line, each answered by one JSON response line.
+type serveServer struct {
+	app    *App
+	g      GitHelper
+	gitDir string
+
+	// mu serializes the requests: they share the app (its messages) and the repository.
+	mu sync.Mutex
+}
+
+// serveRequest is a request: the method with its parameters. ID is echoed in the response, so clients
+// can match them.
+type serveRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params serveParams     `json:"params"`
+}
+
+// serveParams are the parameters of the methods (each one uses some of them).
+type serveParams struct {
+	// ID selects the entry to preview or undo (the latest one by default).
+	ID string `json:"id"`
+	// Limit is how many of the latest entries history returns (all of them if not positive).
+	Limit int `json:"limit"`
+	// MessageOnly, CommitMode and Force are the --message-only, --soft/--unstage/--hard and --force options.
+	MessageOnly bool   `json:"messageOnly"`
+	CommitMode  string `json:"commitMode"`
+	Force       bool   `json:"force"`
+}
+
+// serveResponse is the response to a request: its result or its error, along with the messages
+// (info, warnings) the app wrote while handling it.
+type serveResponse struct {
+	ID       json.RawMessage `json:"id,omitempty"`
+	Result   any             `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Messages []string        `json:"messages,omitempty"`
+}
+
+// cmdServe handles `git undo serve [<socket>]`: it serves the JSON protocol over stdin/stdout
+// until stdin is closed, or on the given unix socket until interrupted.
+func (a *App) cmdServe(ctx context.Context, g GitHelper, gitDir string, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: %s %s [<socket>]", a.getAppName(), CommandServe)
+	}
+
+	ss := &serveServer{app: a, g: g, gitDir: gitDir}
+	if len(args) == 0 {
+		return ss.serve(ctx, a.getStdin(), os.Stdout)
+	}
+	return ss.listen(ctx, args[0])
+}
+
+// listen serves the connections of the unix socket until the context is done. Only the user can connect.
+func (ss *serveServer) listen(ctx context.Context, path string) error {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("%s already exists and is not a socket", path)
+		}
+		// The socket of a server that's gone is left behind: it's only in use while it accepts connections
+		if conn, err := (&net.Dialer{}).DialContext(ctx, "unix", path); err == nil {
+			_ = conn.Close()
+			return fmt.Errorf("%s is already served", path)
+		}
+		_ = os.Remove(path)
+	}
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	defer listener.Close()
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("failed to restrict the socket: %w", err)
+	}
+	context.AfterFunc(ctx, func() { _ = listener.Close() })
+
+	ss.app.logInfof("Serving the undo history at %s (Ctrl+C to stop)", ss.app.getTheme().highlight(path))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+			defer stop()
+			defer conn.Close()
+			_ = ss.serve(ctx, conn, conn)
+		}()
+	}
+}
+
+// serve answers the requests read from r until it's exhausted (or the context is done).
+func (ss *serveServer) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), serveMaxRequestSize)
+	encoder := json.NewEncoder(w)
+	for ctx.Err() == nil && scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := encoder.Encode(ss.handle(ctx, line)); err != nil {
+			return fmt.Errorf("failed to write the response: %w", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers the request line. Messages the app writes meanwhile are collected into the response,
+// as plain text: the output of the server is only the protocol.
+func (ss *serveServer) handle(ctx context.Context, line string) serveResponse {
+	var req serveRequest
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return serveResponse{Error: fmt.Sprintf("invalid request: %s", err)}
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var messages strings.Builder
+	theme, _ := newOutputTheme(ThemePlain)
+	prevMessages, prevTheme := ss.app.messages, ss.app.theme
+	ss.app.messages, ss.app.theme = &messages, &theme
+	defer func() { ss.app.messages, ss.app.theme = prevMessages, prevTheme }()
+
+	result, err := ss.call(ctx, req.Method, req.Params)
+	resp := serveResponse{ID: req.ID, Result: result}
+	if err != nil {
+		resp.Result, resp.Error = nil, err.Error()
+	}
+	for _, message := range strings.Split(messages.String(), "\n") {
+		if message = strings.TrimSpace(message); message != "" {
+			resp.Messages = append(resp.Messages, message)
+		}
+	}
+	return resp
+}
+
+// call runs the method.
+func (ss *serveServer) call(ctx context.Context, method string, params serveParams) (any, error) {
+	opts := RunOptions{
+		EntryID:     params.ID,
+		MessageOnly: params.MessageOnly,
+		CommitMode:  params.CommitMode,
+		Force:       params.Force,
+	}
+
+	switch method {
+	case serveMethodHistory:
+		entries, err := ss.app.newReadOnlyLogger(ss.gitDir, ss.g).GetEntries(params.Limit, nil)
+		if err != nil {
+			return nil, err
+		}
+		history := make([]webEntry, 0, len(entries))
+		for _, entry := range entries {
+			history = append(history, newWebEntry(entry))
+		}
+		return history, nil
+
+	case serveMethodPreview:
+		entry, undoCmds, err := ss.app.planUndo(ss.g, ss.gitDir, opts)
+		if err != nil {
+			return nil, err
+		}
+		return newUndoPlan(ss.g, false, entry, undoCmds), nil
+
+	case serveMethodUndo:
+		return ss.run(ctx, opts)
+
+	case serveMethodRedo:
+		return ss.run(ctx, RunOptions{Args: []string{githelpers.CustomCommandUndo}})
+
+	default:
+		return nil, fmt.Errorf("unknown method %q (supported: %s, %s, %s, %s)",
+			method, serveMethodHistory, serveMethodPreview, serveMethodUndo, serveMethodRedo)
+	}
+}
+
+// run runs the undo (or redo) like the CLI would. The client previewed it already,
+// so it never asks for confirmation: stdin may be the protocol itself.
+func (ss *serveServer) run(ctx context.Context, opts RunOptions) (any, error) {
+	if err := checkLogOwnership(ss.gitDir); err != nil {
+		return nil, err
+	}
+	lgr, err := ss.app.newLogger(ss.gitDir, ss.g)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed := ss.app.confirmed
+	ss.app.confirmed = true
+	defer func() { ss.app.confirmed = confirmed }()
+
+	if err := ss.app.run(ctx, lgr, ss.g, opts); err != nil {
+		return nil, err
+	}
+	return map[string]bool{"ok": true}, nil
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Signing formats supported by git (gpg.format).
+const (
+	signingFormatOpenPGP = "openpgp"
+	signingFormatSSH     = "ssh"
+	signingFormatX509    = "x509"
+)
+
+// commitSigning describes how commits are signed in the repository.
+type commitSigning struct {
+	// enabled is true when commit.gpgsign is set.
+	enabled bool
+	// format is gpg.format (openpgp by default).
+	format string
+	// program is the signing program that git would call.
+	program string
+}
+
+// detectCommitSigning reads commit signing configuration of the repository.
+func detectCommitSigning(g GitHelper) commitSigning {
+	cs := commitSigning{format: signingFormatOpenPGP}
+
+	if out, err := g.GitOutput("config", "--type=bool", "--get", "commit.gpgsign"); err == nil {
+		cs.enabled = strings.TrimSpace(out) == "true"
+	}
+	if out, err := g.GitOutput("config", "--get", "gpg.format"); err == nil && strings.TrimSpace(out) != "" {
+		cs.format = strings.TrimSpace(out)
+	}
+
+	// Defaults are the ones git itself uses when gpg.<format>.program is not set
+	defaultPrograms := map[string]string{
+		signingFormatOpenPGP: "gpg",
+		signingFormatSSH:     "ssh-keygen",
+		signingFormatX509:    "gpgsm",
+	}
+	cs.program = defaultPrograms[cs.format]
+	programKeys := []string{"gpg." + cs.format + ".program"}
+	if cs.format == signingFormatOpenPGP {
+		// gpg.program is the legacy name of gpg.openpgp.program
+		programKeys = append(programKeys, "gpg.program")
+	}
+	for _, key := range programKeys {
+		if out, err := g.GitOutput("config", "--get", key); err == nil && strings.TrimSpace(out) != "" {
+			cs.program = strings.TrimSpace(out)
+			break
+		}
+	}
+
+	return cs
+}
+
+// available checks if the signing program can be found.
+func (cs commitSigning) available() bool {
+	if cs.program == "" {
+		return false
+	}
+	_, err := exec.LookPath(cs.program)
+	return err == nil
+}
+
+// isSignFlag checks if the commit argument explicitly requests signing (-S, -S<key>, --gpg-sign[=<key>]).
+func isSignFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-S") || arg == "--gpg-sign" || strings.HasPrefix(arg, "--gpg-sign=")
+}
+
+// withCommitSigning adjusts the args of a redone commit so it's signed the same way the original one was.
+// It returns adjusted args, whether the commit is going to be signed and a warning (if any).
+func withCommitSigning(args []string, cs commitSigning, signerAvailable bool) ([]string, bool, string) {
+	if slices.Contains(args, "--no-gpg-sign") {
+		return args, false, ""
+	}
+
+	explicit := slices.ContainsFunc(args, isSignFlag)
+	if !explicit && !cs.enabled {
+		return args, false, ""
+	}
+
+	if !signerAvailable {
+		unsigned := slices.DeleteFunc(slices.Clone(args), isSignFlag)
+		unsigned = append(unsigned, "--no-gpg-sign")
+		warning := "signing program " + cs.program + " is not available: the redone commit will NOT be signed"
+		return unsigned, false, warning
+	}
+
+	if explicit {
+		return args, true, ""
+	}
+	return append(slices.Clone(args), "--gpg-sign"), true, ""
+}
+
+// describeSignatureStatus converts `git log --format=%G?` output into a human-readable status.
+func describeSignatureStatus(status string) string {
+	switch strings.TrimSpace(status) {
+	case "G":
+		return "signed (good signature)"
+	case "U":
+		return "signed (good signature, unknown validity)"
+	case "X":
+		return "signed (expired signature)"
+	case "Y":
+		return "signed (expired key)"
+	case "R":
+		return "signed (revoked key)"
+	case "E":
+		return "signed (signature cannot be checked)"
+	case "B":
+		return "signed (BAD signature)"
+	default:
+		return "unsigned"
+	}
+}
@@ -0,0 +1,78 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCommitSigning(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		signingEnabled  bool
+		signerAvailable bool
+		expectedArgs    []string
+		expectedSigned  bool
+		expectWarning   bool
+	}{
+		{
+			name:            "no signing configured",
+			args:            []string{"-m", "msg"},
+			signerAvailable: true,
+			expectedArgs:    []string{"-m", "msg"},
+		},
+		{
+			name:            "commit.gpgsign enabled",
+			args:            []string{"-m", "msg"},
+			signingEnabled:  true,
+			signerAvailable: true,
+			expectedArgs:    []string{"-m", "msg", "--gpg-sign"},
+			expectedSigned:  true,
+		},
+		{
+			name:            "explicit -S with key is kept",
+			args:            []string{"-SABCDEF", "-m", "msg"},
+			signerAvailable: true,
+			expectedArgs:    []string{"-SABCDEF", "-m", "msg"},
+			expectedSigned:  true,
+		},
+		{
+			name:            "explicit --no-gpg-sign wins",
+			args:            []string{"--no-gpg-sign", "-m", "msg"},
+			signingEnabled:  true,
+			signerAvailable: true,
+			expectedArgs:    []string{"--no-gpg-sign", "-m", "msg"},
+		},
+		{
+			name:           "signer unavailable with commit.gpgsign",
+			args:           []string{"-m", "msg"},
+			signingEnabled: true,
+			expectedArgs:   []string{"-m", "msg", "--no-gpg-sign"},
+			expectWarning:  true,
+		},
+		{
+			name:          "signer unavailable with explicit --gpg-sign",
+			args:          []string{"--gpg-sign=KEY", "-m", "msg"},
+			expectedArgs:  []string{"-m", "msg", "--no-gpg-sign"},
+			expectWarning: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, signed, warning := app.WithCommitSigning(tt.args, tt.signingEnabled, tt.signerAvailable)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedSigned, signed)
+			assert.Equal(t, tt.expectWarning, warning != "")
+		})
+	}
+}
+
+func TestDescribeSignatureStatus(t *testing.T) {
+	assert.Equal(t, "signed (good signature)", app.DescribeSignatureStatus("G\n"))
+	assert.Equal(t, "signed (BAD signature)", app.DescribeSignatureStatus("B"))
+	assert.Equal(t, "unsigned", app.DescribeSignatureStatus("N"))
+	assert.Equal(t, "unsigned", app.DescribeSignatureStatus(""))
+}
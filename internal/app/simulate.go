@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// simulateUndo runs the undo commands in a sandbox copy of the repository and reports the resulting state.
+// The sandbox is a temporary repository sharing the objects of the real one, with its refs, HEAD, index
+// and tracked working tree changes copied: nothing the undo commands do there reaches the real repository.
+// The commands that would reach beyond the sandbox (e.g. changing the global git config) are not simulated.
+func (a *App) simulateUndo(
+	ctx context.Context,
+	g GitHelper,
+	entry *logging.Entry,
+	undoCmds []*undoer.UndoCommand,
+) error {
+	dir, err := os.MkdirTemp("", "git-undo-simulate-*")
+	if err != nil {
+		return fmt.Errorf("failed to create simulation sandbox: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sandbox, err := createSandbox(ctx, g, dir)
+	if err != nil {
+		return fmt.Errorf("failed to create simulation sandbox: %w", err)
+	}
+
+	headBefore := getHead(sandbox)
+	for i, undoCmd := range undoCmds {
+		// They'd reach beyond the sandbox (or can't run there at all)
+		if _, inPlace := undoCmd.InPlaceOnly(); inPlace {
+			continue
+		}
+		if err := undoCmd.ExecWith(sandbox); err != nil {
+			return fmt.Errorf("simulated undo command %d/%d %s failed: %w", i+1, len(undoCmds), undoCmd.Command, err)
+		}
+	}
+
+	a.logInfof("Simulated undo of %s (your repository is untouched):", a.getTheme().highlight(entry.Command))
+	for _, undoCmd := range undoCmds {
+		a.logInfof("  %s", undoCmd.Command)
+		if reason, inPlace := undoCmd.InPlaceOnly(); inPlace {
+			a.logInfof("    (not simulated: %s)", reason)
+		}
+		for _, warning := range undoCmd.Warnings {
+			a.logWarnf("%s", warning)
+		}
+	}
+
+	status, err := sandbox.GitOutput("status", "--short", "--branch")
+	if err != nil {
+		return fmt.Errorf("failed to read simulated status: %w", err)
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "Status after undo:\n%s\n", status)
+
+	if headAfter := getHead(sandbox); headAfter != headBefore && headBefore != "" && headAfter != "" {
+		diff, err := sandbox.GitOutput("diff", "--stat", headBefore, headAfter)
+		if err != nil {
+			return fmt.Errorf("failed to diff simulated HEAD: %w", err)
+		}
+		head, _ := sandbox.GitOutput("log", "-1", "--format=%h %s", headAfter)
+		_, _ = fmt.Fprintf(os.Stdout, "HEAD would move to %s\n%s\n", head, diff)
+	}
+
+	a.logInfof("Untracked and ignored files are not part of the simulation")
+	return nil
+}
+
+// createSandbox sets up a repository in dir mirroring the state of the real repository.
+// Objects are shared via alternates, so no history is copied.
+func createSandbox(ctx context.Context, g GitHelper, dir string) (*githelpers.H, error) {
+	toplevel, err := g.GitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find working tree: %w", err)
+	}
+	commonDir, err := gitCommonDir(g, toplevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find git dir: %w", err)
+	}
+
+	// The repository may be located by the environment (GIT_DIR...): the sandbox must not be
+	sandbox := githelpers.NewGitHelper(ctx, dir).Isolated()
+	if err := sandbox.GitRun("init", "-q"); err != nil {
+		return nil, fmt.Errorf("failed to init: %w", err)
+	}
+	alternates := filepath.Join(dir, ".git", "objects", "info", "alternates")
+	if err := os.WriteFile(alternates, []byte(filepath.Join(commonDir, "objects")+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to share objects: %w", err)
+	}
+
+	if err := sandbox.GitRun("fetch", "-q", "--no-tags", "--update-head-ok", commonDir, "+refs/*:refs/*"); err != nil {
+		return nil, fmt.Errorf("failed to copy refs: %w", err)
+	}
+	if branch, err := g.GitOutput("symbolic-ref", "-q", "HEAD"); err == nil {
+		if err := sandbox.GitRun("symbolic-ref", "HEAD", branch); err != nil {
+			return nil, fmt.Errorf("failed to copy HEAD: %w", err)
+		}
+	} else if head := getHead(g); head != "" {
+		if err := sandbox.GitRun("update-ref", "--no-deref", "HEAD", head); err != nil {
+			return nil, fmt.Errorf("failed to copy HEAD: %w", err)
+		}
+	}
+
+	// The index is copied as a tree, then checked out to become the working tree
+	index, err := g.GitOutput("write-tree")
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy index (are there unresolved conflicts?): %w", err)
+	}
+	if err := sandbox.GitRun("read-tree", index); err != nil {
+		return nil, fmt.Errorf("failed to copy index: %w", err)
+	}
+	if err := sandbox.GitRun("checkout-index", "-a", "-f"); err != nil {
+		return nil, fmt.Errorf("failed to check out index: %w", err)
+	}
+
+	// Unstaged changes of tracked files are applied on top
+	changes, err := g.GitOutput("diff", "--binary")
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy working tree changes: %w", err)
+	}
+	if strings.TrimSpace(changes) != "" {
+		patch := filepath.Join(dir, ".git", "worktree.patch")
+		if err := os.WriteFile(patch, []byte(changes+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("failed to copy working tree changes: %w", err)
+		}
+		if err := sandbox.GitRun("apply", patch); err != nil {
+			return nil, fmt.Errorf("failed to copy working tree changes: %w", err)
+		}
+	}
+
+	return sandbox, nil
+}
+
+// gitCommonDir returns the absolute path of the git dir shared by all worktrees of the repository.
+func gitCommonDir(g GitHelper, toplevel string) (string, error) {
+	if githelpers.InstalledGitVersion().Supports(githelpers.CapPathFormat) {
+		return g.GitOutput("rev-parse", "--path-format=absolute", "--git-common-dir")
+	}
+
+	// Older git prints it relative to the directory git runs in (the top-level one)
+	commonDir, err := g.GitOutput("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(toplevel, commonDir)
+	}
+	return commonDir, nil
+}
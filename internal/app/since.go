@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+)
+
+// runUndoSince handles `git undo --since <date|duration>`: every command logged on the current ref since then
+// is undone, newest first. The whole plan is shown first, and nothing runs until the user confirms it.
+func (a *App) runUndoSince(ctx context.Context, lgr *logging.Logger, g GitHelper, opts RunOptions) error {
+	since, err := parseHistoryTime(opts.Since, entryClockNow())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	ref, _ := g.GetCurrentGitRef()
+	entries, err := lgr.GetEntriesBetween(since, time.Time{}, func(e *logging.Entry) bool {
+		return !e.Undoed && !e.IsNavigation && (ref == "" || e.Ref.String() == ref)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+	if len(entries) == 0 {
+		a.logInfof("nothing to undo since %s", since.Format(time.DateTime))
+		return nil
+	}
+
+	a.logInfof("%d commands were run on %s since %s:", len(entries), ref, since.Format(time.DateTime))
+	planOpts := opts
+	planOpts.DryRun = true
+	for _, entry := range entries {
+		// The undo of older entries may only be known once the newer ones are undone
+		if err := a.executeUndoOperation(ctx, lgr, g, planOpts, entry, false); err != nil {
+			a.logWarnf("Would undo %s (on %s, ID %s): %v", a.getTheme().highlight(entry.Command), entry.Ref,
+				entry.ID(), err)
+		}
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Undo these %d commands? [y/N] ", len(entries))
+	answer, err := readLine(a.getStdin())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotConfirmed, err)
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return ErrNotConfirmed
+	}
+
+	return a.undoEntries(ctx, lgr, g, opts, entries)
+}
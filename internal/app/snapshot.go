@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/snapshot"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// snapshotMatchWindow is how long before a logged command a snapshot taken before the same command
+// is assumed to be its own, when the entry doesn't name it.
+const snapshotMatchWindow = time.Minute
+
+// takeSnapshot copies the files the destructive command is about to lose, and prunes snapshots past
+// their retention. It returns the ID of the snapshot (empty when none was taken).
+func (a *App) takeSnapshot(gitDir string, g GitHelper, verbose bool, hooked string,
+	gitCmd *githelpers.GitCommand) string {
+	store := snapshot.NewStore(gitDir)
+	if a.cfg != nil {
+		if pruned, err := store.Prune(a.cfg.SnapshotRetention); err != nil {
+			a.logDebugf(verbose, "failed to prune snapshots: %s", err)
+		} else if pruned > 0 {
+			a.logDebugf(verbose, "pruned %d snapshots", pruned)
+		}
+	}
+
+	paths, err := snapshot.AffectedPaths(g, gitCmd.Name, gitCmd.Args)
+	if err != nil || len(paths) == 0 {
+		return ""
+	}
+	worktree, err := g.GitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+
+	snap, err := store.Save(worktree, hooked, paths)
+	switch {
+	case errors.Is(err, snapshot.ErrTooLarge):
+		a.logWarnf("the files %q is about to remove are too large to snapshot: it won't be undoable", hooked)
+		return ""
+	case err != nil:
+		a.logDebugf(verbose, "failed to snapshot the files of %q: %s", hooked, err)
+		return ""
+	case snap == nil:
+		return ""
+	}
+	a.logDebugf(verbose, "pre-hook: snapshot %s has %d files", snap.ID, len(snap.Files))
+	return snap.ID
+}
+
+// entrySnapshot returns the snapshot taken right before the command of the entry (nil if there is none).
+func entrySnapshot(g GitHelper, entry *logging.Entry) *snapshot.Snapshot {
+	gitDir, err := g.GetRepoGitDir()
+	if err != nil {
+		return nil
+	}
+	store := snapshot.NewStore(gitDir)
+
+	if id := entry.Metadata[logging.MetaSnapshot]; id != "" {
+		snap, _ := store.Get(id)
+		return snap
+	}
+
+	// Entries logged without the pre-hook metadata: the latest snapshot of the same command is theirs
+	// if it was taken right before it
+	snap, err := store.Latest(entry.Command)
+	if err != nil || snap == nil {
+		return nil
+	}
+	// Log timestamps are local wall clock times in seconds: the snapshot time is compared the same way
+	taken, err := time.Parse(time.DateTime, snap.Time.Format(time.DateTime))
+	if err != nil {
+		return nil
+	}
+	if age := entry.Timestamp.Sub(taken); age < 0 || age > snapshotMatchWindow {
+		return nil
+	}
+	return snap
+}
+
+// cmdListSnapshots handles `git undo --list-snapshots`: the snapshots, newest first.
+func (a *App) cmdListSnapshots(gitDir string) error {
+	snapshots, err := snapshot.NewStore(gitDir).List()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		a.logInfof("No snapshots")
+		return nil
+	}
+
+	for _, snap := range slices.Backward(snapshots) {
+		files := fmt.Sprintf("%d files", len(snap.Files))
+		if missing := snap.Missing(); len(missing) > 0 {
+			files += fmt.Sprintf(", %d missing", len(missing))
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s\t%s (%s, taken %s)\n",
+			snap.ID, snap.Command, files, snap.Time.Local().Format(time.DateTime))
+	}
+	return nil
+}
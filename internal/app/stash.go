@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// createsStash tells whether the git command creates a stash: `git stash`, `git stash push/save`
+// or `git stash <push options>`.
+func createsStash(gitCmd *githelpers.GitCommand) bool {
+	if gitCmd.Name != "stash" {
+		return false
+	}
+	if len(gitCmd.Args) == 0 {
+		return true
+	}
+	first := gitCmd.Args[0]
+	return first == "push" || first == "save" || strings.HasPrefix(first, "-")
+}
+
+// annotateStash records the stash commit created by a just logged (or redone) stash entry,
+// so undoing it later pops that very stash even after newer ones shifted its stash@{n} index.
+func (a *App) annotateStash(lgr *logging.Logger, g GitHelper, gitCmd *githelpers.GitCommand, entry *logging.Entry) {
+	if !createsStash(gitCmd) {
+		return
+	}
+	stash, err := g.GitOutput("rev-parse", "--verify", "-q", "refs/stash")
+	if err != nil || strings.TrimSpace(stash) == "" {
+		return
+	}
+
+	if entry == nil {
+		entries, err := lgr.GetEntries(1, nil)
+		if err != nil || len(entries) == 0 || entries[0].IsNavigation {
+			return
+		}
+		entry = entries[0]
+	}
+	_ = lgr.SetEntryMeta(entry.GetIdentifier(), logging.MetaStash, strings.TrimSpace(stash))
+}
+
+// newUndoer returns the undoer of the entry, targeting what was recorded with it when logged.
+// It runs git in the directory the command ran in (see logging.MetaDir).
+func newUndoer(g GitHelper, entry *logging.Entry, isBackMode bool) undoer.Undoer {
+	if dir := entry.Metadata[logging.MetaDir]; dir != "" {
+		if top, err := g.GitOutput("rev-parse", "--show-toplevel"); err == nil {
+			g = dirGit{GitHelper: g, dir: filepath.Join(top, dir)}
+		}
+	}
+	if isBackMode {
+		return undoer.NewBack(entry.Command, g)
+	}
+
+	u := undoer.New(entry.Command, g)
+	if stashUndoer, ok := u.(interface{ TargetStash(string) }); ok && entry.Metadata[logging.MetaStash] != "" {
+		stashUndoer.TargetStash(entry.Metadata[logging.MetaStash])
+	}
+	targetPreState(g, u, entry)
+	return u
+}
+
+// displayOnlyMeta are the metadata keys undo never uses: redacting them changes nothing.
+var displayOnlyMeta = []string{
+	logging.MetaTyped, logging.MetaAuthor, logging.MetaUndoCommands, logging.MetaUndoError, logging.MetaRedacted,
+}
+
+// redactedValue returns the metadata key of the entry undo would use, if any, whose value
+// was redacted when logged (see logging.MetaRedacted).
+func redactedValue(entry *logging.Entry) string {
+	for _, part := range entry.RedactedParts() {
+		if part != logging.RedactedCommand && !slices.Contains(displayOnlyMeta, part) {
+			return part
+		}
+	}
+	return ""
+}
+
+// undoCommands returns the undo commands of the entry, refusing to undo with the values redacted from
+// the log (see logging.MetaRedacted): the redacted text would be restored instead of them.
+// A redacted command only matters if the undo commands use its redacted parts (e.g. not a commit message).
+func undoCommands(u undoer.Undoer, entry *logging.Entry) ([]*undoer.UndoCommand, error) {
+	if key := redactedValue(entry); key != "" {
+		return nil, fmt.Errorf("%w: the recorded %s was redacted (see git-undo.redact): "+
+			"undoing would restore the redacted text", undoer.ErrUndoNotSupported, key)
+	}
+
+	undoCmds, err := u.GetUndoCommands()
+	if err != nil || !slices.Contains(entry.RedactedParts(), logging.RedactedCommand) {
+		return undoCmds, err
+	}
+	for _, undoCmd := range undoCmds {
+		if strings.Contains(undoCmd.Command, logging.RedactedText) {
+			return nil, fmt.Errorf("%w: the command was redacted (see git-undo.redact): "+
+				"undoing would use the redacted text", undoer.ErrUndoNotSupported)
+		}
+	}
+	return undoCmds, nil
+}
+
+// dirGit runs the git commands of the helper in a directory of the work tree (like `git -C <dir>`).
+type dirGit struct {
+	GitHelper
+	dir string
+}
+
+func (d dirGit) GitRun(subCmd string, args ...string) error {
+	return d.GitHelper.GitRun("-C", append([]string{d.dir, subCmd}, args...)...)
+}
+
+func (d dirGit) GitRunWithInput(input string, subCmd string, args ...string) error {
+	return d.GitHelper.GitRunWithInput(input, "-C", append([]string{d.dir, subCmd}, args...)...)
+}
+
+func (d dirGit) GitOutput(subCmd string, args ...string) (string, error) {
+	return d.GitHelper.GitOutput("-C", append([]string{d.dir, subCmd}, args...)...)
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// statsTopCount is how many command types and branches `git undo stats` ranks.
+const statsTopCount = 5
+
+// statsDays is how many of the latest days with undos or redos `git undo stats` counts them for.
+const statsDays = 14
+
+// undoStats summarizes the log (see cmdStats).
+type undoStats struct {
+	Entries     int `json:"entries"`
+	Mutations   int `json:"mutations"`
+	Navigations int `json:"navigations"`
+	Failed      int `json:"failed"`
+	// Undone counts the entries undone at some point: the undone ones and the redone ones.
+	Undone int `json:"undone"`
+	Redone int `json:"redone"`
+	// AvgTimeToUndo is the average time between a command and its (successful) undo, in seconds.
+	AvgTimeToUndo float64 `json:"avgTimeToUndoSeconds"`
+
+	TopUndone []statsCommand `json:"topUndone"`
+	PerDay    []statsDay     `json:"perDay"`
+	TopRefs   []statsRef     `json:"topRefs"`
+}
+
+// statsCommand is how often the commands of a type (git subcommand) are undone.
+type statsCommand struct {
+	Command string `json:"command"`
+	Undone  int    `json:"undone"`
+	Logged  int    `json:"logged"`
+}
+
+// statsDay is the number of undos and redos of a day.
+type statsDay struct {
+	Day   string `json:"day"`
+	Undos int    `json:"undos"`
+	Redos int    `json:"redos"`
+}
+
+// statsRef is the activity on a ref.
+type statsRef struct {
+	Ref      string `json:"ref"`
+	Commands int    `json:"commands"`
+	Undone   int    `json:"undone"`
+}
+
+// cmdStats handles `git undo stats`: it sums up the log of the repository (since the given date or duration,
+// see parseHistoryTime): the most often undone command types, undos and redos per day, the average time
+// between a command and its undo, and the most active refs. The log keeps the latest undo and redo
+// of each entry only, so an entry undone and redone several times counts once.
+func (a *App) cmdStats(lgr *logging.Logger, since string, asJSON bool) error {
+	sinceTime, err := parseHistoryTime(since, entryClockNow())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	var entries []*logging.Entry
+	failed := 0
+	err = lgr.ProcessLogFile(func(line string) bool {
+		entry, err := logging.ParseLogLine(line)
+		switch {
+		case err != nil:
+			return true
+		// The log is newest first: the remaining entries are older
+		case !sinceTime.IsZero() && entry.Timestamp.Before(sinceTime):
+			return false
+		case entry.Failed:
+			failed++
+		case entry.Summary:
+			// Stands for commands that weren't logged
+		default:
+			entries = append(entries, entry)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	stats := collectStats(entries)
+	stats.Failed = failed
+	stats.Entries += failed
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	if stats.Entries == 0 {
+		a.logInfof("No log entries to sum up")
+		return nil
+	}
+	return a.printStats(stats)
+}
+
+// collectStats sums up the entries.
+func collectStats(entries []*logging.Entry) *undoStats {
+	stats := &undoStats{TopUndone: []statsCommand{}, PerDay: []statsDay{}, TopRefs: []statsRef{}}
+	commands := make(map[string]*statsCommand)
+	days := make(map[string]*statsDay)
+	refs := make(map[string]*statsRef)
+	var timeToUndo time.Duration
+	undos := 0
+	day := func(at time.Time) *statsDay {
+		key := at.Format(time.DateOnly)
+		if days[key] == nil {
+			days[key] = &statsDay{Day: key}
+		}
+		return days[key]
+	}
+
+	for _, entry := range entries {
+		stats.Entries++
+		if entry.IsNavigation {
+			stats.Navigations++
+		} else {
+			stats.Mutations++
+		}
+
+		ref := refs[entry.Ref.String()]
+		if ref == nil {
+			ref = &statsRef{Ref: entry.Ref.String()}
+			refs[ref.Ref] = ref
+		}
+		ref.Commands++
+
+		name := entry.Command
+		if gitCmd, err := githelpers.ParseGitCommand(entry.Command); err == nil {
+			name = "git " + gitCmd.Name
+		}
+		command := commands[name]
+		if command == nil {
+			command = &statsCommand{Command: name}
+			commands[name] = command
+		}
+		command.Logged++
+
+		redoneAt, redone := entry.RedoTime()
+		if redone {
+			stats.Redone++
+			day(redoneAt).Redos++
+		}
+		if record := entry.UndoRecord(); record != nil && record.Succeeded() && !record.Time.IsZero() {
+			day(record.Time).Undos++
+			timeToUndo += record.Time.Sub(entry.Timestamp)
+			undos++
+		}
+		if entry.Undoed || redone {
+			stats.Undone++
+			command.Undone++
+			ref.Undone++
+		}
+	}
+
+	if undos > 0 {
+		stats.AvgTimeToUndo = (timeToUndo / time.Duration(undos)).Seconds()
+	}
+
+	for _, command := range commands {
+		if command.Undone > 0 {
+			stats.TopUndone = append(stats.TopUndone, *command)
+		}
+	}
+	slices.SortFunc(stats.TopUndone, func(x, y statsCommand) int {
+		return cmp.Or(cmp.Compare(y.Undone, x.Undone), cmp.Compare(x.Command, y.Command))
+	})
+	stats.TopUndone = stats.TopUndone[:min(len(stats.TopUndone), statsTopCount)]
+
+	// The latest days first
+	for _, key := range slices.Backward(slices.Sorted(maps.Keys(days))) {
+		if len(stats.PerDay) == statsDays {
+			break
+		}
+		stats.PerDay = append(stats.PerDay, *days[key])
+	}
+
+	for _, ref := range refs {
+		stats.TopRefs = append(stats.TopRefs, *ref)
+	}
+	slices.SortFunc(stats.TopRefs, func(x, y statsRef) int {
+		return cmp.Or(cmp.Compare(y.Commands, x.Commands), cmp.Compare(x.Ref, y.Ref))
+	})
+	stats.TopRefs = stats.TopRefs[:min(len(stats.TopRefs), statsTopCount)]
+	return stats
+}
+
+// printStats prints the summary in sections.
+func (a *App) printStats(stats *undoStats) error {
+	theme := a.getTheme()
+	out := os.Stdout
+	_, _ = fmt.Fprintf(out, "%s %d (%d mutations, %d navigations, %d failed)\n", theme.highlight("Entries:"),
+		stats.Entries, stats.Mutations, stats.Navigations, stats.Failed)
+	_, _ = fmt.Fprintf(out, "%s %d undone, %d of them redone\n", theme.highlight("Undos:"), stats.Undone, stats.Redone)
+	if stats.AvgTimeToUndo > 0 {
+		avg := time.Duration(stats.AvgTimeToUndo * float64(time.Second)).Round(time.Second)
+		_, _ = fmt.Fprintf(out, "%s %s between a command and its undo\n", theme.highlight("Average:"), avg)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if len(stats.TopUndone) > 0 {
+		_, _ = fmt.Fprintf(w, "\n%s\n", theme.highlight("Most undone commands:"))
+		for _, command := range stats.TopUndone {
+			_, _ = fmt.Fprintf(w, "  %s\t%d of %d\t(%d%%)\n", command.Command, command.Undone, command.Logged,
+				command.Undone*100/command.Logged)
+		}
+	}
+	if len(stats.PerDay) > 0 {
+		_, _ = fmt.Fprintf(w, "\n%s\n", theme.highlight("Undos and redos per day:"))
+		for _, day := range stats.PerDay {
+			_, _ = fmt.Fprintf(w, "  %s\t%d undos\t%d redos\n", day.Day, day.Undos, day.Redos)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\n%s\n", theme.highlight("Most active refs:"))
+	for _, ref := range stats.TopRefs {
+		_, _ = fmt.Fprintf(w, "  %s\t%d commands\t%d undone\n", ref.Ref, ref.Commands, ref.Undone)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// cmdStatus displays the state of git-undo in the current repository.
+func (a *App) cmdStatus(g GitHelper, gitDir string, cfg *config.Config) error {
+	repoPath := a.getRepoPath(g, gitDir)
+	_, _ = fmt.Fprintf(os.Stdout, "Repository: %s\n", repoPath)
+
+	included, reason := cfg.IsRepoIncluded(repoPath)
+	if included {
+		_, _ = fmt.Fprintf(os.Stdout, "History collection: enabled (%s)\n", reason)
+	} else {
+		_, _ = fmt.Fprintf(os.Stdout, "History collection: disabled (%s)\n", reason)
+	}
+	printNotesStatus(cfg)
+	_, _ = fmt.Fprintf(os.Stdout, "Git version: %s\n", githelpers.InstalledGitVersion())
+
+	return printUndoStack(a.newReadOnlyLogger(gitDir, g), g)
+}
+
+// printUndoStack displays where the current ref is in the undo history: the commands the next undo and
+// redo would act on, how many of each are left, and whether the last operation was navigation
+// (which git back undoes instead).
+func printUndoStack(lgr *logging.Logger, g GitHelper) error {
+	ref, err := g.GetCurrentGitRef()
+	if err != nil {
+		return fmt.Errorf("failed to determine the current ref: %w", err)
+	}
+	entries, err := lgr.GetEntries(0, func(e *logging.Entry) bool {
+		return !e.IsNavigation && e.Ref.String() == ref
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+	redoable, err := redoableEntries(lgr, g, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+
+	var undoable []*logging.Entry
+	for _, entry := range entries {
+		if !entry.Undoed {
+			undoable = append(undoable, entry)
+		}
+	}
+
+	now := entryClockNow()
+	describe := func(entries []*logging.Entry) string {
+		if len(entries) == 0 {
+			return "nothing"
+		}
+		return fmt.Sprintf("%s (ID %s, %s)", entries[0].Command, entries[0].ID(),
+			relativeTime(entries[0].Timestamp, now))
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "Next undo: %s\n", describe(undoable))
+	_, _ = fmt.Fprintf(os.Stdout, "Next redo: %s\n", describe(redoable))
+	_, _ = fmt.Fprintf(os.Stdout, "On %s: %d undoable, %d redoable\n", ref, len(undoable), len(redoable))
+
+	if last, err := lgr.GetLastEntry(); err == nil && last != nil && last.IsNavigation {
+		_, _ = fmt.Fprintf(os.Stdout, "Last operation: %s is navigation, use git back to undo it\n", last.Command)
+	}
+	return nil
+}
+
+// isRepoIncluded checks if history should be collected in the current repository
+// according to git-undo.include / git-undo.exclude settings.
+func (a *App) isRepoIncluded(g GitHelper, gitDir string, cfg *config.Config) (bool, string) {
+	return cfg.IsRepoIncluded(a.getRepoPath(g, gitDir))
+}
+
+// getRepoPath returns the top-level directory of the repository (or its git dir if there's no worktree).
+func (a *App) getRepoPath(g GitHelper, gitDir string) string {
+	if toplevel, err := g.GitOutput("rev-parse", "--show-toplevel"); err == nil && strings.TrimSpace(toplevel) != "" {
+		return strings.TrimSpace(toplevel)
+	}
+	return gitDir
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// auditLogFileName is the audit log of executed undo operations (next to the command log).
+const auditLogFileName = "audit"
+
+// historyRewritingCommands are commands whose undo moves the current branch.
+var historyRewritingCommands = map[string]struct{}{
+	"am":          {},
+	"cherry-pick": {},
+	"commit":      {},
+	"merge":       {},
+	"pull":        {},
+	"rebase":      {},
+	"reset":       {},
+	"revert":      {},
+}
+
+// ErrNotConfirmed is returned when the user declines (or can't answer) the confirmation prompt.
+var ErrNotConfirmed = errors.New("undo was not confirmed")
+
+// checkUndoPolicy refuses undo operations forbidden by the configured safety features (see strict mode).
+func (a *App) checkUndoPolicy(g GitHelper, entry *logging.Entry) error {
+	if a.cfg == nil {
+		return nil
+	}
+
+	gitCmd, err := githelpers.ParseGitCommand(entry.Command)
+	if err != nil {
+		return nil //nolint:nilerr // unparsable commands are refused by the undoer itself
+	}
+
+	if a.cfg.IsUndoerDisabled(gitCmd.Name) {
+		reason := "in strict mode"
+		if slices.Contains(a.cfg.DisabledUndoers, gitCmd.Name) {
+			reason = "by " + config.Section + "." + config.KeyDisableUndoer
+		}
+		return fmt.Errorf("%w: undoing `git %s` is disabled %s", undoer.ErrUndoNotSupported, gitCmd.Name, reason)
+	}
+
+	if _, rewrites := historyRewritingCommands[gitCmd.Name]; rewrites {
+		branch, err := g.GitOutput("symbolic-ref", "--short", "-q", "HEAD")
+		if err == nil && a.cfg.IsBranchProtected(strings.TrimSpace(branch)) {
+			return fmt.Errorf("branch %s is protected: undoing `%s` would rewrite its history",
+				strings.TrimSpace(branch), entry.Command)
+		}
+	}
+
+	return nil
+}
+
+// confirmUndo asks the user to confirm the undo commands
+// (when confirmation is required, or when a command asks for it).
+func (a *App) confirmUndo(entry *logging.Entry, undoCmds []*undoer.UndoCommand) error {
+	asked := slices.ContainsFunc(undoCmds, func(undoCmd *undoer.UndoCommand) bool { return undoCmd.Confirm })
+	if a.confirmed || !asked && (a.cfg == nil || !a.cfg.ConfirmRequired()) {
+		return nil
+	}
+
+	a.logInfof("About to undo %s by running:", a.getTheme().highlight(entry.Command))
+	for _, undoCmd := range undoCmds {
+		a.logInfof("  %s", undoCmd.Command)
+	}
+	_, _ = fmt.Fprint(os.Stderr, "Proceed? [y/N] ")
+
+	answer, err := bufio.NewReader(a.getStdin()).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %w", ErrNotConfirmed, err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return ErrNotConfirmed
+	}
+}
+
+// getStdin returns the input confirmations are read from.
+func (a *App) getStdin() io.Reader {
+	if a.stdin != nil {
+		return a.stdin
+	}
+	return os.Stdin
+}
+
+// writeAudit appends the executed undo operation to the audit log (when enabled).
+// Failing to write the audit log is reported but doesn't fail the undo.
+func (a *App) writeAudit(lgr *logging.Logger, g GitHelper, entry *logging.Entry, undoCmds []*undoer.UndoCommand) {
+	if a.cfg == nil || !a.cfg.AuditEnabled() {
+		return
+	}
+
+	user, _ := g.GitOutput("config", "--get", "user.email")
+	commands := make([]string, 0, len(undoCmds))
+	for _, undoCmd := range undoCmds {
+		commands = append(commands, undoCmd.Command)
+	}
+	line := fmt.Sprintf("%s|%s|%s|%s|%s\n", time.Now().Format(time.DateTime), strings.TrimSpace(user),
+		entry.ID(), entry.Command, strings.Join(commands, " && "))
+
+	auditFile := filepath.Join(filepath.Dir(lgr.GetLogPath()), auditLogFileName)
+	f, err := os.OpenFile(auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		a.logWarnf("Failed to write the audit log: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(line); err != nil {
+		a.logWarnf("Failed to write the audit log: %v", err)
+	}
+}
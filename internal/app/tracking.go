@@ -0,0 +1,53 @@
+package app
+
+import (
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+)
+
+// recordTracking records the upstream tracking configuration of the branch deleted by the undo into the entry,
+// so redo can restore it (deleting a branch drops its configuration). Stale records are cleared.
+func (a *App) recordTracking(lgr *logging.Logger, entry *logging.Entry, undoCmds []*undoer.UndoCommand) {
+	var tracking *undoer.BranchTracking
+	for _, undoCmd := range undoCmds {
+		if undoCmd.Tracking != nil {
+			tracking = undoCmd.Tracking
+			break
+		}
+	}
+	if tracking == nil {
+		if _, _, _, ok := entry.Upstream(); !ok {
+			return
+		}
+		tracking = &undoer.BranchTracking{}
+	}
+
+	for key, value := range map[string]string{
+		logging.MetaUpstreamBranch: tracking.Branch,
+		logging.MetaUpstreamRemote: tracking.Remote,
+		logging.MetaUpstreamMerge:  tracking.Merge,
+	} {
+		if err := lgr.SetEntryMeta(entry.GetIdentifier(), key, value); err != nil {
+			a.logWarnf("Failed to record upstream tracking of branch %s: %v", tracking.Branch, err)
+			return
+		}
+	}
+}
+
+// restoreTracking restores the recorded upstream tracking configuration of the branch re-created by redo.
+func (a *App) restoreTracking(g GitHelper, entry *logging.Entry) {
+	branch, remote, merge, ok := entry.Upstream()
+	if !ok {
+		return
+	}
+
+	if err := g.GitRun("config", "branch."+branch+".remote", remote); err != nil {
+		a.logWarnf("Failed to restore upstream tracking of branch %s: %v", branch, err)
+		return
+	}
+	if err := g.GitRun("config", "branch."+branch+".merge", merge); err != nil {
+		a.logWarnf("Failed to restore upstream tracking of branch %s: %v", branch, err)
+		return
+	}
+	a.logInfof("Restored upstream tracking of branch %s (%s %s)", branch, remote, merge)
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// undoTransaction is the state of the repository recorded right before the commands of an undo run,
+// so the ones already executed can be rolled back when a later one fails: a half-done undo would leave
+// the repository matching neither the entry nor its undo. It covers the refs, HEAD, the index and
+// the tracked files of the working tree, not untracked files or the config.
+type undoTransaction struct {
+	g GitHelper
+
+	// symbolicHead is the branch HEAD is on (empty when detached), head the commit (empty on an unborn branch).
+	symbolicHead string
+	head         string
+	// index and worktree are the trees of the index and of the tracked files in the working tree.
+	index    string
+	worktree string
+	// refs are the refs (but git-undo's own) by name.
+	refs map[string]string
+	// inProgress are the operations (see operationMarkers) that were in progress already: not the undo's to quit.
+	inProgress map[string]bool
+}
+
+// operationMarkers are the files of the git dir telling that an operation undo commands may start is in progress.
+// The sequencer is shared by cherry-picks and reverts of several commits.
+var operationMarkers = map[string][]string{
+	"rebase":      {"rebase-merge", "rebase-apply"},
+	"cherry-pick": {"CHERRY_PICK_HEAD", "sequencer"},
+	"revert":      {"REVERT_HEAD", "sequencer"},
+}
+
+// beginUndoTransaction records the state undo commands can be rolled back to.
+// It fails when the state can't be recorded (e.g. while the index has unmerged paths).
+func beginUndoTransaction(g GitHelper) (*undoTransaction, error) {
+	t := &undoTransaction{g: g}
+	if branch, err := g.GitOutput("symbolic-ref", "-q", "HEAD"); err == nil {
+		t.symbolicHead = strings.TrimSpace(branch)
+	}
+	if head, err := g.GitOutput("rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		t.head = strings.TrimSpace(head)
+	}
+
+	index, err := g.GitOutput("write-tree")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the index: %w", err)
+	}
+	t.index = strings.TrimSpace(index)
+
+	if t.head != "" {
+		// Without local changes there's no stash commit: the working tree is HEAD
+		changes, err := g.GitOutput("stash", "create")
+		if err != nil {
+			return nil, fmt.Errorf("failed to record the working tree: %w", err)
+		}
+		worktree := t.head
+		if changes = strings.TrimSpace(changes); changes != "" {
+			worktree = changes
+		}
+		tree, err := g.GitOutput("rev-parse", worktree+"^{tree}")
+		if err != nil {
+			return nil, fmt.Errorf("failed to record the working tree: %w", err)
+		}
+		t.worktree = strings.TrimSpace(tree)
+	}
+
+	if t.refs, err = listRefs(g); err != nil {
+		return nil, fmt.Errorf("failed to record the refs: %w", err)
+	}
+	t.inProgress = operationsInProgress(g)
+	return t, nil
+}
+
+// operationsInProgress returns the operations of operationMarkers in progress in the repository.
+// When the git dir can't be found, they're all reported: quitting one the undo didn't start would lose it.
+func operationsInProgress(g GitHelper) map[string]bool {
+	inProgress := make(map[string]bool)
+	gitDir, err := g.GetRepoGitDir()
+	for operation, markers := range operationMarkers {
+		inProgress[operation] = err != nil || slices.ContainsFunc(markers, func(marker string) bool {
+			_, statErr := os.Stat(filepath.Join(gitDir, marker))
+			return statErr == nil
+		})
+	}
+	return inProgress
+}
+
+// rollback brings the repository back to the recorded state: operations left in progress by the failed
+// command are quit (not the ones in progress before the undo), refs are moved back (refs created since are
+// deleted), then HEAD, the tracked files and the index are restored.
+func (t *undoTransaction) rollback() error {
+	// Quitting one drops the state of the others as well (e.g. CHERRY_PICK_HEAD): only the started ones are quit
+	inProgress := operationsInProgress(t.g)
+	for _, operation := range slices.Sorted(maps.Keys(operationMarkers)) {
+		if inProgress[operation] && !t.inProgress[operation] {
+			_ = t.g.GitRun(operation, "--quit")
+		}
+	}
+
+	current, err := listRefs(t.g)
+	if err != nil {
+		return fmt.Errorf("failed to list the refs: %w", err)
+	}
+	var errs []error
+	for _, ref := range slices.Sorted(maps.Keys(current)) {
+		if _, recorded := t.refs[ref]; !recorded {
+			errs = append(errs, t.g.GitRun("update-ref", "-d", ref, current[ref]))
+		}
+	}
+	for _, ref := range slices.Sorted(maps.Keys(t.refs)) {
+		if current[ref] != t.refs[ref] {
+			errs = append(errs, t.g.GitRun("update-ref", ref, t.refs[ref]))
+		}
+	}
+
+	switch {
+	case t.symbolicHead != "":
+		errs = append(errs, t.g.GitRun("symbolic-ref", "HEAD", t.symbolicHead))
+	case t.head != "":
+		errs = append(errs, t.g.GitRun("update-ref", "--no-deref", "HEAD", t.head))
+	}
+
+	if t.worktree != "" {
+		errs = append(errs, t.g.GitRun("read-tree", "--reset", "-u", t.worktree))
+	}
+	errs = append(errs, t.g.GitRun("read-tree", t.index))
+	// read-tree drops the cached stat info: without a refresh every file would look modified
+	_ = t.g.GitRun("update-index", "-q", "--refresh")
+
+	return errors.Join(errs...)
+}
+
+// listRefs returns the refs of the repository (but git-undo's own, which undo commands don't touch) by name.
+func listRefs(g GitHelper) (map[string]string, error) {
+	output, err := g.GitOutput("for-each-ref", "--format=%(objectname) %(refname)")
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		object, ref, ok := strings.Cut(line, " ")
+		if !ok || strings.HasPrefix(ref, "refs/git-undo/") {
+			continue
+		}
+		refs[ref] = object
+	}
+	return refs, nil
+}
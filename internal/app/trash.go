@@ -0,0 +1,215 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+)
+
+// Subcommands of `git undo trash`.
+const (
+	TrashList    = "list"
+	TrashRestore = "restore"
+	TrashEmpty   = "empty"
+)
+
+// trashKindNames are the user-facing names of trashed item kinds.
+var trashKindNames = map[string]string{
+	undoer.TrashKindBranch: "branch",
+	undoer.TrashKindTag:    "tag",
+	undoer.TrashKindStash:  "stash",
+}
+
+// trashedItem is a ref or stash kept in the trash.
+type trashedItem struct {
+	// ref is the trash ref keeping the item.
+	ref string
+	// kind is one of undoer.TrashKind* constants.
+	kind string
+	// name is the name of the deleted branch or tag (the short commit hash for stashes).
+	name string
+	// summary is the short hash and the subject of the object the item points to.
+	summary string
+	// trashedAt is when the item was trashed (zero if unknown).
+	trashedAt time.Time
+}
+
+// cmdTrash handles `git undo trash [list]`, `git undo trash restore <name>` and `git undo trash empty`:
+// branches, tags and stashes deleted by undo operations (see undoer.TrashRefPrefix) are listed, brought back
+// or dropped for good.
+func (a *App) cmdTrash(g GitHelper, args []string) error {
+	if len(args) == 0 || args[0] == TrashList {
+		return a.listTrash(g)
+	}
+
+	switch args[0] {
+	case TrashRestore:
+		if len(args) < 2 {
+			return fmt.Errorf("usage: git undo trash %s <name>", TrashRestore)
+		}
+		return a.restoreTrashed(g, args[1])
+	case TrashEmpty:
+		items, err := trashedItems(g)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := g.GitRun("update-ref", "-d", item.ref); err != nil {
+				return fmt.Errorf("failed to remove %s from the trash: %w", item.ref, err)
+			}
+		}
+		a.logInfof("Emptied the trash (%d items removed)", len(items))
+		return nil
+	default:
+		return fmt.Errorf("unknown trash command: %s", args[0])
+	}
+}
+
+// trashedItems returns the items kept in the trash.
+func trashedItems(g GitHelper) ([]trashedItem, error) {
+	out, err := g.GitOutput("for-each-ref", "--format=%(refname)%09%(objectname:short) %(subject)",
+		undoer.TrashRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	var items []trashedItem
+	for _, line := range strings.Split(out, "\n") {
+		ref, summary, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if ref == "" {
+			continue
+		}
+		kind, name, _ := strings.Cut(strings.TrimPrefix(ref, undoer.TrashRefPrefix), "/")
+		items = append(items, trashedItem{
+			ref:       ref,
+			kind:      kind,
+			name:      name,
+			summary:   summary,
+			trashedAt: trashedAt(g, ref),
+		})
+	}
+	return items, nil
+}
+
+// trashedAt returns when the item was trashed, as recorded by the reflog of its trash ref.
+func trashedAt(g GitHelper, ref string) time.Time {
+	// e.g. "refs/git-undo/trash/heads/feature@{1700000000}"
+	selector, err := g.GitOutput("reflog", "show", "-1", "--format=%gd", "--date=unix", ref)
+	if err != nil {
+		return time.Time{}
+	}
+	_, stamp, _ := strings.Cut(strings.TrimSpace(selector), "@{")
+	seconds, err := strconv.ParseInt(strings.TrimSuffix(stamp, "}"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// listTrash prints the trashed items, the most recently trashed ones first.
+func (a *App) listTrash(g GitHelper) error {
+	items, err := trashedItems(g)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		a.logInfof("Trash is empty")
+		return nil
+	}
+
+	slices.SortStableFunc(items, func(x, y trashedItem) int { return y.trashedAt.Compare(x.trashedAt) })
+	for _, item := range items {
+		kind := trashKindNames[item.kind]
+		if kind == "" {
+			kind = item.kind
+		}
+		trashed := "unknown"
+		if !item.trashedAt.IsZero() {
+			trashed = item.trashedAt.Format(time.DateTime)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s %s\t%s (trashed %s)\n", kind, item.name, item.summary, trashed)
+	}
+	return nil
+}
+
+// restoreTrashed brings the trashed item back and removes it from the trash.
+// The name is the one shown by `git undo trash`, optionally prefixed with its kind (e.g. tag/v1.0).
+func (a *App) restoreTrashed(g GitHelper, name string) error {
+	items, err := trashedItems(g)
+	if err != nil {
+		return err
+	}
+
+	var matches []trashedItem
+	for _, item := range items {
+		if item.name == name || trashKindNames[item.kind]+"/"+item.name == name {
+			matches = append(matches, item)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%s is not in the trash", name)
+	case 1:
+	default:
+		return fmt.Errorf("%s is ambiguous: use %s/%s or %s/%s", name,
+			trashKindNames[matches[0].kind], name, trashKindNames[matches[1].kind], name)
+	}
+	item := matches[0]
+
+	value, err := g.GitOutput("rev-parse", "--verify", "-q", item.ref)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", item.ref, err)
+	}
+	value = strings.TrimSpace(value)
+
+	switch item.kind {
+	case undoer.TrashKindBranch, undoer.TrashKindTag:
+		// The empty old value makes update-ref refuse to overwrite an existing ref
+		ref := "refs/" + item.kind + "/" + item.name
+		if err := g.GitRun("update-ref", ref, value, ""); err != nil {
+			return fmt.Errorf("failed to restore %s (does it exist already?): %w", ref, err)
+		}
+	case undoer.TrashKindStash:
+		message, _ := g.GitOutput("log", "-1", "--format=%s", value)
+		if err := g.GitRun("stash", "store", "-m", strings.TrimSpace(message), value); err != nil {
+			return fmt.Errorf("failed to restore stash %s: %w", item.name, err)
+		}
+	default:
+		return fmt.Errorf("unknown kind of trashed item: %s", item.ref)
+	}
+
+	if err := g.GitRun("update-ref", "-d", item.ref); err != nil {
+		return fmt.Errorf("failed to remove %s from the trash: %w", item.ref, err)
+	}
+	a.logInfof("Restored %s %s", trashKindNames[item.kind], item.name)
+	return nil
+}
+
+// expireTrash drops items trashed longer than the retention period ago (retention <= 0 keeps them forever).
+// Items whose trashing time is unknown are kept.
+func expireTrash(g GitHelper, retention time.Duration) error {
+	if retention <= 0 {
+		return nil
+	}
+
+	items, err := trashedItems(g)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, item := range items {
+		if item.trashedAt.IsZero() || time.Since(item.trashedAt) < retention {
+			continue
+		}
+		if err := g.GitRun("update-ref", "-d", item.ref); err != nil {
+			errs = append(errs, fmt.Errorf("failed to expire %s: %w", item.ref, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+)
+
+// recordUndo records into the entry the undo commands executed and whether they succeeded,
+// so `git undo log` can tell how (and when) the entry was undone.
+func (a *App) recordUndo(lgr *logging.Logger, entry *logging.Entry, undoCmds []*undoer.UndoCommand, undoErr error) {
+	record := &logging.UndoRecord{Time: time.Now()}
+	for _, undoCmd := range undoCmds {
+		record.Commands = append(record.Commands, undoCmd.Command)
+		if undoCmd.Strategy != "" {
+			record.Strategy = undoCmd.Strategy
+		}
+	}
+	if undoErr != nil {
+		// The first line is enough to tell what went wrong (git output follows it)
+		record.Error, _, _ = strings.Cut(undoErr.Error(), "\n")
+	}
+
+	if err := lgr.SetEntryMetas(entry.GetIdentifier(), logging.UndoRecordMeta(record)); err != nil {
+		a.logWarnf("Failed to record the undo of %s: %v", entry.Command, err)
+	}
+}
+
+// recordRedo drops the record of the undo of the redone entry (it's no longer undone)
+// and records when it was redone instead (see `git undo stats`).
+func (a *App) recordRedo(lgr *logging.Logger, entry *logging.Entry) {
+	meta := logging.UndoRecordMeta(nil)
+	meta[logging.MetaRedoTime] = time.Now().Format(time.DateTime)
+	if err := lgr.SetEntryMetas(entry.GetIdentifier(), meta); err != nil {
+		a.logWarnf("Failed to record the redo of %s: %v", entry.Command, err)
+	}
+}
+
+// describeUndoRecord tells how the entry was undone, e.g. "undone via git reset --soft HEAD~1 at 12:01".
+// Empty if it has no undo record.
+func describeUndoRecord(entry *logging.Entry) string {
+	record := entry.UndoRecord()
+	if record == nil {
+		return ""
+	}
+
+	at := record.Time.Format(time.DateTime)
+	if record.Time.Format(time.DateOnly) == entry.Timestamp.Format(time.DateOnly) {
+		at = record.Time.Format(time.TimeOnly)
+	}
+	commands := strings.Join(record.Commands, " && ")
+	if !record.Succeeded() {
+		return "undo via " + commands + " failed at " + at + ": " + record.Error
+	}
+	return "undone via " + commands + " at " + at
+}
+
+// describeKeptLaterCommits tells why the entry undone keeping the later commits (see logging.MetaUndoStrategy)
+// can't be redone, and how to bring its commit back by hand.
+func describeKeptLaterCommits(g GitHelper, record *logging.UndoRecord) string {
+	// The undone commit ends the rebase and revert commands (e.g. `git rebase --onto <commit>^ <commit>`)
+	var commit string
+	for _, command := range record.Commands {
+		if fields := strings.Fields(command); strings.HasPrefix(command, "git "+record.Strategy+" ") {
+			commit = fields[len(fields)-1]
+		}
+	}
+
+	switch {
+	case commit == "":
+		return fmt.Sprintf("it was undone by `git %s`, which re-running it can't reverse", record.Strategy)
+	case record.Strategy == undoer.StrategyRebase:
+		return fmt.Sprintf("its commit was removed from under the later ones: "+
+			"bring it back on top with `git cherry-pick %s`", commit)
+	}
+	revert, _ := g.GitOutput("log", "-1", "--format=%h", "--fixed-strings", "--grep=This reverts commit "+commit)
+	if revert = strings.TrimSpace(revert); revert == "" {
+		return "its commit was reverted by a new commit: bring it back by reverting that one"
+	}
+	return fmt.Sprintf("its commit was reverted by %s: bring it back with `git revert %s`", revert, revert)
+}
@@ -0,0 +1,368 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+//go:embed web
+var webAssets embed.FS
+
+// webTokenHeader carries the session token every API request of the dashboard must have.
+const webTokenHeader = "X-Git-Undo-Token"
+
+// webEntriesLimit is how many of the latest log entries the dashboard shows.
+const webEntriesLimit = 200
+
+// webServer serves `git undo web`: a local dashboard of the undo history of the repository.
+type webServer struct {
+	app    *App
+	g      GitHelper
+	gitDir string
+	// token is a random secret of the session: it's only known to the page served to the browser,
+	// so other sites (or other local users without the URL) can't use the API.
+	token string
+	// host is the expected Host header (loopback address and port), guarding against DNS rebinding.
+	host string
+
+	// mu serializes undo/redo actions.
+	mu sync.Mutex
+}
+
+// webEntry is a log entry as shown by the dashboard.
+type webEntry struct {
+	ID         string `json:"id"`
+	Command    string `json:"command"`
+	Ref        string `json:"ref"`
+	Timestamp  string `json:"timestamp"`
+	Undone     bool   `json:"undone"`
+	Navigation bool   `json:"navigation"`
+	Pinned     bool   `json:"pinned"`
+	// Snapshot is the HEAD commit recorded right after the command (if any).
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// newWebEntry converts the log entry.
+func newWebEntry(entry *logging.Entry) webEntry {
+	head, _ := entry.State()
+	return webEntry{
+		ID:         entry.ID(),
+		Command:    entry.TypedCommand(),
+		Ref:        entry.Ref.String(),
+		Timestamp:  localRFC3339(entry.Timestamp),
+		Undone:     entry.Undoed,
+		Navigation: entry.IsNavigation,
+		Pinned:     entry.IsPinned(),
+		Snapshot:   head,
+	}
+}
+
+// webBranch is the activity on a ref.
+type webBranch struct {
+	Ref      string `json:"ref"`
+	Commands int    `json:"commands"`
+	Undone   int    `json:"undone"`
+	Last     string `json:"last"`
+}
+
+// webStorage is what git-undo keeps in the repository.
+type webStorage struct {
+	LogPath   string         `json:"logPath"`
+	LogBytes  int64          `json:"logBytes"`
+	Snapshots int            `json:"snapshots"`
+	Kept      []webRef       `json:"kept"`
+	Trash     []webTrashItem `json:"trash"`
+}
+
+// webRef is a ref kept by git-undo (see keepAliveRefPrefix).
+type webRef struct {
+	Ref     string `json:"ref"`
+	Summary string `json:"summary"`
+}
+
+// webTrashItem is a trashed branch, tag or stash.
+type webTrashItem struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Summary   string `json:"summary"`
+	TrashedAt string `json:"trashedAt,omitempty"`
+}
+
+// webState is everything the dashboard shows.
+type webState struct {
+	Repository string      `json:"repository"`
+	CurrentRef string      `json:"currentRef"`
+	Entries    []webEntry  `json:"entries"`
+	Branches   []webBranch `json:"branches"`
+	Storage    webStorage  `json:"storage"`
+}
+
+// webAction is the body of undo/redo requests: they must be confirmed explicitly.
+type webAction struct {
+	ID      string `json:"id"`
+	Confirm bool   `json:"confirm"`
+}
+
+// cmdWeb handles `git undo web [<port>]`: it serves the dashboard on the loopback interface
+// until interrupted. A random free port is used by default.
+func (a *App) cmdWeb(ctx context.Context, g GitHelper, gitDir string, args []string) error {
+	port := 0
+	if len(args) > 0 {
+		var err error
+		if port, err = strconv.Atoi(args[0]); err != nil || port < 0 || port > 65535 {
+			return fmt.Errorf("invalid port: %s", args[0])
+		}
+	}
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("failed to generate a session token: %w", err)
+	}
+	ws := &webServer{
+		app:    a,
+		g:      g,
+		gitDir: gitDir,
+		token:  hex.EncodeToString(token),
+		host:   listener.Addr().String(),
+	}
+
+	server := &http.Server{Handler: ws.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx) //nolint:contextcheck // the parent context is done already
+	}()
+
+	a.logInfof("Serving the undo dashboard at %s (Ctrl+C to stop)",
+		a.getTheme().highlight("http://"+ws.host+"/?token="+ws.token))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// routes returns the handler of the dashboard.
+func (ws *webServer) routes() http.Handler {
+	assets, _ := fs.Sub(webAssets, "web")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", ws.handleIndex)
+	mux.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServerFS(assets)))
+	mux.HandleFunc("GET /api/state", ws.requireToken(ws.handleState))
+	mux.HandleFunc("POST /api/preview", ws.requireToken(ws.handlePreview))
+	mux.HandleFunc("POST /api/undo", ws.requireToken(ws.handleUndo))
+	mux.HandleFunc("POST /api/redo", ws.requireToken(ws.handleRedo))
+	return ws.requireHost(mux)
+}
+
+// requireHost rejects requests addressed to other hosts than the served one (DNS rebinding).
+func (ws *webServer) requireHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != ws.host && r.Host != strings.Replace(ws.host, "127.0.0.1", "localhost", 1) {
+			http.Error(w, "unexpected host", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireToken rejects API requests without the session token.
+func (ws *webServer) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(webTokenHeader)), []byte(ws.token)) != 1 {
+			writeJSONError(w, http.StatusForbidden, errors.New("missing or invalid session token"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleIndex serves the page: the session token from the URL is checked and handed to the scripts.
+func (ws *webServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(ws.token)) != 1 {
+		http.Error(w, "open the URL printed by `git undo web`", http.StatusForbidden)
+		return
+	}
+
+	page, err := template.ParseFS(webAssets, "web/index.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	_ = page.Execute(w, map[string]string{"Token": ws.token, "AppName": ws.app.getAppName()})
+}
+
+// handleState returns the history, branch activity and storage of the repository.
+func (ws *webServer) handleState(w http.ResponseWriter, _ *http.Request) {
+	lgr := ws.app.newReadOnlyLogger(ws.gitDir, ws.g)
+	entries, err := lgr.GetEntries(webEntriesLimit, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	state := webState{
+		Repository: ws.app.getRepoPath(ws.g, ws.gitDir),
+		Entries:    make([]webEntry, 0, len(entries)),
+		Storage:    ws.storage(lgr),
+	}
+	state.CurrentRef, _ = ws.g.GetCurrentGitRef()
+
+	branches := make(map[string]*webBranch)
+	var refs []string
+	for _, entry := range entries {
+		state.Entries = append(state.Entries, newWebEntry(entry))
+		if head, _ := entry.State(); head != "" {
+			state.Storage.Snapshots++
+		}
+
+		branch, ok := branches[entry.Ref.String()]
+		if !ok {
+			// Entries are the newest first: the first one seen is the last activity on the ref
+			branch = &webBranch{Ref: entry.Ref.String(), Last: localRFC3339(entry.Timestamp)}
+			branches[branch.Ref] = branch
+			refs = append(refs, branch.Ref)
+		}
+		branch.Commands++
+		if entry.Undoed {
+			branch.Undone++
+		}
+	}
+	state.Branches = make([]webBranch, 0, len(refs))
+	for _, ref := range refs {
+		state.Branches = append(state.Branches, *branches[ref])
+	}
+
+	writeJSON(w, state)
+}
+
+// storage describes what git-undo keeps in the repository: the log, kept commits and the trash.
+func (ws *webServer) storage(lgr *logging.Logger) webStorage {
+	storage := webStorage{LogPath: lgr.GetLogPath(), Kept: []webRef{}, Trash: []webTrashItem{}}
+	if info, err := os.Stat(lgr.GetLogPath()); err == nil {
+		storage.LogBytes = info.Size()
+	}
+
+	kept, _ := ws.g.GitOutput("for-each-ref", "--format=%(refname)%09%(objectname:short) %(subject)",
+		keepAliveRefPrefix)
+	for _, line := range strings.Split(kept, "\n") {
+		if ref, summary, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			storage.Kept = append(storage.Kept, webRef{Ref: ref, Summary: summary})
+		}
+	}
+
+	items, _ := trashedItems(ws.g)
+	for _, item := range items {
+		trashed := webTrashItem{Kind: trashKindNames[item.kind], Name: item.name, Summary: item.summary}
+		if !item.trashedAt.IsZero() {
+			trashed.TrashedAt = item.trashedAt.Format(time.RFC3339)
+		}
+		storage.Trash = append(storage.Trash, trashed)
+	}
+	return storage
+}
+
+// handlePreview returns the plan of undoing the entry (the same one undo hooks get), without running it:
+// the same as the preview of `git undo serve`.
+func (ws *webServer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	var action webAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	entry, undoCmds, err := ws.app.planUndo(ws.g, ws.gitDir, RunOptions{EntryID: action.ID})
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, newUndoPlan(ws.g, false, entry, undoCmds))
+}
+
+// handleUndo undoes the entry: the same as `git undo --id <id>` (without asking for confirmation in strict mode,
+// as the dashboard asked for it already).
+func (ws *webServer) handleUndo(w http.ResponseWriter, r *http.Request) {
+	ws.runAction(w, r, func(action webAction) RunOptions {
+		return RunOptions{EntryID: action.ID}
+	})
+}
+
+// handleRedo redoes the last undone entry: the same as `git undo undo`.
+func (ws *webServer) handleRedo(w http.ResponseWriter, r *http.Request) {
+	ws.runAction(w, r, func(webAction) RunOptions {
+		return RunOptions{Args: []string{githelpers.CustomCommandUndo}}
+	})
+}
+
+// runAction runs the confirmed action like the CLI would.
+func (ws *webServer) runAction(w http.ResponseWriter, r *http.Request, options func(webAction) RunOptions) {
+	var action webAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if !action.Confirm {
+		writeJSONError(w, http.StatusBadRequest, ErrNotConfirmed)
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err := checkLogOwnership(ws.gitDir); err != nil {
+		writeJSONError(w, http.StatusConflict, err)
+		return
+	}
+	lgr, err := ws.app.newLogger(ws.gitDir, ws.g)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	confirmed := ws.app.confirmed
+	ws.app.confirmed = true
+	defer func() { ws.app.confirmed = confirmed }()
+
+	if err := ws.app.run(r.Context(), lgr, ws.g, options(action)); err != nil {
+		writeJSONError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,154 @@
+// Dashboard of `git undo web`: renders /api/state and runs confirmed undo/redo actions.
+"use strict";
+
+const token = document.querySelector('meta[name="git-undo-token"]').content;
+
+async function api(method, path, body) {
+  const response = await fetch(path, {
+    method,
+    headers: { "Content-Type": "application/json", "X-Git-Undo-Token": token },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+function element(tag, text, className) {
+  const el = document.createElement(tag);
+  if (text !== undefined) {
+    el.textContent = text;
+  }
+  if (className) {
+    el.className = className;
+  }
+  return el;
+}
+
+function showMessage(text, isError) {
+  const message = document.getElementById("message");
+  message.textContent = text;
+  message.classList.toggle("error", Boolean(isError));
+}
+
+function renderTimeline(entries) {
+  const timeline = document.getElementById("timeline");
+  timeline.replaceChildren();
+  for (const entry of entries) {
+    const item = element("li");
+    item.classList.toggle("undone", entry.undone);
+    item.classList.toggle("navigation", entry.navigation);
+    item.append(element("code", entry.command));
+
+    const flags = [entry.ref, new Date(entry.timestamp).toLocaleString()];
+    if (entry.pinned) {
+      flags.push("pinned");
+    }
+    if (entry.undone) {
+      flags.push("undone");
+    }
+    item.append(element("span", flags.join(" · "), "meta"));
+
+    if (!entry.undone) {
+      const preview = element("button", "Preview undo");
+      preview.type = "button";
+      preview.addEventListener("click", () => showPreview(entry));
+      item.append(preview);
+    }
+    timeline.append(item);
+  }
+  if (entries.length === 0) {
+    timeline.append(element("li", "No git commands logged yet."));
+  }
+}
+
+function renderBranches(branches, currentRef) {
+  const body = document.querySelector("#branches tbody");
+  body.replaceChildren();
+  for (const branch of branches) {
+    const row = element("tr");
+    const ref = branch.ref === currentRef ? branch.ref + " (current)" : branch.ref;
+    for (const value of [ref, branch.commands, branch.undone, new Date(branch.last).toLocaleString()]) {
+      row.append(element("td", String(value)));
+    }
+    body.append(row);
+  }
+}
+
+function renderStorage(storage) {
+  const list = document.getElementById("storage");
+  list.replaceChildren();
+  const add = (term, value) => {
+    list.append(element("dt", term), element("dd", value));
+  };
+  add("Log", storage.logPath + " (" + storage.logBytes + " bytes)");
+  add("State snapshots", String(storage.snapshots));
+  add("Kept commits", storage.kept.length ? storage.kept.map((k) => k.summary).join("\n") : "none");
+  add("Trash", storage.trash.length
+    ? storage.trash.map((t) => t.kind + " " + t.name + ": " + t.summary).join("\n")
+    : "empty");
+}
+
+async function refresh() {
+  const state = await api("GET", "/api/state");
+  document.getElementById("repository").textContent = state.repository;
+  renderTimeline(state.entries);
+  renderBranches(state.branches, state.currentRef);
+  renderStorage(state.storage);
+}
+
+async function showPreview(entry) {
+  const section = document.getElementById("preview");
+  try {
+    const plan = await api("POST", "/api/preview", { id: entry.id });
+    document.getElementById("preview-entry").textContent = plan.entry.command;
+    const commands = document.getElementById("preview-commands");
+    const warnings = document.getElementById("preview-warnings");
+    commands.replaceChildren();
+    warnings.replaceChildren();
+    for (const step of plan.commands) {
+      commands.append(element("li", step.command));
+      for (const warning of step.warnings || []) {
+        warnings.append(element("li", warning));
+      }
+    }
+    document.getElementById("preview-run").onclick = () => runUndo(entry);
+    section.hidden = false;
+    showMessage("");
+  } catch (err) {
+    section.hidden = true;
+    showMessage("Cannot undo " + entry.command + ": " + err.message, true);
+  }
+}
+
+async function runUndo(entry) {
+  if (!window.confirm("Undo `" + entry.command + "`?\nThis changes your repository.")) {
+    return;
+  }
+  try {
+    await api("POST", "/api/undo", { id: entry.id, confirm: true });
+    document.getElementById("preview").hidden = true;
+    showMessage("Undone: " + entry.command);
+  } catch (err) {
+    showMessage("Undo failed: " + err.message, true);
+  }
+  await refresh();
+}
+
+async function runRedo() {
+  if (!window.confirm("Redo the last undone command?\nThis changes your repository.")) {
+    return;
+  }
+  try {
+    await api("POST", "/api/redo", { confirm: true });
+    showMessage("Redone the last undone command");
+  } catch (err) {
+    showMessage("Redo failed: " + err.message, true);
+  }
+  await refresh();
+}
+
+document.getElementById("redo").addEventListener("click", runRedo);
+refresh().catch((err) => showMessage("Failed to load the history: " + err.message, true));
@@ -0,0 +1,241 @@
+package e2e_test
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/e2e"
+)
+
+var (
+	buildOnce  sync.Once
+	moduleRoot string
+	binDir     string
+	buildErr   error
+)
+
+// runScenario runs the scenario against binaries built (once per test run) from the current source.
+func runScenario(t *testing.T, sc e2e.Scenario) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("e2e scenarios are skipped in short mode")
+	}
+
+	buildOnce.Do(func() {
+		if moduleRoot, buildErr = e2e.FindModuleRoot(); buildErr != nil {
+			return
+		}
+		if binDir, buildErr = os.MkdirTemp("", "git-undo-e2e-bin-*"); buildErr != nil {
+			return
+		}
+		buildErr = e2e.BuildBinaries(moduleRoot, binDir)
+	})
+	if buildErr != nil {
+		t.Fatalf("failed to build binaries: %v", buildErr)
+	}
+
+	sc.Run(t, moduleRoot, binDir)
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if binDir != "" {
+		_ = os.RemoveAll(binDir)
+	}
+	os.Exit(code)
+}
+
+func TestAddCommitUndoRedo(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		{Run: "echo one > one.txt && echo two > two.txt"},
+		{Run: "git add one.txt"},
+		{Run: "git add two.txt"},
+		{Run: "git status --porcelain", Contains: []string{"A  one.txt", "A  two.txt"}},
+
+		// Undo goes back one command at a time
+		{Run: "git undo"},
+		{Run: "git status --porcelain", Contains: []string{"A  one.txt", "?? two.txt"}},
+		{Run: "git undo"},
+		{Run: "git status --porcelain", Contains: []string{"?? one.txt", "?? two.txt"}},
+
+		// Undoing the undo redoes the undone commands in their original order
+		{Run: "git undo undo"},
+		{Run: "git status --porcelain", Contains: []string{"A  one.txt", "?? two.txt"}},
+
+		{Run: "git add one.txt"},
+		{Run: `git commit -m "add files"`},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("add files")},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("init")},
+		{Run: "git status --porcelain", Contains: []string{"A  one.txt", "?? two.txt"}},
+	}})
+}
+
+func TestPathCommands(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		{Run: "git tag v1.0.0"},
+		{Run: "git undo"},
+		{Run: "git tag -l v1.0.0", Equals: e2e.Output("")},
+
+		{Run: "echo content > original.txt && git add original.txt && git commit -qm original"},
+		{Run: "git mv original.txt renamed.txt"},
+		{Run: "ls", Contains: []string{"renamed.txt"}, NotContains: []string{"original.txt"}},
+		{Run: "git undo"},
+		{Run: "ls", Contains: []string{"original.txt"}, NotContains: []string{"renamed.txt"}},
+
+		{Run: "git rm -q --cached original.txt"},
+		{Run: "git ls-files original.txt", Equals: e2e.Output("")},
+		{Run: "git undo"},
+		{Run: "git ls-files original.txt", Equals: e2e.Output("original.txt")},
+
+		{Run: "echo changed > original.txt && git add original.txt"},
+		{Run: "git restore --staged original.txt"},
+		{Run: "git diff --cached --name-only", Equals: e2e.Output("")},
+		{Run: "git undo"},
+		{Run: "git diff --cached --name-only", Equals: e2e.Output("original.txt")},
+	}})
+}
+
+func TestHistoryCommands(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		{Run: "echo second > second.txt && git add second.txt && git commit -qm second"},
+		{Run: "echo third > third.txt && git add third.txt && git commit -qm third"},
+
+		{Run: "git reset -q --soft HEAD~1"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("second")},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("third")},
+
+		{Run: "git reset -q --hard HEAD~1"},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("third")},
+
+		{Run: "git revert --no-edit HEAD"},
+		{Run: "git log --format=%s -1", Contains: []string{"Revert"}},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("third")},
+
+		{Run: "git switch -q -c feature"},
+		{Run: `echo cherry > cherry.txt && git add cherry.txt && git commit -qm "cherry-pick target"`},
+		{Run: "git switch -q main"},
+		{Run: "git cherry-pick feature"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("cherry-pick target")},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("third")},
+
+		{Run: "git merge -q feature"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("cherry-pick target")},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("third")},
+
+		{Run: "echo stashed >> second.txt && git stash -q"},
+		{Run: "git status --porcelain", Equals: e2e.Output("")},
+		{Run: "git undo"},
+		{Run: "git status --porcelain", Contains: []string{" M second.txt"}},
+
+		// Deleted branches are recreated at their tips recorded right before the deletion
+		{Run: "git rev-parse feature > tip.txt"},
+		{Run: "git branch -q -D feature"},
+		{Run: "git undo"},
+		{Run: "git rev-parse feature | diff - tip.txt && echo same", Equals: e2e.Output("same")},
+	}})
+}
+
+func TestCheckoutDetection(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		{Run: "echo main > main.txt && git add main.txt && git commit -qm main"},
+		{Run: "git switch -q -c feature"},
+		{Run: "echo feature > feature.txt && git add feature.txt && git commit -qm feature"},
+		{Run: "git switch -q main"},
+
+		// Navigation is undone by git back, not git undo
+		{Run: "git undo", Contains: []string{"can't be undone", "git back"}},
+		{Run: "git branch --show-current", Equals: e2e.Output("main")},
+		{Run: "git back"},
+		{Run: "git branch --show-current", Equals: e2e.Output("feature")},
+
+		// Other commands are undone without the hint
+		{Run: "echo test > test.txt && git add test.txt"},
+		{Run: "git undo", NotContains: []string{"git back"}},
+		{Run: "git status --porcelain", Contains: []string{"?? test.txt"}},
+	}})
+}
+
+func TestBackSteps(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		{Run: "git branch first && git branch second && git branch third"},
+		{Run: "git switch -q first"},
+		{Run: "git switch -q second"},
+		{Run: "git switch -q third"},
+		{Run: "git back --list", Contains: []string{"STEPS", "git switch -q third", "git switch -q first"}},
+
+		// Several steps at once walk down the navigation history
+		{Run: "git back -n 2"},
+		{Run: "git branch --show-current", Equals: e2e.Output("first")},
+		{Run: "git back -n 5", Fails: true, Contains: []string{"cannot go back 5 steps"}},
+
+		// Forward retraces the steps gone back, until a new navigation drops them
+		{Run: "git back --forward"},
+		{Run: "git branch --show-current", Equals: e2e.Output("second")},
+		{Run: "git back --forward"},
+		{Run: "git branch --show-current", Equals: e2e.Output("third")},
+		{Run: "git back --forward", Contains: []string{"nothing to go forward to"}},
+		{Run: "git back -n 2 && git switch -q second"},
+		{Run: "git back --forward", Contains: []string{"nothing to go forward to"}},
+		{Run: "git branch --show-current", Equals: e2e.Output("second")},
+	}})
+}
+
+func TestErrorCases(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		// Nothing was done yet
+		{Run: "git undo --log"},
+
+		// Failed commands are not logged
+		{Run: "echo tracked > tracked.txt && git add tracked.txt"},
+		{Run: "git add missing.txt", Fails: true},
+		{Run: "git undo"},
+		{Run: "git status --porcelain", Contains: []string{"?? tracked.txt"}},
+
+		// Commands that can't be undone are refused with a recovery hint
+		{Run: "git add tracked.txt && git commit -qm tracked && git switch -q -c side && git switch -q main"},
+		{Run: "git commit -q --allow-empty -m other"},
+		{Run: "git worktree add -q ../e2e-worktree side && git worktree lock ../e2e-worktree"},
+		{Run: "git undo", Fails: true, Contains: []string{"git worktree list"}},
+	}})
+}
+
+func TestCursorHistory(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		{Run: "touch a.txt b.txt c.txt f.txt"},
+		{Run: "git add a.txt"},
+		{Run: "git add b.txt"},
+		{Run: "git add c.txt"},
+		{Run: "git undo"},
+		{Run: "git undo"},
+		{Run: "git status --porcelain", Contains: []string{"A  a.txt", "?? b.txt", "?? c.txt"}},
+
+		// A new command after undo branches off: the undone commands can't be redone anymore
+		{Run: "git add f.txt"},
+		{Run: "git undo"},
+		{Run: "git undo"},
+		{Run: "git status --porcelain", Contains: []string{"?? a.txt", "?? b.txt", "?? f.txt"}},
+		{Run: "git undo undo"},
+		{Run: "git undo undo"},
+		{Run: "git status --porcelain", Contains: []string{"A  a.txt", "A  f.txt", "?? b.txt", "?? c.txt"}},
+		{Run: "git undo undo", Contains: []string{"nothing to redo"}},
+		{Run: "git status --porcelain", Contains: []string{"?? b.txt", "?? c.txt"}},
+	}})
+}
+
+func TestGitHookLogsCommandsOutsideTheShell(t *testing.T) {
+	runScenario(t, e2e.Scenario{Steps: []e2e.Step{
+		// The shell hook doesn't see git run by another program: the post-commit git hook logs it
+		{Run: "echo file > file.txt && git add file.txt"},
+		{Run: `sh -c 'git commit -qm "from script"'`},
+		{Run: "git undo --log", Contains: []string{"from script"}},
+		{Run: "git undo"},
+		{Run: "git log --format=%s -1", Equals: e2e.Output("init")},
+	}})
+}
@@ -0,0 +1,359 @@
+// Package e2e drives end-to-end scenarios against the real git-undo/git-back binaries:
+// they are compiled from the current source, and commands are typed into real interactive shells
+// with the git-undo hook sourced, exactly as a user's shell would run them.
+//
+// Scenarios run from `go test ./internal/e2e/...` in every shell of Shells found on the machine
+// (`go test -short` skips them).
+package e2e
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stepTimeout limits how long a single typed command may run.
+const stepTimeout = 30 * time.Second
+
+// doneMarker is printed after every typed command, followed by its exit status.
+const doneMarker = "__GIT_UNDO_E2E_DONE__"
+
+// Shell describes how to start an interactive shell with the git-undo hook sourced.
+type Shell struct {
+	// Name is the shell binary (looked up in PATH).
+	Name string
+	// Hook is the hook script (relative to the module root) sourced into the shell.
+	Hook string
+	// Args start the shell interactively without reading any user rc files.
+	Args []string
+	// Setup is typed into the shell before the hook is sourced.
+	Setup []string
+	// Source is the command sourcing a script into the shell.
+	Source string
+	// Done prints doneMarker and the exit status of the last command, on a new line.
+	Done string
+}
+
+// printfDone prints the done marker with printf, given the exit status of the last command.
+func printfDone(status string) string {
+	return `printf '\n%s %d\n' ` + doneMarker + " " + status
+}
+
+// Shells are the shells scenarios run in. Shells missing on the machine are skipped.
+var Shells = []Shell{
+	{
+		Name: "bash",
+		Hook: "scripts/git-undo-hook.bash",
+		Args: []string{"--norc", "--noprofile", "--noediting", "-i"},
+		// Without a terminal, prompts would only clutter the output
+		Setup:  []string{"PS1=''", "PS2=''"},
+		Source: "source",
+		Done:   printfDone("$?"),
+	},
+	{
+		Name:   "zsh",
+		Hook:   "scripts/git-undo-hook.zsh",
+		Args:   []string{"-f", "-i"},
+		Setup:  []string{"unsetopt zle", "PROMPT=''", "PS2=''", "RPROMPT=''"},
+		Source: "source",
+		Done:   printfDone("$?"),
+	},
+	{
+		Name:   "fish",
+		Hook:   "scripts/git-undo-hook.fish",
+		Args:   []string{"--no-config", "-i"},
+		Setup:  []string{"function fish_prompt; end", "function fish_right_prompt; end", "set -g fish_greeting"},
+		Source: "source",
+		Done:   printfDone("$status"),
+	},
+	{
+		Name: "pwsh",
+		Hook: "scripts/git-undo-hook.ps1",
+		// Commands are read from stdin one by one, as typed: the prompt (running the hook) follows each
+		Args:   []string{"-NoLogo", "-NoProfile", "-Command", "-"},
+		Setup:  []string{"function global:prompt { '' }"},
+		Source: ".",
+		// LASTEXITCODE is the status of the last native command (git): it's unset before the first one
+		Done: "Write-Output \"`n" + doneMarker + " $([int]$LASTEXITCODE)\"",
+	},
+}
+
+// gitHooks are the git hooks the installer points core.hooksPath to.
+var gitHooks = []string{"post-commit", "post-merge"}
+
+// FindModuleRoot returns the root directory of the git-undo module (the closest parent with go.mod).
+func FindModuleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// BuildBinaries compiles git-undo and git-back from the module into binDir.
+func BuildBinaries(moduleRoot, binDir string) error {
+	for _, name := range []string{"git-undo", "git-back"} {
+		out := filepath.Join(binDir, name)
+		if runtime.GOOS == "windows" {
+			out += ".exe"
+		}
+
+		cmd := exec.Command("go", "build", "-o", out, "./cmd/"+name)
+		cmd.Dir = moduleRoot
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to build %s: %w\n%s", name, err, output)
+		}
+	}
+	return nil
+}
+
+// Result is the outcome of a command typed into the shell.
+type Result struct {
+	// Output is the combined stdout and stderr of the command (including the hook's output).
+	Output   string
+	ExitCode int
+}
+
+// Session is an interactive shell running in a fresh repository with git-undo installed.
+type Session struct {
+	t     testing.TB
+	shell Shell
+
+	// RepoDir is the repository the shell runs in.
+	RepoDir string
+	// HomeDir is the isolated HOME of the shell (holding the global git config and git hooks).
+	HomeDir string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	exited chan error
+
+	// transcript is everything typed and printed, shown when the test fails
+	transcript strings.Builder
+}
+
+// Start starts the shell in a fresh repository (with an initial commit), using the binaries from binDir.
+// The test is skipped if the shell isn't available.
+func Start(t testing.TB, moduleRoot, binDir string, shell Shell) *Session {
+	t.Helper()
+
+	shellPath, err := exec.LookPath(shell.Name)
+	if err != nil {
+		t.Skipf("%s is not available: %v", shell.Name, err)
+	}
+
+	s := &Session{
+		t:       t,
+		shell:   shell,
+		RepoDir: filepath.Join(t.TempDir(), "repo"),
+		HomeDir: t.TempDir(),
+		lines:   make(chan string, 1024),
+		exited:  make(chan error, 1),
+	}
+	env := s.environ(binDir)
+	s.installGitHooks(moduleRoot)
+	s.initRepo(env)
+
+	s.cmd = exec.Command(shellPath, shell.Args...)
+	s.cmd.Dir = s.RepoDir
+	s.cmd.Env = env
+	if s.stdin, err = s.cmd.StdinPipe(); err != nil {
+		t.Fatalf("failed to open %s stdin: %v", shell.Name, err)
+	}
+
+	// Both streams go into one pipe, so messages keep their order
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create output pipe: %v", err)
+	}
+	s.cmd.Stdout = pw
+	s.cmd.Stderr = pw
+	if err := s.cmd.Start(); err != nil {
+		t.Fatalf("failed to start %s: %v", shell.Name, err)
+	}
+	_ = pw.Close()
+
+	go func() {
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+		close(s.lines)
+	}()
+	go func() { s.exited <- s.cmd.Wait() }()
+
+	t.Cleanup(s.close)
+
+	setup := append([]string{}, shell.Setup...)
+	setup = append(setup, shell.Source+" "+shellQuote(filepath.Join(moduleRoot, shell.Hook)))
+	for _, line := range setup {
+		if res := s.run(line); res.ExitCode != 0 {
+			t.Fatalf("failed to set up %s: `%s` exited with %d\n%s", shell.Name, line, res.ExitCode, res.Output)
+		}
+	}
+	// Everything printed so far is the shell's startup noise (e.g. job control warnings)
+	s.transcript.Reset()
+
+	return s
+}
+
+// environ returns the isolated environment of the shell: nothing from the user's configuration leaks in.
+func (s *Session) environ(binDir string) []string {
+	return []string{
+		"HOME=" + s.HomeDir,
+		"PATH=" + binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"GIT_CONFIG_GLOBAL=" + filepath.Join(s.HomeDir, ".gitconfig"),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_UNDO_THEME=plain",
+		"TERM=dumb",
+		"LANG=C",
+	}
+}
+
+// installGitHooks installs the git hooks (and the global git config) the way the installer does.
+func (s *Session) installGitHooks(moduleRoot string) {
+	script, err := os.ReadFile(filepath.Join(moduleRoot, "scripts", "git-undo-git-hook.sh"))
+	if err != nil {
+		s.t.Fatalf("failed to read git hook: %v", err)
+	}
+
+	hooksDir := filepath.Join(s.HomeDir, ".config", "git-undo", "hooks")
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		s.t.Fatalf("failed to create git hooks dir: %v", err)
+	}
+	for _, hook := range gitHooks {
+		// The hook script tells hooks apart by its file name
+		//nolint:gosec // hooks must be executable
+		if err := os.WriteFile(filepath.Join(hooksDir, hook), script, 0o755); err != nil {
+			s.t.Fatalf("failed to install %s hook: %v", hook, err)
+		}
+	}
+
+	gitConfig := fmt.Sprintf("[user]\n\tname = Git-Undo E2E\n\temail = git-undo-e2e@amberpixels.io\n"+
+		"[init]\n\tdefaultBranch = main\n[core]\n\thooksPath = %s\n", filepath.ToSlash(hooksDir))
+	if err := os.WriteFile(filepath.Join(s.HomeDir, ".gitconfig"), []byte(gitConfig), 0o600); err != nil {
+		s.t.Fatalf("failed to write global git config: %v", err)
+	}
+}
+
+// initRepo creates the repository with an initial empty commit (neither shell nor git hooks log it).
+func (s *Session) initRepo(env []string) {
+	for _, args := range [][]string{
+		{"init", "-q", s.RepoDir},
+		{"-C", s.RepoDir, "-c", "core.hooksPath=" + os.DevNull, "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			s.t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+}
+
+// Run types the command into the shell and waits for it (and the hook) to finish.
+func (s *Session) Run(command string) Result {
+	s.t.Helper()
+
+	res := s.run(command)
+	s.transcript.WriteString("$ " + command + "\n")
+	if res.Output != "" {
+		s.transcript.WriteString(res.Output + "\n")
+	}
+	if res.ExitCode != 0 {
+		s.transcript.WriteString("[exit status " + strconv.Itoa(res.ExitCode) + "]\n")
+	}
+	return res
+}
+
+// MustRun runs the command and fails the test if it exits with a non-zero status.
+func (s *Session) MustRun(command string) string {
+	s.t.Helper()
+
+	res := s.Run(command)
+	if res.ExitCode != 0 {
+		s.t.Fatalf("[%s] `%s` exited with %d", s.shell.Name, command, res.ExitCode)
+	}
+	return res.Output
+}
+
+// Transcript returns everything typed into the shell and printed by it so far.
+func (s *Session) Transcript() string {
+	return "--- " + s.shell.Name + " transcript ---\n" + s.transcript.String() + "--- end of transcript ---"
+}
+
+// run types the command followed by the done marker, and collects the output up to the marker.
+// The marker goes on its own line: the hook must see the command exactly as typed.
+func (s *Session) run(command string) Result {
+	s.t.Helper()
+
+	input := command + "\n" + s.shell.Done + "\n"
+	if _, err := io.WriteString(s.stdin, input); err != nil {
+		s.t.Fatalf("[%s] failed to type `%s`: %v", s.shell.Name, command, err)
+	}
+
+	var output []string
+	timeout := time.After(stepTimeout)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("[%s] shell exited while running `%s`:\n%s",
+					s.shell.Name, command, strings.Join(output, "\n"))
+			}
+			if status, found := strings.CutPrefix(line, doneMarker+" "); found {
+				code, _ := strconv.Atoi(strings.TrimSpace(status))
+				// The marker is printed on a new line: drop the extra empty line before it
+				if len(output) > 0 && output[len(output)-1] == "" {
+					output = output[:len(output)-1]
+				}
+				return Result{Output: strings.Join(output, "\n"), ExitCode: code}
+			}
+			output = append(output, line)
+		case <-timeout:
+			s.t.Fatalf("[%s] `%s` didn't finish in %s:\n%s",
+				s.shell.Name, command, stepTimeout, strings.Join(output, "\n"))
+		}
+	}
+}
+
+// close exits the shell (killing it if it doesn't exit) and shows the transcript if the test failed.
+// Failures are reported without the transcript: it's logged once here.
+func (s *Session) close() {
+	_, _ = io.WriteString(s.stdin, "exit\n")
+	_ = s.stdin.Close()
+
+	select {
+	case <-s.exited:
+	case <-time.After(stepTimeout):
+		_ = s.cmd.Process.Kill()
+		<-s.exited
+	}
+
+	if s.t.Failed() {
+		s.t.Log(s.Transcript())
+	}
+}
+
+// shellQuote quotes the string for POSIX-like shells (fish and PowerShell take it as well, but for quotes in it).
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
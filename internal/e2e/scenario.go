@@ -0,0 +1,74 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+)
+
+// Step is a command typed into the shell together with the expectations of its result.
+type Step struct {
+	// Run is the command line typed into the shell.
+	Run string
+	// Fails expects the command to exit with a non-zero status.
+	Fails bool
+	// Contains lists substrings the output must contain.
+	Contains []string
+	// NotContains lists substrings the output must not contain.
+	NotContains []string
+	// Equals is the exact expected output (after trimming), checked when set.
+	Equals *string
+}
+
+// Output returns the expectation of the exact output (see Step.Equals).
+func Output(s string) *string { return &s }
+
+// Scenario is a sequence of steps typed into a fresh shell session.
+type Scenario struct {
+	Name  string
+	Steps []Step
+}
+
+// Run runs the scenario in every available shell (as subtests named after the shells).
+func (sc Scenario) Run(t *testing.T, moduleRoot, binDir string) {
+	t.Helper()
+
+	for _, shell := range Shells {
+		t.Run(shell.Name, func(t *testing.T) {
+			session := Start(t, moduleRoot, binDir, shell)
+			for i, step := range sc.Steps {
+				session.check(i+1, step)
+			}
+		})
+	}
+}
+
+// check runs the step and reports every unmet expectation (continuing with the next steps).
+func (s *Session) check(n int, step Step) {
+	s.t.Helper()
+
+	res := s.Run(step.Run)
+	fail := func(format string, args ...any) {
+		s.t.Helper()
+		s.t.Errorf("[%s] step %d `%s`: "+format, append([]any{s.shell.Name, n, step.Run}, args...)...)
+	}
+
+	switch {
+	case step.Fails && res.ExitCode == 0:
+		fail("expected to fail, but succeeded")
+	case !step.Fails && res.ExitCode != 0:
+		fail("exited with %d", res.ExitCode)
+	}
+	for _, want := range step.Contains {
+		if !strings.Contains(res.Output, want) {
+			fail("output doesn't contain %q", want)
+		}
+	}
+	for _, unwanted := range step.NotContains {
+		if strings.Contains(res.Output, unwanted) {
+			fail("output contains %q", unwanted)
+		}
+	}
+	if step.Equals != nil && strings.TrimSpace(res.Output) != strings.TrimSpace(*step.Equals) {
+		fail("output is %q, want %q", strings.TrimSpace(res.Output), strings.TrimSpace(*step.Equals))
+	}
+}
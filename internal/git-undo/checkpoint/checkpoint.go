@@ -0,0 +1,153 @@
+// Package checkpoint keeps named points in the history of a repository (`git undo checkpoint save <name>`),
+// so `git undo restore <name>` can bring the repository back to them.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// dirName is the directory (in the git-undo dir of the git dir) holding checkpoints, one file each.
+	dirName = "git-undo/checkpoints"
+	// fileExt is the extension of checkpoint files (named after their checkpoints).
+	fileExt = ".json"
+)
+
+// ErrExists is returned when saving a checkpoint under a name that is taken.
+var ErrExists = errors.New("checkpoint already exists")
+
+// Checkpoint is the state of the repository saved under a name.
+type Checkpoint struct {
+	// Name is the name the checkpoint was saved under (its file name).
+	Name string `json:"-"`
+	// Time is when the checkpoint was saved.
+	Time time.Time `json:"time"`
+	// Ref is the branch (or ref) checked out when the checkpoint was saved.
+	Ref string `json:"ref"`
+	// Head is the HEAD commit (empty in a repository with no commits yet).
+	Head string `json:"head,omitempty"`
+	// Index is the tree of the index (see `git write-tree`).
+	Index string `json:"index,omitempty"`
+	// Entry is the identifier of the newest log entry done on Ref: the log position of the checkpoint
+	// (empty if there was none).
+	Entry string `json:"entry,omitempty"`
+}
+
+// Store manages the checkpoints of a repository.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the checkpoint store of the repository with the given git dir.
+func NewStore(gitDir string) *Store {
+	return &Store{dir: filepath.Join(gitDir, filepath.FromSlash(dirName))}
+}
+
+// ValidateName checks that the name can name a checkpoint: it names a file, so no path separators.
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." ||
+		strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid checkpoint name: %q", name)
+	}
+	return nil
+}
+
+// Save stores the checkpoint under its name, refusing to overwrite an existing one (see ErrExists).
+func (s *Store) Save(cp *Checkpoint) error {
+	if err := ValidateName(cp.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	file, err := os.OpenFile(s.path(cp.Name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, cp.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", cp.Name, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(s.path(cp.Name))
+		return fmt.Errorf("failed to write checkpoint %s: %w", cp.Name, err)
+	}
+	return file.Close()
+}
+
+// Get returns the checkpoint with the given name.
+func (s *Store) Get(name string) (*Checkpoint, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no checkpoint named %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", name, err)
+	}
+	cp := &Checkpoint{Name: name}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", name, err)
+	}
+	return cp, nil
+}
+
+// List returns the checkpoints, oldest first. Unreadable ones are skipped.
+func (s *Store) List() ([]*Checkpoint, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	var checkpoints []*Checkpoint
+	for _, dirEntry := range dirEntries {
+		name, ok := strings.CutSuffix(dirEntry.Name(), fileExt)
+		if dirEntry.IsDir() || !ok {
+			continue
+		}
+		if cp, err := s.Get(name); err == nil {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	slices.SortStableFunc(checkpoints, func(x, y *Checkpoint) int { return x.Time.Compare(y.Time) })
+	return checkpoints, nil
+}
+
+// Delete removes the checkpoint with the given name.
+func (s *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no checkpoint named %s", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete checkpoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// path returns the path of the file of the checkpoint.
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+fileExt)
+}
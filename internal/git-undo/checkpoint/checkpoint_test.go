@@ -0,0 +1,47 @@
+package checkpoint_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := checkpoint.NewStore(t.TempDir())
+
+	list, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, list, "no checkpoints dir yet")
+
+	older := &checkpoint.Checkpoint{Name: "before-refactor", Time: time.Now().Add(-time.Hour), Ref: "main",
+		Head: "1111111111111111111111111111111111111111", Entry: "2025-01-01 10:00:00|main|git commit -m 'x'"}
+	newer := &checkpoint.Checkpoint{Name: "wip", Time: time.Now(), Ref: "feature"}
+	require.NoError(t, store.Save(newer))
+	require.NoError(t, store.Save(older))
+	require.ErrorIs(t, store.Save(&checkpoint.Checkpoint{Name: "wip"}), checkpoint.ErrExists)
+
+	got, err := store.Get("before-refactor")
+	require.NoError(t, err)
+	assert.Equal(t, older.Head, got.Head)
+	assert.Equal(t, older.Entry, got.Entry)
+	assert.Equal(t, "main", got.Ref)
+
+	list, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "before-refactor", list[0].Name, "oldest first")
+	assert.Equal(t, "wip", list[1].Name)
+
+	require.NoError(t, store.Delete("wip"))
+	require.Error(t, store.Delete("wip"))
+	_, err = store.Get("wip")
+	require.Error(t, err)
+
+	for _, name := range []string{"", "..", "a/b", `a\b`, "-f"} {
+		require.Error(t, checkpoint.ValidateName(name), name)
+		require.Error(t, store.Save(&checkpoint.Checkpoint{Name: name}), name)
+	}
+}
@@ -0,0 +1,445 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Section is the git config section holding git-undo settings (e.g. `git config git-undo.include ~/work/**`).
+const Section = "git-undo"
+
+// Keys of the supported settings.
+const (
+	// KeyInclude is a (multi-valued) glob of repository paths where history is collected.
+	KeyInclude = "include"
+	// KeyExclude is a (multi-valued) glob of repository paths where history is never collected.
+	KeyExclude = "exclude"
+	// KeyTheme is the output theme (emoji, minimal, plain or accessible).
+	KeyTheme = "theme"
+	// KeyAccessible enables the screen reader friendly output regardless of the theme.
+	KeyAccessible = "accessible"
+	// KeyNotes enables mirroring undo events as git notes.
+	KeyNotes = "notes"
+	// KeyStrict enables the strict profile: every safety feature at once.
+	KeyStrict = "strict"
+	// KeyAudit enables the audit log of executed undo operations.
+	KeyAudit = "audit"
+	// KeyConfirm makes git-undo ask for confirmation before executing undo operations.
+	KeyConfirm = "confirm"
+	// KeyProtectedBranch is a (multi-valued) glob of branches whose history git-undo never rewrites.
+	KeyProtectedBranch = "protectedbranch"
+	// KeyIgnore is a (multi-valued) command (e.g. `fetch` or `remote update`) that is never logged.
+	KeyIgnore = "ignore"
+	// KeyMaxPerMinute caps how many read-only and navigation commands of the same subcommand are logged per minute.
+	KeyMaxPerMinute = "maxperminute"
+	// KeyGroupWindow is how many seconds after a mutating command the next ones join its undo group
+	// (undone together), 0 meaning never.
+	KeyGroupWindow = "groupwindow"
+	// KeyTrashRetention is how many days items deleted by undo operations are kept in the trash (0 means forever).
+	KeyTrashRetention = "trashretention"
+	// KeySnapshotRetention is how many days snapshots of files lost by destructive commands are kept
+	// (0 means forever).
+	KeySnapshotRetention = "snapshotretention"
+	// KeyLogRetention is how many days undone entries are kept in the log before `git undo gc` removes them
+	// (0 means forever).
+	KeyLogRetention = "logretention"
+	// KeyAutoGC is the size (in KiB) of the log past which the hook runs `git undo gc` (0 means never).
+	KeyAutoGC = "autogc"
+	// KeyLogFailed enables logging the mutating commands that failed (never undone, shown by `git undo log`).
+	KeyLogFailed = "logfailed"
+	// KeyGlobalJournal enables recording every logged command, with the repository it ran in, to the global
+	// journal shown by `git undo log --global`.
+	KeyGlobalJournal = "globaljournal"
+	// KeyDebugLog enables writing detailed traces of every invocation (hooks included, read-only commands
+	// excluded) to the debug log (.git/git-undo/debug.log), redacted as the log is. It's disabled while the
+	// log is encrypted.
+	KeyDebugLog = "debuglog"
+	// KeyRedact is a (multi-valued) pattern redacted from logged commands and metadata: `*` matches any run
+	// of non-blank characters (e.g. `https://*:*@` hides credentials of URLs).
+	KeyRedact = "redact"
+	// KeyEncrypt enables encrypting the commands and metadata of the log with a key derived from the passphrase
+	// (GIT_UNDO_PASSPHRASE, or the output of KeyPassphraseCommand).
+	KeyEncrypt = "encrypt"
+	// KeyPassphraseCommand is a shell command printing the passphrase of the encrypted log
+	// (e.g. reading it from the system keyring).
+	KeyPassphraseCommand = "passphrasecommand"
+	// KeyMaxLogEntries caps how many entries the log keeps (0 means no cap): the oldest ones are dropped.
+	KeyMaxLogEntries = "maxlogentries"
+	// KeyDisableUndoer is a (multi-valued) git subcommand (e.g. `push`) whose undo is refused.
+	KeyDisableUndoer = "disableundoer"
+	// KeyColor enables colored output (true by default).
+	KeyColor = "color"
+	// KeyPreUndoHook is a shell command run before undo operations: exiting non-zero vetoes the undo.
+	KeyPreUndoHook = "pre-undo-hook"
+	// KeyPostUndoHook is a shell command run after undo operations.
+	KeyPostUndoHook = "post-undo-hook"
+)
+
+// DefaultTrashRetention is how long items are kept in the trash unless configured otherwise.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// DefaultSnapshotRetention is how long snapshots are kept unless configured otherwise.
+const DefaultSnapshotRetention = 7 * 24 * time.Hour
+
+// DefaultLogRetention is how long undone entries are kept in the log unless configured otherwise.
+const DefaultLogRetention = 30 * 24 * time.Hour
+
+// EnvStrict enables the strict profile regardless of git config (e.g. GIT_UNDO_STRICT=1).
+const EnvStrict = "GIT_UNDO_STRICT"
+
+// strictDefaultProtectedBranches are protected in strict mode when no protected branches are configured.
+var strictDefaultProtectedBranches = []string{"main", "master"}
+
+// strictDisabledUndoers are subcommands whose undo is refused in strict mode
+// (undoing them either rewrites remote state or can't restore deleted files reliably).
+var strictDisabledUndoers = []string{"push", "clean"}
+
+// GitHelper is the subset of git helpers the config needs.
+type GitHelper interface {
+	GitOutput(subCmd string, args ...string) (string, error)
+}
+
+// Config holds git-undo settings.
+type Config struct {
+	// Include limits history collection to repositories matching any of these globs (if set).
+	Include []string
+	// Exclude disables history collection in repositories matching any of these globs.
+	Exclude []string
+	// Theme is the output theme name.
+	Theme string
+	// Accessible enables the screen reader friendly output regardless of the theme.
+	Accessible bool
+	// Notes enables mirroring undo events as git notes.
+	Notes bool
+
+	// Strict enables the strict profile (see StrictProfile methods below).
+	Strict bool
+	// Audit enables the audit log of executed undo operations.
+	Audit bool
+	// Confirm makes git-undo ask for confirmation before executing undo operations.
+	Confirm bool
+	// ProtectedBranches are globs of branches whose history git-undo never rewrites.
+	ProtectedBranches []string
+
+	// Ignore lists commands (subcommand optionally followed by leading arguments) that are never logged.
+	Ignore []string
+	// MaxPerMinute caps how many read-only and navigation commands of the same subcommand are logged per minute
+	// (0 means no cap).
+	MaxPerMinute int
+	// GroupWindow is how long after a mutating command the next ones join its undo group (0 means never).
+	GroupWindow time.Duration
+
+	// LogFailed enables logging the mutating commands that failed.
+	LogFailed bool
+	// GlobalJournal enables recording logged commands to the global journal.
+	GlobalJournal bool
+	// DebugLog enables writing detailed traces to the debug log.
+	DebugLog bool
+	// Redact lists patterns redacted from logged commands and metadata.
+	Redact []string
+	// Encrypt enables encrypting the log at rest.
+	Encrypt bool
+	// PassphraseCommand is a shell command printing the passphrase of the encrypted log.
+	PassphraseCommand string
+	// MaxLogEntries caps how many entries the log keeps (0 means no cap).
+	MaxLogEntries int
+	// DisabledUndoers are git subcommands whose undo is refused.
+	DisabledUndoers []string
+	// Color enables colored output.
+	Color bool
+
+	// TrashRetention is how long items deleted by undo operations are kept in the trash (0 means forever).
+	TrashRetention time.Duration
+	// SnapshotRetention is how long snapshots of files lost by destructive commands are kept (0 means forever).
+	SnapshotRetention time.Duration
+	// LogRetention is how long undone entries are kept in the log before gc removes them (0 means forever).
+	LogRetention time.Duration
+	// AutoGC is the size (in bytes) of the log past which the hook runs gc (0 means never).
+	AutoGC int64
+
+	// PreUndoHook and PostUndoHook are shell commands run around undo operations,
+	// receiving the undo plan as JSON on stdin.
+	PreUndoHook  string
+	PostUndoHook string
+}
+
+// Load reads git-undo settings from git config (all scopes: system, global, local),
+// then from the config file of the repository (see FileName), whose settings win.
+func Load(g GitHelper) (*Config, error) {
+	cfg := &Config{
+		TrashRetention:    DefaultTrashRetention,
+		SnapshotRetention: DefaultSnapshotRetention,
+		LogRetention:      DefaultLogRetention,
+		Color:             true,
+	}
+
+	// git config exits with 1 when nothing matches: that's just an empty config
+	out, err := g.GitOutput("config", "--get-regexp", `^`+regexp.QuoteMeta(Section)+`\.`)
+	if err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+			if key == "" {
+				continue
+			}
+			if err := cfg.set(strings.TrimPrefix(strings.ToLower(key), Section+"."), value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Outside of a repository there is no config file
+	if gitDir, err := g.GitOutput("rev-parse", "--absolute-git-dir"); err == nil && gitDir != "" {
+		if err := cfg.loadFile(FilePath(strings.TrimSpace(gitDir))); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg.withEnv()
+}
+
+// withEnv applies settings given via environment variables (they win over git config).
+func (c *Config) withEnv() (*Config, error) {
+	if value, ok := os.LookupEnv(EnvStrict); ok {
+		strict, err := parseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvStrict, err)
+		}
+		c.Strict = strict
+	}
+	return c, nil
+}
+
+// set applies a single setting.
+func (c *Config) set(key, value string) error {
+	switch key {
+	case KeyInclude:
+		c.Include = append(c.Include, value)
+	case KeyExclude:
+		c.Exclude = append(c.Exclude, value)
+	case KeyTheme:
+		c.Theme = value
+	case KeyAccessible:
+		return setBool(&c.Accessible, key, value)
+	case KeyNotes:
+		return setBool(&c.Notes, key, value)
+	case KeyStrict:
+		return setBool(&c.Strict, key, value)
+	case KeyAudit:
+		return setBool(&c.Audit, key, value)
+	case KeyConfirm:
+		return setBool(&c.Confirm, key, value)
+	case KeyProtectedBranch:
+		c.ProtectedBranches = append(c.ProtectedBranches, value)
+	case KeyIgnore:
+		c.Ignore = append(c.Ignore, value)
+	case KeyMaxPerMinute:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number: %q", Section, key, value)
+		}
+		c.MaxPerMinute = n
+	case KeyGroupWindow:
+		seconds, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || seconds < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number of seconds: %q", Section, key, value)
+		}
+		c.GroupWindow = time.Duration(seconds) * time.Second
+	case KeyLogFailed:
+		return setBool(&c.LogFailed, key, value)
+	case KeyGlobalJournal:
+		return setBool(&c.GlobalJournal, key, value)
+	case KeyDebugLog:
+		return setBool(&c.DebugLog, key, value)
+	case KeyRedact:
+		c.Redact = append(c.Redact, value)
+	case KeyEncrypt:
+		return setBool(&c.Encrypt, key, value)
+	case KeyPassphraseCommand:
+		c.PassphraseCommand = value
+	case KeyMaxLogEntries:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number: %q", Section, key, value)
+		}
+		c.MaxLogEntries = n
+	case KeyDisableUndoer:
+		c.DisabledUndoers = append(c.DisabledUndoers, strings.TrimPrefix(strings.TrimSpace(value), "git "))
+	case KeyColor:
+		return setBool(&c.Color, key, value)
+	case KeyTrashRetention:
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number of days: %q", Section, key, value)
+		}
+		c.TrashRetention = time.Duration(days) * 24 * time.Hour
+	case KeySnapshotRetention:
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number of days: %q", Section, key, value)
+		}
+		c.SnapshotRetention = time.Duration(days) * 24 * time.Hour
+	case KeyLogRetention:
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number of days: %q", Section, key, value)
+		}
+		c.LogRetention = time.Duration(days) * 24 * time.Hour
+	case KeyAutoGC:
+		kib, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || kib < 0 {
+			return fmt.Errorf("invalid %s.%s: not a non-negative number of KiB: %q", Section, key, value)
+		}
+		c.AutoGC = int64(kib) << 10
+	case KeyPreUndoHook:
+		c.PreUndoHook = value
+	case KeyPostUndoHook:
+		c.PostUndoHook = value
+	default:
+		// Unknown keys are ignored, so older binaries work with newer configs
+	}
+	return nil
+}
+
+// setBool parses the boolean value of the key into dst.
+func setBool(dst *bool, key, value string) error {
+	enabled, err := parseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s.%s: %w", Section, key, err)
+	}
+	*dst = enabled
+	return nil
+}
+
+// parseBool parses a boolean value the way git does (a key without a value means true).
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("not a boolean: %q", value)
+	}
+}
+
+// ConfirmRequired reports whether undo operations must be confirmed interactively.
+func (c *Config) ConfirmRequired() bool {
+	return c.Strict || c.Confirm
+}
+
+// AuditEnabled reports whether executed undo operations are written to the audit log.
+func (c *Config) AuditEnabled() bool {
+	return c.Strict || c.Audit
+}
+
+// IsUndoerDisabled reports whether undoing the given git subcommand is refused:
+// it's one of DisabledUndoers, or disabled in strict mode.
+func (c *Config) IsUndoerDisabled(subCommand string) bool {
+	return slices.Contains(c.DisabledUndoers, subCommand) ||
+		c.Strict && slices.Contains(strictDisabledUndoers, subCommand)
+}
+
+// IsBranchProtected reports whether git-undo must not rewrite the history of the branch.
+// Protected branches are enforced in strict mode only (main and master by default).
+func (c *Config) IsBranchProtected(branch string) bool {
+	if !c.Strict || branch == "" {
+		return false
+	}
+
+	patterns := c.ProtectedBranches
+	if len(patterns) == 0 {
+		patterns = strictDefaultProtectedBranches
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool { return MatchPath(pattern, branch) })
+}
+
+// IsCommandIgnored reports whether the git command (given by its subcommand and arguments) is never logged:
+// an ignored command matches when its words lead the command (e.g. `remote update` matches `remote update -p`).
+func (c *Config) IsCommandIgnored(subCommand string, args []string) bool {
+	words := append([]string{subCommand}, args...)
+	return slices.ContainsFunc(c.Ignore, func(ignored string) bool {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(ignored), "git "))
+		return len(fields) > 0 && len(fields) <= len(words) && slices.Equal(fields, words[:len(fields)])
+	})
+}
+
+// IsRepoIncluded checks if history should be collected in the repository at the given path.
+// The returned reason explains the decision (e.g. which pattern matched).
+func (c *Config) IsRepoIncluded(repoPath string) (bool, string) {
+	repoPath = filepath.Clean(repoPath)
+
+	for _, pattern := range c.Exclude {
+		if MatchPath(pattern, repoPath) {
+			return false, fmt.Sprintf("excluded by %s.%s=%s", Section, KeyExclude, pattern)
+		}
+	}
+
+	if len(c.Include) == 0 {
+		return true, "no include/exclude rules"
+	}
+
+	for _, pattern := range c.Include {
+		if MatchPath(pattern, repoPath) {
+			return true, fmt.Sprintf("included by %s.%s=%s", Section, KeyInclude, pattern)
+		}
+	}
+
+	return false, fmt.Sprintf("not matched by any %s.%s pattern", Section, KeyInclude)
+}
+
+// MatchPath reports whether the path matches the glob pattern.
+// Patterns support `~` for the home directory, `*` and `?` within a path segment,
+// and `**` for any number of segments (`~/work/**` matches `~/work` itself and everything below it).
+func MatchPath(pattern, path string) bool {
+	re, err := globToRegexp(expandHome(pattern))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(filepath.ToSlash(filepath.Clean(path)))
+}
+
+// expandHome replaces the leading `~` with the user's home directory.
+func expandHome(pattern string) string {
+	if pattern != "~" && !strings.HasPrefix(pattern, "~/") {
+		return pattern
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return pattern
+	}
+	return home + strings.TrimPrefix(pattern, "~")
+}
+
+// globToRegexp converts a path glob into an anchored regular expression.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(pattern)
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "/**"):
+			sb.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+	sb.WriteString("/?$")
+
+	return regexp.Compile(sb.String())
+}
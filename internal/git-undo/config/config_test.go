@@ -0,0 +1,254 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGit returns the given `git config --get-regexp` output, and the git dir (if any) to `git rev-parse`.
+type fakeGit struct {
+	output string
+	err    error
+	gitDir string
+}
+
+func (f *fakeGit) GitOutput(subCmd string, _ ...string) (string, error) {
+	if subCmd == "rev-parse" {
+		if f.gitDir == "" {
+			return "", errors.New("not a git repository")
+		}
+		return f.gitDir, nil
+	}
+	return f.output, f.err
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := config.Load(&fakeGit{output: "git-undo.include ~/work/**\n" +
+		"git-undo.exclude ~/work/secret\n" +
+		"git-undo.include /srv/repos/*\n" +
+		"git-undo.theme plain\n" +
+		"git-undo.notes yes\n" +
+		"git-undo.accessible true\n" +
+		"git-undo.trashretention 7\n" +
+		"git-undo.snapshotretention 0\n" +
+		"git-undo.autogc 512\n" +
+		"git-undo.logfailed\n" +
+		"git-undo.unknown-key whatever"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"~/work/**", "/srv/repos/*"}, cfg.Include)
+	assert.Equal(t, []string{"~/work/secret"}, cfg.Exclude)
+	assert.Equal(t, "plain", cfg.Theme)
+	assert.True(t, cfg.Notes)
+	assert.True(t, cfg.Accessible)
+	assert.Equal(t, 7*24*time.Hour, cfg.TrashRetention)
+	assert.Zero(t, cfg.SnapshotRetention)
+	assert.Equal(t, int64(512<<10), cfg.AutoGC)
+	assert.True(t, cfg.LogFailed)
+
+	// Invalid boolean values are reported
+	_, err = config.Load(&fakeGit{output: "git-undo.notes maybe"})
+	require.Error(t, err)
+
+	// No git-undo keys at all (git config exits with 1)
+	cfg, err = config.Load(&fakeGit{err: errors.New("exit status 1")})
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Include)
+	assert.Empty(t, cfg.Exclude)
+	assert.Equal(t, config.DefaultTrashRetention, cfg.TrashRetention)
+	assert.Equal(t, config.DefaultSnapshotRetention, cfg.SnapshotRetention)
+	assert.Equal(t, config.DefaultLogRetention, cfg.LogRetention)
+	assert.Zero(t, cfg.AutoGC)
+}
+
+func TestConfigFile(t *testing.T) {
+	gitDir := t.TempDir()
+	writeFile := func(content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(config.FilePath(gitDir)), 0750))
+		require.NoError(t, os.WriteFile(config.FilePath(gitDir), []byte(content), 0600))
+	}
+
+	writeFile(`# git-undo settings of this repository
+[git-undo]
+confirm = true
+maxLogEntries = 1_000
+snapshotretention = 3   # days
+disableundoer = ["push", 'git clean']
+color = false
+theme = "plain # not a comment"
+`)
+	cfg, err := config.Load(&fakeGit{output: "git-undo.theme emoji\ngit-undo.disableundoer rebase", gitDir: gitDir})
+	require.NoError(t, err)
+	assert.True(t, cfg.Confirm)
+	assert.True(t, cfg.ConfirmRequired())
+	assert.Equal(t, 1000, cfg.MaxLogEntries)
+	assert.Equal(t, 3*24*time.Hour, cfg.SnapshotRetention)
+	assert.False(t, cfg.Color)
+	assert.Equal(t, "plain # not a comment", cfg.Theme, "the file wins over git config")
+	assert.Equal(t, []string{"rebase", "push", "clean"}, cfg.DisabledUndoers)
+	assert.True(t, cfg.IsUndoerDisabled("clean"))
+	assert.False(t, cfg.IsUndoerDisabled("commit"))
+
+	// Without the file (or a repository) only git config counts
+	cfg, err = config.Load(&fakeGit{output: "git-undo.maxlogentries 50"})
+	require.NoError(t, err)
+	assert.Equal(t, 50, cfg.MaxLogEntries)
+	assert.True(t, cfg.Color)
+
+	// Invalid files are reported with the line
+	for content, expected := range map[string]string{
+		"confirm = \"maybe\"\n":       "config.toml:1: invalid git-undo.confirm",
+		"[core]\nconfirm = true\n":    "config.toml:1: unknown table [core]",
+		"theme = plain\n":             "config.toml:1: invalid value: plain",
+		"\nconfirm\n":                 "config.toml:2: expected `key = value`",
+		"disableundoer = [\"push\"\n": "config.toml:1: unterminated array",
+	} {
+		writeFile(content)
+		_, err = config.Load(&fakeGit{gitDir: gitDir, err: errors.New("exit status 1")})
+		require.ErrorContains(t, err, expected)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	gitDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Dir(config.FilePath(gitDir)), 0750))
+	require.NoError(t, os.WriteFile(config.FilePath(gitDir), []byte("theme = 'plain'\nignore = ['fetch']\n"), 0600))
+
+	settings, err := config.Settings(&fakeGit{output: "file:/home/me/.gitconfig\tgit-undo.theme minimal\n" +
+		"file:.git/config\tgit-undo.ignore remote update\n" +
+		"file:.git/config\tgit-undo.unknown whatever", gitDir: gitDir})
+	require.NoError(t, err)
+
+	origins := map[string][]string{}
+	for _, setting := range settings {
+		origins[setting.Key] = append(origins[setting.Key], setting.Value+" "+setting.Origin)
+	}
+	assert.Equal(t, []string{"plain file:" + config.FilePath(gitDir)}, origins[config.KeyTheme])
+	assert.Equal(t, []string{"remote update file:.git/config", "fetch file:" + config.FilePath(gitDir)},
+		origins[config.KeyIgnore])
+	assert.Equal(t, []string{"30 default"}, origins[config.KeyTrashRetention])
+	assert.NotContains(t, origins, config.KeyInclude, "no values, no default")
+	assert.NotContains(t, origins, "unknown")
+
+	assert.NoError(t, config.Validate("git-undo.maxPerMinute", "10"))
+	assert.Error(t, config.Validate("maxperminute", "-1"))
+	assert.Error(t, config.Validate("confirm-destructive", "true"))
+}
+
+func TestMatchPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"~/work/**", filepath.Join(home, "work"), true},
+		{"~/work/**", filepath.Join(home, "work", "a", "b"), true},
+		{"~/work/**", filepath.Join(home, "workshop"), false},
+		{"/srv/*", "/srv/repo", true},
+		{"/srv/*", "/srv/repo/nested", false},
+		{"/srv/repo-?", "/srv/repo-1", true},
+		{"/srv/**/secret", "/srv/a/b/secret", true},
+		{"/srv/repo", "/srv/repo/", true},
+		{"/srv/repo", "/srv/repo2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.match, config.MatchPath(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestIsRepoIncluded(t *testing.T) {
+	// No rules: everything is included
+	included, _ := (&config.Config{}).IsRepoIncluded("/any/repo")
+	assert.True(t, included)
+
+	cfg := &config.Config{
+		Include: []string{"/work/**"},
+		Exclude: []string{"/work/secret/**"},
+	}
+
+	included, reason := cfg.IsRepoIncluded("/work/project")
+	assert.True(t, included)
+	assert.Contains(t, reason, "include")
+
+	// Exclude wins over include
+	included, reason = cfg.IsRepoIncluded("/work/secret/project")
+	assert.False(t, included)
+	assert.Contains(t, reason, "exclude")
+
+	// Include rules limit collection to matching repositories
+	included, reason = cfg.IsRepoIncluded("/oss/project")
+	assert.False(t, included)
+	assert.Contains(t, reason, "not matched")
+}
+
+func TestStrictProfile(t *testing.T) {
+	// Nothing is enforced by default
+	cfg, err := config.Load(&fakeGit{output: "git-undo.protectedbranch release/*"})
+	require.NoError(t, err)
+	assert.False(t, cfg.ConfirmRequired())
+	assert.False(t, cfg.AuditEnabled())
+	assert.False(t, cfg.IsUndoerDisabled("clean"))
+	assert.False(t, cfg.IsBranchProtected("release/1.0"))
+
+	// Strict mode enables everything at once
+	cfg, err = config.Load(&fakeGit{output: "git-undo.strict true"})
+	require.NoError(t, err)
+	assert.True(t, cfg.ConfirmRequired())
+	assert.True(t, cfg.AuditEnabled())
+	assert.True(t, cfg.IsUndoerDisabled("clean"))
+	assert.True(t, cfg.IsUndoerDisabled("push"))
+	assert.False(t, cfg.IsUndoerDisabled("commit"))
+	assert.True(t, cfg.IsBranchProtected("main"))
+	assert.False(t, cfg.IsBranchProtected("feature"))
+
+	// Configured protected branches replace the default ones
+	t.Setenv(config.EnvStrict, "1")
+	cfg, err = config.Load(&fakeGit{output: "git-undo.protectedbranch release/*"})
+	require.NoError(t, err)
+	assert.True(t, cfg.Strict)
+	assert.True(t, cfg.IsBranchProtected("release/1.0"))
+	assert.False(t, cfg.IsBranchProtected("main"))
+
+	// The environment variable wins over git config
+	t.Setenv(config.EnvStrict, "0")
+	cfg, err = config.Load(&fakeGit{output: "git-undo.strict true"})
+	require.NoError(t, err)
+	assert.False(t, cfg.Strict)
+}
+
+func TestThrottleSettings(t *testing.T) {
+	cfg, err := config.Load(&fakeGit{output: "git-undo.ignore fetch\n" +
+		"git-undo.ignore git remote update\n" +
+		"git-undo.maxperminute 30"})
+	require.NoError(t, err)
+	assert.Equal(t, 30, cfg.MaxPerMinute)
+
+	assert.True(t, cfg.IsCommandIgnored("fetch", []string{"--all"}))
+	assert.True(t, cfg.IsCommandIgnored("remote", []string{"update", "-p"}))
+	assert.False(t, cfg.IsCommandIgnored("remote", []string{"add", "origin"}))
+	assert.False(t, cfg.IsCommandIgnored("commit", nil))
+
+	_, err = config.Load(&fakeGit{output: "git-undo.maxperminute lots"})
+	require.Error(t, err)
+}
+
+func TestGroupWindow(t *testing.T) {
+	cfg, err := config.Load(&fakeGit{output: "git-undo.groupwindow 5"})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.GroupWindow)
+
+	_, err = config.Load(&fakeGit{output: "git-undo.groupwindow -1"})
+	require.Error(t, err)
+}
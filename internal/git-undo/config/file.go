@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileName is the per-repository config file, in the git-undo dir of the git dir
+// (e.g. `.git/git-undo/config.toml`). Its settings win over git config ones.
+const FileName = "config.toml"
+
+// FilePath returns the path of the config file of the repository with the given git dir.
+func FilePath(gitDir string) string {
+	return filepath.Join(gitDir, "git-undo", FileName)
+}
+
+// loadFile applies the settings of the config file at the path (a missing file has none).
+func (c *Config) loadFile(path string) error {
+	settings, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	for _, setting := range settings {
+		if err := c.set(setting.Key, setting.Value); err != nil {
+			return fmt.Errorf("%s: %w", setting.Origin, err)
+		}
+	}
+	return nil
+}
+
+// readFile reads the settings of the config file at the path (a missing file has none),
+// their origin being the path and the line.
+//
+// The file is a flat TOML document: `key = value` lines with the same keys as git config ones
+// (optionally under a [git-undo] table), values being strings, booleans, numbers or arrays of them
+// (arrays set multi-valued keys such as include). Comments start with #.
+func readFile(path string) ([]Setting, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var settings []Setting
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if table, ok := strings.CutPrefix(line, "["); ok {
+			if table = strings.TrimSpace(strings.TrimSuffix(table, "]")); table != Section {
+				return nil, fmt.Errorf("%s:%d: unknown table [%s] (only [%s] is supported)",
+					path, lineNo, table, Section)
+			}
+			continue
+		}
+
+		key, rawValue, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected `key = value`", path, lineNo)
+		}
+		values, err := parseTOMLValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		key = strings.ToLower(strings.Trim(strings.TrimSpace(key), `"`))
+		for _, value := range values {
+			settings = append(settings, Setting{Key: key, Value: value, Origin: fmt.Sprintf("%s:%d", path, lineNo)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// parseTOMLValue parses a TOML value into the git config style values it sets:
+// an array gives one value per item.
+func parseTOMLValue(raw string) ([]string, error) {
+	if items, ok := strings.CutPrefix(raw, "["); ok {
+		items, ok = strings.CutSuffix(items, "]")
+		if !ok {
+			return nil, fmt.Errorf("unterminated array: %s", raw)
+		}
+		var values []string
+		for _, item := range splitTOMLArray(items) {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, err := parseTOMLScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+
+	value, err := parseTOMLScalar(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+// parseTOMLScalar parses a string (basic or literal), boolean or number.
+func parseTOMLScalar(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string: %s", raw)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("invalid string: %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	default:
+		if _, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64); err != nil {
+			return "", fmt.Errorf("invalid value: %s (strings must be quoted)", raw)
+		}
+		return strings.ReplaceAll(raw, "_", ""), nil
+	}
+}
+
+// splitTOMLArray splits the items of an array at the commas outside of strings.
+func splitTOMLArray(items string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range items {
+		switch {
+		case quote != 0 && r == quote && (quote == '\'' || i == 0 || items[i-1] != '\\'):
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == ',':
+			parts = append(parts, items[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, items[start:])
+}
+
+// stripComment removes the # comment of the line (unless the # is in a string).
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote && (quote == '\'' || i == 0 || line[i-1] != '\\'):
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// KeyInfo describes a supported setting.
+type KeyInfo struct {
+	// Name is the key within Section (e.g. theme for git-undo.theme).
+	Name string
+	// Multi is set for multi-valued keys (every value counts, e.g. include).
+	Multi bool
+	// Default is the effective value when the key is not set ("" when there is none).
+	Default string
+}
+
+// Keys are the supported settings, in the order `git undo config list` shows them.
+var Keys = []KeyInfo{
+	{Name: KeyInclude, Multi: true},
+	{Name: KeyExclude, Multi: true},
+	{Name: KeyTheme, Default: "emoji"},
+	{Name: KeyAccessible, Default: "false"},
+	{Name: KeyColor, Default: "true"},
+	{Name: KeyNotes, Default: "false"},
+	{Name: KeyStrict, Default: "false"},
+	{Name: KeyAudit, Default: "false"},
+	{Name: KeyConfirm, Default: "false"},
+	{Name: KeyProtectedBranch, Multi: true},
+	{Name: KeyDisableUndoer, Multi: true},
+	{Name: KeyIgnore, Multi: true},
+	{Name: KeyMaxPerMinute, Default: "0"},
+	{Name: KeyGroupWindow, Default: "0"},
+	{Name: KeyMaxLogEntries, Default: "0"},
+	{Name: KeyLogFailed, Default: "false"},
+	{Name: KeyGlobalJournal, Default: "false"},
+	{Name: KeyDebugLog, Default: "false"},
+	{Name: KeyRedact, Multi: true},
+	{Name: KeyEncrypt, Default: "false"},
+	{Name: KeyPassphraseCommand},
+	{Name: KeyTrashRetention, Default: strconv.Itoa(int(DefaultTrashRetention.Hours() / 24))},
+	{Name: KeySnapshotRetention, Default: strconv.Itoa(int(DefaultSnapshotRetention.Hours() / 24))},
+	{Name: KeyLogRetention, Default: strconv.Itoa(int(DefaultLogRetention.Hours() / 24))},
+	{Name: KeyAutoGC, Default: "0"},
+	{Name: KeyPreUndoHook},
+	{Name: KeyPostUndoHook},
+}
+
+// LookupKey returns the supported setting of the key, given with or without the section
+// (keys are case-insensitive, as in git config).
+func LookupKey(key string) (KeyInfo, bool) {
+	key = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(key)), Section+".")
+	index := slices.IndexFunc(Keys, func(info KeyInfo) bool { return info.Name == key })
+	if index < 0 {
+		return KeyInfo{}, false
+	}
+	return Keys[index], true
+}
+
+// Validate checks that the value is valid for the key.
+func Validate(key, value string) error {
+	info, ok := LookupKey(key)
+	if !ok {
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+	return (&Config{}).set(info.Name, value)
+}
+
+// Setting is a value of a setting and where it comes from.
+type Setting struct {
+	// Key is the key within Section.
+	Key   string
+	Value string
+	// Origin is the file setting it (as git config --show-origin gives it), the environment variable or "default".
+	Origin string
+}
+
+// OriginDefault is the origin of settings that are not set.
+const OriginDefault = "default"
+
+// Settings returns the effective settings (see Load for their precedence), in the order of Keys:
+// every value of multi-valued keys, the winning one of the others, or their default when not set.
+func Settings(g GitHelper) ([]Setting, error) {
+	var raw []Setting
+
+	// git config exits with 1 when nothing matches: that's just an empty config
+	out, err := g.GitOutput("config", "--show-origin", "--get-regexp", `^`+regexp.QuoteMeta(Section)+`\.`)
+	if err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			origin, keyValue, found := strings.Cut(strings.TrimSpace(line), "\t")
+			if !found {
+				continue
+			}
+			key, value, _ := strings.Cut(keyValue, " ")
+			key = strings.TrimPrefix(strings.ToLower(key), Section+".")
+			raw = append(raw, Setting{Key: key, Value: value, Origin: origin})
+		}
+	}
+
+	if gitDir, err := g.GitOutput("rev-parse", "--absolute-git-dir"); err == nil && gitDir != "" {
+		path := FilePath(strings.TrimSpace(gitDir))
+		fileSettings, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, setting := range fileSettings {
+			raw = append(raw, Setting{Key: setting.Key, Value: setting.Value, Origin: "file:" + path})
+		}
+	}
+
+	if value, ok := os.LookupEnv(EnvStrict); ok {
+		raw = append(raw, Setting{Key: KeyStrict, Value: value, Origin: "env:" + EnvStrict})
+	}
+
+	var settings []Setting
+	for _, info := range Keys {
+		var values []Setting
+		for _, setting := range raw {
+			if setting.Key != info.Name {
+				continue
+			}
+			if err := Validate(setting.Key, setting.Value); err != nil {
+				return nil, fmt.Errorf("%s: %w", setting.Origin, err)
+			}
+			values = append(values, setting)
+		}
+
+		switch {
+		case len(values) == 0 && info.Default != "":
+			settings = append(settings, Setting{Key: info.Name, Value: info.Default, Origin: OriginDefault})
+		case len(values) == 0:
+		case info.Multi:
+			settings = append(settings, values...)
+		default:
+			settings = append(settings, values[len(values)-1])
+		}
+	}
+	return settings, nil
+}
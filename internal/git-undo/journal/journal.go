@@ -0,0 +1,107 @@
+// Package journal keeps the global (cross-repository) journal of logged commands: which repository each one
+// ran in, so `git undo log --global` can show what was done where. It's opt-in (see config.KeyGlobalJournal)
+// and only a view: every repository keeps its own log, the undo works with.
+package journal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// timeFormat is the format of record times: the one of log entries (see logging.Entry.Timestamp).
+const timeFormat = time.DateTime
+
+// Record is a command logged in a repository.
+type Record struct {
+	// Time is when the command was logged, as the timestamp of its log entry.
+	Time time.Time
+	// Repo is the top-level directory of the repository (or its git dir when it has none).
+	Repo string
+	// ID is the ID of the log entry of the command in the repository (see logging.Entry.ID).
+	ID string
+	// Ref is the branch (or tag, or commit) the command was run on.
+	Ref string
+	// Command is the git command as it was typed.
+	Command string
+}
+
+// DefaultPath returns the path of the journal: git-undo/history in the user's data dir
+// ($XDG_DATA_HOME, ~/.local/share by default).
+func DefaultPath() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the home directory: %w", err)
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "git-undo", "history"), nil
+}
+
+// Append adds the record to the journal at path, creating it if needed.
+// A record is a single line appended at once, so concurrent hooks can share the journal.
+func Append(path string, record Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open the journal: %w", err)
+	}
+	defer file.Close()
+
+	fields := []string{record.Time.Format(timeFormat), record.Repo, record.ID, record.Ref, record.Command}
+	for i, field := range fields {
+		// Tabs separate the fields and newlines the records
+		fields[i] = strings.Join(strings.FieldsFunc(field, func(r rune) bool { return r == '\t' || r == '\n' }), " ")
+	}
+	if _, err := file.WriteString(strings.Join(fields, "\t") + "\n"); err != nil {
+		return fmt.Errorf("failed to write the journal: %w", err)
+	}
+	return nil
+}
+
+// Read returns up to limit records of the journal at path (all of them if limit <= 0) logged since the given
+// time (any time if zero), newest first. A missing journal has no records; malformed lines are skipped.
+func Read(path string, since time.Time, limit int) ([]Record, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the journal: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		t, err := time.Parse(timeFormat, fields[0])
+		if err != nil || (!since.IsZero() && t.Before(since)) {
+			continue
+		}
+		records = append(records, Record{Time: t, Repo: fields[1], ID: fields[2], Ref: fields[3], Command: fields[4]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the journal: %w", err)
+	}
+
+	// Records are appended: the newest ones are last
+	newest := make([]Record, 0, len(records))
+	for i := len(records) - 1; i >= 0 && (limit <= 0 || len(newest) < limit); i-- {
+		newest = append(newest, records[i])
+	}
+	return newest, nil
+}
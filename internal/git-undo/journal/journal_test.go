@@ -0,0 +1,53 @@
+package journal_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "git-undo", "history")
+
+	records, err := journal.Read(path, time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, records, "no journal yet")
+
+	morning := time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, journal.Append(path, journal.Record{Time: morning, Repo: "/work/api", ID: "1111111",
+		Ref: "main", Command: "git commit -m fix"}))
+	require.NoError(t, journal.Append(path, journal.Record{Time: morning.Add(time.Hour), Repo: "/work/web",
+		ID: "2222222", Ref: "feature", Command: "git add \"a\tb\""}))
+	require.NoError(t, journal.Append(path, journal.Record{Time: morning.Add(2 * time.Hour), Repo: "/work/api",
+		ID: "3333333", Ref: "main", Command: "git push"}))
+
+	records, err = journal.Read(path, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "3333333", records[0].ID, "newest first")
+	assert.Equal(t, "/work/web", records[1].Repo)
+	assert.Equal(t, "git add \"a b\"", records[1].Command, "tabs can't break the fields")
+	assert.Equal(t, morning, records[2].Time)
+
+	records, err = journal.Read(path, morning.Add(30*time.Minute), 1)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "3333333", records[0].ID)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	path, err := journal.DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/data", "git-undo", "history"), path)
+
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	path, err = journal.DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/home/me", ".local", "share", "git-undo", "history"), path)
+}
@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backgroundCommands are git subcommands running in background on their own (scheduled maintenance,
+// daemons). Whatever git runs on their behalf is not the user's doing and must never be logged.
+var backgroundCommands = map[string]struct{}{
+	"maintenance":              {},
+	"fsmonitor--daemon":        {},
+	"credential-cache--daemon": {},
+	"daemon":                   {},
+}
+
+// launchdMaintenancePrefix prefixes the launchd services `git maintenance start` schedules on macOS.
+const launchdMaintenancePrefix = "org.git-scm.git."
+
+// maxAncestors limits how far up the process tree background git processes are looked for.
+const maxAncestors = 8
+
+// psTimeout limits the process listing (on systems without /proc).
+const psTimeout = time.Second
+
+// BackgroundInvocation reports whether the hook is called on behalf of a background git process
+// (e.g. `git maintenance` or the fsmonitor daemon) rather than the user, and describes why.
+// It's detected once per invocation: the process tree is read only when the environment is inconclusive.
+var BackgroundInvocation = sync.OnceValues(func() (string, bool) {
+	return detectBackground(os.LookupEnv, func() []string { return processAncestors(os.Getppid()) })
+})
+
+// detectBackground looks for background git processes in the environment and, when it's inconclusive,
+// in the command lines of the ancestor processes (closest first).
+func detectBackground(lookupEnv func(string) (string, bool), ancestors func() []string) (string, bool) {
+	// Git passes its command hierarchy to child processes (e.g. "maintenance/gc") when trace2 is enabled
+	if parents, ok := lookupEnv("GIT_TRACE2_PARENT_NAME"); ok {
+		for name := range strings.SplitSeq(parents, "/") {
+			if _, bg := backgroundCommands[name]; bg {
+				return "run by git " + name, true
+			}
+		}
+		return "", false
+	}
+
+	// Scheduled maintenance on macOS doesn't need a git parent to be recognized
+	if service, _ := lookupEnv("XPC_SERVICE_NAME"); strings.HasPrefix(service, launchdMaintenancePrefix) {
+		return "run by the " + service + " launchd service", true
+	}
+
+	// Git sets it for all the processes it runs: without it, no git process is an ancestor (e.g. shell hooks)
+	if _, ok := lookupEnv("GIT_EXEC_PATH"); !ok {
+		return "", false
+	}
+
+	for _, cmdline := range ancestors() {
+		if name, ok := backgroundGitCommand(cmdline); ok {
+			return "run by `" + strings.TrimSpace(cmdline) + "` (git " + name + ")", true
+		}
+	}
+
+	return "", false
+}
+
+// backgroundGitCommand returns the git subcommand of the command line if it's a background one.
+func backgroundGitCommand(cmdline string) (string, bool) {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	program := strings.TrimSuffix(filepath.Base(fields[0]), ".exe")
+	// Dashed forms (e.g. git-maintenance) are run by git itself
+	if name, dashed := strings.CutPrefix(program, "git-"); dashed {
+		_, bg := backgroundCommands[name]
+		return name, bg
+	}
+	if program != "git" {
+		return "", false
+	}
+
+	for i := 1; i < len(fields); i++ {
+		switch {
+		case fields[i] == "-C" || fields[i] == "-c":
+			i++ // skip the option value
+		case strings.HasPrefix(fields[i], "-"):
+		case fields[i] == "for-each-repo":
+			// Schedulers run maintenance via `git for-each-repo --config=maintenance.repo maintenance run`
+		default:
+			_, bg := backgroundCommands[fields[i]]
+			return fields[i], bg
+		}
+	}
+	return "", false
+}
+
+// processAncestors returns the command lines of the process and its ancestors (closest first).
+// Inspection is best effort: nothing is returned where the process tree can't be read.
+func processAncestors(pid int) []string {
+	var parentOf func(pid int) (int, string, bool)
+	switch runtime.GOOS {
+	case "linux":
+		parentOf = procParent
+	case "windows":
+		return nil
+	default:
+		parentOf = psProcessTable()
+	}
+
+	var ancestors []string
+	for range maxAncestors {
+		if pid <= 1 {
+			break
+		}
+		ppid, cmdline, ok := parentOf(pid)
+		if !ok {
+			break
+		}
+		ancestors = append(ancestors, cmdline)
+		pid = ppid
+	}
+	return ancestors
+}
+
+// procParent reads the parent and the command line of the process from /proc.
+func procParent(pid int) (int, string, bool) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return 0, "", false
+	}
+	// The process name in parentheses may contain spaces: fields are counted after it
+	_, afterName, found := bytes.Cut(stat, []byte(") "))
+	fields := strings.Fields(string(afterName))
+	if !found || len(fields) < 2 {
+		return 0, "", false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, "", false
+	}
+
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return 0, "", false
+	}
+	return ppid, strings.TrimSpace(string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '}))), true
+}
+
+// psProcessTable lists all processes once via ps and returns the lookup of their parents and command lines.
+func psProcessTable() func(pid int) (int, string, bool) {
+	type process struct {
+		ppid    int
+		cmdline string
+	}
+	processes := make(map[int]process)
+
+	ctx, cancel := context.WithTimeout(context.Background(), psTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "args=").Output()
+	if err == nil {
+		for line := range strings.SplitSeq(string(output), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			pid, pidErr := strconv.Atoi(fields[0])
+			ppid, ppidErr := strconv.Atoi(fields[1])
+			if pidErr == nil && ppidErr == nil {
+				processes[pid] = process{ppid: ppid, cmdline: strings.Join(fields[2:], " ")}
+			}
+		}
+	}
+
+	return func(pid int) (int, string, bool) {
+		p, ok := processes[pid]
+		return p.ppid, p.cmdline, ok
+	}
+}
@@ -0,0 +1,130 @@
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectBackground(t *testing.T) {
+	interactiveShell := []string{"-bash", "/usr/bin/tmux", "/sbin/init"}
+	const execPath = "/usr/lib/git-core"
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		ancestors  []string
+		background bool
+		// readsProcesses tells whether the environment is inconclusive, so the process tree is read
+		readsProcesses bool
+	}{
+		{
+			name:      "shell hook in an interactive shell",
+			ancestors: interactiveShell,
+		},
+		{
+			name:      "not run by git: the process tree isn't read",
+			ancestors: []string{"git maintenance run --auto"},
+		},
+		{
+			name: "git hook of a commit typed by the user",
+			env: map[string]string{
+				"GIT_UNDO_GIT_HOOK_MARKER": "1",
+				"GIT_HOOK_NAME":            "post-commit",
+				"GIT_DIR":                  ".git",
+				"GIT_INDEX_FILE":           ".git/index",
+				"GIT_EXEC_PATH":            execPath,
+			},
+			ancestors:      append([]string{"git commit -m msg"}, interactiveShell...),
+			readsProcesses: true,
+		},
+		{
+			name: "git hook of a commit made by an IDE",
+			env: map[string]string{
+				"GIT_UNDO_GIT_HOOK_MARKER": "1", "GIT_HOOK_NAME": "post-commit", "GIT_EXEC_PATH": execPath,
+			},
+			ancestors:      []string{"/usr/bin/git -c core.quotepath=false commit -F -", "/opt/idea/bin/idea"},
+			readsProcesses: true,
+		},
+		{
+			name: "git run by scheduled maintenance",
+			env:  map[string]string{"GIT_DIR": "/repo/.git", "GIT_EXEC_PATH": execPath},
+			ancestors: []string{
+				"git fetch --prefetch",
+				"/usr/bin/git --exec-path=/usr/lib/git-core for-each-repo --config=maintenance.repo " +
+					"maintenance run --schedule=hourly",
+			},
+			background:     true,
+			readsProcesses: true,
+		},
+		{
+			name: "git run by maintenance (subcommand after global options)",
+			env:  map[string]string{"GIT_EXEC_PATH": execPath},
+			ancestors: []string{
+				"git fetch --prefetch", "/usr/bin/git -C /repo maintenance run --schedule=hourly", "/sbin/init",
+			},
+			background:     true,
+			readsProcesses: true,
+		},
+		{
+			name:           "maintenance run through the dashed binary",
+			env:            map[string]string{"GIT_EXEC_PATH": execPath},
+			ancestors:      []string{"/usr/lib/git-core/git-maintenance run --auto"},
+			background:     true,
+			readsProcesses: true,
+		},
+		{
+			name:           "fsmonitor daemon",
+			env:            map[string]string{"GIT_DIR": "/repo/.git", "GIT_EXEC_PATH": execPath},
+			ancestors:      []string{"git fsmonitor--daemon run --detach"},
+			background:     true,
+			readsProcesses: true,
+		},
+		{
+			name:       "trace2 parent names of maintenance",
+			env:        map[string]string{"GIT_TRACE2_PARENT_NAME": "maintenance/gc", "GIT_EXEC_PATH": execPath},
+			background: true,
+		},
+		{
+			name: "trace2 parent names of a user command",
+			env:  map[string]string{"GIT_TRACE2_PARENT_NAME": "pull/fetch", "GIT_EXEC_PATH": execPath},
+		},
+		{
+			name:       "launchd maintenance service",
+			env:        map[string]string{"XPC_SERVICE_NAME": "org.git-scm.git.hourly"},
+			background: true,
+		},
+		{
+			name: "other launchd service",
+			env:  map[string]string{"XPC_SERVICE_NAME": "application.com.apple.Terminal.1234"},
+		},
+		{
+			name:           "commands merely mentioning maintenance",
+			env:            map[string]string{"GIT_EXEC_PATH": execPath},
+			ancestors:      []string{"vim maintenance.md", "git log --grep maintenance"},
+			readsProcesses: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookupEnv := func(key string) (string, bool) {
+				value, ok := tt.env[key]
+				return value, ok
+			}
+			readProcesses := false
+			ancestors := func() []string {
+				readProcesses = true
+				return tt.ancestors
+			}
+			reason, background := logging.DetectBackground(lookupEnv, ancestors)
+			assert.Equal(t, tt.background, background)
+			assert.Equal(t, tt.readsProcesses, readProcesses)
+			if background {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
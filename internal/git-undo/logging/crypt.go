@@ -0,0 +1,219 @@
+package logging
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// keyFileName keeps what the key of the encrypted log is derived with: a random salt, and a value sealed
+	// with the key, so wrong passphrases are told apart from corrupted entries.
+	keyFileName = "key"
+	// keyCheck is the value sealed in the key file.
+	keyCheck = "git-undo"
+	// keyIterations is the PBKDF2 work factor: every invocation (hooks included) derives the key once.
+	keyIterations = 100_000
+	// sealedPrefix starts the encrypted part of entries: `+M <time>|<ref>|enc:<base64>`.
+	sealedPrefix = "enc:"
+)
+
+// ErrWrongPassphrase is returned when the passphrase doesn't derive the key the log was encrypted with.
+var ErrWrongPassphrase = errors.New("wrong passphrase for the encrypted log")
+
+// ErrEncrypted is returned when an entry is parsed while still encrypted (the log is read without its key).
+var ErrEncrypted = errors.New("entry is encrypted")
+
+// derivedKeys memoizes the keys derived in this process (by salt and passphrase): several loggers
+// of the same log don't pay for the key derivation again.
+var derivedKeys sync.Map
+
+// SetPassphrase makes the logger decrypt the encrypted entries it reads with the key derived from the passphrase
+// and, with encrypt, encrypt the commands and metadata of the entries it writes (the timestamp, ref and state stay
+// readable). Rewrites of the log (e.g. Compact) re-encrypt every entry, or with encrypt unset, decrypt them.
+// The first encrypting logger creates the key file; without it, there's nothing to decrypt.
+func (l *Logger) SetPassphrase(passphrase string, encrypt bool) error {
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+
+	salt, check, err := l.readKeyFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		if !encrypt || l.readOnly {
+			return nil
+		}
+		salt, check, err = l.createKeyFile(passphrase)
+	}
+	if err != nil {
+		return err
+	}
+
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	if opened, err := openSealed(aead, check, nil); err != nil || string(opened) != keyCheck {
+		return ErrWrongPassphrase
+	}
+	l.aead = aead
+	l.encrypt = encrypt
+	return nil
+}
+
+// IsEncrypted reports whether the log has a key: some of its entries may be encrypted.
+func (l *Logger) IsEncrypted() bool {
+	_, err := os.Stat(filepath.Join(l.logDir, keyFileName))
+	return err == nil
+}
+
+// readKeyFile returns the salt and the sealed check value of the key file.
+func (l *Logger) readKeyFile() ([]byte, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(l.logDir, keyFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return nil, nil, fmt.Errorf("invalid key file: %s", filepath.Join(l.logDir, keyFileName))
+	}
+	salt, saltErr := base64.StdEncoding.DecodeString(fields[0])
+	check, checkErr := base64.StdEncoding.DecodeString(fields[1])
+	if err := errors.Join(saltErr, checkErr); err != nil {
+		return nil, nil, fmt.Errorf("invalid key file: %w", err)
+	}
+	return salt, check, nil
+}
+
+// createKeyFile creates the key file of the passphrase (unless a concurrent hook just did).
+func (l *Logger) createKeyFile(passphrase string) ([]byte, []byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate a salt: %w", err)
+	}
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	check, err := seal(aead, []byte(keyCheck), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := filepath.Join(l.logDir, keyFileName)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return l.readKeyFile()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the key file: %w", permissionError(path, err))
+	}
+	defer func() { _ = file.Close() }()
+	content := base64.StdEncoding.EncodeToString(salt) + " " + base64.StdEncoding.EncodeToString(check) + "\n"
+	if _, err := file.WriteString(content); err != nil {
+		return nil, nil, fmt.Errorf("failed to write the key file: %w", err)
+	}
+	l.keepOwnership(path)
+	return salt, check, nil
+}
+
+// deriveKey returns the AES-256-GCM cipher of the key derived from the passphrase and the salt.
+func deriveKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	id := sha256.Sum256(append(append([]byte{}, salt...), passphrase...))
+	if aead, ok := derivedKeys.Load(id); ok {
+		return aead.(cipher.AEAD), nil
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, keyIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the cipher: %w", err)
+	}
+	derivedKeys.Store(id, aead)
+	return aead, nil
+}
+
+// seal encrypts the data, authenticating the additional data with it: the nonce leads the result.
+func seal(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate a nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, additional), nil
+}
+
+// openSealed decrypts data sealed by seal.
+func openSealed(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+// splitSealable splits the log line into its readable head (`+M <time>|<ref>|`) and the part encrypted
+// in encrypted logs (`{meta}|command`). ok is false for lines that aren't entries.
+func splitSealable(line string) (string, string, bool) {
+	first := strings.IndexByte(line, '|')
+	if first < 0 {
+		return "", "", false
+	}
+	second := strings.IndexByte(line[first+1:], '|')
+	if second < 0 {
+		return "", "", false
+	}
+	end := first + 1 + second + 1
+	return line[:end], line[end:], true
+}
+
+// sealLine encrypts the line when the logger encrypts (see SetPassphrase). The head is authenticated with it,
+// except for the +/- sign: it's toggled in place.
+func (l *Logger) sealLine(line string) (string, error) {
+	head, rest, ok := splitSealable(line)
+	if l.aead == nil || !l.encrypt || !ok || strings.HasPrefix(rest, sealedPrefix) {
+		return line, nil
+	}
+	sealed, err := seal(l.aead, []byte(rest), []byte(head[1:]))
+	if err != nil {
+		return "", err
+	}
+	return head + sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openLine decrypts the line when it's encrypted and the logger has the key.
+// Lines it can't decrypt are returned as they are: ParseLogLine fails on them (see ErrEncrypted).
+func (l *Logger) openLine(line string) string {
+	head, rest, ok := splitSealable(line)
+	if l.aead == nil || !ok {
+		return line
+	}
+	encoded, sealed := strings.CutPrefix(rest, sealedPrefix)
+	if !sealed {
+		return line
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return line
+	}
+	opened, err := openSealed(l.aead, data, []byte(head[1:]))
+	if err != nil {
+		return line
+	}
+	return head + string(opened)
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dedupJournalFileName keeps the commands recently logged by each hook (see dedupJournal):
+// shell and git hooks both report most commands, only the first report is logged.
+const dedupJournalFileName = "dedup"
+
+// dedupWindow is how long a command logged by one hook is recognized when the other hook reports it.
+const dedupWindow = 10 * time.Second
+
+// Origins of logged commands in the dedup journal.
+const (
+	dedupOriginShell = "shell"
+	dedupOriginGit   = "git"
+)
+
+// dedupRecord is a command logged by a hook: `<unix time> <origin> <command identifier>` in the journal.
+type dedupRecord struct {
+	at     time.Time
+	origin string
+	id     string
+}
+
+// isDuplicate reports whether the command (given by its identifier) was already logged by the other hook
+// within dedupWindow. If not, it's recorded as logged by the given hook.
+// The journal only keeps the records of the window, and is read and written under the lock of the log.
+func (l *Logger) isDuplicate(origin, cmdIdentifier string) bool {
+	var duplicate bool
+	_ = l.withLock(func() error {
+		records := l.readDedupJournal(time.Now().Add(-dedupWindow))
+		for _, record := range records {
+			if record.id == cmdIdentifier && record.origin != origin {
+				duplicate = true
+				return nil
+			}
+		}
+
+		records = append(records, dedupRecord{at: time.Now(), origin: origin, id: cmdIdentifier})
+		return l.writeDedupJournal(records)
+	})
+	return duplicate
+}
+
+// readDedupJournal returns the records of the journal made after since.
+// The first time, the flag files older versions used instead of the journal are removed.
+func (l *Logger) readDedupJournal(since time.Time) []dedupRecord {
+	data, err := os.ReadFile(filepath.Join(l.logDir, dedupJournalFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		l.removeLegacyFlagFiles()
+	}
+	if err != nil {
+		return nil
+	}
+
+	var records []dedupRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if at := time.Unix(seconds, 0); !at.Before(since) {
+			records = append(records, dedupRecord{at: at, origin: fields[1], id: fields[2]})
+		}
+	}
+	return records
+}
+
+// writeDedupJournal replaces the records of the journal.
+func (l *Logger) writeDedupJournal(records []dedupRecord) error {
+	var sb strings.Builder
+	for _, record := range records {
+		fmt.Fprintf(&sb, "%d %s %s\n", record.at.Unix(), record.origin, record.id)
+	}
+
+	path := filepath.Join(l.logDir, dedupJournalFileName)
+	if err := os.WriteFile(path, []byte(sb.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write dedup journal: %w", permissionError(path, err))
+	}
+	l.keepOwnership(path)
+	return nil
+}
+
+// removeLegacyFlagFiles removes the `.shell-hook-<hash>` and `.git-hook-<hash>` flag files
+// older versions marked logged commands with.
+func (l *Logger) removeLegacyFlagFiles() {
+	entries, err := os.ReadDir(l.logDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".shell-hook-") || strings.HasPrefix(entry.Name(), ".git-hook-") {
+			_ = os.Remove(filepath.Join(l.logDir, entry.Name()))
+		}
+	}
+}
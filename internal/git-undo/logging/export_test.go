@@ -1,3 +1,7 @@
 package logging
 
 var ToggleLogLine = toggleLine
+
+var DetectBackground = detectBackground
+
+var PermissionError = permissionError
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// preStateMaxAge is how old the pre-state gets before it's considered stale: the command it was captured for
+// failed (so was never logged), or the hook logging it was interrupted.
+const preStateMaxAge = 24 * time.Hour
+
+// Compact rewrites the log without the entries truncation would remove (undone mutation entries that are not
+// pinned, see TruncateToCurrentBranch) logged longer than the retention period ago, whatever their ref
+// (retention <= 0 keeps them forever). Blank lines are dropped as well.
+// It returns the entries removed (whatever their keeps, e.g. kept commits, become useless) and how many bytes
+// the log shrank by.
+func (l *Logger) Compact(retention time.Duration) ([]*Entry, int64, error) {
+	if l.err != nil {
+		return nil, 0, fmt.Errorf("logger is not healthy: %w", l.err)
+	}
+	if l.readOnly {
+		return nil, 0, errors.New("logger is read-only")
+	}
+
+	// Entry timestamps are local wall-clock times parsed as UTC: the cutoff is converted the same way
+	cutoff, _ := time.Parse(logEntryDateFormat, time.Now().Add(-retention).Format(logEntryDateFormat))
+	var removed []*Entry
+	var reclaimed int64
+	err := l.withLock(func() error {
+		sizeBefore := fileSize(l.logFile)
+		var lines []string
+		err := l.ProcessLogFile(func(line string) bool {
+			entry, err := ParseLogLine(line)
+			if err == nil && retention > 0 && entry.Undoed && !entry.IsNavigation && !entry.IsPinned() &&
+				entry.Timestamp.Before(cutoff) {
+				removed = append(removed, entry)
+				return true
+			}
+			lines = append(lines, line)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if err := l.rewriteLogFile(lines); err != nil {
+			return err
+		}
+		reclaimed = sizeBefore - fileSize(l.logFile)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	l.trace("compacted the log", "removed", len(removed), "reclaimed", reclaimed)
+	return removed, reclaimed, nil
+}
+
+// RemoveStaleFiles removes the files of the log dir nothing needs anymore: a dedup journal whose records are all
+// past the window, the flag files of older versions, a stale pre-state and the temporary files of interrupted
+// log rewrites. It returns how many files were removed and their total size.
+func (l *Logger) RemoveStaleFiles() (int, int64, error) {
+	if l.readOnly {
+		return 0, 0, errors.New("logger is read-only")
+	}
+
+	removed := 0
+	var reclaimed int64
+	err := l.withLock(func() error {
+		dirEntries, err := os.ReadDir(l.logDir)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", l.logDir, err)
+		}
+		for _, dirEntry := range dirEntries {
+			info, err := dirEntry.Info()
+			if err != nil || !info.Mode().IsRegular() || !l.isStaleFile(info) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(l.logDir, info.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to remove %s: %w", info.Name(), err)
+			}
+			removed++
+			reclaimed += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	l.trace("removed stale files", "removed", removed, "reclaimed", reclaimed)
+	return removed, reclaimed, nil
+}
+
+// isStaleFile reports whether the file of the log dir is stale (see RemoveStaleFiles).
+// It's called under the lock of the log: no rewrite is in progress.
+func (l *Logger) isStaleFile(info fs.FileInfo) bool {
+	name := info.Name()
+	switch {
+	case name == dedupJournalFileName:
+		return len(l.readDedupJournal(time.Now().Add(-dedupWindow))) == 0
+	case name == preStateFileName:
+		return time.Since(info.ModTime()) > preStateMaxAge
+	case strings.HasPrefix(name, ".shell-hook-"), strings.HasPrefix(name, ".git-hook-"):
+		return true
+	case strings.HasPrefix(name, logFileName+".") && strings.HasSuffix(name, ".tmp"):
+		return true
+	default:
+		return false
+	}
+}
+
+// fileSize returns the size of the file (0 if it can't be read).
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"os"
+	"slices"
+	"time"
+)
+
+// MetaGroup is the ID of the undo group of the entry: entries of a group are undone (and redone) together.
+// It's the ID of the first entry of the group (see Entry.GroupID), or the value of EnvGroup.
+const MetaGroup = "group"
+
+// EnvGroup names the environment variable grouping the commands logged while it's set
+// (e.g. exported by a script running several git commands), whatever their timing.
+const EnvGroup = "GIT_UNDO_GROUP"
+
+// SetGroupWindow makes mutation commands logged within the window after the previous one (on the same ref)
+// join its undo group (0 means no grouping by time).
+func (l *Logger) SetGroupWindow(window time.Duration) {
+	l.groupWindow = window
+}
+
+// GroupID returns the ID of the undo group of the entry. An entry that joined no group
+// is a group of its own: its ID is the entry ID, which later entries of the group refer to.
+func (e *Entry) GroupID() string {
+	if group := e.Metadata[MetaGroup]; group != "" {
+		return group
+	}
+	return e.ID()
+}
+
+// inGroup reports whether the entry belongs to the undo group.
+func (e *Entry) inGroup(group string) bool {
+	return e.Metadata[MetaGroup] == group || e.ID() == group
+}
+
+// groupOf returns the undo group a new mutation entry on the ref joins: the one of EnvGroup,
+// or the one of the previous mutation entry if it's recent enough (see SetGroupWindow). Empty for none.
+func (l *Logger) groupOf(ref Ref, now time.Time) string {
+	if group := os.Getenv(EnvGroup); group != "" {
+		return group
+	}
+	if l.groupWindow <= 0 {
+		return ""
+	}
+
+	// Entry timestamps are local wall-clock times parsed as UTC: the window start is converted the same way
+	since, _ := time.Parse(logEntryDateFormat, now.Add(-l.groupWindow).Format(logEntryDateFormat))
+	previous, err := l.GetEntries(1, func(e *Entry) bool { return !e.IsNavigation })
+	if err != nil || len(previous) == 0 {
+		return ""
+	}
+	last := previous[0]
+	if last.Undoed || last.Ref != ref || last.Timestamp.Before(since) {
+		return ""
+	}
+	return last.GroupID()
+}
+
+// GetLastRegularGroup returns the entries of the undo group of the last regular entry (see GetLastRegularEntry)
+// logged in a row down from it and not undone yet, newest first. It's just that entry when it belongs to no group.
+// The run stops at the first other mutation entry: a run of the group logged before it isn't undone with it,
+// since the undo of each entry only fits the state right after it.
+func (l *Logger) GetLastRegularGroup(refArg ...Ref) ([]*Entry, error) {
+	last, err := l.GetLastRegularEntry(refArg...)
+	if err != nil || last == nil {
+		return nil, err
+	}
+	ref := l.resolveRef(refArg...)
+	group := last.GroupID()
+
+	var entries []*Entry
+	reached := false
+	err = l.ProcessLogFile(func(line string) bool {
+		entry, err := ParseLogLine(line)
+		if err != nil || !l.mutationOn(entry, ref) {
+			return true
+		}
+		reached = reached || entry.GetIdentifier() == last.GetIdentifier()
+		if !reached {
+			return true
+		}
+		if entry.Undoed || !entry.inGroup(group) {
+			return false
+		}
+		entries = append(entries, entry)
+		return true
+	})
+	return entries, err
+}
+
+// GetLastUndoedGroup returns the undone entries of the undo group of the last undone entry
+// (see GetLastUndoedEntry) logged in a row up from it, oldest first: the order to redo them in.
+func (l *Logger) GetLastUndoedGroup(refArg ...Ref) ([]*Entry, error) {
+	last, err := l.GetLastUndoedEntry(refArg...)
+	if err != nil || last == nil {
+		return nil, err
+	}
+	ref := l.resolveRef(refArg...)
+	group := last.GroupID()
+
+	// The log is newest first: the run ends with the last undone entry
+	var entries []*Entry
+	err = l.ProcessLogFile(func(line string) bool {
+		entry, err := ParseLogLine(line)
+		if err != nil || !l.mutationOn(entry, ref) {
+			return true
+		}
+		if !entry.Undoed || !entry.inGroup(group) {
+			entries = nil
+			return true
+		}
+		entries = append(entries, entry)
+		return entry.GetIdentifier() != last.GetIdentifier()
+	})
+	slices.Reverse(entries)
+	return entries, err
+}
+
+// mutationOn reports whether the entry is a mutation entry of the undo history on the ref.
+func (l *Logger) mutationOn(entry *Entry, ref Ref) bool {
+	return !entry.IsNavigation && !entry.Inert() && l.inSession(entry) && l.matchRef(entry.Ref, ref)
+}
@@ -3,10 +3,12 @@ package logging
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"syscall"
 )
 
 // EnsureLogDir ensures the git-undo log directory exists.
@@ -18,6 +20,21 @@ func EnsureLogDir(logDir string) error {
 	return nil
 }
 
+// syncDir flushes the directory entries (e.g. a rename into it) to disk.
+// Platforms that can't sync directories (e.g. Windows) are ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil //nolint:nilerr // directories can't be opened for syncing everywhere
+	}
+	defer func() { _ = d.Close() }()
+
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) && !errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("failed to sync %s: %w", dir, err)
+	}
+	return nil
+}
+
 // ToggleLine toggles commenting/uncommenting of a line in the file using # prefix.
 func ToggleLine(file *os.File, lineNumber int) error {
 	// Reset to start of file
@@ -79,64 +96,16 @@ func ToggleLine(file *os.File, lineNumber int) error {
 	return err
 }
 
-// toggleLine toggles the line number in the log file.
-func toggleLine(file *os.File, lineNumber int) error {
-	// Reset to start of file
-	_, err := file.Seek(0, io.SeekStart)
-	if err != nil {
-		return err
-	}
-
-	reader := bufio.NewReader(file)
-	var buffer bytes.Buffer
-	currentLine := 0
-
-	for {
-		// Read the line including the newline character
-		line, err := reader.ReadString('\n')
-		isEOF := err == io.EOF
-
-		if err != nil && !isEOF {
-			return err
-		}
-
-		if currentLine == lineNumber {
-			// Toggle the line between +/- prefix
-			switch {
-			case strings.HasPrefix(line, "+"):
-				line = "-" + strings.TrimPrefix(line, "+")
-			case strings.HasPrefix(line, "-"):
-				line = "+" + strings.TrimPrefix(line, "-")
-			default:
-				return fmt.Errorf("invalid line syntax. Line must start with +/-, started with `%s`", string(line[0]))
-			}
-		}
-
-		// Write the line to buffer
-		buffer.WriteString(line)
-
-		if isEOF {
-			break
-		}
-
-		currentLine++
-	}
-
-	if currentLine < lineNumber {
-		return fmt.Errorf("line %d not found: file has only %d lines", lineNumber, currentLine)
-	}
-
-	// Write back to file
-	_, err = file.Seek(0, io.SeekStart)
-	if err != nil {
-		return err
-	}
-
-	err = file.Truncate(0)
-	if err != nil {
-		return err
+// toggleLine toggles the log line between done (+) and undone (-) states.
+func toggleLine(line string) (string, error) {
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return "-" + strings.TrimPrefix(line, "+"), nil
+	case strings.HasPrefix(line, "-"):
+		return "+" + strings.TrimPrefix(line, "-"), nil
+	case line == "":
+		return "", errors.New("invalid line syntax: empty line")
+	default:
+		return "", fmt.Errorf("invalid line syntax. Line must start with +/-, started with `%s`", string(line[0]))
 	}
-
-	_, err = buffer.WriteTo(file)
-	return err
 }
@@ -0,0 +1,178 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// logHeader is the first line of append-only log files: entries follow oldest first.
+// Log files without it are in the legacy format, newest entry first (see migrateToAppendOnly).
+const logHeader = "# git-undo log v2: append-only, oldest entry first"
+
+// indexChunkSize is how much of the log file is read at once while indexing it backward.
+const indexChunkSize = 64 * 1024
+
+// logIndex is the in-memory index of the log file: the offsets where its lines start, newest first.
+// It's built backward lazily, so the latest entries are found reading just the end of the file,
+// and kept as long as the file is neither appended to nor replaced.
+type logIndex struct {
+	// info identifies the indexed file (and its size).
+	info os.FileInfo
+	// offsets are where the lines found so far start, newest first.
+	offsets []int64
+	// scanned is where the backward scan is at: the lines starting past it are all in offsets.
+	scanned int64
+}
+
+// newLogIndex returns an empty index of the file described by info.
+func newLogIndex(info os.FileInfo) *logIndex {
+	return &logIndex{info: info, scanned: info.Size()}
+}
+
+// isFor reports whether the index is still valid for the file described by info:
+// it's the same file (not replaced by a rewrite) and nothing was appended since.
+func (x *logIndex) isFor(info os.FileInfo) bool {
+	return os.SameFile(x.info, info) && x.info.Size() == info.Size()
+}
+
+// lineAt returns where the i-th line from the end (0 is the newest) starts and ends,
+// scanning the file backward as far as needed. The last result is false when the file has fewer lines.
+func (x *logIndex) lineAt(file io.ReaderAt, i int) (int64, int64, bool, error) {
+	for i >= len(x.offsets) && x.scanned > 0 {
+		if err := x.scanBack(file); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if i >= len(x.offsets) {
+		return 0, 0, false, nil
+	}
+
+	end := x.info.Size()
+	if i > 0 {
+		end = x.offsets[i-1]
+	}
+	return x.offsets[i], end, true, nil
+}
+
+// scanBack indexes the lines starting in the chunk of the file right before the scanned part.
+func (x *logIndex) scanBack(file io.ReaderAt) error {
+	lo := max(0, x.scanned-indexChunkSize)
+	chunk := make([]byte, x.scanned-lo)
+	if _, err := file.ReadAt(chunk, lo); err != nil && err != io.EOF {
+		return fmt.Errorf("error reading log file: %w", err)
+	}
+
+	size := x.info.Size()
+	for end := len(chunk); end > 0; {
+		p := bytes.LastIndexByte(chunk[:end], '\n')
+		if p < 0 {
+			break
+		}
+		if start := lo + int64(p) + 1; start < size {
+			x.offsets = append(x.offsets, start)
+		}
+		end = p
+	}
+	if lo == 0 {
+		x.offsets = append(x.offsets, 0)
+	}
+	x.scanned = lo
+	return nil
+}
+
+// processLines calls the processor with every non-empty line of the log file and where it starts,
+// newest first, until the processor returns false. Encrypted lines come decrypted when the logger has the key
+// (see SetPassphrase).
+func (l *Logger) processLines(processor func(offset int64, line string) bool) error {
+	if l.err != nil {
+		return fmt.Errorf("logger is not healthy: %w", l.err)
+	}
+
+	file, err := l.getFile()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading log file: %w", err)
+	}
+	if l.index == nil || !l.index.isFor(info) {
+		l.index = newLogIndex(info)
+	}
+
+	// Legacy files are newest first already: only read-only loggers read them (the others migrate them)
+	appendOnly, err := isAppendOnly(file, info)
+	if err != nil {
+		return err
+	}
+	if !appendOnly {
+		return processLegacyLines(file, func(offset int64, line string) bool {
+			return processor(offset, l.openLine(line))
+		})
+	}
+
+	for i := 0; ; i++ {
+		start, end, ok, err := l.index.lineAt(file, i)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		buf := make([]byte, end-start)
+		if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+			return fmt.Errorf("error reading log file: %w", err)
+		}
+		line := strings.TrimSpace(string(buf))
+		if line == "" || line == logHeader {
+			continue
+		}
+
+		offset := start + int64(len(buf)-len(bytes.TrimLeft(buf, " \t\r\n")))
+		if !processor(offset, l.openLine(line)) {
+			return nil
+		}
+	}
+}
+
+// isAppendOnly reports whether the log file is in the append-only format (empty files are as well).
+func isAppendOnly(file io.ReaderAt, info os.FileInfo) (bool, error) {
+	if info.Size() == 0 {
+		return true, nil
+	}
+	head := make([]byte, min(info.Size(), int64(len(logHeader))))
+	if _, err := file.ReadAt(head, 0); err != nil && err != io.EOF {
+		return false, fmt.Errorf("error reading log file: %w", err)
+	}
+	return string(head) == logHeader, nil
+}
+
+// processLegacyLines calls the processor with every non-empty line of the legacy (newest first) log file
+// and where it starts, until the processor returns false.
+func processLegacyLines(file io.Reader, processor func(offset int64, line string) bool) error {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		raw, err := reader.ReadString('\n')
+		if line := strings.TrimSpace(raw); line != "" {
+			if !processor(offset+int64(len(raw)-len(strings.TrimLeft(raw, " \t\r\n"))), line) {
+				return nil
+			}
+		}
+		offset += int64(len(raw))
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading log file: %w", err)
+		}
+	}
+}
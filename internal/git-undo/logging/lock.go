@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// lockFileName is the file locked while the log is written, so concurrent writers
+// (shell and git hooks, several terminals) serialize instead of losing each other's changes.
+const lockFileName = "commands.lock"
+
+// lockTimeout is how long a writer waits for the others before giving up.
+const lockTimeout = 10 * time.Second
+
+// lockRetryInterval is how often a busy lock is tried again.
+const lockRetryInterval = 10 * time.Millisecond
+
+// ErrLogLocked is returned when the log stays locked by another process for longer than lockTimeout.
+var ErrLogLocked = errors.New("the git-undo log is locked by another process")
+
+// withLock runs fn holding the lock of the log: every write, including the reads it's based on
+// (e.g. toggling an entry), happens under it. Nested calls run fn under the lock already held.
+func (l *Logger) withLock(fn func() error) error {
+	if l.lockDepth > 0 {
+		return fn()
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	l.lockDepth++
+	defer func() { l.lockDepth-- }()
+	return fn()
+}
+
+// lock takes the lock of the log, waiting up to lockTimeout for other writers to release it.
+func (l *Logger) lock() (func(), error) {
+	path := filepath.Join(l.logDir, lockFileName)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		unlock, locked, err := tryLock(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock the log: %w", permissionError(path, err))
+		}
+		if locked {
+			l.keepOwnership(path)
+			return unlock, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w (waited %s for %s)", ErrLogLocked, lockTimeout, path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
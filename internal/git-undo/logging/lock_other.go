@@ -0,0 +1,29 @@
+//go:build !unix
+
+package logging
+
+import (
+	"os"
+	"time"
+)
+
+// staleLockAge is how old a lock file must be to be taken over: its owner is assumed dead.
+const staleLockAge = time.Minute
+
+// tryLock takes the lock by creating the lock file at the path (it exists as long as it's held).
+// The lock is released by the returned function; lock files left by dead processes expire after staleLockAge.
+func tryLock(path string) (func(), bool, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(path)
+		}
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	_ = file.Close()
+
+	return func() { _ = os.Remove(path) }, true, nil
+}
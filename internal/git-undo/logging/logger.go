@@ -2,12 +2,18 @@ package logging
 
 import (
 	"bufio"
+	"crypto/cipher"
 	"crypto/sha1" //nolint:gosec // We're fine with this
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,6 +31,35 @@ type Logger struct {
 
 	// git is a GitHelper (calling getting current ref, etc)
 	git GitHelper
+
+	// readOnly loggers never create or modify anything under the git dir.
+	readOnly bool
+
+	// index is the in-memory index of the log file (built as it's read).
+	index *logIndex
+	// lockDepth counts the nested withLock calls of the lock held (see withLock).
+	lockDepth int
+
+	// maxEntries caps how many entries the log keeps (see SetMaxEntries).
+	maxEntries int
+	// maxPerMinute caps logged commands per subcommand and minute (see SetMaxPerMinute).
+	maxPerMinute int
+	// groupWindow is the window grouping consecutive mutation commands (see SetGroupWindow).
+	groupWindow time.Duration
+	// session scopes the getters to the entries of a shell session (see SetSession).
+	session string
+
+	// lastLogged is the entry the last LogCommand* call added (see LastLogged).
+	lastLogged *Entry
+
+	// debug traces what the logger decides (e.g. deduplication) for troubleshooting (see SetDebugLogger).
+	debug *slog.Logger
+
+	// aead decrypts (and when encrypt is set, encrypts) the entries of encrypted logs (see SetPassphrase).
+	aead    cipher.AEAD
+	encrypt bool
+	// redactions are the patterns redacted from logged commands and metadata (see SetRedactions).
+	redactions Redactor
 }
 
 type GitHelper interface {
@@ -35,6 +70,9 @@ const (
 	logEntryDateFormat = time.DateTime
 	logFileDirName     = "git-undo"
 	logFileName        = "commands"
+
+	// entryIDLength is the length of the short entry ID (same as git's default short hash).
+	entryIDLength = 7
 )
 
 // EntryType specifies whether to look for regular or undoed entries.
@@ -88,13 +126,233 @@ type Entry struct {
 
 	// IsNavigation is true if this is a navigation command (checkout/switch).
 	IsNavigation bool
+
+	// Failed is true if the command failed (logged only when enabled, see LogFailedCommand).
+	// Failed entries are history to review, never to undo: the getters below skip them.
+	Failed bool
+
+	// Summary is true if the entry stands for the commands suppressed by the per-minute cap (see SetMaxPerMinute),
+	// counted in its metadata. Like failed entries, summaries are never undone.
+	Summary bool
+
+	// Metadata holds optional annotations of the entry (see Meta* keys).
+	// It's not a part of the identifier, so annotating an entry keeps its ID.
+	Metadata map[string]string
+}
+
+// Keys of entry metadata.
+const (
+	// MetaPinned marks entries that must never be removed by truncation/pruning.
+	MetaPinned = "pinned"
+
+	// MetaUpstreamBranch, MetaUpstreamRemote and MetaUpstreamMerge hold the upstream tracking configuration
+	// of the branch deleted by the undo of the entry, so redo can restore it.
+	MetaUpstreamBranch = "upstream.branch"
+	MetaUpstreamRemote = "upstream.remote"
+	MetaUpstreamMerge  = "upstream.merge"
+
+	// MetaHead and MetaIndex hold the state of the repository right after the command:
+	// the HEAD commit and the tree of the index (see `git write-tree`).
+	MetaHead  = "head"
+	MetaIndex = "index"
+
+	// MetaIndexBefore is the tree of the index right before the command (captured via SavePreState),
+	// so `git add` can be undone by restoring exactly the index entries it changed.
+	MetaIndexBefore = "index.before"
+
+	// MetaAmended is the commit replaced by `git commit --amend` (HEAD@{1} right after the command).
+	MetaAmended = "amended"
+
+	// MetaStash is the commit of the stash created, or popped, applied or dropped, by the command
+	// (see `git stash list --format=%H`).
+	MetaStash = "stash"
+
+	// MetaAuthor is the identity of the user who ran the command (`Name <email>` from git config).
+	MetaAuthor = "author"
+
+	// MetaConfigKey and MetaConfigValues hold the config key changed by the command and its values
+	// right before it (captured via SavePreState). Each value is terminated by a newline,
+	// and MetaConfigValues is absent when the key wasn't set.
+	MetaConfigKey    = "config.key"
+	MetaConfigValues = "config.values"
+
+	// MetaRemoteConfig holds the config of the remote changed by the command right before it
+	// (captured via SavePreState), as printed by `git config -z --get-regexp`.
+	MetaRemoteConfig = "remote.config"
+
+	// MetaBranchTips holds the tips of the branches deleted by the command right before it
+	// (captured via SavePreState): a `<commit> <branch>` line per branch.
+	MetaBranchTips = "branch.tips"
+
+	// MetaTagObjects holds the objects (commits or annotated tag objects) the tags deleted or force-moved
+	// by the command pointed to right before it (captured via SavePreState): a `<object> <tag>` line per tag.
+	MetaTagObjects = "tag.objects"
+
+	// MetaSnapshot is the ID of the snapshot of the files the command lost, taken right before it
+	// (see the snapshot package).
+	MetaSnapshot = "snapshot"
+
+	// MetaDir is the directory the command ran in (given by -C), relative to the top of the work tree:
+	// the paths of its args are relative to it.
+	MetaDir = "dir"
+
+	// MetaTyped is the command as the user typed it, when it's logged otherwise: a git alias is logged
+	// expanded (so it's undone as what it ran), and displayed as typed.
+	MetaTyped = "typed"
+)
+
+// fixupKinds are metadata keys holding the target commit of fixup/squash commit entries.
+var fixupKinds = []string{
+	githelpers.FixupKindFixup,
+	githelpers.FixupKindSquash,
+	githelpers.FixupKindAmend,
+	githelpers.FixupKindReword,
+}
+
+// FixupTarget returns the kind and the target commit of a fixup/squash commit entry.
+// The target is recorded into metadata (under the kind key) when the entry is logged.
+func (e *Entry) FixupTarget() (string, string, bool) {
+	for _, kind := range fixupKinds {
+		if target := e.Metadata[kind]; target != "" {
+			return kind, target, true
+		}
+	}
+	return "", "", false
+}
+
+// Upstream returns the recorded upstream tracking configuration (branch, remote and merge ref) of the entry.
+func (e *Entry) Upstream() (string, string, string, bool) {
+	branch := e.Metadata[MetaUpstreamBranch]
+	remote, merge := e.Metadata[MetaUpstreamRemote], e.Metadata[MetaUpstreamMerge]
+	if branch == "" || remote == "" || merge == "" {
+		return "", "", "", false
+	}
+	return branch, remote, merge, true
+}
+
+// ConfigBefore returns the recorded config key changed by the command, its values before the command
+// and whether it was set at all.
+func (e *Entry) ConfigBefore() (string, []string, bool) {
+	encoded, set := e.Metadata[MetaConfigValues]
+	if !set {
+		return e.Metadata[MetaConfigKey], nil, false
+	}
+	return e.Metadata[MetaConfigKey], strings.Split(strings.TrimSuffix(encoded, "\n"), "\n"), true
+}
+
+// ConfigBeforeMeta returns the metadata recording the values of the config key before a command
+// (see Entry.ConfigBefore).
+func ConfigBeforeMeta(key string, values []string, set bool) map[string]string {
+	meta := map[string]string{MetaConfigKey: key}
+	if set {
+		meta[MetaConfigValues] = strings.Join(values, "\n") + "\n"
+	}
+	return meta
+}
+
+// BranchTips returns the recorded tips of the branches deleted by the command, by branch name.
+func (e *Entry) BranchTips() map[string]string {
+	return e.namedObjects(MetaBranchTips)
+}
+
+// BranchTipsMeta returns the metadata recording the tips of the branches deleted by a command
+// (see Entry.BranchTips).
+func BranchTipsMeta(tips map[string]string) map[string]string {
+	return namedObjectsMeta(MetaBranchTips, tips)
+}
+
+// TagObjects returns the recorded objects of the tags deleted or moved by the command, by tag name.
+func (e *Entry) TagObjects() map[string]string {
+	return e.namedObjects(MetaTagObjects)
+}
+
+// TagObjectsMeta returns the metadata recording the objects of the tags deleted or moved by a command
+// (see Entry.TagObjects).
+func TagObjectsMeta(objects map[string]string) map[string]string {
+	return namedObjectsMeta(MetaTagObjects, objects)
+}
+
+// namedObjects decodes the `<object> <name>` lines of the metadata key into objects by name.
+func (e *Entry) namedObjects(key string) map[string]string {
+	objects := make(map[string]string)
+	for line := range strings.SplitSeq(e.Metadata[key], "\n") {
+		if object, name, ok := strings.Cut(line, " "); ok {
+			objects[name] = object
+		}
+	}
+	return objects
+}
+
+// namedObjectsMeta encodes the objects by name as `<object> <name>` lines of the metadata key.
+func namedObjectsMeta(key string, objects map[string]string) map[string]string {
+	lines := make([]string, 0, len(objects))
+	for _, name := range slices.Sorted(maps.Keys(objects)) {
+		lines = append(lines, objects[name]+" "+name)
+	}
+	return map[string]string{key: strings.Join(lines, "\n")}
+}
+
+// State returns the HEAD commit and the index tree recorded right after the command (empty if not recorded).
+func (e *Entry) State() (string, string) {
+	return e.Metadata[MetaHead], e.Metadata[MetaIndex]
+}
+
+// TypedCommand returns the command as the user typed it (see MetaTyped),
+// or what a summary entry stands for (e.g. "suppressed 240 fetch operations").
+func (e *Entry) TypedCommand() string {
+	if e.Summary {
+		return e.summaryText()
+	}
+	if typed := e.Metadata[MetaTyped]; typed != "" {
+		return typed
+	}
+	return e.Command
+}
+
+// RedactedParts returns what the redact patterns replaced in the entry (see MetaRedacted).
+func (e *Entry) RedactedParts() []string {
+	if listed := e.Metadata[MetaRedacted]; listed != "" {
+		return strings.Split(listed, ",")
+	}
+	return nil
+}
+
+// Inert tells the entry has nothing to undo nor redo: the command failed, or it's a summary.
+func (e *Entry) Inert() bool {
+	return e.Failed || e.Summary
+}
+
+// IsPinned returns true if the entry is pinned.
+func (e *Entry) IsPinned() bool {
+	return e.Metadata[MetaPinned] != ""
+}
+
+// SetMeta sets the metadata value of the entry (an empty value removes the key).
+func (e *Entry) SetMeta(key, value string) {
+	if value == "" {
+		delete(e.Metadata, key)
+		return
+	}
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata[key] = value
+}
+
+// ID returns a short stable identifier of the entry (derived from GetIdentifier).
+// It's meant to be shown to users and typed back (e.g. `git undo --id <ID>`).
+func (e *Entry) ID() string {
+	hash := sha1.Sum([]byte(e.GetIdentifier())) //nolint:gosec // We're fine with this
+	return hex.EncodeToString(hash[:])[:entryIDLength]
 }
 
 // GetIdentifier uses String() representation as the identifier itself
 // But without prefix sign (so undoed command are still found).
 func (e *Entry) GetIdentifier() string {
+	withoutMeta := *e
+	withoutMeta.Metadata = nil
 	return strings.TrimLeft(
-		e.String(), "+-",
+		withoutMeta.String(), "+-",
 	)
 }
 
@@ -108,7 +366,12 @@ func (e *Entry) String() string {
 func (e *Entry) MarshalText() ([]byte, error) {
 	// Determine prefix based on navigation type and undo status
 	prefixLetter := "M" // M for `modified` as the regular entry type
-	if e.IsNavigation {
+	switch {
+	case e.Failed:
+		prefixLetter = "F"
+	case e.Summary:
+		prefixLetter = "S"
+	case e.IsNavigation:
 		prefixLetter = "N"
 	}
 	prefixSign := "+"
@@ -117,7 +380,17 @@ func (e *Entry) MarshalText() ([]byte, error) {
 	}
 	prefix := prefixSign + prefixLetter + " "
 
-	entryString := fmt.Sprintf("%s%s|%s|%s", prefix, e.Timestamp.Format(logEntryDateFormat), e.Ref, e.Command)
+	// Metadata (if any) goes as an optional `{k=v&...}|` field right before the command
+	var meta string
+	if len(e.Metadata) > 0 {
+		values := url.Values{}
+		for key, value := range e.Metadata {
+			values.Set(key, value)
+		}
+		meta = "{" + values.Encode() + "}|"
+	}
+
+	entryString := fmt.Sprintf("%s%s|%s|%s%s", prefix, e.Timestamp.Format(logEntryDateFormat), e.Ref, meta, e.Command)
 	return []byte(entryString), nil
 }
 
@@ -134,16 +407,20 @@ func (e *Entry) UnmarshalText(data []byte) error {
 	}
 
 	entryString = strings.TrimLeft(entryString, "+-")
+	e.IsNavigation, e.Failed, e.Summary = false, false, false
 	switch {
 	case strings.HasPrefix(entryString, "M"):
-		e.IsNavigation = false
 	case strings.HasPrefix(entryString, "N"):
 		e.IsNavigation = true
+	case strings.HasPrefix(entryString, "F"):
+		e.Failed = true
+	case strings.HasPrefix(entryString, "S"):
+		e.Summary = true
 	default:
-		return fmt.Errorf("invalid syntax line: entry must have M/N prefix, not [%s]", string(entryString[0]))
+		return fmt.Errorf("invalid syntax line: entry must have M/N/F/S prefix, not [%s]", string(entryString[0]))
 	}
 
-	entryString = strings.TrimLeft(entryString, "MN")
+	entryString = strings.TrimLeft(entryString, "MNFS")
 	entryString = strings.TrimSpace(entryString)
 
 	// nMustParts = 3 for date, ref, cmd
@@ -161,6 +438,23 @@ func (e *Entry) UnmarshalText(data []byte) error {
 
 	e.Ref = Ref(parts[1])
 	e.Command = parts[2]
+	if strings.HasPrefix(parts[2], sealedPrefix) {
+		return ErrEncrypted
+	}
+
+	e.Metadata = nil
+	if rest, ok := strings.CutPrefix(parts[2], "{"); ok {
+		if encoded, command, ok := strings.Cut(rest, "}|"); ok {
+			values, err := url.ParseQuery(encoded)
+			if err != nil {
+				return fmt.Errorf("failed to parse metadata: %w", err)
+			}
+			for key := range values {
+				e.SetMeta(key, values.Get(key))
+			}
+			e.Command = command
+		}
+	}
 
 	return nil
 }
@@ -176,15 +470,60 @@ func NewLogger(repoGitDir string, git GitHelper) *Logger {
 	if err := EnsureLogDir(lgr.logDir); err != nil {
 		return nil
 	}
+	lgr.keepOwnership(lgr.logDir)
 
 	// Check if we need to migrate/truncate old format
-	if err := lgr.migrateOldFormatIfNeeded(); err != nil {
+	err := lgr.withLock(func() error {
+		if err := lgr.migrateOldFormatIfNeeded(); err != nil {
+			return err
+		}
+		return lgr.migrateToAppendOnly()
+	})
+	if err != nil {
 		return nil
 	}
 
 	return lgr
 }
 
+// NewReadOnlyLogger creates a Logger that never writes to the git dir:
+// no log directory is created, no migration happens and a missing log file is read as empty.
+// It's meant for fast read-only queries (e.g. shell completion).
+func NewReadOnlyLogger(repoGitDir string, git GitHelper) *Logger {
+	logDir := filepath.Join(repoGitDir, logFileDirName)
+	return &Logger{
+		git:      git,
+		logDir:   logDir,
+		logFile:  filepath.Join(logDir, logFileName),
+		readOnly: true,
+	}
+}
+
+// SetDebugLogger makes the logger trace its decisions (skipped, deduplicated or throttled commands,
+// truncations) to the given logger at debug level.
+func (l *Logger) SetDebugLogger(debug *slog.Logger) {
+	l.debug = debug
+}
+
+// trace writes a debug record of a decision of the logger (nothing without a debug logger).
+func (l *Logger) trace(msg string, args ...any) {
+	if l.debug != nil {
+		l.debug.Debug("log: "+msg, args...)
+	}
+}
+
+// LastLogged returns the entry the last LogCommand (or LogCommandWithMeta) call added to the log:
+// nil when it added none (e.g. the command was already logged by the other hook, or throttled).
+func (l *Logger) LastLogged() *Entry {
+	return l.lastLogged
+}
+
+// SetMaxEntries caps how many entries the log keeps (0 means no cap): logging a command past the cap
+// drops the oldest entries, except for pinned ones.
+func (l *Logger) SetMaxEntries(maxEntries int) {
+	l.maxEntries = maxEntries
+}
+
 // migrateOldFormatIfNeeded checks if the log file has old format entries and truncates it if needed.
 func (l *Logger) migrateOldFormatIfNeeded() error {
 	// Check if the log file exists
@@ -217,9 +556,14 @@ func (l *Logger) migrateOldFormatIfNeeded() error {
 
 		lineCount++
 
-		// Check if this line uses new format (+M, -M, +N, -N)
+		// Append-only log files start with their header
+		if line == logHeader {
+			return nil
+		}
+
+		// Check if this line uses new format (+M, -M, +N, -N, +F)
 		if strings.HasPrefix(line, "+M ") || strings.HasPrefix(line, "-M ") ||
-			strings.HasPrefix(line, "+N ") || strings.HasPrefix(line, "-N ") {
+			strings.HasPrefix(line, "+N ") || strings.HasPrefix(line, "-N ") || strings.HasPrefix(line, "+F ") {
 			// Found new format, no migration needed
 			return nil
 		}
@@ -245,68 +589,142 @@ func (l *Logger) migrateOldFormatIfNeeded() error {
 	return nil
 }
 
+// migrateToAppendOnly rewrites a legacy log file (newest entry first) in the append-only format
+// (see logHeader). Empty log files just get the header.
+func (l *Logger) migrateToAppendOnly() error {
+	file, err := os.Open(l.logFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log file for migration check: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to check log file: %w", err)
+	}
+	if appendOnly, err := isAppendOnly(file, info); err != nil || appendOnly && info.Size() > 0 {
+		return err
+	}
+
+	var lines []string
+	if err := processLegacyLines(file, func(_ int64, line string) bool {
+		lines = append(lines, line)
+		return true
+	}); err != nil {
+		return err
+	}
+	return l.rewriteLogFile(lines)
+}
+
 // LogCommand logs a git command with timestamp and handles branch-aware logging.
 func (l *Logger) LogCommand(strGitCommand string) error {
+	return l.LogCommandWithMeta(strGitCommand, nil)
+}
+
+// LogCommandWithMeta logs a git command (see LogCommand) with the given metadata on its entry.
+func (l *Logger) LogCommandWithMeta(strGitCommand string, meta map[string]string) error {
+	l.lastLogged = nil
 	if l.err != nil {
 		return fmt.Errorf("logger is not healthy: %w", l.err)
 	}
+	strGitCommand, meta = l.redactEntry(strGitCommand, meta)
 
 	// Parse and check if command should be logged
 	gitCmd, err := githelpers.ParseGitCommand(strGitCommand)
 	if err != nil {
 		// If we can't parse it, skip logging to be safe
+		l.trace("skipped unparsable command", "command", strGitCommand, "err", err)
 		return nil //nolint:nilerr // it's intended to be like that
 	}
 	if !ShouldBeLogged(gitCmd) {
+		l.trace("skipped command not to be logged", "command", strGitCommand)
 		return nil
 	}
 
-	// Get current ref (branch/tag/commit)
-	var ref = RefUnknown
-	refStr, err := l.git.GetCurrentGitRef()
-	if err == nil {
-		ref = Ref(refStr)
-	}
+	return l.withLock(func() error {
+		// Get current ref (branch/tag/commit)
+		var ref = RefUnknown
+		refStr, err := l.git.GetCurrentGitRef()
+		if err == nil {
+			ref = Ref(refStr)
+		}
 
-	// Handle branch-aware logging for mutation commands
-	if !l.IsNavigationCommand(strGitCommand) {
-		// Check if we have consecutive undone commands
-		undoneCount, err := l.CountConsecutiveUndoneCommands(ref)
-		if err == nil && undoneCount > 0 {
-			// We're branching - truncate undone mutation commands
-			if err := l.TruncateToCurrentBranch(ref); err != nil {
-				// Log the error but don't fail the operation
-				// TODO: Add verbose logging here
-				_ = err
+		// Handle branch-aware logging for mutation commands
+		if !l.IsNavigationCommand(strGitCommand) {
+			// Check if we have consecutive undone commands
+			undoneCount, err := l.CountConsecutiveUndoneCommands(ref)
+			if err == nil && undoneCount > 0 {
+				// We're branching - truncate undone mutation commands
+				l.trace("truncating undone entries", "ref", ref, "count", undoneCount)
+				if err := l.TruncateToCurrentBranch(ref); err != nil {
+					// Log the error but don't fail the operation
+					l.trace("failed to truncate undone entries", "ref", ref, "err", err)
+				}
 			}
 		}
+
+		return l.logCommandWithDedup(gitCmd, strGitCommand, ref, meta)
+	})
+}
+
+// LogFailedCommand logs a git command that failed, marked as such (see Entry.Failed).
+// Unlike LogCommand, it never affects the undo history: undone entries are kept for redo,
+// and there is nothing to deduplicate or throttle (git hooks don't run for failed commands).
+func (l *Logger) LogFailedCommand(strGitCommand string, meta map[string]string) error {
+	if l.err != nil {
+		return fmt.Errorf("logger is not healthy: %w", l.err)
 	}
+	strGitCommand, meta = l.redactEntry(strGitCommand, meta)
 
-	return l.logCommandWithDedup(strGitCommand, ref)
+	gitCmd, err := githelpers.ParseGitCommand(strGitCommand)
+	if err != nil || !ShouldBeLogged(gitCmd) {
+		return nil //nolint:nilerr // unparsable commands are skipped, as by LogCommand
+	}
+
+	return l.withLock(func() error {
+		ref := RefUnknown
+		if refStr, err := l.git.GetCurrentGitRef(); err == nil {
+			ref = Ref(refStr)
+		}
+
+		entry := &Entry{Timestamp: time.Now(), Ref: ref, Command: strGitCommand, Failed: true}
+		for key, value := range meta {
+			entry.SetMeta(key, value)
+		}
+		entry.SetMeta(MetaSession, currentSession())
+		return l.appendLogEntry(entry.String())
+	})
 }
 
 // logCommandWithDedup logs a command while preventing duplicates between shell and git hooks.
-func (l *Logger) logCommandWithDedup(strGitCommand string, ref Ref) error {
+func (l *Logger) logCommandWithDedup(
+	gitCmd *githelpers.GitCommand,
+	strGitCommand string,
+	ref Ref,
+	meta map[string]string,
+) error {
 	// Create a unique identifier for this command + timestamp (within 2 seconds)
 	// This allows us to detect and prevent duplicates between shell and git hooks
 	normalizedTime := time.Now().Truncate(2 * time.Second)
 	cmdIdentifier := l.createCommandIdentifier(strGitCommand, ref, normalizedTime)
 
 	// Check if we already handled this by other hook.
-	isGitHook := l.isGitHookContext()
-
-	if isGitHook && l.wasRecentlyLoggedByShellHook(cmdIdentifier) {
-		return nil
+	origin := dedupOriginShell
+	if l.isGitHookContext() {
+		origin = dedupOriginGit
 	}
-	if !isGitHook && l.wasRecentlyLoggedByGitHook(cmdIdentifier) {
+	if l.isDuplicate(origin, cmdIdentifier) {
+		l.trace("skipped duplicate", "command", strGitCommand, "origin", origin, "id", cmdIdentifier)
 		return nil
 	}
 
-	// Mark:
-	if isGitHook {
-		l.markLoggedByGitHook(cmdIdentifier)
-	} else {
-		l.markLoggedByShellHook(cmdIdentifier)
+	// High-frequency commands (e.g. from IDEs) collapse or get suppressed instead of flooding the log
+	if l.throttle(gitCmd, strGitCommand, ref) {
+		l.trace("throttled", "command", strGitCommand, "ref", ref)
+		return nil
 	}
 
 	// Create entry with proper navigation flag
@@ -318,8 +736,20 @@ func (l *Logger) logCommandWithDedup(strGitCommand string, ref Ref) error {
 		Undoed:       false,
 		IsNavigation: isNav,
 	}
+	for key, value := range meta {
+		entry.SetMeta(key, value)
+	}
+	entry.SetMeta(MetaSession, currentSession())
+	if !isNav {
+		entry.SetMeta(MetaGroup, l.groupOf(ref, entry.Timestamp))
+	}
 
-	return l.prependLogEntry(entry.String())
+	l.trace("logged", "command", strGitCommand, "ref", ref, "origin", origin, "id", cmdIdentifier)
+	if err := l.appendLogEntry(entry.String()); err != nil {
+		return err
+	}
+	l.lastLogged = entry
+	return nil
 }
 
 // createCommandIdentifier creates a short identifier for a command to detect duplicates.
@@ -377,106 +807,58 @@ func (l *Logger) isGitHookContext() bool {
 	return false
 }
 
-// wasRecentlyLoggedByHook checks if this command was recently logged by the given hook type.
-// hookType should be "shell-hook" or "git-hook".
-func (l *Logger) wasRecentlyLoggedByHook(hookType, cmdIdentifier string) bool {
-	flagFile := filepath.Join(l.logDir, "."+hookType+"-"+cmdIdentifier)
-
-	if stat, err := os.Stat(flagFile); err == nil {
-		if time.Since(stat.ModTime()) < 10*time.Second {
-			return true
-		}
-		_ = os.Remove(flagFile)
-	}
-
-	return false
-}
-
-// markLoggedByHook marks that this command was logged by the given hook type.
-// hookType should be "shell-hook" or "git-hook".
-func (l *Logger) markLoggedByHook(hookType, cmdIdentifier string) {
-	flagFile := filepath.Join(l.logDir, "."+hookType+"-"+cmdIdentifier)
-
-	if file, err := os.Create(flagFile); err == nil {
-		_ = file.Close()
-	}
-
-	go l.cleanupOldFlagFiles()
-}
-
-func (l *Logger) wasRecentlyLoggedByShellHook(cmdIdentifier string) bool {
-	return l.wasRecentlyLoggedByHook("shell-hook", cmdIdentifier)
-}
-
-func (l *Logger) markLoggedByShellHook(cmdIdentifier string) {
-	l.markLoggedByHook("shell-hook", cmdIdentifier)
-}
-
-func (l *Logger) wasRecentlyLoggedByGitHook(cmdIdentifier string) bool {
-	return l.wasRecentlyLoggedByHook("git-hook", cmdIdentifier)
-}
-
-func (l *Logger) markLoggedByGitHook(cmdIdentifier string) {
-	l.markLoggedByHook("git-hook", cmdIdentifier)
-}
-
-// cleanupOldFlagFiles removes flag files older than 30 seconds.
-func (l *Logger) cleanupOldFlagFiles() {
-	entries, err := os.ReadDir(l.logDir)
-	if err != nil {
-		return
-	}
-
-	cutoff := time.Now().Add(-30 * time.Second)
-	for _, entry := range entries {
-		if !strings.HasPrefix(entry.Name(), ".shell-hook-") && !strings.HasPrefix(entry.Name(), ".git-hook-") {
-			continue
-		}
-
-		filePath := filepath.Join(l.logDir, entry.Name())
-		if stat, err := os.Stat(filePath); err == nil && stat.ModTime().Before(cutoff) {
-			_ = os.Remove(filePath)
-		}
-	}
-}
-
 // GetLogPath returns the path to the log file.
 func (l *Logger) GetLogPath() string { return l.logFile }
 
-// ToggleEntry toggles the undo state of an entry by adding or removing the "#" prefix.
-// The entryIdentifier should be in the format "TIMESTAMP|REF|COMMAND" (without the # prefix).
+// ToggleEntry toggles the undo state of an entry by flipping its "+"/"-" prefix.
+// The entryIdentifier should be in the format "TIMESTAMP|REF|COMMAND" (without the prefix).
 func (l *Logger) ToggleEntry(entryIdentifier string) error {
 	if l.err != nil {
 		return fmt.Errorf("logger is not healthy: %w", l.err)
 	}
-
-	var foundLineIdx int
-	err := l.ProcessLogFile(func(line string) bool {
-		// Parse the entry and get its identifier
-		entry, err := ParseLogLine(line)
-		if err != nil {
-			foundLineIdx++
+	if l.readOnly {
+		return errors.New("logger is read-only")
+	}
+
+	return l.withLock(func() error {
+		var toggled string
+		var offset int64
+		var toggleErr error
+		err := l.processLines(func(lineOffset int64, line string) bool {
+			// Failed and summary entries have no undo state
+			entry, err := ParseLogLine(line)
+			if err == nil && !entry.Inert() && entry.GetIdentifier() == entryIdentifier {
+				offset = lineOffset
+				toggled, toggleErr = toggleLine(line)
+				return false
+			}
 			return true
+		})
+		if err != nil {
+			return err
 		}
-
-		if entry.GetIdentifier() == entryIdentifier {
-			return false
+		if toggleErr != nil {
+			return toggleErr
+		}
+		if toggled == "" {
+			return fmt.Errorf("entry not found: %s", entryIdentifier)
 		}
 
-		foundLineIdx++
-		return true
+		// Only the +/- sign changes: it's overwritten in place, so the entry is either toggled or not,
+		// even if the process dies meanwhile
+		file, err := os.OpenFile(l.logFile, os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", permissionError(l.logFile, err))
+		}
+		defer func() { _ = file.Close() }()
+		if _, err := file.WriteAt([]byte(toggled[:1]), offset); err != nil {
+			return fmt.Errorf("failed to toggle log entry: %w", err)
+		}
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync log file: %w", err)
+		}
+		return nil
 	})
-	if err != nil {
-		return err
-	}
-
-	file, err := os.OpenFile(l.logFile, os.O_RDWR, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer file.Close()
-
-	return toggleLine(file, foundLineIdx)
 }
 
 // GetLastRegularEntry returns last regular entry (ignoring undoed ones)
@@ -497,7 +879,7 @@ func (l *Logger) GetLastRegularEntry(refArg ...Ref) (*Entry, error) {
 		}
 
 		// Skip navigation commands - git-undo doesn't process these
-		if entry.IsNavigation {
+		if entry.IsNavigation || entry.Inert() || !l.inSession(entry) {
 			return true
 		}
 
@@ -523,6 +905,7 @@ func (l *Logger) GetLastRegularEntry(refArg ...Ref) (*Entry, error) {
 
 // GetLastUndoedEntry returns the last undoed entry for the given ref (or current ref if not specified).
 // This is used for redo functionality to find the most recent undoed command to re-execute.
+// Consecutive undone entries were undone newest first: the last undone one is the oldest of them.
 // For git-undo, this skips navigation commands (N prefixed).
 func (l *Logger) GetLastUndoedEntry(refArg ...Ref) (*Entry, error) {
 	if l.err != nil {
@@ -539,22 +922,16 @@ func (l *Logger) GetLastUndoedEntry(refArg ...Ref) (*Entry, error) {
 		}
 
 		// Skip navigation commands - git-undo doesn't process these
-		if entry.IsNavigation {
-			return true
-		}
-
-		// Only process undoed entries
-		if !entry.Undoed {
+		if entry.IsNavigation || entry.Inert() || !l.inSession(entry) || !l.matchRef(entry.Ref, ref) {
 			return true
 		}
 
-		if !l.matchRef(entry.Ref, ref) {
+		if entry.Undoed {
+			foundEntry = entry
 			return true
 		}
-
-		// Found a matching undoed entry!
-		foundEntry = entry
-		return false
+		// A regular entry ends the undone ones (if any were found yet)
+		return foundEntry == nil
 	})
 	if err != nil {
 		return nil, err
@@ -577,7 +954,7 @@ func (l *Logger) GetLastEntry(refArg ...Ref) (*Entry, error) {
 	err := l.ProcessLogFile(func(line string) bool {
 		// Parse the log line into an Entry
 		entry, err := ParseLogLine(line)
-		if err != nil { // TODO: warnings maybe?
+		if err != nil || entry.Inert() || !l.inSession(entry) { // TODO: warnings maybe?
 			return true
 		}
 
@@ -685,6 +1062,182 @@ func (l *Logger) GetLastCheckoutSwitchEntryForToggle(refArg ...Ref) (*Entry, err
 	return foundEntry, nil
 }
 
+// GetNavigationEntries returns up to limit checkout and switch entries (all of them if limit <= 0)
+// of all refs, newest first: the navigation history. Undoed entries are left out unless withUndoed is set.
+func (l *Logger) GetNavigationEntries(limit int, withUndoed bool) ([]*Entry, error) {
+	return l.GetEntries(limit, func(e *Entry) bool {
+		return e.IsNavigation && isCheckoutOrSwitchCommand(e.Command) && (withUndoed || !e.Undoed)
+	})
+}
+
+// SetEntryMeta sets the metadata value of the entry with the given identifier
+// (an empty value removes the key). See Entry.SetMeta.
+func (l *Logger) SetEntryMeta(entryIdentifier, key, value string) error {
+	return l.SetEntryMetas(entryIdentifier, map[string]string{key: value})
+}
+
+// SetEntryMetas sets several metadata values of the entry with the given identifier at once
+// (empty values remove their keys). See SetEntryMeta.
+func (l *Logger) SetEntryMetas(entryIdentifier string, meta map[string]string) error {
+	if l.err != nil {
+		return fmt.Errorf("logger is not healthy: %w", l.err)
+	}
+	if l.readOnly {
+		return errors.New("logger is read-only")
+	}
+	meta = l.redactMeta(meta)
+
+	return l.withLock(func() error {
+		var lines []string
+		found := false
+		err := l.ProcessLogFile(func(line string) bool {
+			entry, err := ParseLogLine(line)
+			if err != nil || entry.GetIdentifier() != entryIdentifier {
+				lines = append(lines, line)
+				return true
+			}
+
+			found = true
+			redacted := entry.RedactedParts()
+			for key, value := range meta {
+				entry.SetMeta(key, value)
+			}
+			if len(redacted) > 0 {
+				addRedacted(entry.Metadata, redacted...)
+			}
+			lines = append(lines, entry.String())
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("entry not found: %s", entryIdentifier)
+		}
+
+		return l.rewriteLogFile(lines)
+	})
+}
+
+// GetEntries returns up to limit entries (all of them if limit <= 0), newest first,
+// that satisfy the given filter (every entry if filter is nil). Failed and summary entries are left out.
+func (l *Logger) GetEntries(limit int, filter func(*Entry) bool) ([]*Entry, error) {
+	if l.err != nil {
+		return nil, fmt.Errorf("logger is not healthy: %w", l.err)
+	}
+
+	var entries []*Entry
+	err := l.ProcessLogFile(func(line string) bool {
+		entry, err := ParseLogLine(line)
+		if err != nil || entry.Inert() || !l.inSession(entry) {
+			return true
+		}
+		if filter != nil && !filter(entry) {
+			return true
+		}
+
+		entries = append(entries, entry)
+		return limit <= 0 || len(entries) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetEntriesBetween returns the entries logged within [since, until] (a zero time leaves that end open),
+// newest first, that satisfy the given filter (every entry if filter is nil). Failed and summary entries are left out.
+// Entry timestamps are local wall-clock times parsed as UTC: the bounds must be given the same way.
+// The log is chronological, so reading stops at the first entry older than since.
+func (l *Logger) GetEntriesBetween(since, until time.Time, filter func(*Entry) bool) ([]*Entry, error) {
+	if l.err != nil {
+		return nil, fmt.Errorf("logger is not healthy: %w", l.err)
+	}
+
+	var entries []*Entry
+	err := l.ProcessLogFile(func(line string) bool {
+		entry, err := ParseLogLine(line)
+		if err != nil || entry.Inert() || !l.inSession(entry) {
+			return true
+		}
+		if !since.IsZero() && entry.Timestamp.Before(since) {
+			return false
+		}
+		if !until.IsZero() && entry.Timestamp.After(until) {
+			return true
+		}
+		if filter != nil && !filter(entry) {
+			return true
+		}
+
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetEntryByIndex returns the entry at the 1-based position in the log, as `git undo --log` lists it
+// (1 is the latest entry). Returns nil if the log has fewer entries.
+func (l *Logger) GetEntryByIndex(index int) (*Entry, error) {
+	if index < 1 {
+		return nil, fmt.Errorf("invalid entry index %d: indexes start at 1", index)
+	}
+
+	entries, err := l.GetEntries(index, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < index {
+		return nil, nil //nolint:nilnil // nil entry means not found
+	}
+	return entries[index-1], nil
+}
+
+// GetEntryByIdentifier returns the entry with the given identifier (see Entry.GetIdentifier).
+// Returns nil if nothing matches.
+func (l *Logger) GetEntryByIdentifier(identifier string) (*Entry, error) {
+	entries, err := l.GetEntries(1, func(e *Entry) bool {
+		return e.GetIdentifier() == identifier
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil //nolint:nilnil // nil entry means not found
+	}
+	return entries[0], nil
+}
+
+// GetEntryByID returns the entry with the given short ID (see Entry.ID).
+// Unique prefixes of the ID are accepted as well. Returns nil if nothing matches.
+func (l *Logger) GetEntryByID(id string) (*Entry, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return nil, errors.New("empty entry id")
+	}
+
+	entries, err := l.GetEntries(0, func(e *Entry) bool {
+		return strings.HasPrefix(e.ID(), id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(entries) {
+	case 0:
+		return nil, nil //nolint:nilnil // nil entry means not found
+	case 1:
+		return entries[0], nil
+	default:
+		return nil, fmt.Errorf("entry id %q is ambiguous: %d entries match", id, len(entries))
+	}
+}
+
 // isCheckoutOrSwitchCommand checks if a command is a git checkout or git switch command.
 func isCheckoutOrSwitchCommand(command string) bool {
 	gitCmd, err := githelpers.ParseGitCommand(command)
@@ -722,8 +1275,8 @@ func (l *Logger) CountConsecutiveUndoneCommands(refArg ...Ref) (int, error) {
 			return true // Skip malformed lines
 		}
 
-		// Skip navigation commands
-		if entry.IsNavigation {
+		// Skip navigation (and failed or summary) entries
+		if entry.IsNavigation || entry.Inert() {
 			return true
 		}
 
@@ -758,133 +1311,203 @@ func (l *Logger) TruncateToCurrentBranch(refArg ...Ref) error {
 
 	ref := l.resolveRef(refArg...)
 
-	// Read all lines and filter out undone mutation commands for the target ref
-	var filteredLines []string
-	err := l.ProcessLogFile(func(line string) bool {
-		// Skip empty lines
-		if strings.TrimSpace(line) == "" {
-			return true
-		}
+	return l.withLock(func() error {
+		// Read all lines and filter out undone mutation commands for the target ref
+		var filteredLines []string
+		err := l.ProcessLogFile(func(line string) bool {
+			// Skip empty lines
+			if strings.TrimSpace(line) == "" {
+				return true
+			}
 
-		// Parse the log line into an Entry
-		entry, err := ParseLogLine(line)
-		if err != nil {
-			// Keep malformed lines as-is for safety
-			filteredLines = append(filteredLines, line)
-			return true
-		}
+			// Parse the log line into an Entry
+			entry, err := ParseLogLine(line)
+			if err != nil {
+				// Keep malformed lines as-is for safety
+				filteredLines = append(filteredLines, line)
+				return true
+			}
 
-		// Always preserve navigation commands
-		if entry.IsNavigation {
-			filteredLines = append(filteredLines, line)
-			return true
-		}
+			// Always preserve navigation commands
+			if entry.IsNavigation {
+				filteredLines = append(filteredLines, line)
+				return true
+			}
+
+			// If this entry doesn't match our target ref, keep it
+			if !l.matchRef(entry.Ref, ref) {
+				filteredLines = append(filteredLines, line)
+				return true
+			}
+
+			// For entries matching our ref: keep only non-undone (or pinned) mutation commands
+			if !entry.Undoed || entry.IsPinned() {
+				filteredLines = append(filteredLines, line)
+			}
+			// Skip undone mutation commands (they get truncated)
 
-		// If this entry doesn't match our target ref, keep it
-		if !l.matchRef(entry.Ref, ref) {
-			filteredLines = append(filteredLines, line)
 			return true
-		}
+		})
 
-		// For entries matching our ref: keep only non-undone mutation commands
-		if !entry.Undoed {
-			filteredLines = append(filteredLines, line)
+		if err != nil {
+			return err
 		}
-		// Skip undone mutation commands (they get truncated)
 
-		return true
+		// Write the filtered lines back to the log file
+		return l.rewriteLogFile(filteredLines)
 	})
-
-	if err != nil {
-		return err
-	}
-
-	// Write the filtered lines back to the log file
-	return l.rewriteLogFile(filteredLines)
 }
 
-// rewriteLogFile completely rewrites the log file with the provided lines.
+// rewriteLogFile completely rewrites the log file with the provided lines (newest first, as they're read).
+// Lines are encrypted as they're written when the logger encrypts (see SetPassphrase); once a logger that
+// doesn't has decrypted every line, the key file is removed.
 func (l *Logger) rewriteLogFile(lines []string) error {
-	tmpFile := l.logFile + ".tmp"
+	sealed := false
+	err := l.replaceLogFile(func(out io.Writer) error {
+		if _, err := io.WriteString(out, logHeader+"\n"); err != nil {
+			return fmt.Errorf("failed to write log header: %w", err)
+		}
+		for i := len(lines) - 1; i >= 0; i-- {
+			line, err := l.sealLine(lines[i])
+			if err != nil {
+				return err
+			}
+			_, rest, _ := splitSealable(line)
+			sealed = sealed || strings.HasPrefix(rest, sealedPrefix)
+			if _, err := io.WriteString(out, line+"\n"); err != nil {
+				return fmt.Errorf("failed to write log line: %w", err)
+			}
+		}
+		return nil
+	})
+	if err == nil && l.aead != nil && !l.encrypt && !sealed {
+		_ = os.Remove(filepath.Join(l.logDir, keyFileName))
+	}
+	return err
+}
 
-	// Create a temp file
-	out, err := os.Create(tmpFile)
+// replaceLogFile atomically replaces the log file with the content written by write.
+// The content goes into a uniquely named temp file in the same directory (so concurrent writers never share it
+// and the rename never crosses filesystems), which is synced before the rename; the directory is synced after it.
+func (l *Logger) replaceLogFile(write func(out io.Writer) error) error {
+	out, err := os.CreateTemp(l.logDir, logFileName+".*.tmp")
 	if err != nil {
-		return fmt.Errorf("cannot create temporary log file: %w", err)
+		return fmt.Errorf("cannot create temporary log file: %w", permissionError(l.logDir, err))
 	}
-	defer out.Close()
+	tmpFile := out.Name()
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(tmpFile) // no-op after a successful rename
+	}()
 
-	// Write all lines to the temp file
-	for _, line := range lines {
-		if _, err := out.WriteString(line + "\n"); err != nil {
-			return fmt.Errorf("failed to write log line: %w", err)
-		}
+	if err := write(out); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary log file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary log file: %w", err)
 	}
 
-	// Replace the original file
+	// Swap via rename: will remove logFile and make tmpFile our logFile
 	if err := os.Rename(tmpFile, l.logFile); err != nil {
-		return fmt.Errorf("failed to rename temporary log file: %w", err)
+		return fmt.Errorf("failed to rename temporary log file: %w", permissionError(l.logFile, err))
 	}
+	l.keepOwnership(l.logFile)
 
-	return nil
+	// The rename itself is durable only once the directory is synced
+	return syncDir(l.logDir)
 }
 
-// Dump reads the log file content and writes it directly to the provided writer.
+// Dump writes the log lines, newest first, to the provided writer.
 func (l *Logger) Dump(w io.Writer) error {
-	if l.err != nil {
-		return fmt.Errorf("logger is not healthy: %w", l.err)
-	}
-
-	file, err := l.getFile()
+	var writeErr error
+	err := l.ProcessLogFile(func(line string) bool {
+		_, writeErr = io.WriteString(w, line+"\n")
+		return writeErr == nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
+		return err
 	}
-	defer func() { _ = file.Close() }()
-
-	// Copy directly from file to writer
-	if _, err = io.Copy(w, file); err != nil {
-		return fmt.Errorf("failed to dump log file: %w", err)
+	if writeErr != nil {
+		return fmt.Errorf("failed to dump log file: %w", writeErr)
 	}
-
 	return nil
 }
 
-// prependLogEntry prepends a new line into the log file.
-func (l *Logger) prependLogEntry(entry string) error {
+// appendLogEntry appends a new line to the log file: nothing else of the file is read or written.
+// Past the cap of entries (see SetMaxEntries), the oldest entries are dropped, but pinned ones are kept.
+func (l *Logger) appendLogEntry(entry string) error {
 	if l.err != nil {
 		return fmt.Errorf("logger is not healthy: %w", l.err)
 	}
 
-	tmpFile := l.logFile + ".tmp"
-
-	// Create a tmp file
-	out, err := os.Create(tmpFile)
+	// Makes sure the file exists with its header
+	in, err := l.getFile()
 	if err != nil {
-		return fmt.Errorf("cannot create temporary log file: %w", err)
+		return err
 	}
-	defer out.Close()
+	_ = in.Close()
 
-	// Insert our new entry line
-	if _, err := out.WriteString(entry + "\n"); err != nil {
-		return fmt.Errorf("failed to write log entry: %w", err)
+	entry, err = l.sealLine(entry)
+	if err != nil {
+		return err
 	}
 
-	in, err := l.getFile()
+	// Appends of a single write never interleave with the ones of concurrent hooks
+	out, err := os.OpenFile(l.logFile, os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to open log file: %w", permissionError(l.logFile, err))
+	}
+	defer func() { _ = out.Close() }()
+	if _, err := io.WriteString(out, entry+"\n"); err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
 	}
-	defer func() { _ = in.Close() }()
 
-	if _, err := io.Copy(out, in); err != nil {
-		return fmt.Errorf("failed to copy existing log content: %w", err)
+	if l.maxEntries <= 0 {
+		return nil
 	}
+	return l.dropOldEntries()
+}
 
-	// Swap via rename: will remove logFile and make tmpFile our logFile
-	if err := os.Rename(tmpFile, l.logFile); err != nil {
-		return fmt.Errorf("failed to rename temporary log file: %w", err)
+// dropOldEntries rewrites the log without the entries past the cap (see SetMaxEntries), except for pinned ones.
+// The log is only rewritten when some entry past the cap isn't pinned: the pinned ones are skipped while looking
+// for one, so a log trimmed already (its entries past the cap all pinned) is read but never written.
+func (l *Logger) dropOldEntries() error {
+	read, droppable := 0, false
+	err := l.ProcessLogFile(func(line string) bool {
+		if read < l.maxEntries {
+			read++
+			return true
+		}
+		// Pinned entries are kept: any unpinned one past them is still droppable
+		parsed, err := ParseLogLine(line)
+		droppable = err != nil || !parsed.IsPinned()
+		return !droppable
+	})
+	if err != nil || !droppable {
+		return err
 	}
 
-	return nil
+	var lines []string
+	err = l.ProcessLogFile(func(line string) bool {
+		if len(lines) >= l.maxEntries {
+			if parsed, err := ParseLogLine(line); err != nil || !parsed.IsPinned() {
+				return true
+			}
+		}
+		lines = append(lines, line)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	l.trace("dropped entries past the cap", "max", l.maxEntries)
+	return l.rewriteLogFile(lines)
 }
 
 // resolveRef resolves the ref argument to a Ref.
@@ -916,52 +1539,32 @@ func (l *Logger) matchRef(lineRef, targetRef Ref) bool {
 	return lineRef == targetRef
 }
 
-// ProcessLogFile reads the log file line by line and calls the processor function for each line.
-// This is more efficient than reading the entire file at once, especially when only
-// the first few lines are needed.
+// ProcessLogFile calls the processor function for each line of the log file, newest first,
+// until it returns false. The file is read backward (see logIndex): the latest entries
+// are found without reading the whole file.
 func (l *Logger) ProcessLogFile(processor func(line string) bool) error {
-	if l.err != nil {
-		return fmt.Errorf("logger is not healthy: %w", l.err)
-	}
-
-	file, err := l.getFile()
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Create a scanner to read line by line
-	scanner := bufio.NewScanner(file)
-
-	// Process each line
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		// Call the processor function and check if we should continue
-		if !processor(line) {
-			break
-		}
-	}
-
-	// Check for any scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading log file: %w", err)
-	}
-
-	return nil
+	return l.processLines(func(_ int64, line string) bool { return processor(line) })
 }
 
 // getFile returns the os.File for the log file, opened for reading.
-// If the file doesn't exist, it creates it first.
+// If the file doesn't exist, it creates it first (with its header).
 // Caller is responsible for closing the file.
 func (l *Logger) getFile() (*os.File, error) {
 	f, err := os.OpenFile(l.logFile, os.O_RDONLY, 0600)
+	if os.IsNotExist(err) && l.readOnly {
+		// Read-only loggers must not create the file: read it as empty instead
+		return os.Open(os.DevNull)
+	}
 	if os.IsNotExist(err) {
-		if err := os.WriteFile(l.logFile, []byte{}, 0600); err != nil {
-			return nil, fmt.Errorf("failed to create log file: %w", err)
+		// Concurrent hooks may create it at the same time: only one of them writes the header
+		created, err := os.OpenFile(l.logFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = io.WriteString(created, logHeader+"\n")
+			_ = created.Close()
+			l.keepOwnership(l.logFile)
+		}
+		if err != nil && !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create log file: %w", permissionError(l.logFile, err))
 		}
 		return os.OpenFile(l.logFile, os.O_RDONLY, 0600)
 	}
@@ -983,8 +1586,9 @@ func ParseLogLine(line string) (*Entry, error) {
 
 // ShouldBeLogged returns true if the command should be logged.
 func ShouldBeLogged(gitCmd *githelpers.GitCommand) bool {
-	// Internal commands (git undo and git back) should never be logged
-	if gitCmd.Name == githelpers.CustomCommandBack || gitCmd.Name == githelpers.CustomCommandUndo {
+	// Internal commands (git undo, git back and git redo) should never be logged
+	if gitCmd.Name == githelpers.CustomCommandBack || gitCmd.Name == githelpers.CustomCommandUndo ||
+		gitCmd.Name == githelpers.CustomCommandRedo {
 		return false
 	}
 
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// OwnershipError is returned when the log can't be written because of file permissions.
+// The usual cause is a git command run via sudo: its hooks create log files owned by root.
+type OwnershipError struct {
+	Path string
+	Err  error
+}
+
+func (e *OwnershipError) Error() string {
+	return fmt.Sprintf("cannot write %s: %v (is it owned by another user, e.g. after `sudo git ...`? "+
+		"run `sudo git undo doctor --fix` to give it back)", e.Path, e.Err)
+}
+
+func (e *OwnershipError) Unwrap() error { return e.Err }
+
+// permissionError turns permission errors on the path into OwnershipError, keeping others as they are.
+func permissionError(path string, err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return &OwnershipError{Path: path, Err: err}
+	}
+	return err
+}
+
+// ForeignFile is a file of the log dir owned by another user than the git dir is.
+type ForeignFile struct {
+	Path string
+	UID  int
+}
+
+// ForeignFiles returns the files of the log dir (including the dir itself) that aren't owned by the owner
+// of the git dir. Nothing is returned where file ownership isn't known (e.g. on Windows).
+func ForeignFiles(repoGitDir string) ([]ForeignFile, error) {
+	gitDirInfo, err := os.Stat(repoGitDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s: %w", repoGitDir, err)
+	}
+	ownerUID, _, ok := fileOwner(gitDirInfo)
+	if !ok {
+		return nil, nil
+	}
+
+	var foreign []ForeignFile
+	logDir := filepath.Join(repoGitDir, logFileDirName)
+	err = filepath.WalkDir(logDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil //nolint:nilerr // the file is gone meanwhile (e.g. a flag file cleaned up)
+		}
+		if uid, _, ok := fileOwner(info); ok && uid != ownerUID {
+			foreign = append(foreign, ForeignFile{Path: path, UID: uid})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s: %w", logDir, err)
+	}
+	return foreign, nil
+}
+
+// FixOwnership gives the foreign files of the log dir back to the owner of the git dir.
+// Changing the owner needs root, so it's meant to be run via sudo.
+func FixOwnership(repoGitDir string, files []ForeignFile) error {
+	var errs []error
+	for _, file := range files {
+		if err := chownLike(file.Path, repoGitDir); err != nil {
+			errs = append(errs, fmt.Errorf("failed to change the owner of %s: %w", file.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// keepOwnership gives the file written by root (e.g. by hooks of `sudo git ...`) to the owner of the git dir,
+// so the user can still modify the log afterwards. It does nothing for other users.
+func (l *Logger) keepOwnership(path string) {
+	if os.Geteuid() != 0 {
+		return
+	}
+	_ = chownLike(path, filepath.Dir(l.logDir))
+}
+
+// chownLike changes the owner of the path to the owner of the reference file.
+func chownLike(path, reference string) error {
+	info, err := os.Stat(reference)
+	if err != nil {
+		return err
+	}
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, uid, gid)
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package logging
+
+import "os"
+
+// fileOwner returns false: file ownership isn't known on this platform.
+func fileOwner(_ os.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,95 @@
+//go:build unix
+
+package logging_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// foreignUID is a user owning test files that isn't the one running the tests.
+const foreignUID = 4242
+
+func requireRoot(t *testing.T) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("changing file owners needs root")
+	}
+}
+
+func fileUID(t *testing.T, path string) int {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	require.True(t, ok)
+	return int(stat.Uid)
+}
+
+func TestOwnershipError(t *testing.T) {
+	err := logging.PermissionError("/repo/.git/git-undo/commands", &fs.PathError{Op: "open", Err: fs.ErrPermission})
+
+	var ownershipErr *logging.OwnershipError
+	require.ErrorAs(t, err, &ownershipErr)
+	require.ErrorIs(t, err, fs.ErrPermission)
+	assert.Contains(t, err.Error(), "sudo git undo doctor --fix")
+
+	other := errors.New("disk full")
+	assert.Equal(t, other, logging.PermissionError("/repo/.git/git-undo/commands", other))
+}
+
+func TestRootKeepsLogOwnership(t *testing.T) {
+	requireRoot(t)
+
+	gitDir := t.TempDir()
+	require.NoError(t, os.Chown(gitDir, foreignUID, foreignUID))
+
+	mgc := NewMockGitHelper()
+	SwitchRef(mgc, "main")
+	lgr := logging.NewLogger(gitDir, mgc)
+	require.NotNil(t, lgr)
+	require.NoError(t, lgr.LogCommand("git commit -m 'as root'"))
+
+	// Files written by root (e.g. hooks of `sudo git commit`) belong to the owner of the repository
+	assert.Equal(t, foreignUID, fileUID(t, filepath.Join(gitDir, "git-undo")))
+	assert.Equal(t, foreignUID, fileUID(t, lgr.GetLogPath()))
+
+	foreign, err := logging.ForeignFiles(gitDir)
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+}
+
+func TestForeignFilesFix(t *testing.T) {
+	requireRoot(t)
+
+	gitDir := t.TempDir()
+	mgc := NewMockGitHelper()
+	SwitchRef(mgc, "main")
+	lgr := logging.NewLogger(gitDir, mgc)
+	require.NotNil(t, lgr)
+	require.NoError(t, lgr.LogCommand("git commit -m 'foreign'"))
+	require.NoError(t, os.Chown(lgr.GetLogPath(), foreignUID, foreignUID))
+
+	foreign, err := logging.ForeignFiles(gitDir)
+	require.NoError(t, err)
+	require.Equal(t, []logging.ForeignFile{{Path: lgr.GetLogPath(), UID: foreignUID}}, foreign)
+
+	require.NoError(t, logging.FixOwnership(gitDir, foreign))
+	assert.Equal(t, os.Geteuid(), fileUID(t, lgr.GetLogPath()))
+	foreign, err = logging.ForeignFiles(gitDir)
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+
+	// Repositories without a log have nothing foreign
+	foreign, err = logging.ForeignFiles(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// preStateFileName keeps the state captured right before a hooked command runs, until the command is logged.
+const preStateFileName = "prestate"
+
+// preStateAdditional is authenticated with the sealed pre-state of encrypted logs.
+var preStateAdditional = []byte(preStateFileName)
+
+// preState is the metadata captured before a command, waiting for the command to be logged.
+type preState struct {
+	Command string            `json:"command"`
+	Meta    map[string]string `json:"meta"`
+}
+
+// SavePreState keeps the metadata captured right before the command runs:
+// it's added to the entry of the command once it's logged (see TakePreState).
+// It's encrypted as the entries are when the logger encrypts (see SetPassphrase).
+func (l *Logger) SavePreState(command string, meta map[string]string) error {
+	if l.err != nil {
+		return fmt.Errorf("logger is not healthy: %w", l.err)
+	}
+	if l.readOnly {
+		return errors.New("logger is read-only")
+	}
+
+	data, err := json.Marshal(preState{Command: l.redact(command), Meta: l.redactMeta(meta)})
+	if err != nil {
+		return fmt.Errorf("failed to encode pre-state: %w", err)
+	}
+	if l.aead != nil && l.encrypt {
+		sealed, err := seal(l.aead, data, preStateAdditional)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt pre-state: %w", err)
+		}
+		data = []byte(sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed))
+	}
+	path := filepath.Join(l.logDir, preStateFileName)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write pre-state: %w", err)
+	}
+	l.keepOwnership(path)
+	return nil
+}
+
+// TakePreState returns the metadata captured before the command (nil if there is none) and clears it.
+// The state captured before a command that failed (so wasn't logged) is dropped by the next logged one.
+func (l *Logger) TakePreState(command string) map[string]string {
+	if l.readOnly {
+		return nil
+	}
+
+	path := filepath.Join(l.logDir, preStateFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	_ = os.Remove(path)
+	if encoded, sealed := bytes.CutPrefix(data, []byte(sealedPrefix)); sealed {
+		if data, err = l.openPreState(encoded); err != nil {
+			return nil
+		}
+	}
+
+	var state preState
+	if err := json.Unmarshal(data, &state); err != nil || state.Command != l.redact(command) {
+		return nil
+	}
+	return state.Meta
+}
+
+// openPreState decrypts the pre-state sealed by SavePreState.
+func (l *Logger) openPreState(encoded []byte) ([]byte, error) {
+	if l.aead == nil {
+		return nil, ErrEncrypted
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return openSealed(l.aead, sealed, preStateAdditional)
+}
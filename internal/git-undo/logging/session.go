@@ -0,0 +1,31 @@
+package logging
+
+import "os"
+
+// MetaSession is the ID of the shell session the command was run from (see EnvSession).
+const MetaSession = "session"
+
+// EnvSession names the environment variable holding the ID of the shell session, exported by the shell hooks.
+// Commands logged while it's set are recorded with it (see MetaSession).
+const EnvSession = "GIT_UNDO_SESSION"
+
+// SetSession scopes the logger to the entries of the shell session (see MetaSession): the getters of entries
+// skip the others, as if they were not in the log. An empty session unscopes it.
+func (l *Logger) SetSession(session string) {
+	l.session = session
+}
+
+// Session returns the shell session ID of the entry (empty if it was not logged from a hooked shell).
+func (e *Entry) Session() string {
+	return e.Metadata[MetaSession]
+}
+
+// inSession reports whether the entry belongs to the session the logger is scoped to (see SetSession).
+func (l *Logger) inSession(e *Entry) bool {
+	return l.session == "" || e.Session() == l.session
+}
+
+// currentSession returns the shell session ID of the command being logged (empty outside of hooked shells).
+func currentSession() string {
+	return os.Getenv(EnvSession)
+}
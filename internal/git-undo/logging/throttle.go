@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// Metadata keys of throttled entries.
+const (
+	// MetaRepeats counts identical commands collapsed into the entry.
+	MetaRepeats = "repeats"
+	// MetaSuppressed counts the commands of the subcommand a summary entry stands for (see Entry.Summary).
+	MetaSuppressed = "suppressed"
+)
+
+// throttleWindow is the window of the per-minute cap.
+const throttleWindow = time.Minute
+
+// collapsibleCommands only refresh state read from remotes (e.g. IDEs fetching in background):
+// identical consecutive runs of them collapse into a single entry.
+var collapsibleCommands = map[string]struct{}{
+	"fetch":  {},
+	"remote": {},
+}
+
+// readOnlyCommands leave the branches, the index and the work tree alone: the per-minute cap applies to them.
+var readOnlyCommands = map[string]struct{}{
+	"fetch": {},
+}
+
+// SetMaxPerMinute caps how many read-only and navigation commands of the same subcommand are logged per minute
+// (0 means no cap). Commands over the cap are not logged, but counted in a summary entry (see Entry.Summary).
+// Commands changing the repository are never capped: undoing them relies on their entries.
+func (l *Logger) SetMaxPerMinute(maxPerMinute int) {
+	l.maxPerMinute = maxPerMinute
+}
+
+// Repeats returns the number of identical commands collapsed into the entry.
+func (e *Entry) Repeats() int {
+	n, _ := strconv.Atoi(e.Metadata[MetaRepeats])
+	return n
+}
+
+// Suppressed returns the number of commands the summary entry stands for.
+func (e *Entry) Suppressed() int {
+	n, _ := strconv.Atoi(e.Metadata[MetaSuppressed])
+	return n
+}
+
+// summaryText describes what the summary entry stands for (e.g. "suppressed 240 fetch operations").
+func (e *Entry) summaryText() string {
+	name := strings.TrimPrefix(e.Command, "git ")
+	if e.Suppressed() == 1 {
+		return "suppressed 1 " + name + " operation"
+	}
+	return fmt.Sprintf("suppressed %d %s operations", e.Suppressed(), name)
+}
+
+// capped tells whether the per-minute cap applies to the command: read-only and navigation commands only.
+func (l *Logger) capped(gitCmd *githelpers.GitCommand, strGitCommand string) bool {
+	if l.maxPerMinute <= 0 {
+		return false
+	}
+	_, readOnly := readOnlyCommands[gitCmd.Name]
+	return readOnly || l.IsNavigationCommand(strGitCommand)
+}
+
+// throttle reports whether the command must not be logged as a new entry: it's either collapsed into
+// the newest identical entry (counted in its metadata), or suppressed by the per-minute cap
+// (counted in the summary entry of the subcommand, logged by the first suppressed one).
+func (l *Logger) throttle(gitCmd *githelpers.GitCommand, strGitCommand string, ref Ref) bool {
+	var newest, summary *Entry
+	logged := 0
+	capped := l.capped(gitCmd, strGitCommand)
+	// Entry timestamps are local wall-clock times parsed as UTC: the window start is converted the same way
+	since, _ := time.Parse(logEntryDateFormat, time.Now().Add(-throttleWindow).Format(logEntryDateFormat))
+	_ = l.ProcessLogFile(func(line string) bool {
+		entry, err := ParseLogLine(line)
+		if err != nil || entry.Failed {
+			return true
+		}
+		if newest == nil && !entry.Summary {
+			newest = entry
+		}
+		if !capped || entry.Timestamp.Before(since) {
+			return newest == nil
+		}
+		if entryCmd, err := githelpers.ParseGitCommand(entry.Command); err == nil && entryCmd.Name == gitCmd.Name {
+			switch {
+			case !entry.Summary:
+				logged++
+			case summary == nil:
+				summary = entry
+			}
+		}
+		return true
+	})
+
+	if _, ok := collapsibleCommands[gitCmd.Name]; ok && newest != nil && !newest.Undoed &&
+		newest.Ref == ref && newest.Command == strGitCommand {
+		_ = l.SetEntryMeta(newest.GetIdentifier(), MetaRepeats, strconv.Itoa(newest.Repeats()+1))
+		return true
+	}
+
+	if !capped || logged < l.maxPerMinute {
+		return false
+	}
+	if summary != nil {
+		_ = l.SetEntryMeta(summary.GetIdentifier(), MetaSuppressed, strconv.Itoa(summary.Suppressed()+1))
+		return true
+	}
+	summary = &Entry{Timestamp: time.Now(), Ref: ref, Command: "git " + gitCmd.Name, Summary: true}
+	summary.SetMeta(MetaSuppressed, "1")
+	summary.SetMeta(MetaSession, currentSession())
+	_ = l.appendLogEntry(summary.String())
+	return true
+}
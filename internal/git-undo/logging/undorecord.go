@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"strings"
+	"time"
+)
+
+// Metadata keys of the record of the last undo of an entry.
+const (
+	// MetaUndoCommands are the commands the undo executed, each terminated by a newline.
+	MetaUndoCommands = "undo.commands"
+	// MetaUndoTime is when the undo was executed (a local wall-clock time, as entry timestamps).
+	MetaUndoTime = "undo.at"
+	// MetaUndoError is why the undo failed (absent when it succeeded).
+	MetaUndoError = "undo.error"
+	// MetaUndoStrategy is how the undo kept the later commits when it didn't reset the commit away
+	// (e.g. "rebase" or "revert", absent otherwise): redo can't re-run the command then.
+	MetaUndoStrategy = "undo.strategy"
+	// MetaRedoTime is when the entry was last redone (a local wall-clock time, as entry timestamps).
+	MetaRedoTime = "redo.at"
+)
+
+// UndoRecord tells how the entry was undone: the commands executed and whether they succeeded.
+type UndoRecord struct {
+	// Commands are the commands the undo executed (or was executing when it failed).
+	Commands []string
+	// Time is when the undo was executed.
+	Time time.Time
+	// Error is why the undo failed, empty if it succeeded.
+	Error string
+	// Strategy is how the undo kept the later commits (see MetaUndoStrategy), empty if it didn't have to.
+	Strategy string
+}
+
+// Succeeded reports whether the recorded undo succeeded.
+func (r *UndoRecord) Succeeded() bool {
+	return r.Error == ""
+}
+
+// UndoRecord returns the record of the last undo of the entry (nil if it was never undone, or was redone since).
+func (e *Entry) UndoRecord() *UndoRecord {
+	encoded, ok := e.Metadata[MetaUndoCommands]
+	if !ok {
+		return nil
+	}
+	at, _ := time.Parse(logEntryDateFormat, e.Metadata[MetaUndoTime])
+	return &UndoRecord{
+		Commands: strings.Split(strings.TrimSuffix(encoded, "\n"), "\n"),
+		Time:     at,
+		Error:    e.Metadata[MetaUndoError],
+		Strategy: e.Metadata[MetaUndoStrategy],
+	}
+}
+
+// RedoTime returns when the entry was last redone (ok is false if it never was).
+func (e *Entry) RedoTime() (time.Time, bool) {
+	at, err := time.Parse(logEntryDateFormat, e.Metadata[MetaRedoTime])
+	return at, err == nil
+}
+
+// UndoRecordMeta returns the metadata recording an undo executed at the given time (see Entry.UndoRecord).
+// A nil record returns the metadata clearing it (as redo does).
+func UndoRecordMeta(record *UndoRecord) map[string]string {
+	if record == nil {
+		return map[string]string{MetaUndoCommands: "", MetaUndoTime: "", MetaUndoError: "", MetaUndoStrategy: ""}
+	}
+	return map[string]string{
+		MetaUndoCommands: strings.Join(record.Commands, "\n") + "\n",
+		MetaUndoTime:     record.Time.Format(logEntryDateFormat),
+		MetaUndoError:    record.Error,
+		MetaUndoStrategy: record.Strategy,
+	}
+}
@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GitHelper is the subset of git helpers finding the files a command is about to lose.
+type GitHelper interface {
+	GitOutput(subCmd string, args ...string) (string, error)
+}
+
+// cleanDryRunDropped are the git clean flags (as letters of short flags too) dropped from its dry run.
+var cleanDryRunDropped = []string{"-f", "--force", "-i", "--interactive", "-q", "--quiet", "-n", "--dry-run"}
+
+// IsDestructive tells whether the git command loses working tree files that git can't bring back:
+// `git clean`, `git reset --hard` and `git checkout [<tree-ish>] -- <paths>`.
+func IsDestructive(subCommand string, args []string) bool {
+	switch subCommand {
+	case "clean":
+		return !slices.Contains(args, "-n") && !slices.Contains(args, "--dry-run")
+	case "reset":
+		return slices.Contains(args, "--hard")
+	case "checkout":
+		return slices.Contains(args, "--")
+	default:
+		return false
+	}
+}
+
+// AffectedPaths returns the paths (relative to the working tree root) of the files the destructive command
+// is about to remove or overwrite. Directories (removed by `git clean -d`) end with a slash.
+func AffectedPaths(g GitHelper, subCommand string, args []string) ([]string, error) {
+	switch subCommand {
+	case "clean":
+		return cleanPaths(g, args)
+	case "reset":
+		return changedPaths(g)
+	case "checkout":
+		return changedPaths(g, args[slices.Index(args, "--"):]...)
+	default:
+		return nil, fmt.Errorf("git %s is not a destructive command", subCommand)
+	}
+}
+
+// cleanPaths returns the paths git clean would remove, asking its dry run.
+func cleanPaths(g GitHelper, args []string) ([]string, error) {
+	dryRun := []string{"clean", "--dry-run"}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case slices.Contains(cleanDryRunDropped, arg):
+			continue
+		case arg == "-e" || arg == "--exclude":
+			dryRun = append(dryRun, args[i:min(i+2, len(args))]...)
+			i++
+			continue
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && !strings.Contains(arg, "e"):
+			// Combined short flags, e.g. -fdx
+			arg = "-" + strings.Map(func(r rune) rune {
+				if strings.ContainsRune("fiqn", r) {
+					return -1
+				}
+				return r
+			}, arg[1:])
+			if arg == "-" {
+				continue
+			}
+		}
+		dryRun = append(dryRun, arg)
+	}
+
+	// Paths are printed relative to the current directory
+	prefix, err := g.GitOutput("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the current directory: %w", err)
+	}
+	output, err := g.GitOutput("-c", append([]string{"core.quotePath=false"}, dryRun...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files git clean removes: %w", err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		path, ok := strings.CutPrefix(strings.TrimSpace(line), "Would remove ")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		paths = append(paths, strings.TrimSpace(prefix)+path)
+	}
+	return paths, nil
+}
+
+// changedPaths returns the paths (limited to the pathspec, if any) with changes not committed:
+// staged ones and ones in the working tree.
+func changedPaths(g GitHelper, pathspec ...string) ([]string, error) {
+	var paths []string
+	for _, base := range [][]string{{"HEAD"}, nil} {
+		args := append(append([]string{"--name-only", "-z"}, base...), pathspec...)
+		output, err := g.GitOutput("diff", args...)
+		if err != nil {
+			// There is no HEAD yet
+			continue
+		}
+		for _, path := range strings.Split(output, "\x00") {
+			if path != "" && !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,312 @@
+// Package snapshot keeps copies of working tree files taken right before destructive git commands
+// (e.g. `git clean`), so undoing these commands can bring the files back.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// dirName is the directory (in the git-undo dir of the git dir) holding snapshots, one per directory.
+	dirName = "git-undo/snapshots"
+	// metaFileName describes a snapshot: its command, time and files.
+	metaFileName = "snapshot.json"
+	// filesDirName holds the copied files of a snapshot at their paths in the working tree.
+	filesDirName = "files"
+
+	// idFormat names snapshot directories: sorting the names sorts snapshots by time.
+	idFormat = "20060102-150405.000000000"
+)
+
+// MaxBytes is the largest total size of files a snapshot is taken of: larger ones are skipped.
+const MaxBytes = 100 << 20
+
+// ErrTooLarge is returned when the files exceed MaxBytes.
+var ErrTooLarge = errors.New("files are too large to snapshot")
+
+// Snapshot is a copy of working tree files taken right before a command.
+type Snapshot struct {
+	// ID names the snapshot (its directory).
+	ID string `json:"-"`
+	// Command is the git command the snapshot was taken before.
+	Command string `json:"command"`
+	// Time is when the snapshot was taken.
+	Time time.Time `json:"time"`
+	// Files are the copied files, relative to the working tree root.
+	Files []string `json:"files"`
+
+	dir string
+}
+
+// Store manages the snapshots of a repository.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the snapshot store of the repository with the given git dir.
+func NewStore(gitDir string) *Store {
+	return &Store{dir: filepath.Join(gitDir, filepath.FromSlash(dirName))}
+}
+
+// Save copies the files at the given paths (relative to the worktree root, directories included recursively)
+// into a new snapshot. Paths that don't exist are skipped: nil is returned when nothing is left to copy.
+func (s *Store) Save(worktree, command string, paths []string) (*Snapshot, error) {
+	files, err := collectFiles(worktree, paths)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	now := time.Now()
+	snap := &Snapshot{ID: now.UTC().Format(idFormat), Command: command, Time: now, Files: files}
+	snap.dir = filepath.Join(s.dir, snap.ID)
+	for _, file := range files {
+		if err := copyFile(filepath.Join(worktree, file), filepath.Join(snap.FilesDir(), file)); err != nil {
+			_ = os.RemoveAll(snap.dir)
+			return nil, fmt.Errorf("failed to snapshot %s: %w", file, err)
+		}
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		_ = os.RemoveAll(snap.dir)
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(snap.dir, metaFileName), data, 0600); err != nil {
+		_ = os.RemoveAll(snap.dir)
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// Get returns the snapshot with the given ID.
+func (s *Store) Get(id string) (*Snapshot, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, fmt.Errorf("invalid snapshot ID: %q", id)
+	}
+
+	dir := filepath.Join(s.dir, id)
+	data, err := os.ReadFile(filepath.Join(dir, metaFileName))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s not found: %w", id, err)
+	}
+	snap := &Snapshot{ID: id, dir: dir}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", id, err)
+	}
+	return snap, nil
+}
+
+// List returns the snapshots, oldest first. Unreadable ones are skipped.
+func (s *Store) List() ([]*Snapshot, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []*Snapshot
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		if snap, err := s.Get(dirEntry.Name()); err == nil {
+			snapshots = append(snapshots, snap)
+		}
+	}
+	return snapshots, nil
+}
+
+// Latest returns the most recent snapshot taken before the command (nil if there is none).
+func (s *Store) Latest(command string) (*Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range slices.Backward(snapshots) {
+		if snap.Command == command {
+			return snap, nil
+		}
+	}
+	return nil, nil
+}
+
+// Prune removes snapshots taken longer than the retention period ago (retention <= 0 keeps them forever).
+// It returns how many were removed.
+func (s *Store) Prune(retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	snapshots, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	cutoff := time.Now().Add(-retention)
+	for _, snap := range snapshots {
+		if !snap.Time.Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(snap.dir); err != nil {
+			return pruned, fmt.Errorf("failed to remove snapshot %s: %w", snap.ID, err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// Size returns the total size of the files of the snapshots (0 when there are none).
+func (s *Store) Size() (int64, error) {
+	var size int64
+	err := filepath.WalkDir(s.dir, func(_ string, dirEntry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || dirEntry.IsDir() {
+			return err
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure snapshots: %w", err)
+	}
+	return size, nil
+}
+
+// FilesDir returns the directory holding the copied files.
+func (snap *Snapshot) FilesDir() string {
+	return filepath.Join(snap.dir, filesDirName)
+}
+
+// Existing returns the files of the snapshot that exist in the worktree now.
+func (snap *Snapshot) Existing(worktree string) []string {
+	var existing []string
+	for _, file := range snap.Files {
+		if _, err := os.Lstat(filepath.Join(worktree, file)); err == nil {
+			existing = append(existing, file)
+		}
+	}
+	return existing
+}
+
+// Missing returns the files of the snapshot whose copies are gone from it (e.g. removed by hand).
+func (snap *Snapshot) Missing() []string {
+	var missing []string
+	for _, file := range snap.Files {
+		if _, err := os.Lstat(filepath.Join(snap.FilesDir(), file)); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
+
+// Restore copies the files of the snapshot back into the worktree, overwriting the existing ones.
+// Files whose copies are missing (see Missing) are skipped: it returns the restored ones.
+func (snap *Snapshot) Restore(worktree string) ([]string, error) {
+	missing := snap.Missing()
+	var restored []string
+	for _, file := range snap.Files {
+		if slices.Contains(missing, file) {
+			continue
+		}
+		dst := filepath.Join(worktree, file)
+		if err := os.RemoveAll(dst); err != nil {
+			return restored, fmt.Errorf("failed to replace %s: %w", file, err)
+		}
+		if err := copyFile(filepath.Join(snap.FilesDir(), file), dst); err != nil {
+			return restored, fmt.Errorf("failed to restore %s: %w", file, err)
+		}
+		restored = append(restored, file)
+	}
+	return restored, nil
+}
+
+// collectFiles expands the paths into the files (and symlinks) under them, failing past MaxBytes.
+func collectFiles(worktree string, paths []string) ([]string, error) {
+	var files []string
+	var size int64
+	for _, path := range paths {
+		root := filepath.Join(worktree, filepath.FromSlash(strings.TrimSuffix(path, "/")))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if size += info.Size(); size > MaxBytes {
+				return ErrTooLarge
+			}
+			rel, err := filepath.Rel(worktree, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// copyFile copies the file (or symlink) keeping its mode, creating the parent directories of dst.
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
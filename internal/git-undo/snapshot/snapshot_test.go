@@ -0,0 +1,143 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/snapshot"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	worktree, gitDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(worktree, "build", "nested"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "notes.txt"), []byte("notes"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "build", "nested", "out.bin"), []byte("out"), 0755))
+	require.NoError(t, os.Symlink("notes.txt", filepath.Join(worktree, "link")))
+
+	store := snapshot.NewStore(gitDir)
+	snap, err := store.Save(worktree, "git clean -fd", []string{"notes.txt", "build/", "link", "missing.txt"})
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	assert.Equal(t, []string{"build/nested/out.bin", "link", "notes.txt"}, snap.Files)
+
+	// Nothing to copy: no snapshot
+	empty, err := store.Save(worktree, "git clean -f", []string{"missing.txt"})
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+
+	// The files come back as they were
+	require.NoError(t, os.RemoveAll(filepath.Join(worktree, "build")))
+	require.NoError(t, os.Remove(filepath.Join(worktree, "notes.txt")))
+	require.NoError(t, os.Remove(filepath.Join(worktree, "link")))
+	assert.Empty(t, snap.Existing(worktree))
+	assert.Empty(t, snap.Missing())
+	restored, err := snap.Restore(worktree)
+	require.NoError(t, err)
+	assert.Equal(t, snap.Files, restored)
+	content, err := os.ReadFile(filepath.Join(worktree, "build", "nested", "out.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, "out", string(content))
+	info, err := os.Stat(filepath.Join(worktree, "build", "nested", "out.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	target, err := os.Readlink(filepath.Join(worktree, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "notes.txt", target)
+	assert.Len(t, snap.Existing(worktree), 3)
+
+	// Files whose copies are gone from the snapshot are skipped
+	require.NoError(t, os.Remove(filepath.Join(snap.FilesDir(), "notes.txt")))
+	assert.Equal(t, []string{"notes.txt"}, snap.Missing())
+	restored, err = snap.Restore(worktree)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build/nested/out.bin", "link"}, restored)
+
+	// Snapshots are found by ID and by command
+	got, err := store.Get(snap.ID)
+	require.NoError(t, err)
+	assert.Equal(t, snap.Files, got.Files)
+	_, err = store.Get("../escape")
+	require.Error(t, err)
+	latest, err := store.Latest("git clean -fd")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, snap.ID, latest.ID)
+	latest, err = store.Latest("git reset --hard")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	// Retention
+	size, err := store.Size()
+	require.NoError(t, err)
+	assert.Positive(t, size)
+	pruned, err := store.Prune(time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+	time.Sleep(10 * time.Millisecond)
+	pruned, err = store.Prune(time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	snapshots, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+	size, err = store.Size()
+	require.NoError(t, err)
+	assert.Zero(t, size)
+}
+
+func TestAffectedPaths(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	write := func(file, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, file)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0600))
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("tracked.txt", "base")
+	write("other.txt", "base")
+	write(".gitignore", "*.log\n")
+	git("add", ".")
+	git("commit", "-m", "base")
+	write("tracked.txt", "changed")
+	write("other.txt", "staged")
+	git("add", "other.txt")
+	write("untracked file.txt", "untracked")
+	write("dir/inner.txt", "inner")
+	write("debug.log", "ignored")
+
+	g := githelpers.NewGitHelper(context.Background(), repoDir)
+	affected := func(command string) []string {
+		args := strings.Fields(command)
+		require.True(t, snapshot.IsDestructive(args[0], args[1:]), command)
+		paths, err := snapshot.AffectedPaths(g, args[0], args[1:])
+		require.NoError(t, err)
+		return paths
+	}
+
+	assert.ElementsMatch(t, []string{"untracked file.txt"}, affected("clean -f"))
+	assert.ElementsMatch(t, []string{"dir/", "untracked file.txt"}, affected("clean -fd"))
+	assert.ElementsMatch(t, []string{"debug.log", "dir/", "untracked file.txt"}, affected("clean -fdx"))
+	assert.ElementsMatch(t, []string{"dir/", "untracked file.txt"}, affected("clean -f -d -e *.log"))
+	assert.ElementsMatch(t, []string{"other.txt", "tracked.txt"}, affected("reset --hard"))
+	assert.ElementsMatch(t, []string{"tracked.txt"}, affected("checkout -- tracked.txt"))
+
+	assert.False(t, snapshot.IsDestructive("clean", []string{"-n"}))
+	assert.False(t, snapshot.IsDestructive("reset", []string{"--soft", "HEAD~1"}))
+	assert.False(t, snapshot.IsDestructive("checkout", []string{"main"}))
+}
@@ -1,21 +1,101 @@
 package undoer
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
 // AddUndoer handles undoing git add operations.
+// With the index recorded right before the add, exactly the index entries the add changed are restored
+// (so `git add -p` hunks, `-u` and `.` are undone precisely). Otherwise the added paths are unstaged.
 type AddUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	// indexBefore and indexAfter are the trees of the index right before and right after the add.
+	indexBefore string
+	indexAfter  string
 }
 
 var _ Undoer = &AddUndoer{}
 
+// patchAddFlags make git add stage only the hunks (or the edits) chosen by the user.
+var patchAddFlags = []string{"-p", "--patch", "-i", "--interactive", "-e", "--edit"}
+
+// RecordedIndex makes the undo restore the index entries changed between the given trees of the index
+// (recorded right before and right after the add) to what they were before it.
+func (a *AddUndoer) RecordedIndex(before, after string) {
+	a.indexBefore, a.indexAfter = before, after
+}
+
 // GetUndoCommands returns the commands that would undo the add operation.
 func (a *AddUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	if a.indexBefore != "" && a.indexAfter != "" {
+		return a.restoreIndex()
+	}
+
+	undoCmds, err := a.unstagePaths()
+	if err != nil {
+		return nil, err
+	}
+	isPatch := slices.ContainsFunc(a.originalCmd.Args, func(arg string) bool {
+		return slices.Contains(patchAddFlags, arg)
+	})
+	if isPatch {
+		undoCmds[0].Warnings = append(undoCmds[0].Warnings, "The index before the add wasn't recorded: "+
+			"the whole files are unstaged, not only the hunks the add staged")
+	}
+	return undoCmds, nil
+}
+
+// restoreIndex restores the index entries the add changed to what they were right before it,
+// keeping whatever was staged before the add.
+func (a *AddUndoer) restoreIndex() ([]*UndoCommand, error) {
+	output, err := a.git.GitOutput("diff-tree", "-r", "--name-only", a.indexBefore, a.indexAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare the index before and after the add: %w", err)
+	}
+	paths := splitLines(output)
+	if len(paths) == 0 {
+		return nil, errors.New("the add staged nothing: nothing to undo")
+	}
+
+	var warnings []string
+	changed, err := a.git.GitOutput("diff-index", "--cached", "--name-only", a.indexAfter, "--")
+	if err == nil {
+		var restaged []string
+		for _, path := range splitLines(changed) {
+			if slices.Contains(paths, path) {
+				restaged = append(restaged, path)
+			}
+		}
+		if len(restaged) > 0 {
+			warnings = append(warnings, fmt.Sprintf("Changes staged to %s after the add are unstaged as well",
+				summarizePaths(restaged)))
+		}
+	}
+
+	restoreCmd := "git reset -q " + a.indexBefore
+	if supports(a.git, githelpers.CapRestore) {
+		restoreCmd = "git restore --staged --source=" + a.indexBefore
+	}
+	// Paths are relative to the repository root, so they're passed with the `:/` (top) pathspec magic
+	topPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		topPaths = append(topPaths, quotePath(":/"+path))
+	}
+	return []*UndoCommand{NewUndoCommand(a.git,
+		restoreCmd+" -- "+strings.Join(topPaths, " "), "", warnings...,
+	).WithPaths(paths, restoreCmd, "Unstage")}, nil
+}
+
+// unstagePaths returns the command unstaging the paths given to the add (everything if none were given).
+func (a *AddUndoer) unstagePaths() ([]*UndoCommand, error) {
 	// Check if HEAD exists (i.e., if there are any commits)
 	// If there's no HEAD, we need to use 'git reset' instead of 'git restore --staged'
 	headExists := true
@@ -37,10 +117,7 @@ func (a *AddUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 
 	// If --all flag was used or no specific files, unstage everything
 	if hasAllFlag || len(a.originalCmd.Args) == 0 {
-		if headExists {
-			return []*UndoCommand{NewUndoCommand(a.git, "git restore --staged .", "Unstage all files")}, nil
-		}
-		return []*UndoCommand{NewUndoCommand(a.git, "git reset", "Unstage all files")}, nil
+		return []*UndoCommand{a.unstageAll(headExists)}, nil
 	}
 
 	// For other cases, filter out flags and only pass real file paths to restore
@@ -54,23 +131,54 @@ func (a *AddUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 
 	// If we only had flags but no files, default to restoring everything
 	if len(filesToRestore) == 0 {
-		if headExists {
-			return []*UndoCommand{NewUndoCommand(a.git, "git restore --staged .", "Unstage all files")}, nil
-		}
-
-		return []*UndoCommand{NewUndoCommand(a.git, "git reset", "Unstage all files")}, nil
+		return []*UndoCommand{a.unstageAll(headExists)}, nil
 	}
 
-	if headExists {
-		return []*UndoCommand{NewUndoCommand(
-			a.git,
-			fmt.Sprintf("git restore --staged %s", strings.Join(filesToRestore, " ")),
-			fmt.Sprintf("Unstage specific files: %s", strings.Join(filesToRestore, ", ")),
-		)}, nil
+	unstageCmd := "git reset"
+	if headExists && supports(a.git, githelpers.CapRestore) {
+		unstageCmd = "git restore --staged"
 	}
-	return []*UndoCommand{NewUndoCommand(
+	quoted := make([]string, 0, len(filesToRestore))
+	for _, file := range filesToRestore {
+		quoted = append(quoted, quotePath(file))
+	}
+	undoCmd := NewUndoCommand(
 		a.git,
-		fmt.Sprintf("git reset %s", strings.Join(filesToRestore, " ")),
+		fmt.Sprintf("%s %s", unstageCmd, strings.Join(quoted, " ")),
 		fmt.Sprintf("Unstage specific files: %s", strings.Join(filesToRestore, ", ")),
-	)}, nil
+	)
+
+	// Directories (or globs) were added: summarize what they expanded to
+	if paths := a.stagedPaths(headExists, filesToRestore...); len(paths) != len(filesToRestore) {
+		undoCmd.WithPaths(paths, unstageCmd, "Unstage")
+	}
+	return []*UndoCommand{undoCmd}, nil
+}
+
+// unstageAll returns the command that unstages everything.
+func (a *AddUndoer) unstageAll(headExists bool) *UndoCommand {
+	if headExists && supports(a.git, githelpers.CapRestore) {
+		return NewUndoCommand(a.git, "git restore --staged .", "Unstage all files").
+			WithPaths(a.stagedPaths(headExists, ":/"), "git restore --staged", "Unstage")
+	}
+	return NewUndoCommand(a.git, "git reset", "Unstage all files").
+		WithPaths(a.stagedPaths(headExists, ":/"), "git reset", "Unstage")
+}
+
+// stagedPaths returns staged paths (relative to the repository root) matching the given pathspecs.
+// Failing to get them is not fatal: the undo just won't have the aggregated summary.
+func (a *AddUndoer) stagedPaths(headExists bool, pathspecs ...string) []string {
+	args := []string{"--cached", "--name-only", "--"}
+	subCmd := "diff"
+	if !headExists {
+		// Without commits everything in the index is staged
+		args = []string{"--cached", "--full-name", "--"}
+		subCmd = "ls-files"
+	}
+
+	output, err := a.git.GitOutput(subCmd, append(args, pathspecs...)...)
+	if err != nil {
+		return nil
+	}
+	return splitLines(output)
 }
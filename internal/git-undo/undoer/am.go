@@ -0,0 +1,80 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AmUndoer handles undoing git am operations: an unfinished am session is aborted,
+// a completed one is undone by resetting the branch to ORIG_HEAD (its tip before the patches were applied).
+type AmUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &AmUndoer{}
+
+// GetUndoCommands returns the commands that would undo the am.
+func (a *AmUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	// A session stopped by a patch that doesn't apply is still in progress
+	status, _ := a.git.GitOutput("status")
+	if strings.Contains(status, "in the middle of an am session") {
+		return []*UndoCommand{NewUndoCommand(a.git,
+			"git am --abort",
+			"Abort the am session and restore the branch as before applying the patches",
+		)}, nil
+	}
+
+	branch, err := a.git.GitOutput("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, &UnsupportedError{
+			Command:    a.originalCmd.FullCommand,
+			Suggestion: GetSuggestion("am"),
+			cause:      errors.New("the patches were applied on a detached HEAD"),
+		}
+	}
+	branch = strings.TrimSpace(branch)
+
+	origHead, err := a.git.GitOutput("rev-parse", "--verify", "--quiet", "ORIG_HEAD")
+	if err != nil {
+		return nil, errors.New("ORIG_HEAD not found, cannot safely undo am")
+	}
+	origHead = strings.TrimSpace(origHead)
+
+	// The latest updates of the branch are the applied patches, made on top of ORIG_HEAD
+	reflog, err := a.git.GitOutput("reflog", "show", "--format=%H%x09%gs", "refs/heads/"+branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the reflog of branch '%s': %w", branch, err)
+	}
+	applied := -1
+	for i, line := range splitLines(reflog) {
+		commit, subject, _ := strings.Cut(line, "\t")
+		if i > 0 && commit == origHead {
+			applied = i
+			break
+		}
+		if !strings.HasPrefix(subject, "am: ") {
+			break
+		}
+	}
+	if applied < 0 {
+		return nil, fmt.Errorf("the last updates of branch '%s' are not patches applied on ORIG_HEAD "+
+			"(no patch was applied, or the branch has changed since)", branch)
+	}
+
+	warnings := []string{fmt.Sprintf("The %d applied patches are dropped", applied)}
+	if output, err := a.git.GitOutput("status", "--porcelain", "--untracked-files=no"); err == nil &&
+		strings.TrimSpace(output) != "" {
+		warnings = append(warnings,
+			"Local changes are kept (the reset is refused if the patches changed the same files)")
+	}
+
+	// --keep moves the branch back keeping local changes (and refuses if the patches touched them)
+	return []*UndoCommand{NewUndoCommand(a.git,
+		"git reset --keep "+origHead,
+		fmt.Sprintf("Restore branch '%s' as before applying the patches (%s)", branch, getShortHash(origHead)),
+		warnings...,
+	)}, nil
+}
@@ -0,0 +1,78 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAmUndoer_Integration tests undoing completed and stopped am sessions.
+func TestAmUndoer_Integration(t *testing.T) {
+	repoDir, patchesDir := t.TempDir(), t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0644))
+		git("add", file)
+		git("commit", "-m", file+": "+content)
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	commit("base.txt", "base")
+	git("switch", "-c", "patches")
+	commit("one.txt", "one")
+	commit("two.txt", "two")
+	git("format-patch", "-q", "main", "-o", patchesDir)
+	git("switch", "main")
+	commit("main.txt", "main")
+	before := git("rev-parse", "HEAD")
+	patches, err := filepath.Glob(filepath.Join(patchesDir, "*.patch"))
+	require.NoError(t, err)
+	amCmd := "git am " + strings.Join(patches, " ")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// Completed am: the branch is reset to its tip before the patches
+	git(append([]string{"am", "-q"}, patches...)...)
+	undoCmds, err := undoer.New(amCmd, gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git reset --keep "+before, undoCmds[0].Command)
+	assert.Equal(t, "The 2 applied patches are dropped", undoCmds[0].Warnings[0])
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+
+	// The branch changed since the am
+	git(append([]string{"am", "-q"}, patches...)...)
+	commit("after.txt", "after")
+	_, err = undoer.New(amCmd, gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "are not patches applied on ORIG_HEAD")
+	git("reset", "--hard", before)
+
+	// Am stopped by a patch that doesn't apply: it's aborted
+	commit("one.txt", "conflicting")
+	cmd := exec.Command("git", append([]string{"am", "-q"}, patches...)...)
+	cmd.Dir = repoDir
+	require.Error(t, cmd.Run())
+	undoCmds, err = undoer.New(amCmd, gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git am --abort", undoCmds[0].Command)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.NotContains(t, git("status"), "am session")
+}
@@ -0,0 +1,198 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ApplyUndoer handles undoing git apply operations.
+type ApplyUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &ApplyUndoer{}
+
+// applyValueOptions are git apply options taking a separate value (e.g. `--directory sub`).
+var applyValueOptions = []string{"-p", "-C", "--directory", "--include", "--exclude", "--whitespace"}
+
+// applyShapingPrefixes are git apply options that change how the patch maps onto files.
+// They're kept when the patch is applied in reverse, so it hits the same lines of the same files.
+var applyShapingPrefixes = []string{
+	"-p", "-C", "--directory", "--include", "--exclude", "--unidiff-zero", "--recount", "--inaccurate-eof",
+	"--ignore-space-change", "--ignore-whitespace",
+}
+
+// applyMode is what git apply changed.
+type applyMode struct {
+	// cached means only the index was patched (--cached).
+	cached bool
+	// index means both the index and the working tree were patched (--index, --3way).
+	index bool
+	// threeWay means a 3-way merge was attempted where the patch didn't apply cleanly (--3way).
+	threeWay bool
+	// reverse means the patch was applied in reverse (-R).
+	reverse bool
+}
+
+// GetUndoCommands returns the commands that would undo the patch application.
+func (a *ApplyUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	var mode applyMode
+	var shaping, patches []string
+	args := a.originalCmd.Args
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--cached":
+			mode.cached = true
+		case arg == "--index":
+			mode.index = true
+		case arg == "--3way" || arg == "-3":
+			mode.threeWay, mode.index = true, true
+		case arg == "-R" || arg == "--reverse":
+			mode.reverse = true
+		case arg == "--reject":
+			return nil, fmt.Errorf("%w for apply --reject: rejected hunks leave the patch partially applied",
+				ErrUndoNotSupported)
+		case arg == "-":
+			return nil, fmt.Errorf("%w for apply of a patch read from stdin", ErrUndoNotSupported)
+		case slices.Contains(applyValueOptions, arg) && i+1 < len(args):
+			if slices.Contains(applyShapingPrefixes, arg) {
+				shaping = append(shaping, arg, args[i+1])
+			}
+			i++
+		case strings.HasPrefix(arg, "-"):
+			if slices.ContainsFunc(applyShapingPrefixes, func(prefix string) bool {
+				return strings.HasPrefix(arg, prefix)
+			}) {
+				shaping = append(shaping, arg)
+			}
+		default:
+			patches = append(patches, arg)
+		}
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("%w for apply of a patch read from stdin", ErrUndoNotSupported)
+	}
+
+	if mode.threeWay {
+		conflicted, err := a.conflictedPaths(shaping, patches)
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicted) > 0 {
+			return a.discardConflicts(conflicted)
+		}
+	}
+
+	// Applying the patch the other way round undoes it (in the same place it was applied to)
+	undoArgs := []string{"apply"}
+	if !mode.reverse {
+		undoArgs = append(undoArgs, "-R")
+	}
+	target := "working tree"
+	switch {
+	case mode.cached:
+		undoArgs = append(undoArgs, "--cached")
+		target = "index"
+	case mode.index:
+		undoArgs = append(undoArgs, "--index")
+		target = "index and working tree"
+	}
+	undoArgs = append(append(undoArgs, shaping...), patches...)
+
+	// The patched files may have been changed since: then the patch doesn't revert cleanly anymore
+	if err := a.git.GitRun(undoArgs[0], append([]string{"--check"}, undoArgs[1:]...)...); err != nil {
+		return nil, fmt.Errorf("cannot undo apply: %s no longer reverts cleanly from the %s (changed since?): %w",
+			strings.Join(patches, ", "), target, err)
+	}
+
+	return []*UndoCommand{NewUndoCommand(a.git,
+		"git "+strings.Join(undoArgs, " "),
+		fmt.Sprintf("Revert %s in the %s", strings.Join(patches, ", "), target),
+	)}, nil
+}
+
+// conflictedPaths returns the paths touched by the patches if any of them is left with conflicts.
+func (a *ApplyUndoer) conflictedPaths(shaping, patches []string) ([]string, error) {
+	unmerged, err := a.git.GitOutput("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicts: %w", err)
+	}
+	if strings.TrimSpace(unmerged) == "" {
+		return nil, nil
+	}
+
+	touched, err := a.touchedPaths(shaping, patches)
+	if err != nil {
+		return nil, err
+	}
+	var conflicted []string
+	for _, path := range strings.Split(unmerged, "\n") {
+		if path = strings.TrimSpace(path); slices.Contains(touched, path) {
+			conflicted = append(conflicted, path)
+		}
+	}
+	if len(conflicted) == 0 {
+		return nil, nil
+	}
+	return touched, nil
+}
+
+// touchedPaths returns the paths the patches touch (new names for renames).
+func (a *ApplyUndoer) touchedPaths(shaping, patches []string) ([]string, error) {
+	numstat, err := a.git.GitOutput("apply", append(append([]string{"--numstat"}, shaping...), patches...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", strings.Join(patches, ", "), err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(numstat, "\n") {
+		// <added>\t<deleted>\t<path>
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(fields) == 3 {
+			paths = append(paths, fields[2])
+		}
+	}
+	return paths, nil
+}
+
+// discardConflicts undoes a 3-way apply that stopped with conflicts: reversing the patch isn't possible then,
+// so the touched paths are brought back to HEAD (and the ones the patch created are removed).
+func (a *ApplyUndoer) discardConflicts(paths []string) ([]*UndoCommand, error) {
+	tracked, err := a.git.GitOutput("ls-tree", append([]string{"--name-only", "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return nil, errors.New("cannot undo apply --3way: failed to read HEAD")
+	}
+	inHead := strings.Split(strings.TrimSpace(tracked), "\n")
+
+	var restore, remove []string
+	for _, path := range paths {
+		if slices.Contains(inHead, path) {
+			restore = append(restore, path)
+		} else {
+			remove = append(remove, path)
+		}
+	}
+
+	warning := "Warning: the 3-way apply stopped with conflicts, so the touched paths are restored from HEAD: " +
+		"changes made to them before the apply are discarded too"
+	var cmds []*UndoCommand
+	if len(restore) > 0 {
+		cmds = append(cmds, NewUndoCommand(a.git,
+			"git checkout HEAD -- "+strings.Join(restore, " "),
+			fmt.Sprintf("Discard the conflicted 3-way apply in %s", strings.Join(restore, ", ")),
+			warning,
+		))
+	}
+	if len(remove) > 0 {
+		cmds = append(cmds, NewUndoCommand(a.git,
+			"git rm -f -q -- "+strings.Join(remove, " "),
+			fmt.Sprintf("Remove %s created by the conflicted 3-way apply", strings.Join(remove, ", ")),
+		))
+	}
+	return cmds, nil
+}
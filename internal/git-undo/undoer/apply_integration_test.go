@@ -0,0 +1,161 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// originalGreeting is the content of greeting.txt the testdata/change.patch applies to.
+// The patch also creates added.txt.
+const originalGreeting = "one\ntwo\nthree\n"
+
+// setupApplyRepo creates a repository with greeting.txt committed and returns its dir
+// and the absolute path of the patch fixture.
+func setupApplyRepo(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeting.txt"), []byte(originalGreeting), 0644))
+	runGit(t, dir, "add", "greeting.txt")
+	runGit(t, dir, "commit", "-m", "init")
+
+	patch, err := filepath.Abs(filepath.Join("testdata", "change.patch"))
+	require.NoError(t, err)
+	return dir, patch
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return string(out)
+}
+
+// undoApply runs the undo commands of the apply command in the repository.
+func undoApply(t *testing.T, dir, applyCmd string) []*undoer.UndoCommand {
+	t.Helper()
+
+	cmdDetails, err := undoer.ParseGitCommand(applyCmd)
+	require.NoError(t, err)
+	applyUndoer := undoer.NewApplyUndoerForTest(githelpers.NewGitHelper(context.Background(), dir), cmdDetails)
+
+	undoCommands, err := applyUndoer.GetUndoCommands()
+	require.NoError(t, err)
+	for _, undoCmd := range undoCommands {
+		require.NoError(t, undoCmd.Exec(), "undo command %q should succeed", undoCmd.Command)
+	}
+	return undoCommands
+}
+
+func TestApplyUndoer_Integration_WorkingTree(t *testing.T) {
+	dir, patch := setupApplyRepo(t)
+	runGit(t, dir, "apply", patch)
+
+	undoCommands := undoApply(t, dir, "git apply "+patch)
+	require.Len(t, undoCommands, 1)
+	assert.Equal(t, "git apply -R "+patch, undoCommands[0].Command)
+
+	content, err := os.ReadFile(filepath.Join(dir, "greeting.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, originalGreeting, string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "added.txt"))
+	assert.Empty(t, runGit(t, dir, "status", "--porcelain"))
+}
+
+func TestApplyUndoer_Integration_Cached(t *testing.T) {
+	dir, patch := setupApplyRepo(t)
+	runGit(t, dir, "apply", "--cached", patch)
+	// Working tree changes made meanwhile must survive the undo
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeting.txt"), []byte("one\ntwo\nthree\nfour\n"), 0644))
+
+	undoCommands := undoApply(t, dir, "git apply --cached "+patch)
+	require.Len(t, undoCommands, 1)
+	assert.Equal(t, "git apply -R --cached "+patch, undoCommands[0].Command)
+
+	assert.Empty(t, runGit(t, dir, "diff", "--cached", "--name-only"), "index should match HEAD again")
+	content, err := os.ReadFile(filepath.Join(dir, "greeting.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree\nfour\n", string(content))
+}
+
+func TestApplyUndoer_Integration_ThreeWayClean(t *testing.T) {
+	dir, patch := setupApplyRepo(t)
+	runGit(t, dir, "apply", "--3way", patch)
+
+	undoCommands := undoApply(t, dir, "git apply --3way "+patch)
+	require.Len(t, undoCommands, 1)
+	assert.Equal(t, "git apply -R --index "+patch, undoCommands[0].Command)
+
+	assert.Empty(t, runGit(t, dir, "status", "--porcelain"))
+	assert.NoFileExists(t, filepath.Join(dir, "added.txt"))
+}
+
+func TestApplyUndoer_Integration_ThreeWayConflict(t *testing.T) {
+	dir, patch := setupApplyRepo(t)
+	// Change the very line the patch changes, so the 3-way merge conflicts
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeting.txt"), []byte("one\nzwei\nthree\n"), 0644))
+	runGit(t, dir, "commit", "-am", "zwei")
+
+	cmd := exec.Command("git", "apply", "--3way", patch)
+	cmd.Dir = dir
+	require.Error(t, cmd.Run(), "the 3-way apply should stop with conflicts")
+	require.Contains(t, runGit(t, dir, "diff", "--name-only", "--diff-filter=U"), "greeting.txt")
+
+	undoCommands := undoApply(t, dir, "git apply --3way "+patch)
+	require.NotEmpty(t, undoCommands)
+	assert.NotEmpty(t, undoCommands[0].Warnings)
+
+	assert.Empty(t, runGit(t, dir, "status", "--porcelain"))
+	content, err := os.ReadFile(filepath.Join(dir, "greeting.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "one\nzwei\nthree\n", string(content))
+}
+
+func TestApplyUndoer_Integration_Reverse(t *testing.T) {
+	dir, patch := setupApplyRepo(t)
+	runGit(t, dir, "apply", "--index", patch)
+	runGit(t, dir, "commit", "-m", "patched")
+	runGit(t, dir, "apply", "-R", patch)
+
+	undoCommands := undoApply(t, dir, "git apply -R "+patch)
+	require.Len(t, undoCommands, 1)
+	assert.Equal(t, "git apply "+patch, undoCommands[0].Command)
+	assert.Empty(t, runGit(t, dir, "status", "--porcelain"))
+}
+
+func TestApplyUndoer_Integration_ChangedSince(t *testing.T) {
+	dir, patch := setupApplyRepo(t)
+	runGit(t, dir, "apply", patch)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeting.txt"), []byte("one\nTWO\nthree\n"), 0644))
+
+	cmdDetails, err := undoer.ParseGitCommand("git apply " + patch)
+	require.NoError(t, err)
+	applyUndoer := undoer.NewApplyUndoerForTest(githelpers.NewGitHelper(context.Background(), dir), cmdDetails)
+	_, err = applyUndoer.GetUndoCommands()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no longer reverts cleanly")
+}
+
+func TestApplyUndoer_Unsupported(t *testing.T) {
+	for _, applyCmd := range []string{"git apply", "git apply -", "git apply --reject fix.patch"} {
+		cmdDetails, err := undoer.ParseGitCommand(applyCmd)
+		require.NoError(t, err)
+		_, err = undoer.NewApplyUndoerForTest(nil, cmdDetails).GetUndoCommands()
+		require.ErrorIs(t, err, undoer.ErrUndoNotSupported, applyCmd)
+	}
+}
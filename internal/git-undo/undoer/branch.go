@@ -2,33 +2,109 @@ package undoer
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 )
 
 var _ Undoer = &BranchUndoer{}
 
-// BranchUndoer handles undoing git branch operations.
+// BranchUndoer handles undoing git branch operations: a created branch is deleted,
+// and deleted branches are re-created at their tips (recorded right before the deletion,
+// or else found in the HEAD reflog).
 type BranchUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	// tips are the recorded tips of the deleted branches, by branch name.
+	tips map[string]string
 }
 
-// GetUndoCommands returns the commands that would undo the branch creation.
-func (b *BranchUndoer) GetUndoCommands() ([]*UndoCommand, error) {
-	// Check if this was a branch deletion operation
-	for _, arg := range b.originalCmd.Args {
-		if arg == "-d" || arg == "-D" || arg == "--delete" {
-			return nil, fmt.Errorf("%w for branch deletion", ErrUndoNotSupported)
+// RecordedTips makes the undo re-create the deleted branches at the given tips (recorded right before the command).
+func (b *BranchUndoer) RecordedTips(tips map[string]string) {
+	b.tips = tips
+}
+
+// BranchDeletionTargets returns the branches deleted by the git branch arguments.
+// It returns false for arguments not deleting branches.
+func BranchDeletionTargets(args []string) ([]string, bool) {
+	if !slices.ContainsFunc(args, func(arg string) bool { return arg == "-d" || arg == "-D" || arg == "--delete" }) {
+		return nil, false
+	}
+
+	var branches []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			branches = append(branches, arg)
 		}
 	}
+	return branches, len(branches) > 0
+}
+
+// GetUndoCommands returns the commands that would undo the branch creation or deletion.
+func (b *BranchUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	if branches, ok := BranchDeletionTargets(b.originalCmd.Args); ok {
+		return b.undoDeletion(branches)
+	}
 
 	branchName := b.originalCmd.getFirstNonFlagArg()
 	if branchName == "" {
 		return nil, fmt.Errorf("no branch name found in command: %s", b.originalCmd.FullCommand)
 	}
 
-	return []*UndoCommand{NewUndoCommand(b.git,
-		fmt.Sprintf("git branch -D %s", branchName),
+	return []*UndoCommand{newDeleteBranchCommand(b.git, branchName,
 		fmt.Sprintf("Delete branch '%s'", branchName),
 	)}, nil
 }
+
+// undoDeletion returns the commands re-creating the deleted branches at their tips.
+func (b *BranchUndoer) undoDeletion(branches []string) ([]*UndoCommand, error) {
+	undoCmds := make([]*UndoCommand, 0, len(branches))
+	for _, branch := range branches {
+		if err := b.git.GitRun("show-ref", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return nil, fmt.Errorf("branch '%s' exists again: nothing to recover", branch)
+		}
+
+		var warnings []string
+		tip, recorded := b.tips[branch]
+		if !recorded {
+			var err error
+			if tip, err = b.lastCheckedOutTip(branch); err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, fmt.Sprintf("The tip of '%s' wasn't recorded before the deletion: "+
+				"it's restored to %s, the commit it was on when last checked out", branch, shortHash(tip)))
+		}
+		if err := b.git.GitRun("cat-file", "-e", tip+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("the tip %s of branch '%s' is gone (garbage collected?)", shortHash(tip), branch)
+		}
+
+		undoCmds = append(undoCmds, NewUndoCommand(b.git,
+			fmt.Sprintf("git branch %s %s", branch, tip),
+			fmt.Sprintf("Recreate deleted branch '%s' at %s", branch, shortHash(tip)),
+			warnings...,
+		))
+	}
+	return undoCmds, nil
+}
+
+// lastCheckedOutTip returns the commit the branch was on when HEAD last moved away from it:
+// the branch's own reflog is deleted along with it, but the HEAD reflog keeps that.
+func (b *BranchUndoer) lastCheckedOutTip(branch string) (string, error) {
+	output, err := b.git.GitOutput("reflog", "show", "--format=%H %gs", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("%w: the tip of deleted branch '%s' is unknown", ErrUndoNotSupported, branch)
+	}
+
+	// Each reflog entry's previous value is the next (older) entry's commit
+	lines := splitLines(output)
+	for i, line := range lines[:max(len(lines)-1, 0)] {
+		_, subject, _ := strings.Cut(line, " ")
+		if strings.HasPrefix(subject, "checkout: moving from "+branch+" to ") {
+			tip, _, _ := strings.Cut(lines[i+1], " ")
+			return tip, nil
+		}
+	}
+	return "", fmt.Errorf("%w: the tip of deleted branch '%s' wasn't recorded and isn't in the HEAD reflog",
+		ErrUndoNotSupported, branch)
+}
@@ -0,0 +1,75 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBranchUndoer_Deletion tests recreating deleted branches at their recorded tips or the ones in the HEAD reflog.
+func TestBranchUndoer_Deletion(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	git("commit", "--allow-empty", "-m", "base")
+	git("switch", "-c", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "feature.txt"), []byte("feature"), 0644))
+	git("add", "feature.txt")
+	git("commit", "-m", "feature")
+	tip := git("rev-parse", "HEAD")
+	git("switch", "main")
+	git("branch", "other")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// Without the recorded tip, the one HEAD left the branch at is used
+	git("branch", "-D", "feature")
+	undoCmds, err := undoer.New("git branch -D feature", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git branch feature "+tip, undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "wasn't recorded")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, tip, git("rev-parse", "feature"))
+
+	// Recorded tips are used as they are, for every deleted branch
+	base := git("rev-parse", "main")
+	git("branch", "-D", "feature", "other")
+	branchUndoer, ok := undoer.New("git branch -D feature other", gitExec).(*undoer.BranchUndoer)
+	require.True(t, ok)
+	branchUndoer.RecordedTips(map[string]string{"feature": tip, "other": base})
+	undoCmds, err = branchUndoer.GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 2)
+	assert.Empty(t, undoCmds[1].Warnings)
+	for _, undoCmd := range undoCmds {
+		require.NoError(t, undoCmd.Exec())
+	}
+	assert.Equal(t, base, git("rev-parse", "other"))
+
+	// A branch that was never checked out and wasn't recorded can't be recovered
+	git("branch", "-D", "other")
+	_, err = undoer.New("git branch -D other", gitExec).GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+
+	// Neither can a branch that exists again
+	_, err = undoer.New("git branch -d feature", gitExec).GetUndoCommands()
+	require.Error(t, err)
+}
@@ -19,8 +19,7 @@ func (c *CheckoutUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 	for i, arg := range c.originalCmd.Args {
 		if (arg == "-b" || arg == "--branch") && i+1 < len(c.originalCmd.Args) {
 			branchName := c.originalCmd.Args[i+1]
-			return []*UndoCommand{NewUndoCommand(c.git,
-				fmt.Sprintf("git branch -D %s", branchName),
+			return []*UndoCommand{newDeleteBranchCommand(c.git, branchName,
 				fmt.Sprintf("Delete branch '%s' created by checkout -b", branchName),
 			)}, nil
 		}
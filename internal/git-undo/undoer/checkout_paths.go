@@ -0,0 +1,51 @@
+package undoer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CheckoutPathsUndoer handles undoing `git checkout [<tree-ish>] -- <paths>`: the working tree changes
+// it overwrote are restored from the snapshot taken right before it (see TargetSnapshot).
+type CheckoutPathsUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+
+	recordedSnapshot
+}
+
+var _ Undoer = &CheckoutPathsUndoer{}
+
+// GetUndoCommands returns the commands that would undo the checkout of the paths.
+func (c *CheckoutPathsUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	paths := c.originalCmd.Args[slices.Index(c.originalCmd.Args, "--")+1:]
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths found in checkout command: %s", c.originalCmd.FullCommand)
+	}
+	if c.snapshot == nil {
+		return nil, fmt.Errorf("%w: the changes of %s overwritten by checkout cannot be recovered "+
+			"(no snapshot was taken before it: the shell hook takes one when there are changes to lose)",
+			ErrUndoNotSupported, summarizePaths(paths))
+	}
+
+	// Files edited since the checkout would lose these edits
+	changed, err := c.git.GitOutput("diff", append([]string{"--name-only", "--"}, c.snapshot.Files...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the files for changes since the checkout: %w", err)
+	}
+	if edited := splitLines(changed); len(edited) > 0 {
+		return nil, fmt.Errorf("cannot restore the overwritten changes: %s changed since the checkout",
+			summarizePaths(edited))
+	}
+
+	worktree, err := c.git.GitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the working tree: %w", err)
+	}
+	return []*UndoCommand{restoreSnapshotCommand(c.git, c.snapshot, strings.TrimSpace(worktree),
+		fmt.Sprintf("Restore the changes of %s overwritten by checkout (snapshot %s)",
+			summarizePaths(c.snapshot.Files), c.snapshot.ID),
+	)}, nil
+}
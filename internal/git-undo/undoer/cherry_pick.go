@@ -11,6 +11,8 @@ type CherryPickUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	pushGuard
 }
 
 var _ Undoer = &CherryPickUndoer{}
@@ -83,6 +85,11 @@ func (c *CherryPickUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		}
 	}
 
+	// A pushed commit is reverted instead of being removed from the published history
+	if remotes := c.pushedRemotes(c.git, currentHead); len(remotes) > 0 {
+		return []*UndoCommand{c.revertPushed(c.git, currentHead, "cherry-pick commit", remotes)}, nil
+	}
+
 	// Get parent commit to reset to
 	parentCommit, err := c.git.GitOutput("rev-parse", "HEAD~1")
 	if err != nil {
@@ -2,14 +2,18 @@ package undoer
 
 import (
 	"fmt"
+	"strings"
 )
 
 // CleanUndoer handles undoing git clean operations.
-// Note: git clean removes untracked files, so undo requires proactive backup.
+// Note: git clean removes untracked files, so undo requires proactive backup: the files are restored
+// from the snapshot taken right before the command (see TargetSnapshot).
 type CleanUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	recordedSnapshot
 }
 
 var _ Undoer = &CleanUndoer{}
@@ -23,6 +27,26 @@ func (c *CleanUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("%w: git clean permanently removes untracked files that cannot be recovered",
-		ErrUndoNotSupported)
+	if c.snapshot == nil {
+		return nil, fmt.Errorf("%w: git clean permanently removes untracked files that cannot be recovered "+
+			"(no snapshot was taken before it: the shell hook takes one)", ErrUndoNotSupported)
+	}
+
+	if missing := c.snapshot.Missing(); len(missing) == len(c.snapshot.Files) {
+		return nil, fmt.Errorf("cannot restore the removed files: snapshot %s has none of them left", c.snapshot.ID)
+	}
+
+	worktree, err := c.git.GitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the working tree: %w", err)
+	}
+	worktree = strings.TrimSpace(worktree)
+	if existing := c.snapshot.Existing(worktree); len(existing) > 0 {
+		return nil, fmt.Errorf("cannot restore the removed files: some exist again (%s), move them away first",
+			summarizePaths(existing))
+	}
+
+	return []*UndoCommand{restoreSnapshotCommand(c.git, c.snapshot, worktree,
+		fmt.Sprintf("Restore %s removed by git clean (snapshot %s)", summarizePaths(c.snapshot.Files), c.snapshot.ID),
+	)}, nil
 }
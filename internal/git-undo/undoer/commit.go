@@ -3,24 +3,131 @@ package undoer
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
 var _ Undoer = &CommitUndoer{}
 
+// ErrFixupConsumed is returned when the fixup/squash commit being undone is not HEAD anymore
+// (most likely it was consumed by `git rebase --autosquash`).
+var ErrFixupConsumed = fmt.Errorf("%w: the fixup commit is gone", ErrUndoNotSupported)
+
+// ErrNotAmend is returned when restoring the previous message only is asked for a commit that is not an amend.
+var ErrNotAmend = errors.New("restoring the previous message only applies to undoing git commit --amend")
+
+// ErrNotCommit is returned when a commit undo mode is asked for a command that is not a commit.
+var ErrNotCommit = errors.New("--soft, --unstage and --hard only apply to undoing git commit")
+
+// CommitMode is what undoing a commit does with the changes of the commit.
+type CommitMode string
+
+const (
+	// CommitModeSoft keeps the changes of the undone commit staged (the default).
+	CommitModeSoft CommitMode = "soft"
+	// CommitModeUnstage leaves the changes of the undone commit in the working tree, unstaged.
+	CommitModeUnstage CommitMode = "unstage"
+	// CommitModeHard discards the undone commit along with its changes.
+	CommitModeHard CommitMode = "hard"
+)
+
 // CommitUndoer handles undoing git commit operations.
 type CommitUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	// amended is the commit replaced by the amend (see TargetAmended).
+	amended string
+	// messageOnly makes the undo of an amend restore the previous message only (see RestoreMessageOnly).
+	messageOnly bool
+	// mode is what the undo does with the changes of the commit (see SetMode).
+	mode CommitMode
+	// commit is the commit the command created (see TargetCommit).
+	commit string
+
+	pushGuard
+}
+
+// TargetCommit makes the undo remove the given commit (the one the command created) even when it's not HEAD
+// anymore: an older commit is removed by rebasing the later ones onto its parent, or reverted once pushed.
+func (c *CommitUndoer) TargetCommit(commit string) {
+	c.commit = commit
+}
+
+// UndoneCommit returns the commit the undo removes: the recorded one when it's older than HEAD, HEAD otherwise.
+func (c *CommitUndoer) UndoneCommit() string {
+	if c.isOlderCommit() {
+		return c.commit
+	}
+	return "HEAD"
+}
+
+// TargetAmended makes the undo of `git commit --amend` restore the given commit (the one the amend replaced)
+// instead of HEAD@{1}, which later commands move.
+func (c *CommitUndoer) TargetAmended(commit string) {
+	c.amended = commit
+}
+
+// RestoreMessageOnly makes the undo of `git commit --amend` restore only the message of the commit
+// the amend replaced: the changes the amend added stay committed.
+func (c *CommitUndoer) RestoreMessageOnly() error {
+	if !c.isAmend() {
+		return ErrNotAmend
+	}
+	if c.mode != "" && c.mode != CommitModeSoft {
+		return errors.New("restoring the previous message only keeps the amended changes committed: " +
+			"it can't be combined with --unstage or --hard")
+	}
+	c.messageOnly = true
+	return nil
+}
+
+// SetMode sets what the undo does with the changes of the commit: keeps them staged (the default),
+// leaves them unstaged or discards them.
+func (c *CommitUndoer) SetMode(mode CommitMode) error {
+	switch mode {
+	case CommitModeSoft, CommitModeUnstage, CommitModeHard:
+	default:
+		return fmt.Errorf("unknown commit undo mode: %s", mode)
+	}
+	if c.messageOnly && mode != CommitModeSoft {
+		return errors.New("restoring the previous message only keeps the amended changes committed: " +
+			"it can't be combined with --unstage or --hard")
+	}
+	c.mode = mode
+	return nil
 }
 
 // GetUndoCommands returns the commands that would undo the commit.
 func (c *CommitUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	if c.isAmend() {
+		return c.undoAmend()
+	}
+	if c.isOlderCommit() {
+		return c.undoOlderCommit()
+	}
+
 	if err := c.git.GitRun("rev-parse", "HEAD~1"); err != nil {
 		return nil, errors.New("this appears to be the initial commit and cannot be undone this way")
 	}
 
+	// Fixup/squash commits are undone like regular ones, unless an autosquash rebase already consumed them
+	if kind, _, ok := (&githelpers.GitCommand{Name: c.originalCmd.SubCommand, Args: c.originalCmd.Args}).Fixup(); ok {
+		subject, err := c.git.GitOutput("log", "-1", "--format=%s")
+		if err == nil && !strings.HasPrefix(subject, githelpers.FixupSubjectPrefix(kind)) {
+			return nil, fmt.Errorf("%w: HEAD is not a %s commit (was it squashed by `git rebase --autosquash`?)",
+				ErrFixupConsumed, kind)
+		}
+	}
+
+	// A pushed commit is reverted instead of being removed from the published history
+	if remotes := c.pushedRemotes(c.git, "HEAD"); len(remotes) > 0 {
+		return []*UndoCommand{c.revertPushed(c.git, "HEAD", "commit", remotes)}, nil
+	}
+
 	// Check if this is a merge commit
 	if err := c.git.GitRun("rev-parse", "-q", "--verify", "HEAD^2"); err == nil {
 		return []*UndoCommand{NewUndoCommand(c.git,
@@ -32,27 +139,161 @@ func (c *CommitUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 	// Get the commit message to check if it was an amended commit
 	commitMsg, err := c.git.GitOutput("log", "-1", "--pretty=%B")
 	if err == nil && strings.Contains(commitMsg, "[amend]") {
+		resetCmd, _, warnings := c.reset("HEAD@{1}")
 		return []*UndoCommand{NewUndoCommand(c.git,
-			"git reset --soft HEAD@{1}",
+			resetCmd,
 			"Undo amended commit by resetting to previous HEAD",
+			warnings...,
 		)}, nil
 	}
 
+	resetCmd, changes, warnings := c.reset("HEAD~1")
+
 	// Check if the commit is tagged
 	tagOutput, err := c.git.GitOutput("tag", "--points-at", "HEAD")
 	if err == nil && tagOutput != "" {
+		warnings = append([]string{fmt.Sprintf(
+			"Warning: The commit being undone has the following tags: %s\nThese tags will now point to the parent commit.",
+			tagOutput,
+		)}, warnings...)
+	}
+
+	return []*UndoCommand{NewUndoCommand(c.git,
+		resetCmd,
+		"Undo commit "+changes,
+		warnings...,
+	)}, nil
+}
+
+// reset returns the command resetting HEAD to the target according to the mode, what it does with the changes
+// of the undone commit (for the description) and the warnings about it.
+func (c *CommitUndoer) reset(target string) (string, string, []string) {
+	switch c.mode {
+	case CommitModeUnstage:
+		return "git reset " + target, "leaving its changes unstaged", nil
+	case CommitModeHard:
+		// Unlike --hard, --keep refuses to reset files having local changes instead of discarding them
+		return "git reset --keep " + target, "and discard its changes", []string{
+			"The changes are discarded from the working tree (the undone commit is kept for redo under refs/git-undo/keep/)",
+		}
+	case CommitModeSoft:
+		fallthrough
+	default:
+		return "git reset --soft " + target, "while keeping changes staged", nil
+	}
+}
+
+// isOlderCommit reports whether the commit being undone is not HEAD but one of its ancestors
+// (a commit rewritten since or on another branch isn't undone this way).
+func (c *CommitUndoer) isOlderCommit() bool {
+	if c.commit == "" || c.isAmend() {
+		return false
+	}
+	head, err := c.git.GitOutput("rev-parse", "HEAD")
+	if err != nil || strings.TrimSpace(head) == c.commit {
+		return false
+	}
+	return c.git.GitRun("merge-base", "--is-ancestor", c.commit, "HEAD") == nil
+}
+
+// undoOlderCommit removes a commit made before HEAD: the later commits are rebased onto its parent,
+// unless the commit is already pushed (see ForcePushed) or is a merge, in which case it's reverted
+// by a new commit so the history isn't rewritten. The changes of the commit are dropped either way:
+// the rebase, which loses them, requires CommitModeHard.
+func (c *CommitUndoer) undoOlderCommit() ([]*UndoCommand, error) {
+	if c.mode == CommitModeSoft || c.mode == CommitModeUnstage {
+		return nil, fmt.Errorf("%w: the changes of a commit older than HEAD can't be kept staged or unstaged, "+
+			"they're dropped with it", ErrUndoNotSupported)
+	}
+	if err := c.git.GitRun("rev-parse", "-q", "--verify", c.commit+"^"); err != nil {
+		return nil, fmt.Errorf("%w: the initial commit can't be removed from under later commits",
+			ErrUndoNotSupported)
+	}
+
+	short := shortHash(c.commit)
+	subject, _ := c.git.GitOutput("log", "-1", "--format=%s", c.commit)
+	later, _ := c.git.GitOutput("rev-list", "--count", c.commit+"..HEAD")
+
+	if remotes := c.pushedRemotes(c.git, c.commit); len(remotes) > 0 {
+		return []*UndoCommand{c.revertPushed(c.git, c.commit, "commit", remotes)}, nil
+	}
+	if err := c.git.GitRun("rev-parse", "-q", "--verify", c.commit+"^2"); err == nil {
+		revertCmd := NewUndoCommand(c.git,
+			"git revert --no-edit -m 1 "+c.commit,
+			fmt.Sprintf("Revert older merge commit %s (%q)", short, strings.TrimSpace(subject)),
+			"The commit is a merge: it's reverted by a new commit instead of being removed from the history",
+			"Conflicts stop the revert: resolve them and run `git revert --continue`, or `git revert --abort`",
+		)
+		revertCmd.Strategy = StrategyRevert
+		return []*UndoCommand{revertCmd}, nil
+	}
+
+	if c.mode != CommitModeHard {
+		return nil, fmt.Errorf("%w: removing commit %s (%q) older than HEAD drops its changes with it: "+
+			"run again with --hard to drop them", ErrUndoNotSupported, short, strings.TrimSpace(subject))
+	}
+
+	rebaseCmd := fmt.Sprintf("git rebase --autostash --onto %s^ %s", c.commit, c.commit)
+	if merges, err := c.git.GitOutput("rev-list", "--merges", c.commit+"..HEAD"); err == nil &&
+		strings.TrimSpace(merges) != "" {
+		// Without it the later merges would be flattened
+		rebaseCmd = fmt.Sprintf("git rebase --autostash --rebase-merges --onto %s^ %s", c.commit, c.commit)
+	}
+	undoCmd := NewUndoCommand(c.git,
+		rebaseCmd,
+		fmt.Sprintf("Remove older commit %s (%q) by rebasing the later commits onto its parent",
+			short, strings.TrimSpace(subject)),
+		fmt.Sprintf("History is rewritten: the %s later commit(s) get new hashes", strings.TrimSpace(later)),
+		"Conflicts stop the rebase: resolve them and run `git rebase --continue`, or `git rebase --abort`",
+		fmt.Sprintf("Redo can't put the commit back under the later ones: "+
+			"bring it back with `git cherry-pick %s`", short),
+	)
+	undoCmd.Strategy = StrategyRebase
+	return []*UndoCommand{undoCmd}, nil
+}
+
+// isAmend reports whether the commit command amended HEAD (fixup commits amending a target are new commits).
+func (c *CommitUndoer) isAmend() bool {
+	return slices.Contains(c.originalCmd.Args, "--amend")
+}
+
+// undoAmend brings back the commit the amend replaced: entirely (the changes the amend added are left staged),
+// or just its message (see RestoreMessageOnly). The message coming back is shown in the description.
+func (c *CommitUndoer) undoAmend() ([]*UndoCommand, error) {
+	previous := c.amended
+	if previous == "" {
+		previous = "HEAD@{1}"
+	}
+	// Both the previous commit and the previous message replace the amended commit
+	if remotes := c.pushedRemotes(c.git, "HEAD"); len(remotes) > 0 {
+		return nil, pushedError(1, remotes)
+	}
+	subject, err := c.git.GitOutput("log", "-1", "--format=%s", previous)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the commit before the amend (%s) is gone", ErrUndoNotSupported, previous)
+	}
+	subject = strings.TrimSpace(subject)
+
+	if c.messageOnly {
 		return []*UndoCommand{NewUndoCommand(c.git,
-			"git reset --soft HEAD~1",
-			"Undo commit while keeping changes staged",
-			fmt.Sprintf(
-				"Warning: The commit being undone has the following tags: %s\nThese tags will now point to the parent commit.",
-				tagOutput,
-			),
+			"git commit --amend --only --allow-empty --no-verify -C "+previous,
+			fmt.Sprintf("Restore the message of the commit before the amend (%q), keeping the amended changes",
+				subject),
 		)}, nil
 	}
-
+	resetCmd, _, warnings := c.reset(previous)
+	changes := "keeping the amended changes staged"
+	switch c.mode {
+	case CommitModeUnstage:
+		changes = "leaving the amended changes unstaged"
+	case CommitModeHard:
+		changes = "discarding the amended changes"
+	case CommitModeSoft:
+	}
 	return []*UndoCommand{NewUndoCommand(c.git,
-		"git reset --soft HEAD~1",
-		"Undo commit while keeping changes staged",
+		resetCmd,
+		fmt.Sprintf("Restore the commit before the amend (%q), %s", subject, changes),
+		append(warnings,
+			"To restore only the previous message and keep the amended changes committed: git undo --message-only")...,
 	)}, nil
 }
@@ -0,0 +1,94 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommitUndoer_OlderCommit_Integration tests undoing commits that are not HEAD anymore.
+func TestCommitUndoer_OlderCommit_Integration(t *testing.T) {
+	repoDir, remoteDir := t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commitFile := func(name string) string {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(name), 0600))
+		git(repoDir, "add", name)
+		git(repoDir, "commit", "-m", "add "+name)
+		return git(repoDir, "rev-parse", "HEAD")
+	}
+
+	git(remoteDir, "init", "--bare")
+	git(repoDir, "init", "-b", "main")
+	git(repoDir, "config", "user.email", "test@example.com")
+	git(repoDir, "config", "user.name", "Test User")
+	git(repoDir, "remote", "add", "origin", remoteDir)
+	commitFile("base.txt")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	commitUndoer := func(commit string) *undoer.CommitUndoer {
+		u, ok := undoer.New("git commit -m older", gitExec).(*undoer.CommitUndoer)
+		require.True(t, ok)
+		u.TargetCommit(commit)
+		return u
+	}
+
+	// An unpushed older commit is removed by rebasing the later ones onto its parent, which drops its changes:
+	// it takes --hard
+	older := commitFile("older.txt")
+	commitFile("later.txt")
+	u := commitUndoer(older)
+	assert.Equal(t, older, u.UndoneCommit())
+	_, err := u.GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+	assert.ErrorContains(t, err, "run again with --hard")
+	require.NoError(t, u.SetMode(undoer.CommitModeHard))
+	undoCmds, err := u.GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Contains(t, undoCmds[0].Command, "git rebase --autostash --onto "+older+"^ "+older)
+	assert.Contains(t, undoCmds[0].Warnings[0], "History is rewritten: the 1 later commit(s)")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.NoFileExists(t, filepath.Join(repoDir, "older.txt"))
+	assert.FileExists(t, filepath.Join(repoDir, "later.txt"))
+	assert.Equal(t, "add later.txt", git(repoDir, "log", "-1", "--format=%s"))
+
+	// The changes of an older commit can't be kept
+	older = commitFile("kept.txt")
+	commitFile("after-kept.txt")
+	u = commitUndoer(older)
+	require.NoError(t, u.SetMode(undoer.CommitModeUnstage))
+	_, err = u.GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+
+	// A pushed older commit is reverted instead
+	git(repoDir, "push", "-u", "origin", "main")
+	undoCmds, err = commitUndoer(older).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git revert --no-edit "+older, undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "already pushed (to origin/main)")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.NoFileExists(t, filepath.Join(repoDir, "kept.txt"))
+	assert.Equal(t, git(repoDir, "rev-parse", "origin/main"), git(repoDir, "rev-parse", "HEAD^"))
+
+	// HEAD itself is undone as usual
+	u = commitUndoer(git(repoDir, "rev-parse", "HEAD"))
+	assert.Equal(t, "HEAD", u.UndoneCommit())
+	undoCmds, err = u.GetUndoCommands()
+	require.NoError(t, err)
+	assert.Equal(t, "git reset --soft HEAD~1", undoCmds[0].Command)
+}
@@ -0,0 +1,167 @@
+package undoer_test
+
+import (
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitUndoer_Fixup(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		subject     string
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			name:        "fixup commit is HEAD",
+			command:     "git commit --fixup=abc123",
+			subject:     "fixup! Add feature",
+			expectedCmd: "git reset --soft HEAD~1",
+		},
+		{
+			name:        "squash commit is HEAD",
+			command:     "git commit -m more --squash=abc123",
+			subject:     "squash! Add feature",
+			expectedCmd: "git reset --soft HEAD~1",
+		},
+		{
+			name:        "fixup commit was consumed by autosquash",
+			command:     "git commit --fixup=abc123",
+			subject:     "Add feature",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := new(MockGitExec).notPushed()
+			mockGit.On("GitRun", "rev-parse", "HEAD~1").Return(nil)
+			mockGit.On("GitOutput", "log", "-1", "--format=%s").Return(tt.subject, nil)
+			if !tt.expectError {
+				mockGit.On("GitRun", "rev-parse", "-q", "--verify", "HEAD^2").Return(assert.AnError)
+				mockGit.On("GitOutput", "log", "-1", "--pretty=%B").Return(tt.subject, nil)
+				mockGit.On("GitOutput", "tag", "--points-at", "HEAD").Return("", nil)
+			}
+
+			cmdDetails, err := undoer.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+
+			undoCmds, err := undoer.NewCommitUndoerForTest(mockGit, cmdDetails).GetUndoCommands()
+			if tt.expectError {
+				require.ErrorIs(t, err, undoer.ErrFixupConsumed)
+				require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, undoCmds, 1)
+				assert.Equal(t, tt.expectedCmd, undoCmds[0].Command)
+			}
+
+			mockGit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCommitUndoer_Amend(t *testing.T) {
+	tests := []struct {
+		name        string
+		amended     string
+		messageOnly bool
+		expectedCmd string
+	}{
+		{
+			name:        "recorded amended commit",
+			amended:     "abc123",
+			expectedCmd: "git reset --soft abc123",
+		},
+		{
+			name:        "no recorded commit falls back to the reflog",
+			expectedCmd: "git reset --soft HEAD@{1}",
+		},
+		{
+			name:        "message only",
+			amended:     "abc123",
+			messageOnly: true,
+			expectedCmd: "git commit --amend --only --allow-empty --no-verify -C abc123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := tt.amended
+			if previous == "" {
+				previous = "HEAD@{1}"
+			}
+			mockGit := new(MockGitExec).notPushed()
+			mockGit.On("GitOutput", "log", "-1", "--format=%s", previous).Return("Old message\n", nil)
+
+			cmdDetails, err := undoer.ParseGitCommand("git commit --amend -m 'New message'")
+			require.NoError(t, err)
+			u := undoer.NewCommitUndoerForTest(mockGit, cmdDetails)
+			if tt.amended != "" {
+				u.TargetAmended(tt.amended)
+			}
+			if tt.messageOnly {
+				require.NoError(t, u.RestoreMessageOnly())
+			}
+
+			undoCmds, err := u.GetUndoCommands()
+			require.NoError(t, err)
+			require.Len(t, undoCmds, 1)
+			assert.Equal(t, tt.expectedCmd, undoCmds[0].Command)
+			assert.Contains(t, undoCmds[0].Description, `"Old message"`, "the message coming back is shown")
+			mockGit.AssertExpectations(t)
+		})
+	}
+
+	cmdDetails, err := undoer.ParseGitCommand("git commit -m 'Not an amend'")
+	require.NoError(t, err)
+	require.ErrorIs(t, undoer.NewCommitUndoerForTest(new(MockGitExec), cmdDetails).RestoreMessageOnly(),
+		undoer.ErrNotAmend)
+}
+
+func TestCommitUndoer_Modes(t *testing.T) {
+	tests := []struct {
+		mode         undoer.CommitMode
+		expectedCmd  string
+		expectedDesc string
+		warns        bool
+	}{
+		{undoer.CommitModeSoft, "git reset --soft HEAD~1", "Undo commit while keeping changes staged", false},
+		{undoer.CommitModeUnstage, "git reset HEAD~1", "Undo commit leaving its changes unstaged", false},
+		{undoer.CommitModeHard, "git reset --keep HEAD~1", "Undo commit and discard its changes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			mockGit := new(MockGitExec).notPushed()
+			mockGit.On("GitRun", "rev-parse", "HEAD~1").Return(nil)
+			mockGit.On("GitRun", "rev-parse", "-q", "--verify", "HEAD^2").Return(assert.AnError)
+			mockGit.On("GitOutput", "log", "-1", "--pretty=%B").Return("Add feature", nil)
+			mockGit.On("GitOutput", "tag", "--points-at", "HEAD").Return("", nil)
+
+			cmdDetails, err := undoer.ParseGitCommand("git commit -m 'Add feature'")
+			require.NoError(t, err)
+			commitUndoer := undoer.NewCommitUndoerForTest(mockGit, cmdDetails)
+			require.NoError(t, commitUndoer.SetMode(tt.mode))
+
+			undoCmds, err := commitUndoer.GetUndoCommands()
+			require.NoError(t, err)
+			require.Len(t, undoCmds, 1)
+			assert.Equal(t, tt.expectedCmd, undoCmds[0].Command)
+			assert.Equal(t, tt.expectedDesc, undoCmds[0].Description)
+			assert.Equal(t, tt.warns, len(undoCmds[0].Warnings) > 0)
+		})
+	}
+
+	// Restoring only the message of an amend keeps the amended changes committed
+	cmdDetails, err := undoer.ParseGitCommand("git commit --amend -m 'New message'")
+	require.NoError(t, err)
+	commitUndoer := undoer.NewCommitUndoerForTest(new(MockGitExec), cmdDetails)
+	require.NoError(t, commitUndoer.RestoreMessageOnly())
+	require.Error(t, commitUndoer.SetMode(undoer.CommitModeHard))
+	require.Error(t, commitUndoer.SetMode("mixed"))
+}
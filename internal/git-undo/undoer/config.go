@@ -0,0 +1,171 @@
+package undoer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ConfigUndoer handles undoing git config set/unset operations: the values the key had before the command
+// are restored. They can't be known afterward, so they're recorded before the command runs (see RecordedValues).
+type ConfigUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+
+	// recorded tells whether the values of the key before the command were recorded.
+	recorded bool
+	// key, values and set are the recorded key, its values and whether it was set at all.
+	key    string
+	values []string
+	set    bool
+}
+
+var _ Undoer = &ConfigUndoer{}
+
+// configValueFlags are the git config options taking the next argument as their value.
+var configValueFlags = []string{"-f", "--file", "--blob", "--type", "--default", "--comment", "--value"}
+
+// configScopeFlags are the git config options selecting the config file to change.
+var configScopeFlags = []string{"--global", "--system", "--local", "--worktree"}
+
+// configSectionActions change whole sections (or open an editor): they're not undone.
+var configSectionActions = []string{"--rename-section", "--remove-section", "-e", "--edit",
+	"rename-section", "remove-section", "edit"}
+
+// RecordedValues makes the undo restore the given values of the key (recorded right before the command).
+// A key that wasn't set is unset by the undo.
+func (c *ConfigUndoer) RecordedValues(key string, values []string, set bool) {
+	c.recorded = true
+	c.key, c.values, c.set = key, values, set
+}
+
+// ConfigTarget returns the config file options (e.g. --global) and the key changed by the git config
+// arguments. It returns false for arguments not changing a single key (e.g. reading one, or removing a section).
+func ConfigTarget(args []string) ([]string, string, bool) {
+	var scope, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case slices.Contains(configSectionActions, arg) || arg == "--blob" || strings.HasPrefix(arg, "--blob="):
+			return nil, "", false
+		case slices.Contains(configScopeFlags, arg) || strings.HasPrefix(arg, "--file="):
+			scope = append(scope, arg)
+		case arg == "-f" || arg == "--file":
+			if i+1 < len(args) {
+				scope = append(scope, arg, args[i+1])
+			}
+			i++
+		case slices.Contains(configValueFlags, arg):
+			i++
+		case !strings.HasPrefix(arg, "-"):
+			positional = append(positional, arg)
+		}
+	}
+
+	// The subcommand syntax (e.g. `git config set user.name "Jane"`)
+	if len(positional) > 0 && (positional[0] == "set" || positional[0] == "unset") {
+		positional = positional[1:]
+	} else if len(positional) < 2 && !slices.ContainsFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--unset")
+	}) {
+		// A key without a value is read
+		return nil, "", false
+	}
+
+	if len(positional) == 0 || !strings.Contains(positional[0], ".") {
+		return nil, "", false
+	}
+	return scope, positional[0], true
+}
+
+// GetUndoCommands returns the commands that would undo the config change.
+func (c *ConfigUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	scope, key, ok := ConfigTarget(c.originalCmd.Args)
+	if !ok {
+		return nil, c.unsupported("only changes of a single key can be undone")
+	}
+	if !c.recorded || !strings.EqualFold(c.key, key) {
+		return nil, c.unsupported("the previous value of %s wasn't recorded "+
+			"(the shell hook records it right before git config runs)", key)
+	}
+
+	configCmd := "git config "
+	for _, arg := range scope {
+		configCmd += quotePath(arg) + " "
+	}
+
+	var warnings []string
+	for _, shared := range []string{"--global", "--system"} {
+		if slices.Contains(scope, shared) {
+			warning := fmt.Sprintf("This changes your %s git config", strings.TrimPrefix(shared, "--"))
+			warnings = append(warnings, warning)
+		}
+	}
+
+	if !c.set {
+		if _, err := c.git.GitOutput("config", append(slices.Clone(scope), "--get-all", key)...); err != nil {
+			return nil, fmt.Errorf("%s is not set: nothing to undo", key)
+		}
+	}
+	undoCmds := configRestoreCommands(c.git, configCmd, key, c.values, c.set, warnings...)
+	if outside := configOutside(scope); outside != "" {
+		for _, undoCmd := range undoCmds {
+			undoCmd.outside = outside
+		}
+	}
+	return undoCmds, nil
+}
+
+// configOutside returns the config file outside of the repository the config options select
+// (nothing for the config of the repository).
+func configOutside(scope []string) string {
+	for i, arg := range scope {
+		switch {
+		case arg == "--global" || arg == "--system":
+			return "your " + strings.TrimPrefix(arg, "--") + " git config"
+		case arg == "-f" || arg == "--file":
+			return "the config file " + scope[i+1]
+		case strings.HasPrefix(arg, "--file="):
+			return "the config file " + strings.TrimPrefix(arg, "--file=")
+		}
+	}
+	return ""
+}
+
+// configRestoreCommands returns the commands (starting with configCmd, e.g. "git config --global ")
+// setting the values of the key back, or unsetting it when it wasn't set.
+func configRestoreCommands(git GitExec, configCmd, key string, values []string, set bool,
+	warnings ...string) []*UndoCommand {
+	if !set {
+		return []*UndoCommand{NewUndoCommand(git,
+			configCmd+"--unset-all "+key,
+			fmt.Sprintf("Unset %s (it wasn't set before)", key),
+			warnings...,
+		)}
+	}
+
+	// All values are replaced by the first one, then the others are added back
+	undoCmds := []*UndoCommand{NewUndoCommand(git,
+		configCmd+"--replace-all "+key+" "+quotePath(values[0]),
+		fmt.Sprintf("Restore %s to %q", key, values[0]),
+		warnings...,
+	)}
+	for _, value := range values[1:] {
+		undoCmds = append(undoCmds, NewUndoCommand(git,
+			configCmd+"--add "+key+" "+quotePath(value),
+			fmt.Sprintf("Add %q back to %s", value, key),
+		))
+	}
+	return undoCmds
+}
+
+// unsupported returns the error of a config change whose undo isn't known,
+// with the guidance for a manual recovery.
+func (c *ConfigUndoer) unsupported(format string, args ...any) error {
+	return &UnsupportedError{
+		Command:    c.originalCmd.FullCommand,
+		Suggestion: GetSuggestion("config"),
+		cause:      fmt.Errorf(format, args...),
+	}
+}
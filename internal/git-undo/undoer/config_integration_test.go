@@ -0,0 +1,125 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigTarget tests finding the config scope and the key changed by git config arguments.
+func TestConfigTarget(t *testing.T) {
+	tests := []struct {
+		args  string
+		scope []string
+		key   string
+		ok    bool
+	}{
+		{"user.name Jane", nil, "user.name", true},
+		{"--global user.name Jane", []string{"--global"}, "user.name", true},
+		{"--local --unset user.name", []string{"--local"}, "user.name", true},
+		{"--unset-all remote.origin.fetch", nil, "remote.origin.fetch", true},
+		{"-f custom.cfg --add core.foo bar", []string{"-f", "custom.cfg"}, "core.foo", true},
+		{"--type bool core.bare true", nil, "core.bare", true},
+		{"set --global user.name Jane", []string{"--global"}, "user.name", true},
+		{"unset user.name", nil, "user.name", true},
+		{"user.name", nil, "", false},
+		{"--get user.name", nil, "", false},
+		{"--list", nil, "", false},
+		{"--remove-section user", nil, "", false},
+		{"rename-section user person", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			scope, key, ok := undoer.ConfigTarget(strings.Fields(tt.args))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.key, key)
+			assert.Equal(t, tt.scope, scope)
+		})
+	}
+}
+
+// TestConfigUndoer_Integration tests restoring the recorded values of changed config keys.
+func TestConfigUndoer_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	undo := func(command string, key string, values []string, set bool) []*undoer.UndoCommand {
+		u := undoer.New(command, githelpers.NewGitHelper(context.Background(), repoDir))
+		configUndoer, ok := u.(*undoer.ConfigUndoer)
+		require.True(t, ok)
+		configUndoer.RecordedValues(key, values, set)
+		undoCmds, err := u.GetUndoCommands()
+		require.NoError(t, err)
+		for _, undoCmd := range undoCmds {
+			require.NoError(t, undoCmd.Exec())
+		}
+		return undoCmds
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.name", "Jane Doe")
+
+	// A changed value is restored
+	git("config", "user.name", "John")
+	undoCmds := undo(`git config user.name John`, "user.name", []string{"Jane Doe"}, true)
+	assert.Equal(t, "git config --replace-all user.name 'Jane Doe'", undoCmds[0].Command)
+	assert.Equal(t, "Jane Doe", git("config", "user.name"))
+
+	// A key that wasn't set is unset
+	git("config", "--local", "core.foo", "bar")
+	undoCmds = undo(`git config --local core.foo bar`, "core.foo", nil, false)
+	assert.Equal(t, "git config --local --unset-all core.foo", undoCmds[0].Command)
+	cmd := exec.Command("git", "config", "core.foo")
+	cmd.Dir = repoDir
+	require.Error(t, cmd.Run())
+
+	// All values of a multi-valued key are restored
+	git("config", "--add", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+	git("config", "--add", "remote.origin.fetch", "+refs/tags/*:refs/tags/*")
+	before := git("config", "--get-all", "remote.origin.fetch")
+	git("config", "--unset-all", "remote.origin.fetch")
+	undoCmds = undo(`git config --unset-all remote.origin.fetch`, "remote.origin.fetch",
+		strings.Split(before, "\n"), true)
+	require.Len(t, undoCmds, 2)
+	assert.Equal(t, before, git("config", "--get-all", "remote.origin.fetch"))
+
+	// A config file outside of the repository is changed wherever the command runs: never in another repository
+	configFile := filepath.Join(t.TempDir(), "shared.gitconfig")
+	git("config", "--file", configFile, "user.name", "John")
+	u := undoer.New("git config --file "+configFile+" user.name John",
+		githelpers.NewGitHelper(context.Background(), repoDir))
+	configUndoer, ok := u.(*undoer.ConfigUndoer)
+	require.True(t, ok)
+	configUndoer.RecordedValues("user.name", []string{"Jane"}, true)
+	undoCmds, err := u.GetUndoCommands()
+	require.NoError(t, err)
+	reason, inPlace := undoCmds[0].InPlaceOnly()
+	assert.True(t, inPlace)
+	assert.Equal(t, "it changes the config file "+configFile, reason)
+	otherRepo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "-q", otherRepo).Run())
+	err = undoCmds[0].ExecWith(githelpers.NewGitHelper(context.Background(), otherRepo))
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+	assert.Equal(t, "John", git("config", "--file", configFile, "user.name"))
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, "Jane", git("config", "--file", configFile, "user.name"))
+
+	// Without recorded values the change can't be undone
+	_, err = undoer.New(`git config user.name John`,
+		githelpers.NewGitHelper(context.Background(), repoDir)).GetUndoCommands()
+	var unsupported *undoer.UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Contains(t, err.Error(), "wasn't recorded")
+}
@@ -5,6 +5,13 @@ var ParseGitCommand = parseGitCommand
 
 // Constructor functions for testing with private fields
 
+func NewApplyUndoerForTest(git GitExec, originalCmd *CommandDetails) *ApplyUndoer {
+	return &ApplyUndoer{
+		git:         git,
+		originalCmd: originalCmd,
+	}
+}
+
 func NewCherryPickUndoerForTest(git GitExec, originalCmd *CommandDetails) *CherryPickUndoer {
 	return &CherryPickUndoer{
 		git:         git,
@@ -12,6 +19,13 @@ func NewCherryPickUndoerForTest(git GitExec, originalCmd *CommandDetails) *Cherr
 	}
 }
 
+func NewCommitUndoerForTest(git GitExec, originalCmd *CommandDetails) *CommitUndoer {
+	return &CommitUndoer{
+		git:         git,
+		originalCmd: originalCmd,
+	}
+}
+
 func NewCleanUndoerForTest(git GitExec, originalCmd *CommandDetails) *CleanUndoer {
 	return &CleanUndoer{
 		git:         git,
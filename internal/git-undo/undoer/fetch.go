@@ -0,0 +1,115 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FetchUndoer handles undoing git fetch operations: the remote-tracking branches the fetch updated
+// are rolled back to their previous positions, and the ones it created are removed.
+type FetchUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &FetchUndoer{}
+
+// fetchRefsWindow is how long after the newest fetched ref update the other updates of the same fetch may be.
+const fetchRefsWindow = 5
+
+// fetchedRef is a remote-tracking branch updated by a fetch.
+type fetchedRef struct {
+	name string
+	// updated is when the fetch updated it (unix time).
+	updated int64
+}
+
+// GetUndoCommands returns the commands that would undo the fetch.
+func (f *FetchUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	// A single fetched remote limits the search (the first argument may be the value of a flag too)
+	prefix := "refs/remotes/"
+	remotes, _ := f.git.GitOutput("remote")
+	if remote := f.originalCmd.getFirstNonFlagArg(); slices.Contains(splitLines(remotes), remote) &&
+		!slices.Contains(f.originalCmd.Args, "--all") && !slices.Contains(f.originalCmd.Args, "--multiple") {
+		prefix += remote + "/"
+	}
+
+	fetched, err := f.fetchedRefs(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(fetched) == 0 {
+		return nil, errors.New("no remote-tracking branch was updated by a fetch (the fetch was up to date?)")
+	}
+
+	var warnings []string
+	if slices.Contains(f.originalCmd.Args, "--prune") || slices.Contains(f.originalCmd.Args, "-p") {
+		warnings = append(warnings, "Remote-tracking branches pruned by the fetch are not restored")
+	}
+
+	undoCmds := make([]*UndoCommand, 0, len(fetched))
+	for _, ref := range fetched {
+		current, err := f.git.GitOutput("rev-parse", "--verify", ref.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", ref.name, err)
+		}
+		current = strings.TrimSpace(current)
+		shortName := strings.TrimPrefix(ref.name, "refs/remotes/")
+
+		var undoCmd *UndoCommand
+		if previous, err := f.git.GitOutput("rev-parse", "--verify", "--quiet", ref.name+"@{1}"); err == nil {
+			previous = strings.TrimSpace(previous)
+			undoCmd = NewUndoCommand(f.git,
+				fmt.Sprintf("git update-ref %s %s %s", ref.name, previous, current),
+				fmt.Sprintf("Roll back %s to %s", shortName, getShortHash(previous)),
+			)
+		} else {
+			undoCmd = NewUndoCommand(f.git,
+				fmt.Sprintf("git update-ref -d %s %s", ref.name, current),
+				fmt.Sprintf("Remove %s (created by the fetch)", shortName),
+			)
+		}
+		undoCmd.Report = true
+		undoCmds = append(undoCmds, undoCmd)
+	}
+	undoCmds[0].Warnings = warnings
+	return undoCmds, nil
+}
+
+// fetchedRefs returns the remote-tracking branches (under the prefix) updated by the latest fetch:
+// the ones whose latest reflog entry is a fetch, made together with the newest of them.
+func (f *FetchUndoer) fetchedRefs(prefix string) ([]fetchedRef, error) {
+	refs, err := f.git.GitOutput("for-each-ref", "--format=%(refname)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote-tracking branches: %w", err)
+	}
+
+	var candidates []fetchedRef
+	var newest int64
+	for _, name := range splitLines(refs) {
+		// e.g. "fetch origin: fast-forward	origin/main@{1717171717}"
+		lastUpdate, err := f.git.GitOutput("reflog", "show", "-n", "1", "--date=unix", "--format=%gs%x09%gd", name)
+		if err != nil {
+			continue
+		}
+		message, selector, _ := strings.Cut(strings.TrimSpace(lastUpdate), "\t")
+		if !strings.HasPrefix(message, "fetch") {
+			continue
+		}
+		updated, ok := reflogTime(selector)
+		if !ok {
+			continue
+		}
+
+		candidates = append(candidates, fetchedRef{name: name, updated: updated})
+		newest = max(newest, updated)
+	}
+
+	// Older fetches may be the latest update of other branches: they're not undone
+	return slices.DeleteFunc(candidates, func(ref fetchedRef) bool {
+		return ref.updated < newest-fetchRefsWindow
+	}), nil
+}
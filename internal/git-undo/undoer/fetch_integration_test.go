@@ -0,0 +1,60 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchUndoer_Integration tests rolling back the remote-tracking branches updated by a fetch.
+func TestFetchUndoer_Integration(t *testing.T) {
+	remoteDir, repoDir, otherDir := t.TempDir(), t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git(remoteDir, "init", "--bare", "-b", "main")
+	for _, dir := range []string{repoDir, otherDir} {
+		git(dir, "init", "-b", "main")
+		git(dir, "config", "user.email", "test@example.com")
+		git(dir, "config", "user.name", "Test User")
+		git(dir, "remote", "add", "origin", remoteDir)
+	}
+	git(otherDir, "commit", "--allow-empty", "-m", "first")
+	git(otherDir, "push", "origin", "main")
+	git(repoDir, "fetch", "origin")
+	before := git(repoDir, "rev-parse", "refs/remotes/origin/main")
+
+	git(otherDir, "commit", "--allow-empty", "-m", "second")
+	git(otherDir, "push", "origin", "main", "main:feature")
+	git(repoDir, "fetch", "origin")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	undoCmds, err := undoer.New("git fetch origin", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 2)
+	descriptions := []string{undoCmds[0].Description, undoCmds[1].Description}
+	assert.Contains(t, descriptions, "Remove origin/feature (created by the fetch)")
+	assert.Contains(t, descriptions, "Roll back origin/main to "+before[:8])
+	for _, undoCmd := range undoCmds {
+		assert.True(t, undoCmd.Report)
+		require.NoError(t, undoCmd.Exec())
+	}
+
+	assert.Equal(t, before, git(repoDir, "rev-parse", "refs/remotes/origin/main"))
+	assert.Empty(t, git(repoDir, "for-each-ref", "refs/remotes/origin/feature"))
+
+	// The rolled back refs aren't fetched anymore: there is nothing left to undo
+	_, err = undoer.New("git fetch origin", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "no remote-tracking branch was updated")
+}
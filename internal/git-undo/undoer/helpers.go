@@ -1,6 +1,27 @@
 package undoer
 
-import "strings"
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
+)
+
+// versionedGit is implemented by GitExec implementations knowing the version of git they run.
+type versionedGit interface {
+	GitVersion() githelpers.GitVersion
+}
+
+// supports returns true if the git behind GitExec has the capability
+// (assumed when its version isn't known), so undo commands never use what the installed git lacks.
+func supports(git GitExec, capability githelpers.Capability) bool {
+	if versioned, ok := git.(versionedGit); ok {
+		return versioned.GitVersion().Supports(capability)
+	}
+	return true
+}
 
 func getShortHash(hash string) string {
 	const lenShortHash = 8
@@ -34,3 +55,53 @@ func collectWorkingDirWarnings(git GitExec, conflictContext string, stashHint st
 
 	return warnings
 }
+
+// summarizePaths returns a short human-readable summary of a set of paths,
+// e.g. "src/main.go" or "142 files under src/".
+func summarizePaths(paths []string) string {
+	if len(paths) == 1 {
+		return paths[0]
+	}
+
+	common := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for common != "." && !strings.HasPrefix(p, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+
+	if common == "." {
+		return fmt.Sprintf("%d files", len(paths))
+	}
+	return fmt.Sprintf("%d files under %s/", len(paths), common)
+}
+
+// splitLines splits the git output into non-empty trimmed lines.
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// quotePath quotes the path for the undo command string (which is parsed back via shellwords).
+func quotePath(p string) string {
+	isSafe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("-_./:@+=,", r)
+	}
+	if p != "" && strings.IndexFunc(p, func(r rune) bool { return !isSafe(r) }) == -1 {
+		return p
+	}
+	return "'" + strings.ReplaceAll(p, "'", `'\''`) + "'"
+}
+
+// reflogTime returns the time of a reflog entry formatted with --date=unix (e.g. "main@{1717171717}").
+func reflogTime(entry string) (int64, bool) {
+	_, updated, _ := strings.Cut(strings.TrimSuffix(entry, "}"), "@{")
+	seconds, err := strconv.ParseInt(updated, 10, 64)
+	return seconds, err == nil
+}
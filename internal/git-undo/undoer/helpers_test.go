@@ -0,0 +1,62 @@
+package undoer_test
+
+import (
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoCommandsOnOldGit(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		setupMock   func(*MockGitExec)
+		expectedCmd string
+	}{
+		{
+			name:    "add is unstaged without git restore",
+			command: "git add file.txt",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitRun", "rev-parse", "--verify", "HEAD").Return(nil)
+				m.On("GitOutput", "diff", "--cached", "--name-only", "--", "file.txt").Return("file.txt", nil)
+			},
+			expectedCmd: "git reset file.txt",
+		},
+		{
+			name:    "rm is restored via checkout",
+			command: "git rm file.txt",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitRun", "rev-parse", "--verify", "HEAD").Return(nil)
+			},
+			expectedCmd: "git checkout HEAD -- file.txt",
+		},
+		{
+			name:    "switch is undone via checkout",
+			command: "git switch main",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--symbolic-full-name", "@{-1}").Return("refs/heads/feature", nil)
+				m.On("GitOutput", "diff", "--cached", "--name-only").Return("", nil)
+				m.On("GitOutput", "diff", "--name-only").Return("", nil)
+				m.On("GitOutput", "ls-files", "--others", "--exclude-standard").Return("", nil)
+			},
+			expectedCmd: "git checkout -",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := new(MockGitExec)
+			tt.setupMock(mockGit)
+			oldGit := versionedMockGitExec{MockGitExec: mockGit, version: githelpers.GitVersion{Major: 2, Minor: 20}}
+
+			undoCmds, err := undoer.New(tt.command, oldGit).GetUndoCommands()
+			require.NoError(t, err)
+			require.Len(t, undoCmds, 1)
+			assert.Equal(t, tt.expectedCmd, undoCmds[0].Command)
+			mockGit.AssertExpectations(t)
+		})
+	}
+}
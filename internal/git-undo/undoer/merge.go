@@ -2,50 +2,127 @@ package undoer
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
-// MergeUndoer handles undoing git merge operations.
+// MergeUndoer handles undoing git merge operations: a merge in progress (stopped by conflicts, or --no-commit)
+// is aborted, a fast-forward is undone by resetting the branch to ORIG_HEAD, and a merge commit
+// (including octopus merges of several branches) by resetting the branch onto its first parent.
+// A squash merge creates no commit: its changes are unstaged and removed from the working tree instead.
 type MergeUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	pushGuard
 }
 
 var _ Undoer = &MergeUndoer{}
 
 // GetUndoCommands returns the commands that would undo the merge operation.
 func (m *MergeUndoer) GetUndoCommands() ([]*UndoCommand, error) {
-	// Check if this was a merge with conflicts
-	output, err := m.git.GitOutput("status")
-	if err == nil && strings.Contains(output, "You have unmerged paths") {
+	// A merge stopped by conflicts (or --no-commit) is still in progress
+	if err := m.git.GitRun("rev-parse", "-q", "--verify", "MERGE_HEAD"); err == nil {
 		return []*UndoCommand{NewUndoCommand(m.git,
 			"git merge --abort",
-			"Abort merge and restore state before merging",
+			"Abort the merge in progress and restore the state before merging",
+			"Changes made to the merge in progress (e.g. resolved conflicts) are lost",
 		)}, nil
 	}
 
-	// Check if ORIG_HEAD exists (it should for a merge)
-	_, err = m.git.GitOutput("rev-parse", "--verify", "ORIG_HEAD")
-	if err != nil {
-		return nil, errors.New("ORIG_HEAD not found, cannot safely undo merge")
+	if slices.Contains(m.originalCmd.Args, "--squash") {
+		return m.undoSquash()
 	}
 
-	// Check if this was a fast-forward merge
-	// We can detect this by checking if HEAD has multiple parents
-	if fastForwardMergeErr := m.git.GitRun("rev-parse", "-q", "--verify", "HEAD^2"); fastForwardMergeErr != nil {
-		// For fast-forward merges, we can just reset to ORIG_HEAD
-		//nolint:nilerr // it's OK here
+	var warnings []string
+	if m.hasLocalChanges() {
+		warnings = append(warnings, "Uncommitted changes are discarded by the reset")
+	}
+
+	// A merge commit: its first parent is the branch before the merge
+	if err := m.git.GitRun("rev-parse", "-q", "--verify", "HEAD^2"); err == nil {
+		if remotes := m.pushedRemotes(m.git, "HEAD"); len(remotes) > 0 {
+			return []*UndoCommand{m.revertPushed(m.git, "HEAD", "merge commit", remotes)}, nil
+		}
+		parent, err := m.git.GitOutput("rev-parse", "--verify", "HEAD~1")
+		if err != nil {
+			return nil, errors.New("the first parent of the merge commit not found, cannot safely undo merge")
+		}
+		description := "Undo merge commit"
+		if merged := m.mergedParents(); merged > 1 {
+			description = fmt.Sprintf("Undo octopus merge of %d branches", merged)
+		}
 		return []*UndoCommand{NewUndoCommand(m.git,
-			"git reset --hard ORIG_HEAD",
-			"Undo fast-forward merge by resetting to ORIG_HEAD",
+			"git reset --hard HEAD~1",
+			fmt.Sprintf("%s by resetting onto its first parent (%s)",
+				description, getShortHash(strings.TrimSpace(parent))),
+			append([]string{"This discards the merge commit and its conflict resolutions " +
+				"(the merged commits stay on their branch)"}, warnings...)...,
 		)}, nil
 	}
 
-	// For true merges (with a merge commit), we use --merge flag
+	// Check if ORIG_HEAD exists (it should for a fast-forward)
+	origHead, err := m.git.GitOutput("rev-parse", "--verify", "ORIG_HEAD")
+	if err != nil {
+		return nil, errors.New("ORIG_HEAD not found, cannot safely undo merge")
+	}
+	origHead = strings.TrimSpace(origHead)
+	if err := m.checkDropped(m.git, origHead); err != nil {
+		return nil, err
+	}
+	if count, err := m.git.GitOutput("rev-list", "--count", origHead+"..HEAD"); err == nil {
+		warnings = append([]string{fmt.Sprintf("The %s fast-forwarded commits are dropped from the branch "+
+			"(they stay on the merged branch)", strings.TrimSpace(count))}, warnings...)
+	}
+	return []*UndoCommand{NewUndoCommand(m.git,
+		"git reset --hard ORIG_HEAD",
+		fmt.Sprintf("Undo fast-forward merge by resetting to ORIG_HEAD (%s)", getShortHash(origHead)),
+		warnings...,
+	)}, nil
+}
+
+// undoSquash undoes `git merge --squash`: it only staged the merged changes (HEAD didn't move),
+// so resetting the index and the working tree of the staged files to HEAD undoes it.
+func (m *MergeUndoer) undoSquash() ([]*UndoCommand, error) {
+	output, err := m.git.GitOutput("diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the squashed changes: %w", err)
+	}
+	paths := splitLines(output)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: no squashed changes are staged (were they committed already?)",
+			ErrUndoNotSupported)
+	}
+
+	branches := githelpers.MergeOperands(m.originalCmd.Args)
+	description := "Unstage the squashed changes and remove them from the working tree"
+	if len(branches) > 0 {
+		description = fmt.Sprintf("Unstage the changes squashed from %s and remove them from the working tree",
+			strings.Join(branches, ", "))
+	}
 	return []*UndoCommand{NewUndoCommand(m.git,
-		"git reset --merge ORIG_HEAD",
-		"Undo merge commit by resetting to ORIG_HEAD",
-		"This will undo the merge and restore the state before merging",
+		"git reset --merge",
+		fmt.Sprintf("%s (%s)", description, summarizePaths(paths)),
+		"Changes staged along with the squashed ones are reset as well "+
+			"(unstaged changes to other files are kept)",
 	)}, nil
 }
+
+// mergedParents returns the number of parents HEAD merged into its first one.
+func (m *MergeUndoer) mergedParents() int {
+	output, err := m.git.GitOutput("rev-list", "--parents", "-n", "1", "HEAD")
+	if err != nil {
+		return 0
+	}
+	return len(strings.Fields(output)) - 2
+}
+
+// hasLocalChanges tells whether the working tree or the index has uncommitted changes.
+func (m *MergeUndoer) hasLocalChanges() bool {
+	output, err := m.git.GitOutput("status", "--porcelain", "--untracked-files=no")
+	return err == nil && strings.TrimSpace(output) != ""
+}
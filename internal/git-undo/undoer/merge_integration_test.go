@@ -0,0 +1,142 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMergeUndoer_Integration tests undoing merge commits, fast-forwards and merges stopped by conflicts.
+func TestMergeUndoer_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0644))
+		git("add", file)
+		git("commit", "-m", file+": "+content)
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	commit("base.txt", "base")
+	git("switch", "-c", "feature")
+	commit("feature.txt", "feature")
+	git("switch", "main")
+	before := git("rev-parse", "HEAD")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// Fast-forward: the branch is reset to ORIG_HEAD
+	git("merge", "feature")
+	undoCmds, err := undoer.New("git merge feature", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git reset --hard ORIG_HEAD", undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "The 1 fast-forwarded commits are dropped")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+
+	// Merge commit: the branch is reset onto its first parent, whatever ORIG_HEAD is
+	commit("main.txt", "main")
+	before = git("rev-parse", "HEAD")
+	git("merge", "--no-edit", "feature")
+	git("update-ref", "ORIG_HEAD", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.txt"), []byte("local"), 0644))
+	undoCmds, err = undoer.New("git merge --no-edit feature", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git reset --hard HEAD~1", undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "discards the merge commit")
+	assert.Contains(t, undoCmds[0].Warnings[1], "Uncommitted changes are discarded")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+
+	// Merge stopped by conflicts: it's aborted
+	git("switch", "feature")
+	commit("main.txt", "conflicting")
+	git("switch", "main")
+	cmd := exec.Command("git", "merge", "feature")
+	cmd.Dir = repoDir
+	require.Error(t, cmd.Run())
+	undoCmds, err = undoer.New("git merge feature", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git merge --abort", undoCmds[0].Command)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+	assert.Empty(t, git("status", "--porcelain"))
+}
+
+// TestMergeUndoer_OctopusAndSquash tests undoing octopus merges and squash merges.
+func TestMergeUndoer_OctopusAndSquash(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0644))
+		git("add", file)
+		git("commit", "-m", file+": "+content)
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	commit("base.txt", "base")
+	for _, branch := range []string{"one", "two"} {
+		git("switch", "-c", branch, "main")
+		commit(branch+".txt", branch)
+	}
+	git("switch", "main")
+	commit("main.txt", "main")
+	before := git("rev-parse", "HEAD")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// Octopus merge: the branch is reset onto the first parent
+	git("merge", "--no-edit", "one", "two")
+	undoCmds, err := undoer.New("git merge --no-edit one two", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git reset --hard HEAD~1", undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Description, "Undo octopus merge of 2 branches")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+
+	// Squash merge: nothing is committed, the squashed changes are unstaged and removed
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.txt"), []byte("local"), 0644))
+	git("merge", "--squash", "one")
+	assert.Contains(t, git("status", "--porcelain", "--untracked-files=no"), "A  one.txt")
+	undoCmds, err = undoer.New("git merge --squash one", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git reset --merge", undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Description, "squashed from one")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+	assert.Equal(t, "M main.txt", git("status", "--porcelain", "--untracked-files=no"))
+	assert.NoFileExists(t, filepath.Join(repoDir, "one.txt"))
+
+	// Once the squashed changes are gone (e.g. committed), the squash can't be undone anymore
+	_, err = undoer.New("git merge --squash one", gitExec).GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+}
@@ -0,0 +1,76 @@
+package undoer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotesUndoer handles undoing git notes operations: the notes ref is reset to its previous value
+// (from its reflog), or deleted if the command created it.
+type NotesUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &NotesUndoer{}
+
+// GetUndoCommands returns the commands that would undo the notes operation.
+func (n *NotesUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	action := n.originalCmd.Action
+	switch action {
+	case "", "list", "show", "get-ref":
+		return nil, fmt.Errorf("%w: `git notes %s` doesn't change anything", ErrUndoNotSupported, action)
+	case "merge":
+		return nil, fmt.Errorf("%w for notes merges", ErrUndoNotSupported)
+	}
+
+	// The notes ref is resolved by git itself (e.g. --ref=review, core.notesRef or GIT_NOTES_REF)
+	args := []string{"get-ref"}
+	if ref := n.notesRefArg(); ref != "" {
+		args = []string{"--ref", ref, "get-ref"}
+	}
+	ref, err := n.git.GitOutput("notes", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the notes ref: %w", err)
+	}
+	ref = strings.TrimSpace(ref)
+
+	// e.g. "notes: Notes added by 'git notes append'"
+	lastUpdate, err := n.git.GitOutput("reflog", "show", "-n", "1", "--format=%gs", ref)
+	if err != nil || !strings.Contains(lastUpdate, "'git notes "+action+"'") {
+		return nil, fmt.Errorf("the last update of %s is not a `git notes %s` (the notes have changed since)",
+			ref, action)
+	}
+	current, err := n.git.GitOutput("rev-parse", "--verify", ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	current = strings.TrimSpace(current)
+
+	previous, err := n.git.GitOutput("rev-parse", "--verify", "--quiet", ref+"@{1}")
+	if err != nil {
+		return []*UndoCommand{NewUndoCommand(n.git,
+			fmt.Sprintf("git update-ref -d %s %s", ref, current),
+			fmt.Sprintf("Remove %s (created by `git notes %s`)", ref, action),
+		)}, nil
+	}
+	previous = strings.TrimSpace(previous)
+	return []*UndoCommand{NewUndoCommand(n.git,
+		fmt.Sprintf("git update-ref %s %s %s", ref, previous, current),
+		fmt.Sprintf("Restore %s as before `git notes %s` (%s)", ref, action, getShortHash(previous)),
+	)}, nil
+}
+
+// notesRefArg returns the notes ref given to the command via --ref (empty if none).
+func (n *NotesUndoer) notesRefArg() string {
+	for i, arg := range n.originalCmd.ActionArgs {
+		if value, ok := strings.CutPrefix(arg, "--ref="); ok {
+			return value
+		}
+		if arg == "--ref" && i+1 < len(n.originalCmd.ActionArgs) {
+			return n.originalCmd.ActionArgs[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNotesUndoer_Integration tests resetting the notes ref updated by git notes.
+func TestNotesUndoer_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	undo := func(command string) *undoer.UndoCommand {
+		undoCmds, err := undoer.New(command, githelpers.NewGitHelper(context.Background(), repoDir)).
+			GetUndoCommands()
+		require.NoError(t, err)
+		require.Len(t, undoCmds, 1)
+		require.NoError(t, undoCmds[0].Exec())
+		return undoCmds[0]
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	git("commit", "--allow-empty", "-m", "first")
+
+	// The first note creates the notes ref: it's deleted
+	git("notes", "add", "-m", "note")
+	undoCmd := undo("git notes add -m note")
+	assert.Contains(t, undoCmd.Command, "git update-ref -d refs/notes/commits ")
+	assert.Empty(t, git("for-each-ref", "refs/notes/"))
+
+	// Later ones restore the previous notes
+	git("notes", "add", "-m", "note")
+	git("notes", "append", "-m", "more")
+	undo("git notes append -m more")
+	assert.Equal(t, "note", git("notes", "show"))
+
+	git("notes", "remove")
+	undo("git notes remove")
+	assert.Equal(t, "note", git("notes", "show"))
+
+	// Other notes refs are resolved as git does
+	git("notes", "--ref", "review", "add", "-m", "reviewed")
+	undoCmd = undo("git notes --ref review add -m reviewed")
+	assert.Contains(t, undoCmd.Command, "refs/notes/review")
+	assert.Equal(t, "note", git("notes", "show"))
+
+	// The notes changed since the command
+	git("notes", "append", "-m", "more")
+	_, err := undoer.New("git notes remove", githelpers.NewGitHelper(context.Background(), repoDir)).
+		GetUndoCommands()
+	require.ErrorContains(t, err, "is not a `git notes remove`")
+}
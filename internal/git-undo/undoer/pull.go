@@ -0,0 +1,97 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PullUndoer handles undoing git pull operations: the branch is moved back to where it was before the pull
+// (the fetch itself can't be undone: remote-tracking branches keep the fetched commits).
+type PullUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &PullUndoer{}
+
+// GetUndoCommands returns the commands that would undo the pull.
+func (p *PullUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	// A pull stopped by conflicts is still in progress: aborting it restores the state before
+	status, _ := p.git.GitOutput("status")
+	switch {
+	case strings.Contains(status, "rebase in progress"):
+		return []*UndoCommand{NewUndoCommand(p.git,
+			"git rebase --abort",
+			"Abort the rebase of the pull and restore the branch as before pulling",
+		)}, nil
+	case strings.Contains(status, "You have unmerged paths"):
+		return []*UndoCommand{NewUndoCommand(p.git,
+			"git merge --abort",
+			"Abort the merge of the pull and restore the state before pulling",
+		)}, nil
+	}
+
+	branch, err := p.git.GitOutput("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, &UnsupportedError{
+			Command:    p.originalCmd.FullCommand,
+			Suggestion: GetSuggestion("pull"),
+			cause:      errors.New("pull into a detached HEAD"),
+		}
+	}
+	branch = strings.TrimSpace(branch)
+
+	// The pull is the last update of the branch: its reflog tells where the branch was before
+	lastUpdate, err := p.git.GitOutput("reflog", "show", "-n", "1", "--format=%gs", "refs/heads/"+branch)
+	if err != nil || !strings.HasPrefix(strings.TrimSpace(lastUpdate), "pull") {
+		return nil, fmt.Errorf("the last update of branch '%s' is not a pull "+
+			"(the pull was up to date, or the branch has changed since)", branch)
+	}
+	lastUpdate = strings.TrimSpace(lastUpdate)
+	previous, err := p.git.GitOutput("rev-parse", "--verify", "--quiet", branch+"@{1}")
+	if err != nil {
+		return nil, fmt.Errorf("cannot find where branch '%s' was before the pull", branch)
+	}
+	previous = strings.TrimSpace(previous)
+	shortHash := getShortHash(previous)
+
+	// --keep moves the branch back keeping local changes (and refuses if the pull touched them)
+	command := "git reset --keep " + previous
+	var warnings []string
+	if p.hasLocalChanges() {
+		warnings = append(warnings, "Local changes are kept (the reset is refused if the pull changed the same files)")
+	}
+
+	count, _ := p.git.GitOutput("rev-list", "--count", previous+"..HEAD")
+	count = strings.TrimSpace(count)
+	switch {
+	case strings.Contains(lastUpdate, "(finish)"):
+		// The commits only the original branch has are the ones the rebase rewrote
+		rebased, _ := p.git.GitOutput("rev-list", "--count", "--left-only", previous+"...HEAD")
+		return []*UndoCommand{NewUndoCommand(p.git, command,
+			fmt.Sprintf("Restore branch '%s' as before the rebasing pull (%s)", branch, shortHash),
+			append(warnings, fmt.Sprintf("The %s rebased commits are dropped: "+
+				"your original commits are restored instead", strings.TrimSpace(rebased)))...,
+		)}, nil
+	case p.git.GitRun("rev-parse", "-q", "--verify", "HEAD^2") == nil:
+		return []*UndoCommand{NewUndoCommand(p.git, command,
+			fmt.Sprintf("Undo the merging pull by resetting branch '%s' to %s", branch, shortHash),
+			append(warnings, fmt.Sprintf("This discards the merge commit and the %s commits it brought in "+
+				"(they're still fetched: pull again to get them back)", count))...,
+		)}, nil
+	default:
+		return []*UndoCommand{NewUndoCommand(p.git, command,
+			fmt.Sprintf("Undo the fast-forward pull by resetting branch '%s' to %s", branch, shortHash),
+			append(warnings, fmt.Sprintf("The %s pulled commits are dropped from the branch "+
+				"(they're still fetched: pull again to get them back)", count))...,
+		)}, nil
+	}
+}
+
+// hasLocalChanges tells whether the working tree or the index has uncommitted changes.
+func (p *PullUndoer) hasLocalChanges() bool {
+	output, err := p.git.GitOutput("status", "--porcelain", "--untracked-files=no")
+	return err == nil && strings.TrimSpace(output) != ""
+}
@@ -0,0 +1,84 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPullUndoer_Integration tests undoing fast-forward, merging and rebasing pulls.
+func TestPullUndoer_Integration(t *testing.T) {
+	remoteDir, repoDir, otherDir := t.TempDir(), t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(dir, file string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(file), 0644))
+		git(dir, "add", file)
+		git(dir, "commit", "-m", file)
+	}
+
+	git(remoteDir, "init", "--bare", "-b", "main")
+	for _, dir := range []string{repoDir, otherDir} {
+		git(dir, "init", "-b", "main")
+		git(dir, "config", "user.email", "test@example.com")
+		git(dir, "config", "user.name", "Test User")
+		git(dir, "remote", "add", "origin", remoteDir)
+	}
+	commit(repoDir, "base.txt")
+	git(repoDir, "push", "-u", "origin", "main")
+	git(otherDir, "pull", "origin", "main")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	undo := func(command string) *undoer.UndoCommand {
+		undoCmds, err := undoer.New(command, gitExec).GetUndoCommands()
+		require.NoError(t, err)
+		require.Len(t, undoCmds, 1)
+		require.NoError(t, undoCmds[0].Exec())
+		return undoCmds[0]
+	}
+
+	// Fast-forward pull, with a local change kept
+	before := git(repoDir, "rev-parse", "HEAD")
+	commit(otherDir, "ff.txt")
+	git(otherDir, "push", "origin", "main")
+	git(repoDir, "pull", "--ff-only")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "base.txt"), []byte("local change"), 0644))
+	undoCmd := undo("git pull --ff-only")
+	assert.Contains(t, undoCmd.Description, "fast-forward")
+	assert.Equal(t, before, git(repoDir, "rev-parse", "HEAD"))
+	assert.Contains(t, git(repoDir, "status", "--porcelain"), "M base.txt")
+	git(repoDir, "checkout", "base.txt")
+
+	// Merging pull
+	commit(repoDir, "local.txt")
+	before = git(repoDir, "rev-parse", "HEAD")
+	git(repoDir, "pull", "--no-rebase", "--no-edit")
+	undoCmd = undo("git pull --no-rebase --no-edit")
+	assert.Contains(t, undoCmd.Description, "merging pull")
+	assert.NotEmpty(t, undoCmd.Warnings)
+	assert.Equal(t, before, git(repoDir, "rev-parse", "HEAD"))
+
+	// Rebasing pull
+	git(repoDir, "pull", "--rebase")
+	undoCmd = undo("git pull --rebase")
+	assert.Contains(t, undoCmd.Description, "rebasing pull")
+	assert.Equal(t, before, git(repoDir, "rev-parse", "HEAD"))
+
+	// The branch changed since: the pull can't be told apart anymore
+	commit(repoDir, "after.txt")
+	_, err := undoer.New("git pull", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "not a pull")
+}
@@ -0,0 +1,185 @@
+package undoer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PushUndoer handles undoing git push operations: the pushed remote branches are moved back
+// (or deleted if the push created them) by force-pushing with a lease.
+type PushUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &PushUndoer{}
+
+// pushUnsupportedFlags push more than branches (or delete them): their undo isn't known.
+var pushUnsupportedFlags = []string{"--all", "--branches", "--mirror", "--tags", "--follow-tags", "--prune",
+	"-d", "--delete"}
+
+// pushValueFlags are the push flags taking the next argument as their value.
+var pushValueFlags = []string{"-o", "--push-option", "--receive-pack", "--exec", "--repo"}
+
+// pushReflogMessage is the reflog message of remote-tracking branches updated by a push.
+const pushReflogMessage = "update by push"
+
+// GetUndoCommands returns the commands that would undo the push.
+func (p *PushUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	var positional []string
+	args := p.originalCmd.Args
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		flag, _, _ := strings.Cut(arg, "=")
+		switch {
+		case slices.Contains(pushUnsupportedFlags, flag):
+			return nil, p.unsupported("git push %s moves more than branches", flag)
+		case slices.Contains(pushValueFlags, arg):
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	var remote string
+	var branches []string
+	if len(positional) > 0 {
+		remote = positional[0]
+	}
+	if len(positional) > 1 {
+		for _, refspec := range positional[1:] {
+			branch, err := p.pushedBranch(refspec)
+			if err != nil {
+				return nil, err
+			}
+			branches = append(branches, branch)
+		}
+	} else {
+		pushRemote, branch, err := p.defaultPushTarget()
+		if err != nil {
+			return nil, err
+		}
+		if remote == "" {
+			remote = pushRemote
+		}
+		branches = append(branches, branch)
+	}
+
+	undoCmds := make([]*UndoCommand, 0, len(branches))
+	for _, branch := range branches {
+		undoCmd, err := p.undoBranchPush(remote, branch)
+		if err != nil {
+			return nil, err
+		}
+		undoCmds = append(undoCmds, undoCmd)
+	}
+	return undoCmds, nil
+}
+
+// pushedBranch returns the remote branch the refspec (e.g. `main`, `HEAD:feature` or `+topic:refs/heads/topic`)
+// updated.
+func (p *PushUndoer) pushedBranch(refspec string) (string, error) {
+	src, dst, hasDst := strings.Cut(strings.TrimPrefix(refspec, "+"), ":")
+	if hasDst && src == "" {
+		return "", p.unsupported("%s deleted a remote branch", refspec)
+	}
+	if !hasDst || dst == "" {
+		dst = src
+	}
+	if dst == "HEAD" {
+		branch, err := p.git.GitOutput("symbolic-ref", "--short", "HEAD")
+		if err != nil {
+			return "", p.unsupported("HEAD was pushed from a detached HEAD")
+		}
+		dst = strings.TrimSpace(branch)
+	}
+
+	if strings.HasPrefix(dst, "refs/tags/") {
+		return "", p.unsupported("%s pushed a tag", refspec)
+	}
+	if !hasDst {
+		if _, err := p.git.GitOutput("rev-parse", "--verify", "--quiet", "refs/tags/"+src); err == nil {
+			return "", p.unsupported("%s pushed a tag", refspec)
+		}
+	}
+	return strings.TrimPrefix(dst, "refs/heads/"), nil
+}
+
+// defaultPushTarget returns the remote and the branch a bare `git push` updates: the push destination
+// of the current branch (see `@{push}`).
+func (p *PushUndoer) defaultPushTarget() (string, string, error) {
+	current, err := p.git.GitOutput("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", "", p.unsupported("nothing is pushed from a detached HEAD")
+	}
+	current = strings.TrimSpace(current)
+
+	pushRef, err := p.git.GitOutput("rev-parse", "--symbolic-full-name", current+"@{push}")
+	if err != nil {
+		return "", "", fmt.Errorf("cannot tell where branch '%s' was pushed to: %w", current, err)
+	}
+
+	// e.g. refs/remotes/origin/feature: the remote name may contain slashes, so it's checked against remotes
+	tracking := strings.TrimPrefix(strings.TrimSpace(pushRef), "refs/remotes/")
+	remotes, _ := p.git.GitOutput("remote")
+	for _, remote := range splitLines(remotes) {
+		if branch, ok := strings.CutPrefix(tracking, remote+"/"); ok {
+			return remote, branch, nil
+		}
+	}
+	return "", "", fmt.Errorf("cannot tell where branch '%s' was pushed to (%s)", current, pushRef)
+}
+
+// undoBranchPush returns the command moving the remote branch back to where it was before the push.
+// The remote-tracking branch tells both positions: the push updated it, so its previous reflog entry
+// is the position before the push.
+func (p *PushUndoer) undoBranchPush(remote, branch string) (*UndoCommand, error) {
+	tracking := fmt.Sprintf("refs/remotes/%s/%s", remote, branch)
+	pushed, err := p.git.GitOutput("rev-parse", "--verify", "--quiet", tracking)
+	if err != nil {
+		return nil, fmt.Errorf("remote-tracking branch %s/%s not found: cannot tell what was pushed", remote, branch)
+	}
+	pushed = strings.TrimSpace(pushed)
+
+	lastUpdate, err := p.git.GitOutput("reflog", "show", "-n", "1", "--format=%gs", tracking)
+	if err != nil || strings.TrimSpace(lastUpdate) != pushReflogMessage {
+		return nil, fmt.Errorf("%s/%s has been updated since the push (e.g. fetched): "+
+			"cannot tell where the push moved it from", remote, branch)
+	}
+
+	lease := fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", branch, pushed)
+	warnings := []string{
+		fmt.Sprintf("This rewrites %s/%s: anyone who fetched it since the push will have to reset their copy",
+			remote, branch),
+		fmt.Sprintf("The force push is leased: it's rejected if %s/%s has moved since the push", remote, branch),
+	}
+
+	previous, err := p.git.GitOutput("rev-parse", "--verify", "--quiet", tracking+"@{1}")
+	if err != nil {
+		// No position before the push: the push created the remote branch
+		return NewUndoCommand(p.git,
+			fmt.Sprintf("git push %s %s --delete %s", lease, remote, branch),
+			fmt.Sprintf("Delete remote branch %s/%s created by the push", remote, branch),
+			warnings...,
+		), nil
+	}
+	previous = strings.TrimSpace(previous)
+
+	return NewUndoCommand(p.git,
+		fmt.Sprintf("git push %s %s %s:refs/heads/%s", lease, remote, previous, branch),
+		fmt.Sprintf("Move remote branch %s/%s back to %s (force push)", remote, branch, getShortHash(previous)),
+		warnings...,
+	), nil
+}
+
+// unsupported returns the error of a push whose undo isn't known, with the guidance for a manual recovery.
+func (p *PushUndoer) unsupported(format string, args ...any) error {
+	return &UnsupportedError{
+		Command:    p.originalCmd.FullCommand,
+		Suggestion: GetSuggestion("push"),
+		cause:      fmt.Errorf(format, args...),
+	}
+}
@@ -0,0 +1,73 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPushUndoer_Integration tests undoing pushes against a real (bare) remote.
+func TestPushUndoer_Integration(t *testing.T) {
+	repoDir, remoteDir := t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git(remoteDir, "init", "--bare")
+	git(repoDir, "init", "-b", "main")
+	git(repoDir, "config", "user.email", "test@example.com")
+	git(repoDir, "config", "user.name", "Test User")
+	git(repoDir, "remote", "add", "origin", remoteDir)
+	git(repoDir, "commit", "--allow-empty", "-m", "first")
+	first := git(repoDir, "rev-parse", "HEAD")
+	git(repoDir, "push", "-u", "origin", "main")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	undo := func(command string) []*undoer.UndoCommand {
+		undoCmds, err := undoer.New(command, gitExec).GetUndoCommands()
+		require.NoError(t, err)
+		for _, undoCmd := range undoCmds {
+			assert.NotEmpty(t, undoCmd.Warnings)
+			require.NoError(t, undoCmd.Exec())
+		}
+		return undoCmds
+	}
+
+	// Pushing an update: the remote branch moves back
+	git(repoDir, "commit", "--allow-empty", "-m", "second")
+	git(repoDir, "push")
+	undoCmds := undo("git push")
+	require.Len(t, undoCmds, 1)
+	assert.Contains(t, undoCmds[0].Description, "Move remote branch origin/main back to")
+	assert.Equal(t, first, git(remoteDir, "rev-parse", "refs/heads/main"))
+
+	// Pushing a new branch: the remote branch is deleted
+	git(repoDir, "branch", "feature")
+	git(repoDir, "push", "origin", "feature")
+	undoCmds = undo("git push origin feature")
+	require.Len(t, undoCmds, 1)
+	assert.Contains(t, undoCmds[0].Description, "Delete remote branch origin/feature")
+	assert.Empty(t, git(remoteDir, "branch", "--list", "feature"))
+
+	// The remote-tracking branch updated since the push: the previous position isn't known anymore
+	git(repoDir, "push", "origin", "main")
+	git(repoDir, "update-ref", "-m", "fetch: fast-forward", "refs/remotes/origin/main", first)
+	_, err := undoer.New("git push origin main", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "updated since the push")
+
+	// Tags and deletions aren't supported
+	for _, command := range []string{"git push --tags", "git push origin :feature", "git push --delete origin main"} {
+		_, err = undoer.New(command, gitExec).GetUndoCommands()
+		require.ErrorIs(t, err, undoer.ErrUndoNotSupported, command)
+	}
+}
@@ -0,0 +1,98 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPushed is returned when the undo would rewrite commits that are already pushed
+// (reachable from a remote-tracking branch) and isn't forced to (see ForcePushed).
+var ErrPushed = errors.New("the undo would rewrite commits that are already pushed")
+
+// pushGuard protects commits already pushed from being rewritten by the undo: the undoers embedding it
+// revert a pushed commit instead of resetting it away, or refuse to, unless forced.
+type pushGuard struct {
+	force bool
+}
+
+// ForcePushed makes the undo rewrite the history even when the commits it drops are already pushed.
+func (p *pushGuard) ForcePushed() {
+	p.force = true
+}
+
+// pushedRemotes returns the remote-tracking branches the given commits are pushed to
+// (nothing when the undo is forced to rewrite them).
+func (p *pushGuard) pushedRemotes(git GitExec, commits ...string) []string {
+	if p.force || len(commits) == 0 {
+		return nil
+	}
+	return pushedTo(git, commits...)
+}
+
+// checkDropped returns an ErrPushed error when resetting HEAD to the target drops commits already pushed.
+func (p *pushGuard) checkDropped(git GitExec, target string) error {
+	if p.force {
+		return nil
+	}
+	output, err := git.GitOutput("rev-list", target+"..HEAD")
+	if err != nil {
+		return nil //nolint:nilerr // an unknown range is left to the undo command itself
+	}
+	dropped := splitLines(output)
+	if remotes := pushedTo(git, dropped...); len(remotes) > 0 {
+		return pushedError(len(dropped), remotes)
+	}
+	return nil
+}
+
+// revertPushed returns the command reverting the pushed commit by a new commit, instead of dropping it.
+func (p *pushGuard) revertPushed(git GitExec, commit, kind string, remotes []string) *UndoCommand {
+	if sha, err := git.GitOutput("rev-parse", "--verify", commit); err == nil {
+		commit = strings.TrimSpace(sha)
+	}
+	revertCmd := "git revert --no-edit " + commit
+	if git.GitRun("rev-parse", "-q", "--verify", commit+"^2") == nil {
+		// The mainline of a merge is its first parent: the branch it was merged into
+		revertCmd = "git revert --no-edit -m 1 " + commit
+	}
+	undoCmd := NewUndoCommand(git,
+		revertCmd,
+		fmt.Sprintf("Revert %s %s by a new commit", kind, getShortHash(commit)),
+		fmt.Sprintf("The %s is already pushed (to %s): it's reverted instead of being removed from "+
+			"the published history (use --force to remove it anyway)", kind, strings.Join(remotes, ", ")),
+		"Conflicts stop the revert: resolve them and run `git revert --continue`, or `git revert --abort`",
+	)
+	undoCmd.Strategy = StrategyRevert
+	return undoCmd
+}
+
+// pushedError returns the ErrPushed error for dropping the given number of commits pushed to the remotes.
+func pushedError(dropped int, remotes []string) error {
+	return fmt.Errorf("%w: %d commit(s) would be dropped, pushed to %s (use --force to rewrite them anyway)",
+		ErrPushed, dropped, strings.Join(remotes, ", "))
+}
+
+// pushedTo returns the remote-tracking branches containing any of the commits (empty if none is pushed).
+func pushedTo(git GitExec, commits ...string) []string {
+	if len(commits) == 0 {
+		return nil
+	}
+	args := []string{"--format=%(refname:short)"}
+	for _, commit := range commits {
+		args = append(args, "--contains", commit)
+	}
+	output, err := git.GitOutput("for-each-ref", append(args, "refs/remotes")...)
+	if err != nil {
+		return nil
+	}
+
+	var remotes []string
+	for _, remote := range splitLines(output) {
+		// The symbolic <remote>/HEAD just repeats the default branch
+		if !strings.HasSuffix(remote, "/HEAD") {
+			remotes = append(remotes, remote)
+		}
+	}
+	return remotes
+}
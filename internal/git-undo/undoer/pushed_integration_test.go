@@ -0,0 +1,62 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPushGuard_Integration tests that undoing pushed commits reverts them, or refuses to unless forced.
+func TestPushGuard_Integration(t *testing.T) {
+	repoDir, remoteDir := t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git(remoteDir, "init", "--bare")
+	git(repoDir, "init", "-b", "main")
+	git(repoDir, "config", "user.email", "test@example.com")
+	git(repoDir, "config", "user.name", "Test User")
+	git(repoDir, "remote", "add", "origin", remoteDir)
+	git(repoDir, "commit", "--allow-empty", "-m", "first")
+	git(repoDir, "commit", "--allow-empty", "-m", "second")
+	second := git(repoDir, "rev-parse", "HEAD")
+	git(repoDir, "push", "-u", "origin", "main")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// A pushed commit is reverted instead of being reset away
+	undoCmds, err := undoer.New("git commit -m second", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git revert --no-edit "+second, undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "already pushed (to origin/main)")
+
+	// Unless forced
+	u := undoer.New("git commit -m second", gitExec)
+	forcer, ok := u.(interface{ ForcePushed() })
+	require.True(t, ok)
+	forcer.ForcePushed()
+	undoCmds, err = u.GetUndoCommands()
+	require.NoError(t, err)
+	assert.Equal(t, "git reset --soft HEAD~1", undoCmds[0].Command)
+
+	// Undoing a reset that would drop pushed commits is refused
+	git(repoDir, "branch", "other", "HEAD~1")
+	git(repoDir, "reset", "-q", "--hard", "other")
+	git(repoDir, "commit", "--allow-empty", "-m", "local")
+	git(repoDir, "reset", "-q", "--hard", "origin/main")
+	_, err = undoer.New("git reset --hard origin/main", gitExec).GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrPushed)
+	assert.ErrorContains(t, err, "1 commit(s) would be dropped, pushed to origin/main")
+}
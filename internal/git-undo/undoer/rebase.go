@@ -0,0 +1,76 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RebaseUndoer handles undoing git rebase operations: an unfinished rebase is aborted,
+// a completed one is undone by resetting the branch to ORIG_HEAD (its tip before the rebase).
+type RebaseUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &RebaseUndoer{}
+
+// GetUndoCommands returns the commands that would undo the rebase.
+func (r *RebaseUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	// A rebase stopped by conflicts (or an edit) is still in progress
+	status, _ := r.git.GitOutput("status")
+	if strings.Contains(status, "rebase in progress") {
+		return []*UndoCommand{NewUndoCommand(r.git,
+			"git rebase --abort",
+			"Abort the rebase and restore the branch as before rebasing",
+		)}, nil
+	}
+
+	branch, err := r.git.GitOutput("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, &UnsupportedError{
+			Command:    r.originalCmd.FullCommand,
+			Suggestion: GetSuggestion("rebase"),
+			cause:      errors.New("the rebase left HEAD detached"),
+		}
+	}
+	branch = strings.TrimSpace(branch)
+
+	// The rebase must be the last update of the branch, and ORIG_HEAD its tip before the rebase
+	lastUpdate, err := r.git.GitOutput("reflog", "show", "-n", "1", "--format=%gs", "refs/heads/"+branch)
+	lastUpdate = strings.TrimSpace(lastUpdate)
+	if err != nil || !strings.HasPrefix(lastUpdate, "rebase") || !strings.Contains(lastUpdate, "(finish)") {
+		return nil, fmt.Errorf("the last update of branch '%s' is not a completed rebase "+
+			"(the branch was up to date, or it has changed since)", branch)
+	}
+	origHead, err := r.git.GitOutput("rev-parse", "--verify", "--quiet", "ORIG_HEAD")
+	if err != nil {
+		return nil, errors.New("ORIG_HEAD not found, cannot safely undo rebase")
+	}
+	origHead = strings.TrimSpace(origHead)
+	previous, err := r.git.GitOutput("rev-parse", "--verify", "--quiet", branch+"@{1}")
+	if err != nil || strings.TrimSpace(previous) != origHead {
+		return nil, fmt.Errorf("ORIG_HEAD is not where branch '%s' was before the rebase "+
+			"(it was moved by another command since)", branch)
+	}
+
+	var warnings []string
+	// The commits only the original branch has are the ones the rebase rewrote
+	if count, err := r.git.GitOutput("rev-list", "--count", "--left-only", origHead+"...HEAD"); err == nil {
+		warnings = append(warnings, fmt.Sprintf("The %s rebased commits are dropped: "+
+			"the original commits are restored instead", strings.TrimSpace(count)))
+	}
+	if output, err := r.git.GitOutput("status", "--porcelain", "--untracked-files=no"); err == nil &&
+		strings.TrimSpace(output) != "" {
+		warnings = append(warnings,
+			"Local changes are kept (the reset is refused if the rebase changed the same files)")
+	}
+
+	// --keep moves the branch back keeping local changes (and refuses if the rebase touched them)
+	return []*UndoCommand{NewUndoCommand(r.git,
+		"git reset --keep "+origHead,
+		fmt.Sprintf("Restore branch '%s' as before the rebase (%s)", branch, getShortHash(origHead)),
+		warnings...,
+	)}, nil
+}
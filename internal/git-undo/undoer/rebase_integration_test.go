@@ -0,0 +1,74 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRebaseUndoer_Integration tests undoing completed and stopped rebases.
+func TestRebaseUndoer_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0644))
+		git("add", file)
+		git("commit", "-m", file+": "+content)
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	commit("base.txt", "base")
+	git("switch", "-c", "feature")
+	commit("feature.txt", "feature")
+	git("switch", "main")
+	commit("main.txt", "main")
+	git("switch", "feature")
+	before := git("rev-parse", "HEAD")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// Completed rebase: the branch is reset to its tip before the rebase
+	git("rebase", "main")
+	undoCmds, err := undoer.New("git rebase main", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git reset --keep "+before, undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "The 1 rebased commits are dropped")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, before, git("rev-parse", "HEAD"))
+
+	// The branch changed since the rebase: ORIG_HEAD can't be trusted anymore
+	git("rebase", "main")
+	commit("after.txt", "after")
+	_, err = undoer.New("git rebase main", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "not a completed rebase")
+	git("reset", "--hard", before)
+
+	// Rebase stopped by conflicts: it's aborted
+	commit("main.txt", "conflicting")
+	cmd := exec.Command("git", "rebase", "main")
+	cmd.Dir = repoDir
+	require.Error(t, cmd.Run())
+	undoCmds, err = undoer.New("git rebase main", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git rebase --abort", undoCmds[0].Command)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.NotContains(t, git("status"), "rebase in progress")
+}
@@ -0,0 +1,235 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrRefChanged is returned when a ref the undo updates was moved after the undo had been planned
+// (e.g. by a commit from another terminal): the undo is refused instead of clobbering the new work.
+var ErrRefChanged = errors.New("ref changed since the undo was planned")
+
+// refUpdate is an instruction of `git update-ref --stdin`: the ref must still point to oldValue.
+type refUpdate struct {
+	ref      string
+	oldValue string
+	// newValue is the value the ref is moved to (empty means the ref is only verified, or deleted).
+	newValue string
+	// delete deletes the ref.
+	delete bool
+}
+
+func (u refUpdate) instruction() string {
+	switch {
+	case u.delete:
+		return fmt.Sprintf("delete %s %s\n", u.ref, u.oldValue)
+	case u.newValue == "":
+		return fmt.Sprintf("verify %s %s\n", u.ref, u.oldValue)
+	default:
+		return fmt.Sprintf("update %s %s %s\n", u.ref, u.newValue, u.oldValue)
+	}
+}
+
+// moves tells whether the instruction changes the ref.
+func (u refUpdate) moves() bool {
+	return u.delete || u.newValue != ""
+}
+
+// refLock pins the refs an undo command updates to their values at planning time.
+type refLock struct {
+	updates []refUpdate
+	// reflogMessage is the reflog message of the updated refs.
+	reflogMessage string
+	// followUp is what's left to run (as git args) once the refs are moved by the transaction itself.
+	// It's only used when some ref is updated (not just verified); nil means nothing is left to run.
+	followUp []string
+	// aftermath is what git does besides moving the refs (as git args, e.g. setting ORIG_HEAD on reset),
+	// run before the follow-up. It's best effort: failing doesn't fail the command.
+	aftermath [][]string
+}
+
+// resetFollowUps finish what `git reset --<mode>` does besides moving HEAD: without a commit argument,
+// read-tree doesn't write an extra "moving to HEAD" reflog entry as a second reset would.
+var resetFollowUps = map[string][]string{
+	"soft":  nil,
+	"mixed": {"read-tree", "--reset", "HEAD"},
+	"hard":  {"read-tree", "--reset", "-u", "HEAD"},
+}
+
+// LockRefs pins the refs the undo commands update to their current values. When executed, the commands
+// move the refs atomically via `git update-ref --stdin` (or verify them right before running), failing
+// with ErrRefChanged if another process moved any of them in the meantime.
+func LockRefs(cmds []*UndoCommand) error {
+	for _, cmd := range cmds {
+		if err := cmd.lockRefs(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockRefs records the ref updates of the command (commands not updating refs are left as is).
+func (cmd *UndoCommand) lockRefs() error {
+	if cmd.removeDir != "" || cmd.restore != nil {
+		return nil
+	}
+	details, err := parseGitCommand(cmd.Command)
+	if err != nil {
+		return fmt.Errorf("invalid command: %w", err)
+	}
+
+	switch details.SubCommand {
+	case "reset":
+		return cmd.lockReset(details.Args)
+	case "branch", "tag":
+		if !slices.ContainsFunc(details.Args, func(arg string) bool {
+			return arg == "-d" || arg == "-D" || arg == "--delete"
+		}) {
+			return nil
+		}
+		prefix := map[string]string{"branch": "refs/heads/", "tag": "refs/tags/"}[details.SubCommand]
+		lock := &refLock{}
+		for _, arg := range details.Args {
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			value, err := cmd.git.GitOutput("rev-parse", "--verify", "-q", prefix+arg)
+			if err != nil {
+				return fmt.Errorf("cannot resolve %s%s: %w", prefix, arg, err)
+			}
+			lock.updates = append(lock.updates, refUpdate{ref: prefix + arg, oldValue: value})
+		}
+		if deletesInTransaction(cmd.git, details, lock.updates) {
+			for i, update := range lock.updates {
+				lock.updates[i].delete = true
+				if details.SubCommand == "branch" {
+					// git branch -D drops the configuration of the branch (e.g. its upstream) as well
+					lock.aftermath = append(lock.aftermath,
+						[]string{"config", "--remove-section", "branch." + strings.TrimPrefix(update.ref, prefix)})
+				}
+			}
+		}
+		cmd.lock = lock
+	}
+	return nil
+}
+
+// deletesInTransaction tells whether the refs deleted by the branch or tag command can be deleted by the
+// transaction itself. Safe branch deletions (-d, which checks the branch is merged) and the deletions of
+// branches checked out in some worktree (which git refuses) are verified, then left to git.
+func deletesInTransaction(git GitExec, details *CommandDetails, updates []refUpdate) bool {
+	if details.SubCommand == "tag" {
+		return true
+	}
+	forced := slices.Contains(details.Args, "-D") || slices.ContainsFunc(details.Args, func(arg string) bool {
+		return arg == "-f" || arg == "--force"
+	})
+	if !forced {
+		return false
+	}
+	worktrees, err := git.GitOutput("worktree", "list", "--porcelain")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(worktrees, "\n") {
+		checkedOut, ok := strings.CutPrefix(strings.TrimSpace(line), "branch ")
+		if ok && slices.ContainsFunc(updates, func(update refUpdate) bool { return update.ref == checkedOut }) {
+			return false
+		}
+	}
+	return true
+}
+
+// lockReset locks HEAD (and the branch it points to) for a reset to a commit.
+// Soft, mixed and hard resets move HEAD within the transaction; other modes are only verified.
+func (cmd *UndoCommand) lockReset(args []string) error {
+	mode := "mixed"
+	var positional []string
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return nil // resetting paths doesn't move HEAD
+		case slices.Contains([]string{"--soft", "--mixed", "--hard", "--merge", "--keep"}, arg):
+			mode = strings.TrimPrefix(arg, "--")
+		case !strings.HasPrefix(arg, "-"):
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 1 {
+		return nil // resetting paths doesn't move HEAD
+	}
+	target := "HEAD"
+	if len(positional) == 1 {
+		target = positional[0]
+	}
+
+	newValue, err := cmd.git.GitOutput("rev-parse", "--verify", "-q", target+"^{commit}")
+	if err != nil {
+		return nil //nolint:nilerr // not a commit: it's a path reset
+	}
+	oldValue, err := cmd.git.GitOutput("rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return fmt.Errorf("cannot determine current HEAD: %w", err)
+	}
+
+	followUp, moves := resetFollowUps[mode]
+	if !moves {
+		cmd.lock = &refLock{updates: []refUpdate{{ref: "HEAD", oldValue: oldValue}}}
+		return nil
+	}
+	cmd.lock = &refLock{
+		// Updating HEAD updates the branch it points to, and both reflogs
+		updates:       []refUpdate{{ref: "HEAD", oldValue: oldValue, newValue: newValue}},
+		reflogMessage: "reset: moving to " + target,
+		followUp:      followUp,
+		// ORIG_HEAD has no reflog: it's set outside of the transaction (which creates them)
+		aftermath: [][]string{{"update-ref", "--no-deref", "ORIG_HEAD", oldValue}},
+	}
+	return nil
+}
+
+// execLocked applies the ref updates of the locked command in a single transaction, then runs the rest.
+func (cmd *UndoCommand) execLocked(git GitExec, details *CommandDetails) error {
+	var input strings.Builder
+	moves := false
+	for _, update := range cmd.lock.updates {
+		input.WriteString(update.instruction())
+		moves = moves || update.moves()
+	}
+
+	// The deleted item is kept in the trash by the transaction checking the refs: it's only trashed
+	// when they are unchanged, and it's never lost in between (the refs may be deleted by the transaction).
+	// The reflog message is shared by all refs, so only a transaction without one of its own can trash.
+	reflogMessage := cmd.lock.reflogMessage
+	trashed := cmd.trash != nil && reflogMessage == ""
+	if trashed {
+		input.WriteString(cmd.trash.instruction())
+		reflogMessage = TrashReflogMessage
+	}
+
+	args := []string{"--create-reflog", "--stdin"}
+	if reflogMessage != "" {
+		args = append([]string{"-m", reflogMessage}, args...)
+	}
+	if err := git.GitRunWithInput(input.String(), "update-ref", args...); err != nil {
+		return fmt.Errorf("%w: refusing to run `%s` (%w)", ErrRefChanged, cmd.Command, err)
+	}
+	if cmd.trash != nil && !trashed {
+		if err := cmd.moveToTrash(git); err != nil {
+			return err
+		}
+	}
+
+	if !moves {
+		return git.GitRun(details.SubCommand, details.Args...)
+	}
+	for _, args := range cmd.lock.aftermath {
+		_ = git.GitRun(args[0], args[1:]...)
+	}
+	if cmd.lock.followUp != nil {
+		return git.GitRun(cmd.lock.followUp[0], cmd.lock.followUp[1:]...)
+	}
+	return nil
+}
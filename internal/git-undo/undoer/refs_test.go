@@ -0,0 +1,134 @@
+package undoer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockRefs(t *testing.T) {
+	const (
+		head   = "1111111111111111111111111111111111111111"
+		parent = "2222222222222222222222222222222222222222"
+	)
+	updateArgs := []any{"update-ref", "-m", "reset: moving to HEAD~1", "--create-reflog", "--stdin"}
+
+	tests := []struct {
+		name      string
+		command   string
+		setupMock func(*MockGitExec)
+		errIs     error
+	}{
+		{
+			name:    "soft reset moves HEAD in the transaction",
+			command: "git reset --soft HEAD~1",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD~1^{commit}").Return(parent, nil)
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD").Return(head, nil)
+				m.On("GitRunWithInput", append([]any{"update HEAD " + parent + " " + head + "\n"},
+					updateArgs...)...).Return(nil)
+				m.On("GitRun", "update-ref", "--no-deref", "ORIG_HEAD", head).Return(nil)
+			},
+		},
+		{
+			name:    "hard reset updates index and working tree after moving HEAD",
+			command: "git reset --hard HEAD~1",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD~1^{commit}").Return(parent, nil)
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD").Return(head, nil)
+				m.On("GitRunWithInput", append([]any{"update HEAD " + parent + " " + head + "\n"},
+					updateArgs...)...).Return(nil)
+				m.On("GitRun", "update-ref", "--no-deref", "ORIG_HEAD", head).Return(nil)
+				m.On("GitRun", "read-tree", "--reset", "-u", "HEAD").Return(nil)
+			},
+		},
+		{
+			name:    "merge reset is verified and then run",
+			command: "git reset --merge ORIG_HEAD",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "ORIG_HEAD^{commit}").Return(parent, nil)
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD").Return(head, nil)
+				m.On("GitRunWithInput", "verify HEAD "+head+"\n", "update-ref", "--create-reflog", "--stdin").
+					Return(nil)
+				m.On("GitRun", "reset", "--merge", "ORIG_HEAD").Return(nil)
+			},
+		},
+		{
+			name:    "branch deletion happens in the transaction",
+			command: "git branch -D feature",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "refs/heads/feature").Return(head, nil)
+				m.On("GitOutput", "worktree", "list", "--porcelain").
+					Return("worktree /repo\nHEAD "+parent+"\nbranch refs/heads/main", nil)
+				m.On("GitRunWithInput", "delete refs/heads/feature "+head+"\n", "update-ref", "--create-reflog",
+					"--stdin").Return(nil)
+				m.On("GitRun", "config", "--remove-section", "branch.feature").Return(errors.New("no such section"))
+			},
+		},
+		{
+			name:    "deletion of a checked out branch is verified and then run",
+			command: "git branch -D feature",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "refs/heads/feature").Return(head, nil)
+				m.On("GitOutput", "worktree", "list", "--porcelain").
+					Return("worktree /repo\nHEAD "+parent+"\nbranch refs/heads/main\n\n"+
+						"worktree /other\nHEAD "+head+"\nbranch refs/heads/feature", nil)
+				m.On("GitRunWithInput", "verify refs/heads/feature "+head+"\n", "update-ref", "--create-reflog",
+					"--stdin").Return(nil)
+				m.On("GitRun", "branch", "-D", "feature").Return(nil)
+			},
+		},
+		{
+			name:    "tag deletion happens in the transaction",
+			command: "git tag -d v1.0.0",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "refs/tags/v1.0.0").Return(head, nil)
+				m.On("GitRunWithInput", "delete refs/tags/v1.0.0 "+head+"\n", "update-ref", "--create-reflog",
+					"--stdin").Return(nil)
+			},
+		},
+		{
+			name:    "moved HEAD refuses the reset",
+			command: "git reset --soft HEAD~1",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD~1^{commit}").Return(parent, nil)
+				m.On("GitOutput", "rev-parse", "--verify", "-q", "HEAD").Return(head, nil)
+				m.On("GitRunWithInput", append([]any{"update HEAD " + parent + " " + head + "\n"},
+					updateArgs...)...).Return(errors.New("exit status 128"))
+			},
+			errIs: undoer.ErrRefChanged,
+		},
+		{
+			name:    "path reset isn't locked",
+			command: "git reset -- file.txt",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitRun", "reset", "--", "file.txt").Return(nil)
+			},
+		},
+		{
+			name:      "commands not updating refs aren't locked",
+			command:   "git restore --staged file.txt",
+			setupMock: func(m *MockGitExec) { m.On("GitRun", "restore", "--staged", "file.txt").Return(nil) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := new(MockGitExec)
+			tt.setupMock(mockGit)
+
+			cmds := []*undoer.UndoCommand{undoer.NewUndoCommand(mockGit, tt.command, "")}
+			require.NoError(t, undoer.LockRefs(cmds))
+
+			err := cmds[0].Exec()
+			if tt.errIs != nil {
+				require.ErrorIs(t, err, tt.errIs)
+			} else {
+				require.NoError(t, err)
+			}
+			mockGit.AssertExpectations(t)
+		})
+	}
+}
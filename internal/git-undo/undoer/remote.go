@@ -0,0 +1,215 @@
+package undoer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RemoteUndoer handles undoing git remote operations: an added remote is removed, a renamed one is renamed back,
+// and the URLs or the whole config of a remote are restored as recorded right before set-url or remove
+// (see RecordedConfig).
+type RemoteUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+
+	// recorded is the config of the remote right before the command (nil when not recorded).
+	recorded []configValue
+}
+
+var _ Undoer = &RemoteUndoer{}
+
+// configValue is a single value of a config key.
+type configValue struct {
+	key   string
+	value string
+}
+
+// remoteAddValueFlags are the `git remote add` flags taking the next argument as their value.
+var remoteAddValueFlags = []string{"-t", "-m"}
+
+// RecordedConfig makes the undo restore the config of the remote recorded right before the command,
+// as printed by `git config -z --get-regexp`.
+func (r *RemoteUndoer) RecordedConfig(config string) {
+	r.recorded = []configValue{}
+	for _, item := range strings.Split(config, "\x00") {
+		if item == "" {
+			continue
+		}
+		// A key without a value (a bare boolean) has no newline
+		key, value, found := strings.Cut(item, "\n")
+		if !found {
+			value = "true"
+		}
+		r.recorded = append(r.recorded, configValue{key: key, value: value})
+	}
+}
+
+// RemoteTarget returns the remote whose config the git remote arguments change or remove
+// (so it has to be recorded before the command). It returns false for other remote operations.
+func RemoteTarget(args []string) (string, bool) {
+	positional := positionalArgs(args, nil)
+	if len(positional) < 2 || !slices.Contains([]string{"set-url", "remove", "rm"}, positional[0]) {
+		return "", false
+	}
+	return positional[1], true
+}
+
+// GetUndoCommands returns the commands that would undo the remote operation.
+func (r *RemoteUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	switch r.originalCmd.Action {
+	case "add":
+		return r.undoAdd()
+	case "rename":
+		return r.undoRename()
+	case "set-url":
+		return r.undoSetURL()
+	case "remove", "rm":
+		return r.undoRemove()
+	case "", "show", "get-url":
+		return nil, fmt.Errorf("%w: `git remote %s` doesn't change anything", ErrUndoNotSupported, r.originalCmd.Action)
+	default:
+		return nil, r.unsupported("`git remote %s` can't be undone", r.originalCmd.Action)
+	}
+}
+
+// undoAdd removes the added remote (with its remote-tracking branches).
+func (r *RemoteUndoer) undoAdd() ([]*UndoCommand, error) {
+	positional := positionalArgs(r.originalCmd.ActionArgs, remoteAddValueFlags)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no remote name found in command: %s", r.originalCmd.FullCommand)
+	}
+	name := positional[0]
+	if !r.remoteExists(name) {
+		return nil, fmt.Errorf("remote %s not found (it was removed or renamed since)", name)
+	}
+
+	var warnings []string
+	if refs, _ := r.git.GitOutput("for-each-ref", "--format=%(refname)", "refs/remotes/"+name+"/"); refs != "" {
+		warnings = append(warnings, fmt.Sprintf("The remote-tracking branches of %s are deleted too", name))
+	}
+	return []*UndoCommand{NewUndoCommand(r.git,
+		"git remote remove "+quotePath(name),
+		fmt.Sprintf("Remove remote %s", name),
+		warnings...,
+	)}, nil
+}
+
+// undoRename renames the remote back.
+func (r *RemoteUndoer) undoRename() ([]*UndoCommand, error) {
+	positional := positionalArgs(r.originalCmd.ActionArgs, nil)
+	if len(positional) < 2 {
+		return nil, fmt.Errorf("invalid remote rename command: %s", r.originalCmd.FullCommand)
+	}
+	oldName, newName := positional[0], positional[1]
+	if !r.remoteExists(newName) {
+		return nil, fmt.Errorf("remote %s not found (it was removed or renamed since)", newName)
+	}
+	if r.remoteExists(oldName) {
+		return nil, fmt.Errorf("remote %s exists again: can't rename %s back", oldName, newName)
+	}
+
+	return []*UndoCommand{NewUndoCommand(r.git,
+		fmt.Sprintf("git remote rename %s %s", quotePath(newName), quotePath(oldName)),
+		fmt.Sprintf("Rename remote %s back to %s", newName, oldName),
+	)}, nil
+}
+
+// undoSetURL restores the recorded (push) URLs of the remote.
+func (r *RemoteUndoer) undoSetURL() ([]*UndoCommand, error) {
+	args := r.originalCmd.ActionArgs
+	positional := positionalArgs(args, nil)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no remote name found in command: %s", r.originalCmd.FullCommand)
+	}
+	name := positional[0]
+	if r.recorded == nil {
+		return nil, r.unsupported("the previous URLs of %s weren't recorded "+
+			"(the shell hook records them right before git remote runs)", name)
+	}
+
+	key := "remote." + name + ".url"
+	if slices.Contains(args, "--push") {
+		key = "remote." + name + ".pushurl"
+	}
+	var values []string
+	for _, recorded := range r.recorded {
+		if recorded.key == key {
+			values = append(values, recorded.value)
+		}
+	}
+	current, _ := r.git.GitOutput("config", "--local", "--get-all", key)
+	currentValues := splitLines(current)
+	if slices.Equal(values, currentValues) {
+		return nil, fmt.Errorf("the URLs of %s are as before the command: nothing to undo", name)
+	}
+
+	if len(values) == 1 && len(currentValues) == 1 {
+		setURL := "git remote set-url "
+		if slices.Contains(args, "--push") {
+			setURL += "--push "
+		}
+		return []*UndoCommand{NewUndoCommand(r.git,
+			setURL+quotePath(name)+" "+quotePath(values[0]),
+			fmt.Sprintf("Restore the URL of %s to %s", name, values[0]),
+		)}, nil
+	}
+	return configRestoreCommands(r.git, "git config --local ", key, values, len(values) > 0), nil
+}
+
+// undoRemove adds the recorded config of the removed remote back.
+func (r *RemoteUndoer) undoRemove() ([]*UndoCommand, error) {
+	positional := positionalArgs(r.originalCmd.ActionArgs, nil)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no remote name found in command: %s", r.originalCmd.FullCommand)
+	}
+	name := positional[0]
+	if len(r.recorded) == 0 {
+		return nil, r.unsupported("the config of %s wasn't recorded "+
+			"(the shell hook records it right before git remote runs)", name)
+	}
+	if r.remoteExists(name) {
+		return nil, fmt.Errorf("remote %s exists again: can't restore the removed one", name)
+	}
+
+	undoCmds := make([]*UndoCommand, 0, len(r.recorded))
+	for i, recorded := range r.recorded {
+		description := fmt.Sprintf("Restore %s", recorded.key)
+		var warnings []string
+		if i == 0 {
+			description = fmt.Sprintf("Restore remote %s", name)
+			warnings = append(warnings,
+				fmt.Sprintf("Its remote-tracking branches come back with `git fetch %s`", name),
+				"Branches that tracked it need their upstream set again (`git branch -u`)")
+		}
+		undoCmds = append(undoCmds, NewUndoCommand(r.git,
+			"git config --local --add "+quotePath(recorded.key)+" "+quotePath(recorded.value),
+			description,
+			warnings...,
+		))
+	}
+	return undoCmds, nil
+}
+
+// remoteExists returns true if the repository config has the remote.
+func (r *RemoteUndoer) remoteExists(name string) bool {
+	_, err := r.git.GitOutput("config", "--local", "--get-regexp", RemoteConfigPattern(name))
+	return err == nil
+}
+
+// RemoteConfigPattern returns the `git config --get-regexp` pattern matching the keys of the remote.
+func RemoteConfigPattern(name string) string {
+	return `^remote\.` + regexp.QuoteMeta(name) + `\.`
+}
+
+// unsupported returns the error of a remote operation whose undo isn't known,
+// with the guidance for a manual recovery.
+func (r *RemoteUndoer) unsupported(format string, args ...any) error {
+	return &UnsupportedError{
+		Command:    r.originalCmd.FullCommand,
+		Suggestion: GetSuggestion("remote"),
+		cause:      fmt.Errorf(format, args...),
+	}
+}
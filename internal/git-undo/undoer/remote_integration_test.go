@@ -0,0 +1,119 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRemoteUndoer_Integration tests undoing added, renamed, re-pointed and removed remotes.
+func TestRemoteUndoer_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	// record captures the remote config the way the pre-hook does
+	record := func(name string) string {
+		out, err := gitExec.GitOutput("config", "--local", "-z", "--get-regexp", undoer.RemoteConfigPattern(name))
+		require.NoError(t, err)
+		return out
+	}
+	undo := func(command, recorded string) []*undoer.UndoCommand {
+		u := undoer.New(command, gitExec)
+		if recorded != "" {
+			u.(*undoer.RemoteUndoer).RecordedConfig(recorded)
+		}
+		undoCmds, err := u.GetUndoCommands()
+		require.NoError(t, err)
+		for _, undoCmd := range undoCmds {
+			require.NoError(t, undoCmd.Exec())
+		}
+		return undoCmds
+	}
+
+	git("init", "-b", "main")
+
+	// Added remote is removed
+	git("remote", "add", "origin", "https://example.com/repo.git")
+	undoCmds := undo("git remote add origin https://example.com/repo.git", "")
+	assert.Equal(t, "git remote remove origin", undoCmds[0].Command)
+	assert.Empty(t, git("remote"))
+
+	// Renamed remote is renamed back
+	git("remote", "add", "origin", "https://example.com/repo.git")
+	git("remote", "rename", "origin", "upstream")
+	undoCmds = undo("git remote rename origin upstream", "")
+	assert.Equal(t, "git remote rename upstream origin", undoCmds[0].Command)
+	assert.Equal(t, "origin", git("remote"))
+
+	// The recorded URL is restored
+	recorded := record("origin")
+	git("remote", "set-url", "origin", "https://example.com/moved.git")
+	undoCmds = undo("git remote set-url origin https://example.com/moved.git", recorded)
+	assert.Equal(t, "git remote set-url origin https://example.com/repo.git", undoCmds[0].Command)
+	assert.Equal(t, "https://example.com/repo.git", git("remote", "get-url", "origin"))
+
+	// A push URL that wasn't set is unset
+	recorded = record("origin")
+	git("remote", "set-url", "--push", "origin", "https://example.com/push.git")
+	undoCmds = undo("git remote set-url --push origin https://example.com/push.git", recorded)
+	assert.Equal(t, "git config --local --unset-all remote.origin.pushurl", undoCmds[0].Command)
+	assert.Equal(t, "https://example.com/repo.git", git("remote", "get-url", "--push", "origin"))
+
+	// Without the recorded URLs set-url can't be undone
+	_, err := undoer.New("git remote set-url origin https://example.com/moved.git", gitExec).GetUndoCommands()
+	var unsupported *undoer.UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+
+	// The removed remote gets its config back
+	git("config", "--add", "remote.origin.fetch", "+refs/tags/*:refs/tags/*")
+	recorded = record("origin")
+	git("remote", "remove", "origin")
+	undoCmds = undo("git remote remove origin", recorded)
+	require.Len(t, undoCmds, 3)
+	assert.Equal(t, recorded, record("origin"))
+
+	// A remote added again since can't be restored
+	recorded = record("origin")
+	git("remote", "remove", "origin")
+	git("remote", "add", "origin", "https://example.com/other.git")
+	u := undoer.New("git remote remove origin", gitExec)
+	u.(*undoer.RemoteUndoer).RecordedConfig(recorded)
+	_, err = u.GetUndoCommands()
+	require.ErrorContains(t, err, "exists again")
+}
+
+// TestRemoteTarget tests finding the remote whose config has to be recorded before git remote runs.
+func TestRemoteTarget(t *testing.T) {
+	tests := []struct {
+		args string
+		name string
+		ok   bool
+	}{
+		{"set-url origin https://example.com/repo.git", "origin", true},
+		{"set-url --push origin https://example.com/repo.git", "origin", true},
+		{"-v remove upstream", "upstream", true},
+		{"rm upstream", "upstream", true},
+		{"add origin https://example.com/repo.git", "", false},
+		{"rename origin upstream", "", false},
+		{"-v", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			name, ok := undoer.RemoteTarget(strings.Fields(tt.args))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
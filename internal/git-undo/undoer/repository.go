@@ -0,0 +1,288 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CloneUndoer handles undoing git clone operations: the cloned repository directory is removed,
+// as long as nothing was done in it since.
+type CloneUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &CloneUndoer{}
+
+// InitUndoer handles undoing git init operations: the created .git directory is removed,
+// as long as nothing was committed or staged since.
+type InitUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &InitUndoer{}
+
+// cloneValueFlags are the clone flags taking the next argument as their value.
+var cloneValueFlags = []string{"-o", "--origin", "-b", "--branch", "-u", "--upload-pack", "--reference",
+	"--reference-if-able", "--separate-git-dir", "--depth", "-c", "--config", "-j", "--jobs", "--template",
+	"--shallow-since", "--shallow-exclude", "--filter", "--bundle-uri", "--server-option"}
+
+// initValueFlags are the init flags taking the next argument as their value.
+var initValueFlags = []string{"--template", "--separate-git-dir", "--object-format", "--ref-format",
+	"-b", "--initial-branch"}
+
+// removeDirCommand returns the command removing the directory: it isn't a git command, so it can't be
+// run anywhere but in the repository it was planned in (e.g. not in a simulation sandbox).
+func removeDirCommand(git GitExec, dir, description string, warnings ...string) *UndoCommand {
+	cmd := NewUndoCommand(git, "rm -rf "+quotePath(dir), description, warnings...)
+	cmd.removeDir = dir
+	cmd.Confirm = true
+	return cmd
+}
+
+// execRemoveDir removes the directory of the command.
+func (cmd *UndoCommand) execRemoveDir(git GitExec) error {
+	if git != cmd.git {
+		return fmt.Errorf("%w: removing %s can't be run in another repository", ErrUndoNotSupported, cmd.removeDir)
+	}
+	if err := os.RemoveAll(cmd.removeDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", cmd.removeDir, err)
+	}
+	return nil
+}
+
+// positionalArgs returns the non-flag arguments, skipping the values of the given flags.
+func positionalArgs(args []string, valueFlags []string) []string {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			return append(positional, args[i+1:]...)
+		case slices.Contains(valueFlags, args[i]):
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			positional = append(positional, args[i])
+		}
+	}
+	return positional
+}
+
+// workingDir returns the directory git commands run in: the one relative paths of logged commands start from.
+func workingDir(git GitExec) (string, error) {
+	toplevel, err := git.GitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the working directory: %w", err)
+	}
+	prefix, _ := git.GitOutput("rev-parse", "--show-prefix")
+	return filepath.Join(strings.TrimSpace(toplevel), strings.TrimSpace(prefix)), nil
+}
+
+// GetUndoCommands returns the commands that would undo the clone.
+func (c *CloneUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	positional := positionalArgs(c.originalCmd.Args, cloneValueFlags)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no repository found in command: %s", c.originalCmd.FullCommand)
+	}
+	bare := slices.Contains(c.originalCmd.Args, "--bare") || slices.Contains(c.originalCmd.Args, "--mirror")
+	if slices.ContainsFunc(c.originalCmd.Args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--separate-git-dir")
+	}) {
+		return nil, &UnsupportedError{
+			Command:    c.originalCmd.FullCommand,
+			Suggestion: GetSuggestion("clone"),
+			cause:      errors.New("the clone has a separate git dir"),
+		}
+	}
+
+	dir := cloneDirName(positional[0], bare)
+	if len(positional) > 1 {
+		dir = positional[1]
+	}
+	if !filepath.IsAbs(dir) {
+		cwd, err := workingDir(c.git)
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cwd, dir)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("cloned directory %s not found: %w", dir, err)
+	}
+	// Without its own repository, the directory would resolve to the one it's in
+	root := "--show-toplevel"
+	if bare {
+		root = "--absolute-git-dir"
+	}
+	own, err := c.git.GitOutput("-C", dir, "rev-parse", root)
+	if err != nil || !isSameDir(strings.TrimSpace(own), dir) {
+		return nil, fmt.Errorf("%s is not a repository anymore: refusing to remove it", dir)
+	}
+
+	// Local commits or changes would be lost with the directory
+	if bare {
+		if err := c.checkBareCloneUnchanged(dir); err != nil {
+			return nil, err
+		}
+	} else {
+		unpushed, err := c.git.GitOutput("-C", dir, "rev-list", "--count", "--branches", "--not", "--remotes")
+		if err != nil || strings.TrimSpace(unpushed) != "0" {
+			return nil, fmt.Errorf("%s has commits that are not on its remote (or isn't a repository anymore): "+
+				"refusing to remove it", dir)
+		}
+		if status, err := c.git.GitOutput("-C", dir, "status", "--porcelain"); err != nil ||
+			strings.TrimSpace(status) != "" {
+			return nil, fmt.Errorf("%s has local changes: refusing to remove it", dir)
+		}
+	}
+
+	return []*UndoCommand{removeDirCommand(c.git, dir,
+		fmt.Sprintf("Remove the cloned repository %s", dir),
+		fmt.Sprintf("This deletes the directory %s with everything in it", dir),
+	)}, nil
+}
+
+// checkBareCloneUnchanged checks that the bare (or mirror) clone has no commits its source doesn't:
+// without remote-tracking branches, its refs are compared with the ones of the source.
+func (c *CloneUndoer) checkBareCloneUnchanged(dir string) error {
+	remote, err := c.git.GitOutput("-C", dir, "remote")
+	if err != nil {
+		return fmt.Errorf("%s isn't a repository anymore: refusing to remove it", dir)
+	}
+	remote, _, _ = strings.Cut(strings.TrimSpace(remote), "\n")
+	if remote == "" {
+		return fmt.Errorf("%s has no remote to compare it with: refusing to remove it", dir)
+	}
+
+	sourceRefs, err := c.git.GitOutput("-C", dir, "ls-remote", remote)
+	if err != nil {
+		return fmt.Errorf("%s can't be compared with its source (%w): refusing to remove it", dir, err)
+	}
+	args := []string{"-C", dir, "rev-list", "--count", "--ignore-missing", "--all", "--not"}
+	for line := range strings.SplitSeq(strings.TrimSpace(sourceRefs), "\n") {
+		if sha, _, ok := strings.Cut(line, "\t"); ok {
+			args = append(args, sha)
+		}
+	}
+	unpushed, err := c.git.GitOutput(args[0], args[1:]...)
+	if err != nil || strings.TrimSpace(unpushed) != "0" {
+		return fmt.Errorf("%s has commits that are not on its source: refusing to remove it", dir)
+	}
+	return nil
+}
+
+// cloneDirName returns the directory git clone creates for the repository
+// (e.g. https://host/org/project.git is cloned into project, or project.git when bare).
+func cloneDirName(repository string, bare bool) string {
+	name := strings.TrimRight(repository, "/")
+	name = strings.TrimSuffix(name, "/.git")
+	if index := strings.LastIndexAny(name, "/:"); index >= 0 {
+		name = name[index+1:]
+	}
+	name = strings.TrimSuffix(name, ".git")
+	if bare {
+		name += ".git"
+	}
+	return name
+}
+
+// GetUndoCommands returns the commands that would undo the init.
+func (i *InitUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	if slices.ContainsFunc(i.originalCmd.Args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--separate-git-dir")
+	}) {
+		return nil, &UnsupportedError{
+			Command:    i.originalCmd.FullCommand,
+			Suggestion: GetSuggestion("init"),
+			cause:      errors.New("the repository has a separate git dir"),
+		}
+	}
+
+	// The undo runs in the created repository (it's where the init got logged), unless the init was given
+	// another directory: it got logged in the repository it ran in, so the created one is looked up there
+	bare := slices.Contains(i.originalCmd.Args, "--bare")
+	dir, err := i.targetDir()
+	if err != nil {
+		return nil, err
+	}
+	var at []string
+	if dir != "" {
+		at = []string{"-C", dir}
+	}
+	gitOutput := func(args ...string) (string, error) {
+		args = append(slices.Clone(at), args...)
+		return i.git.GitOutput(args[0], args[1:]...)
+	}
+
+	gitDir, err := gitOutput("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the created repository: %w", err)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	// Without its own repository, the directory would resolve to the one it's in
+	if dir != "" {
+		created := filepath.Join(dir, ".git")
+		if bare {
+			created = dir
+		}
+		if !isSameDir(gitDir, created) {
+			return nil, fmt.Errorf("%s is not a repository anymore: nothing to remove", dir)
+		}
+	}
+
+	// A repository with commits or staged files was either reinitialized or used since
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		return nil, errors.New("the repository has commits (it was used since, or the init reinitialized it): " +
+			"refusing to remove it")
+	}
+	refs, _ := gitOutput("for-each-ref", "--format=%(refname)", "refs/heads/", "refs/tags/")
+	if strings.TrimSpace(refs) != "" {
+		return nil, errors.New("the repository has branches or tags: refusing to remove it")
+	}
+	if staged, _ := gitOutput("ls-files", "--cached"); strings.TrimSpace(staged) != "" {
+		return nil, errors.New("the repository has staged files: refusing to remove it")
+	}
+
+	cmd := removeDirCommand(i.git, gitDir,
+		fmt.Sprintf("Remove the repository created in %s", filepath.Dir(gitDir)),
+		fmt.Sprintf("This deletes %s (your files outside of it are kept)", gitDir),
+	)
+	if bare {
+		cmd.Description = fmt.Sprintf("Remove the bare repository %s", gitDir)
+		cmd.Warnings = []string{fmt.Sprintf("This deletes the directory %s with everything in it", gitDir)}
+	}
+	// The git-undo log goes with the repository the undo runs in (not with the one `git init <dir>` created)
+	own, _ := i.git.GitOutput("rev-parse", "--absolute-git-dir")
+	cmd.RemovesRepository = isSameDir(strings.TrimSpace(own), gitDir)
+	return []*UndoCommand{cmd}, nil
+}
+
+// targetDir returns the directory given to the init (absolute), or nothing when it ran in the current one.
+func (i *InitUndoer) targetDir() (string, error) {
+	positional := positionalArgs(i.originalCmd.Args, initValueFlags)
+	if len(positional) == 0 {
+		return "", nil
+	}
+	dir := positional[0]
+	if !filepath.IsAbs(dir) {
+		cwd, err := workingDir(i.git)
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cwd, dir)
+	}
+	return dir, nil
+}
+
+// isSameDir reports whether both paths are the same existing directory (whatever symlinks lead to it).
+func isSameDir(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,142 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloneUndoer_Integration tests removing a cloned repository, unless it was used since.
+func TestCloneUndoer_Integration(t *testing.T) {
+	remoteDir, repoDir := t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git(remoteDir, "init", "-b", "main")
+	git(remoteDir, "-c", "user.email=test@example.com", "-c", "user.name=Test User",
+		"commit", "--allow-empty", "-m", "first")
+	git(repoDir, "init", "-b", "main")
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// The directory is named after the repository
+	git(repoDir, "clone", "-q", remoteDir+"/.git")
+	clonedDir := filepath.Join(repoDir, filepath.Base(remoteDir))
+	cloneCmd := "git clone -q " + remoteDir + "/.git"
+	undoCmds, err := undoer.New(cloneCmd, gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.True(t, undoCmds[0].Confirm)
+	assert.Equal(t, "Remove the cloned repository "+clonedDir, undoCmds[0].Description)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.NoDirExists(t, clonedDir)
+
+	// Changes made in the clone would be lost: it's kept
+	git(repoDir, "clone", "-q", "--origin", "upstream", remoteDir, "cloned")
+	clonedDir = filepath.Join(repoDir, "cloned")
+	cloneCmd = "git clone -q --origin upstream " + remoteDir + " cloned"
+	require.NoError(t, os.WriteFile(filepath.Join(clonedDir, "new.txt"), []byte("new"), 0644))
+	_, err = undoer.New(cloneCmd, gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "has local changes")
+
+	// So are local commits
+	git(clonedDir, "add", "new.txt")
+	git(clonedDir, "-c", "user.email=test@example.com", "-c", "user.name=Test User", "commit", "-m", "local")
+	_, err = undoer.New(cloneCmd, gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "has commits that are not on its remote")
+	assert.DirExists(t, clonedDir)
+
+	// Once its repository is gone, the directory would resolve to the one it's in: it's kept
+	require.NoError(t, os.RemoveAll(filepath.Join(clonedDir, ".git")))
+	require.NoError(t, os.Remove(filepath.Join(clonedDir, "new.txt")))
+	_, err = undoer.New(cloneCmd, gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "is not a repository anymore")
+	assert.DirExists(t, clonedDir)
+	require.NoError(t, os.RemoveAll(clonedDir))
+
+	// Bare and mirror clones have no remote-tracking branches: they're compared with their source
+	for _, flag := range []string{"--bare", "--mirror"} {
+		git(repoDir, "clone", "-q", flag, remoteDir, "cloned"+flag+".git")
+		clonedDir = filepath.Join(repoDir, "cloned"+flag+".git")
+		cloneCmd = "git clone -q " + flag + " " + remoteDir + " cloned" + flag + ".git"
+		undoCmds, err = undoer.New(cloneCmd, gitExec).GetUndoCommands()
+		require.NoError(t, err, flag)
+		require.Len(t, undoCmds, 1)
+
+		tree := git(clonedDir, "rev-parse", "main^{tree}")
+		commit := git(clonedDir, "-c", "user.email=test@example.com", "-c", "user.name=Test User",
+			"commit-tree", "-p", "main", "-m", "pushed to the clone", tree)
+		git(clonedDir, "update-ref", "refs/heads/main", commit)
+		_, err = undoer.New(cloneCmd, gitExec).GetUndoCommands()
+		require.ErrorContains(t, err, "has commits that are not on its source", flag)
+
+		require.NoError(t, undoCmds[0].Exec())
+		assert.NoDirExists(t, clonedDir)
+	}
+}
+
+// TestInitUndoer_Integration tests removing a created repository, unless it was used since.
+func TestInitUndoer_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "file.txt"), []byte("content"), 0644))
+	git("init", "-b", "main")
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// Staged files mean the repository was used
+	git("add", "file.txt")
+	_, err := undoer.New("git init -b main", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "has staged files")
+	git("rm", "-q", "--cached", "file.txt")
+
+	undoCmds, err := undoer.New("git init -b main", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.True(t, undoCmds[0].Confirm)
+	assert.True(t, undoCmds[0].RemovesRepository)
+	require.NoError(t, undoCmds[0].Exec())
+
+	// Only the repository is removed: the files are kept
+	assert.NoDirExists(t, filepath.Join(repoDir, ".git"))
+	assert.FileExists(t, filepath.Join(repoDir, "file.txt"))
+
+	// A repository with commits is never removed
+	git("init", "-b", "main")
+	git("-c", "user.email=test@example.com", "-c", "user.name=Test User", "commit", "--allow-empty", "-m", "first")
+	_, err = undoer.New("git init", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "has commits")
+
+	// `git init <dir>` is logged in the repository it ran in: the created repository is removed, not that one
+	git("init", "-q", "-b", "main", "nested")
+	undoCmds, err = undoer.New("git init -q -b main nested", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.False(t, undoCmds[0].RemovesRepository)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.NoDirExists(t, filepath.Join(repoDir, "nested", ".git"))
+	assert.DirExists(t, filepath.Join(repoDir, ".git"))
+
+	// Once gone, the directory would resolve to the repository it's in
+	_, err = undoer.New("git init -q -b main nested", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "is not a repository anymore")
+	assert.DirExists(t, filepath.Join(repoDir, ".git"))
+}
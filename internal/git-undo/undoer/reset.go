@@ -11,6 +11,10 @@ type ResetUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	// recordedSnapshot has the changes a hard reset discarded (see TargetSnapshot).
+	recordedSnapshot
+	pushGuard
 }
 
 var _ Undoer = &ResetUndoer{}
@@ -46,6 +50,11 @@ func (r *ResetUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 	}
 	previousHead := parts[0]
 
+	// Moving HEAD back drops the commits made on top of the reset target (e.g. after `git reset <other-branch>`)
+	if err := r.checkDropped(r.git, previousHead); err != nil {
+		return nil, err
+	}
+
 	// Determine the reset mode from the original command
 	resetMode := r.getResetMode()
 
@@ -90,7 +99,20 @@ func (r *ResetUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		return nil, fmt.Errorf("%w: unsupported reset mode: %s", ErrUndoNotSupported, resetMode)
 	}
 
-	return []*UndoCommand{NewUndoCommand(r.git, undoCommand, description, warnings...)}, nil
+	undoCmds := []*UndoCommand{NewUndoCommand(r.git, undoCommand, description, warnings...)}
+	if resetMode == "hard" && r.snapshot != nil {
+		// The uncommitted changes the reset discarded come back on top of the previous HEAD
+		worktree, err := r.git.GitOutput("rev-parse", "--show-toplevel")
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine the working tree: %w", err)
+		}
+		undoCmds = append(undoCmds, restoreSnapshotCommand(r.git, r.snapshot, strings.TrimSpace(worktree),
+			fmt.Sprintf("Restore the uncommitted changes of %s (snapshot %s)",
+				summarizePaths(r.snapshot.Files), r.snapshot.ID),
+			"Staged changes come back unstaged",
+		))
+	}
+	return undoCmds, nil
 }
 
 // getResetMode determines the reset mode from the original command arguments.
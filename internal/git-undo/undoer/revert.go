@@ -11,6 +11,8 @@ type RevertUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	pushGuard
 }
 
 var _ Undoer = &RevertUndoer{}
@@ -63,6 +65,11 @@ func (r *RevertUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		}
 	}
 
+	// A pushed commit is reverted instead of being removed from the published history
+	if remotes := r.pushedRemotes(r.git, currentHead); len(remotes) > 0 {
+		return []*UndoCommand{r.revertPushed(r.git, currentHead, "revert commit", remotes)}, nil
+	}
+
 	// Get parent commit to reset to
 	parentCommit, err := r.git.GitOutput("rev-parse", "HEAD~1")
 	if err != nil {
@@ -68,7 +68,7 @@ func TestRevertUndoer_GetUndoCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockGit := new(MockGitExec)
+			mockGit := new(MockGitExec).notPushed()
 			tt.setupMock(mockGit)
 
 			cmdDetails, err := undoer.ParseGitCommand(tt.command)
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
 // RmUndoer handles undoing git rm operations.
@@ -85,10 +87,24 @@ func (r *RmUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		warnings = append(warnings, "This was a recursive removal - all files and subdirectories will be restored")
 	}
 
-	// Use git restore to bring back both working tree and staged versions
-	return []*UndoCommand{NewUndoCommand(r.git,
-		fmt.Sprintf("git restore --source=HEAD --staged --worktree %s", strings.Join(files, " ")),
+	// Use git restore to bring back both working tree and staged versions (git checkout before git 2.23)
+	restoreCmd := "git restore --source=HEAD --staged --worktree"
+	if !supports(r.git, githelpers.CapRestore) {
+		restoreCmd = "git checkout HEAD --"
+	}
+	undoCmd := NewUndoCommand(r.git,
+		fmt.Sprintf("%s %s", restoreCmd, strings.Join(files, " ")),
 		fmt.Sprintf("Restore removed files: %s", strings.Join(files, ", ")),
 		warnings...,
-	)}, nil
+	)
+
+	if isRecursive {
+		// Summarize what the recursive removal expanded to (failing to get it is not fatal)
+		args := append([]string{"--cached", "--name-only", "--diff-filter=D", "--"}, files...)
+		if output, err := r.git.GitOutput("diff", args...); err == nil {
+			undoCmd.WithPaths(splitLines(output), restoreCmd, "Restore removed")
+		}
+	}
+
+	return []*UndoCommand{undoCmd}, nil
 }
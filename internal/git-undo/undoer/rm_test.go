@@ -42,9 +42,11 @@ func TestRmUndoer_GetUndoCommand(t *testing.T) {
 			command: "git rm -r src/",
 			setupMock: func(m *MockGitExec) {
 				m.On("GitRun", "rev-parse", "--verify", "HEAD").Return(nil)
+				m.On("GitOutput", "diff", "--cached", "--name-only", "--diff-filter=D", "--", "src/").
+					Return("src/main.go\nsrc/app/app.go\n", nil)
 			},
 			expectedCmd:  "git restore --source=HEAD --staged --worktree src/",
-			expectedDesc: "Restore removed files: src/",
+			expectedDesc: "Restore removed 2 files under src/",
 			expectError:  false,
 		},
 		{
@@ -93,3 +95,31 @@ func TestRmUndoer_GetUndoCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestRmUndoer_LimitPaths(t *testing.T) {
+	mockGit := new(MockGitExec)
+	mockGit.On("GitRun", "rev-parse", "--verify", "HEAD").Return(nil)
+	mockGit.On("GitOutput", "diff", "--cached", "--name-only", "--diff-filter=D", "--", "src/").
+		Return("src/main.go\nsrc/app/app.go\nsrc/app/my file.go\n", nil)
+
+	cmdDetails, err := undoer.ParseGitCommand("git rm -r src/")
+	require.NoError(t, err)
+
+	undoCmds, err := undoer.NewRmUndoerForTest(mockGit, cmdDetails).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Len(t, undoCmds[0].Paths, 3)
+
+	left, err := undoCmds[0].LimitPaths([]string{"src/app/**"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, left)
+	assert.Equal(t, "git restore --source=HEAD --staged --worktree -- :/src/app/app.go ':/src/app/my file.go'",
+		undoCmds[0].Command)
+	assert.Equal(t, "Restore removed 2 files under src/app/", undoCmds[0].Description)
+
+	left, err = undoCmds[0].LimitPaths([]string{"docs/**"})
+	require.NoError(t, err)
+	assert.Zero(t, left)
+
+	mockGit.AssertExpectations(t)
+}
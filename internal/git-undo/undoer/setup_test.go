@@ -1,6 +1,7 @@
 package undoer_test
 
 import (
+	"github.com/amberpixels/git-undo/internal/githelpers"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,6 +18,14 @@ func (m *MockGitExec) GitRun(subCmd string, args ...string) error {
 	return m.Called(mockArgs...).Error(0)
 }
 
+func (m *MockGitExec) GitRunWithInput(input string, subCmd string, args ...string) error {
+	mockArgs := []any{input, subCmd}
+	for _, arg := range args {
+		mockArgs = append(mockArgs, arg)
+	}
+	return m.Called(mockArgs...).Error(0)
+}
+
 func (m *MockGitExec) GitOutput(subCmd string, args ...string) (string, error) {
 	mockArgs := []any{subCmd}
 	for _, arg := range args {
@@ -25,3 +34,33 @@ func (m *MockGitExec) GitOutput(subCmd string, args ...string) (string, error) {
 	result := m.Called(mockArgs...)
 	return result.String(0), result.Error(1)
 }
+
+// versionedMockGitExec is a MockGitExec of the given git version.
+type versionedMockGitExec struct {
+	*MockGitExec
+
+	version githelpers.GitVersion
+}
+
+func (m versionedMockGitExec) GitVersion() githelpers.GitVersion {
+	return m.version
+}
+
+// versionedGitHelper is a git helper reporting the given git version.
+type versionedGitHelper struct {
+	*githelpers.H
+
+	version githelpers.GitVersion
+}
+
+func (h versionedGitHelper) GitVersion() githelpers.GitVersion {
+	return h.version
+}
+
+// notPushed makes the mock report that no commit is pushed (and that undoing drops no commit).
+func (m *MockGitExec) notPushed() *MockGitExec {
+	m.On("GitOutput", "for-each-ref", "--format=%(refname:short)", "--contains", mock.Anything, "refs/remotes").
+		Return("", nil).Maybe()
+	m.On("GitOutput", "rev-list", mock.Anything).Return("", nil).Maybe()
+	return m
+}
@@ -0,0 +1,53 @@
+package undoer
+
+import (
+	"fmt"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/snapshot"
+)
+
+// recordedSnapshot is the snapshot of the files a destructive command lost, taken right before it
+// (see TargetSnapshot).
+type recordedSnapshot struct {
+	snapshot *snapshot.Snapshot
+}
+
+// TargetSnapshot makes the undo restore the files from the given snapshot.
+func (r *recordedSnapshot) TargetSnapshot(snap *snapshot.Snapshot) {
+	r.snapshot = snap
+}
+
+// restoreSnapshotCommand returns the command copying the files of the snapshot back into the worktree:
+// it isn't a git command, so it can't be run anywhere but in the repository it was planned in.
+// Once executed, its Description reports the restored files; the ones missing from the snapshot are warned about.
+func restoreSnapshotCommand(git GitExec, snap *snapshot.Snapshot, worktree, description string,
+	warnings ...string) *UndoCommand {
+	if missing := snap.Missing(); len(missing) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Missing from snapshot %s (can't be restored): %s",
+			snap.ID, summarizePaths(missing)))
+	}
+	cmd := NewUndoCommand(git, fmt.Sprintf("cp -a %s/. %s", quotePath(snap.FilesDir()), quotePath(worktree)),
+		description, warnings...)
+	cmd.Report = true
+	cmd.restore = &snapshotRestore{snapshot: snap, worktree: worktree}
+	return cmd
+}
+
+// snapshotRestore is what a command restoring a snapshot restores, and where.
+type snapshotRestore struct {
+	snapshot *snapshot.Snapshot
+	worktree string
+}
+
+// execRestoreSnapshot copies the files of the snapshot of the command back.
+func (cmd *UndoCommand) execRestoreSnapshot(git GitExec) error {
+	if git != cmd.git {
+		return fmt.Errorf("%w: restoring snapshot %s can't be run in another repository",
+			ErrUndoNotSupported, cmd.restore.snapshot.ID)
+	}
+	restored, err := cmd.restore.snapshot.Restore(cmd.restore.worktree)
+	if len(restored) > 0 {
+		cmd.Description = fmt.Sprintf("Restored %s from snapshot %s", summarizePaths(restored), cmd.restore.snapshot.ID)
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/snapshot"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotUndoers_Integration tests restoring the files lost by destructive commands from snapshots.
+func TestSnapshotUndoers_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	write := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0600))
+	}
+	read := func(file string) string {
+		content, err := os.ReadFile(filepath.Join(repoDir, file))
+		require.NoError(t, err)
+		return string(content)
+	}
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	store := snapshot.NewStore(filepath.Join(repoDir, ".git"))
+	// run takes the snapshot the pre-hook would take, then runs the command
+	run := func(command string) *snapshot.Snapshot {
+		args := strings.Fields(command)[1:]
+		paths, err := snapshot.AffectedPaths(gitExec, args[0], args[1:])
+		require.NoError(t, err)
+		snap, err := store.Save(repoDir, command, paths)
+		require.NoError(t, err)
+		git(args...)
+		return snap
+	}
+	undo := func(command string, snap *snapshot.Snapshot) []*undoer.UndoCommand {
+		u := undoer.New(command, gitExec)
+		u.(interface{ TargetSnapshot(*snapshot.Snapshot) }).TargetSnapshot(snap)
+		undoCmds, err := u.GetUndoCommands()
+		require.NoError(t, err)
+		for _, undoCmd := range undoCmds {
+			require.NoError(t, undoCmd.Exec())
+		}
+		return undoCmds
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("tracked.txt", "base")
+	git("add", "tracked.txt")
+	git("commit", "-m", "base")
+
+	// Clean: the removed files come back
+	write("untracked.txt", "untracked")
+	snap := run("git clean -f")
+	undoCmds := undo("git clean -f", snap)
+	require.Len(t, undoCmds, 1)
+	assert.Contains(t, undoCmds[0].Command, "cp -a ")
+	assert.Equal(t, "untracked", read("untracked.txt"))
+	assert.True(t, undoCmds[0].Report)
+	assert.Equal(t, "Restored untracked.txt from snapshot "+snap.ID, undoCmds[0].Description)
+
+	// Files missing from the snapshot are reported, and skipped
+	write("other.txt", "other")
+	snap = run("git clean -f")
+	require.NoError(t, os.Remove(filepath.Join(snap.FilesDir(), "other.txt")))
+	undoCmds = undo("git clean -f", snap)
+	require.Len(t, undoCmds[0].Warnings, 1)
+	assert.Contains(t, undoCmds[0].Warnings[0], "Missing from snapshot "+snap.ID)
+	assert.Equal(t, "Restored untracked.txt from snapshot "+snap.ID, undoCmds[0].Description)
+	assert.NoFileExists(t, filepath.Join(repoDir, "other.txt"))
+
+	// ...and nothing is left to restore when all of them are missing
+	snap = run("git clean -f")
+	require.NoError(t, os.RemoveAll(snap.FilesDir()))
+	u := undoer.New("git clean -f", gitExec)
+	u.(*undoer.CleanUndoer).TargetSnapshot(snap)
+	_, err := u.GetUndoCommands()
+	require.ErrorContains(t, err, "none of them left")
+
+	// ...unless they exist again
+	write("untracked.txt", "untracked")
+	snap = run("git clean -f")
+	write("untracked.txt", "recreated")
+	u = undoer.New("git clean -f", gitExec)
+	u.(*undoer.CleanUndoer).TargetSnapshot(snap)
+	_, err = u.GetUndoCommands()
+	require.ErrorContains(t, err, "exist again")
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "untracked.txt")))
+
+	// Without a snapshot clean can't be undone
+	_, err = undoer.New("git clean -f", gitExec).GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+
+	// Checkout of paths: the overwritten changes come back
+	write("tracked.txt", "edited")
+	snap = run("git checkout -- tracked.txt")
+	assert.Equal(t, "base", read("tracked.txt"))
+	undo("git checkout -- tracked.txt", snap)
+	assert.Equal(t, "edited", read("tracked.txt"))
+
+	// ...unless the files were edited since, or no snapshot was taken
+	snap = run("git checkout -- tracked.txt")
+	write("tracked.txt", "edited again")
+	u = undoer.New("git checkout -- tracked.txt", gitExec)
+	require.IsType(t, &undoer.CheckoutPathsUndoer{}, u)
+	u.(*undoer.CheckoutPathsUndoer).TargetSnapshot(snap)
+	_, err = u.GetUndoCommands()
+	require.ErrorContains(t, err, "tracked.txt changed since the checkout")
+	git("checkout", "--", "tracked.txt")
+	_, err = undoer.New("git checkout -- tracked.txt", gitExec).GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+	require.ErrorContains(t, err, "no snapshot was taken")
+
+	// Hard reset: HEAD moves back and the discarded changes come back
+	base := git("rev-parse", "HEAD")
+	write("tracked.txt", "next")
+	git("commit", "-am", "next")
+	write("tracked.txt", "uncommitted")
+	snap = run("git reset --hard HEAD~1")
+	undoCmds = undo("git reset --hard HEAD~1", snap)
+	require.Len(t, undoCmds, 2)
+	assert.NotEqual(t, base, git("rev-parse", "HEAD"))
+	assert.Equal(t, "uncommitted", read("tracked.txt"))
+}
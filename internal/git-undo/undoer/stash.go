@@ -3,25 +3,120 @@ package undoer
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
-// StashUndoer handles undoing git stash operations.
+// recordedStash is the stash commit recorded with the entry of a stash command (see TargetStash).
+type recordedStash struct {
+	// stash is the commit of the stash created (or popped, applied, dropped) by the command.
+	// Stashes made later shift its stash@{n} index, so it's looked up by the commit.
+	stash string
+}
+
+// TargetStash makes the undo target the stash with the given commit instead of guessing it.
+func (r *recordedStash) TargetStash(commit string) {
+	r.stash = commit
+}
+
+// StashUndoer handles undoing git stash operations creating a stash (`git stash`, `git stash push`).
 type StashUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	recordedStash
+}
+
+// StashPopUndoer handles undoing git stash pop: the popped changes are reverted and the stash is stored back.
+type StashPopUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+
+	recordedStash
+}
+
+// StashApplyUndoer handles undoing git stash apply: the applied changes are reverted.
+type StashApplyUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+
+	recordedStash
 }
 
-var _ Undoer = &StashUndoer{}
+// StashDropUndoer handles undoing git stash drop: the dropped stash is stored back.
+type StashDropUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+
+	recordedStash
+}
+
+var (
+	_ Undoer = &StashUndoer{}
+	_ Undoer = &StashPopUndoer{}
+	_ Undoer = &StashApplyUndoer{}
+	_ Undoer = &StashDropUndoer{}
+)
+
+// ErrStashGone is returned when the stash created by the undone command was popped or dropped since.
+var ErrStashGone = errors.New("the stash is gone")
+
+// stashSubjectRe matches the subjects git gives to stash commits.
+var stashSubjectRe = regexp.MustCompile(`^(WIP on|On) [^:]+: `)
+
+// newStashUndoer returns the undoer of the stash subcommand of the command.
+func newStashUndoer(cmdDetails *CommandDetails, gitExec GitExec) Undoer {
+	switch stashAction(cmdDetails.Args) {
+	case "push", "save":
+		return &StashUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "pop":
+		return &StashPopUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "apply":
+		return &StashApplyUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "drop":
+		return &StashDropUndoer{originalCmd: cmdDetails, git: gitExec}
+	default:
+		return &InvalidUndoer{rawCommand: cmdDetails.FullCommand}
+	}
+}
+
+// stashAction returns the subcommand of git stash arguments: push when there is none (e.g. `git stash -u`).
+func stashAction(args []string) string {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "push"
+	}
+	return args[0]
+}
+
+// StashTarget returns the revision of the stash the git stash arguments pop, apply or drop
+// (so its commit has to be recorded before the command). It returns false for other stash operations.
+func StashTarget(args []string) (string, bool) {
+	if !slices.Contains([]string{"pop", "apply", "drop"}, stashAction(args)) {
+		return "", false
+	}
+	positional := positionalArgs(args[1:], nil)
+	if len(positional) == 0 {
+		return "stash@{0}", true
+	}
+	// A bare number is an index in the stash list
+	if _, err := strconv.Atoi(positional[0]); err == nil {
+		return "stash@{" + positional[0] + "}", true
+	}
+	return positional[0], true
+}
 
 // GetUndoCommands returns the commands that would undo the stash operation.
 func (s *StashUndoer) GetUndoCommands() ([]*UndoCommand, error) {
-	// Check if this was a stash pop/apply operation
-	for _, arg := range s.originalCmd.Args {
-		if arg == "pop" || arg == "apply" {
-			return nil, fmt.Errorf("%w for stash pop/apply", ErrUndoNotSupported)
-		}
+	if s.stash != "" {
+		return s.popRecordedStash()
 	}
 
 	// For stash push or plain stash, we need to pop the stash and drop it
@@ -31,9 +126,211 @@ func (s *StashUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		return nil, errors.New("no stashes found to undo")
 	}
 
-	// Pop the most recent stash and drop it
+	// Popping the most recent stash removes it: its commit is kept in the trash,
+	// so the stashed changes survive even if the popped ones get discarded later
+	stash, err := s.git.GitOutput("rev-parse", "--verify", "-q", "refs/stash")
+	if err != nil {
+		return nil, errors.New("no stashes found to undo")
+	}
+	stash = strings.TrimSpace(stash)
+
 	return []*UndoCommand{NewUndoCommand(s.git,
-		"git stash pop && git stash drop",
+		"git stash pop",
 		"Pop the most recent stash and remove it",
-	)}, nil
+	).keepInTrash(TrashKindStash, shortHash(stash), stash)}, nil
+}
+
+// popRecordedStash pops the stash the command created, wherever it is in the stash list now.
+func (s *StashUndoer) popRecordedStash() ([]*UndoCommand, error) {
+	index, err := stashIndex(s.git, s.stash)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case index < 0:
+		return nil, fmt.Errorf("cannot undo stash: %w: %s was popped or dropped since (see `git stash list`)",
+			ErrStashGone, shortHash(s.stash))
+	case index == 0:
+		return []*UndoCommand{NewUndoCommand(s.git,
+			"git stash pop",
+			"Pop the most recent stash and remove it",
+		).keepInTrash(TrashKindStash, shortHash(s.stash), s.stash)}, nil
+	default:
+		ref := fmt.Sprintf("stash@{%d}", index)
+		return []*UndoCommand{NewUndoCommand(s.git,
+			"git stash pop "+ref,
+			fmt.Sprintf("Pop %s (%s) and remove it", ref, shortHash(s.stash)),
+		).keepInTrash(TrashKindStash, shortHash(s.stash), s.stash)}, nil
+	}
+}
+
+// GetUndoCommands returns the commands that would undo the stash pop.
+func (s *StashPopUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	stash, warnings, err := droppedStash(s.git, s.stash)
+	if err != nil {
+		return nil, err
+	}
+	revertCmds, err := revertStashChanges(s.git, stash)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stash goes back first: the reverted changes are in it
+	storeCmd, err := storeStashCommand(s.git, stash, warnings...)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*UndoCommand{storeCmd}, revertCmds...), nil
+}
+
+// GetUndoCommands returns the commands that would undo the stash apply.
+func (s *StashApplyUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	stash := s.stash
+	if stash == "" {
+		// Not recorded: the stash is resolved as given to the command, hoping the stash list didn't shift since
+		rev, _ := StashTarget(s.originalCmd.Args)
+		resolved, err := s.git.GitOutput("rev-parse", "--verify", "-q", rev)
+		if err != nil {
+			return nil, fmt.Errorf("cannot undo stash apply: %w: %s not found", ErrStashGone, rev)
+		}
+		stash = strings.TrimSpace(resolved)
+	}
+	return revertStashChanges(s.git, stash)
+}
+
+// GetUndoCommands returns the commands that would undo the stash drop.
+func (s *StashDropUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	stash, warnings, err := droppedStash(s.git, s.stash)
+	if err != nil {
+		return nil, err
+	}
+	storeCmd, err := storeStashCommand(s.git, stash, warnings...)
+	if err != nil {
+		return nil, err
+	}
+	return []*UndoCommand{storeCmd}, nil
+}
+
+// stashIndex returns the index of the stash commit in the stash list (-1 if it's not there).
+func stashIndex(git GitExec, stash string) (int, error) {
+	output, err := git.GitOutput("stash", "list", "--format=%H")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stashes: %w", err)
+	}
+	return slices.Index(splitLines(output), stash), nil
+}
+
+// droppedStash returns the stash commit removed from the stash list by the command: the recorded one,
+// or (when not recorded) the most recent stash-like commit git fsck finds unreachable.
+func droppedStash(git GitExec, recorded string) (string, []string, error) {
+	if recorded != "" {
+		index, err := stashIndex(git, recorded)
+		if err != nil {
+			return "", nil, err
+		}
+		if index >= 0 {
+			return "", nil, fmt.Errorf("%s is in the stash list again: nothing to restore", shortHash(recorded))
+		}
+		return recorded, nil, nil
+	}
+
+	output, err := git.GitOutput("fsck", "--unreachable", "--no-reflogs", "--no-progress")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to look for the dropped stash: %w", err)
+	}
+	var found string
+	var foundTime int64
+	for _, line := range splitLines(output) {
+		commit, ok := strings.CutPrefix(line, "unreachable commit ")
+		if !ok {
+			continue
+		}
+		// Stash commits merge the index (and the untracked files) into the commit they were made on
+		info, err := git.GitOutput("log", "-1", "--format=%ct %p%n%s", commit)
+		if err != nil {
+			continue
+		}
+		header, subject, _ := strings.Cut(info, "\n")
+		fields := strings.Fields(header)
+		if len(fields) < 3 || !stashSubjectRe.MatchString(subject) {
+			continue
+		}
+		if ts, err := strconv.ParseInt(fields[0], 10, 64); err == nil && ts > foundTime {
+			found, foundTime = commit, ts
+		}
+	}
+	if found == "" {
+		return "", nil, fmt.Errorf("cannot undo: %w: no dropped stash found by `git fsck --unreachable`", ErrStashGone)
+	}
+	return found, []string{fmt.Sprintf("The stash wasn't recorded: %s is the most recent dropped one",
+		shortHash(found))}, nil
+}
+
+// storeStashCommand returns the command storing the stash commit back into the stash list, with its message.
+func storeStashCommand(git GitExec, stash string, warnings ...string) (*UndoCommand, error) {
+	message, err := git.GitOutput("log", "-1", "--format=%s", stash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stash %s: %w", shortHash(stash), err)
+	}
+	message = strings.TrimSpace(message)
+	return NewUndoCommand(git,
+		fmt.Sprintf("git stash store -m %s %s", quotePath(message), stash),
+		fmt.Sprintf("Restore stash %s (%s)", shortHash(stash), message),
+		warnings...,
+	), nil
+}
+
+// revertStashChanges returns the commands reverting the changes the stash brought into the working tree:
+// the paths it changed are restored from HEAD and the untracked files it had are removed.
+// It's refused when these paths changed since, as their changes would be lost.
+func revertStashChanges(git GitExec, stash string) ([]*UndoCommand, error) {
+	output, err := git.GitOutput("diff", "--name-only", stash+"^1", stash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stash %s: %w", shortHash(stash), err)
+	}
+	tracked := splitLines(output)
+	var untracked []string
+	if _, err := git.GitOutput("rev-parse", "--verify", "-q", stash+"^3"); err == nil {
+		output, err := git.GitOutput("ls-tree", "-r", "--name-only", stash+"^3")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the untracked files of stash %s: %w", shortHash(stash), err)
+		}
+		untracked = splitLines(output)
+	}
+	if len(tracked) == 0 && len(untracked) == 0 {
+		return nil, fmt.Errorf("stash %s has no changes: nothing to revert", shortHash(stash))
+	}
+
+	var undoCmds []*UndoCommand
+	if len(tracked) > 0 {
+		if _, err := git.GitOutput("diff", append([]string{"--quiet", stash, "--"}, tracked...)...); err != nil {
+			return nil, fmt.Errorf("the files of stash %s changed since it was applied: "+
+				"reverting them would lose these changes", shortHash(stash))
+		}
+		quoted := make([]string, len(tracked))
+		for i, path := range tracked {
+			quoted[i] = quotePath(path)
+		}
+		// git checkout before git 2.23
+		restoreCmd := "git restore --source=HEAD --staged --worktree --"
+		if !supports(git, githelpers.CapRestore) {
+			restoreCmd = "git checkout HEAD --"
+		}
+		undoCmds = append(undoCmds, NewUndoCommand(git,
+			restoreCmd+" "+strings.Join(quoted, " "),
+			"Revert the changes of stash "+shortHash(stash),
+		).WithPaths(tracked, restoreCmd, "Revert"))
+	}
+	if len(untracked) > 0 {
+		quoted := make([]string, len(untracked))
+		for i, path := range untracked {
+			quoted[i] = quotePath(path)
+		}
+		undoCmds = append(undoCmds, NewUndoCommand(git,
+			"git clean -f -q -- "+strings.Join(quoted, " "),
+			fmt.Sprintf("Remove the untracked files of stash %s: %s", shortHash(stash), summarizePaths(untracked)),
+		))
+	}
+	return undoCmds, nil
 }
@@ -0,0 +1,129 @@
+package undoer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStashUndoers_Integration tests undoing stash pop, apply and drop.
+func TestStashUndoers_Integration(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	write := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0644))
+	}
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+	undo := func(command, recorded string) []*undoer.UndoCommand {
+		u := undoer.New(command, gitExec)
+		if recorded != "" {
+			u.(interface{ TargetStash(string) }).TargetStash(recorded)
+		}
+		undoCmds, err := u.GetUndoCommands()
+		require.NoError(t, err)
+		for _, undoCmd := range undoCmds {
+			require.NoError(t, undoCmd.Exec())
+		}
+		return undoCmds
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("tracked.txt", "base")
+	git("add", "tracked.txt")
+	git("commit", "-m", "base")
+
+	write("tracked.txt", "stashed")
+	write("untracked.txt", "untracked")
+	git("stash", "push", "-u", "-m", "work")
+	stash := git("rev-parse", "stash@{0}")
+
+	// Pop: the stash is stored back and its changes are reverted
+	git("stash", "pop")
+	undoCmds := undo("git stash pop", stash)
+	require.Len(t, undoCmds, 3)
+	assert.Equal(t, "git stash store -m 'On main: work' "+stash, undoCmds[0].Command)
+	assert.Equal(t, stash, git("rev-parse", "stash@{0}"))
+	assert.Empty(t, git("status", "--porcelain"))
+
+	// Pop without the recorded stash: it's found among the unreachable commits
+	git("stash", "pop")
+	undoCmds = undo("git stash pop", "")
+	assert.Contains(t, undoCmds[0].Warnings[0], "most recent dropped one")
+	assert.Equal(t, stash, git("rev-parse", "stash@{0}"))
+
+	// Apply: the changes are reverted, the stash stays
+	git("stash", "apply")
+	undo("git stash apply", "")
+	assert.Empty(t, git("status", "--porcelain"))
+	assert.Equal(t, stash, git("rev-parse", "stash@{0}"))
+
+	// Before git 2.23 (no git restore), the changes are reverted via checkout
+	git("stash", "apply")
+	oldGit := versionedGitHelper{H: gitExec, version: githelpers.GitVersion{Major: 2, Minor: 20}}
+	undoCmds, err := undoer.New("git stash apply", oldGit).GetUndoCommands()
+	require.NoError(t, err)
+	assert.Equal(t, "git checkout HEAD -- tracked.txt", undoCmds[0].Command)
+	for _, undoCmd := range undoCmds {
+		require.NoError(t, undoCmd.Exec())
+	}
+	assert.Empty(t, git("status", "--porcelain"))
+
+	// Applied changes edited since aren't reverted
+	git("stash", "apply")
+	write("tracked.txt", "edited")
+	_, err = undoer.New("git stash apply", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "changed since it was applied")
+	git("checkout", "--", "tracked.txt")
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "untracked.txt")))
+
+	// Drop: the stash is stored back
+	git("stash", "drop")
+	undo("git stash drop", stash)
+	assert.Equal(t, stash, git("rev-parse", "stash@{0}"))
+
+	// A stash that is in the list again isn't restored twice
+	u := undoer.New("git stash drop", gitExec)
+	u.(*undoer.StashDropUndoer).TargetStash(stash)
+	_, err = u.GetUndoCommands()
+	require.ErrorContains(t, err, "in the stash list again")
+}
+
+// TestStashTarget tests finding the stash popped, applied or dropped by git stash arguments.
+func TestStashTarget(t *testing.T) {
+	tests := []struct {
+		args string
+		rev  string
+		ok   bool
+	}{
+		{"pop", "stash@{0}", true},
+		{"pop --index 2", "stash@{2}", true},
+		{"apply stash@{1}", "stash@{1}", true},
+		{"drop -q", "stash@{0}", true},
+		{"push -m message", "", false},
+		{"-u", "", false},
+		{"list", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			rev, ok := undoer.StashTarget(strings.Fields(tt.args))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.rev, rev)
+		})
+	}
+}
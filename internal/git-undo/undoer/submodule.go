@@ -0,0 +1,183 @@
+package undoer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SubmoduleUndoer handles undoing git submodule operations: an added submodule is deinitialized and removed,
+// and the submodules moved by `git submodule update --remote` are checked out at their recorded commits again.
+type SubmoduleUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &SubmoduleUndoer{}
+
+// submoduleAddValueFlags are the `git submodule add` flags taking the next argument as their value.
+var submoduleAddValueFlags = []string{"-b", "--branch", "--name", "--reference", "--depth"}
+
+// GetUndoCommands returns the commands that would undo the submodule operation.
+func (s *SubmoduleUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	switch s.originalCmd.Action {
+	case "add":
+		return s.undoAdd()
+	case "update":
+		if slices.Contains(s.originalCmd.ActionArgs, "--remote") {
+			return s.undoUpdateRemote()
+		}
+		return nil, s.unsupported("only `git submodule update --remote` can be undone")
+	default:
+		return nil, s.unsupported("`git submodule %s` can't be undone", s.originalCmd.Action)
+	}
+}
+
+// undoAdd deinitializes the added submodule and removes it from the index and .gitmodules,
+// along with its clone (under .git/modules), so it can be added again.
+func (s *SubmoduleUndoer) undoAdd() ([]*UndoCommand, error) {
+	positional := positionalArgs(s.originalCmd.ActionArgs, submoduleAddValueFlags)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no repository found in command: %s", s.originalCmd.FullCommand)
+	}
+	path := cloneDirName(positional[0], false)
+	if len(positional) > 1 {
+		path = filepath.Clean(positional[1])
+	}
+
+	// The submodule must still be only staged
+	fullPath, err := s.git.GitOutput("ls-files", "--full-name", "--", path)
+	if err != nil || strings.TrimSpace(fullPath) == "" {
+		return nil, fmt.Errorf("submodule %s not found in the index (it was removed since)", path)
+	}
+	fullPath = strings.TrimSpace(fullPath)
+	if committed, _ := s.git.GitOutput("ls-tree", "HEAD", "--", fullPath); strings.TrimSpace(committed) != "" {
+		return nil, fmt.Errorf("submodule %s was committed since: cannot undo the add", path)
+	}
+
+	// Its clone is removed: local commits or changes would be lost with it
+	moduleGitDir, err := s.git.GitOutput("-C", path, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return nil, fmt.Errorf("submodule %s is not checked out: %w", path, err)
+	}
+	moduleGitDir = strings.TrimSpace(moduleGitDir)
+	unpushed, err := s.git.GitOutput("-C", path, "rev-list", "--count", "HEAD", "--branches", "--not", "--remotes")
+	if err != nil || strings.TrimSpace(unpushed) != "0" {
+		return nil, fmt.Errorf("submodule %s has commits that are not on its remote: refusing to remove it", path)
+	}
+	if status, err := s.git.GitOutput("-C", path, "status", "--porcelain"); err != nil ||
+		strings.TrimSpace(status) != "" {
+		return nil, fmt.Errorf("submodule %s has local changes: refusing to remove it", path)
+	}
+
+	undoCmds := []*UndoCommand{
+		NewUndoCommand(s.git,
+			"git submodule deinit -q -f -- "+quotePath(path),
+			fmt.Sprintf("Deinitialize submodule %s", path),
+		),
+		// Removing the submodule removes its .gitmodules entry as well
+		NewUndoCommand(s.git,
+			"git rm -q -f -- "+quotePath(path),
+			fmt.Sprintf("Remove submodule %s from the index and .gitmodules", path),
+		),
+	}
+
+	// A .gitmodules created by the add is removed once its only entry is
+	_, inHead := s.git.GitOutput("cat-file", "-e", "HEAD:.gitmodules")
+	entries, _ := s.git.GitOutput("config", "-f", ".gitmodules", "--get-regexp", `^submodule\..*\.path$`)
+	if inHead != nil && len(splitLines(entries)) == 1 {
+		undoCmds = append(undoCmds, NewUndoCommand(s.git,
+			"git rm -q -f -- :/.gitmodules",
+			"Remove .gitmodules (created by the add)",
+		))
+	}
+
+	// Without its clone, the submodule can be added again
+	removeClone := removeDirCommand(s.git, moduleGitDir, fmt.Sprintf("Remove the clone of submodule %s", path))
+	removeClone.Confirm = false
+	return append(undoCmds, removeClone), nil
+}
+
+// undoUpdateRemote checks out the submodules updated from their remote at the commits recorded
+// in the superproject: the ones they were at before the update, as their reflogs tell.
+func (s *SubmoduleUndoer) undoUpdateRemote() ([]*UndoCommand, error) {
+	args := append([]string{"status", "--"}, positionalArgs(s.originalCmd.ActionArgs, nil)...)
+	status, err := s.git.GitOutput("submodule", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the status of submodules: %w", err)
+	}
+
+	var paths, warnings []string
+	for _, line := range strings.Split(status, "\n") {
+		// e.g. "+15ad26b5c726b03b6830aae2914f3c610b3b77bb libs/lib (heads/main)":
+		// the checked out commit differs from the recorded one
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "+") {
+			continue
+		}
+		path := fields[1]
+
+		recorded, err := s.git.GitOutput("rev-parse", "--verify", "--quiet", ":"+fullSubmodulePath(s.git, path))
+		if err != nil {
+			continue
+		}
+		// The update must be the latest checkout of the submodule, from the recorded commit
+		reflog, err := s.git.GitOutput("-C", path, "reflog", "show", "-n", "2", "--format=%H%x09%gs", "HEAD")
+		lines := splitLines(reflog)
+		if err != nil || len(lines) < 2 {
+			return nil, fmt.Errorf("submodule %s has no previous checkout to go back to", path)
+		}
+		_, lastCheckout, _ := strings.Cut(lines[0], "\t")
+		previous, _, _ := strings.Cut(lines[1], "\t")
+		// e.g. "checkout: moving from main to 15ad26b5c726b03b6830aae2914f3c610b3b77bb"
+		from, ok := strings.CutPrefix(lastCheckout, "checkout: moving from ")
+		if !ok {
+			return nil, fmt.Errorf("submodule %s was not moved by the update (or it has changed since)", path)
+		}
+		if previous != strings.TrimSpace(recorded) {
+			return nil, fmt.Errorf("submodule %s was not at its recorded commit before the update: "+
+				"cannot tell where to check it out", path)
+		}
+
+		// It was on a branch: the recorded commit is checked out detached
+		if from, _, _ = strings.Cut(from, " to "); from != previous {
+			warnings = append(warnings, fmt.Sprintf("Submodule %s is left detached at the commit of '%s'", path, from))
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("no submodule was moved by the update (the update was up to date?)")
+	}
+
+	quoted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		quoted = append(quoted, quotePath(path))
+	}
+	return []*UndoCommand{NewUndoCommand(s.git,
+		"git submodule update --checkout -- "+strings.Join(quoted, " "),
+		fmt.Sprintf("Check out the recorded commits of submodules %s", strings.Join(paths, ", ")),
+		warnings...,
+	)}, nil
+}
+
+// fullSubmodulePath returns the path of the submodule relative to the top of the superproject.
+func fullSubmodulePath(git GitExec, path string) string {
+	if fullPath, err := git.GitOutput("ls-files", "--full-name", "--", path); err == nil &&
+		strings.TrimSpace(fullPath) != "" {
+		return strings.TrimSpace(fullPath)
+	}
+	return path
+}
+
+// unsupported returns the error of a submodule operation whose undo isn't known,
+// with the guidance for a manual recovery.
+func (s *SubmoduleUndoer) unsupported(format string, args ...any) error {
+	return &UnsupportedError{
+		Command:    s.originalCmd.FullCommand,
+		Suggestion: GetSuggestion("submodule"),
+		cause:      fmt.Errorf(format, args...),
+	}
+}
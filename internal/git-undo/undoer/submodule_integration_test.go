@@ -0,0 +1,69 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubmoduleUndoer_Integration tests undoing submodule add and update --remote.
+func TestSubmoduleUndoer_Integration(t *testing.T) {
+	libDir, repoDir := t.TempDir(), t.TempDir()
+	git := func(dir string, args ...string) string {
+		// Local submodules are only allowed explicitly
+		cmd := exec.Command("git", append([]string{"-c", "protocol.file.allow=always"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	for _, dir := range []string{libDir, repoDir} {
+		git(dir, "init", "-b", "main")
+		git(dir, "config", "user.email", "test@example.com")
+		git(dir, "config", "user.name", "Test User")
+		git(dir, "commit", "--allow-empty", "-m", "first")
+	}
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// The added submodule is removed along with .gitmodules and its clone
+	git(repoDir, "submodule", "add", "-q", libDir, "libs/lib")
+	undoCmds, err := undoer.New("git submodule add -q "+libDir+" libs/lib", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 4)
+	assert.Equal(t, "git submodule deinit -q -f -- libs/lib", undoCmds[0].Command)
+	for _, undoCmd := range undoCmds {
+		require.NoError(t, undoCmd.Exec())
+	}
+	assert.Empty(t, git(repoDir, "status", "--porcelain"))
+	assert.NoDirExists(t, filepath.Join(repoDir, ".git", "modules", "libs", "lib"))
+
+	// It can be added again
+	git(repoDir, "submodule", "add", "-q", libDir, "libs/lib")
+	git(repoDir, "commit", "-m", "add lib")
+	recorded := git(repoDir, "rev-parse", ":libs/lib")
+
+	// Submodules updated from their remote are checked out at the recorded commits again
+	git(libDir, "commit", "--allow-empty", "-m", "second")
+	git(repoDir, "submodule", "update", "--remote")
+	require.NotEqual(t, recorded, git(filepath.Join(repoDir, "libs", "lib"), "rev-parse", "HEAD"))
+
+	undoCmds, err = undoer.New("git submodule update --remote", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git submodule update --checkout -- libs/lib", undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Warnings[0], "left detached at the commit of 'main'")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, recorded, git(filepath.Join(repoDir, "libs", "lib"), "rev-parse", "HEAD"))
+
+	// A committed submodule is not removed anymore
+	_, err = undoer.New("git submodule add -q "+libDir+" libs/lib", gitExec).GetUndoCommands()
+	require.ErrorContains(t, err, "was committed since")
+}
@@ -0,0 +1,175 @@
+package undoer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Suggestion is the guidance for a command that can't be undone automatically.
+type Suggestion struct {
+	// Changed describes what the command likely changed.
+	Changed string
+	// Reason explains why automatic undo isn't possible.
+	Reason string
+	// Recovery lists manual recovery commands (`<placeholders>` are to be filled by the user).
+	Recovery []string
+}
+
+// reflogRecovery is the recovery of commands that moved HEAD (or a branch).
+var reflogRecovery = []string{
+	"git reflog                  # find the commit you were on before",
+	"git reset --hard ORIG_HEAD  # if ORIG_HEAD still points to the state before the command",
+	"git reset --hard HEAD@{1}   # or pick the right entry from the reflog",
+}
+
+// suggestions are keyed by git subcommand.
+var suggestions = map[string]Suggestion{
+	"rebase": {
+		Changed:  "Rewrote commits of the current branch on top of another base.",
+		Reason:   "The original commits are replaced by new ones that git-undo doesn't track.",
+		Recovery: append([]string{"git rebase --abort          # if it's still in progress"}, reflogRecovery...),
+	},
+	"pull": {
+		Changed:  "Fetched remote commits and merged (or rebased) them into the current branch.",
+		Reason:   "A pull is a fetch plus a merge or rebase, and the fetched remote state can't be un-fetched.",
+		Recovery: reflogRecovery,
+	},
+	"am": {
+		Changed:  "Applied patches from a mailbox as new commits.",
+		Reason:   "Without a branch reflog, the number of created commits isn't known to git-undo.",
+		Recovery: append([]string{"git am --abort              # if applying is still in progress"}, reflogRecovery...),
+	},
+	"push": {
+		Changed: "Updated branches or tags on the remote.",
+		Reason:  "Others may have fetched the remote already: rewriting it must be a deliberate decision.",
+		Recovery: []string{
+			"git push --force-with-lease <remote> <previous-sha>:<branch>  # move the remote branch back",
+		},
+	},
+	"fetch": {
+		Changed: "Updated remote-tracking branches and FETCH_HEAD.",
+		Reason:  "Fetching doesn't change your branches or working tree, so there is nothing to undo.",
+		Recovery: []string{
+			"git reflog show <remote>/<branch>  # see where a remote-tracking branch was before",
+		},
+	},
+	"filter-repo": {
+		Changed: "Rewrote the whole history of the repository.",
+		Reason:  "Every rewritten commit gets a new identity and the old refs are removed.",
+		Recovery: []string{
+			"git reflog                  # old commits stay reachable from the reflog until it expires",
+			"re-clone the repository     # the safest way back if the original remote is intact",
+		},
+	},
+	"filter-branch": {
+		Changed:  "Rewrote the history of the filtered branches.",
+		Reason:   "Every rewritten commit gets a new identity.",
+		Recovery: []string{"git for-each-ref refs/original/  # filter-branch keeps the original refs here"},
+	},
+	"gc": {
+		Changed:  "Packed objects and pruned unreachable ones.",
+		Reason:   "Pruned objects are deleted from disk.",
+		Recovery: []string{"git fsck --lost-found        # recover objects that are still dangling"},
+	},
+	"prune": {
+		Changed:  "Deleted unreachable objects.",
+		Reason:   "Deleted objects are gone from disk.",
+		Recovery: []string{"git fsck --lost-found        # recover objects that are still dangling"},
+	},
+	"stash": {
+		Changed: "Changed the stash list.",
+		Reason:  "Cleared stashes leave no trace in it.",
+		Recovery: []string{
+			"git fsck --unreachable | grep commit  # dropped stashes stay as unreachable commits",
+			"git stash store -m <message> <commit>  # put one back into the stash list",
+		},
+	},
+	"worktree": {
+		Changed: "Added, moved or removed a linked working tree.",
+		Reason:  "Working trees live outside of the repository history.",
+		Recovery: []string{
+			"git worktree list",
+			"git worktree remove <path>  # or `git worktree add` to bring it back",
+		},
+	},
+	"submodule": {
+		Changed: "Changed submodule configuration or checked-out submodule commits.",
+		Reason:  "Submodules are separate repositories with their own history.",
+		Recovery: []string{
+			"git restore --staged .gitmodules <path>",
+			"git submodule update --init  # checkout recorded commits",
+		},
+	},
+	"config": {
+		Changed:  "Changed a git config value.",
+		Reason:   "The previous value is only known when the shell hook recorded it before the command.",
+		Recovery: []string{"git config --show-origin --get-all <key>  # check what is set, and where"},
+	},
+	"remote": {
+		Changed:  "Changed the URLs of a remote, or removed it.",
+		Reason:   "The previous config of the remote is only known when the shell hook recorded it beforehand.",
+		Recovery: []string{"git remote -v  # check the remotes, then add or set-url them back"},
+	},
+	"clone": {
+		Changed:  "Created a new repository.",
+		Reason:   "Only an untouched clone (no local commits or changes) is removed automatically.",
+		Recovery: []string{"delete the cloned directory"},
+	},
+	"init": {
+		Changed:  "Created (or reinitialized) a repository.",
+		Reason:   "Only an empty repository is removed automatically: reinitializing is harmless anyway.",
+		Recovery: []string{"delete the .git directory    # only if you really meant to create no repository"},
+	},
+}
+
+// defaultSuggestion is used for commands without a specific one.
+var defaultSuggestion = Suggestion{
+	Reason:   "git-undo doesn't know how to reverse this command.",
+	Recovery: reflogRecovery,
+}
+
+// GetSuggestion returns the guidance for the git subcommand that can't be undone automatically.
+func GetSuggestion(subCommand string) Suggestion {
+	if suggestion, ok := suggestions[subCommand]; ok {
+		return suggestion
+	}
+	return defaultSuggestion
+}
+
+// UnsupportedError is returned when a command can't be undone automatically.
+// It wraps ErrUndoNotSupported and carries the guidance for a manual recovery.
+type UnsupportedError struct {
+	Command    string
+	Suggestion Suggestion
+
+	cause error
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s", ErrUndoNotSupported, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", ErrUndoNotSupported, e.Command)
+}
+
+func (e *UnsupportedError) Unwrap() []error {
+	if e.cause != nil {
+		return []error{ErrUndoNotSupported, e.cause}
+	}
+	return []error{ErrUndoNotSupported}
+}
+
+// extractSubCommand returns the git subcommand of a raw command string (skipping git's global options).
+func extractSubCommand(rawCommand string) string {
+	fields := strings.Fields(rawCommand)
+	for i := 1; i < len(fields); i++ {
+		switch {
+		case fields[i] == "-C" || fields[i] == "-c":
+			i++ // skip the option value
+		case strings.HasPrefix(fields[i], "-"):
+		default:
+			return fields[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package undoer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsupportedCommandSuggestions(t *testing.T) {
+	tests := []struct {
+		name            string
+		command         string
+		expectedChanged string
+		expectRecovery  string
+	}{
+		{
+			name:            "gc",
+			command:         "git gc --prune=now",
+			expectedChanged: "Packed objects and pruned unreachable ones.",
+			expectRecovery:  "git fsck",
+		},
+		{
+			name:            "filter-repo (unknown to git-undo)",
+			command:         "git filter-repo --path src/",
+			expectedChanged: "Rewrote the whole history of the repository.",
+			expectRecovery:  "git reflog",
+		},
+		{
+			name:            "global options are skipped",
+			command:         "git -C repo -c gc.auto=0 gc --prune=now",
+			expectedChanged: "Packed objects and pruned unreachable ones.",
+			expectRecovery:  "git fsck",
+		},
+		{
+			name:           "no specific suggestion",
+			command:        "git bisect start",
+			expectRecovery: "git reflog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := undoer.New(tt.command, new(MockGitExec)).GetUndoCommands()
+			require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+
+			var unsupported *undoer.UnsupportedError
+			require.ErrorAs(t, err, &unsupported)
+			assert.Equal(t, tt.expectedChanged, unsupported.Suggestion.Changed)
+			assert.NotEmpty(t, unsupported.Suggestion.Reason)
+			assert.Contains(t, strings.Join(unsupported.Suggestion.Recovery, "\n"), tt.expectRecovery)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package undoer
 import (
 	"fmt"
 	"strings"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
 // SwitchUndoer handles undoing git switch operations.
@@ -20,8 +22,7 @@ func (s *SwitchUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 	for i, arg := range s.originalCmd.Args {
 		if (arg == "-c" || arg == "--create") && i+1 < len(s.originalCmd.Args) {
 			branchName := s.originalCmd.Args[i+1]
-			return []*UndoCommand{NewUndoCommand(s.git,
-				fmt.Sprintf("git branch -D %s", branchName),
+			return []*UndoCommand{newDeleteBranchCommand(s.git, branchName,
 				fmt.Sprintf("Delete branch '%s' created by switch -c", branchName),
 			)}, nil
 		}
@@ -30,8 +31,7 @@ func (s *SwitchUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 			branchName := s.originalCmd.Args[i+1]
 			// For force create, we can't easily restore the previous branch state
 			// so we provide a warning and delete the branch
-			return []*UndoCommand{NewUndoCommand(s.git,
-				fmt.Sprintf("git branch -D %s", branchName),
+			return []*UndoCommand{newDeleteBranchCommand(s.git, branchName,
 				fmt.Sprintf("Delete branch '%s' created by switch -C", branchName),
 				"Warning: switch -C may have overwritten an existing branch that cannot be restored",
 			)}, nil
@@ -58,9 +58,13 @@ func (s *SwitchUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 	warnings := collectWorkingDirWarnings(s.git, "branch switching", "switch undo")
 
 	// Use "git switch -" to go back to the previous branch
-	// git switch supports the same "-" syntax as git checkout
+	// git switch supports the same "-" syntax as git checkout (which is used before git 2.23)
+	switchBack := "git switch -"
+	if !supports(s.git, githelpers.CapSwitch) {
+		switchBack = "git checkout -"
+	}
 	return []*UndoCommand{NewUndoCommand(s.git,
-		"git switch -",
+		switchBack,
 		fmt.Sprintf("Switch back to previous branch (%s)", prevBranch),
 		warnings...,
 	)}, nil
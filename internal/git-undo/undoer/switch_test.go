@@ -6,6 +6,7 @@ import (
 
 	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,41 +20,66 @@ func TestSwitchUndoer_GetUndoCommand(t *testing.T) {
 		expectError    bool
 		errorContains  string
 		expectWarnings bool
+		expectTracking *undoer.BranchTracking
 	}{
 		{
-			name:         "branch creation with -c",
-			command:      "git switch -c feature-branch",
-			setupMock:    func(_ *MockGitExec) {},
+			name:    "branch creation with -c",
+			command: "git switch -c feature-branch",
+			setupMock: func(m *MockGitExec) {
+				mockUnchangedBranch(m, "feature-branch")
+				mockNoTracking(m, "feature-branch")
+			},
 			expectedCmd:  "git branch -D feature-branch",
 			expectedDesc: "Delete branch 'feature-branch' created by switch -c",
 			expectError:  false,
 		},
 		{
-			name:         "branch creation with --create",
-			command:      "git switch --create new-feature",
-			setupMock:    func(_ *MockGitExec) {},
+			name:    "branch creation with --create",
+			command: "git switch --create new-feature",
+			setupMock: func(m *MockGitExec) {
+				mockUnchangedBranch(m, "new-feature")
+				mockNoTracking(m, "new-feature")
+			},
 			expectedCmd:  "git branch -D new-feature",
 			expectedDesc: "Delete branch 'new-feature' created by switch -c",
 			expectError:  false,
 		},
 		{
-			name:           "force branch creation with -C",
-			command:        "git switch -C hotfix main",
-			setupMock:      func(_ *MockGitExec) {},
+			name:    "force branch creation with -C",
+			command: "git switch -C hotfix main",
+			setupMock: func(m *MockGitExec) {
+				mockUnchangedBranch(m, "hotfix")
+				mockNoTracking(m, "hotfix")
+			},
 			expectedCmd:    "git branch -D hotfix",
 			expectedDesc:   "Delete branch 'hotfix' created by switch -C",
 			expectError:    false,
 			expectWarnings: true,
 		},
 		{
-			name:           "force branch creation with --force-create",
-			command:        "git switch --force-create existing-branch",
-			setupMock:      func(_ *MockGitExec) {},
+			name:    "force branch creation with --force-create",
+			command: "git switch --force-create existing-branch",
+			setupMock: func(m *MockGitExec) {
+				mockUnchangedBranch(m, "existing-branch")
+				mockNoTracking(m, "existing-branch")
+			},
 			expectedCmd:    "git branch -D existing-branch",
 			expectedDesc:   "Delete branch 'existing-branch' created by switch -C",
 			expectError:    false,
 			expectWarnings: true,
 		},
+		{
+			name:    "branch creation with upstream tracking",
+			command: "git switch -c tracked",
+			setupMock: func(m *MockGitExec) {
+				mockUnchangedBranch(m, "tracked")
+				m.On("GitOutput", "config", "--get", "branch.tracked.remote").Return("origin\n", nil)
+				m.On("GitOutput", "config", "--get", "branch.tracked.merge").Return("refs/heads/tracked\n", nil)
+			},
+			expectedCmd:    "git branch -D tracked",
+			expectedDesc:   "Delete branch 'tracked' created by switch -c",
+			expectTracking: &undoer.BranchTracking{Branch: "tracked", Remote: "origin", Merge: "refs/heads/tracked"},
+		},
 		{
 			name:    "regular branch switch",
 			command: "git switch main",
@@ -140,9 +166,123 @@ func TestSwitchUndoer_GetUndoCommand(t *testing.T) {
 				if tt.expectWarnings {
 					assert.NotEmpty(t, undoCmds[0].Warnings)
 				}
+				assert.Equal(t, tt.expectTracking, undoCmds[0].Tracking)
+			}
+
+			mockGit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSwitchUndoer_MovedBranchIsWarnedAbout(t *testing.T) {
+	const (
+		created = "1111111111111111111111111111111111111111"
+		tip     = "2222222222222222222222222222222222222222"
+	)
+
+	tests := []struct {
+		name   string
+		reflog func(*mock.Call)
+	}{
+		{
+			name: "commits added after creation",
+			reflog: func(c *mock.Call) {
+				c.Return(tip+" commit: more work\n"+created+" branch: Created from HEAD", nil)
+			},
+		},
+		{
+			name:   "no reflog to find the creation point",
+			reflog: func(c *mock.Call) { c.Return("", errors.New("exit status 128")) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := new(MockGitExec)
+			mockGit.On("GitOutput", "rev-parse", "--verify", "-q", "refs/heads/feature").Return(tip+"\n", nil)
+			tt.reflog(mockGit.On("GitOutput", "reflog", "show", "--format=%H %gs", "refs/heads/feature"))
+			mockNoTracking(mockGit, "feature")
+
+			cmdDetails, err := undoer.ParseGitCommand("git switch -c feature")
+			require.NoError(t, err)
+			undoCmds, err := undoer.NewSwitchUndoerForTest(mockGit, cmdDetails).GetUndoCommands()
+			require.NoError(t, err)
+
+			require.Len(t, undoCmds, 1)
+			assert.Equal(t, "git branch -D feature", undoCmds[0].Command)
+			require.Len(t, undoCmds[0].Warnings, 1)
+			assert.Contains(t, undoCmds[0].Warnings[0], "2222222")
+
+			// The tip is kept in the trash before the branch gets deleted
+			mockGit.On("GitRun", "update-ref", "--create-reflog", "-m", undoer.TrashReflogMessage,
+				"refs/git-undo/trash/heads/feature", tip).Return(nil).Once()
+			mockGit.On("GitRun", "branch", "-D", "feature").Return(nil).Once()
+			require.NoError(t, undoCmds[0].Exec())
+			mockGit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSwitchUndoer_LockedDeletionTrashesInTransaction(t *testing.T) {
+	const tip = "1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name   string
+		result error
+		errIs  error
+	}{
+		{name: "unchanged branch is trashed and deleted at once"},
+		{name: "moved branch is neither trashed nor deleted", result: errors.New("exit status 128"),
+			errIs: undoer.ErrRefChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := new(MockGitExec)
+			mockUnchangedBranch(mockGit, "feature")
+			mockNoTracking(mockGit, "feature")
+
+			cmdDetails, err := undoer.ParseGitCommand("git switch -c feature")
+			require.NoError(t, err)
+			undoCmds, err := undoer.NewSwitchUndoerForTest(mockGit, cmdDetails).GetUndoCommands()
+			require.NoError(t, err)
+			require.Len(t, undoCmds, 1)
+
+			mockGit.On("GitOutput", "worktree", "list", "--porcelain").
+				Return("worktree /repo\nHEAD "+tip+"\nbranch refs/heads/main", nil)
+			require.NoError(t, undoer.LockRefs(undoCmds))
+
+			// The trash ref is only stored by the transaction checking the branch is unchanged
+			mockGit.On("GitRunWithInput",
+				"delete refs/heads/feature "+tip+"\nupdate refs/git-undo/trash/heads/feature "+tip+"\n",
+				"update-ref", "-m", undoer.TrashReflogMessage, "--create-reflog", "--stdin").
+				Return(tt.result).Once()
+			if tt.result == nil {
+				mockGit.On("GitRun", "config", "--remove-section", "branch.feature").Return(nil).Once()
 			}
 
+			err = undoCmds[0].Exec()
+			if tt.errIs != nil {
+				require.ErrorIs(t, err, tt.errIs)
+			} else {
+				require.NoError(t, err)
+			}
 			mockGit.AssertExpectations(t)
+			mockGit.AssertNotCalled(t, "GitRun", "update-ref", "--create-reflog", "-m", undoer.TrashReflogMessage,
+				"refs/git-undo/trash/heads/feature", tip)
 		})
 	}
 }
+
+// mockNoTracking mocks the branch having no upstream tracking configuration.
+func mockNoTracking(m *MockGitExec, branch string) {
+	m.On("GitOutput", "config", "--get", "branch."+branch+".remote").Return("", errors.New("exit status 1"))
+}
+
+// mockUnchangedBranch mocks the branch still pointing to the commit it was created at.
+func mockUnchangedBranch(m *MockGitExec, branch string) {
+	const tip = "1111111111111111111111111111111111111111"
+	m.On("GitOutput", "rev-parse", "--verify", "-q", "refs/heads/"+branch).Return(tip, nil)
+	m.On("GitOutput", "reflog", "show", "--format=%H %gs", "refs/heads/"+branch).
+		Return(tip+" branch: Created from HEAD", nil)
+}
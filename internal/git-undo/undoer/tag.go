@@ -2,32 +2,201 @@ package undoer
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-// TagUndoer handles undoing git tag operations.
+// TagUndoer handles undoing git tag operations: a created tag is deleted, deleted tags are recreated
+// and a force-moved tag (`git tag -f`) is moved back. Previous tag objects are recorded right before
+// the command; unrecorded annotated tags are looked for among the unreachable objects (see `git fsck`).
 type TagUndoer struct {
 	git GitExec
 
 	originalCmd *CommandDetails
+
+	// objects are the recorded objects the deleted or moved tags pointed to, by tag name.
+	objects map[string]string
 }
 
 var _ Undoer = &TagUndoer{}
 
-// GetUndoCommands returns the commands that would undo the tag creation.
+// RecordedObjects makes the undo point the deleted or moved tags back to the given objects
+// (recorded right before the command).
+func (t *TagUndoer) RecordedObjects(objects map[string]string) {
+	t.objects = objects
+}
+
+// TagChangeTargets returns the existing tags the git tag arguments delete or may move (with -f).
+// It returns false for arguments that can't change an existing tag.
+func TagChangeTargets(args []string) ([]string, bool) {
+	if isTagDeletion(args) {
+		var tags []string
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				tags = append(tags, arg)
+			}
+		}
+		return tags, len(tags) > 0
+	}
+
+	if !slices.Contains(args, "-f") && !slices.Contains(args, "--force") {
+		return nil, false
+	}
+	if tagName := findTagName(args); tagName != "" {
+		return []string{tagName}, true
+	}
+	return nil, false
+}
+
+// GetUndoCommands returns the commands that would undo the tag creation, deletion or move.
 func (t *TagUndoer) GetUndoCommands() ([]*UndoCommand, error) {
-	// Check if this was a tag deletion operation
-	for _, arg := range t.originalCmd.Args {
-		if arg == "-d" || arg == "-D" || arg == "--delete" {
-			return nil, fmt.Errorf("%w for tag deletion", ErrUndoNotSupported)
+	if isTagDeletion(t.originalCmd.Args) {
+		tags, _ := TagChangeTargets(t.originalCmd.Args)
+		return t.undoDeletion(tags)
+	}
+
+	tagName := findTagName(t.originalCmd.Args)
+	if tagName == "" {
+		return nil, fmt.Errorf("no tag name found in command: %s", t.originalCmd.FullCommand)
+	}
+
+	// Verify the tag exists before trying to delete it (the tag object is kept in the trash)
+	tagObject, err := t.git.GitOutput("rev-parse", "--verify", "refs/tags/"+tagName)
+	if err != nil {
+		return nil, fmt.Errorf("tag '%s' does not exist, cannot undo tag creation", tagName)
+	}
+
+	if _, forced := TagChangeTargets(t.originalCmd.Args); forced {
+		if recorded, ok := t.objects[tagName]; ok && recorded == tagObject {
+			return nil, fmt.Errorf("tag '%s' points where it did before the command: nothing to undo", tagName)
+		}
+		if previous, warnings := t.previousObject(tagName, tagObject); previous != "" {
+			return []*UndoCommand{NewUndoCommand(t.git,
+				fmt.Sprintf("git tag -f %s %s", tagName, previous),
+				fmt.Sprintf("Move tag '%s' back to %s", tagName, t.describeObject(previous)),
+				warnings...,
+			).keepInTrash(TrashKindTag, tagName, tagObject)}, nil
 		}
 	}
 
-	// Find the tag name - it's the first non-flag argument
-	tagName := ""
-	skipNext := false
+	return []*UndoCommand{NewUndoCommand(t.git,
+		fmt.Sprintf("git tag -d %s", tagName),
+		fmt.Sprintf("Delete tag '%s'", tagName),
+	).keepInTrash(TrashKindTag, tagName, tagObject)}, nil
+}
+
+// undoDeletion returns the commands recreating the deleted tags.
+func (t *TagUndoer) undoDeletion(tags []string) ([]*UndoCommand, error) {
+	undoCmds := make([]*UndoCommand, 0, len(tags))
+	for _, tagName := range tags {
+		if err := t.git.GitRun("show-ref", "--verify", "--quiet", "refs/tags/"+tagName); err == nil {
+			return nil, fmt.Errorf("tag '%s' exists again: nothing to recover", tagName)
+		}
 
-	for _, arg := range t.originalCmd.Args {
+		previous, warnings := t.previousObject(tagName, "")
+		if previous == "" {
+			return nil, fmt.Errorf("%w: the deleted tag '%s' wasn't recorded "+
+				"(only annotated tags can be found among unreachable objects)", ErrUndoNotSupported, tagName)
+		}
+
+		// Pointing the new tag to the annotated tag object brings back the annotated tag itself
+		undoCmds = append(undoCmds, NewUndoCommand(t.git,
+			fmt.Sprintf("git tag %s %s", tagName, previous),
+			fmt.Sprintf("Recreate deleted tag '%s' at %s", tagName, t.describeObject(previous)),
+			warnings...,
+		))
+	}
+	return undoCmds, nil
+}
+
+// previousObject returns the object the tag pointed to before the command (empty if unknown),
+// with a warning when it wasn't recorded but found among the unreachable objects
+// (other than the current object of the tag, if any).
+func (t *TagUndoer) previousObject(tagName, current string) (string, []string) {
+	if recorded, ok := t.objects[tagName]; ok {
+		return recorded, nil
+	}
+
+	found := t.unreachableTagObject(tagName, current)
+	if found == "" {
+		return "", nil
+	}
+	return found, []string{fmt.Sprintf("The previous object of tag '%s' wasn't recorded: "+
+		"it's the latest unreachable annotated tag of that name (%s)", tagName, shortHash(found))}
+}
+
+// unreachableTagObject returns the latest (by tagger date) unreachable annotated tag object of the given name
+// other than the current one: deleting or moving an annotated tag leaves its object behind until gc.
+func (t *TagUndoer) unreachableTagObject(tagName, current string) string {
+	output, err := t.git.GitOutput("fsck", "--unreachable", "--no-reflogs", "--no-progress")
+	if err != nil {
+		return ""
+	}
+
+	var latest string
+	var latestTime int64
+	for _, line := range splitLines(output) {
+		object, ok := strings.CutPrefix(line, "unreachable tag ")
+		if !ok || object == current {
+			continue
+		}
+		content, err := t.git.GitOutput("cat-file", "tag", object)
+		if err != nil {
+			continue
+		}
+		name, taggedAt := parseTagHeader(content)
+		if name == tagName && (latest == "" || taggedAt > latestTime) {
+			latest, latestTime = object, taggedAt
+		}
+	}
+	return latest
+}
+
+// describeObject describes the tag target: its short hash, with the message subject of annotated tags.
+func (t *TagUndoer) describeObject(object string) string {
+	content, err := t.git.GitOutput("cat-file", "tag", object)
+	if err != nil {
+		// Not an annotated tag object: a commit of a lightweight tag
+		return shortHash(object)
+	}
+
+	_, message, _ := strings.Cut(content, "\n\n")
+	if subject, _, _ := strings.Cut(message, "\n"); subject != "" {
+		return fmt.Sprintf("%s (annotated: %q)", shortHash(object), subject)
+	}
+	return shortHash(object) + " (annotated)"
+}
+
+// parseTagHeader returns the tag name and the tagger timestamp from the content of a tag object.
+func parseTagHeader(content string) (string, int64) {
+	var name string
+	var taggedAt int64
+	header, _, _ := strings.Cut(content, "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		if value, ok := strings.CutPrefix(line, "tag "); ok {
+			name = value
+		}
+		if value, ok := strings.CutPrefix(line, "tagger "); ok {
+			if fields := strings.Fields(value); len(fields) >= 2 { //nolint:mnd // <timestamp> <timezone>
+				taggedAt, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+	return name, taggedAt
+}
+
+// isTagDeletion tells whether the git tag arguments delete tags.
+func isTagDeletion(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return arg == "-d" || arg == "-D" || arg == "--delete"
+	})
+}
+
+// findTagName returns the name of the tag created by the git tag arguments: the first non-flag argument.
+func findTagName(args []string) string {
+	skipNext := false
+	for _, arg := range args {
 		if skipNext {
 			skipNext = false
 			continue
@@ -56,21 +225,7 @@ func (t *TagUndoer) GetUndoCommands() ([]*UndoCommand, error) {
 		}
 
 		// This should be the tag name
-		tagName = arg
-		break
+		return arg
 	}
-
-	if tagName == "" {
-		return nil, fmt.Errorf("no tag name found in command: %s", t.originalCmd.FullCommand)
-	}
-
-	// Verify the tag exists before trying to delete it
-	if err := t.git.GitRun("rev-parse", "--verify", "refs/tags/"+tagName); err != nil {
-		return nil, fmt.Errorf("tag '%s' does not exist, cannot undo tag creation", tagName)
-	}
-
-	return []*UndoCommand{NewUndoCommand(t.git,
-		fmt.Sprintf("git tag -d %s", tagName),
-		fmt.Sprintf("Delete tag '%s'", tagName),
-	)}, nil
+	return ""
 }
@@ -0,0 +1,81 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTagUndoer_DeletionAndForce tests recreating deleted tags and moving force-moved tags back.
+func TestTagUndoer_DeletionAndForce(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	git("commit", "--allow-empty", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	git("tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	git("tag", "light")
+	annotated := git("rev-parse", "refs/tags/v1.0.0")
+	git("commit", "--allow-empty", "-m", "second")
+
+	gitExec := githelpers.NewGitHelper(context.Background(), repoDir)
+
+	// A deleted annotated tag is found among the unreachable objects
+	git("tag", "-d", "v1.0.0")
+	undoCmds, err := undoer.New("git tag -d v1.0.0", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git tag v1.0.0 "+annotated, undoCmds[0].Command)
+	assert.Contains(t, undoCmds[0].Description, `(annotated: "Release 1.0.0")`)
+	assert.Contains(t, undoCmds[0].Warnings[0], "wasn't recorded")
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, annotated, git("rev-parse", "refs/tags/v1.0.0"))
+	assert.Equal(t, "Release 1.0.0", git("tag", "-l", "--format=%(contents:subject)", "v1.0.0"))
+
+	// A deleted lightweight tag needs its recorded commit
+	git("tag", "-d", "light")
+	_, err = undoer.New("git tag -d light", gitExec).GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+	tagUndoer, ok := undoer.New("git tag -d light", gitExec).(*undoer.TagUndoer)
+	require.True(t, ok)
+	tagUndoer.RecordedObjects(map[string]string{"light": first})
+	undoCmds, err = tagUndoer.GetUndoCommands()
+	require.NoError(t, err)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, first, git("rev-parse", "refs/tags/light"))
+
+	// A force-moved annotated tag is moved back to its previous tag object
+	git("tag", "-f", "-a", "v1.0.0", "-m", "Release 1.0.0 again")
+	undoCmds, err = undoer.New("git tag -f -a v1.0.0 -m 'Release 1.0.0 again'", gitExec).GetUndoCommands()
+	require.NoError(t, err)
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "git tag -f v1.0.0 "+annotated, undoCmds[0].Command)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, annotated, git("rev-parse", "refs/tags/v1.0.0"))
+
+	// A force-moved lightweight tag is moved back to its recorded commit
+	git("tag", "-f", "light")
+	tagUndoer, ok = undoer.New("git tag -f light", gitExec).(*undoer.TagUndoer)
+	require.True(t, ok)
+	tagUndoer.RecordedObjects(map[string]string{"light": first})
+	undoCmds, err = tagUndoer.GetUndoCommands()
+	require.NoError(t, err)
+	assert.Equal(t, "Move tag 'light' back to "+first[:7], undoCmds[0].Description)
+	require.NoError(t, undoCmds[0].Exec())
+	assert.Equal(t, first, git("rev-parse", "refs/tags/light"))
+}
@@ -23,7 +23,7 @@ func TestTagUndoer_GetUndoCommand(t *testing.T) {
 			name:    "simple tag creation",
 			command: "git tag v1.0.0",
 			setupMock: func(m *MockGitExec) {
-				m.On("GitRun", "rev-parse", "--verify", "refs/tags/v1.0.0").Return(nil)
+				m.On("GitOutput", "rev-parse", "--verify", "refs/tags/v1.0.0").Return("1111111", nil)
 			},
 			expectedCmd:  "git tag -d v1.0.0",
 			expectedDesc: "Delete tag 'v1.0.0'",
@@ -33,24 +33,27 @@ func TestTagUndoer_GetUndoCommand(t *testing.T) {
 			name:    "annotated tag creation",
 			command: "git tag -a v2.0.0 -m 'Release version 2.0.0'",
 			setupMock: func(m *MockGitExec) {
-				m.On("GitRun", "rev-parse", "--verify", "refs/tags/v2.0.0").Return(nil)
+				m.On("GitOutput", "rev-parse", "--verify", "refs/tags/v2.0.0").Return("2222222", nil)
 			},
 			expectedCmd:  "git tag -d v2.0.0",
 			expectedDesc: "Delete tag 'v2.0.0'",
 			expectError:  false,
 		},
 		{
-			name:          "tag deletion command",
-			command:       "git tag -d v1.0.0",
-			setupMock:     func(_ *MockGitExec) {},
+			name:    "unrecorded lightweight tag deletion",
+			command: "git tag -d v1.0.0",
+			setupMock: func(m *MockGitExec) {
+				m.On("GitRun", "show-ref", "--verify", "--quiet", "refs/tags/v1.0.0").Return(errors.New("no such ref"))
+				m.On("GitOutput", "fsck", "--unreachable", "--no-reflogs", "--no-progress").Return("", nil)
+			},
 			expectError:   true,
-			errorContains: "tag deletion",
+			errorContains: "wasn't recorded",
 		},
 		{
 			name:    "tag doesn't exist",
 			command: "git tag v3.0.0",
 			setupMock: func(m *MockGitExec) {
-				m.On("GitRun", "rev-parse", "--verify", "refs/tags/v3.0.0").Return(errors.New("tag not found"))
+				m.On("GitOutput", "rev-parse", "--verify", "refs/tags/v3.0.0").Return("", errors.New("tag not found"))
 			},
 			expectError:   true,
 			errorContains: "does not exist",
@@ -0,0 +1,31 @@
+package undoer
+
+import (
+	"strings"
+)
+
+// BranchTracking is the upstream tracking configuration of a branch (branch.<name>.remote/merge).
+// Deleting a branch drops its configuration, so it's captured for redo to restore it.
+type BranchTracking struct {
+	Branch string
+	Remote string
+	Merge  string
+}
+
+// getBranchTracking returns the tracking configuration of the branch (nil if it tracks nothing).
+func getBranchTracking(git GitExec, branch string) *BranchTracking {
+	remote, err := git.GitOutput("config", "--get", "branch."+branch+".remote")
+	if err != nil || strings.TrimSpace(remote) == "" {
+		return nil
+	}
+	merge, err := git.GitOutput("config", "--get", "branch."+branch+".merge")
+	if err != nil || strings.TrimSpace(merge) == "" {
+		return nil
+	}
+
+	return &BranchTracking{
+		Branch: branch,
+		Remote: strings.TrimSpace(remote),
+		Merge:  strings.TrimSpace(merge),
+	}
+}
@@ -0,0 +1,113 @@
+package undoer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrashRefPrefix is where refs and stashes deleted by undo operations are kept (soft deletion),
+// per kind of the deleted item (e.g. branch feature is kept as refs/git-undo/trash/heads/feature).
+const TrashRefPrefix = "refs/git-undo/trash/"
+
+// Kinds of trashed items: the part of their trash refs right after TrashRefPrefix.
+const (
+	TrashKindBranch = "heads"
+	TrashKindTag    = "tags"
+	TrashKindStash  = "stash"
+)
+
+// TrashReflogMessage is the reflog message of trash refs: their reflog tells when items were trashed.
+const TrashReflogMessage = "git-undo: trash"
+
+// TrashRef returns the trash ref keeping the deleted item of the given kind.
+// Stashes are named by their (short) commit hash.
+func TrashRef(kind, name string) string {
+	return TrashRefPrefix + kind + "/" + name
+}
+
+// trashItem is what an undo command deletes: it's kept in the trash right before the command runs
+// (within the transaction checking the refs of locked commands, see execLocked).
+type trashItem struct {
+	// ref is the trash ref (see TrashRef).
+	ref string
+	// value is the object the deleted ref (or stash entry) points to.
+	value string
+}
+
+// keepInTrash makes the command keep the deleted item in the trash before running.
+func (cmd *UndoCommand) keepInTrash(kind, name, value string) *UndoCommand {
+	cmd.trash = &trashItem{ref: TrashRef(kind, name), value: strings.TrimSpace(value)}
+	return cmd
+}
+
+// instruction is the `git update-ref --stdin` instruction storing the item in the trash
+// (whatever the trash ref held before: the latest deleted item wins).
+func (item *trashItem) instruction() string {
+	return fmt.Sprintf("update %s %s\n", item.ref, item.value)
+}
+
+// moveToTrash stores the item the command deletes in the trash.
+func (cmd *UndoCommand) moveToTrash(git GitExec) error {
+	err := git.GitRun("update-ref", "--create-reflog", "-m", TrashReflogMessage, cmd.trash.ref, cmd.trash.value)
+	if err != nil {
+		return fmt.Errorf("failed to keep %s in the trash (refusing to run `%s`): %w", cmd.trash.ref, cmd.Command, err)
+	}
+	return nil
+}
+
+// branchCreationSubjects are reflog subjects of git creating (or force-recreating) a branch.
+var branchCreationSubjects = []string{"branch: Created from ", "branch: Reset to "}
+
+// branchMovedSinceCreation returns the tip of the branch and whether it differs from the commit
+// the branch was created at (e.g. commits were added to it since).
+// Without a reflog the creation point is unknown, so the branch is considered moved.
+func branchMovedSinceCreation(git GitExec, branch string) (string, bool) {
+	tip, err := git.GitOutput("rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	if err != nil {
+		return "", false // no such branch: nothing to lose
+	}
+	tip = strings.TrimSpace(tip)
+
+	reflog, err := git.GitOutput("reflog", "show", "--format=%H %gs", "refs/heads/"+branch)
+	if err != nil {
+		return tip, true
+	}
+	// Newest entries go first: the latest creation is what the undone command did
+	for _, line := range strings.Split(reflog, "\n") {
+		sha, subject, _ := strings.Cut(strings.TrimSpace(line), " ")
+		for _, prefix := range branchCreationSubjects {
+			if strings.HasPrefix(subject, prefix) {
+				return tip, sha != tip
+			}
+		}
+	}
+	return tip, true
+}
+
+// newDeleteBranchCommand returns the command deleting the branch, capturing its tracking configuration.
+// The branch is soft deleted: its tip is kept in the trash, so `git undo trash restore` can bring it back.
+func newDeleteBranchCommand(git GitExec, branch, description string, warnings ...string) *UndoCommand {
+	tip, moved := branchMovedSinceCreation(git, branch)
+	if moved {
+		warnings = append(warnings, fmt.Sprintf(
+			"Branch '%s' has changed since it was created: its tip %s is kept in the trash (see `git undo trash`)",
+			branch, shortHash(tip),
+		))
+	}
+
+	cmd := NewUndoCommand(git, "git branch -D "+branch, description, warnings...)
+	cmd.Tracking = getBranchTracking(git, branch)
+	if tip != "" {
+		cmd.keepInTrash(TrashKindBranch, branch, tip)
+	}
+	return cmd
+}
+
+// shortHash abbreviates the commit hash the way git does by default.
+func shortHash(hash string) string {
+	const shortHashLength = 7
+	if len(hash) > shortHashLength {
+		return hash[:shortHashLength]
+	}
+	return hash
+}
@@ -3,8 +3,10 @@ package undoer
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/amberpixels/git-undo/internal/git-undo/config"
 	"github.com/amberpixels/git-undo/internal/githelpers"
 )
 
@@ -18,11 +20,20 @@ type Undoer interface {
 // GitExec represents an interface for executing git commands.
 type GitExec interface {
 	GitRun(subCmd string, args ...string) error
+	GitRunWithInput(input string, subCmd string, args ...string) error
 	GitOutput(subCmd string, args ...string) (string, error)
 }
 
 var ErrUndoNotSupported = errors.New("git undo not supported")
 
+// Strategies of the undo commands keeping the commits logged after the undone one (see UndoCommand.Strategy).
+const (
+	// StrategyRebase removes a commit older than HEAD by rebasing the later commits onto its parent.
+	StrategyRebase = "rebase"
+	// StrategyRevert reverts a commit (e.g. a pushed one) by a new commit.
+	StrategyRevert = "revert"
+)
+
 // UndoCommand represents a command that can undo a git operation.
 type UndoCommand struct {
 	// Command is the actual git command string to execute
@@ -31,6 +42,41 @@ type UndoCommand struct {
 	Warnings []string
 	// Description is a human-readable description of what the command will do
 	Description string
+	// Paths is the set of paths (relative to the repository root) the command affects, if known.
+	// Commands with known paths can be limited to a subset of them (see LimitPaths).
+	Paths []string
+	// Report makes the Description printed once the command is executed (e.g. the refs an undo rolled back).
+	Report bool
+	// Tracking is the upstream configuration of the branch the command deletes (if it tracked anything),
+	// so redo can restore it after re-creating the branch.
+	Tracking *BranchTracking
+	// Confirm makes the undo ask for confirmation even when not required by the config
+	// (e.g. for removing a whole repository).
+	Confirm bool
+	// RemovesRepository tells the command removes the repository itself (along with the git-undo log).
+	RemovesRepository bool
+	// Strategy tells how the command undoes a commit it doesn't reset away (see StrategyRebase and StrategyRevert),
+	// empty for the others: the command of the entry can't simply be re-run to redo it then.
+	Strategy string
+
+	// trash is what the command deletes, kept in the trash before the command runs (see keepInTrash).
+	trash *trashItem
+
+	// removeDir is the directory the command removes (it is not a git command then, see removeDirCommand).
+	removeDir string
+	// restore is the snapshot the command restores (it is not a git command then, see restoreSnapshotCommand).
+	restore *snapshotRestore
+	// outside is what the command changes outside of the repository (e.g. the global git config):
+	// wherever it runs, it changes the same thing.
+	outside string
+
+	// lock pins the refs the command updates to their values at planning time (see LockRefs).
+	lock *refLock
+
+	// pathsCmd is the command (without paths) Command is rebuilt from when paths are limited.
+	pathsCmd string
+	// pathsVerb is the verb (e.g. "Unstage") the aggregated Description is built with.
+	pathsVerb string
 
 	git GitExec
 }
@@ -45,14 +91,97 @@ func NewUndoCommand(git GitExec, cmdStr string, description string, warnings ...
 	}
 }
 
+// WithPaths attaches the set of affected paths to the command and summarizes them in its Description
+// (e.g. "Unstage 142 files under src/"). pathsCmd is the command that gets the paths appended
+// when they are limited via LimitPaths (e.g. "git restore --staged").
+func (cmd *UndoCommand) WithPaths(paths []string, pathsCmd, verb string) *UndoCommand {
+	if len(paths) == 0 {
+		return cmd
+	}
+
+	cmd.Paths = paths
+	cmd.pathsCmd = pathsCmd
+	cmd.pathsVerb = verb
+	cmd.Description = verb + " " + summarizePaths(paths)
+	return cmd
+}
+
+// LimitPaths limits the command to the affected paths matching any of the given glob patterns
+// (relative to the repository root, `**` matches any number of directories).
+// It returns the number of paths left: zero means the command has nothing to do anymore.
+func (cmd *UndoCommand) LimitPaths(patterns []string) (int, error) {
+	if len(cmd.Paths) == 0 || cmd.pathsCmd == "" {
+		return 0, fmt.Errorf("%w: cannot limit paths of `%s`", ErrUndoNotSupported, cmd.Command)
+	}
+
+	var kept []string
+	for _, path := range cmd.Paths {
+		if slices.ContainsFunc(patterns, func(pattern string) bool { return config.MatchPath(pattern, path) }) {
+			kept = append(kept, path)
+		}
+	}
+	if len(kept) == 0 {
+		return 0, nil
+	}
+
+	// Paths are relative to the repository root, so they're passed with the `:/` (top) pathspec magic
+	topPaths := make([]string, 0, len(kept))
+	for _, path := range kept {
+		topPaths = append(topPaths, quotePath(":/"+path))
+	}
+
+	cmd.Command = cmd.pathsCmd + " -- " + strings.Join(topPaths, " ")
+	cmd.Paths = kept
+	cmd.Description = cmd.pathsVerb + " " + summarizePaths(kept)
+	return len(kept), nil
+}
+
 // Exec executes the undo command and returns its success status.
 func (cmd *UndoCommand) Exec() error {
+	return cmd.ExecWith(cmd.git)
+}
+
+// InPlaceOnly reports whether the command can't be executed in another repository than the one it was planned in
+// (see ExecWith), and why: it isn't a git command, or it changes what's outside of the repository.
+func (cmd *UndoCommand) InPlaceOnly() (string, bool) {
+	switch {
+	case cmd.removeDir != "":
+		return "it removes " + cmd.removeDir, true
+	case cmd.restore != nil:
+		return "it restores files from snapshot " + cmd.restore.snapshot.ID, true
+	case cmd.outside != "":
+		return "it changes " + cmd.outside, true
+	}
+	return "", false
+}
+
+// ExecWith executes the undo command via the given git (e.g. in another repository).
+// Commands changing what's outside of the repository are only executed in their own (see InPlaceOnly).
+func (cmd *UndoCommand) ExecWith(git GitExec) error {
+	if cmd.outside != "" && git != cmd.git {
+		return fmt.Errorf("%w: %s changes %s, it can't be run in another repository",
+			ErrUndoNotSupported, cmd.Command, cmd.outside)
+	}
+	if cmd.removeDir != "" {
+		return cmd.execRemoveDir(git)
+	}
+	if cmd.restore != nil {
+		return cmd.execRestoreSnapshot(git)
+	}
 	gitCmd, err := parseGitCommand(cmd.Command)
 	if err != nil {
 		return fmt.Errorf("invalid command: %w", err)
 	}
+	if cmd.lock != nil {
+		return cmd.execLocked(git, gitCmd)
+	}
+	if cmd.trash != nil {
+		if err := cmd.moveToTrash(git); err != nil {
+			return err
+		}
+	}
 
-	return cmd.git.GitRun(gitCmd.SubCommand, gitCmd.Args...)
+	return git.GitRun(gitCmd.SubCommand, gitCmd.Args...)
 }
 
 // CommandDetails represents parsed git command details.
@@ -61,6 +190,21 @@ type CommandDetails struct {
 	Command     string   // git
 	SubCommand  string   // commit
 	Args        []string // []string{"-m", "message"}
+
+	// Action and ActionArgs split the Args of subcommands with actions (see actionSubCommands):
+	// e.g. `git worktree add -b topic ../topic` has the action "add" and the args {"-b", "topic", "../topic"}.
+	// Options given before the action (e.g. `git submodule --quiet add`) are a part of ActionArgs.
+	Action     string
+	ActionArgs []string
+}
+
+// actionSubCommands are the subcommands whose first argument is an action with arguments of its own,
+// by the options they accept before the action that take a value.
+var actionSubCommands = map[string][]string{
+	"worktree":  nil,
+	"submodule": nil,
+	"notes":     {"--ref"},
+	"remote":    nil,
 }
 
 func (d *CommandDetails) getFirstNonFlagArg() string {
@@ -87,11 +231,14 @@ func New(cmdStr string, gitExec GitExec) Undoer {
 	case "branch":
 		return &BranchUndoer{originalCmd: cmdDetails, git: gitExec}
 	case "checkout":
+		if slices.Contains(cmdDetails.Args, "--") {
+			return &CheckoutPathsUndoer{originalCmd: cmdDetails, git: gitExec}
+		}
 		return &CheckoutUndoer{originalCmd: cmdDetails, git: gitExec}
 	case "switch":
 		return &SwitchUndoer{originalCmd: cmdDetails, git: gitExec}
 	case "stash":
-		return &StashUndoer{originalCmd: cmdDetails, git: gitExec}
+		return newStashUndoer(cmdDetails, gitExec)
 	case "merge":
 		return &MergeUndoer{originalCmd: cmdDetails, git: gitExec}
 	case "rm":
@@ -110,6 +257,32 @@ func New(cmdStr string, gitExec GitExec) Undoer {
 		return &CherryPickUndoer{originalCmd: cmdDetails, git: gitExec}
 	case "clean":
 		return &CleanUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "apply":
+		return &ApplyUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "push":
+		return &PushUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "pull":
+		return &PullUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "fetch":
+		return &FetchUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "rebase":
+		return &RebaseUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "am":
+		return &AmUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "config":
+		return &ConfigUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "clone":
+		return &CloneUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "init":
+		return &InitUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "worktree":
+		return &WorktreeUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "submodule":
+		return &SubmoduleUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "notes":
+		return &NotesUndoer{originalCmd: cmdDetails, git: gitExec}
+	case "remote":
+		return &RemoteUndoer{originalCmd: cmdDetails, git: gitExec}
 	default:
 		return &InvalidUndoer{rawCommand: cmdStr}
 	}
@@ -125,10 +298,24 @@ func parseGitCommand(gitCmdStr string) (*CommandDetails, error) {
 		return nil, fmt.Errorf("unsupported git command format: %s", gitCmdStr)
 	}
 
-	return &CommandDetails{
+	details := &CommandDetails{
 		FullCommand: gitCmdStr,
 		Command:     "git",
 		SubCommand:  parsed.Name,
 		Args:        parsed.Args,
-	}, nil
+	}
+	if valueFlags, ok := actionSubCommands[parsed.Name]; ok {
+		for i := 0; i < len(parsed.Args); i++ {
+			if slices.Contains(valueFlags, parsed.Args[i]) {
+				i++
+				continue
+			}
+			if !strings.HasPrefix(parsed.Args[i], "-") {
+				details.Action = parsed.Args[i]
+				details.ActionArgs = append(slices.Clone(parsed.Args[:i]), parsed.Args[i+1:]...)
+				break
+			}
+		}
+	}
+	return details, nil
 }
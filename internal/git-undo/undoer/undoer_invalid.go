@@ -1,7 +1,5 @@
 package undoer
 
-import "fmt"
-
 var _ Undoer = &InvalidUndoer{}
 
 // InvalidUndoer represents an undoer for commands that cannot be parsed or are not supported.
@@ -11,10 +9,11 @@ type InvalidUndoer struct {
 }
 
 // GetUndoCommands implements the Undoer interface.
+// The returned *UnsupportedError carries the guidance for a manual recovery.
 func (i *InvalidUndoer) GetUndoCommands() ([]*UndoCommand, error) {
-	if i.parseError != nil {
-		return nil, fmt.Errorf("%w: %w", ErrUndoNotSupported, i.parseError)
+	return nil, &UnsupportedError{
+		Command:    i.rawCommand,
+		Suggestion: GetSuggestion(extractSubCommand(i.rawCommand)),
+		cause:      i.parseError,
 	}
-
-	return nil, fmt.Errorf("%w: %s", ErrUndoNotSupported, i.rawCommand)
 }
@@ -0,0 +1,269 @@
+package undoer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WorktreeUndoer handles undoing git worktree operations: an added worktree is removed,
+// a removed one is added back (when its branch is known) and a moved one is moved back.
+type WorktreeUndoer struct {
+	git GitExec
+
+	originalCmd *CommandDetails
+}
+
+var _ Undoer = &WorktreeUndoer{}
+
+// worktreeAddValueFlags are the `git worktree add` flags taking the next argument as their value.
+var worktreeAddValueFlags = []string{"-b", "-B", "--reason"}
+
+// worktreeBranchWindow is how long before the worktree got its first HEAD the branch it created may be created.
+const worktreeBranchWindow = 1
+
+// worktreeInfo is a worktree as listed by `git worktree list --porcelain`.
+type worktreeInfo struct {
+	path   string
+	branch string // empty when detached
+	locked bool
+}
+
+// GetUndoCommands returns the commands that would undo the worktree operation.
+func (w *WorktreeUndoer) GetUndoCommands() ([]*UndoCommand, error) {
+	switch w.originalCmd.Action {
+	case "add":
+		return w.undoAdd()
+	case "remove":
+		return w.undoRemove()
+	case "move":
+		return w.undoMove()
+	default:
+		return nil, w.unsupported("`git worktree %s` can't be undone", w.originalCmd.Action)
+	}
+}
+
+// undoAdd removes the added worktree, and deletes the branch the add created.
+func (w *WorktreeUndoer) undoAdd() ([]*UndoCommand, error) {
+	args := w.originalCmd.ActionArgs
+	positional := positionalArgs(args, worktreeAddValueFlags)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no worktree path found in command: %s", w.originalCmd.FullCommand)
+	}
+	path, err := w.resolvePath(positional[0])
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := w.findWorktree(path)
+	if err != nil {
+		return nil, err
+	}
+	if worktree == nil {
+		return nil, fmt.Errorf("worktree %s not found (it was removed or moved since)", path)
+	}
+
+	var undoCmds []*UndoCommand
+	if worktree.locked {
+		undoCmds = append(undoCmds, NewUndoCommand(w.git,
+			"git worktree unlock "+quotePath(path),
+			fmt.Sprintf("Unlock worktree %s", path),
+		))
+	}
+	// Without --force, the removal is refused when the worktree has changes
+	undoCmds = append(undoCmds, NewUndoCommand(w.git,
+		"git worktree remove "+quotePath(path),
+		fmt.Sprintf("Remove worktree %s", path),
+	))
+
+	// The new branch is either given via -b, or (with no commit-ish) named after the worktree
+	var created string
+	if index := slices.Index(args, "-b"); index >= 0 && index+1 < len(args) {
+		created = args[index+1]
+	} else if len(positional) == 1 && !slices.Contains(args, "-B") && !slices.Contains(args, "--detach") &&
+		worktree.branch == filepath.Base(path) {
+		// The branch may have existed before: it's only deleted if it was created along with the worktree
+		if w.createdWithWorktree(worktree) {
+			created = worktree.branch
+		}
+	}
+
+	switch {
+	case created != "" && created == worktree.branch:
+		undoCmds = append(undoCmds, newDeleteBranchCommand(w.git, created,
+			fmt.Sprintf("Delete branch '%s' (created by the worktree add)", created)))
+	case slices.Contains(args, "-B") && worktree.branch != "":
+		undoCmds[0].Warnings = append(undoCmds[0].Warnings,
+			fmt.Sprintf("Branch '%s' is kept where -B left it", worktree.branch))
+	}
+	return undoCmds, nil
+}
+
+// createdWithWorktree tells whether the branch of the worktree was created by adding it:
+// the creation is all its reflog has, made when the worktree got its first HEAD.
+func (w *WorktreeUndoer) createdWithWorktree(worktree *worktreeInfo) bool {
+	reflog, err := w.git.GitOutput("reflog", "show", "--date=unix", "--format=%gs%x09%gd",
+		"refs/heads/"+worktree.branch)
+	lines := splitLines(reflog)
+	if err != nil || len(lines) != 1 || !strings.HasPrefix(lines[0], "branch: Created from HEAD\t") {
+		return false
+	}
+	headReflog, err := w.git.GitOutput("-C", worktree.path, "reflog", "show", "--date=unix", "--format=%gd", "HEAD")
+	headLines := splitLines(headReflog)
+	if err != nil || len(headLines) == 0 {
+		return false
+	}
+
+	created, ok := reflogTime(lines[0])
+	added, addedOk := reflogTime(headLines[len(headLines)-1])
+	return ok && addedOk && created <= added && added-created <= worktreeBranchWindow
+}
+
+// undoRemove adds the removed worktree back. Its admin files are gone with it,
+// so it's only recoverable when its branch is known: the one named after the worktree.
+func (w *WorktreeUndoer) undoRemove() ([]*UndoCommand, error) {
+	positional := positionalArgs(w.originalCmd.ActionArgs, nil)
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("no worktree path found in command: %s", w.originalCmd.FullCommand)
+	}
+	path, err := w.resolvePath(positional[0])
+	if err != nil {
+		return nil, err
+	}
+	if worktree, err := w.findWorktree(path); err != nil {
+		return nil, err
+	} else if worktree != nil {
+		return nil, fmt.Errorf("worktree %s exists (it was added back since)", path)
+	}
+	if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("%s is not empty: cannot add the worktree back there", path)
+	}
+
+	branch := filepath.Base(path)
+	if _, err := w.git.GitOutput("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		return nil, w.unsupported("cannot tell what the removed worktree %s had checked out", path)
+	}
+	worktrees, err := w.listWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(worktrees, func(worktree worktreeInfo) bool { return worktree.branch == branch }) {
+		return nil, w.unsupported("cannot tell what the removed worktree %s had checked out "+
+			"(branch '%s' is checked out elsewhere)", path, branch)
+	}
+
+	warnings := []string{fmt.Sprintf("The worktree is added back on branch '%s' (the one named after it)", branch)}
+	if slices.Contains(w.originalCmd.ActionArgs, "-f") || slices.Contains(w.originalCmd.ActionArgs, "--force") {
+		warnings = append(warnings, "Changes discarded by the forced removal are not restored")
+	}
+	return []*UndoCommand{NewUndoCommand(w.git,
+		fmt.Sprintf("git worktree add %s %s", quotePath(path), branch),
+		fmt.Sprintf("Add worktree %s back", path),
+		warnings...,
+	)}, nil
+}
+
+// undoMove moves the worktree back to where it was.
+func (w *WorktreeUndoer) undoMove() ([]*UndoCommand, error) {
+	positional := positionalArgs(w.originalCmd.ActionArgs, nil)
+	if len(positional) < 2 {
+		return nil, fmt.Errorf("no worktree paths found in command: %s", w.originalCmd.FullCommand)
+	}
+	from, err := w.resolvePath(positional[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := w.resolvePath(positional[1])
+	if err != nil {
+		return nil, err
+	}
+	// Moving into an existing directory puts the worktree inside of it
+	if worktree, _ := w.findWorktree(to); worktree == nil {
+		to = filepath.Join(to, filepath.Base(from))
+	}
+
+	worktree, err := w.findWorktree(to)
+	if err != nil {
+		return nil, err
+	}
+	if worktree == nil {
+		return nil, fmt.Errorf("worktree %s not found (it was removed or moved since)", to)
+	}
+	if _, err := os.Stat(from); err == nil {
+		return nil, fmt.Errorf("%s exists: cannot move the worktree back there", from)
+	}
+
+	// Moving a locked worktree must be forced twice
+	force := ""
+	if worktree.locked {
+		force = "-f -f "
+	}
+	return []*UndoCommand{NewUndoCommand(w.git,
+		fmt.Sprintf("git worktree move %s%s %s", force, quotePath(to), quotePath(from)),
+		fmt.Sprintf("Move worktree %s back to %s", to, from),
+	)}, nil
+}
+
+// resolvePath returns the absolute path of the worktree path given to the command.
+func (w *WorktreeUndoer) resolvePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		cwd, err := workingDir(w.git)
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(cwd, path)
+	}
+	// Worktrees are listed with symlinks resolved (e.g. /tmp on macOS)
+	if real, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		path = filepath.Join(real, filepath.Base(path))
+	}
+	return path, nil
+}
+
+// findWorktree returns the worktree at the path (nil if there is none).
+func (w *WorktreeUndoer) findWorktree(path string) (*worktreeInfo, error) {
+	worktrees, err := w.listWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	for _, worktree := range worktrees {
+		if worktree.path == path {
+			return &worktree, nil
+		}
+	}
+	return nil, nil
+}
+
+// listWorktrees returns the worktrees of the repository.
+func (w *WorktreeUndoer) listWorktrees() ([]worktreeInfo, error) {
+	output, err := w.git.GitOutput("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var worktrees []worktreeInfo
+	for _, line := range splitLines(output) {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			worktrees = append(worktrees, worktreeInfo{path: strings.TrimPrefix(line, "worktree ")})
+		case len(worktrees) == 0:
+			continue
+		case strings.HasPrefix(line, "branch "):
+			worktrees[len(worktrees)-1].branch = strings.TrimPrefix(line, "branch refs/heads/")
+		case line == "locked" || strings.HasPrefix(line, "locked "):
+			worktrees[len(worktrees)-1].locked = true
+		}
+	}
+	return worktrees, nil
+}
+
+// unsupported returns the error of a worktree operation whose undo isn't known,
+// with the guidance for a manual recovery.
+func (w *WorktreeUndoer) unsupported(format string, args ...any) error {
+	return &UnsupportedError{
+		Command:    w.originalCmd.FullCommand,
+		Suggestion: GetSuggestion("worktree"),
+		cause:      fmt.Errorf(format, args...),
+	}
+}
@@ -0,0 +1,79 @@
+package undoer_test
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorktreeUndoer_Integration tests undoing worktree add, move and remove.
+func TestWorktreeUndoer_Integration(t *testing.T) {
+	baseDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	repoDir := filepath.Join(baseDir, "repo")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	undo := func(command string) []*undoer.UndoCommand {
+		undoCmds, err := undoer.New(command, githelpers.NewGitHelper(context.Background(), repoDir)).
+			GetUndoCommands()
+		require.NoError(t, err)
+		for _, undoCmd := range undoCmds {
+			require.NoError(t, undoCmd.Exec())
+		}
+		return undoCmds
+	}
+
+	require.NoError(t, exec.Command("git", "init", "-q", "-b", "main", repoDir).Run())
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	git("commit", "--allow-empty", "-m", "first")
+
+	// The added worktree is removed along with the branch it created
+	git("worktree", "add", "-q", "-b", "topic", "../topic-wt")
+	undoCmds := undo("git worktree add -q -b topic ../topic-wt")
+	require.Len(t, undoCmds, 2)
+	assert.Equal(t, "git worktree remove "+filepath.Join(baseDir, "topic-wt"), undoCmds[0].Command)
+	assert.Equal(t, "git branch -D topic", undoCmds[1].Command)
+	assert.NotContains(t, git("worktree", "list"), "topic-wt")
+	assert.Empty(t, git("branch", "--list", "topic"))
+
+	// A branch named after the worktree is created as well, unless it existed
+	git("branch", "existing")
+	git("worktree", "add", "-q", "../existing")
+	undoCmds = undo("git worktree add -q ../existing")
+	require.Len(t, undoCmds, 1)
+	assert.Equal(t, "existing", git("branch", "--list", "--format=%(refname:short)", "existing"))
+
+	// Moved worktrees are moved back
+	git("worktree", "add", "-q", "../feature")
+	git("worktree", "move", "../feature", "../moved")
+	undoCmds = undo("git worktree move ../feature ../moved")
+	require.Len(t, undoCmds, 1)
+	assert.Contains(t, git("worktree", "list"), filepath.Join(baseDir, "feature"))
+
+	// Removed worktrees are added back on the branch named after them
+	git("worktree", "remove", "../feature")
+	undoCmds = undo("git worktree remove ../feature")
+	require.Len(t, undoCmds, 1)
+	assert.Contains(t, git("worktree", "list"), filepath.Join(baseDir, "feature")+" ")
+	assert.Contains(t, git("worktree", "list"), "[feature]")
+
+	// A detached one can't be recovered
+	git("worktree", "add", "-q", "--detach", "../detached")
+	git("worktree", "remove", "../detached")
+	_, err = undoer.New("git worktree remove ../detached", githelpers.NewGitHelper(context.Background(), repoDir)).
+		GetUndoCommands()
+	require.ErrorIs(t, err, undoer.ErrUndoNotSupported)
+}
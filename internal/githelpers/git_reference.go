@@ -51,6 +51,7 @@ var alwaysReadOnly = map[string]struct{}{
 // conditionalBehavior are commands whose behavior depends on their arguments.
 // These need special logic to determine if they're mutating, navigating, or read-only.
 var conditionalBehavior = map[string]struct{}{
+	"apply":    {},
 	"branch":   {},
 	"checkout": {},
 	"restore":  {},
@@ -58,14 +59,16 @@ var conditionalBehavior = map[string]struct{}{
 	"tag":      {},
 	"remote":   {},
 	"config":   {},
+	"notes":    {},
 
 	CustomCommandUndo: {},
 	CustomCommandBack: {},
+	CustomCommandRedo: {},
 }
 
 // porcelainCommands is the list of "user-facing" verbs (main porcelain commands).
 var porcelainCommands = []string{
-	"add", "am", "archive", "bisect", "blame", "branch", "bundle",
+	"add", "am", "apply", "archive", "bisect", "blame", "branch", "bundle",
 	"checkout", "cherry", "cherry-pick", "citool", "clean", "clone",
 	"commit", "describe", "diff", "fetch", "format-patch", "gc",
 	"grep", "gui", "help", "init", "log", "merge", "mv", "notes",
@@ -94,12 +97,14 @@ var plumbingCommands = []string{
 const (
 	CustomCommandUndo = "undo"
 	CustomCommandBack = "back"
+	CustomCommandRedo = "redo"
 )
 
 // customCommands is the list of custom commands (third-party plugins).
 var customCommands = []string{
 	CustomCommandUndo,
 	CustomCommandBack,
+	CustomCommandRedo,
 }
 
 // buildLookup builds a map from verb → its CommandType.
@@ -3,10 +3,10 @@ package githelpers
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
-
-	"github.com/mattn/go-shellwords"
 )
 
 // CommandType is the type of a git command.
@@ -69,6 +69,17 @@ type GitCommand struct {
 	Supported    bool         // was Name in our lookup?
 	Type         CommandType  // Porcelain, Plumbing, or Unknown
 	BehaviorType BehaviorType // Mutating, Navigating, or ReadOnly
+
+	// GlobalOptions are the git options given before the subcommand (e.g. --no-pager), as typed.
+	GlobalOptions []string
+	// Dir is the directory the command ran in, relative to the current one, when given (by -C).
+	Dir string
+	// GitDir and WorkTree locate the repository when given (by --git-dir and --work-tree).
+	GitDir, WorkTree string
+	// Config are the configuration values the command ran with (by -c <name>=<value>).
+	Config []string
+	// Alias is the alias the command was typed as (see ExpandAlias).
+	Alias string
 }
 
 // IsReadOnly returns true if the command is read-only (for backward compatibility).
@@ -87,46 +98,358 @@ func (c *GitCommand) IsNavigating() bool {
 }
 
 // IsCheckoutOrSwitch returns true if the command is a git checkout or git switch command.
+// Checking out paths (`git checkout -- <paths>`) doesn't switch anything, so it isn't one.
 func (c *GitCommand) IsCheckoutOrSwitch() bool {
+	if c.Name == "checkout" && slices.Contains(c.Args, "--") {
+		return false
+	}
 	return c.Name == "checkout" || c.Name == "switch"
 }
 
-// ParseGitCommand parses a git command string into a GitCommand struct.
+// Fixup kinds of `git commit --fixup/--squash` commands (see GitCommand.Fixup).
+const (
+	FixupKindFixup  = "fixup"
+	FixupKindSquash = "squash"
+	FixupKindAmend  = "amend"  // --fixup=amend:<commit>
+	FixupKindReword = "reword" // --fixup=reword:<commit>
+)
+
+// Fixup returns the kind and the target commit of `git commit --fixup/--squash` commands.
+func (c *GitCommand) Fixup() (string, string, bool) {
+	if c.Name != "commit" {
+		return "", "", false
+	}
+	return parseFixupArgs(c.Args)
+}
+
+// FixupSubjectPrefix returns the subject prefix git gives to commits of the fixup kind
+// (the prefix `git rebase --autosquash` looks for).
+func FixupSubjectPrefix(kind string) string {
+	switch kind {
+	case FixupKindSquash:
+		return "squash! "
+	case FixupKindAmend, FixupKindReword:
+		return "amend! "
+	default:
+		return "fixup! "
+	}
+}
+
+// parseFixupArgs finds --fixup/--squash in commit arguments.
+func parseFixupArgs(args []string) (string, string, bool) {
+	for i, arg := range args {
+		for _, flag := range []string{"--" + FixupKindFixup, "--" + FixupKindSquash} {
+			var target string
+			switch {
+			case arg == flag && i+1 < len(args):
+				target = args[i+1]
+			case strings.HasPrefix(arg, flag+"="):
+				target = strings.TrimPrefix(arg, flag+"=")
+			default:
+				continue
+			}
+
+			kind := strings.TrimPrefix(flag, "--")
+			if kind == FixupKindFixup {
+				for _, option := range []string{FixupKindAmend, FixupKindReword} {
+					if rest, ok := strings.CutPrefix(target, option+":"); ok {
+						kind, target = option, rest
+					}
+				}
+			}
+			return kind, target, target != ""
+		}
+	}
+	return "", "", false
+}
+
+// commitMessage is where the message of a commit comes from.
+type commitMessage struct {
+	// paragraphs are the message paragraphs (from -m options or the -F file), whitespace collapsed.
+	paragraphs []string
+	// file is the -F file whose content is unknown (unreadable or stdin).
+	file string
+	// reuse is the commit whose message is reused by reuseFlag (-C or -c).
+	reuse, reuseFlag string
+	// template is the -t template file.
+	template string
+}
+
+// parseCommitMessage parses the message sources of git commit args:
+// -m/--message (several ones are paragraphs), -F/--file, -C/--reuse-message, -c/--reedit-message, -t/--template.
+// Logged commands may lose their quoting, so a -m value spans up to the next option.
+func parseCommitMessage(args []string) commitMessage {
+	var msg commitMessage
+	var messages []string
+	var file string
+
+	// take returns the value of an option: either attached or the next arg
+	take := func(i *int, attached string, hasAttached bool) string {
+		if hasAttached {
+			return attached
+		}
+		if *i+1 < len(args) {
+			*i++
+			return args[*i]
+		}
+		return ""
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var opt, value string
+		var hasValue bool
+		switch {
+		case arg == "--":
+			i = len(args) // the rest are pathspecs
+			continue
+		case strings.HasPrefix(arg, "--"):
+			name, attached, ok := strings.Cut(arg, "=")
+			switch name {
+			case "--message":
+				opt = "m"
+			case "--file":
+				opt = "F"
+			case "--template":
+				opt = "t"
+			case "--reuse-message":
+				opt = "C"
+			case "--reedit-message":
+				opt = "c"
+			default:
+				continue
+			}
+			value, hasValue = attached, ok
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Short options may be clustered (e.g. -am "msg", -m"msg")
+			for j := 1; j < len(arg); j++ {
+				if strings.IndexByte("mFtCc", arg[j]) >= 0 {
+					opt, value, hasValue = string(arg[j]), arg[j+1:], j+1 < len(arg)
+					break
+				}
+				if strings.IndexByte("Su", arg[j]) >= 0 {
+					break // the rest is their (optional) value
+				}
+			}
+			if opt == "" {
+				continue
+			}
+		default:
+			continue
+		}
+
+		value = take(&i, value, hasValue)
+		switch opt {
+		case "m":
+			// Unquoted message words follow until the next option
+			for i+1 < len(args) && !hasValue && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				value += " " + args[i]
+			}
+			messages = append(messages, strings.Trim(value, `"'`))
+		case "F":
+			file = value
+		case "t":
+			msg.template = value
+		case "C", "c":
+			msg.reuse, msg.reuseFlag = value, "-"+opt
+		}
+	}
+
+	if len(messages) == 0 && file != "" && file != "-" {
+		// The file is read right after the commit (by the hook), so it's still there normally
+		if content, err := os.ReadFile(file); err == nil {
+			messages = append(messages, string(content))
+		}
+	}
+	if len(messages) == 0 {
+		msg.file = file
+	}
+	for _, message := range messages {
+		msg.paragraphs = append(msg.paragraphs, messageParagraphs(message)...)
+	}
+	return msg
+}
+
+// messageParagraphs splits the message into its paragraphs, collapsing whitespace inside of them.
+func messageParagraphs(message string) []string {
+	var paragraphs []string
+	var words []string
+	for line := range strings.SplitSeq(message+"\n", "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			words = append(words, fields...)
+			continue
+		}
+		if len(words) > 0 {
+			paragraphs = append(paragraphs, strings.Join(words, " "))
+			words = nil
+		}
+	}
+	return paragraphs
+}
+
+// ParseGitCommand parses a git command string (POSIX-quoted) into a GitCommand struct.
 func ParseGitCommand(raw string) (*GitCommand, error) {
-	parts, err := shellwords.NewParser().Parse(raw)
+	return ParseGitCommandWith(raw, PosixQuoting{})
+}
+
+// ParseGitCommandWith parses a git command string quoted by the given rules into a GitCommand struct.
+func ParseGitCommandWith(raw string, quoting Quoting) (*GitCommand, error) {
+	parts, err := quoting.Split(raw)
 	if err != nil {
 		return nil, errors.New("not a shell command")
 	}
+	return parseGitWords(parts)
+}
+
+// parseGitWords parses the words of a git command ("git", the global options, the subcommand and its args).
+func parseGitWords(parts []string) (*GitCommand, error) {
 	if len(parts) < 2 || parts[0] != "git" {
 		return nil, errors.New("not a git command")
 	}
 
-	name := parts[1]
-	args := parts[2:]
+	cmd := &GitCommand{}
+	n := parseGlobalOptions(parts[1:], cmd)
+	if n == len(parts)-1 {
+		return nil, errors.New("no git subcommand")
+	}
+	cmd.Name, cmd.Args = parts[1+n], parts[2+n:]
 
 	// Special handling for git undo --hook
-	if name == CustomCommandUndo {
-		if slices.Contains(args, "--hook") {
-			return &GitCommand{
-				Name:         name,
-				Args:         args,
-				Supported:    false,
-				Type:         Custom,
-				BehaviorType: Mutating,
-			}, nil
+	if cmd.Name == CustomCommandUndo && slices.Contains(cmd.Args, "--hook") {
+		cmd.Type, cmd.BehaviorType = Custom, Mutating
+		return cmd, nil
+	}
+
+	cmd.Type, cmd.Supported = lookup[cmd.Name]
+	cmd.BehaviorType = determineBehaviorType(cmd.Name, cmd.Args)
+	return cmd, nil
+}
+
+// globalOptionsWithValue are the git options (before the subcommand) taking a value:
+// attached with `=` (for the long ones) or as the next argument.
+var globalOptionsWithValue = []string{
+	"-C", "-c", "--git-dir", "--work-tree", "--namespace", "--config-env", "--super-prefix", "--attr-source",
+}
+
+// globalFlags are the git options (before the subcommand) without a value.
+var globalFlags = []string{
+	"-p", "--paginate", "-P", "--no-pager", "--bare", "--no-replace-objects", "--no-lazy-fetch",
+	"--no-optional-locks", "--no-advice", "--literal-pathspecs", "--glob-pathspecs", "--noglob-pathspecs",
+	"--icase-pathspecs",
+}
+
+// parseGlobalOptions records the leading global options of git arguments into the command (see GitCommand)
+// and returns how many arguments they are. Options that are commands of their own (e.g. --version)
+// end them, like the subcommand does.
+func parseGlobalOptions(args []string, cmd *GitCommand) int {
+	i := 0
+loop:
+	for i < len(args) {
+		arg := args[i]
+		name, value, attached := strings.Cut(arg, "=")
+		switch {
+		case slices.Contains(globalFlags, arg), strings.HasPrefix(arg, "--exec-path="):
+			i++
+			continue
+		case attached && strings.HasPrefix(name, "--") && slices.Contains(globalOptionsWithValue, name):
+			i++
+		case slices.Contains(globalOptionsWithValue, arg) && i+1 < len(args):
+			name, value = arg, args[i+1]
+			i += 2
+		default:
+			break loop
+		}
+
+		switch name {
+		case "-C":
+			// Like `cd`: each -C is relative to the previous ones
+			if filepath.IsAbs(value) {
+				cmd.Dir = value
+			} else {
+				cmd.Dir = filepath.Join(cmd.Dir, value)
+			}
+		case "-c":
+			cmd.Config = append(cmd.Config, value)
+		case "--git-dir":
+			cmd.GitDir = value
+		case "--work-tree":
+			cmd.WorkTree = value
 		}
 	}
+	if i > 0 {
+		cmd.GlobalOptions = args[:i:i]
+	}
+	return i
+}
 
-	typ, ok := lookup[name]
-	behaviorType := determineBehaviorType(name, args)
+// AliasResolver returns the definition of a git alias (see H.GetAlias): ok is false if there's no such alias.
+type AliasResolver func(name string) (definition string, ok bool)
+
+// maxAliasDepth bounds the expansion of aliases of aliases.
+const maxAliasDepth = 10
+
+// ExpandAlias returns the command an alias invocation stands for, with Alias set to the typed name:
+// with `alias.co = checkout`, `git co main` is `git checkout main`. Aliases given with -c take precedence
+// over the resolved ones. Git commands (which can't be aliased), shell aliases (`!...`), unknown names
+// and alias loops are returned as they are.
+func (c *GitCommand) ExpandAlias(resolve AliasResolver) *GitCommand {
+	expanded := c
+	seen := make(map[string]bool)
+	for range maxAliasDepth {
+		if _, known := lookup[expanded.Name]; known {
+			return expanded
+		}
+		// Alias names are case-insensitive, as config keys are
+		name := strings.ToLower(expanded.Name)
+		if seen[name] {
+			return c
+		}
+		seen[name] = true
 
-	return &GitCommand{
-		Name:         name,
-		Args:         args,
-		Supported:    ok,
-		Type:         func() CommandType { return typ }(),
-		BehaviorType: behaviorType,
-	}, nil
+		definition, ok := expanded.configAlias(name)
+		if !ok {
+			definition, ok = resolve(name)
+		}
+		if !ok || strings.HasPrefix(definition, "!") {
+			return expanded
+		}
+		words, err := PosixQuoting{}.Split(definition)
+		if err != nil || len(words) == 0 {
+			return expanded
+		}
+		next, err := parseGitWords(slices.Concat([]string{"git"}, expanded.GlobalOptions, words, expanded.Args))
+		if err != nil {
+			return expanded
+		}
+		next.Alias = c.Name
+		expanded = next
+	}
+	return c
+}
+
+// configAlias returns the definition of the alias given with -c (the last one wins, like in git).
+func (c *GitCommand) configAlias(name string) (string, bool) {
+	for _, config := range slices.Backward(c.Config) {
+		key, value, _ := strings.Cut(config, "=")
+		if strings.EqualFold(key, "alias."+name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// SplitGitCommand splits a git command string (POSIX-quoted, as logged) into the arguments of git
+// exactly as the shell passed them: running git with them re-runs the command verbatim.
+func SplitGitCommand(raw string) ([]string, error) {
+	parts, err := PosixQuoting{}.Split(raw)
+	if err != nil {
+		return nil, errors.New("not a shell command")
+	}
+	if len(parts) < 2 || parts[0] != "git" {
+		return nil, errors.New("not a git command")
+	}
+	return parts[1:], nil
 }
 
 // String returns a human-readable representation of the command.
@@ -155,11 +478,17 @@ func (c *GitCommand) Normalize() (*GitCommand, error) {
 	}
 
 	return &GitCommand{
-		Name:         c.Name,
-		Args:         normalizedArgs,
-		Supported:    c.Supported,
-		Type:         c.Type,
-		BehaviorType: c.BehaviorType,
+		Name:          c.Name,
+		Args:          normalizedArgs,
+		Supported:     c.Supported,
+		Type:          c.Type,
+		BehaviorType:  c.BehaviorType,
+		GlobalOptions: c.GlobalOptions,
+		Dir:           c.Dir,
+		GitDir:        c.GitDir,
+		WorkTree:      c.WorkTree,
+		Config:        c.Config,
+		Alias:         c.Alias,
 	}, nil
 }
 
@@ -167,64 +496,48 @@ type argsNormalizer func([]string) ([]string, error)
 
 var (
 	// normalizeCommitArgs normalizes commit command arguments to canonical form.
+	// The message is normalized to its paragraphs (one -m per paragraph, whitespace collapsed), so
+	// `-m a -m b`, `-m $'a\n\nb'` and `-F msg.txt` (with that content) are the same commit.
 	normalizeCommitArgs = func(args []string) ([]string, error) {
-		var messageParts []string
-		amend := false
-
-		n := len(args)
-		if n == 0 {
+		if len(args) == 0 {
 			return args, nil
 		}
 
-		// Parse arguments to extract key information
-		for i := range n {
-			arg := args[i]
-			switch {
-			case arg == "-m" && i+1 < n:
-				// Collect all arguments after -m that don't start with - as the message
-				// This handles both quoted and unquoted commit messages
-				for j := i + 1; j < n; j++ {
-					nextArg := args[j]
-					if strings.HasPrefix(nextArg, "-") {
-						break // Stop at next flag
-					}
-					// Remove quotes and add to message parts
-					cleanPart := strings.Trim(nextArg, `"'`)
-					messageParts = append(messageParts, cleanPart)
-				}
-
-				// Skip all the message arguments we just processed
-				j := i + 1
-				for j < n && !strings.HasPrefix(args[j], "-") {
-					j++
-				}
-				i = j - 1 //nolint:ineffassign,staticcheck // Skip processed arguments in the range loop
-			case arg == "--amend":
-				amend = true
-			case strings.HasPrefix(arg, "-m"):
-				// Handle -m"message" format
-				if len(arg) > 2 {
-					cleanMsg := strings.Trim(arg[2:], `"'`)
-					messageParts = append(messageParts, cleanMsg)
-				}
-				// Ignore other flags like --verbose, --signoff, etc.
-			}
-		}
+		msg := parseCommitMessage(args)
 
 		// Build normalized arguments
 		var result []string
-		if amend {
+		switch {
+		case slices.Contains(args, "--amend"):
 			result = append(result, "--amend")
-		} else if len(messageParts) > 0 {
-			// Join all message parts with spaces to create the full message
-			message := strings.Join(messageParts, " ")
-			result = append(result, "-m", message)
+		case len(msg.paragraphs) > 0:
+			for _, paragraph := range msg.paragraphs {
+				result = append(result, "-m", paragraph)
+			}
+		case msg.file != "":
+			result = append(result, "-F", msg.file)
+		case msg.reuse != "":
+			result = append(result, msg.reuseFlag, msg.reuse)
+		case msg.template != "":
+			// The template only matters when the message is edited from scratch
+			result = append(result, "-t", msg.template)
+		}
+
+		// Fixup/squash commits keep their target (in the --flag=<target> form)
+		if kind, target, ok := parseFixupArgs(args); ok {
+			switch kind {
+			case FixupKindAmend, FixupKindReword:
+				result = append(result, "--"+FixupKindFixup+"="+kind+":"+target)
+			default:
+				result = append(result, "--"+kind+"="+target)
+			}
 		}
 
 		return result, nil
 	}
 
 	// normalizeMergeArgs normalizes merge command arguments to canonical form.
+	// All the merged branches are kept (in their order): octopus merges take several.
 	normalizeMergeArgs = func(args []string) ([]string, error) {
 		n := len(args)
 		if n == 0 {
@@ -232,23 +545,14 @@ var (
 		}
 
 		var squash, noFf, ff bool
-		var branch string
-
 		for _, arg := range args {
 			switch arg {
 			case "--squash":
 				squash = true
 			case "--no-ff":
 				noFf = true
-			case "--ff":
+			case "--ff", "--ff-only":
 				ff = true
-			case "--ff-only":
-				ff = true
-			default:
-				// Assume it's a branch name if it doesn't start with -
-				if !strings.HasPrefix(arg, "-") && branch == "" {
-					branch = arg
-				}
 			}
 		}
 
@@ -262,11 +566,7 @@ var (
 			result = append(result, "--ff")
 		}
 
-		if branch != "" {
-			result = append(result, branch)
-		}
-
-		return result, nil
+		return append(result, MergeOperands(args)...), nil
 	}
 
 	// normalizeRebaseArgs normalizes rebase command arguments to canonical form.
@@ -326,6 +626,26 @@ var (
 	}
 )
 
+// mergeValueFlags are the options of `git merge` that take their value as a separate argument.
+var mergeValueFlags = []string{"-m", "-F", "--file", "-s", "--strategy", "-X", "--strategy-option", "--into-name"}
+
+// MergeOperands returns the commits (branches) given to `git merge` with the given args, in their order:
+// an octopus merge has several of them.
+func MergeOperands(args []string) []string {
+	var operands []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case slices.Contains(mergeValueFlags, args[i]):
+			i++
+		case args[i] == "--":
+			return append(operands, args[i+1:]...)
+		case !strings.HasPrefix(args[i], "-"):
+			operands = append(operands, args[i])
+		}
+	}
+	return operands
+}
+
 // NormalizedString returns the normalized command as a string.
 func (c *GitCommand) NormalizedString() (string, error) {
 	normalized, err := c.Normalize()
@@ -368,14 +688,20 @@ func determineConditionalBehavior(name string, args []string) BehaviorType {
 		return determineBranchBehavior(args)
 	case "tag":
 		return determineTagBehavior(args)
+	case "apply":
+		return determineApplyBehavior(args)
 	case "remote":
 		return determineRemoteBehavior(args)
 	case "config":
 		return determineConfigBehavior(args)
+	case "notes":
+		return determineNotesBehavior(args)
 	case CustomCommandUndo: // "undo"
 		return determineUndoBehavior(args)
 	case CustomCommandBack: // "back
 		return determineBackBehavior(args)
+	case CustomCommandRedo: // "redo"
+		return determineRedoBehavior(args)
 	case "restore":
 		// restore is always mutating when it has file arguments
 		for _, arg := range args {
@@ -400,6 +726,11 @@ func determineCheckoutBehavior(args []string) BehaviorType {
 		}
 	}
 
+	// Restoring paths (e.g. `git checkout -- file.txt`) overwrites their changes
+	if slices.Contains(args, "--") {
+		return Mutating
+	}
+
 	// Check for non-flag arguments (branch names, commit hashes)
 	// Special case: "-" is not a flag, it means "previous branch"
 	for _, arg := range args {
@@ -423,6 +754,11 @@ func determineSwitchBehavior(args []string) BehaviorType {
 		}
 	}
 
+	// Restoring paths (e.g. `git checkout -- file.txt`) overwrites their changes
+	if slices.Contains(args, "--") {
+		return Mutating
+	}
+
 	// Check for non-flag arguments (branch names, commit hashes)
 	// Special case: "-" is not a flag, it means "previous branch"
 	for _, arg := range args {
@@ -465,6 +801,24 @@ func determineBranchBehavior(args []string) BehaviorType {
 	return ReadOnly
 }
 
+// determineApplyBehavior determines if a git apply command changes anything:
+// --check and the diffstat options only inspect the patch, unless --apply is given too.
+func determineApplyBehavior(args []string) BehaviorType {
+	inspects := false
+	for _, arg := range args {
+		switch arg {
+		case "--apply":
+			return Mutating
+		case "--check", "--stat", "--numstat", "--summary":
+			inspects = true
+		}
+	}
+	if inspects {
+		return ReadOnly
+	}
+	return Mutating
+}
+
 // determineTagBehavior determines if a tag command is mutating, navigating, or read-only.
 func determineTagBehavior(args []string) BehaviorType {
 	// Check for read-only flags
@@ -511,6 +865,31 @@ func determineRemoteBehavior(args []string) BehaviorType {
 	}
 }
 
+// determineNotesBehavior determines if a notes command is mutating or read-only.
+func determineNotesBehavior(args []string) BehaviorType {
+	// The action follows the options of notes itself (e.g. `git notes --ref review add`)
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--ref" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(args[i], "-") {
+			continue
+		}
+
+		switch args[i] {
+		case "list", "show", "get-ref":
+			return ReadOnly
+		case "add", "append", "copy", "edit", "remove", "merge", "prune":
+			return Mutating
+		default:
+			// Unknown subcommand, assume read-only
+			return ReadOnly
+		}
+	}
+	return ReadOnly // Lists notes
+}
+
 // determineConfigBehavior determines if a config command is mutating, navigating, or read-only.
 func determineConfigBehavior(args []string) BehaviorType {
 	// Check for read-only flags
@@ -521,21 +900,51 @@ func determineConfigBehavior(args []string) BehaviorType {
 		}
 	}
 
-	// If no read-only flags and has arguments, assume mutating (setting config)
+	// Flags changing the config whatever the other arguments
 	for _, arg := range args {
-		if !strings.HasPrefix(arg, "-") {
+		if strings.HasPrefix(arg, "--unset") || arg == "--add" || arg == "--replace-all" ||
+			arg == "--rename-section" || arg == "--remove-section" || arg == "-e" || arg == "--edit" {
 			return Mutating
 		}
 	}
 
-	// Only flags or no arguments - read-only
-	return ReadOnly
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-f" || args[i] == "--file" || args[i] == "--type" || args[i] == "--default":
+			i++ // skip the value of the option
+		case !strings.HasPrefix(args[i], "-"):
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) == 0 {
+		// Only flags or no arguments - read-only
+		return ReadOnly
+	}
+
+	// The subcommand syntax (e.g. `git config set user.name "Jane"`)
+	switch positional[0] {
+	case "get", "list":
+		return ReadOnly
+	case "set", "unset", "rename-section", "remove-section", "edit":
+		return Mutating
+	}
+
+	// A key alone is read, a key with a value is set
+	if len(positional) == 1 {
+		return ReadOnly
+	}
+	return Mutating
 }
 
+// readOnlyUndoSubcommands are the git undo subcommands that change nothing to undo.
+var readOnlyUndoSubcommands = []string{"log", "config", "completion"}
+
 // determineUndoBehavior determines if an undo command is mutating, navigating, or read-only.
 func determineUndoBehavior(args []string) BehaviorType {
-	// git undo --log (same as git back --log) are simple read-only commands (show commands log)
-	if slices.Contains(args, "--log") {
+	// git undo --log (same as git back --log) and git undo log are simple read-only commands (show commands log),
+	// git undo config changes settings, not the repository, and git undo completion prints a script: nothing to undo
+	if slices.Contains(args, "--log") || len(args) > 0 && slices.Contains(readOnlyUndoSubcommands, args[0]) {
 		return ReadOnly
 	}
 	return Mutating
@@ -544,3 +953,7 @@ func determineUndoBehavior(args []string) BehaviorType {
 // determineBackBehavior determines if a back command is mutating, navigating, or read-only.
 // behaves exactly the same as `git undo`.
 var determineBackBehavior = determineUndoBehavior
+
+// determineRedoBehavior determines if a redo command is mutating, navigating, or read-only.
+// behaves exactly the same as `git undo`.
+var determineRedoBehavior = determineUndoBehavior
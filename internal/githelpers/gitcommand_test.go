@@ -1,6 +1,8 @@
 package githelpers_test
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -195,6 +197,28 @@ func TestIsReadOnlyGitCommand(t *testing.T) {
 			expected: false,
 		},
 
+		// Special case: notes
+		{
+			name:     "Notes with no args",
+			command:  "git notes",
+			expected: true,
+		},
+		{
+			name:     "Notes show",
+			command:  "git notes --ref review show HEAD",
+			expected: true,
+		},
+		{
+			name:     "Notes add (modifying)",
+			command:  "git notes --ref review add -m message",
+			expected: false,
+		},
+		{
+			name:     "Notes remove (modifying)",
+			command:  "git notes remove HEAD",
+			expected: false,
+		},
+
 		// Special case: branch
 		{
 			name:     "Branch with no args",
@@ -471,6 +495,30 @@ func TestBranchCommandVariations(t *testing.T) {
 	}
 }
 
+// TestApplyCommandVariations tests specifically the different variations of the git apply command.
+func TestApplyCommandVariations(t *testing.T) {
+	readOnlyApplyCommands := []string{
+		"git apply --check fix.patch",
+		"git apply --stat fix.patch",
+		"git apply --numstat --summary fix.patch",
+	}
+
+	modifyingApplyCommands := []string{
+		"git apply fix.patch",
+		"git apply --cached fix.patch",
+		"git apply --3way fix.patch",
+		"git apply --stat --apply fix.patch",
+	}
+
+	for _, cmd := range readOnlyApplyCommands {
+		assert.True(t, githelpers.IsReadOnlyGitCommand(cmd), "Expected read-only: %s", cmd)
+	}
+
+	for _, cmd := range modifyingApplyCommands {
+		assert.False(t, githelpers.IsReadOnlyGitCommand(cmd), "Expected modifying: %s", cmd)
+	}
+}
+
 // TestTagCommandVariations tests specifically the different variations of the git tag command.
 func TestTagCommandVariations(t *testing.T) {
 	readOnlyTagCommands := []string{
@@ -508,6 +556,11 @@ func TestConfigCommandVariations(t *testing.T) {
 		"git config --get-all remote.origin.fetch",
 		"git config --get-regexp '^user.'",
 		"git config --get-urlmatch http github.com",
+		"git config user.name",
+		"git config --global user.name",
+		"git config -f custom.cfg core.foo",
+		"git config get user.name",
+		"git config list --global",
 	}
 
 	modifyingConfigCommands := []string{
@@ -518,6 +571,9 @@ func TestConfigCommandVariations(t *testing.T) {
 		"git config --add section.key value",
 		"git config --replace-all section.key value",
 		"git config --local core.autocrlf true",
+		"git config set user.name 'John Doe'",
+		"git config unset --global user.name",
+		"git config --remove-section user",
 	}
 
 	for _, cmd := range readOnlyConfigCommands {
@@ -560,6 +616,42 @@ func TestParseGitCommand_Undo(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "undo log is read-only",
+			command: "git undo log --all-refs",
+			want: &githelpers.GitCommand{
+				Name:         "undo",
+				Args:         []string{"log", "--all-refs"},
+				Supported:    true,
+				Type:         githelpers.Custom,
+				BehaviorType: githelpers.ReadOnly,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "undo config is not logged",
+			command: "git undo config set theme plain",
+			want: &githelpers.GitCommand{
+				Name:         "undo",
+				Args:         []string{"config", "set", "theme", "plain"},
+				Supported:    true,
+				Type:         githelpers.Custom,
+				BehaviorType: githelpers.ReadOnly,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "undo completion is not logged",
+			command: "git undo completion zsh",
+			want: &githelpers.GitCommand{
+				Name:         "undo",
+				Args:         []string{"completion", "zsh"},
+				Supported:    true,
+				Type:         githelpers.Custom,
+				BehaviorType: githelpers.ReadOnly,
+			},
+			wantErr: false,
+		},
 		{
 			name:    "undo with --hook is not supported",
 			command: "git undo --hook",
@@ -639,6 +731,16 @@ func TestCheckoutCommandReadOnly(t *testing.T) {
 			command:  "git checkout --branch new-branch",
 			expected: githelpers.Mutating,
 		},
+		{
+			name:     "checkout of paths (mutating - overwrites their changes)",
+			command:  "git checkout -- file.txt",
+			expected: githelpers.Mutating,
+		},
+		{
+			name:     "checkout of paths from a commit (mutating)",
+			command:  "git checkout HEAD~1 -- file.txt",
+			expected: githelpers.Mutating,
+		},
 		{
 			name:     "checkout with only flags (read-only)",
 			command:  "git checkout -b",
@@ -852,3 +954,256 @@ func TestBehaviorTypeClassification(t *testing.T) {
 		})
 	}
 }
+
+func TestGitCommandFixup(t *testing.T) {
+	tests := []struct {
+		command        string
+		expectedKind   string
+		expectedTarget string
+		expectedNorm   string
+	}{
+		{"git commit --fixup abc123", githelpers.FixupKindFixup, "abc123", "git commit --fixup=abc123"},
+		{"git commit --fixup=HEAD~2", githelpers.FixupKindFixup, "HEAD~2", "git commit --fixup=HEAD~2"},
+		{
+			"git commit --squash=abc123 -m 'more'",
+			githelpers.FixupKindSquash, "abc123", "git commit -m more --squash=abc123",
+		},
+		{"git commit --fixup=amend:abc123", githelpers.FixupKindAmend, "abc123", "git commit --fixup=amend:abc123"},
+		{"git commit --fixup=reword:abc123", githelpers.FixupKindReword, "abc123", "git commit --fixup=reword:abc123"},
+		{"git commit -m 'regular'", "", "", "git commit -m regular"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			gitCmd, err := githelpers.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+
+			kind, target, ok := gitCmd.Fixup()
+			assert.Equal(t, tt.expectedKind != "", ok)
+			assert.Equal(t, tt.expectedKind, kind)
+			assert.Equal(t, tt.expectedTarget, target)
+
+			normalized, err := gitCmd.NormalizedString()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedNorm, normalized)
+		})
+	}
+
+	assert.Equal(t, "squash! ", githelpers.FixupSubjectPrefix(githelpers.FixupKindSquash))
+	assert.Equal(t, "amend! ", githelpers.FixupSubjectPrefix(githelpers.FixupKindReword))
+}
+
+func TestCommitNormalization(t *testing.T) {
+	msgFile := filepath.Join(t.TempDir(), "msg.txt")
+	require.NoError(t, os.WriteFile(msgFile, []byte("Subject line\n\nBody first line\nbody second line\n"), 0600))
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"git commit", "git commit"},
+		{`git commit -m "Add file"`, "git commit -m Add file"},
+		{"git commit -m Add file", "git commit -m Add file"},
+		{`git commit -m"Add file"`, "git commit -m Add file"},
+		{`git commit --message="Add file"`, "git commit -m Add file"},
+		{`git commit --message "Add file"`, "git commit -m Add file"},
+		{`git commit -am "Add file"`, "git commit -m Add file"},
+		{`git commit -m "-dash first"`, "git commit -m -dash first"},
+		{`git commit -m "Subject" -m "Body"`, "git commit -m Subject -m Body"},
+		{`git commit -m Subject -m Body text`, "git commit -m Subject -m Body text"},
+		{"git commit -m 'Subject\n\nBody  first\nline'", "git commit -m Subject -m Body first line"},
+		{`git commit -m "Subject" -m ""`, "git commit -m Subject"},
+		{"git commit -F " + msgFile, "git commit -m Subject line -m Body first line body second line"},
+		{"git commit --file=" + msgFile, "git commit -m Subject line -m Body first line body second line"},
+		{"git commit -F missing.txt", "git commit -F missing.txt"},
+		{"git commit --file -", "git commit -F -"},
+		{"git commit -t tmpl.txt", "git commit -t tmpl.txt"},
+		{"git commit --template=tmpl.txt -m Message", "git commit -m Message"},
+		{"git commit -C HEAD~1", "git commit -C HEAD~1"},
+		{"git commit --reedit-message=abc123", "git commit -c abc123"},
+		{"git commit -S -m Signed", "git commit -m Signed"},
+		{"git commit -m Message -- file.txt", "git commit -m Message"},
+		{`git commit --amend -m "New message"`, "git commit --amend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			gitCmd, err := githelpers.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+
+			normalized, err := gitCmd.NormalizedString()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, normalized)
+		})
+	}
+
+	// The git hook logs one -m per paragraph of the created commit: it must match what the user typed
+	hookCmd, err := githelpers.ParseGitCommand(`git commit -m "Subject line" -m "Body first line body second line"`)
+	require.NoError(t, err)
+	for _, typed := range []string{"git commit -F " + msgFile, `git commit -m "Subject line" -m "Body first line
+body second line"`} {
+		typedCmd, err := githelpers.ParseGitCommand(typed)
+		require.NoError(t, err)
+		typedNorm, err := typedCmd.NormalizedString()
+		require.NoError(t, err)
+		hookNorm, err := hookCmd.NormalizedString()
+		require.NoError(t, err)
+		assert.Equal(t, hookNorm, typedNorm, typed)
+	}
+}
+
+func TestMergeNormalization(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"git merge feature", "git merge feature"},
+		{"git merge --no-ff -m 'Merge it' feature", "git merge --no-ff feature"},
+		{"git merge --ff-only origin/main", "git merge --ff origin/main"},
+		{"git merge --squash feature", "git merge --squash feature"},
+		{"git merge one two three", "git merge one two three"},
+		{"git merge -s octopus -X ours one two", "git merge one two"},
+		{"git merge --no-edit --strategy=ort feature", "git merge feature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			gitCmd, err := githelpers.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+
+			normalized, err := gitCmd.NormalizedString()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, normalized)
+		})
+	}
+
+	assert.Equal(t, []string{"one", "two"}, githelpers.MergeOperands([]string{"-m", "msg", "--", "one", "two"}))
+}
+
+func TestSplitGitCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{`git commit -m "it's \"done\""`, []string{"commit", "-m", `it's "done"`}},
+		{`git commit -m 'fix: a  b' --no-verify`, []string{"commit", "-m", "fix: a  b", "--no-verify"}},
+		{`git tag -a v1 -m 'multi word notes'`, []string{"tag", "-a", "v1", "-m", "multi word notes"}},
+		{`git -c user.name='A B' commit --allow-empty`, []string{"-c", "user.name=A B", "commit", "--allow-empty"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			args, err := githelpers.SplitGitCommand(tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	for _, invalid := range []string{"", "git", "ls -la", `git commit -m "unterminated`} {
+		_, err := githelpers.SplitGitCommand(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseGitCommand_GlobalOptions(t *testing.T) {
+	tests := []struct {
+		command  string
+		name     string
+		args     []string
+		globals  []string
+		gitDir   string
+		workTree string
+	}{
+		{"git --no-pager log -1", "log", []string{"-1"}, []string{"--no-pager"}, "", ""},
+		{
+			"git --git-dir=../other/.git --work-tree ../other commit -m msg", "commit", []string{"-m", "msg"},
+			[]string{"--git-dir=../other/.git", "--work-tree", "../other"}, "../other/.git", "../other",
+		},
+		{
+			"git --git-dir /repo.git -c core.pager=cat -p branch feature", "branch", []string{"feature"},
+			[]string{"--git-dir", "/repo.git", "-c", "core.pager=cat", "-p"}, "/repo.git", "",
+		},
+		{"git --literal-pathspecs add -- '*.go'", "add", []string{"--", "*.go"}, []string{"--literal-pathspecs"}, "", ""},
+		{"git --version", "--version", []string{}, nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			gitCmd, err := githelpers.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, gitCmd.Name)
+			assert.Equal(t, tt.args, gitCmd.Args)
+			assert.Equal(t, tt.globals, gitCmd.GlobalOptions)
+			assert.Equal(t, tt.gitDir, gitCmd.GitDir)
+			assert.Equal(t, tt.workTree, gitCmd.WorkTree)
+		})
+	}
+
+	gitCmd, err := githelpers.ParseGitCommand("git --git-dir=.git commit -m msg")
+	require.NoError(t, err)
+	assert.True(t, gitCmd.Supported)
+	assert.True(t, gitCmd.IsMutating())
+
+	gitCmd, err = githelpers.ParseGitCommand("git -C repo -C sub -c user.name=x -c core.pager=cat commit -m msg")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("repo", "sub"), gitCmd.Dir)
+	assert.Equal(t, []string{"user.name=x", "core.pager=cat"}, gitCmd.Config)
+	gitCmd, err = githelpers.ParseGitCommand("git -C repo -C /abs add .")
+	require.NoError(t, err)
+	assert.Equal(t, "/abs", gitCmd.Dir)
+
+	for _, invalid := range []string{"git --no-pager", "git --git-dir=.git"} {
+		_, err := githelpers.ParseGitCommand(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGitCommandExpandAlias(t *testing.T) {
+	aliases := map[string]string{
+		"co":   "checkout",
+		"ci":   "commit -v",
+		"amd":  "ci --amend",
+		"lg":   "log --oneline",
+		"sh":   "!git status && git log",
+		"loop": "loop",
+		"np":   "--no-pager branch",
+	}
+	resolve := func(name string) (string, bool) {
+		definition, ok := aliases[name]
+		return definition, ok
+	}
+
+	tests := []struct {
+		command  string
+		expected string
+		alias    string
+	}{
+		{"git co main", "git checkout main", "co"},
+		{"git CO main", "git checkout main", "CO"},
+		{"git ci -m msg", "git commit -v -m msg", "ci"},
+		{"git amd --no-edit", "git commit -v --amend --no-edit", "amd"},
+		{"git np feature", "git branch feature", "np"},
+		{"git -c alias.co=switch co main", "git switch main", "co"},
+		{"git checkout main", "git checkout main", ""},
+		{"git sh", "git sh", ""},
+		{"git loop", "git loop", ""},
+		{"git unknown", "git unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			gitCmd, err := githelpers.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+
+			expanded := gitCmd.ExpandAlias(resolve)
+			assert.Equal(t, tt.expected, expanded.String())
+			assert.Equal(t, tt.alias, expanded.Alias)
+		})
+	}
+
+	gitCmd, err := githelpers.ParseGitCommand("git ci -m msg")
+	require.NoError(t, err)
+	expanded := gitCmd.ExpandAlias(resolve)
+	assert.True(t, expanded.Supported)
+	assert.True(t, expanded.IsMutating())
+	expanded = (&githelpers.GitCommand{Name: "lg"}).ExpandAlias(resolve)
+	assert.True(t, expanded.IsReadOnly())
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -14,13 +16,27 @@ import (
 type H struct {
 	repoDir string
 	ctx     context.Context
+	// env locates the repository instead of repoDir (GIT_DIR and GIT_WORK_TREE, absolute).
+	env []string
+	// isolated drops the location inherited from the environment (see Isolated).
+	isolated bool
 }
 
 const invalidRepoDir = "<invalid repo dir>"
 
+// Environment variables locating the repository (see git(1)).
+const (
+	EnvGitDir       = "GIT_DIR"
+	EnvGitWorkTree  = "GIT_WORK_TREE"
+	EnvGitIndexFile = "GIT_INDEX_FILE"
+	EnvGitCommonDir = "GIT_COMMON_DIR"
+)
+
 // NewGitHelper creates a new GitHelper instance.
+// GIT_DIR and GIT_WORK_TREE are honored: relative to the current directory, whatever the repoDir.
 func NewGitHelper(ctx context.Context, repoDirArg ...string) *H {
 	h := &H{ctx: ctx}
+	h.env = locationEnv("", os.Getenv(EnvGitDir), os.Getenv(EnvGitWorkTree))
 
 	if len(repoDirArg) > 0 {
 		h.repoDir = repoDirArg[0]
@@ -34,17 +50,70 @@ func NewGitHelper(ctx context.Context, repoDirArg ...string) *H {
 	return h
 }
 
-// execGitOutput executes a git command and returns its output as string.
-func (h *H) execGitOutput(subCmd string, args ...string) (string, error) {
-	if h.repoDir == invalidRepoDir {
-		return "", errors.New("not a valid git repository")
+// WithLocation returns a copy of the helper for the repository at the git dir and work tree
+// (like `git --git-dir=<gitDir> --work-tree=<workTree>`, relative to the helper's repoDir).
+// Empty ones are left as they are.
+func (h *H) WithLocation(gitDir, workTree string) *H {
+	base := h.repoDir
+	located := *h
+	if base == invalidRepoDir {
+		base, located.repoDir = "", "."
+	}
+	located.env = append(slices.Clone(h.env), locationEnv(base, gitDir, workTree)...)
+	return &located
+}
+
+// Isolated returns a copy of the helper for the repository at its repoDir only: the location inherited
+// from the environment (GIT_DIR, GIT_WORK_TREE, GIT_INDEX_FILE and GIT_COMMON_DIR) is dropped.
+func (h *H) Isolated() *H {
+	isolated := *h
+	isolated.env, isolated.isolated = nil, true
+	return &isolated
+}
+
+// locationEnv returns the environment variables locating the repository at the git dir and work tree
+// (made absolute from base) that are given.
+func locationEnv(base, gitDir, workTree string) []string {
+	var env []string
+	for _, location := range []struct{ name, path string }{{EnvGitDir, gitDir}, {EnvGitWorkTree, workTree}} {
+		if location.path == "" {
+			continue
+		}
+		path := location.path
+		if !filepath.IsAbs(path) {
+			if abs, err := filepath.Abs(filepath.Join(base, path)); err == nil {
+				path = abs
+			}
+		}
+		env = append(env, location.name+"="+path)
 	}
+	return env
+}
 
+// command returns the git command run in the repository.
+func (h *H) command(subCmd string, args ...string) *exec.Cmd {
 	gitArgs := append([]string{subCmd}, args...)
 	cmd := exec.CommandContext(h.ctx, "git", gitArgs...)
 	cmd.Dir = h.repoDir
+	switch {
+	case h.isolated:
+		cmd.Env = slices.DeleteFunc(os.Environ(), func(variable string) bool {
+			name, _, _ := strings.Cut(variable, "=")
+			return slices.Contains([]string{EnvGitDir, EnvGitWorkTree, EnvGitIndexFile, EnvGitCommonDir}, name)
+		})
+	case len(h.env) > 0:
+		cmd.Env = append(os.Environ(), h.env...)
+	}
+	return cmd
+}
+
+// execGitOutput executes a git command and returns its output as string.
+func (h *H) execGitOutput(subCmd string, args ...string) (string, error) {
+	if h.repoDir == invalidRepoDir {
+		return "", errors.New("not a valid git repository")
+	}
 
-	output, err := cmd.Output()
+	output, err := h.command(subCmd, args...).Output()
 	if err != nil {
 		return "", err
 	}
@@ -58,9 +127,17 @@ func (h *H) execGitRun(subCmd string, args ...string) error {
 		return errors.New("not a valid git repository")
 	}
 
-	gitArgs := append([]string{subCmd}, args...)
-	cmd := exec.CommandContext(h.ctx, "git", gitArgs...)
-	cmd.Dir = h.repoDir
+	return h.command(subCmd, args...).Run()
+}
+
+// execGitRunWithInput executes a git command with the input on its stdin.
+func (h *H) execGitRunWithInput(input string, subCmd string, args ...string) error {
+	if h.repoDir == invalidRepoDir {
+		return errors.New("not a valid git repository")
+	}
+
+	cmd := h.command(subCmd, args...)
+	cmd.Stdin = strings.NewReader(input)
 
 	return cmd.Run()
 }
@@ -75,6 +152,33 @@ func (h *H) validateGitRepo() (string, error) {
 	return gitDir, nil
 }
 
+// Errors of repositories without a work tree (see CheckWorkTree).
+var (
+	ErrBareRepository = errors.New("in a bare repository")
+	ErrInsideGitDir   = errors.New("inside the git dir")
+)
+
+// CheckWorkTree returns an error when the commands have no work tree to run in: in a bare repository
+// (unless a work tree is given, e.g. via GIT_WORK_TREE) or inside the git dir. It's nil outside of repositories.
+func (h *H) CheckWorkTree() error {
+	output, err := h.execGitOutput("rev-parse", "--is-bare-repository", "--is-inside-git-dir", "--is-inside-work-tree")
+	if err != nil {
+		return nil
+	}
+	answers := strings.Fields(output)
+	if len(answers) != 3 {
+		return nil
+	}
+	switch bare, insideGitDir, insideWorkTree := answers[0], answers[1], answers[2]; {
+	case bare == "true":
+		return ErrBareRepository
+	case insideGitDir == "true" && insideWorkTree != "true":
+		return ErrInsideGitDir
+	default:
+		return nil
+	}
+}
+
 // GetCurrentGitRef returns the current ref (branch, tag, commit hash) in the repository.
 func (h *H) GetCurrentGitRef() (string, error) {
 	// Try to get branch name first
@@ -116,11 +220,23 @@ func (h *H) GetRepoGitDir() (string, error) {
 	return gitDir, nil
 }
 
+// GetAlias returns the definition of the git alias (`git config --get alias.<name>`),
+// ok is false if there's no such alias. It's an AliasResolver.
+func (h *H) GetAlias(name string) (string, bool) {
+	definition, err := h.execGitOutput("config", "--get", "alias."+name)
+	return definition, err == nil && definition != ""
+}
+
 // GitRun executes a git command without output (via Run).
 func (h *H) GitRun(subCmd string, args ...string) error {
 	return h.execGitRun(subCmd, args...)
 }
 
+// GitRunWithInput executes a git command with the input on its stdin (e.g. `git update-ref --stdin`).
+func (h *H) GitRunWithInput(input string, subCmd string, args ...string) error {
+	return h.execGitRunWithInput(input, subCmd, args...)
+}
+
 // GitOutput executes a git command and returns its output as string.
 func (h *H) GitOutput(subCmd string, args ...string) (string, error) {
 	return h.execGitOutput(subCmd, args...)
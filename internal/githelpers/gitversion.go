@@ -0,0 +1,159 @@
+package githelpers
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GitVersion is a version of git. The zero value means the version is unknown.
+type GitVersion struct {
+	Major, Minor, Patch int
+}
+
+// String returns the version in its usual form (e.g. 2.39.5).
+func (v GitVersion) String() string {
+	if v.IsUnknown() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// IsUnknown returns true if the version couldn't be detected.
+func (v GitVersion) IsUnknown() bool {
+	return v == GitVersion{}
+}
+
+// AtLeast returns true if the version is the given one or newer.
+func (v GitVersion) AtLeast(other GitVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// Supports returns true if git of this version has the capability.
+// An unknown version is assumed to be recent enough for everything.
+func (v GitVersion) Supports(c Capability) bool {
+	since, ok := capabilities[c]
+	return !ok || v.IsUnknown() || v.AtLeast(since)
+}
+
+// ParseGitVersion parses the output of `git version`
+// (e.g. "git version 2.39.5", "git version 2.39.3 (Apple Git-146)" or "git version 2.45.1.windows.1").
+func ParseGitVersion(output string) (GitVersion, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(output), "git version"))
+	if len(fields) == 0 {
+		return GitVersion{}, fmt.Errorf("unexpected git version output: %q", output)
+	}
+
+	var numbers [3]int
+	for i, part := range strings.SplitN(fields[0], ".", 4) {
+		if i == len(numbers) {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			if i < 2 {
+				return GitVersion{}, fmt.Errorf("unexpected git version: %q", fields[0])
+			}
+			break // e.g. release candidates (2.40.0-rc1) or vendor suffixes
+		}
+		numbers[i] = n
+	}
+	return GitVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// detectedGitVersion is the version of the installed git, detected once per process.
+var detectedGitVersion = sync.OnceValue(func() GitVersion {
+	output, err := exec.Command("git", "version").Output()
+	if err != nil {
+		return GitVersion{}
+	}
+	version, err := ParseGitVersion(string(output))
+	if err != nil {
+		return GitVersion{}
+	}
+	return version
+})
+
+// InstalledGitVersion returns the version of the installed git (unknown if it couldn't be detected).
+// It's detected on the first call only.
+func InstalledGitVersion() GitVersion {
+	return detectedGitVersion()
+}
+
+// GitVersion returns the version of the installed git (see InstalledGitVersion).
+func (h *H) GitVersion() GitVersion {
+	return InstalledGitVersion()
+}
+
+// Capability is a git subcommand or flag that not every git version has.
+type Capability string
+
+// Capabilities that undo and redo commands depend on.
+const (
+	// CapSwitch is `git switch`.
+	CapSwitch Capability = "switch"
+	// CapRestore is `git restore`.
+	CapRestore Capability = "restore"
+	// CapStashPush is `git stash push` (with pathspecs).
+	CapStashPush Capability = "stash push"
+	// CapPathFormat is `git rev-parse --path-format`.
+	CapPathFormat Capability = "rev-parse --path-format"
+	// CapFixupAmend is `git commit --fixup=amend:<commit>` (and reword:).
+	CapFixupAmend Capability = "commit --fixup=amend"
+	// CapMergeAutostash is `git merge --autostash`.
+	CapMergeAutostash Capability = "merge --autostash"
+)
+
+// capabilities are the git versions that introduced the capabilities.
+var capabilities = map[Capability]GitVersion{
+	CapSwitch:         {Major: 2, Minor: 23},
+	CapRestore:        {Major: 2, Minor: 23},
+	CapStashPush:      {Major: 2, Minor: 13},
+	CapPathFormat:     {Major: 2, Minor: 31},
+	CapFixupAmend:     {Major: 2, Minor: 32},
+	CapMergeAutostash: {Major: 2, Minor: 27},
+}
+
+// RequiredCapabilities returns the capabilities the command needs from git.
+func (c *GitCommand) RequiredCapabilities() []Capability {
+	var required []Capability
+	switch c.Name {
+	case "switch":
+		required = append(required, CapSwitch)
+	case "restore":
+		required = append(required, CapRestore)
+	case "stash":
+		if len(c.Args) > 0 && c.Args[0] == "push" {
+			required = append(required, CapStashPush)
+		}
+	case "commit":
+		if kind, _, ok := c.Fixup(); ok && (kind == FixupKindAmend || kind == FixupKindReword) {
+			required = append(required, CapFixupAmend)
+		}
+	case "merge":
+		for _, arg := range c.Args {
+			if arg == "--autostash" {
+				required = append(required, CapMergeAutostash)
+			}
+		}
+	}
+	return required
+}
+
+// SupportedBy returns the first capability the command needs that git of the given version lacks.
+func (c *GitCommand) SupportedBy(v GitVersion) (Capability, bool) {
+	for _, capability := range c.RequiredCapabilities() {
+		if !v.Supports(capability) {
+			return capability, false
+		}
+	}
+	return "", true
+}
@@ -0,0 +1,75 @@
+package githelpers_test
+
+import (
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitVersion(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected githelpers.GitVersion
+	}{
+		{"git version 2.39.5\n", githelpers.GitVersion{Major: 2, Minor: 39, Patch: 5}},
+		{"git version 2.39.3 (Apple Git-146)", githelpers.GitVersion{Major: 2, Minor: 39, Patch: 3}},
+		{"git version 2.45.1.windows.1", githelpers.GitVersion{Major: 2, Minor: 45, Patch: 1}},
+		{"git version 2.40.0-rc1", githelpers.GitVersion{Major: 2, Minor: 40}},
+		{"git version 2.17", githelpers.GitVersion{Major: 2, Minor: 17}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			version, err := githelpers.ParseGitVersion(tt.output)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+
+	for _, output := range []string{"", "git version", "git version next"} {
+		_, err := githelpers.ParseGitVersion(output)
+		require.Error(t, err, output)
+	}
+}
+
+func TestGitVersionSupports(t *testing.T) {
+	old := githelpers.GitVersion{Major: 2, Minor: 22, Patch: 5}
+	recent := githelpers.GitVersion{Major: 2, Minor: 23}
+
+	assert.False(t, old.Supports(githelpers.CapSwitch))
+	assert.False(t, old.Supports(githelpers.CapRestore))
+	assert.True(t, old.Supports(githelpers.CapStashPush))
+	assert.True(t, recent.Supports(githelpers.CapSwitch))
+	assert.False(t, recent.Supports(githelpers.CapPathFormat))
+
+	// Unknown versions are assumed to support everything
+	assert.True(t, githelpers.GitVersion{}.Supports(githelpers.CapFixupAmend))
+	assert.Equal(t, "unknown", githelpers.GitVersion{}.String())
+}
+
+func TestGitCommandSupportedBy(t *testing.T) {
+	old := githelpers.GitVersion{Major: 2, Minor: 20}
+
+	tests := []struct {
+		command    string
+		capability githelpers.Capability
+	}{
+		{"git switch main", githelpers.CapSwitch},
+		{"git restore --staged file.txt", githelpers.CapRestore},
+		{"git commit --fixup=amend:HEAD~1", githelpers.CapFixupAmend},
+		{"git merge --autostash feature", githelpers.CapMergeAutostash},
+		{"git checkout main", ""},
+		{"git stash push -m wip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			gitCmd, err := githelpers.ParseGitCommand(tt.command)
+			require.NoError(t, err)
+
+			capability, ok := gitCmd.SupportedBy(old)
+			assert.Equal(t, tt.capability == "", ok)
+			assert.Equal(t, tt.capability, capability)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package githelpers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// Quoting splits a command line into words the way a shell does.
+// Hooked commands arrive quoted by the user's shell, so they have to be split by its rules.
+type Quoting interface {
+	Split(line string) ([]string, error)
+}
+
+// Shell names (as given by the hooks) with non-POSIX quoting.
+const (
+	ShellCsh        = "csh"
+	ShellTcsh       = "tcsh"
+	ShellPowerShell = "powershell"
+	ShellPwsh       = "pwsh"
+	ShellCmd        = "cmd"
+)
+
+// ErrUnterminatedQuote is returned when a command line ends inside of quotes.
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// QuotingFor returns the quoting of the shell: POSIX one (sh, bash, zsh) for unknown or empty names.
+func QuotingFor(shell string) Quoting {
+	switch strings.ToLower(strings.TrimSuffix(shell, ".exe")) {
+	case ShellCsh, ShellTcsh:
+		return CshQuoting{}
+	case ShellPowerShell, ShellPwsh:
+		return PowerShellQuoting{}
+	case ShellCmd:
+		return CmdQuoting{}
+	default:
+		return PosixQuoting{}
+	}
+}
+
+// PosixQuoting is the quoting of sh, bash and zsh (and of the logged commands).
+type PosixQuoting struct{}
+
+// Split splits the line by POSIX shell rules.
+func (PosixQuoting) Split(line string) ([]string, error) {
+	return shellwords.NewParser().Parse(line)
+}
+
+// CshQuoting is the quoting of csh and tcsh: unlike POSIX shells, a backslash inside double quotes is literal.
+type CshQuoting struct{}
+
+// Split splits the line by csh rules.
+func (CshQuoting) Split(line string) ([]string, error) {
+	var sp wordSplitter
+	var quote rune
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			sp.add(r)
+		case r == '\'' || r == '"':
+			quote = r
+			sp.startWord()
+		case r == '\\' && i+1 < len(runes):
+			i++
+			sp.add(runes[i])
+		case isSpace(r):
+			sp.endWord()
+		default:
+			sp.add(r)
+		}
+	}
+	return sp.finish(quote != 0)
+}
+
+// PowerShellQuoting is the quoting of PowerShell: single quotes are literal, double quotes and bare words
+// escape with a backtick. A doubled quote inside of quotes is the quote itself.
+type PowerShellQuoting struct{}
+
+// powerShellEscapes are the special backtick escapes (others just keep the escaped character).
+var powerShellEscapes = map[rune]rune{'n': '\n', 't': '\t', 'r': '\r', '0': 0}
+
+// Split splits the line by PowerShell rules.
+func (PowerShellQuoting) Split(line string) ([]string, error) {
+	var sp wordSplitter
+	var quote rune
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0 && r == quote && i+1 < len(runes) && runes[i+1] == quote:
+			// Doubled quote is the quote itself
+			i++
+			sp.add(r)
+		case quote != 0 && r == quote:
+			quote = 0
+		case r == '`' && quote != '\'' && i+1 < len(runes):
+			i++
+			if escaped, ok := powerShellEscapes[runes[i]]; ok {
+				sp.add(escaped)
+			} else {
+				sp.add(runes[i])
+			}
+		case quote != 0:
+			sp.add(r)
+		case r == '\'' || r == '"':
+			quote = r
+			sp.startWord()
+		case isSpace(r):
+			sp.endWord()
+		default:
+			sp.add(r)
+		}
+	}
+	return sp.finish(quote != 0)
+}
+
+// CmdQuoting is the quoting of Windows cmd.exe: caret escapes outside of double quotes,
+// and the arguments are split the way Windows programs do it (backslashes escape only quotes).
+type CmdQuoting struct{}
+
+// Split splits the line by cmd.exe and CommandLineToArgvW rules.
+func (CmdQuoting) Split(line string) ([]string, error) {
+	var sp wordSplitter
+	quoted := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			backslashes := 1
+			for i+backslashes < len(runes) && runes[i+backslashes] == '\\' {
+				backslashes++
+			}
+			i += backslashes - 1
+			if i+1 < len(runes) && runes[i+1] == '"' {
+				// 2n backslashes + quote: n backslashes and the quote toggles; 2n+1: n backslashes and a quote
+				sp.add([]rune(strings.Repeat(`\`, backslashes/2))...)
+				if backslashes%2 == 1 {
+					i++
+					sp.add('"')
+				}
+				continue
+			}
+			sp.add([]rune(strings.Repeat(`\`, backslashes))...)
+		case r == '"' && quoted && i+1 < len(runes) && runes[i+1] == '"':
+			i++
+			sp.add('"')
+		case r == '"':
+			quoted = !quoted
+			sp.startWord()
+		case r == '^' && !quoted && i+1 < len(runes):
+			i++
+			sp.add(runes[i])
+		case isSpace(r) && !quoted:
+			sp.endWord()
+		default:
+			sp.add(r)
+		}
+	}
+	return sp.finish(quoted)
+}
+
+// QuotePosix joins the words into a command line that POSIX quoting splits back into the same words.
+func QuotePosix(words []string) string {
+	isSafe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("-_./:@+=,~^", r)
+	}
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word != "" && strings.IndexFunc(word, func(r rune) bool { return !isSafe(r) }) == -1 {
+			quoted = append(quoted, word)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(word, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// wordSplitter collects the words of a command line.
+type wordSplitter struct {
+	words   []string
+	current strings.Builder
+	// inWord is set once a word is started (even if empty, e.g. by "")
+	inWord bool
+}
+
+func (s *wordSplitter) add(runes ...rune) {
+	s.inWord = true
+	for _, r := range runes {
+		s.current.WriteRune(r)
+	}
+}
+
+func (s *wordSplitter) startWord() { s.inWord = true }
+
+func (s *wordSplitter) endWord() {
+	if s.inWord {
+		s.words = append(s.words, s.current.String())
+	}
+	s.current.Reset()
+	s.inWord = false
+}
+
+func (s *wordSplitter) finish(unterminated bool) ([]string, error) {
+	if unterminated {
+		return nil, ErrUnterminatedQuote
+	}
+	s.endWord()
+	return s.words, nil
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
@@ -0,0 +1,66 @@
+package githelpers_test
+
+import (
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/githelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotingSplit(t *testing.T) {
+	tests := []struct {
+		shell    string
+		line     string
+		expected []string
+	}{
+		{"", `git commit -m "it's done"`, []string{"git", "commit", "-m", "it's done"}},
+		{"zsh", `git commit -m 'say "hi"'`, []string{"git", "commit", "-m", `say "hi"`}},
+		{"bash", `git add my\ file.txt`, []string{"git", "add", "my file.txt"}},
+
+		{githelpers.ShellCsh, `git commit -m "C:\dir\"`, []string{"git", "commit", "-m", `C:\dir\`}},
+		{githelpers.ShellTcsh, `git add my\ file.txt 'a\b'`, []string{"git", "add", "my file.txt", `a\b`}},
+		{githelpers.ShellCsh, `git commit -m ""`, []string{"git", "commit", "-m", ""}},
+
+		{githelpers.ShellPwsh, "git commit -m 'it''s done'", []string{"git", "commit", "-m", "it's done"}},
+		{githelpers.ShellPowerShell, "git commit -m \"say `\"hi`\"\"", []string{"git", "commit", "-m", `say "hi"`}},
+		{githelpers.ShellPwsh, `git commit -m "say ""hi"""`, []string{"git", "commit", "-m", `say "hi"`}},
+		{githelpers.ShellPwsh, "git add my` file.txt C:\\dir\\x", []string{"git", "add", "my file.txt", `C:\dir\x`}},
+		{"pwsh.exe", "git commit -m 'a`nb'", []string{"git", "commit", "-m", "a`nb"}},
+
+		{githelpers.ShellCmd, `git commit -m "it's done"`, []string{"git", "commit", "-m", "it's done"}},
+		{githelpers.ShellCmd, `git add C:\dir\file.txt`, []string{"git", "add", `C:\dir\file.txt`}},
+		{githelpers.ShellCmd, `git commit -m "say \"hi\""`, []string{"git", "commit", "-m", `say "hi"`}},
+		{githelpers.ShellCmd, `git commit -m "say ""hi"""`, []string{"git", "commit", "-m", `say "hi"`}},
+		{githelpers.ShellCmd, `git commit -m a^&b 'x'`, []string{"git", "commit", "-m", "a&b", "'x'"}},
+		{githelpers.ShellCmd, `git add "C:\my dir\\"`, []string{"git", "add", `C:\my dir\`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell+": "+tt.line, func(t *testing.T) {
+			words, err := githelpers.QuotingFor(tt.shell).Split(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, words)
+
+			// Re-quoted words are parsed back the same by the default (POSIX) quoting
+			requoted, err := githelpers.PosixQuoting{}.Split(githelpers.QuotePosix(words))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, requoted)
+		})
+	}
+}
+
+func TestQuotingUnterminated(t *testing.T) {
+	for _, shell := range []string{githelpers.ShellCsh, githelpers.ShellPwsh, githelpers.ShellCmd} {
+		_, err := githelpers.QuotingFor(shell).Split(`git commit -m "oops`)
+		require.ErrorIs(t, err, githelpers.ErrUnterminatedQuote, shell)
+	}
+}
+
+func TestParseGitCommandWith(t *testing.T) {
+	gitCmd, err := githelpers.ParseGitCommandWith("git commit -m 'it''s done'", githelpers.PowerShellQuoting{})
+	require.NoError(t, err)
+	assert.Equal(t, "commit", gitCmd.Name)
+	assert.Equal(t, []string{"-m", "it's done"}, gitCmd.Args)
+	assert.True(t, gitCmd.Supported)
+}
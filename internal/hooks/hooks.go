@@ -0,0 +1,199 @@
+// Package hooks manages the git hooks notifying git-undo of the git operations
+// the shell hooks can't see (commits and merges made by IDEs, scripts, aliases…).
+//
+// Each hook gets a shim: a block running the git-undo dispatcher script, fenced by markers.
+// A hook that already exists (e.g. installed by another tool) keeps its own content:
+// the block is chained at its end, so both run.
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Names are the git hooks git-undo installs shims for.
+var Names = []string{"post-commit", "post-checkout", "post-merge", "post-rewrite"}
+
+const (
+	blockStart = "# >>> git-undo >>>"
+	blockEnd   = "# <<< git-undo <<<"
+
+	// The install script used to append these lines to existing hooks
+	// (or to write them as a hook of their own).
+	legacyMarker     = "# git-undo integration"
+	legacyShimMarker = "# git-undo hook - auto-generated"
+	legacyPrefix     = "GIT_UNDO_INTERNAL_HOOK=1 git-undo --hook="
+
+	shebang = "#!/usr/bin/env sh"
+)
+
+// Install writes the shims of all Names into dir (created if needed), calling the dispatcher script.
+// Shims already there are refreshed. It returns the names of the hooks that changed.
+func Install(dir, dispatcher string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var changed []string
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		if isLinkTo(path, dispatcher) {
+			continue
+		}
+		content, err := readHook(path)
+		if err != nil {
+			return changed, err
+		}
+
+		updated := withBlock(content, block(name, dispatcher))
+		if updated == content {
+			continue
+		}
+		//nolint:gosec // git runs hooks only when they are executable
+		if err := os.WriteFile(path, []byte(updated), 0o755); err != nil {
+			return changed, fmt.Errorf("failed to write the %s hook: %w", name, err)
+		}
+		// WriteFile keeps the mode of existing files
+		//nolint:gosec // see above
+		if err := os.Chmod(path, 0o755); err != nil {
+			return changed, fmt.Errorf("failed to make the %s hook executable: %w", name, err)
+		}
+		changed = append(changed, name)
+	}
+	return changed, nil
+}
+
+// Uninstall removes the shims of all Names from dir: hooks left with no content of their own are deleted,
+// others keep it. It returns the names of the hooks that changed.
+func Uninstall(dir, dispatcher string) ([]string, error) {
+	var changed []string
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		if isLinkTo(path, dispatcher) {
+			if err := os.Remove(path); err != nil {
+				return changed, fmt.Errorf("failed to remove the %s hook: %w", name, err)
+			}
+			changed = append(changed, name)
+			continue
+		}
+		content, err := readHook(path)
+		if err != nil {
+			return changed, err
+		}
+
+		stripped := withoutBlock(content)
+		if stripped == content {
+			continue
+		}
+		if isEmptyScript(stripped) {
+			err = os.Remove(path)
+		} else {
+			err = os.WriteFile(path, []byte(stripped), 0)
+		}
+		if err != nil {
+			return changed, fmt.Errorf("failed to update the %s hook: %w", name, err)
+		}
+		changed = append(changed, name)
+	}
+	return changed, nil
+}
+
+// Installed returns the names of the hooks of dir having a shim calling the dispatcher.
+func Installed(dir, dispatcher string) []string {
+	var installed []string
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		content, err := readHook(path)
+		if isLinkTo(path, dispatcher) || (err == nil && strings.Contains(content, blockStart)) {
+			installed = append(installed, name)
+		}
+	}
+	return installed
+}
+
+// block returns the shim block of the hook: the dispatcher learns the hook name from GIT_UNDO_HOOK_NAME,
+// as it can't tell it by its own name when chained. Its failures never fail the git operation.
+func block(name, dispatcher string) string {
+	return blockStart + "\n" +
+		fmt.Sprintf("GIT_UNDO_HOOK_NAME=%s %s \"$@\" || true\n", name, shellQuote(filepath.ToSlash(dispatcher))) +
+		blockEnd + "\n"
+}
+
+// withBlock returns the hook content with the block in place of the previous one (and legacy lines),
+// appended at the end or as a whole new script for a missing hook.
+func withBlock(content, blk string) string {
+	stripped := withoutBlock(content)
+	if isEmptyScript(stripped) {
+		return shebang + "\n" + blk
+	}
+	if !strings.HasSuffix(stripped, "\n") {
+		stripped += "\n"
+	}
+	return stripped + blk
+}
+
+// withoutBlock returns the hook content with no block or legacy lines left.
+func withoutBlock(content string) string {
+	if content == "" {
+		return ""
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	kept := make([]string, 0, len(lines))
+	inBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == blockStart:
+			inBlock = true
+		case trimmed == blockEnd:
+			inBlock = false
+		case inBlock, trimmed == legacyMarker, trimmed == legacyShimMarker, strings.HasPrefix(trimmed, legacyPrefix):
+		default:
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// isEmptyScript reports whether the hook content does nothing: just a shebang, comments, blank lines
+// or shell options (set -e of the hooks the install script wrote).
+func isEmptyScript(content string) bool {
+	return !slices.ContainsFunc(strings.Split(content, "\n"), func(line string) bool {
+		line = strings.TrimSpace(line)
+		return line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "set -")
+	})
+}
+
+// readHook returns the content of the hook file, empty if there is none.
+func readHook(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the %s hook: %w", filepath.Base(path), err)
+	}
+	return string(content), nil
+}
+
+// isLinkTo reports whether path is a symlink to target: the install script used to link hooks to the dispatcher.
+func isLinkTo(path, target string) bool {
+	dest, err := os.Readlink(path)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(filepath.Dir(path), dest)
+	}
+	return filepath.Clean(dest) == filepath.Clean(target)
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,82 @@
+package hooks_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/hooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallUninstall(t *testing.T) {
+	dir := t.TempDir()
+	dispatcher := filepath.Join(t.TempDir(), "git-hooks.sh")
+
+	foreign := "#!/bin/sh\necho lint\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "post-merge"), []byte(foreign), 0o644))
+
+	changed, err := hooks.Install(dir, dispatcher)
+	require.NoError(t, err)
+	assert.Equal(t, hooks.Names, changed)
+	assert.Equal(t, hooks.Names, hooks.Installed(dir, dispatcher))
+
+	shim, err := os.ReadFile(filepath.Join(dir, "post-commit"))
+	require.NoError(t, err)
+	assert.Contains(t, string(shim), "GIT_UNDO_HOOK_NAME=post-commit '"+filepath.ToSlash(dispatcher)+"' \"$@\" || true")
+	info, err := os.Stat(filepath.Join(dir, "post-commit"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0o100, "hooks must be executable")
+
+	chained, err := os.ReadFile(filepath.Join(dir, "post-merge"))
+	require.NoError(t, err)
+	assert.Contains(t, string(chained), foreign, "the existing hook keeps its content")
+	assert.Contains(t, string(chained), "GIT_UNDO_HOOK_NAME=post-merge")
+
+	// Installing again changes nothing
+	changed, err = hooks.Install(dir, dispatcher)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	changed, err = hooks.Uninstall(dir, dispatcher)
+	require.NoError(t, err)
+	assert.Equal(t, hooks.Names, changed)
+	assert.Empty(t, hooks.Installed(dir, dispatcher))
+
+	assert.NoFileExists(t, filepath.Join(dir, "post-commit"))
+	restored, err := os.ReadFile(filepath.Join(dir, "post-merge"))
+	require.NoError(t, err)
+	assert.Equal(t, foreign, string(restored))
+}
+
+func TestInstallReplacesLegacyIntegration(t *testing.T) {
+	dir := t.TempDir()
+	dispatcher := filepath.Join(dir, "git-hooks.sh")
+	require.NoError(t, os.WriteFile(dispatcher, []byte("#!/bin/sh\n"), 0o755))
+
+	// The install script used to link hooks to the dispatcher, or append a line to existing hooks
+	require.NoError(t, os.Symlink(dispatcher, filepath.Join(dir, "post-commit")))
+	legacy := "#!/bin/sh\necho lint\n# git-undo integration\nGIT_UNDO_INTERNAL_HOOK=1 git-undo --hook=\"$hook\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "post-merge"), []byte(legacy), 0o755))
+	standalone := "#!/usr/bin/env bash\n# git-undo hook - auto-generated\nset -e\n" +
+		"GIT_UNDO_INTERNAL_HOOK=1 git-undo --hook=\"post-rewrite\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "post-rewrite"), []byte(standalone), 0o755))
+
+	changed, err := hooks.Install(dir, dispatcher)
+	require.NoError(t, err)
+	assert.NotContains(t, changed, "post-commit", "links to the dispatcher are kept")
+
+	merge, err := os.ReadFile(filepath.Join(dir, "post-merge"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(merge), "git-undo integration")
+	assert.Contains(t, string(merge), "GIT_UNDO_HOOK_NAME=post-merge")
+
+	_, err = hooks.Uninstall(dir, dispatcher)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "post-commit"))
+	assert.NoFileExists(t, filepath.Join(dir, "post-rewrite"))
+	merge, err = os.ReadFile(filepath.Join(dir, "post-merge"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho lint\n", string(merge))
+}
@@ -0,0 +1,207 @@
+// Package gitundo embeds git-undo into other tools (e.g. editor integrations): it lists the git commands
+// logged in a repository by the git-undo hooks, plans and executes their undo, and redoes them,
+// the way `git undo` and `git redo` do, without shelling out to them.
+//
+// Planning never changes anything, so a plan can be shown to the user first:
+//
+//	repo, err := gitundo.Open(dir)
+//	...
+//	plan, err := repo.PlanUndo(ctx, gitundo.PlanOptions{})
+//	...
+//	for _, step := range plan.Steps {
+//		fmt.Println(step.Description, step.Command)
+//	}
+//	err = repo.ExecutePlan(ctx, plan)
+package gitundo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/amberpixels/git-undo/internal/app"
+	"github.com/amberpixels/git-undo/internal/git-undo/logging"
+	"github.com/amberpixels/git-undo/internal/git-undo/undoer"
+)
+
+// version is the version the embedded app reports (e.g. in crash reports).
+const version = "library"
+
+var (
+	// ErrNothingToUndo is returned when the log has no command left to undo.
+	ErrNothingToUndo = app.ErrNothingToUndo
+	// ErrUndoNotSupported is returned when the command can't be undone automatically.
+	ErrUndoNotSupported = undoer.ErrUndoNotSupported
+	// ErrPushed is returned when the undo would rewrite commits already pushed (see PlanOptions.Force).
+	ErrPushed = undoer.ErrPushed
+	// ErrRolledBack is returned when an undo command failed after others were executed:
+	// they were rolled back, so the repository is left as it was.
+	ErrRolledBack = app.ErrUndoRolledBack
+	// ErrStalePlan is returned by ExecutePlan when the repository changed since the plan was made:
+	// the undo would run other commands than the planned ones.
+	ErrStalePlan = errors.New("the repository changed since the undo was planned")
+)
+
+// Repository is a git repository whose commands are logged by git-undo.
+type Repository struct {
+	dir string
+
+	// Messages is where the messages of the undo (info, warnings) are written to. Nothing is written if nil.
+	Messages io.Writer
+}
+
+// Open opens the git repository (or the worktree) in the given directory.
+func Open(dir string) (*Repository, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("cannot open %s: not a directory", dir)
+	}
+	return &Repository{dir: dir}, nil
+}
+
+// Entry is a git command logged by git-undo.
+type Entry struct {
+	// ID identifies the entry (the one `git undo --id` takes).
+	ID string `json:"id"`
+	// Command is the git command as it was typed.
+	Command string `json:"command"`
+	// Ref is the branch (or tag, or commit) the command was run on.
+	Ref string `json:"ref"`
+	// Time is when the command was run.
+	Time time.Time `json:"time"`
+	// Undone tells the command is undone (it can be redone).
+	Undone bool `json:"undone"`
+	// Navigation tells the command is a checkout or a switch (undone by `git back`, not `git undo`).
+	Navigation bool `json:"navigation"`
+}
+
+// newEntry converts the log entry.
+func newEntry(entry *logging.Entry) Entry {
+	return Entry{
+		ID:         entry.ID(),
+		Command:    entry.Command,
+		Ref:        entry.Ref.String(),
+		Time:       entry.Timestamp,
+		Undone:     entry.Undoed,
+		Navigation: entry.IsNavigation,
+	}
+}
+
+// ListHistory returns the latest logged commands (all of them if limit is not positive), newest first.
+func (r *Repository) ListHistory(ctx context.Context, limit int) ([]Entry, error) {
+	entries, err := r.newApp(app.NewAppGitUndo).History(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		history = append(history, newEntry(entry))
+	}
+	return history, nil
+}
+
+// PlanOptions select the command to undo and how. The zero value plans the undo of the latest command.
+type PlanOptions struct {
+	// EntryID selects the command to undo (see Entry.ID) instead of the latest one.
+	EntryID string
+	// MessageOnly makes the undo of `git commit --amend` restore only the previous message.
+	MessageOnly bool
+	// CommitMode is what the undo of a commit does with its changes: "soft" (keeps them staged, the default),
+	// "unstage" or "hard" (discards them).
+	CommitMode string
+	// Force makes the undo rewrite commits already pushed instead of reverting them (or refusing to).
+	Force bool
+}
+
+// Step is a command the undo runs.
+type Step struct {
+	// Command is the command (usually a git one).
+	Command string `json:"command"`
+	// Description tells what the command does.
+	Description string `json:"description"`
+	// Warnings are what the user should know before running it (e.g. what it discards).
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Plan is the undo of a logged command: the commands it runs, in order.
+type Plan struct {
+	// Entry is the command the plan undoes.
+	Entry Entry `json:"entry"`
+	// Steps are the commands the undo runs.
+	Steps []Step `json:"steps"`
+
+	opts PlanOptions
+}
+
+// PlanUndo plans the undo of a logged command without changing anything.
+func (r *Repository) PlanUndo(ctx context.Context, opts PlanOptions) (*Plan, error) {
+	entry, undoCmds, err := r.newApp(app.NewAppGitUndo).PlanUndo(ctx, runOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Entry: newEntry(entry), Steps: make([]Step, 0, len(undoCmds)), opts: opts}
+	plan.opts.EntryID = plan.Entry.ID
+	for _, undoCmd := range undoCmds {
+		plan.Steps = append(plan.Steps, Step{
+			Command:     undoCmd.Command,
+			Description: undoCmd.Description,
+			Warnings:    undoCmd.Warnings,
+		})
+	}
+	return plan, nil
+}
+
+// ExecutePlan executes the undo as planned: when the repository changed since, so that the undo would
+// run other commands, nothing is executed and ErrStalePlan is returned. The plan counts as confirmed:
+// the undo never asks for confirmation. When a command of the undo fails, the ones already executed
+// are rolled back (see ErrRolledBack).
+func (r *Repository) ExecutePlan(ctx context.Context, plan *Plan) error {
+	current, err := r.PlanUndo(ctx, plan.opts)
+	if err != nil {
+		return err
+	}
+	if !slices.EqualFunc(current.Steps, plan.Steps, func(a, b Step) bool { return a.Command == b.Command }) {
+		return ErrStalePlan
+	}
+
+	opts := runOptions(plan.opts)
+	opts.Repo = r.dir
+	a := r.newApp(app.NewAppGitUndo)
+	a.AssumeConfirmed()
+	return a.Run(ctx, opts)
+}
+
+// Redo redoes the latest undone command (like `git redo`).
+func (r *Repository) Redo(ctx context.Context) error {
+	return r.newApp(app.NewAppGitRedo).Run(ctx, app.RunOptions{Repo: r.dir})
+}
+
+// newApp returns the app of the repository, writing its messages to Messages.
+func (r *Repository) newApp(newAppFn func(version, versionSource string) *app.App) *app.App {
+	a := newAppFn(version, version).InDir(r.dir)
+	messages := r.Messages
+	if messages == nil {
+		messages = io.Discard
+	}
+	a.SetMessages(messages)
+	return a
+}
+
+// runOptions converts the plan options into the options of the app.
+func runOptions(opts PlanOptions) app.RunOptions {
+	return app.RunOptions{
+		EntryID:     opts.EntryID,
+		MessageOnly: opts.MessageOnly,
+		CommitMode:  opts.CommitMode,
+		Force:       opts.Force,
+	}
+}
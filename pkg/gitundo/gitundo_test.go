@@ -0,0 +1,78 @@
+package gitundo_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amberpixels/git-undo/internal/app"
+	"github.com/amberpixels/git-undo/pkg/gitundo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository(t *testing.T) {
+	t.Setenv("GIT_UNDO_INTERNAL_HOOK", "1")
+	ctx := context.Background()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	// logged runs the git command and logs it the way the shell hook does
+	logged := func(args ...string) {
+		git(args...)
+		hook := app.NewAppGitUndo("test", "test").InDir(dir)
+		require.NoError(t, hook.Run(ctx, app.RunOptions{HookCommand: "git " + strings.Join(args, " ")}))
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	git("commit", "--allow-empty", "-m", "init")
+
+	repo, err := gitundo.Open(dir)
+	require.NoError(t, err)
+	_, err = repo.PlanUndo(ctx, gitundo.PlanOptions{})
+	require.ErrorIs(t, err, gitundo.ErrNothingToUndo)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0600))
+	logged("add", "a.txt")
+	logged("commit", "-m", "added")
+
+	history, err := repo.ListHistory(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "git commit -m added", history[0].Command)
+	assert.Equal(t, "main", history[1].Ref)
+	assert.False(t, history[0].Undone)
+
+	// Planning changes nothing
+	plan, err := repo.PlanUndo(ctx, gitundo.PlanOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, history[0], plan.Entry)
+	require.Len(t, plan.Steps, 1)
+	assert.Equal(t, "git reset --soft HEAD~1", plan.Steps[0].Command)
+	assert.Equal(t, "added", git("log", "-1", "--format=%s"))
+
+	require.NoError(t, repo.ExecutePlan(ctx, plan))
+	assert.Equal(t, "init", git("log", "-1", "--format=%s"))
+	history, err = repo.ListHistory(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, history[0].Undone)
+
+	require.NoError(t, repo.Redo(ctx))
+	assert.Equal(t, "added", git("log", "-1", "--format=%s"))
+
+	// A plan of an older entry is stale once the repository changed
+	plan, err = repo.PlanUndo(ctx, gitundo.PlanOptions{EntryID: history[0].ID, CommitMode: "hard"})
+	require.NoError(t, err)
+	git("commit", "--allow-empty", "-m", "later")
+	require.ErrorIs(t, repo.ExecutePlan(ctx, plan), gitundo.ErrStalePlan)
+}
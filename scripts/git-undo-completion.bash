@@ -0,0 +1,59 @@
+# Bash completion for `git undo` and `git back` (picked up by git's bash completion).
+# Load it with: source <(git undo completion bash)
+
+_git_undo() {
+  case "$prev" in
+  --id | pin | unpin)
+    __gitcomp_nl "$(command git-undo --complete=ids 2>/dev/null | cut -f1)"
+    return
+    ;;
+  --at)
+    # Identifiers contain spaces: candidates are quoted for the command line
+    local IFS=$'\n' identifier
+    COMPREPLY=()
+    for identifier in $(command git-undo --complete=identifiers 2>/dev/null | cut -f1); do
+      [[ $identifier == "$cur"* ]] && COMPREPLY+=("$(printf '%q' "$identifier")")
+    done
+    return
+    ;;
+  config)
+    __gitcomp "list get set unset"
+    return
+    ;;
+  checkpoint)
+    __gitcomp "save list delete"
+    return
+    ;;
+  restore)
+    __gitcomp_nl "$(command git-undo --complete=checkpoints 2>/dev/null)"
+    return
+    ;;
+  self)
+    __gitcomp "update uninstall info version help"
+    return
+    ;;
+  completion)
+    __gitcomp "bash zsh fish"
+    return
+    ;;
+  esac
+
+  case "$cur" in
+  -*)
+    __gitcomp "--dry-run --verbose --count --since --session --message-only --soft --unstage --hard --force --log --id --at --interactive --json --version --help"
+    ;;
+  *)
+    __gitcomp "undo log history stats status config diff pin unpin checkpoint restore notes trash gc web serve doctor completion self"
+    ;;
+  esac
+}
+
+_git_back() {
+  case "$prev" in
+  --to)
+    __gitcomp_nl "$(command git-back --complete=refs 2>/dev/null | cut -f1)"
+    return
+    ;;
+  esac
+  __gitcomp "--dry-run --verbose --log --to --count --list --forward --version --help"
+}
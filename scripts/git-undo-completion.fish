@@ -0,0 +1,48 @@
+# Fish completion for `git undo` and `git back`.
+# Load it with: git undo completion fish | source
+
+# The subcommands are expanded right away: conditions run when completing, out of this file's scope
+set -l subcommands undo log history stats status config diff pin unpin checkpoint restore notes trash gc web serve doctor completion self
+
+# git undo
+complete -c git -n "__fish_git_using_command undo; and not __fish_seen_subcommand_from $subcommands" \
+    -f -a "$subcommands"
+complete -c git -n '__fish_git_using_command undo' -l dry-run -d 'Show what would be executed without running commands'
+complete -c git -n '__fish_git_using_command undo' -s v -l verbose -d 'Enable verbose output'
+complete -c git -n '__fish_git_using_command undo' -s n -l count -x -d 'Undo the latest N commands at once'
+complete -c git -n '__fish_git_using_command undo' -l log -d 'Display the git-undo command log'
+complete -c git -n '__fish_git_using_command undo' -l id -x -d 'Undo the log entry with the given ID' \
+    -a '(command git-undo --complete=ids 2>/dev/null)'
+complete -c git -n '__fish_git_using_command undo' -l at -x -d 'Undo the log entry with the given identifier' \
+    -a '(command git-undo --complete=identifiers 2>/dev/null)'
+complete -c git -n '__fish_git_using_command undo' -s i -l interactive -d 'Pick the entries to undo'
+complete -c git -n '__fish_git_using_command undo' -l since -x -d 'Undo every command run since a date or duration ago'
+complete -c git -n '__fish_git_using_command undo' -l session -d 'Only consider the commands run from the current shell'
+complete -c git -n '__fish_git_using_command undo' -l message-only -d 'Undo an amend by restoring only the previous message'
+complete -c git -n '__fish_git_using_command undo' -l soft -d 'Undo a commit keeping its changes staged'
+complete -c git -n '__fish_git_using_command undo' -l unstage -d 'Undo a commit leaving its changes unstaged'
+complete -c git -n '__fish_git_using_command undo' -l hard -d 'Undo a commit discarding its changes'
+complete -c git -n '__fish_git_using_command undo' -l force -d 'Rewrite commits that are already pushed'
+complete -c git -n '__fish_git_using_command undo' -l json -d 'Print machine-readable output'
+complete -c git -n '__fish_git_using_command undo' -l version -d 'Print the version'
+complete -c git -n '__fish_git_using_command undo; and __fish_seen_subcommand_from pin unpin' -f \
+    -a '(command git-undo --complete=ids 2>/dev/null)'
+complete -c git -n '__fish_git_using_command undo; and __fish_seen_subcommand_from config' -f -a 'list get set unset'
+complete -c git -n '__fish_git_using_command undo; and __fish_seen_subcommand_from checkpoint' -f \
+    -a 'save list delete'
+complete -c git -n '__fish_git_using_command undo; and __fish_seen_subcommand_from restore' -f \
+    -a '(command git-undo --complete=checkpoints 2>/dev/null)'
+complete -c git -n '__fish_git_using_command undo; and __fish_seen_subcommand_from self' -f \
+    -a 'update uninstall info version help'
+complete -c git -n '__fish_git_using_command undo; and __fish_seen_subcommand_from completion' -f -a 'bash zsh fish'
+
+# git back
+complete -c git -n '__fish_git_using_command back' -l dry-run -d 'Show what would be executed without running commands'
+complete -c git -n '__fish_git_using_command back' -s v -l verbose -d 'Enable verbose output'
+complete -c git -n '__fish_git_using_command back' -l log -d 'Display the git-undo command log'
+complete -c git -n '__fish_git_using_command back' -l to -x -d 'Go back to the given ref from the navigation history' \
+    -a '(command git-back --complete=refs 2>/dev/null)'
+complete -c git -n '__fish_git_using_command back' -s n -l count -x -d 'Go back N steps of the navigation history'
+complete -c git -n '__fish_git_using_command back' -l list -d 'List the navigation history'
+complete -c git -n '__fish_git_using_command back' -l forward -d 'Go forward again to where git back went back from'
+complete -c git -n '__fish_git_using_command back' -l version -d 'Print the version'
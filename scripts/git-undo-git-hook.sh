@@ -3,7 +3,8 @@
 # This script is called by git hooks to notify git-undo of git operations.
 set -euo pipefail
 
-hook_name=$(basename "$0")   # post-commit / post-merge
+# Shims chained into existing hooks tell the hook name, links to this script are named after it.
+hook_name=${GIT_UNDO_HOOK_NAME:-$(basename "$0")}
 cmd=""
 
 # Check if git-undo is available
@@ -15,12 +16,17 @@ fi
 case "$hook_name" in
     post-commit)
         # Reconstruct something close to what the user typed.
-        # We can’t know flags, so keep it minimal.
+        # We can’t know flags, so keep it minimal: one -m per message paragraph
+        # (lines of a paragraph joined), the way git-undo normalizes commit messages.
         # Escape quotes in commit message for safe shell execution
 
-        commit_msg=$(git log -1 --pretty=format:'%s' 2>/dev/null || echo "commit")
-        commit_msg=$(printf '%s\n' "$commit_msg" | sed 's/"/\\"/g')
-        cmd="git commit -m \"$commit_msg\""
+        cmd="git commit"
+        while IFS= read -r paragraph; do
+            cmd+=" -m \"${paragraph//\"/\\\"}\""
+        done < <(git log -1 --pretty=format:'%B' 2>/dev/null | awk 'BEGIN { RS = "" } { gsub(/[ \t\n]+/, " "); print }')
+        if [[ "$cmd" == "git commit" ]]; then
+            cmd="git commit -m \"commit\""
+        fi
         ;;
     post-merge)
         # $1 contains the squash-merge flag (1 for squash, 0 for regular merge)
@@ -39,7 +45,7 @@ case "$hook_name" in
         fi
         ;;
     *)
-        exit 0        # unknown hook → ignore
+        exit 0        # nothing to report yet (post-checkout, post-rewrite: the shell hooks log them) → ignore
         ;;
 esac
 
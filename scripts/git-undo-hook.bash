@@ -1,3 +1,7 @@
+# ID of this shell session, recorded with the logged commands: `git undo --session` only considers its own.
+# It's kept when the hook is sourced again, renewed in nested shells (their PID differs).
+[[ "${GIT_UNDO_SESSION%%-*}" == "$$" ]] || export GIT_UNDO_SESSION="$$-$(date +%s)"
+
 # Variable to store the git command temporarily
 GIT_COMMAND_TO_LOG=""
 
@@ -20,13 +24,22 @@ store_git_command() {
   # Only store if it's a git command
   [[ "$raw_cmd" == git\ * ]] || return
   GIT_COMMAND_TO_LOG="$raw_cmd"
+
+  # Some commands need the state they change recorded beforehand (e.g. a config value or the files git clean removes)
+  case "$raw_cmd" in
+  git\ add\ * | git\ branch\ * | git\ config\ * | git\ remote\ * | git\ stash\ * | git\ tag\ * | git\ clean\ * | git\ reset\ * | git\ checkout\ *)
+    GIT_UNDO_INTERNAL_HOOK=1 command git-undo --pre-hook="$raw_cmd" --hook-shell=bash
+    ;;
+  esac
 }
 
-# Function to log the command only if it was successful
-log_successful_git_command() {
-  # Check if we have a git command to log and if the previous command was successful
-  if [[ -n "$GIT_COMMAND_TO_LOG" && $? -eq 0 ]]; then
-    GIT_UNDO_INTERNAL_HOOK=1 command git-undo --hook="$GIT_COMMAND_TO_LOG"
+# Function to log the command once it ran: failed commands are only logged (as such) if git-undo.logfailed is set
+log_git_command() {
+  local exit_code=$?
+  if [[ -n "$GIT_COMMAND_TO_LOG" && $exit_code -eq 0 ]]; then
+    GIT_UNDO_INTERNAL_HOOK=1 command git-undo --hook="$GIT_COMMAND_TO_LOG" --hook-shell=bash
+  elif [[ -n "$GIT_COMMAND_TO_LOG" ]]; then
+    GIT_UNDO_INTERNAL_HOOK=1 command git-undo --hook="$GIT_COMMAND_TO_LOG" --hook-shell=bash --hook-failed
   fi
   # Clear the stored command
   GIT_COMMAND_TO_LOG=""
@@ -37,7 +50,7 @@ trap 'store_git_command "$BASH_COMMAND"' DEBUG
 
 # Set up PROMPT_COMMAND to log successful commands after execution
 if [[ -z "$PROMPT_COMMAND" ]]; then
-  PROMPT_COMMAND="log_successful_git_command"
+  PROMPT_COMMAND="log_git_command"
 else
-  PROMPT_COMMAND="$PROMPT_COMMAND; log_successful_git_command"
-fi
\ No newline at end of file
+  PROMPT_COMMAND="$PROMPT_COMMAND; log_git_command"
+fi
@@ -0,0 +1,29 @@
+# git-undo hook for fish: logs the git commands typed in the shell.
+# Installed by `git undo self install-hooks --shell fish`.
+
+# ID of this shell session, recorded with the logged commands: `git undo --session` only considers its own.
+# It's kept when the hook is sourced again, renewed in nested shells (their PID differs).
+string match -q -- "$fish_pid-*" "$GIT_UNDO_SESSION"; or set -gx GIT_UNDO_SESSION "$fish_pid-"(date +%s)
+
+function __git_undo_store_command --on-event fish_preexec
+    set -l raw_cmd $argv[1]
+    string match -qr '^git\s' -- $raw_cmd; or return
+    set -g __git_undo_command $raw_cmd
+
+    # Some commands need the state they change recorded beforehand (e.g. a config value or the files git clean removes)
+    switch $raw_cmd
+        case 'git add *' 'git branch *' 'git config *' 'git remote *' 'git stash *' 'git tag *' 'git clean *' 'git reset *' 'git checkout *'
+            GIT_UNDO_INTERNAL_HOOK=1 command git-undo --pre-hook="$raw_cmd" --hook-shell=fish
+    end
+end
+
+# Log the command once it ran: failed commands are only logged (as such) if git-undo.logfailed is set
+function __git_undo_log_command --on-event fish_postexec
+    set -l last_status $status
+    if set -q __git_undo_command; and test $last_status -eq 0
+        GIT_UNDO_INTERNAL_HOOK=1 command git-undo --hook="$__git_undo_command" --hook-shell=fish
+    else if set -q __git_undo_command
+        GIT_UNDO_INTERNAL_HOOK=1 command git-undo --hook="$__git_undo_command" --hook-shell=fish --hook-failed
+    end
+    set -e __git_undo_command
+end
@@ -0,0 +1,53 @@
+# git-undo hook for PowerShell: logs the git commands typed in the shell.
+# Installed by `git undo self install-hooks --shell powershell`.
+
+# ID of this shell session, recorded with the logged commands: `git undo --session` only considers its own.
+# It's kept when the hook is loaded again, renewed in nested shells (their PID differs).
+if (-not ("$env:GIT_UNDO_SESSION" -like "$PID-*")) {
+    $env:GIT_UNDO_SESSION = "$PID-$([DateTimeOffset]::UtcNow.ToUnixTimeSeconds())"
+}
+
+# Runs git-undo with the marker telling it's called by a hook (not by the user)
+function global:__GitUndoInvoke([string]$Flag, [string]$Command, [string[]]$Extra = @()) {
+    $env:GIT_UNDO_INTERNAL_HOOK = '1'
+    try {
+        & git-undo "$Flag=$Command" --hook-shell=powershell @Extra
+    } finally {
+        Remove-Item Env:GIT_UNDO_INTERNAL_HOOK -ErrorAction SilentlyContinue
+    }
+}
+
+# Some commands need the state they change recorded beforehand (e.g. a config value or the files git clean removes):
+# PSReadLine lets the typed line be seen before it runs
+if (Get-Module PSReadLine) {
+    Set-PSReadLineKeyHandler -Key Enter -BriefDescription GitUndoAcceptLine -ScriptBlock {
+        $line = $null
+        $cursor = $null
+        [Microsoft.PowerShell.PSConsoleReadLine]::GetBufferState([ref]$line, [ref]$cursor)
+        if ($line -match '^git (add|branch|config|remote|stash|tag|clean|reset|checkout)\s') {
+            __GitUndoInvoke '--pre-hook' $line
+        }
+        [Microsoft.PowerShell.PSConsoleReadLine]::AcceptLine()
+    }
+}
+
+# The prompt runs after each command: the command is logged if it was a git command
+# (failed ones are only logged, as such, if git-undo.logfailed is set)
+$global:__GitUndoLastHistoryId = (Get-History -Count 1).Id
+$global:__GitUndoPrompt = $function:prompt
+function global:prompt {
+    $exitCode = $global:LASTEXITCODE
+    $succeeded = $? -and $exitCode -eq 0
+    $last = Get-History -Count 1
+    if ($last -and $last.Id -ne $global:__GitUndoLastHistoryId) {
+        $global:__GitUndoLastHistoryId = $last.Id
+        if ($succeeded -and $last.CommandLine -match '^git\s') {
+            __GitUndoInvoke '--hook' $last.CommandLine
+        } elseif ($last.CommandLine -match '^git\s') {
+            __GitUndoInvoke '--hook' $last.CommandLine @('--hook-failed')
+        }
+    }
+    # The user's exit code is theirs, not git-undo's
+    $global:LASTEXITCODE = $exitCode
+    & $global:__GitUndoPrompt
+}
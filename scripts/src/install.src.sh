@@ -233,7 +233,7 @@ main() {
                      # Get the version that was just installed
                      INSTALLED_VERSION=$(git-undo --version 2>/dev/null  || echo "unknown")
                      echo -e "${GRAY}git-undo:${NC} Binaries installed with version: ${BLUE}$INSTALLED_VERSION${NC}"
-                     log "Installed: git-undo and git-back"
+                     log "Installed: git-undo, git-back and git-redo"
                  else
                      verbose_log "make binary-install failed"
                      echo -e "${GRAY}git-undo:${NC} ${RED}Failed to build from source using Makefile${NC}"
@@ -246,7 +246,7 @@ main() {
                      # Get the version that was just installed
                      INSTALLED_VERSION=$(git-undo --version 2>/dev/null  || echo "unknown")
                      echo -e "${GRAY}git-undo:${NC} Binaries installed with version: ${BLUE}$INSTALLED_VERSION${NC}"
-                     log "Installed: git-undo and git-back"
+                     log "Installed: git-undo, git-back and git-redo"
                  else
                      verbose_log "make binary-install failed"
                      echo -e "${GRAY}git-undo:${NC} ${RED}Failed to build from source using Makefile${NC}"
@@ -302,6 +302,14 @@ main() {
                  fi
              fi
              
+             verbose_log "Installing git-redo from $GITHUB_REPO_URL/cmd/$REDO_BIN_NAME@latest"
+             # Install git-redo (optional: `git undo undo` works without it)
+             if ! go install "$GITHUB_REPO_URL/cmd/$REDO_BIN_NAME@latest" 2>/dev/null; then
+                 verbose_log "git-redo installation failed - continuing without it"
+             else
+                 verbose_log "git-redo installation succeeded"
+             fi
+
              # Success message based on what was installed
              UNDO_BIN_PATH=$(command -v git-undo || echo "$BIN_DIR/$UNDO_BIN_NAME")
              INSTALLED_VERSION=$(git-undo --version 2>/dev/null  || echo "unknown")
@@ -323,37 +331,22 @@ main() {
         verbose_log "Skipping binary installation due to Go issues"
     fi
 
-    # 2) Git hooks integration
+    # 2) Git hooks integration: the binary installs them (see `git-undo self install-hooks`),
+    # chaining them into the hooks of a global core.hooksPath or setting it to $GIT_HOOKS_DIR.
     echo -en "${GRAY}git-undo:${NC} 2. Git integration..."
     verbose_log "Starting git hooks integration"
 
-    current_hooks_path=$(git config --global --get core.hooksPath || echo "")
-    target_hooks_path="$GIT_HOOKS_DIR"
-    
-    verbose_log "Current global hooks path: '${current_hooks_path}'"
-    verbose_log "Target hooks path: '${target_hooks_path}'"
-
-    if [[ -z "$current_hooks_path" ]]; then
-        verbose_log "No global hooks path set, configuring our hooks path"
-        if git config --global core.hooksPath "$target_hooks_path"; then
-            verbose_log "Successfully set global core.hooksPath to $target_hooks_path"
-        else
-            verbose_log "Failed to set global core.hooksPath"
-        fi
-        
-        verbose_log "Installing dispatcher into $target_hooks_path"
-        install_dispatcher_into "$target_hooks_path"
-        echo -e " ${GREEN}OK${NC} (set core.hooksPath)"
-    elif [[ "$current_hooks_path" == "$target_hooks_path" ]]; then
-        verbose_log "Hooks path already configured correctly"
-        verbose_log "Installing dispatcher into $target_hooks_path"
-        install_dispatcher_into "$target_hooks_path"
-        echo -e " ${YELLOW}SKIP${NC} (already configured)"
+    local hooks_output
+    if ! command -v git-undo >/dev/null 2>&1; then
+        verbose_log "git-undo binary not found, can't install git hooks"
+        echo -e " ${YELLOW}SKIP${NC} (git-undo binary not found)"
+    # Outside of any repository, so that only the global hooks get installed
+    elif hooks_output=$(cd / && git-undo self install-hooks --shell git 2>&1); then
+        verbose_log "$hooks_output"
+        echo -e " ${GREEN}OK${NC}"
     else
-        verbose_log "Different hooks path already configured, piggybacking on it"
-        verbose_log "Installing dispatcher into existing path: $current_hooks_path"
-        install_dispatcher_into "$current_hooks_path"
-        echo -e " ${YELLOW}SHARED${NC} (pig-backed on $current_hooks_path)"
+        echo -e " ${RED}FAILED${NC}"
+        log_error "$hooks_output"
     fi
 
     # 3) Shell integration
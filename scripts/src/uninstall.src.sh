@@ -13,7 +13,8 @@ scrub_rc() {
     [[ -f "$real_rc" ]] || return 1
 
     # Check if hook line exists before attempting to remove it
-    if ! grep -q "source .*git-undo-hook" "$real_rc" 2>/dev/null; then
+    # (`source ~/.config/git-undo/git-undo-hook.<shell>`, or `. "…/git-undo-hook.ps1"` in PowerShell profiles)
+    if ! grep -q "git-undo/git-undo-hook\." "$real_rc" 2>/dev/null; then
         return 1  # No hook line found, nothing to do
     fi
 
@@ -22,9 +23,9 @@ scrub_rc() {
 
     # cross-platform sed in-place
     if sed --version &>/dev/null; then                    # GNU
-        sed -i "/source .*git-undo-hook/d" "$real_rc"
+        sed -i "/git-undo\/git-undo-hook\./d" "$real_rc"
     else                                                  # BSD / macOS
-        sed -i '' "/source .*git-undo-hook/d" "$real_rc"
+        sed -i '' "/git-undo\/git-undo-hook\./d" "$real_rc"
     fi
     return 0  # Successfully cleaned
 }
@@ -32,6 +33,12 @@ scrub_rc() {
 main() {
     log "Starting uninstallation..."
 
+    # The binary removes the git hooks it installed (keeping the hooks they were chained to),
+    # before it's gone: step 4 cleans whatever is left
+    if command -v git-undo >/dev/null 2>&1; then
+        (cd / && git-undo self uninstall-hooks >/dev/null 2>&1) || true
+    fi
+
     # 1) Remove binaries
     echo -en "${GRAY}git-undo:${NC} 1. Removing binaries..."
     local removed_count=0
@@ -45,6 +52,11 @@ main() {
         rm -f "$BACK_BIN_PATH"
         ((removed_count++))
     fi
+
+    if [[ -f "$REDO_BIN_PATH" ]]; then
+        rm -f "$REDO_BIN_PATH"
+        ((removed_count++))
+    fi
     
     if [ $removed_count -gt 0 ]; then
         echo -e " ${GREEN}OK${NC} ($removed_count binaries removed)"
@@ -66,6 +78,12 @@ main() {
     if scrub_rc "$HOME/.bash_profile"; then
         ((cleaned_files++))
     fi
+    if scrub_rc "$HOME/.config/fish/config.fish"; then
+        ((cleaned_files++))
+    fi
+    if scrub_rc "$HOME/.config/powershell/Microsoft.PowerShell_profile.ps1"; then
+        ((cleaned_files++))
+    fi
 
     if [ $cleaned_files -gt 0 ]; then
         echo -e " ${GREEN}OK${NC} ($cleaned_files files)"
@@ -88,11 +106,9 @@ main() {
         git config --global --unset core.hooksPath
     fi
 
-    for h in post-commit post-merge; do
-        for dir in "$GIT_HOOKS_DIR" "$(git config --global --get core.hooksPath 2>/dev/null || true)"; do
-            [[ -z "$dir" ]] && continue
-            rm -f "$dir/$h"
-        done
+    # Hooks of other dirs are someone else's: the binary unchained its shims from them
+    for h in post-commit post-checkout post-merge post-rewrite; do
+        rm -f "$GIT_HOOKS_DIR/$h"
     done
     rm -f "$DISPATCHER_FILE"
     echo -e " ${GREEN}OK${NC}"
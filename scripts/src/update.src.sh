@@ -75,25 +75,12 @@ main() {
     local temp_installer
     temp_installer=$(mktemp)
 
-    if command -v curl >/dev/null 2>&1; then
-        if curl -sL "$INSTALL_URL" -o "$temp_installer"; then
-            echo -e " ${GREEN}OK${NC}"
-        else
-            echo -e " ${RED}FAILED${NC}"
-            rm -f "$temp_installer"
-            exit 1
-        fi
-    elif command -v wget >/dev/null 2>&1; then
-        if wget -qO "$temp_installer" "$INSTALL_URL"; then
-            echo -e " ${GREEN}OK${NC}"
-        else
-            echo -e " ${RED}FAILED${NC}"
-            rm -f "$temp_installer"
-            exit 1
-        fi
+    if http_get "$INSTALL_URL" "$temp_installer"; then
+        echo -e " ${GREEN}OK${NC}"
     else
         echo -e " ${RED}FAILED${NC}"
-        log "curl or wget required for update"
+        log "Failed to download $INSTALL_URL"
+        rm -f "$temp_installer"
         exit 1
     fi
 
@@ -78,10 +78,12 @@ echo "SCRIPT DIR $SCRIPT_DIR"
 # Git-undo specific configuration
 UNDO_BIN_NAME="git-undo"
 BACK_BIN_NAME="git-back"
+REDO_BIN_NAME="git-redo"
 BIN_DIR=$(go env GOBIN 2>/dev/null || true)
 [[ -z "$BIN_DIR" ]] && BIN_DIR="$(go env GOPATH)/bin"
 export UNDO_BIN_PATH="$BIN_DIR/$UNDO_BIN_NAME"
 export BACK_BIN_PATH="$BIN_DIR/$BACK_BIN_NAME"
+export REDO_BIN_PATH="$BIN_DIR/$REDO_BIN_NAME"
 
 # Legacy variable for backward compatibility
 export BIN_PATH="$UNDO_BIN_PATH"
@@ -91,13 +93,58 @@ export BASH_HOOK="$CFG_DIR/git-undo-hook.bash"
 export ZSH_HOOK="$CFG_DIR/git-undo-hook.zsh"
 GIT_HOOKS_DIR="$CFG_DIR/hooks"
 DISPATCHER_FILE="$GIT_HOOKS_DIR/git-hooks.sh"
-DISPATCHER_SRC="$SCRIPT_DIR/scripts/git-undo-git-hook.sh"
 
 REPO_OWNER="amberpixels"
 REPO_NAME="git-undo"
-export GITHUB_REPO_URL="github.com/$REPO_OWNER/$REPO_NAME"
-GITHUB_API_URL="https://api.github.com/repos/$REPO_OWNER/$REPO_NAME"
-export INSTALL_URL="https://raw.githubusercontent.com/$REPO_OWNER/$REPO_NAME/main/install.sh"
+
+# _setting prints the environment variable $1 if set, otherwise git config git-undo.$2, otherwise $3.
+# Trailing slashes are dropped, so both "https://host/x" and "https://host/x/" work.
+_setting() {
+    local value="${!1:-}"
+    if [[ -z "$value" ]]; then
+        value=$(git config --get "git-undo.$2" 2>/dev/null || true)
+    fi
+    value="${value:-$3}"
+    printf '%s' "${value%/}"
+}
+
+# Release endpoints can point to a fork or a GitHub Enterprise mirror instead of github.com:
+#   GIT_UNDO_MODULE / git-undo.module             Go module installed by `go install`
+#   GIT_UNDO_RELEASES_API / git-undo.releasesapi  repository API URL (https://<host>/api/v3/repos/<owner>/<repo>)
+#   GIT_UNDO_DOWNLOAD_URL / git-undo.downloadurl  base URL of raw files (https://<host>/raw/<owner>/<repo>/main)
+#   GIT_UNDO_GITHUB_TOKEN                         token sent to both endpoints (for private mirrors)
+export GITHUB_REPO_URL
+GITHUB_REPO_URL="$(_setting GIT_UNDO_MODULE module "github.com/$REPO_OWNER/$REPO_NAME")"
+export GITHUB_API_URL
+GITHUB_API_URL="$(_setting GIT_UNDO_RELEASES_API releasesapi "https://api.github.com/repos/$REPO_OWNER/$REPO_NAME")"
+export DOWNLOAD_URL
+DOWNLOAD_URL="$(_setting GIT_UNDO_DOWNLOAD_URL downloadurl "https://raw.githubusercontent.com/$REPO_OWNER/$REPO_NAME/main")"
+export INSTALL_URL="$DOWNLOAD_URL/install.sh"
+unset -f _setting
+
+# http_get downloads the URL to the file $2 (or stdout), authenticating with GIT_UNDO_GITHUB_TOKEN if set.
+http_get() {
+    local url="$1"
+    local output="${2:--}"
+    local token="${GIT_UNDO_GITHUB_TOKEN:-}"
+
+    if command -v curl >/dev/null 2>&1; then
+        if [[ -n "$token" ]]; then
+            curl -fsSL -H "Authorization: token $token" -o "$output" "$url"
+        else
+            curl -fsSL -o "$output" "$url"
+        fi
+    elif command -v wget >/dev/null 2>&1; then
+        if [[ -n "$token" ]]; then
+            wget -qO "$output" --header="Authorization: token $token" "$url"
+        else
+            wget -qO "$output" "$url"
+        fi
+    else
+        echo "error: curl or wget required" >&2
+        return 1
+    fi
+}
 
 detect_shell() {
     # Method 1: Check $SHELL environment variable (most reliable for login shell)
@@ -129,14 +176,7 @@ detect_shell() {
 
 get_latest_version() {
     local latest_release
-    if command -v curl >/dev/null 2>&1; then
-        latest_release=$(curl -s "$GITHUB_API_URL/releases/latest" | grep '"tag_name":' | sed -E 's/.*"([^"]+)".*/\1/')
-    elif command -v wget >/dev/null 2>&1; then
-        latest_release=$(wget -qO- "$GITHUB_API_URL/releases/latest" | grep '"tag_name":' | sed -E 's/.*"([^"]+)".*/\1/')
-    else
-        echo "error: curl or wget required for version check" >&2
-        return 1
-    fi
+    latest_release=$(http_get "$GITHUB_API_URL/releases/latest" | grep '"tag_name":' | sed -E 's/.*"([^"]+)".*/\1/')
 
     if [[ -z "$latest_release" || "$latest_release" == "null" ]]; then
         echo "error: failed to fetch latest version" >&2
@@ -188,135 +228,6 @@ version_compare() {
     fi
 }
 
-install_dispatcher_into() {
-    local target="$1"
-
-    # Validate target directory
-    if [[ -z "$target" ]]; then
-        log_error "Target directory not specified"
-        return 1
-    fi
-
-    log "Installing git hooks into: $target"
-
-    # Create target directory if it doesn't exist
-    if ! mkdir -p "$target" 2>/dev/null; then
-        log_error "Failed to create hooks directory: $target"
-        return 1
-    fi
-
-    # 1) Install the dispatcher script only if target is our managed hooks directory
-    if [[ "$target" == "$GIT_HOOKS_DIR" ]]; then
-        log "Installing dispatcher script to: $DISPATCHER_FILE"
-
-        # Debug: Check if source file exists
-        if [[ ! -f "$DISPATCHER_SRC" ]]; then
-            log_error "Source dispatcher script not found: $DISPATCHER_SRC"
-            log_error "DISPATCHER_SRC variable: '$DISPATCHER_SRC'"
-            log_error "Contents of script directory:"
-            ls -la "$(dirname "$DISPATCHER_SRC")" 2>/dev/null || log_error "Cannot list script directory"
-            return 1
-        fi
-
-        log "Source file exists: $DISPATCHER_SRC"
-        log "Source file permissions: $(ls -l "$DISPATCHER_SRC")"
-
-        # Ensure the hooks directory exists
-        if ! mkdir -p "$GIT_HOOKS_DIR" 2>/dev/null; then
-            log_error "Failed to create git hooks directory: $GIT_HOOKS_DIR"
-            return 1
-        fi
-
-        log "Target directory exists: $GIT_HOOKS_DIR"
-        log "Target directory permissions: $(ls -ld "$GIT_HOOKS_DIR")"
-
-        # Try using cp instead of install command for better compatibility
-        if cp "$DISPATCHER_SRC" "$DISPATCHER_FILE" 2>/dev/null; then
-            chmod 755 "$DISPATCHER_FILE" 2>/dev/null || {
-                log_error "Failed to set permissions on dispatcher script"
-                return 1
-            }
-            log "Dispatcher copied and permissions set successfully"
-        else
-            log_error "Failed to copy dispatcher script from $DISPATCHER_SRC to $DISPATCHER_FILE"
-            log_error "Let's try to understand why:"
-
-            # More detailed debugging
-            log_error "Source file readable? $(test -r "$DISPATCHER_SRC" && echo "YES" || echo "NO")"
-            log_error "Target directory writable? $(test -w "$GIT_HOOKS_DIR" && echo "YES" || echo "NO")"
-            log_error "Disk space available? $(df -h "$GIT_HOOKS_DIR" | tail -1)"
-
-            return 1
-        fi
-    fi
-
-    # 2) Wire up post-commit & post-merge hooks
-    for hook in post-commit post-merge; do
-        local hook_file="$target/$hook"
-        log "Processing hook: $hook_file"
-
-        if [[ -f "$hook_file" && ! -L "$hook_file" ]]; then
-            # Existing regular file - append our hook call if not already present
-            log "Found existing hook file, checking if git-undo is already integrated"
-
-            if ! grep -q 'git-undo --hook' "$hook_file" 2>/dev/null; then
-                log "Adding git-undo integration to existing hook"
-                {
-                    echo ""
-                    echo "# git-undo integration"
-                    echo "GIT_UNDO_INTERNAL_HOOK=1 git-undo --hook=\"$hook\""
-                } >> "$hook_file"
-
-                # Ensure it's executable
-                chmod +x "$hook_file" 2>/dev/null || {
-                    log_error "Failed to make hook executable: $hook_file"
-                    return 1
-                }
-                log "Successfully integrated with existing $hook hook"
-            else
-                log "git-undo already integrated in $hook hook"
-            fi
-
-        elif [[ -L "$hook_file" ]]; then
-            # It's a symlink - check if it points to our dispatcher
-            local link_target
-            link_target=$(readlink "$hook_file" 2>/dev/null || echo "")
-
-            if [[ "$link_target" != "$DISPATCHER_FILE" ]]; then
-                log_warning "Hook $hook_file is a symlink to $link_target, not our dispatcher"
-                log "This hook may not work with git-undo"
-            else
-                log "Hook $hook_file already points to our dispatcher"
-            fi
-
-        else
-            # No hook exists yet - create one
-            log "Creating new $hook hook"
-
-            # Try to create a symlink first (preferred method)
-            if ln -sf "$DISPATCHER_FILE" "$hook_file" 2>/dev/null; then
-                log "Created symlink: $hook_file -> $DISPATCHER_FILE"
-            else
-                # Fallback for filesystems that don't support symlinks
-                log "Symlink failed, creating standalone hook script"
-                cat > "$hook_file" << EOF
-# git-undo hook - auto-generated
-set -e
-GIT_UNDO_INTERNAL_HOOK=1 git-undo --hook="$hook"
-EOF
-                chmod +x "$hook_file" 2>/dev/null || {
-                    log_error "Failed to make hook executable: $hook_file"
-                    return 1
-                }
-                log "Created standalone hook: $hook_file"
-            fi
-        fi
-    done
-
-    log "Hook installation completed for: $target"
-    return 0
-}
-
 # ── End of inlined content ──────────────────────────────────────────────────
 
 scrub_rc() {
@@ -328,7 +239,8 @@ scrub_rc() {
     [[ -f "$real_rc" ]] || return 1
 
     # Check if hook line exists before attempting to remove it
-    if ! grep -q "source .*git-undo-hook" "$real_rc" 2>/dev/null; then
+    # (`source ~/.config/git-undo/git-undo-hook.<shell>`, or `. "…/git-undo-hook.ps1"` in PowerShell profiles)
+    if ! grep -q "git-undo/git-undo-hook\." "$real_rc" 2>/dev/null; then
         return 1  # No hook line found, nothing to do
     fi
 
@@ -337,9 +249,9 @@ scrub_rc() {
 
     # cross-platform sed in-place
     if sed --version &>/dev/null; then                    # GNU
-        sed -i "/source .*git-undo-hook/d" "$real_rc"
+        sed -i "/git-undo\/git-undo-hook\./d" "$real_rc"
     else                                                  # BSD / macOS
-        sed -i '' "/source .*git-undo-hook/d" "$real_rc"
+        sed -i '' "/git-undo\/git-undo-hook\./d" "$real_rc"
     fi
     return 0  # Successfully cleaned
 }
@@ -347,6 +259,12 @@ scrub_rc() {
 main() {
     log "Starting uninstallation..."
 
+    # The binary removes the git hooks it installed (keeping the hooks they were chained to),
+    # before it's gone: step 4 cleans whatever is left
+    if command -v git-undo >/dev/null 2>&1; then
+        (cd / && git-undo self uninstall-hooks >/dev/null 2>&1) || true
+    fi
+
     # 1) Remove binaries
     echo -en "${GRAY}git-undo:${NC} 1. Removing binaries..."
     local removed_count=0
@@ -360,6 +278,11 @@ main() {
         rm -f "$BACK_BIN_PATH"
         ((removed_count++))
     fi
+
+    if [[ -f "$REDO_BIN_PATH" ]]; then
+        rm -f "$REDO_BIN_PATH"
+        ((removed_count++))
+    fi
     
     if [ $removed_count -gt 0 ]; then
         echo -e " ${GREEN}OK${NC} ($removed_count binaries removed)"
@@ -381,6 +304,12 @@ main() {
     if scrub_rc "$HOME/.bash_profile"; then
         ((cleaned_files++))
     fi
+    if scrub_rc "$HOME/.config/fish/config.fish"; then
+        ((cleaned_files++))
+    fi
+    if scrub_rc "$HOME/.config/powershell/Microsoft.PowerShell_profile.ps1"; then
+        ((cleaned_files++))
+    fi
 
     if [ $cleaned_files -gt 0 ]; then
         echo -e " ${GREEN}OK${NC} ($cleaned_files files)"
@@ -403,11 +332,9 @@ main() {
         git config --global --unset core.hooksPath
     fi
 
-    for h in post-commit post-merge; do
-        for dir in "$GIT_HOOKS_DIR" "$(git config --global --get core.hooksPath 2>/dev/null || true)"; do
-            [[ -z "$dir" ]] && continue
-            rm -f "$dir/$h"
-        done
+    # Hooks of other dirs are someone else's: the binary unchained its shims from them
+    for h in post-commit post-checkout post-merge post-rewrite; do
+        rm -f "$GIT_HOOKS_DIR/$h"
     done
     rm -f "$DISPATCHER_FILE"
     echo -e " ${GREEN}OK${NC}"